@@ -0,0 +1,51 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Command azure-webhook serves the webhook and the installer as an Azure
+// Functions custom handler. The Functions host forwards HTTP requests to it
+// on FUNCTIONS_CUSTOMHANDLER_PORT (see distros/azure-functions).
+// Configuration can reference Key Vault secrets with azkv:// references, and
+// the installer saves credentials to the Key Vault at AZURE_KEY_VAULT_URI. It
+// is otherwise "octo-sts serve webhook".
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+	"github.com/cruxstack/octo-sts-distros/internal/server"
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+)
+
+// envCustomHandlerPort is set by the Functions host to the port it forwards
+// requests to.
+const envCustomHandlerPort = "FUNCTIONS_CUSTOMHANDLER_PORT"
+
+func main() {
+	shared.SetupEnvMapping()
+
+	// The Functions host stops the worker with SIGTERM
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	ctx = clog.WithLogger(ctx, clog.New(shared.NewSlogHandler()))
+
+	// Save installer credentials to the function app's Key Vault by default
+	if os.Getenv(configstore.EnvStorageMode) == "" && os.Getenv(configstore.EnvAzureKeyVaultURI) != "" {
+		os.Setenv(configstore.EnvStorageMode, configstore.StorageModeAzureKeyVault)
+	}
+
+	opts := server.OptionsFromEnv(server.ServiceWebhook)
+	if port, err := strconv.Atoi(os.Getenv(envCustomHandlerPort)); err == nil && port > 0 {
+		opts.Port = port
+	}
+	if err := server.Run(ctx, opts); err != nil {
+		clog.FromContext(ctx).Errorf("%v", err)
+		os.Exit(1)
+	}
+}
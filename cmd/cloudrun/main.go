@@ -0,0 +1,53 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Command cloudrun serves the STS, the webhook, and the installer as one
+// Google Cloud Run service. Like http-all, the STS is mounted at /sts, the
+// webhook at /webhook, and the installer, when enabled, at /setup. It
+// listens on PORT, resolves gcpsm:// references as the service account of
+// the service, writes Cloud Logging structured logs unless LOG_FORMAT is
+// set, and shuts down within the grace period Cloud Run allows after
+// SIGTERM.
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/cruxstack/octo-sts-distros/internal/server"
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+)
+
+const (
+	// envService is set by Cloud Run to the name of the service.
+	envService = "K_SERVICE"
+
+	// shutdownTimeout fits within the 10 seconds Cloud Run waits after
+	// SIGTERM before killing the container.
+	shutdownTimeout = 8 * time.Second
+)
+
+func main() {
+	shared.SetupEnvMapping()
+
+	// Write logs Cloud Logging parses for severity by default
+	if os.Getenv(shared.EnvLogFormat) == "" && os.Getenv(envService) != "" {
+		os.Setenv(shared.EnvLogFormat, shared.LogFormatGCP)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	ctx = clog.WithLogger(ctx, clog.New(shared.NewSlogHandler()))
+
+	opts := server.OptionsFromEnv(server.ServiceAll)
+	opts.ShutdownTimeout = shutdownTimeout
+	if err := server.Run(ctx, opts); err != nil {
+		clog.FromContext(ctx).Errorf("%v", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Command http-all serves the STS, the webhook, and the installer on one
+// port, for small deployments that don't want a process per service. The
+// STS is mounted at /sts, the webhook at /webhook, and the installer, when
+// enabled, at /setup. Both services share one GitHub App transport and are
+// reloaded together. It is equivalent to "octo-sts serve all".
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/cruxstack/octo-sts-distros/internal/server"
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+)
+
+func main() {
+	shared.SetupEnvMapping()
+
+	// systemd and container runtimes stop services with SIGTERM
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	ctx = clog.WithLogger(ctx, clog.New(shared.NewSlogHandler()))
+
+	if err := server.Run(ctx, server.OptionsFromEnv(server.ServiceAll)); err != nil {
+		clog.FromContext(ctx).Errorf("%v", err)
+		os.Exit(1)
+	}
+}
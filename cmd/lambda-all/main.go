@@ -0,0 +1,452 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Command lambda-all serves the STS, the webhook, and the installer from one
+// Lambda function, for small installations that don't want a function per
+// service. As with http-all, the STS is routed at /sts, the webhook at
+// /webhook, and the installer, when enabled, at /setup. Both services share
+// one GitHub App transport and are loaded together, so a cold start reads
+// the configuration once.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
+	"github.com/chainguard-dev/clog"
+
+	envConfig "github.com/octo-sts/app/pkg/envconfig"
+	"github.com/octo-sts/app/pkg/ghtransport"
+
+	"github.com/cruxstack/github-app-setup-go/ghappsetup"
+	"github.com/cruxstack/octo-sts-distros/internal/admin"
+	"github.com/cruxstack/octo-sts-distros/internal/app"
+	"github.com/cruxstack/octo-sts-distros/internal/audit"
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+	"github.com/cruxstack/octo-sts-distros/internal/errreport"
+	"github.com/cruxstack/octo-sts-distros/internal/installer"
+	"github.com/cruxstack/octo-sts-distros/internal/lambdaevent"
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+	"github.com/cruxstack/octo-sts-distros/internal/ssmresolver"
+	"github.com/cruxstack/octo-sts-distros/internal/sts"
+	"github.com/cruxstack/octo-sts-distros/internal/vaultresolver"
+	"github.com/cruxstack/octo-sts-distros/internal/version"
+	"github.com/cruxstack/octo-sts-distros/internal/xraytrace"
+)
+
+// stsBasePath is where the STS is routed, and stripped before the STS
+// handles the request.
+const stsBasePath = "/sts"
+
+var (
+	// runtime provides unified lifecycle management for the Lambda function
+	runtime *ghappsetup.Runtime
+
+	// stsInstance handles STS requests (initialized via runtime.EnsureLoaded)
+	stsInstance *sts.STS
+
+	// recorder ships exchange audit events (nil unless AUDIT_SINK is set)
+	recorder *audit.Recorder
+
+	// appInstance handles webhook requests (initialized via runtime.EnsureLoaded)
+	appInstance *app.App
+
+	// installerAdapter wraps the installer handler for Lambda (nil if installer disabled)
+	installerAdapter *httpadapter.HandlerAdapterV2
+
+	// configStore is used to check installer status and store health at request time
+	configStore configstore.Store
+
+	// installerEnabled indicates whether the installer is enabled (from env var)
+	installerEnabled bool
+)
+
+func init() {
+	shared.SetupEnvMapping()
+
+	ctx := context.Background()
+	ctx = clog.WithLogger(ctx, clog.New(shared.NewSlogHandler()))
+	log := clog.FromContext(ctx)
+	log.Infof("[version] %s", version.Get())
+
+	// Report panics and server errors when SENTRY_DSN is set
+	if err := errreport.Init(ctx, "all"); err != nil {
+		log.Errorf("%v, not reporting errors", err)
+	}
+
+	installerEnabled = configstore.InstallerEnabled()
+
+	store, err := configstore.NewFromEnv()
+	if err != nil {
+		log.Errorf("failed to create config store: %v", err)
+		// Continue without installer - let EnsureLoaded handle the error
+	}
+
+	recorder, err = audit.FromEnv(ctx)
+	if err != nil {
+		log.Errorf("failed to create audit recorder: %v", err)
+		// Don't exit - serve without auditing
+	}
+
+	if store != nil {
+		configStore = store
+	}
+
+	// Initialize installer handler if enabled (doesn't require GitHub App credentials)
+	if installerEnabled && store != nil {
+		installerHandler, err := installer.New(installer.NewOctoSTSConfig(store))
+		if err != nil {
+			log.Errorf("failed to create installer handler: %v", err)
+		} else {
+			var h http.Handler = installerHandler
+			h = installer.NewMetadataHandler(h)
+			if installer.KubernetesManifestEnabled() {
+				h = installer.NewKubernetesManifestHandler(h, installer.NewKubernetesManifestConfigFromEnv())
+			}
+			h = installer.NewStoreCheckHandler(h, store)
+			installerAdapter = httpadapter.NewV2(h)
+			log.Infof("[config] installer enabled: /setup endpoint available")
+		}
+	}
+
+	runtime, err = ghappsetup.NewRuntime(ghappsetup.Config{
+		Store: store,
+		LoadFunc: shared.WithEnvRollback(func(ctx context.Context) error {
+			// Resolve AWS, Google Secret Manager, and Azure Key Vault references
+			if err := xraytrace.Capture(ctx, xraytrace.SegmentSSMResolve, ssmresolver.ResolveEnvironmentWithDefaults); err != nil {
+				return err
+			}
+			// Resolve Vault references and credentials saved to the Vault store
+			if err := vaultresolver.ResolveEnvironmentWithDefaults(ctx); err != nil {
+				return err
+			}
+			shared.SetupEnvMapping()
+			// Prefer credentials saved by the installer over the environment
+			if err := shared.ApplyStoreCredentials(ctx, store); err != nil {
+				return err
+			}
+			return initHandlers(ctx)
+		}),
+	})
+	if err != nil {
+		log.Errorf("failed to create runtime: %v", err)
+		// Don't exit - let EnsureLoaded handle the error
+	}
+}
+
+// initHandlers creates the STS and webhook handlers with current
+// configuration, sharing one GitHub App transport.
+func initHandlers(ctx context.Context) error {
+	log := clog.FromContext(ctx)
+
+	baseCfg, err := envConfig.BaseConfig()
+	if err != nil {
+		return err
+	}
+
+	appConfig, err := envConfig.AppConfig()
+	if err != nil {
+		return err
+	}
+
+	webhookConfig, err := envConfig.WebhookConfig()
+	if err != nil {
+		return err
+	}
+
+	baseCfg.Metrics = false // GCP-specific
+
+	appID, kmsKey, err := shared.PrimaryGitHubApp(baseCfg)
+	if err != nil {
+		return err
+	}
+
+	atr, err := ghtransport.New(ctx, appID, kmsKey, baseCfg, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	stsMaxBodySize, err := shared.MaxBodySizeFromEnv(shared.EnvSTSMaxBodySize)
+	if err != nil {
+		return err
+	}
+
+	webhookMaxBodySize, err := shared.MaxBodySizeFromEnv(shared.EnvWebhookMaxBodySize)
+	if err != nil {
+		return err
+	}
+
+	newSTS, err := sts.New(atr, sts.Config{
+		Domain:            appConfig.Domain,
+		AdditionalDomains: shared.AdditionalDomainsFromEnv(),
+		MaxBodySize:       stsMaxBodySize,
+		Trace:             xraytrace.Capture, // X-Ray subsegments when active tracing is enabled
+		Audit:             recorder.Record,
+	})
+	if err != nil {
+		return err
+	}
+
+	var orgs []string
+	for _, s := range strings.Split(webhookConfig.OrganizationFilter, ",") {
+		if o := strings.TrimSpace(s); o != "" {
+			orgs = append(orgs, o)
+		}
+	}
+
+	newApp, err := app.New(atr, app.Config{
+		WebhookSecrets: [][]byte{[]byte(webhookConfig.WebhookSecret)},
+		Organizations:  orgs,
+		MaxBodySize:    webhookMaxBodySize,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Replace the handlers only once both are built
+	stsInstance, appInstance = newSTS, newApp
+
+	log.Infof("[config] STS handler configured for domain: %s", appConfig.Domain)
+	log.Infof("[config] webhook handler configured for %d organizations", len(orgs))
+	return nil
+}
+
+func handler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	ctx = clog.WithLogger(ctx, clog.New(shared.NewSlogHandler()))
+	log := clog.FromContext(ctx)
+
+	path := req.RawPath
+	method := req.RequestContext.HTTP.Method
+
+	log.Infof("request: method=%s path=%s", method, path)
+
+	// Route based on path
+	switch {
+	// Health check - returns 200 unless a deep check (?deep=1) finds the store unreachable
+	case path == "/healthz":
+		if shared.IsDeepHealthCheck(req.QueryStringParameters[shared.DeepHealthQueryParam]) {
+			if err := shared.PingStore(ctx, configStore); err != nil {
+				log.Errorf("[health] config store check failed: %v", err)
+				return serviceUnavailableResponse("config store unavailable"), nil
+			}
+		}
+		return healthzResponse(), nil
+
+	// Build information of the deployed function
+	case path == version.Path:
+		return versionResponse(), nil
+
+	// STS endpoints
+	case path == stsBasePath || strings.HasPrefix(path, stsBasePath+"/"):
+		// A function URL is only known once created, so unless configured the
+		// audience defaults to the domain the function is invoked on
+		if os.Getenv(configstore.EnvSTSDomain) == "" && lambdaevent.IsFunctionURL(req) {
+			log.Infof("[config] %s not set, using function URL domain: %s", configstore.EnvSTSDomain, req.RequestContext.DomainName)
+			os.Setenv(configstore.EnvSTSDomain, req.RequestContext.DomainName)
+		}
+		if err := runtime.EnsureLoaded(ctx); err != nil {
+			log.Warnf("failed to load configuration: %v", err)
+			return serviceUnavailableResponse("STS service not configured - complete GitHub App setup first"), nil
+		}
+		return handleSTS(ctx, req)
+
+	// Webhook endpoint
+	case path == "/webhook" || strings.HasPrefix(path, "/webhook/"):
+		// Lazy-load config with retries (idempotent after first success)
+		if err := runtime.EnsureLoaded(ctx); err != nil {
+			log.Warnf("failed to load configuration: %v", err)
+			return serviceUnavailableResponse("webhook handler not configured - complete GitHub App setup first"), nil
+		}
+		return handleWebhook(ctx, req)
+
+	// Installer routes - use httpadapter for proper HTTP handling
+	case path == "/setup" || strings.HasPrefix(path, "/setup/") || path == "/callback":
+		if installerAdapter == nil {
+			return notFoundResponse(), nil
+		}
+		return installerAdapter.ProxyWithContext(ctx, req)
+
+	// Root path
+	case path == "/" || path == "":
+		// Only redirect to /setup while the installer is enabled, the app
+		// is not yet configured, and the installer hasn't been disabled
+		if installerEnabled && installerAdapter != nil && !runtime.IsReady() && !isInstallerDisabled(ctx) {
+			return installerAdapter.ProxyWithContext(ctx, req)
+		}
+		return notFoundResponse(), nil
+
+	default:
+		return notFoundResponse(), nil
+	}
+}
+
+// handleSTS processes STS requests through the STS handler, with the STS
+// base path stripped.
+func handleSTS(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	stsReq := shared.Request{
+		Type:        shared.RequestTypeHTTP,
+		Method:      req.RequestContext.HTTP.Method,
+		Path:        lambdaevent.StripPathPrefix(req.RawPath, stsBasePath),
+		Headers:     shared.NormalizeHeaders(req.Headers),
+		QueryParams: req.QueryStringParameters,
+		Body:        []byte(req.Body),
+	}
+
+	resp := stsInstance.HandleRequest(ctx, stsReq)
+
+	return lambdaevent.FromResponse(resp), nil
+}
+
+// handleWebhook processes webhook requests through the app handler.
+func handleWebhook(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	appReq := shared.Request{
+		Type:    shared.RequestTypeHTTP,
+		Method:  req.RequestContext.HTTP.Method,
+		Path:    req.RawPath,
+		Headers: shared.NormalizeHeaders(req.Headers),
+		Body:    []byte(req.Body),
+	}
+
+	resp := appInstance.HandleRequest(ctx, appReq)
+
+	return lambdaevent.FromResponse(resp), nil
+}
+
+// handleQueuedWebhook processes a webhook delivery queued on SQS. Deliveries
+// the app rejects, e.g. for a bad signature, are dropped since a retry would
+// fail the same way; server errors are left on the queue to be retried.
+func handleQueuedWebhook(ctx context.Context, msg events.SQSMessage) error {
+	ctx = clog.WithLogger(ctx, clog.New(shared.NewSlogHandler()))
+	log := clog.FromContext(ctx)
+
+	if err := runtime.EnsureLoaded(ctx); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	resp, err := handleWebhook(ctx, lambdaevent.FromSQS(msg, "/webhook"))
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("webhook handler returned %d: %s", resp.StatusCode, resp.Body)
+	case resp.StatusCode >= http.StatusBadRequest:
+		log.Warnf("[sqs] dropping message %s: webhook handler returned %d: %s", msg.MessageId, resp.StatusCode, resp.Body)
+	}
+	return nil
+}
+
+// isInstallerDisabled checks if the installer has been disabled via the UI.
+// This checks the SSM-stored status, not the environment variable.
+func isInstallerDisabled(ctx context.Context) bool {
+	if configStore == nil {
+		return false
+	}
+	status, err := configStore.Status(ctx)
+	if err != nil {
+		clog.FromContext(ctx).Warnf("failed to check installer status: %v", err)
+		return false
+	}
+	return status != nil && status.InstallerDisabled
+}
+
+// Response helpers
+
+func healthzResponse() events.APIGatewayV2HTTPResponse {
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "text/plain"},
+		Body:       "ok",
+	}
+}
+
+func versionResponse() events.APIGatewayV2HTTPResponse {
+	body, _ := json.Marshal(version.Get())
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+	}
+}
+
+func notFoundResponse() events.APIGatewayV2HTTPResponse {
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: http.StatusNotFound,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       `{"error":"not_found","message":"not found"}`,
+	}
+}
+
+func serviceUnavailableResponse(message string) events.APIGatewayV2HTTPResponse {
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: http.StatusServiceUnavailable,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+			"Retry-After":  "5",
+		},
+		Body: `{"error":"service_unavailable","message":"` + message + `"}`,
+	}
+}
+
+// warmUp loads the configuration and fills the installation and trust
+// policy caches, so the requests after a scheduled warm-up invocation skip
+// the lookups.
+func warmUp(ctx context.Context) error {
+	ctx = clog.WithLogger(ctx, clog.New(shared.NewSlogHandler()))
+	log := clog.FromContext(ctx)
+
+	if err := runtime.EnsureLoaded(ctx); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	count, err := stsInstance.WarmUp(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list installations: %w", err)
+	}
+	log.Infof("[warmup] cached %d installations", count)
+
+	// Re-read the listed and the most recently used trust policies before
+	// their cache entries expire
+	cfg, err := shared.WarmUpConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	count, err = stsInstance.RefreshTrustPolicies(ctx, cfg.TrustPolicies, cfg.RecentTrustPolicies)
+	if err != nil {
+		log.Warnf("[warmup] failed to read trust policies: %v", err)
+	}
+	log.Infof("[warmup] cached %d trust policies", count)
+	return nil
+}
+
+func main() {
+	// Direct invocations with an "admin" payload perform admin actions
+	adminAPI := admin.New(admin.Config{
+		Reload:        runtime.Reload,
+		FlushCaches:   admin.FlushSTSCaches,
+		Installations: sts.Installations,
+	})
+
+	// Cancel the work of each invocation shortly before it times out
+	margin, err := lambdaevent.DeadlineMarginFromEnv()
+	if err != nil {
+		clog.New(shared.NewSlogHandler()).Errorf("%v, using %s", err, margin)
+	}
+
+	// EMF_METRICS writes the metrics of each invocation for CloudWatch
+	emf, err := lambdaevent.EMFFromEnv("all")
+	if err != nil {
+		clog.New(shared.NewSlogHandler()).Errorf("%v, not writing metrics", err)
+	}
+
+	// Scheduled events and {"warmup":true} load the configuration ahead of
+	// requests, and SQS events carry queued webhook deliveries
+	lambda.Start(lambdaevent.WrapDeadline(margin, errreport.WrapLambda(lambdaevent.WrapEMF(emf, audit.WrapLambda(recorder, admin.WrapLambda(adminAPI, lambdaevent.WrapWarmUp(warmUp,
+		lambdaevent.WrapSQS(handleQueuedWebhook, lambdaevent.Wrap(errreport.WrapEvent(handler))))))))))
+}
@@ -5,7 +5,10 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"os"
+	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -15,17 +18,36 @@ import (
 	"github.com/octo-sts/app/pkg/ghtransport"
 
 	"github.com/cruxstack/github-app-setup-go/ghappsetup"
-	"github.com/cruxstack/github-app-setup-go/ssmresolver"
+	"github.com/cruxstack/octo-sts-distros/internal/admin"
+	"github.com/cruxstack/octo-sts-distros/internal/audit"
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+	"github.com/cruxstack/octo-sts-distros/internal/errreport"
+	"github.com/cruxstack/octo-sts-distros/internal/lambdaevent"
 	"github.com/cruxstack/octo-sts-distros/internal/shared"
+	"github.com/cruxstack/octo-sts-distros/internal/ssmresolver"
 	"github.com/cruxstack/octo-sts-distros/internal/sts"
+	"github.com/cruxstack/octo-sts-distros/internal/vaultresolver"
+	"github.com/cruxstack/octo-sts-distros/internal/version"
+	"github.com/cruxstack/octo-sts-distros/internal/xraytrace"
 )
 
+// envBasePath overrides the path prefix stripped from requests before
+// routing, e.g. "" when the STS is routed from the root of a custom layout.
+const envBasePath = "STS_BASE_PATH"
+
+// defaultBasePath is stripped when the base path can't be derived from the
+// request, as for the $default route and ALB requests.
+const defaultBasePath = "/sts"
+
 var (
 	// runtime provides unified lifecycle management for the Lambda function
 	runtime *ghappsetup.Runtime
 
 	// stsInstance handles STS requests (initialized via runtime.EnsureLoaded)
 	stsInstance *sts.STS
+
+	// recorder ships exchange audit events (nil unless AUDIT_SINK is set)
+	recorder *audit.Recorder
 )
 
 func init() {
@@ -34,16 +56,43 @@ func init() {
 	ctx := context.Background()
 	ctx = clog.WithLogger(ctx, clog.New(shared.NewSlogHandler()))
 	log := clog.FromContext(ctx)
+	log.Infof("[version] %s", version.Get())
+
+	// Report panics and server errors when SENTRY_DSN is set
+	if err := errreport.Init(ctx, "sts"); err != nil {
+		log.Errorf("%v, not reporting errors", err)
+	}
+
+	store, err := configstore.NewFromEnv()
+	if err != nil {
+		log.Errorf("failed to create config store: %v", err)
+		// Don't exit - let EnsureLoaded handle the error
+	}
+
+	recorder, err = audit.FromEnv(ctx)
+	if err != nil {
+		log.Errorf("failed to create audit recorder: %v", err)
+		// Don't exit - serve without auditing
+	}
 
-	var err error
 	runtime, err = ghappsetup.NewRuntime(ghappsetup.Config{
-		LoadFunc: func(ctx context.Context) error {
-			// Resolve SSM parameters passed as ARNs
-			if err := ssmresolver.ResolveEnvironmentWithDefaults(ctx); err != nil {
+		Store: store,
+		LoadFunc: shared.WithEnvRollback(func(ctx context.Context) error {
+			// Resolve AWS, Google Secret Manager, and Azure Key Vault references
+			if err := xraytrace.Capture(ctx, xraytrace.SegmentSSMResolve, ssmresolver.ResolveEnvironmentWithDefaults); err != nil {
+				return err
+			}
+			// Resolve Vault references and credentials saved to the Vault store
+			if err := vaultresolver.ResolveEnvironmentWithDefaults(ctx); err != nil {
+				return err
+			}
+			shared.SetupEnvMapping()
+			// Prefer credentials saved by the installer over the environment
+			if err := shared.ApplyStoreCredentials(ctx, store); err != nil {
 				return err
 			}
 			return initSTSHandler(ctx)
-		},
+		}),
 	})
 	if err != nil {
 		log.Errorf("failed to create runtime: %v", err)
@@ -77,9 +126,17 @@ func initSTSHandler(ctx context.Context) error {
 		return err
 	}
 
+	maxBodySize, err := shared.MaxBodySizeFromEnv(shared.EnvSTSMaxBodySize)
+	if err != nil {
+		return err
+	}
+
 	stsInstance, err = sts.New(atr, sts.Config{
-		Domain:   appConfig.Domain,
-		BasePath: "/sts", // API Gateway routes /sts/* to this Lambda
+		Domain:            appConfig.Domain,
+		AdditionalDomains: shared.AdditionalDomainsFromEnv(),
+		MaxBodySize:       maxBodySize,
+		Trace:             xraytrace.Capture, // X-Ray subsegments when active tracing is enabled
+		Audit:             recorder.Record,
 	})
 	if err != nil {
 		return err
@@ -89,10 +146,31 @@ func initSTSHandler(ctx context.Context) error {
 	return nil
 }
 
+// basePath returns the path prefix of req to strip before routing:
+// STS_BASE_PATH when set, else the prefix of the API Gateway route, e.g.
+// "/sts" for "ANY /sts/{proxy+}", else defaultBasePath. Function URLs serve
+// from the root, where the prefix doesn't match.
+func basePath(req events.APIGatewayV2HTTPRequest) string {
+	if v, ok := os.LookupEnv(envBasePath); ok {
+		return strings.TrimSuffix(v, "/")
+	}
+	if prefix, ok := lambdaevent.RoutePrefix(req); ok {
+		return prefix
+	}
+	return defaultBasePath
+}
+
 func handler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
 	ctx = clog.WithLogger(ctx, clog.New(shared.NewSlogHandler()))
 	log := clog.FromContext(ctx)
 
+	// A function URL is only known once created, so unless configured the
+	// audience defaults to the domain the function is invoked on
+	if os.Getenv(configstore.EnvSTSDomain) == "" && lambdaevent.IsFunctionURL(req) {
+		log.Infof("[config] %s not set, using function URL domain: %s", configstore.EnvSTSDomain, req.RequestContext.DomainName)
+		os.Setenv(configstore.EnvSTSDomain, req.RequestContext.DomainName)
+	}
+
 	// Lazy-load config with retries (idempotent after first success)
 	if err := runtime.EnsureLoaded(ctx); err != nil {
 		log.Warnf("failed to load configuration: %v", err)
@@ -106,7 +184,7 @@ func handler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.AP
 		}, nil
 	}
 
-	path := req.RawPath
+	path := lambdaevent.StripPathPrefix(req.RawPath, basePath(req))
 	method := req.RequestContext.HTTP.Method
 
 	log.Infof("request: method=%s path=%s", method, path)
@@ -124,13 +202,60 @@ func handler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.AP
 	// Handle the request
 	resp := stsInstance.HandleRequest(ctx, stsReq)
 
-	return events.APIGatewayV2HTTPResponse{
-		StatusCode: resp.StatusCode,
-		Headers:    resp.Headers,
-		Body:       string(resp.Body),
-	}, nil
+	return lambdaevent.FromResponse(resp), nil
+}
+
+// warmUp loads the configuration and fills the installation and trust
+// policy caches, so the requests after a scheduled warm-up invocation skip
+// the lookups.
+func warmUp(ctx context.Context) error {
+	ctx = clog.WithLogger(ctx, clog.New(shared.NewSlogHandler()))
+	log := clog.FromContext(ctx)
+
+	if err := runtime.EnsureLoaded(ctx); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	count, err := stsInstance.WarmUp(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list installations: %w", err)
+	}
+	log.Infof("[warmup] cached %d installations", count)
+
+	// Re-read the listed and the most recently used trust policies before
+	// their cache entries expire
+	cfg, err := shared.WarmUpConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	count, err = stsInstance.RefreshTrustPolicies(ctx, cfg.TrustPolicies, cfg.RecentTrustPolicies)
+	if err != nil {
+		log.Warnf("[warmup] failed to read trust policies: %v", err)
+	}
+	log.Infof("[warmup] cached %d trust policies", count)
+	return nil
 }
 
 func main() {
-	lambda.Start(handler)
+	// Direct invocations with an "admin" payload perform admin actions
+	adminAPI := admin.New(admin.Config{
+		Reload:        runtime.Reload,
+		FlushCaches:   admin.FlushSTSCaches,
+		Installations: sts.Installations,
+	})
+
+	// Cancel the work of each invocation shortly before it times out
+	margin, err := lambdaevent.DeadlineMarginFromEnv()
+	if err != nil {
+		clog.New(shared.NewSlogHandler()).Errorf("%v, using %s", err, margin)
+	}
+
+	// EMF_METRICS writes the metrics of each invocation for CloudWatch
+	emf, err := lambdaevent.EMFFromEnv("sts")
+	if err != nil {
+		clog.New(shared.NewSlogHandler()).Errorf("%v, not writing metrics", err)
+	}
+
+	// Scheduled events and {"warmup":true} load the configuration ahead of requests
+	lambda.Start(lambdaevent.WrapDeadline(margin, errreport.WrapLambda(lambdaevent.WrapEMF(emf, audit.WrapLambda(recorder, admin.WrapLambda(adminAPI,
+		lambdaevent.WrapWarmUp(warmUp, lambdaevent.Wrap(errreport.WrapEvent(handler)))))))))
 }
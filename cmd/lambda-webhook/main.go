@@ -5,6 +5,8 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -14,11 +16,17 @@ import (
 	"github.com/chainguard-dev/clog"
 
 	"github.com/cruxstack/github-app-setup-go/ghappsetup"
-	"github.com/cruxstack/github-app-setup-go/ssmresolver"
+	"github.com/cruxstack/octo-sts-distros/internal/admin"
 	"github.com/cruxstack/octo-sts-distros/internal/app"
 	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+	"github.com/cruxstack/octo-sts-distros/internal/errreport"
 	"github.com/cruxstack/octo-sts-distros/internal/installer"
+	"github.com/cruxstack/octo-sts-distros/internal/lambdaevent"
 	"github.com/cruxstack/octo-sts-distros/internal/shared"
+	"github.com/cruxstack/octo-sts-distros/internal/ssmresolver"
+	"github.com/cruxstack/octo-sts-distros/internal/vaultresolver"
+	"github.com/cruxstack/octo-sts-distros/internal/version"
+	"github.com/cruxstack/octo-sts-distros/internal/xraytrace"
 	envConfig "github.com/octo-sts/app/pkg/envconfig"
 	"github.com/octo-sts/app/pkg/ghtransport"
 )
@@ -33,7 +41,7 @@ var (
 	// installerAdapter wraps the installer handler for Lambda (nil if installer disabled)
 	installerAdapter *httpadapter.HandlerAdapterV2
 
-	// configStore is used to check installer status at request time
+	// configStore is used to check installer status and store health at request time
 	configStore configstore.Store
 
 	// installerEnabled indicates whether the installer is enabled (from env var)
@@ -46,42 +54,65 @@ func init() {
 	ctx := context.Background()
 	ctx = clog.WithLogger(ctx, clog.New(shared.NewSlogHandler()))
 	log := clog.FromContext(ctx)
+	log.Infof("[version] %s", version.Get())
+
+	// Report panics and server errors when SENTRY_DSN is set
+	if err := errreport.Init(ctx, "webhook"); err != nil {
+		log.Errorf("%v, not reporting errors", err)
+	}
 
 	installerEnabled = configstore.InstallerEnabled()
 
+	store, err := configstore.NewFromEnv()
+	if err != nil {
+		log.Errorf("failed to create config store: %v", err)
+		// Continue without installer - let EnsureLoaded handle the error
+	}
+
+	if store != nil {
+		configStore = store
+	}
+
 	// Initialize installer handler if enabled (doesn't require GitHub App credentials)
-	if installerEnabled {
-		store, err := configstore.NewFromEnv()
+	if installerEnabled && store != nil {
+		installerCfg := installer.NewOctoSTSConfig(store)
+		// Note: We can't wire runtime.Reload here because runtime isn't created yet,
+		// but for Lambda, reload semantics are different (cold start will pick up new config)
+
+		installerHandler, err := installer.New(installerCfg)
 		if err != nil {
-			log.Errorf("failed to create config store: %v", err)
-			// Continue without installer
+			log.Errorf("failed to create installer handler: %v", err)
 		} else {
-			configStore = store
-
-			installerCfg := installer.NewOctoSTSConfig(store)
-			// Note: We can't wire runtime.Reload here because runtime isn't created yet,
-			// but for Lambda, reload semantics are different (cold start will pick up new config)
-
-			installerHandler, err := installer.New(installerCfg)
-			if err != nil {
-				log.Errorf("failed to create installer handler: %v", err)
-			} else {
-				installerAdapter = httpadapter.NewV2(installerHandler)
-				log.Infof("[config] installer enabled: /setup endpoint available")
+			var h http.Handler = installerHandler
+			h = installer.NewMetadataHandler(h)
+			if installer.KubernetesManifestEnabled() {
+				h = installer.NewKubernetesManifestHandler(h, installer.NewKubernetesManifestConfigFromEnv())
 			}
+			h = installer.NewStoreCheckHandler(h, store)
+			installerAdapter = httpadapter.NewV2(h)
+			log.Infof("[config] installer enabled: /setup endpoint available")
 		}
 	}
 
 	// Create runtime for webhook handler lifecycle
-	var err error
 	runtime, err = ghappsetup.NewRuntime(ghappsetup.Config{
-		LoadFunc: func(ctx context.Context) error {
-			// Resolve SSM parameters passed as ARNs
-			if err := ssmresolver.ResolveEnvironmentWithDefaults(ctx); err != nil {
+		Store: store,
+		LoadFunc: shared.WithEnvRollback(func(ctx context.Context) error {
+			// Resolve AWS, Google Secret Manager, and Azure Key Vault references
+			if err := xraytrace.Capture(ctx, xraytrace.SegmentSSMResolve, ssmresolver.ResolveEnvironmentWithDefaults); err != nil {
+				return err
+			}
+			// Resolve Vault references and credentials saved to the Vault store
+			if err := vaultresolver.ResolveEnvironmentWithDefaults(ctx); err != nil {
+				return err
+			}
+			shared.SetupEnvMapping()
+			// Prefer credentials saved by the installer over the environment
+			if err := shared.ApplyStoreCredentials(ctx, store); err != nil {
 				return err
 			}
 			return initWebhookHandler(ctx)
-		},
+		}),
 	})
 	if err != nil {
 		log.Errorf("failed to create runtime: %v", err)
@@ -122,9 +153,15 @@ func initWebhookHandler(ctx context.Context) error {
 		}
 	}
 
+	maxBodySize, err := shared.MaxBodySizeFromEnv(shared.EnvWebhookMaxBodySize)
+	if err != nil {
+		return err
+	}
+
 	appInstance, err = app.New(atr, app.Config{
 		WebhookSecrets: [][]byte{[]byte(webhookConfig.WebhookSecret)},
 		Organizations:  orgs,
+		MaxBodySize:    maxBodySize,
 	})
 	if err != nil {
 		return err
@@ -145,10 +182,20 @@ func handler(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.AP
 
 	// Route based on path
 	switch {
-	// Health check - always returns 200
+	// Health check - returns 200 unless a deep check (?deep=1) finds the store unreachable
 	case path == "/healthz":
+		if shared.IsDeepHealthCheck(req.QueryStringParameters[shared.DeepHealthQueryParam]) {
+			if err := shared.PingStore(ctx, configStore); err != nil {
+				log.Errorf("[health] config store check failed: %v", err)
+				return serviceUnavailableResponse("config store unavailable"), nil
+			}
+		}
 		return healthzResponse(), nil
 
+	// Build information of the deployed function
+	case path == version.Path:
+		return versionResponse(), nil
+
 	// Installer routes - use httpadapter for proper HTTP handling
 	case path == "/setup" || strings.HasPrefix(path, "/setup/"):
 		if installerAdapter == nil {
@@ -199,11 +246,31 @@ func handleWebhook(ctx context.Context, req events.APIGatewayV2HTTPRequest) (eve
 
 	resp := appInstance.HandleRequest(ctx, appReq)
 
-	return events.APIGatewayV2HTTPResponse{
-		StatusCode: resp.StatusCode,
-		Headers:    resp.Headers,
-		Body:       string(resp.Body),
-	}, nil
+	return lambdaevent.FromResponse(resp), nil
+}
+
+// handleQueuedWebhook processes a webhook delivery queued on SQS. Deliveries
+// the app rejects, e.g. for a bad signature, are dropped since a retry would
+// fail the same way; server errors are left on the queue to be retried.
+func handleQueuedWebhook(ctx context.Context, msg events.SQSMessage) error {
+	ctx = clog.WithLogger(ctx, clog.New(shared.NewSlogHandler()))
+	log := clog.FromContext(ctx)
+
+	if err := runtime.EnsureLoaded(ctx); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	resp, err := handleWebhook(ctx, lambdaevent.FromSQS(msg, "/webhook"))
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("webhook handler returned %d: %s", resp.StatusCode, resp.Body)
+	case resp.StatusCode >= http.StatusBadRequest:
+		log.Warnf("[sqs] dropping message %s: webhook handler returned %d: %s", msg.MessageId, resp.StatusCode, resp.Body)
+	}
+	return nil
 }
 
 // isInstallerDisabled checks if the installer has been disabled via the UI.
@@ -230,6 +297,15 @@ func healthzResponse() events.APIGatewayV2HTTPResponse {
 	}
 }
 
+func versionResponse() events.APIGatewayV2HTTPResponse {
+	body, _ := json.Marshal(version.Get())
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+	}
+}
+
 func notFoundResponse() events.APIGatewayV2HTTPResponse {
 	return events.APIGatewayV2HTTPResponse{
 		StatusCode: http.StatusNotFound,
@@ -249,6 +325,36 @@ func serviceUnavailableResponse(message string) events.APIGatewayV2HTTPResponse
 	}
 }
 
+// warmUp loads the configuration, so the requests after a scheduled warm-up
+// invocation skip it.
+func warmUp(ctx context.Context) error {
+	ctx = clog.WithLogger(ctx, clog.New(shared.NewSlogHandler()))
+	if err := runtime.EnsureLoaded(ctx); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	return nil
+}
+
 func main() {
-	lambda.Start(handler)
+	// Direct invocations with an "admin" payload perform admin actions
+	adminAPI := admin.New(admin.Config{
+		Reload: runtime.Reload,
+	})
+
+	// Cancel the work of each invocation shortly before it times out
+	margin, err := lambdaevent.DeadlineMarginFromEnv()
+	if err != nil {
+		clog.New(shared.NewSlogHandler()).Errorf("%v, using %s", err, margin)
+	}
+
+	// EMF_METRICS writes the metrics of each invocation for CloudWatch
+	emf, err := lambdaevent.EMFFromEnv("webhook")
+	if err != nil {
+		clog.New(shared.NewSlogHandler()).Errorf("%v, not writing metrics", err)
+	}
+
+	// Scheduled events and {"warmup":true} load the configuration ahead of
+	// requests, and SQS events carry queued webhook deliveries
+	lambda.Start(lambdaevent.WrapDeadline(margin, errreport.WrapLambda(lambdaevent.WrapEMF(emf, admin.WrapLambda(adminAPI, lambdaevent.WrapWarmUp(warmUp,
+		lambdaevent.WrapSQS(handleQueuedWebhook, lambdaevent.Wrap(errreport.WrapEvent(handler)))))))))
 }
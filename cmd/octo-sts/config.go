@@ -0,0 +1,50 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cruxstack/octo-sts-distros/internal/server"
+)
+
+func newConfigCommand() *cobra.Command {
+	config := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the service configuration",
+	}
+	config.AddCommand(newConfigCheckCommand())
+	return config
+}
+
+func newConfigCheckCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check [sts|webhook|all]",
+		Short: "Load the configuration once and report problems",
+		Long: `Load the configuration of a service (all by default) the way serve
+would: resolve secret references, read the config store, and build the
+GitHub App transport and handlers. Nothing is served.
+
+Every missing or invalid setting is reported at once, with the subsystem
+that needs it. The exit status is non-zero when the service would not
+become ready.`,
+		Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: []string{string(server.ServiceSTS), string(server.ServiceWebhook), string(server.ServiceAll)},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			service := server.ServiceAll
+			if len(args) == 1 {
+				service = server.Service(args[0])
+			}
+			if err := server.Check(cmd.Context(), service); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "configuration for %s is valid\n", service)
+			return nil
+		},
+	}
+	addStoreFlags(cmd.Flags())
+	return cmd
+}
@@ -0,0 +1,55 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cruxstack/octo-sts-distros/internal/generate"
+)
+
+func newGenerateCommand() *cobra.Command {
+	var opts generate.Options
+
+	cmd := &cobra.Command{
+		Use:   "generate terraform|kubernetes",
+		Short: "Print deployment artifacts for a distro and config store",
+		Long: `Print a starting point for a deployment, wired for one config store:
+
+  terraform    a root configuration using the aws-lambda module: API
+               Gateway, the Lambda functions, and their access to the
+               credentials in SSM (aws-ssm, the default, with the setup
+               wizard enabled) or Secrets Manager (aws-secretsmanager)
+  kubernetes   manifests running this image with "octo-sts serve all",
+               with RBAC for the kubernetes store (the default), or a
+               mounted Secret for the envfile and files stores
+
+For example:
+
+  octo-sts generate terraform --name octo-sts-prod > main.tf
+  octo-sts generate kubernetes --image registry.example.com/octo-sts:v1 \
+    --domain sts.example.com | kubectl apply -f -`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{generate.TargetTerraform, generate.TargetKubernetes},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Target = args[0]
+			if err := generate.Generate(cmd.OutOrStdout(), opts); err != nil {
+				return fmt.Errorf("failed to generate %s: %w", opts.Target, err)
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.StorageMode, "storage-mode", "", "config store of the GitHub App credentials")
+	flags.StringVar(&opts.Name, "name", generate.DefaultName, "name of the deployment's resources")
+	flags.StringVar(&opts.Domain, "domain", "", "STS domain, required for kubernetes")
+	flags.StringVar(&opts.Prefix, "prefix", "", "where the store keeps the credentials, e.g. the SSM parameter prefix")
+	flags.StringVar(&opts.Namespace, "namespace", "", "Kubernetes namespace (default: the name)")
+	flags.StringVar(&opts.Image, "image", "", "container image, required for kubernetes")
+	flags.StringVar(&opts.Ref, "ref", generate.DefaultRef, "git ref of the Terraform module")
+	return cmd
+}
@@ -0,0 +1,34 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cruxstack/octo-sts-distros/internal/server"
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+)
+
+func newInstallCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Serve the installer until a GitHub App is registered",
+		Long: `Serve only the installer, at /setup, and exit once the GitHub App
+credentials are saved to the config store.
+
+Use it to register the app before deploying the services, e.g. on a
+workstation with --storage-mode pointing at the production store.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return server.Install(cmd.Context(), server.PortFromEnv())
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.Int("port", shared.DefaultPort, "port to listen on when LISTEN_ADDR and LISTEN_SOCKET are not set (env "+server.EnvPort+")")
+	annotateEnv(flags, "port", server.EnvPort)
+	envFlag(flags, "listen-addr", "", shared.EnvListenAddr, "TCP address to listen on instead of every interface on the port, e.g. [::]:8080")
+	addStoreFlags(flags)
+	return cmd
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Command octo-sts runs and manages the standalone distributions from one
+// binary:
+//
+//	octo-sts serve sts|webhook|all   serve the STS, the webhook, or both
+//	octo-sts install                 serve only the installer until an app is registered
+//	octo-sts store migrate           copy credentials between config stores
+//	octo-sts store status            show the installer status and credential metadata
+//	octo-sts store export            print the stored credentials as .env, JSON, or YAML
+//	octo-sts store history           list the stored credential versions
+//	octo-sts store rollback <id>     rotate the credentials back to a prior version
+//	octo-sts config check            load the configuration once and report problems
+//	octo-sts selftest                exchange a token end to end against a fake GitHub
+//	octo-sts generate                print Terraform or Kubernetes deployment artifacts
+//
+// Every flag that has an environment variable equivalent falls back to it,
+// so the CLI can replace the http-* commands without changing deployments.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+	"github.com/cruxstack/octo-sts-distros/internal/version"
+)
+
+// envAnnotation marks a flag that sets an environment variable, for the
+// internal packages that are configured from the environment.
+const envAnnotation = "octo-sts/env"
+
+func main() {
+	// systemd and container runtimes stop services with SIGTERM
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if err := newRootCommand().ExecuteContext(ctx); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:          "octo-sts",
+		Short:        "Run and manage the Octo-STS services",
+		Version:      version.Get().String(),
+		SilenceUsage: true,
+		PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+			applyEnvFlags(cmd.Flags())
+			shared.SetupEnvMapping()
+			cmd.SetContext(clog.WithLogger(cmd.Context(), clog.New(shared.NewSlogHandler())))
+		},
+	}
+
+	envFlag(root.PersistentFlags(), "log-level", "", shared.EnvLogLevel, "log level: debug, info, warn, or error")
+	envFlag(root.PersistentFlags(), "log-format", "", shared.EnvLogFormat, "log format: json or text")
+
+	root.AddCommand(
+		newServeCommand(),
+		newInstallCommand(),
+		newStoreCommand(),
+		newConfigCommand(),
+		newSelftestCommand(),
+		newGenerateCommand(),
+	)
+	return root
+}
+
+// envFlag defines a string flag that sets env when given.
+func envFlag(flags *pflag.FlagSet, name, value, env, usage string) {
+	flags.String(name, value, fmt.Sprintf("%s (env %s)", usage, env))
+	annotateEnv(flags, name, env)
+}
+
+// annotateEnv marks the flag name as setting env when given.
+func annotateEnv(flags *pflag.FlagSet, name, env string) {
+	_ = flags.SetAnnotation(name, envAnnotation, []string{env})
+}
+
+// addStoreFlags adds the flags that select the config store.
+func addStoreFlags(flags *pflag.FlagSet) {
+	envFlag(flags, "storage-mode", "", configstore.EnvStorageMode, "config store for the GitHub App credentials")
+	envFlag(flags, "storage-dir", "", configstore.EnvStorageDir, "directory or file of the local config stores")
+}
+
+// applyEnvFlags sets the environment variable of every annotated flag that
+// was given on the command line, so flags take precedence over the
+// environment.
+func applyEnvFlags(flags *pflag.FlagSet) {
+	flags.Visit(func(f *pflag.Flag) {
+		if env, ok := f.Annotations[envAnnotation]; ok && len(env) > 0 {
+			os.Setenv(env[0], f.Value.String())
+		}
+	})
+}
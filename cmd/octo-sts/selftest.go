@@ -0,0 +1,30 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cruxstack/octo-sts-distros/internal/selftest"
+)
+
+func newSelftestCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "selftest",
+		Short: "Exchange a token end to end against a fake GitHub",
+		Long: `Exchange a token end to end within the process: mint an OIDC token
+with a local signer registered as a test issuer, run it through the STS
+handler against a fake GitHub API, and deliver a signed webhook to the
+webhook handler.
+
+No configuration or network access is needed, so the command can run in a
+build pipeline or against a freshly built image to catch packaging and
+wiring problems before a deploy. The exit status is non-zero when a step
+fails.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return selftest.Run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+	"github.com/cruxstack/octo-sts-distros/internal/server"
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+)
+
+func newServeCommand() *cobra.Command {
+	serve := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the STS, the webhook, or both",
+		Long: `Serve the STS, the webhook, or both on one port.
+
+The services are configured from the same environment variables as the
+http-sts, http-app, and http-all commands; the flags below override them.`,
+	}
+
+	serve.AddCommand(
+		newServeServiceCommand(server.ServiceSTS, "Serve the STS at the root path", false),
+		newServeServiceCommand(server.ServiceWebhook, "Serve the webhook at /webhook", true),
+		newServeServiceCommand(server.ServiceAll, "Serve the STS at /sts and the webhook at /webhook", true),
+	)
+	return serve
+}
+
+// newServeServiceCommand returns the serve subcommand of service, with an
+// --installer flag when the service can serve the installer.
+func newServeServiceCommand(service server.Service, short string, installer bool) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   string(service),
+		Short: short,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return server.Run(cmd.Context(), server.OptionsFromEnv(service))
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.Int("port", shared.DefaultPort, "port to listen on when LISTEN_ADDR and LISTEN_SOCKET are not set (env "+server.EnvPort+")")
+	annotateEnv(flags, "port", server.EnvPort)
+	envFlag(flags, "listen-addr", "", shared.EnvListenAddr, "TCP address to listen on instead of every interface on the port, e.g. [::]:8080")
+	flags.Bool("dry-run", false, "check the configuration and GitHub App authentication, report, and exit instead of serving (env "+server.EnvDryRun+")")
+	annotateEnv(flags, "dry-run", server.EnvDryRun)
+	if installer {
+		flags.Bool("installer", false, "serve the installer at /setup (env "+configstore.EnvGitHubAppInstallerEnabled+")")
+		annotateEnv(flags, "installer", configstore.EnvGitHubAppInstallerEnabled)
+	}
+	addStoreFlags(flags)
+	return cmd
+}
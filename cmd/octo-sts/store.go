@@ -0,0 +1,298 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+)
+
+func newStoreCommand() *cobra.Command {
+	store := &cobra.Command{
+		Use:   "store",
+		Short: "Manage the GitHub App credentials in the config stores",
+	}
+	store.AddCommand(
+		newStoreMigrateCommand(),
+		newStoreStatusCommand(),
+		newStoreExportCommand(),
+		newStoreHistoryCommand(),
+		newStoreRollbackCommand(),
+	)
+	return store
+}
+
+func newStoreMigrateCommand() *cobra.Command {
+	var (
+		from, to, fromDir, toDir     string
+		overwrite, dryRun, allValues bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "migrate --from <mode> --to <mode>",
+		Short: "Copy credentials and the installer flag from one store to another",
+		Long: `Copy the GitHub App credentials and the installer flag from one config
+store to another, e.g. from an .env file to production storage:
+
+  octo-sts store migrate --from envfile --from-dir ./.env --to aws-ssm
+
+Each store is configured from the same environment variables the services
+use. Since the local stores all read STORAGE_DIR, --from-dir and --to-dir
+set it for one side only.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if from == to && fromDir == toDir {
+				return errors.New("source and destination are the same store")
+			}
+
+			source, err := configstore.NewForModeInDir(from, fromDir)
+			if err != nil {
+				return fmt.Errorf("failed to create source store: %w", err)
+			}
+			dest, err := configstore.NewForModeInDir(to, toDir)
+			if err != nil {
+				return fmt.Errorf("failed to create destination store: %w", err)
+			}
+
+			result, err := configstore.Migrate(cmd.Context(), source, dest, configstore.MigrateOptions{
+				Overwrite: overwrite,
+				DryRun:    dryRun,
+				AllValues: allValues,
+			})
+			if errors.Is(err, configstore.ErrDestinationRegistered) {
+				return fmt.Errorf("%w (use --overwrite to replace them)", err)
+			}
+			if err != nil {
+				return err
+			}
+
+			stdout := cmd.OutOrStdout()
+			verb := "migrated"
+			if dryRun {
+				verb = "would migrate"
+			}
+			fmt.Fprintf(stdout, "%s app %d from %s to %s\n", verb, result.AppID, from, to)
+			fmt.Fprintf(stdout, "  keys: %s\n", strings.Join(result.Keys, ", "))
+			if result.InstallerDisabled {
+				fmt.Fprintln(stdout, "  installer: disabled")
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&from, "from", "", "source storage mode (e.g. envfile)")
+	flags.StringVar(&to, "to", "", "destination storage mode (e.g. aws-ssm)")
+	flags.StringVar(&fromDir, "from-dir", "", "STORAGE_DIR for the source store")
+	flags.StringVar(&toDir, "to-dir", "", "STORAGE_DIR for the destination store")
+	flags.BoolVar(&overwrite, "overwrite", false, "replace credentials already in the destination")
+	flags.BoolVar(&dryRun, "dry-run", false, "check both stores without writing")
+	flags.BoolVar(&allValues, "all-values", false, "also copy values other than the app credentials")
+	_ = cmd.MarkFlagRequired("from")
+	_ = cmd.MarkFlagRequired("to")
+	return cmd
+}
+
+func newStoreStatusCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the installer status and which setup created the credentials",
+		Long: `Show whether a GitHub App is registered in the config store, whether the
+installer is disabled, and the metadata the installer saved with the
+credentials: when and from where they were created, the installer
+version, and the GitHub host.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			mode := storageMode()
+			store, err := configstore.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create store: %w", err)
+			}
+			status, err := configstore.ReadStatus(cmd.Context(), store)
+			if err != nil {
+				return err
+			}
+
+			stdout := cmd.OutOrStdout()
+			if !status.Registered {
+				fmt.Fprintf(stdout, "no app registered in %s\n", mode)
+			} else {
+				fmt.Fprintf(stdout, "app %d registered in %s\n", status.AppID, mode)
+				if status.AppSlug != "" {
+					fmt.Fprintf(stdout, "  slug: %s\n", status.AppSlug)
+				}
+			}
+			installer := "enabled"
+			if status.InstallerDisabled {
+				installer = "disabled"
+			}
+			fmt.Fprintf(stdout, "  installer: %s\n", installer)
+
+			m := status.Metadata
+			if !m.CreatedAt.IsZero() {
+				fmt.Fprintf(stdout, "  created at: %s\n", m.CreatedAt.Format(time.RFC3339))
+			}
+			for _, field := range []struct{ label, value string }{
+				{"created by", m.CreatedBy},
+				{"installer version", m.InstallerVersion},
+				{"github host", m.GitHubHost},
+			} {
+				if field.value != "" {
+					fmt.Fprintf(stdout, "  %s: %s\n", field.label, field.value)
+				}
+			}
+			return nil
+		},
+	}
+	addStoreFlags(cmd.Flags())
+	return cmd
+}
+
+func newStoreExportCommand() *cobra.Command {
+	var (
+		format, output    string
+		redact, allValues bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Print the stored credentials as .env, JSON, or YAML",
+		Long: `Print the GitHub App credentials of the config store, e.g. to debug a
+setup or seed another environment:
+
+  octo-sts store export --storage-mode aws-ssm --format json -o creds.json
+
+The output holds the private key unless --redact-private-key is given, so
+a file written with -o is only readable by its owner.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			store, err := configstore.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create store: %w", err)
+			}
+
+			var buf bytes.Buffer
+			if err := configstore.Export(cmd.Context(), store, &buf, configstore.ExportOptions{
+				Format:           format,
+				RedactPrivateKey: redact,
+				AllValues:        allValues,
+			}); err != nil {
+				return err
+			}
+
+			if output == "" {
+				_, err := cmd.OutOrStdout().Write(buf.Bytes())
+				return err
+			}
+			if err := os.WriteFile(output, buf.Bytes(), 0600); err != nil {
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "exported credentials from %s to %s\n", storageMode(), output)
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&format, "format", configstore.ExportFormatEnv, "output format: env, json, or yaml")
+	flags.StringVarP(&output, "output", "o", "", "write to this file (mode 0600) instead of stdout")
+	flags.BoolVar(&redact, "redact-private-key", false, "replace the private key with "+configstore.RedactedValue)
+	flags.BoolVar(&allValues, "all-values", false, "also export values other than the app credentials")
+	addStoreFlags(flags)
+	return cmd
+}
+
+func newStoreHistoryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List the stored credential versions, newest first",
+		Long: `List the credential versions the config store keeps, newest first, with
+the ID to pass to "octo-sts store rollback". The local stores keep 10
+versions before the current one; AWS SSM, AWS Secrets Manager, and Vault
+list the versions they retain.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			mode := storageMode()
+			store, err := configstore.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create store: %w", err)
+			}
+			versions, err := configstore.History(cmd.Context(), store)
+			if errors.Is(err, configstore.ErrHistoryNotSupported) {
+				return fmt.Errorf("%w: %s", err, mode)
+			}
+			if err != nil {
+				return err
+			}
+			if len(versions) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "no credential versions in %s\n", mode)
+				return nil
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tCREATED\tAPP\t")
+			for _, version := range versions {
+				created := "-"
+				if !version.CreatedAt.IsZero() {
+					created = version.CreatedAt.Format(time.RFC3339)
+				}
+				current := ""
+				if version.Current {
+					current = "current"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", version.ID, created, version.Credentials.AppID, current)
+			}
+			return w.Flush()
+		},
+	}
+	addStoreFlags(cmd.Flags())
+	return cmd
+}
+
+func newStoreRollbackCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback <id>",
+		Short: "Rotate the credentials back to a prior version",
+		Long: `Rotate the credentials back to a version listed by "octo-sts store
+history", e.g. after a botched rotation. The replaced credentials become a
+version in turn, so a rollback can itself be rolled back.
+
+Values the version lacks are removed, as with any rotation, except that
+STS_DOMAIN is kept from the current credentials, as is the setup metadata
+when the version is of the same app. Rolling back to another app drops the
+metadata of the current one.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mode := storageMode()
+			store, err := configstore.NewFromEnv()
+			if err != nil {
+				return fmt.Errorf("failed to create store: %w", err)
+			}
+			err = configstore.Rollback(cmd.Context(), store, args[0])
+			if errors.Is(err, configstore.ErrHistoryNotSupported) {
+				return fmt.Errorf("%w: %s", err, mode)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "rolled back %s to version %s\n", mode, args[0])
+			return nil
+		},
+	}
+	addStoreFlags(cmd.Flags())
+	return cmd
+}
+
+// storageMode returns the storage mode NewFromEnv uses.
+func storageMode() string {
+	return configstore.GetEnvDefault(configstore.EnvStorageMode, configstore.StorageModeEnvFile)
+}
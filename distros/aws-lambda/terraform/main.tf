@@ -31,21 +31,41 @@ locals {
     replace(aws_apigatewayv2_api.this[0].api_endpoint, "https://", "") : ""
   )
 
+  status_table_enabled = local.enabled && var.installer_config.enabled && var.installer_config.status_table_enabled
+
   ssm_arn_prefix = "arn:${local.aws_partition}:ssm:${local.aws_region_name}:${local.aws_account_id}:parameter${var.installer_config.ssm_parameter_prefix}"
 
   lambda_env_common = {
-    LOG_LEVEL              = var.lambda_config.log_level
-    GITHUB_APP_ID          = var.installer_config.enabled ? "${local.ssm_arn_prefix}GITHUB_APP_ID" : var.github_app_config.app_id
-    GITHUB_APP_PRIVATE_KEY = var.installer_config.enabled ? "${local.ssm_arn_prefix}GITHUB_APP_PRIVATE_KEY" : var.github_app_config.private_key
+    LOG_LEVEL                            = var.lambda_config.log_level
+    GITHUB_APP_ID                        = var.installer_config.enabled ? "${local.ssm_arn_prefix}GITHUB_APP_ID" : var.github_app_config.app_id
+    GITHUB_APP_PRIVATE_KEY               = var.installer_config.enabled ? "${local.ssm_arn_prefix}GITHUB_APP_PRIVATE_KEY" : var.github_app_config.private_key
+    SSM_RESOLVER_ASSUME_ROLE_ARN         = var.resolver_assume_role.arn
+    SSM_RESOLVER_ASSUME_ROLE_EXTERNAL_ID = var.resolver_assume_role.external_id
+    SSM_RESOLVER_PATH                    = var.resolver_ssm_path
   }
 
   lambda_env_sts = merge(local.lambda_env_common, {
-    STS_DOMAIN = local.sts_domain
+    STS_DOMAIN             = local.sts_domain
+    STS_ADDITIONAL_DOMAINS = join(",", var.sts_config.additional_domains)
+    AUDIT_SINK             = var.audit_config.sink
+    AUDIT_FIREHOSE_STREAM  = var.audit_config.firehose_stream
+    AUDIT_LOG_GROUP        = var.audit_config.log_group
+    AUDIT_S3_BUCKET        = var.audit_config.s3_bucket
+    AUDIT_S3_PREFIX        = var.audit_config.s3_prefix
   }, var.lambda_environment_variables)
 
-  lambda_env_webhook = merge(local.lambda_env_common, {
+  # with a cross-account role, the credentials are not in this account, so the
+  # webhook function loads them from the store instead of resolving local ARNs
+  lambda_env_webhook_cross_account = {
+    for k in ["GITHUB_APP_ID", "GITHUB_APP_PRIVATE_KEY"] : k => "" if var.installer_config.ssm_assume_role_arn != ""
+  }
+
+  lambda_env_webhook = merge(local.lambda_env_common, local.lambda_env_webhook_cross_account, {
+    AWS_DYNAMODB_STATUS_TABLE          = local.status_table_enabled ? aws_dynamodb_table.status[0].name : ""
+    AWS_SSM_ASSUME_ROLE_ARN            = var.installer_config.ssm_assume_role_arn
     AWS_SSM_KMS_KEY_ID                 = var.installer_config.kms_key_id
     AWS_SSM_PARAMETER_PREFIX           = var.installer_config.ssm_parameter_prefix
+    AWS_SSM_TIER                       = var.installer_config.ssm_tier
     GITHUB_APP_INSTALLER_ENABLED       = tostring(var.installer_config.enabled)
     GITHUB_ORG                         = var.installer_config.github_org
     GITHUB_URL                         = var.installer_config.github_url
@@ -120,6 +140,11 @@ resource "aws_lambda_function" "sts" {
   timeout                        = var.lambda_config.timeout
   reserved_concurrent_executions = var.lambda_config.reserved_concurrent_executions
   architectures                  = [var.lambda_config.architecture]
+  layers                         = var.lambda_config.layers
+
+  tracing_config {
+    mode = var.lambda_config.tracing_mode
+  }
 
   filename = module.lambda_artifact_sts[0].artifact_package_path
 
@@ -148,6 +173,11 @@ resource "aws_lambda_function" "webhook" {
   timeout                        = var.lambda_config.timeout
   reserved_concurrent_executions = var.lambda_config.reserved_concurrent_executions
   architectures                  = [var.lambda_config.architecture]
+  layers                         = var.lambda_config.layers
+
+  tracing_config {
+    mode = var.lambda_config.tracing_mode
+  }
 
   filename = module.lambda_artifact_webhook[0].artifact_package_path
 
@@ -295,6 +325,14 @@ resource "aws_apigatewayv2_route" "healthz" {
   target    = "integrations/${aws_apigatewayv2_integration.webhook[0].id}"
 }
 
+resource "aws_apigatewayv2_route" "version" {
+  count = local.enabled && var.api_gateway_config.enabled ? 1 : 0
+
+  api_id    = aws_apigatewayv2_api.this[0].id
+  route_key = "GET /version"
+  target    = "integrations/${aws_apigatewayv2_integration.webhook[0].id}"
+}
+
 resource "aws_apigatewayv2_route" "setup" {
   count = local.enabled && var.api_gateway_config.enabled && var.installer_config.enabled ? 1 : 0
 
@@ -339,6 +377,96 @@ resource "aws_lambda_permission" "webhook" {
   source_arn    = "${aws_apigatewayv2_api.this[0].execution_arn}/*/*"
 }
 
+# ============================================================= function url ===
+
+resource "aws_lambda_function_url" "sts" {
+  count = local.enabled && var.function_url_config.enabled ? 1 : 0
+
+  function_name      = aws_lambda_function.sts[0].function_name
+  authorization_type = var.function_url_config.authorization_type
+}
+
+resource "aws_lambda_function_url" "webhook" {
+  count = local.enabled && var.function_url_config.enabled ? 1 : 0
+
+  function_name      = aws_lambda_function.webhook[0].function_name
+  authorization_type = var.function_url_config.authorization_type
+}
+
+# ================================================================== warm-up ===
+
+# scheduled events load the configuration and fill the caches of a warm
+# instance, so requests don't pay for it after a cold start
+resource "aws_cloudwatch_event_rule" "warmup" {
+  count = local.enabled && var.lambda_config.warmup_schedule != "" ? 1 : 0
+
+  name                = "${module.this.id}-warmup"
+  description         = "Keeps the octo-sts functions warm"
+  schedule_expression = var.lambda_config.warmup_schedule
+  tags                = module.this.tags
+}
+
+resource "aws_cloudwatch_event_target" "warmup" {
+  for_each = local.enabled && var.lambda_config.warmup_schedule != "" ? {
+    sts     = aws_lambda_function.sts[0].arn
+    webhook = aws_lambda_function.webhook[0].arn
+  } : {}
+
+  rule = aws_cloudwatch_event_rule.warmup[0].name
+  arn  = each.value
+}
+
+resource "aws_lambda_permission" "warmup" {
+  for_each = local.enabled && var.lambda_config.warmup_schedule != "" ? {
+    sts     = aws_lambda_function.sts[0].function_name
+    webhook = aws_lambda_function.webhook[0].function_name
+  } : {}
+
+  statement_id  = "AllowExecutionFromEventBridge"
+  action        = "lambda:InvokeFunction"
+  function_name = each.value
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.warmup[0].arn
+}
+
+# ==================================================================== queue ===
+
+# webhook deliveries queued on SQS are processed by the webhook function, and
+# only the failed ones are redelivered or moved to the queue's dead-letter
+# queue. the function reports them as batch item failures, which are ignored
+# unless ReportBatchItemFailures is enabled
+resource "aws_lambda_event_source_mapping" "webhook_queue" {
+  count = local.enabled && var.webhook_config.queue_arn != "" ? 1 : 0
+
+  event_source_arn        = var.webhook_config.queue_arn
+  function_name           = aws_lambda_function.webhook[0].arn
+  batch_size              = var.webhook_config.queue_batch_size
+  function_response_types = ["ReportBatchItemFailures"]
+}
+
+# ================================================================= dynamodb ===
+
+# holds the installer status and the lock that serializes setups and disables
+# across concurrent lambda instances
+resource "aws_dynamodb_table" "status" {
+  count = local.status_table_enabled ? 1 : 0
+
+  name         = "${module.this.id}-status"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "id"
+
+  attribute {
+    name = "id"
+    type = "S"
+  }
+
+  point_in_time_recovery {
+    enabled = true
+  }
+
+  tags = module.this.tags
+}
+
 # ====================================================================== iam ===
 
 resource "aws_iam_role" "lambda" {
@@ -375,6 +503,71 @@ data "aws_iam_policy_document" "lambda" {
     ]
   }
 
+  dynamic "statement" {
+    for_each = var.lambda_config.tracing_mode == "Active" ? [1] : []
+
+    content {
+      sid    = "XRayWriteAccess"
+      effect = "Allow"
+      actions = [
+        "xray:PutTraceSegments",
+        "xray:PutTelemetryRecords"
+      ]
+      resources = ["*"]
+    }
+  }
+
+  dynamic "statement" {
+    for_each = var.audit_config.sink == "firehose" ? [1] : []
+
+    content {
+      sid       = "AuditFirehoseAccess"
+      effect    = "Allow"
+      actions   = ["firehose:PutRecordBatch"]
+      resources = ["arn:${local.aws_partition}:firehose:${local.aws_region_name}:${local.aws_account_id}:deliverystream/${var.audit_config.firehose_stream}"]
+    }
+  }
+
+  dynamic "statement" {
+    for_each = var.audit_config.sink == "cloudwatch-logs" ? [1] : []
+
+    content {
+      sid    = "AuditLogsAccess"
+      effect = "Allow"
+      actions = [
+        "logs:CreateLogStream",
+        "logs:PutLogEvents"
+      ]
+      resources = ["arn:${local.aws_partition}:logs:${local.aws_region_name}:${local.aws_account_id}:log-group:${var.audit_config.log_group}:*"]
+    }
+  }
+
+  dynamic "statement" {
+    for_each = var.audit_config.sink == "s3" ? [1] : []
+
+    content {
+      sid       = "AuditS3Access"
+      effect    = "Allow"
+      actions   = ["s3:PutObject"]
+      resources = ["arn:${local.aws_partition}:s3:::${var.audit_config.s3_bucket}/${var.audit_config.s3_prefix}*"]
+    }
+  }
+
+  dynamic "statement" {
+    for_each = var.webhook_config.queue_arn != "" ? [1] : []
+
+    content {
+      sid    = "SQSWebhookQueueAccess"
+      effect = "Allow"
+      actions = [
+        "sqs:ReceiveMessage",
+        "sqs:DeleteMessage",
+        "sqs:GetQueueAttributes"
+      ]
+      resources = [var.webhook_config.queue_arn]
+    }
+  }
+
   dynamic "statement" {
     for_each = length(var.ssm_parameter_arns) > 0 ? [1] : []
 
@@ -385,7 +578,40 @@ data "aws_iam_policy_document" "lambda" {
         "ssm:GetParameter",
         "ssm:GetParameters"
       ]
-      resources = var.ssm_parameter_arns
+      # IAM matches the parameter itself, without a JSON key or a pinned
+      # version or label.
+      resources = distinct([
+        for arn in var.ssm_parameter_arns : replace(split("#", arn)[0], "/^(arn:[^:]+:ssm:[^:]*:[^:]*:parameter/[^:]+):.*$/", "$1")
+      ])
+    }
+  }
+
+  dynamic "statement" {
+    for_each = length(var.secretsmanager_secret_arns) > 0 ? [1] : []
+
+    content {
+      sid    = "SecretsManagerSecretReadAccess"
+      effect = "Allow"
+      actions = [
+        "secretsmanager:GetSecretValue"
+      ]
+      resources = distinct([for arn in var.secretsmanager_secret_arns : split("#", arn)[0]])
+    }
+  }
+
+  dynamic "statement" {
+    for_each = var.resolver_ssm_path != "" ? [1] : []
+
+    content {
+      sid    = "SSMParameterPathReadAccess"
+      effect = "Allow"
+      actions = [
+        "ssm:GetParametersByPath"
+      ]
+      resources = [
+        "arn:${local.aws_partition}:ssm:${local.aws_region_name}:${local.aws_account_id}:parameter${trimsuffix(var.resolver_ssm_path, "/")}",
+        "arn:${local.aws_partition}:ssm:${local.aws_region_name}:${local.aws_account_id}:parameter${trimsuffix(var.resolver_ssm_path, "/")}/*"
+      ]
     }
   }
 
@@ -397,10 +623,12 @@ data "aws_iam_policy_document" "lambda" {
       effect = "Allow"
       actions = [
         "ssm:GetParameter",
-        "ssm:GetParameters"
+        "ssm:GetParameters",
+        "ssm:GetParametersByPath"
       ]
       resources = [
-        "arn:${local.aws_partition}:ssm:${local.aws_region_name}:${local.aws_account_id}:parameter${var.installer_config.ssm_parameter_prefix}*"
+        "arn:${local.aws_partition}:ssm:${local.aws_region_name}:${local.aws_account_id}:parameter${var.installer_config.ssm_parameter_prefix}*",
+        "arn:${local.aws_partition}:ssm:${local.aws_region_name}:${local.aws_account_id}:parameter${trimsuffix(var.installer_config.ssm_parameter_prefix, "/")}"
       ]
     }
   }
@@ -420,6 +648,42 @@ data "aws_iam_policy_document" "lambda" {
       ]
     }
   }
+
+  dynamic "statement" {
+    for_each = var.installer_config.enabled && var.installer_config.ssm_assume_role_arn != "" ? [1] : []
+
+    content {
+      sid       = "SSMAssumeRole"
+      effect    = "Allow"
+      actions   = ["sts:AssumeRole"]
+      resources = [var.installer_config.ssm_assume_role_arn]
+    }
+  }
+
+  dynamic "statement" {
+    for_each = var.resolver_assume_role.arn != "" ? [1] : []
+
+    content {
+      sid       = "ResolverAssumeRole"
+      effect    = "Allow"
+      actions   = ["sts:AssumeRole"]
+      resources = [var.resolver_assume_role.arn]
+    }
+  }
+
+  dynamic "statement" {
+    for_each = local.status_table_enabled ? [1] : []
+
+    content {
+      sid    = "DynamoDBStatusAccess"
+      effect = "Allow"
+      actions = [
+        "dynamodb:GetItem",
+        "dynamodb:UpdateItem"
+      ]
+      resources = [aws_dynamodb_table.status[0].arn]
+    }
+  }
 }
 
 resource "aws_iam_role_policy" "lambda" {
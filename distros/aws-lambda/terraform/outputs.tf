@@ -98,12 +98,12 @@ output "api_gateway_execution_arn" {
 
 output "sts_url" {
   description = "Full URL for STS token exchange endpoint"
-  value       = try("${trimsuffix(aws_apigatewayv2_stage.this[0].invoke_url, "/")}/sts/exchange", null)
+  value       = try("${trimsuffix(aws_apigatewayv2_stage.this[0].invoke_url, "/")}/sts/exchange", "${trimsuffix(aws_lambda_function_url.sts[0].function_url, "/")}/exchange", null)
 }
 
 output "webhook_url" {
   description = "Full webhook URL to configure in GitHub App settings"
-  value       = try("${trimsuffix(aws_apigatewayv2_stage.this[0].invoke_url, "/")}/webhook", null)
+  value       = try("${trimsuffix(aws_apigatewayv2_stage.this[0].invoke_url, "/")}/webhook", "${trimsuffix(aws_lambda_function_url.webhook[0].function_url, "/")}/webhook", null)
 }
 
 output "webhook_secret" {
@@ -119,14 +119,31 @@ output "sts_domain" {
   value       = local.sts_domain
 }
 
+# ------------------------------------------------------------- function url ---
+
+output "sts_function_url" {
+  description = "Function URL of the STS Lambda function (when enabled)"
+  value       = try(aws_lambda_function_url.sts[0].function_url, null)
+}
+
+output "webhook_function_url" {
+  description = "Function URL of the Webhook Lambda function (when enabled)"
+  value       = try(aws_lambda_function_url.webhook[0].function_url, null)
+}
+
 # ---------------------------------------------------------------- installer ---
 
 output "setup_url" {
   description = "URL for the setup wizard (only available when installer is enabled)"
-  value       = var.installer_config.enabled ? try("${trimsuffix(aws_apigatewayv2_stage.this[0].invoke_url, "/")}/setup", null) : null
+  value       = var.installer_config.enabled ? try("${trimsuffix(aws_apigatewayv2_stage.this[0].invoke_url, "/")}/setup", "${trimsuffix(aws_lambda_function_url.webhook[0].function_url, "/")}/setup", null) : null
 }
 
 output "healthz_url" {
   description = "URL for health check endpoint"
-  value       = try("${trimsuffix(aws_apigatewayv2_stage.this[0].invoke_url, "/")}/healthz", null)
+  value       = try("${trimsuffix(aws_apigatewayv2_stage.this[0].invoke_url, "/")}/healthz", "${trimsuffix(aws_lambda_function_url.webhook[0].function_url, "/")}/healthz", null)
+}
+
+output "status_table_name" {
+  description = "Name of the DynamoDB table holding the installer status (only available when status_table_enabled is true)"
+  value       = try(aws_dynamodb_table.status[0].name, null)
 }
@@ -29,6 +29,9 @@ variable "lambda_config" {
     architecture                   = optional(string, "arm64")
     reserved_concurrent_executions = optional(number, -1)
     log_level                      = optional(string, "info")
+    layers                         = optional(list(string), [])
+    warmup_schedule                = optional(string, "")
+    tracing_mode                   = optional(string, "PassThrough")
   })
   default = {}
 
@@ -51,6 +54,11 @@ variable "lambda_config" {
     condition     = contains(["debug", "info", "warn", "error"], var.lambda_config.log_level)
     error_message = "Lambda log_level must be one of: debug, info, warn, error."
   }
+
+  validation {
+    condition     = contains(["Active", "PassThrough"], var.lambda_config.tracing_mode)
+    error_message = "Lambda tracing_mode must be 'Active' or 'PassThrough'."
+  }
 }
 
 variable "lambda_log_retention_days" {
@@ -88,9 +96,27 @@ variable "github_app_config" {
 variable "sts_config" {
   description = "STS service configuration."
   type = object({
-    domain = optional(string, "") # Custom domain for audience validation. If empty, uses API Gateway endpoint hostname.
+    domain             = optional(string, "")       # Custom domain for audience validation. If empty, uses API Gateway endpoint hostname.
+    additional_domains = optional(list(string), []) # Domains also accepted as the audience, e.g. during a DNS migration.
+  })
+  default = {}
+}
+
+variable "audit_config" {
+  description = "Shipping of the STS function's token exchange audit events. Auditing is disabled when sink is empty."
+  type = object({
+    sink            = optional(string, "")       # firehose, cloudwatch-logs, or s3.
+    firehose_stream = optional(string, "")       # Name of the Firehose stream of the firehose sink.
+    log_group       = optional(string, "")       # Name of the log group of the cloudwatch-logs sink.
+    s3_bucket       = optional(string, "")       # Name of the bucket of the s3 sink.
+    s3_prefix       = optional(string, "audit/") # Key prefix of the s3 sink's objects.
   })
   default = {}
+
+  validation {
+    condition     = contains(["", "firehose", "cloudwatch-logs", "s3"], var.audit_config.sink)
+    error_message = "audit_config.sink must be firehose, cloudwatch-logs, or s3 when specified."
+  }
 }
 
 # ------------------------------------------------------------------ webhook ---
@@ -99,6 +125,8 @@ variable "webhook_config" {
   description = "Webhook service configuration."
   type = object({
     organization_filter = optional(string, "") # Comma-separated list of organizations to process webhooks for. Empty means all.
+    queue_arn           = optional(string, "") # ARN of an SQS queue of webhook deliveries to process. Empty means none.
+    queue_batch_size    = optional(number, 10) # Maximum number of queued deliveries per invocation.
   })
   default = {}
 }
@@ -114,6 +142,28 @@ variable "api_gateway_config" {
   default = {}
 }
 
+# ------------------------------------------------------------- function url ---
+
+variable "function_url_config" {
+  description = <<-EOT
+    Configuration for Lambda function URLs. When enabled, each function gets its
+    own URL and can be used without API Gateway: the STS function serves token
+    exchanges from the root of its URL and the webhook function serves /webhook,
+    /healthz, and the installer. Unless sts_config.domain is set, the STS
+    function uses the domain of its function URL as the audience.
+  EOT
+  type = object({
+    enabled            = optional(bool, false)
+    authorization_type = optional(string, "NONE")
+  })
+  default = {}
+
+  validation {
+    condition     = contains(["NONE", "AWS_IAM"], var.function_url_config.authorization_type)
+    error_message = "function_url_config.authorization_type must be NONE or AWS_IAM."
+  }
+}
+
 # ---------------------------------------------------------------- installer ---
 
 variable "installer_config" {
@@ -135,6 +185,9 @@ variable "installer_config" {
     enabled              = optional(bool, false)                  # Enable the setup wizard
     ssm_parameter_prefix = optional(string, "")                   # Required when enabled, e.g., "/octo-sts/prod/"
     kms_key_id           = optional(string, "")                   # Optional KMS key for SSM parameter encryption
+    ssm_tier             = optional(string, "")                   # Optional SSM parameter tier: Standard, Advanced, or Intelligent-Tiering
+    ssm_assume_role_arn  = optional(string, "")                   # Optional role to assume for writing parameters in another account
+    status_table_enabled = optional(bool, false)                  # Keep the installer status in a DynamoDB table to serialize setups across instances
     github_url           = optional(string, "https://github.com") # GitHub URL (for GitHub Enterprise Server)
     github_org           = optional(string, "")                   # Organization to create the GitHub App under (empty = personal account)
   })
@@ -149,6 +202,16 @@ variable "installer_config" {
     condition     = var.installer_config.ssm_parameter_prefix == "" || startswith(var.installer_config.ssm_parameter_prefix, "/")
     error_message = "ssm_parameter_prefix must start with '/' when specified."
   }
+
+  validation {
+    condition     = contains(["", "Standard", "Advanced", "Intelligent-Tiering"], var.installer_config.ssm_tier)
+    error_message = "ssm_tier must be Standard, Advanced, or Intelligent-Tiering when specified."
+  }
+
+  validation {
+    condition     = var.installer_config.ssm_assume_role_arn == "" || can(regex("^arn:[^:]+:iam::[0-9]{12}:role/", var.installer_config.ssm_assume_role_arn))
+    error_message = "ssm_assume_role_arn must be an IAM role ARN when specified."
+  }
 }
 
 variable "api_gateway_cors_config" {
@@ -175,3 +238,34 @@ variable "ssm_parameter_arns" {
   type        = list(string)
   default     = []
 }
+
+variable "resolver_ssm_path" {
+  description = "SSM path, e.g. /octo-sts/prod/, whose parameters the Lambda functions load into their environment at runtime, named after the rest of the parameter name."
+  type        = string
+  default     = ""
+
+  validation {
+    condition     = var.resolver_ssm_path == "" || startswith(var.resolver_ssm_path, "/")
+    error_message = "resolver_ssm_path must start with '/' when specified."
+  }
+}
+
+variable "resolver_assume_role" {
+  description = "Role the Lambda functions assume to resolve SSM and Secrets Manager ARNs, e.g. to read parameters shared from a central security account."
+  type = object({
+    arn         = optional(string, "")
+    external_id = optional(string, "")
+  })
+  default = {}
+
+  validation {
+    condition     = var.resolver_assume_role.arn == "" || can(regex("^arn:[^:]+:iam::[0-9]{12}:role/", var.resolver_assume_role.arn))
+    error_message = "resolver_assume_role.arn must be an IAM role ARN when specified."
+  }
+}
+
+variable "secretsmanager_secret_arns" {
+  description = "List of Secrets Manager secret ARNs that Lambda functions can access for secrets resolution at runtime. A `#<key>` suffix is ignored."
+  type        = list(string)
+  default     = []
+}
@@ -0,0 +1,195 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package admin implements runtime operations for operators: flushing the
+// STS caches, reloading the configuration, and inspecting the configuration
+// and the cached installations. The operations are served over HTTP under
+// PathPrefix to holders of the admin token, and to direct Lambda invocations
+// through WrapLambda, which IAM already authorizes.
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+	"github.com/cruxstack/octo-sts-distros/internal/sts"
+)
+
+// EnvToken is the bearer token required by the HTTP admin API, which is
+// disabled when it is unset.
+const EnvToken = "ADMIN_TOKEN"
+
+// Actions that can be performed.
+const (
+	ActionFlushCache    = "flush-cache"
+	ActionReload        = "reload"
+	ActionConfig        = "config"
+	ActionInstallations = "installations"
+	ActionLastReload    = "last-reload"
+	ActionReloadHistory = "reload-history"
+)
+
+var (
+	// ErrUnknownAction is returned for an action that doesn't exist.
+	ErrUnknownAction = errors.New("unknown action")
+
+	// ErrUnavailable is returned for an action the service doesn't support,
+	// e.g. flushing the STS caches of the webhook service.
+	ErrUnavailable = errors.New("action not available in this service")
+)
+
+// Config configures the admin operations. Operations whose function is nil
+// are unavailable.
+type Config struct {
+	// Token authenticates HTTP requests as "Authorization: Bearer <token>".
+	// The HTTP API is disabled without one.
+	Token string
+
+	// Reload reloads the configuration, e.g. ghappsetup.Runtime.Reload.
+	Reload func(ctx context.Context) error
+
+	// FlushCaches empties the caches and returns the number of entries
+	// dropped from each, by cache name.
+	FlushCaches func() map[string]int
+
+	// Installations returns the cached installation ID of each owner.
+	Installations func() map[string]int64
+
+	// LastReload returns the status of the last reload, e.g.
+	// shared.Reloader.LastReload.
+	LastReload func() *shared.ReloadStatus
+
+	// ReloadHistory returns the last reloads, newest first, e.g.
+	// shared.Reloader.History.
+	ReloadHistory func() []*shared.ReloadStatus
+}
+
+// Admin performs admin operations.
+type Admin struct {
+	token         string
+	reload        func(ctx context.Context) error
+	flushCaches   func() map[string]int
+	installations func() map[string]int64
+	lastReload    func() *shared.ReloadStatus
+	reloadHistory func() []*shared.ReloadStatus
+}
+
+// New creates an Admin with the given configuration.
+func New(cfg Config) *Admin {
+	return &Admin{
+		token:         cfg.Token,
+		reload:        cfg.Reload,
+		flushCaches:   cfg.FlushCaches,
+		installations: cfg.Installations,
+		lastReload:    cfg.LastReload,
+		reloadHistory: cfg.ReloadHistory,
+	}
+}
+
+// FlushSTSCaches flushes the STS caches, for Config.FlushCaches.
+func FlushSTSCaches() map[string]int {
+	installations, policies := sts.FlushCaches()
+	return map[string]int{
+		"installations":  installations,
+		"trust_policies": policies,
+	}
+}
+
+// Do performs action and returns its result, which encodes to JSON.
+func (a *Admin) Do(ctx context.Context, action string) (any, error) {
+	log := clog.FromContext(ctx)
+
+	switch action {
+	case ActionFlushCache:
+		if a.flushCaches == nil {
+			return nil, ErrUnavailable
+		}
+		flushed := a.flushCaches()
+		log.Infof("[admin] flushed caches: %v", flushed)
+		return map[string]any{"flushed": flushed}, nil
+
+	case ActionReload:
+		if a.reload == nil {
+			return nil, ErrUnavailable
+		}
+		log.Infof("[admin] reloading configuration")
+		if err := a.reload(ctx); err != nil {
+			return nil, fmt.Errorf("reload failed: %w", err)
+		}
+		return map[string]any{"reloaded": true}, nil
+
+	case ActionConfig:
+		return Summarize(), nil
+
+	case ActionInstallations:
+		if a.installations == nil {
+			return nil, ErrUnavailable
+		}
+		return map[string]any{"installations": a.installations()}, nil
+
+	case ActionLastReload:
+		if a.lastReload == nil {
+			return nil, ErrUnavailable
+		}
+		return map[string]any{"last_reload": summarizeReload(a.lastReload())}, nil
+
+	case ActionReloadHistory:
+		if a.reloadHistory == nil {
+			return nil, ErrUnavailable
+		}
+		reloads := []*ReloadSummary{}
+		for _, status := range a.reloadHistory() {
+			reloads = append(reloads, summarizeReload(status))
+		}
+		return map[string]any{"reloads": reloads}, nil
+
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAction, action)
+	}
+}
+
+// ReloadSummary is a reload as reported by ActionLastReload and
+// ActionReloadHistory. Unlike /readyz, it includes what triggered the
+// reload and why each failed hook failed; the configuration is left as it
+// was before a failed reload.
+type ReloadSummary struct {
+	Time       time.Time       `json:"time"`
+	DurationMS int64           `json:"duration_ms"`
+	Status     string          `json:"status"`
+	Failures   []ReloadFailure `json:"failures,omitempty"`
+	Reloads    int             `json:"reloads"`
+	Sources    []string        `json:"sources,omitempty"`
+}
+
+// ReloadFailure is a hook that failed in a reload, and its error.
+type ReloadFailure struct {
+	Hook  string `json:"hook"`
+	Error string `json:"error"`
+}
+
+// summarizeReload returns the summary of status, or nil if there was no
+// reload yet.
+func summarizeReload(status *shared.ReloadStatus) *ReloadSummary {
+	if status == nil {
+		return nil
+	}
+	summary := &ReloadSummary{
+		Time:       status.Time.UTC(),
+		DurationMS: status.Duration.Milliseconds(),
+		Status:     "ok",
+		Reloads:    status.Reloads,
+		Sources:    status.Sources,
+	}
+	if !status.OK {
+		summary.Status = "failed"
+	}
+	for _, e := range status.Errors {
+		summary.Failures = append(summary.Failures, ReloadFailure{Hook: e.Hook, Error: e.Err.Error()})
+	}
+	return summary
+}
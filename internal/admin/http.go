@@ -0,0 +1,92 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// PathPrefix is where the HTTP admin API is mounted.
+const PathPrefix = "/-/admin/"
+
+// ReloadPath triggers a reload like POST PathPrefix+"reload", at a path
+// short enough for orchestrator hooks and scripts that can't send SIGHUP.
+// It takes the admin token too.
+const ReloadPath = "/-/reload"
+
+// routes maps the method and path of each HTTP endpoint to its action.
+var routes = map[string]string{
+	"POST " + PathPrefix + "cache/flush":        ActionFlushCache,
+	"POST " + PathPrefix + "reload":             ActionReload,
+	"GET " + PathPrefix + "config":              ActionConfig,
+	"GET " + PathPrefix + "cache/installations": ActionInstallations,
+	"GET " + PathPrefix + "reload":              ActionLastReload,
+	"GET " + PathPrefix + "reload/history":      ActionReloadHistory,
+	"POST " + ReloadPath:                        ActionReload,
+}
+
+// Enabled reports whether the HTTP admin API is enabled, i.e. a token is
+// configured.
+func (a *Admin) Enabled() bool {
+	return a.token != ""
+}
+
+// ServeHTTP serves the admin API to requests bearing the admin token. Every
+// path answers 404 while the API is disabled. Mount it at both PathPrefix
+// and ReloadPath, e.g. with Register.
+func (a *Admin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !a.Enabled() {
+		http.NotFound(w, r)
+		return
+	}
+
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(a.token)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+		writeJSON(w, r, http.StatusUnauthorized, errorBody("unauthorized"))
+		return
+	}
+
+	action, ok := routes[r.Method+" "+r.URL.Path]
+	if !ok {
+		writeJSON(w, r, http.StatusNotFound, errorBody("not found"))
+		return
+	}
+
+	result, err := a.Do(r.Context(), action)
+	switch {
+	case errors.Is(err, ErrUnavailable), errors.Is(err, ErrUnknownAction):
+		writeJSON(w, r, http.StatusNotFound, errorBody(err.Error()))
+	case err != nil:
+		clog.FromContext(r.Context()).Errorf("[admin] %s failed: %v", action, err)
+		writeJSON(w, r, http.StatusInternalServerError, errorBody(err.Error()))
+	default:
+		writeJSON(w, r, http.StatusOK, result)
+	}
+}
+
+// Register mounts a at PathPrefix and ReloadPath of mux.
+func (a *Admin) Register(mux *http.ServeMux) {
+	mux.Handle(PathPrefix, a)
+	mux.Handle(ReloadPath, a)
+}
+
+func errorBody(message string) map[string]string {
+	return map[string]string{"error": message}
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		clog.FromContext(r.Context()).Errorf("failed to write response body: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Invocation is the payload of a direct Lambda invocation that performs an
+// admin action, e.g.
+//
+//	aws lambda invoke --function-name octo-sts-sts \
+//	  --cli-binary-format raw-in-base64-out \
+//	  --payload '{"admin":{"action":"flush-cache"}}' out.json
+type Invocation struct {
+	Admin *struct {
+		Action string `json:"action"`
+	} `json:"admin"`
+}
+
+// InvocationResult is the response to an Invocation.
+type InvocationResult struct {
+	Action string `json:"action"`
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// LambdaHandler handles a raw Lambda event.
+type LambdaHandler func(ctx context.Context, payload json.RawMessage) (any, error)
+
+// WrapLambda returns a Lambda handler that performs the admin action of an
+// Invocation and passes every other event to next. Only direct invocations
+// carry the top-level "admin" field; API Gateway, function URL, and ALB
+// events nest client input in their body, so clients cannot reach it.
+func WrapLambda(a *Admin, next LambdaHandler) LambdaHandler {
+	return func(ctx context.Context, payload json.RawMessage) (any, error) {
+		var inv Invocation
+		if err := json.Unmarshal(payload, &inv); err != nil || inv.Admin == nil {
+			return next(ctx, payload)
+		}
+
+		action := inv.Admin.Action
+		result, err := a.Do(ctx, action)
+		if err != nil {
+			return InvocationResult{Action: action, Error: err.Error()}, nil
+		}
+		return InvocationResult{Action: action, Result: result}, nil
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package admin
+
+import (
+	"os"
+
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+	"github.com/cruxstack/octo-sts-distros/internal/version"
+)
+
+// redacted replaces the value of a secret that is set.
+const redacted = "REDACTED"
+
+// summaryKeys are the settings reported by the config summary.
+var summaryKeys = []string{
+	configstore.EnvGitHubAppID,
+	configstore.EnvGitHubAppSlug,
+	configstore.EnvGitHubAppHTMLURL,
+	configstore.EnvGitHubClientID,
+	configstore.EnvGitHubAppHost,
+	configstore.EnvGitHubAppCreatedAt,
+	configstore.EnvGitHubAppCreatedBy,
+	configstore.EnvGitHubAppInstallerVersion,
+	configstore.EnvGitHubAppInstallerEnabled,
+	configstore.EnvSTSDomain,
+	configstore.EnvStorageMode,
+	configstore.EnvStorageDir,
+	configstore.EnvAWSSSMParameterPfx,
+	"GITHUB_APP_IDS",
+	"KMS_KEYS",
+	"GITHUB_WEBHOOK_ORGANIZATION_FILTER",
+	shared.EnvLogFormat,
+	shared.EnvRateLimit,
+	shared.EnvRateLimitPerClient,
+	shared.EnvSTSMaxBodySize,
+	shared.EnvWebhookMaxBodySize,
+}
+
+// secretKeys are the settings whose values are redacted; the summary only
+// tells whether they are set.
+var secretKeys = []string{
+	configstore.EnvGitHubAppPrivateKey,
+	configstore.EnvGitHubWebhookSecret,
+	configstore.EnvGitHubClientSecret,
+	"APP_SECRET_CERTIFICATE_ENV_VAR",
+	shared.EnvMetricsToken,
+	EnvToken,
+}
+
+// Summary describes the running configuration without its secrets.
+type Summary struct {
+	Version  version.Info      `json:"version"`
+	Settings map[string]string `json:"settings"`
+}
+
+// Summarize returns the settings the service is running with. Settings that
+// are unset are left out, and secrets are reported as REDACTED.
+func Summarize() Summary {
+	settings := make(map[string]string)
+	for _, key := range summaryKeys {
+		if value := os.Getenv(key); value != "" {
+			settings[key] = value
+		}
+	}
+	for _, key := range secretKeys {
+		if os.Getenv(key) != "" {
+			settings[key] = redacted
+		}
+	}
+	return Summary{Version: version.Get(), Settings: settings}
+}
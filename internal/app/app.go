@@ -25,8 +25,16 @@ type Config struct {
 	// For example, if BasePath is "/webhook", then a request to "/webhook/foo"
 	// will be routed as if it were "/foo".
 	BasePath string
+
+	// MaxBodySize is the largest request body accepted, in bytes. Larger
+	// requests are rejected with 413. Defaults to DefaultMaxBodySize.
+	MaxBodySize int64
 }
 
+// DefaultMaxBodySize is the default limit of webhook payloads, which GitHub
+// caps at 25 MB.
+const DefaultMaxBodySize int64 = 25 << 20
+
 // App handles GitHub App webhook requests in a runtime-agnostic way.
 // It provides a unified interface that works with both standard HTTP servers
 // and AWS API Gateway v2 with Lambda.
@@ -35,6 +43,7 @@ type App struct {
 	webhookSecret [][]byte
 	organizations []string
 	basePath      string
+	maxBodySize   int64
 }
 
 // New creates a new App instance with the given GitHub App transport and configuration.
@@ -54,10 +63,16 @@ func New(transport *ghinstallation.AppsTransport, cfg Config) (*App, error) {
 	// Normalize base path: ensure no trailing slash
 	basePath := strings.TrimSuffix(cfg.BasePath, "/")
 
+	maxBodySize := cfg.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+
 	return &App{
 		transport:     transport,
 		webhookSecret: cfg.WebhookSecrets,
 		organizations: cfg.Organizations,
 		basePath:      basePath,
+		maxBodySize:   maxBodySize,
 	}, nil
 }
@@ -18,6 +18,7 @@ import (
 	"net/http/httputil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
@@ -267,6 +268,70 @@ func TestHandleRequestRouting(t *testing.T) {
 	}
 }
 
+func TestWebhookResponseHeaders(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := ghinstallation.NewAppsTransportFromPrivateKey(http.DefaultTransport, 1234, key)
+
+	app, err := New(tr, Config{
+		WebhookSecrets: [][]byte{[]byte("secret")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The headers the webhook handler sets reach the response
+	resp := app.HandleRequest(slogtest.Context(t), shared.Request{
+		Type:   shared.RequestTypeHTTP,
+		Method: http.MethodPost,
+		Path:   "/",
+		Body:   []byte("{}"),
+	})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("HandleRequest() status = %d, expected %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	if got := resp.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain") {
+		t.Errorf("Content-Type = %q, expected the handler's text/plain", got)
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := ghinstallation.NewAppsTransportFromPrivateKey(http.DefaultTransport, 1234, key)
+
+	app, err := New(tr, Config{
+		WebhookSecrets: [][]byte{[]byte("secret")},
+		MaxBodySize:    16,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := slogtest.Context(t)
+	body := strings.Repeat("x", 17)
+
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequestWithContext(ctx, http.MethodPost, "/webhook", strings.NewReader(body)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("ServeHTTP() status = %d, expected %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	resp := app.HandleRequest(ctx, shared.Request{
+		Type:   shared.RequestTypeHTTP,
+		Method: http.MethodPost,
+		Path:   "/webhook",
+		Body:   []byte(body),
+	})
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("HandleRequest() status = %d, expected %d", resp.StatusCode, http.StatusRequestEntityTooLarge)
+	}
+}
+
 func TestResponseHelpers(t *testing.T) {
 	t.Run("OKResponse", func(t *testing.T) {
 		resp := OKResponse()
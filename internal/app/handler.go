@@ -6,9 +6,10 @@ package app
 import (
 	"bytes"
 	"context"
-	"io"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/chainguard-dev/clog"
 
@@ -38,10 +39,19 @@ func (a *App) HandleRequest(ctx context.Context, req shared.Request) shared.Resp
 	)
 	ctx = clog.WithLogger(ctx, log)
 
+	// Bodies not read through ServeHTTP, e.g. from Lambda events, are
+	// checked here
+	if int64(len(req.Body)) > a.maxBodySize {
+		return ErrorResponse(http.StatusRequestEntityTooLarge, "request body too large")
+	}
+
 	// Route based on method and path
 	switch {
 	case req.Method == http.MethodPost && (path == "/" || path == "" || path == "/webhook"):
-		return a.handleWebhook(ctx, req)
+		start := time.Now()
+		resp := a.handleWebhook(ctx, req)
+		observeWebhook(req.Headers[HeaderEvent], resp.StatusCode, time.Since(start).Seconds())
+		return resp
 	default:
 		return ErrorResponse(http.StatusNotFound, "not found")
 	}
@@ -51,7 +61,11 @@ func (a *App) HandleRequest(ctx context.Context, req shared.Request) shared.Resp
 // directly as an HTTP handler without the Request/Response abstraction.
 func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Read body
-	body, err := io.ReadAll(r.Body)
+	body, err := shared.ReadBody(w, r, a.maxBodySize)
+	if errors.Is(err, shared.ErrBodyTooLarge) {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
 	if err != nil {
 		http.Error(w, "failed to read request body", http.StatusBadRequest)
 		return
@@ -76,17 +90,8 @@ func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Handle request
 	resp := a.HandleRequest(r.Context(), req)
 
-	// Write response headers
-	for k, v := range resp.Headers {
-		w.Header().Set(k, v)
-	}
-
-	// Write status code and body
-	w.WriteHeader(resp.StatusCode)
-	if resp.Body != nil {
-		if _, err := w.Write(resp.Body); err != nil {
-			clog.FromContext(r.Context()).Errorf("failed to write response body: %v", err)
-		}
+	if err := resp.Write(w); err != nil {
+		clog.FromContext(r.Context()).Errorf("failed to write response body: %v", err)
 	}
 }
 
@@ -129,11 +134,7 @@ func (a *App) handleWebhook(ctx context.Context, req shared.Request) shared.Resp
 	// Delegate to existing webhook handler
 	validator.ServeHTTP(recorder, httpReq)
 
-	return shared.Response{
-		StatusCode: recorder.statusCode,
-		Headers:    recorder.headers,
-		Body:       recorder.body.Bytes(),
-	}
+	return shared.NewResponse(recorder.statusCode, recorder.headers, recorder.body.Bytes())
 }
 
 // toHTTPRequest converts a shared.Request to a standard http.Request.
@@ -154,7 +155,7 @@ func (a *App) toHTTPRequest(ctx context.Context, req shared.Request) (*http.Requ
 // responseRecorder implements http.ResponseWriter to capture the response
 // from the webhook handler.
 type responseRecorder struct {
-	headers    map[string]string
+	headers    http.Header
 	statusCode int
 	body       *bytes.Buffer
 }
@@ -162,20 +163,15 @@ type responseRecorder struct {
 // newResponseRecorder creates a new responseRecorder with default values.
 func newResponseRecorder() *responseRecorder {
 	return &responseRecorder{
-		headers:    make(map[string]string),
+		headers:    make(http.Header),
 		statusCode: http.StatusOK,
 		body:       new(bytes.Buffer),
 	}
 }
 
-// Header returns the response headers as an http.Header.
-// Note: This is a simplified implementation that only supports single values per key.
+// Header returns the response headers, which the handler may add to.
 func (r *responseRecorder) Header() http.Header {
-	h := make(http.Header)
-	for k, v := range r.headers {
-		h.Set(k, v)
-	}
-	return h
+	return r.headers
 }
 
 // Write writes the data to the response body buffer.
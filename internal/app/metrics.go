@@ -0,0 +1,35 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	webhookEventsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "octo_sts_webhook_events_total",
+		Help: "GitHub webhook deliveries, by event type and response status code.",
+	}, []string{"event", "code"})
+	webhookDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "octo_sts_webhook_duration_seconds",
+		Help:    "Duration of GitHub webhook deliveries.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+// observeWebhook records a delivery of event that answered with code. The
+// event header is only trusted, as a label, once the delivery succeeded and
+// so its signature was verified; anyone can send the header.
+func observeWebhook(event string, code int, seconds float64) {
+	if event == "" || code >= http.StatusBadRequest {
+		event = "unverified"
+	}
+	webhookEventsTotal.WithLabelValues(event, strconv.Itoa(code)).Inc()
+	webhookDuration.Observe(seconds)
+}
@@ -0,0 +1,354 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package audit records the outcome of each token exchange and ships the
+// records to a Sink, e.g. Kinesis Data Firehose, a CloudWatch Logs log
+// group, or batched S3 objects, so exchange audit trails reach the
+// organization's SIEM pipeline. A Recorder batches the events and writes
+// them from one goroutine, so a slow sink holds back exchanges only once
+// its queue is full, and never for longer than the enqueue timeout.
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Outcomes of an exchange.
+const (
+	OutcomeSuccess = "success"
+	OutcomeDenied  = "denied"
+	OutcomeError   = "error"
+)
+
+// Event is the audit record of one token exchange. Fields that were not
+// known when the exchange ended, e.g. the installation of a request whose
+// token failed to verify, are left out.
+type Event struct {
+	// ID identifies the event, so a consumer can drop the duplicates of a
+	// batch that was written again after a partial failure.
+	ID         string    `json:"id"`
+	Time       time.Time `json:"time"`
+	Outcome    string    `json:"outcome"`
+	Status     int       `json:"status"`
+	Reason     string    `json:"reason,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	Tenant     string    `json:"tenant,omitempty"`
+
+	// Scope and Identity are those requested.
+	Scope    string `json:"scope,omitempty"`
+	Identity string `json:"identity,omitempty"`
+
+	// Issuer, Subject, and Audience are the claims of the OIDC token. The
+	// issuer is reported even if the token failed to verify.
+	Issuer   string   `json:"issuer,omitempty"`
+	Subject  string   `json:"subject,omitempty"`
+	Audience []string `json:"audience,omitempty"`
+
+	// InstallationID, Repositories, and Permissions are those of the trust
+	// policy that matched the request.
+	InstallationID int64             `json:"installation_id,omitempty"`
+	Repositories   []string          `json:"repositories,omitempty"`
+	Permissions    map[string]string `json:"permissions,omitempty"`
+}
+
+// OutcomeOf returns the outcome of an exchange that answered with status:
+// denied for client errors and error for server errors.
+func OutcomeOf(status int) string {
+	switch {
+	case status < http.StatusBadRequest:
+		return OutcomeSuccess
+	case status < http.StatusInternalServerError:
+		return OutcomeDenied
+	default:
+		return OutcomeError
+	}
+}
+
+// newID returns a random event ID.
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Sink ships batches of events. Write is never called concurrently. A
+// batch that fails is written again, so sinks deliver each event at least
+// once.
+type Sink interface {
+	Write(ctx context.Context, events []Event) error
+}
+
+// Defaults of Options.
+const (
+	DefaultBatchSize      = 100
+	DefaultFlushInterval  = 5 * time.Second
+	DefaultQueueSize      = 1000
+	DefaultEnqueueTimeout = 100 * time.Millisecond
+)
+
+// CloseTimeout is how long a service waits on shutdown for the queued
+// events to be written.
+const CloseTimeout = 10 * time.Second
+
+// writeAttempts is how many times a batch is written before it is dropped.
+const writeAttempts = 3
+
+// writeBackoff is the delay before the second attempt to write a batch,
+// doubled for each further attempt.
+var writeBackoff = 200 * time.Millisecond
+
+// Options configures a Recorder. Zero values use the defaults.
+type Options struct {
+	// BatchSize is the number of events that are written at once.
+	BatchSize int
+
+	// FlushInterval is the longest an event waits for its batch to fill.
+	FlushInterval time.Duration
+
+	// QueueSize is the number of events waiting to be written beyond which
+	// Record waits for room.
+	QueueSize int
+
+	// EnqueueTimeout bounds how long Record waits for room in a full
+	// queue; the event is dropped then.
+	EnqueueTimeout time.Duration
+}
+
+var (
+	eventsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "octo_sts_audit_events_total",
+		Help: "Audit events, by result: written, or dropped because the queue was full or the sink failed.",
+	}, []string{"result"})
+	writeDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "octo_sts_audit_write_duration_seconds",
+		Help:    "Duration of audit batch writes, including retries.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+// ErrClosed is returned by Flush once the Recorder is closed.
+var ErrClosed = errors.New("audit recorder closed")
+
+// Recorder batches events and writes them to its sink.
+type Recorder struct {
+	sink    Sink
+	opts    Options
+	queue   chan Event
+	flushes chan chan error
+	stop    chan struct{}
+	done    chan struct{}
+
+	stopOnce sync.Once
+}
+
+// NewRecorder starts a Recorder that writes to sink until Close.
+func NewRecorder(ctx context.Context, sink Sink, opts Options) *Recorder {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultFlushInterval
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultQueueSize
+	}
+	if opts.EnqueueTimeout <= 0 {
+		opts.EnqueueTimeout = DefaultEnqueueTimeout
+	}
+	r := &Recorder{
+		sink:    sink,
+		opts:    opts,
+		queue:   make(chan Event, opts.QueueSize),
+		flushes: make(chan chan error),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go r.run(context.WithoutCancel(ctx))
+	return r
+}
+
+// Record queues e to be written. If the queue is full, it waits up to the
+// enqueue timeout for room, then drops e. A nil Recorder drops every event,
+// so callers needn't check whether auditing is enabled.
+func (r *Recorder) Record(ctx context.Context, e Event) {
+	if r == nil {
+		return
+	}
+	if e.ID == "" {
+		e.ID = newID()
+	}
+	select {
+	case r.queue <- e:
+		return
+	default:
+	}
+
+	timer := time.NewTimer(r.opts.EnqueueTimeout)
+	defer timer.Stop()
+	select {
+	case r.queue <- e:
+	case <-timer.C:
+		eventsTotal.WithLabelValues("queue_full").Inc()
+		clog.FromContext(ctx).Warnf("[audit] queue full, dropped the event of %s", e.Identity)
+	case <-ctx.Done():
+		eventsTotal.WithLabelValues("queue_full").Inc()
+	}
+}
+
+// Flush writes the events queued so far, e.g. at the end of a Lambda
+// invocation, before the environment is frozen.
+func (r *Recorder) Flush(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+	reply := make(chan error, 1)
+	select {
+	case r.flushes <- reply:
+	case <-r.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-reply:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close writes the queued events and stops the Recorder. Events recorded
+// after Close are dropped.
+func (r *Recorder) Close(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+	r.stopOnce.Do(func() { close(r.stop) })
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run collects events into batches and writes each once it is full or has
+// waited the flush interval.
+func (r *Recorder) run(ctx context.Context) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, r.opts.BatchSize)
+	write := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := r.write(ctx, batch)
+		batch = batch[:0]
+		return err
+	}
+	// drain moves the queued events into batches, writing each full one.
+	drain := func() error {
+		var errs []error
+		for {
+			select {
+			case e := <-r.queue:
+				batch = append(batch, e)
+				if len(batch) >= r.opts.BatchSize {
+					errs = append(errs, write())
+				}
+			default:
+				errs = append(errs, write())
+				return errors.Join(errs...)
+			}
+		}
+	}
+
+	for {
+		select {
+		case e := <-r.queue:
+			batch = append(batch, e)
+			if len(batch) >= r.opts.BatchSize {
+				_ = write()
+			}
+		case <-ticker.C:
+			_ = write()
+		case reply := <-r.flushes:
+			reply <- drain()
+		case <-r.stop:
+			_ = drain()
+			return
+		}
+	}
+}
+
+// write writes batch, retrying with backoff, and drops it if every attempt
+// fails.
+func (r *Recorder) write(ctx context.Context, batch []Event) error {
+	start := time.Now()
+	defer func() { writeDuration.Observe(time.Since(start).Seconds()) }()
+
+	var err error
+	delay := writeBackoff
+	for attempt := 1; attempt <= writeAttempts; attempt++ {
+		if err = r.sink.Write(ctx, batch); err == nil {
+			eventsTotal.WithLabelValues("written").Add(float64(len(batch)))
+			return nil
+		}
+		if attempt < writeAttempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	eventsTotal.WithLabelValues("sink_error").Add(float64(len(batch)))
+	clog.FromContext(ctx).Errorf("[audit] dropped %d events after %d attempts: %v", len(batch), writeAttempts, err)
+	return fmt.Errorf("failed to write %d audit events: %w", len(batch), err)
+}
+
+// encodeLines encodes each event as a JSON line.
+func encodeLines(events []Event) ([][]byte, error) {
+	lines := make([][]byte, len(events))
+	for i, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode audit event: %w", err)
+		}
+		lines[i] = append(data, '\n')
+	}
+	return lines, nil
+}
+
+// chunk splits items into chunks of at most maxCount items and maxBytes
+// bytes, as counted by size.
+func chunk[T any](items []T, size func(T) int, maxCount, maxBytes int) [][]T {
+	var chunks [][]T
+	var cur []T
+	bytes := 0
+	for _, item := range items {
+		n := size(item)
+		if len(cur) > 0 && (len(cur) == maxCount || bytes+n > maxBytes) {
+			chunks = append(chunks, cur)
+			cur, bytes = nil, 0
+		}
+		cur = append(cur, item)
+		bytes += n
+	}
+	if len(cur) > 0 {
+		chunks = append(chunks, cur)
+	}
+	return chunks
+}
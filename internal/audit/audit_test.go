@@ -0,0 +1,177 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSink records the batches written to it, failing the first fails
+// writes.
+type fakeSink struct {
+	mu      sync.Mutex
+	batches [][]Event
+	fails   int
+	block   chan struct{}
+}
+
+func (s *fakeSink) Write(_ context.Context, events []Event) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fails > 0 {
+		s.fails--
+		return errors.New("unavailable")
+	}
+	s.batches = append(s.batches, append([]Event(nil), events...))
+	return nil
+}
+
+func (s *fakeSink) sizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sizes []int
+	for _, b := range s.batches {
+		sizes = append(sizes, len(b))
+	}
+	return sizes
+}
+
+func TestRecorderBatches(t *testing.T) {
+	ctx := context.Background()
+	sink := &fakeSink{}
+	r := NewRecorder(ctx, sink, Options{BatchSize: 2, FlushInterval: time.Hour})
+	for _, identity := range []string{"a", "b", "c"} {
+		r.Record(ctx, Event{Identity: identity})
+	}
+	if err := r.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if got := sink.sizes(); len(got) != 2 || got[0] != 2 || got[1] != 1 {
+		t.Fatalf("batch sizes = %v, want [2 1]", got)
+	}
+	if id := sink.batches[0][0].ID; len(id) != 32 {
+		t.Errorf("ID = %q, want a random ID", id)
+	}
+
+	r.Record(ctx, Event{Identity: "d"})
+	if err := r.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := sink.sizes(); len(got) != 3 {
+		t.Errorf("batch sizes after Close = %v, want the queued event written", got)
+	}
+	if err := r.Flush(ctx); !errors.Is(err, ErrClosed) {
+		t.Errorf("Flush() after Close error = %v, want ErrClosed", err)
+	}
+}
+
+func TestRecorderFlushInterval(t *testing.T) {
+	ctx := context.Background()
+	sink := &fakeSink{}
+	r := NewRecorder(ctx, sink, Options{BatchSize: 10, FlushInterval: 10 * time.Millisecond})
+	defer r.Close(ctx)
+
+	r.Record(ctx, Event{Identity: "a"})
+	deadline := time.Now().Add(5 * time.Second)
+	for len(sink.sizes()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("event was not written after the flush interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRecorderRetries(t *testing.T) {
+	defer func(d time.Duration) { writeBackoff = d }(writeBackoff)
+	writeBackoff = time.Millisecond
+	ctx := context.Background()
+
+	sink := &fakeSink{fails: writeAttempts - 1}
+	r := NewRecorder(ctx, sink, Options{})
+	r.Record(ctx, Event{Identity: "a"})
+	if err := r.Flush(ctx); err != nil {
+		t.Errorf("Flush() error = %v, want the batch written on the last attempt", err)
+	}
+
+	sink.mu.Lock()
+	sink.fails = writeAttempts
+	sink.mu.Unlock()
+	r.Record(ctx, Event{Identity: "b"})
+	if err := r.Flush(ctx); err == nil {
+		t.Error("Flush() error = nil, want the error of the dropped batch")
+	}
+	_ = r.Close(ctx)
+	if got := sink.sizes(); len(got) != 1 {
+		t.Errorf("batch sizes = %v, want only the first batch written", got)
+	}
+}
+
+func TestRecorderQueueFull(t *testing.T) {
+	ctx := context.Background()
+	sink := &fakeSink{block: make(chan struct{})}
+	r := NewRecorder(ctx, sink, Options{BatchSize: 1, QueueSize: 1, EnqueueTimeout: 10 * time.Millisecond})
+
+	// The first event is being written, the second fills the queue, and
+	// the third is dropped
+	for _, identity := range []string{"a", "b", "c"} {
+		start := time.Now()
+		r.Record(ctx, Event{Identity: identity})
+		if d := time.Since(start); d > time.Second {
+			t.Fatalf("Record() took %s, want at most the enqueue timeout", d)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(sink.block)
+	if err := r.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := sink.sizes(); len(got) != 2 {
+		t.Errorf("batch sizes = %v, want 2 events written", got)
+	}
+}
+
+func TestNilRecorder(t *testing.T) {
+	ctx := context.Background()
+	var r *Recorder
+	r.Record(ctx, Event{})
+	if err := r.Flush(ctx); err != nil {
+		t.Errorf("Flush() error = %v", err)
+	}
+	if err := r.Close(ctx); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestOutcomeOf(t *testing.T) {
+	for status, want := range map[int]string{200: OutcomeSuccess, 401: OutcomeDenied, 403: OutcomeDenied, 500: OutcomeError, 503: OutcomeError} {
+		if got := OutcomeOf(status); got != want {
+			t.Errorf("OutcomeOf(%d) = %q, want %q", status, got, want)
+		}
+	}
+}
+
+func TestChunk(t *testing.T) {
+	size := func(s string) int { return len(s) }
+	got := chunk([]string{"aa", "bb", "cc", "dddd", "e"}, size, 2, 5)
+	want := [][]string{{"aa", "bb"}, {"cc"}, {"dddd", "e"}}
+	if len(got) != len(want) {
+		t.Fatalf("chunk() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if len(got[i]) != len(want[i]) || got[i][0] != want[i][0] {
+			t.Errorf("chunk()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+	// An item larger than maxBytes gets a chunk of its own
+	if got := chunk([]string{"toolarge"}, size, 2, 5); len(got) != 1 {
+		t.Errorf("chunk() = %v, want one chunk", got)
+	}
+}
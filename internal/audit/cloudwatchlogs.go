@@ -0,0 +1,179 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// Limits of a CloudWatch Logs PutLogEvents request, where each event counts
+// its message and 26 bytes.
+const (
+	logsMaxEvents     = 10000
+	logsMaxBytes      = 1 << 20
+	logsEventOverhead = 26
+)
+
+// ErrLogStreamNotFound is returned by CloudWatchLogsClient.PutLogEvents
+// when the log stream doesn't exist.
+var ErrLogStreamNotFound = errors.New("log stream not found")
+
+// LogEvent is an event of a CloudWatch Logs log stream.
+type LogEvent struct {
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+// CloudWatchLogsClient is the subset of the CloudWatch Logs API used by
+// CloudWatchLogsSink.
+type CloudWatchLogsClient interface {
+	PutLogEvents(ctx context.Context, group, stream string, events []LogEvent) error
+	CreateLogStream(ctx context.Context, group, stream string) error
+}
+
+// CloudWatchLogsSink writes events to a CloudWatch Logs log stream, one
+// JSON message per event, creating the stream on first use.
+type CloudWatchLogsSink struct {
+	client CloudWatchLogsClient
+	group  string
+	stream string
+}
+
+// NewCloudWatchLogsSink creates a sink that writes to stream of group.
+func NewCloudWatchLogsSink(client CloudWatchLogsClient, group, stream string) *CloudWatchLogsSink {
+	return &CloudWatchLogsSink{client: client, group: group, stream: stream}
+}
+
+// Write puts events in time order, in as few requests as the CloudWatch
+// Logs limits allow.
+func (s *CloudWatchLogsSink) Write(ctx context.Context, events []Event) error {
+	sorted := append([]Event(nil), events...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	lines, err := encodeLines(sorted)
+	if err != nil {
+		return err
+	}
+	logEvents := make([]LogEvent, len(lines))
+	for i, line := range lines {
+		logEvents[i] = LogEvent{Timestamp: sorted[i].Time.UnixMilli(), Message: strings.TrimSuffix(string(line), "\n")}
+	}
+
+	size := func(e LogEvent) int { return len(e.Message) + logsEventOverhead }
+	for _, batch := range chunk(logEvents, size, logsMaxEvents, logsMaxBytes) {
+		err := s.client.PutLogEvents(ctx, s.group, s.stream, batch)
+		if errors.Is(err, ErrLogStreamNotFound) {
+			if err := s.client.CreateLogStream(ctx, s.group, s.stream); err != nil {
+				return fmt.Errorf("failed to create log stream %s of %s: %w", s.stream, s.group, err)
+			}
+			err = s.client.PutLogEvents(ctx, s.group, s.stream, batch)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to put log events to %s: %w", s.group, err)
+		}
+	}
+	return nil
+}
+
+// logsClient calls the CloudWatch Logs JSON API directly, signed with the
+// credentials of an AWS config. It implements only the two calls the sink
+// needs.
+type logsClient struct {
+	cfg      aws.Config
+	endpoint string
+	signer   *v4.Signer
+	http     *http.Client
+}
+
+// NewCloudWatchLogsClient creates a CloudWatch Logs client for the region
+// and credentials of cfg, at its BaseEndpoint if set.
+func NewCloudWatchLogsClient(cfg aws.Config) CloudWatchLogsClient {
+	endpoint := aws.ToString(cfg.BaseEndpoint)
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://logs.%s.amazonaws.com", cfg.Region)
+	}
+	return &logsClient{
+		cfg:      cfg,
+		endpoint: strings.TrimSuffix(endpoint, "/") + "/",
+		signer:   v4.NewSigner(),
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *logsClient) PutLogEvents(ctx context.Context, group, stream string, events []LogEvent) error {
+	return c.call(ctx, "PutLogEvents", map[string]any{
+		"logGroupName":  group,
+		"logStreamName": stream,
+		"logEvents":     events,
+	})
+}
+
+func (c *logsClient) CreateLogStream(ctx context.Context, group, stream string) error {
+	err := c.call(ctx, "CreateLogStream", map[string]any{
+		"logGroupName":  group,
+		"logStreamName": stream,
+	})
+	// Another instance may have created it first
+	if err != nil && strings.Contains(err.Error(), "ResourceAlreadyExistsException") {
+		return nil
+	}
+	return err
+}
+
+// call sends a signed request for action with body.
+func (c *logsClient) call(ctx context.Context, action string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Logs_20140328."+action)
+
+	creds, err := c.cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	hash := sha256.Sum256(payload)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "logs", c.cfg.Region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var apiErr struct {
+		Type    string `json:"__type"`
+		Message string `json:"message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	_ = json.Unmarshal(data, &apiErr)
+	if strings.HasSuffix(apiErr.Type, "ResourceNotFoundException") && action == "PutLogEvents" {
+		return fmt.Errorf("%w: %s", ErrLogStreamNotFound, apiErr.Message)
+	}
+	return fmt.Errorf("%s failed with %s: %s %s", action, resp.Status, apiErr.Type, apiErr.Message)
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// fakeLogs fails PutLogEvents with ErrLogStreamNotFound until the stream
+// is created.
+type fakeLogs struct {
+	created bool
+	events  []LogEvent
+}
+
+func (f *fakeLogs) PutLogEvents(_ context.Context, _, _ string, events []LogEvent) error {
+	if !f.created {
+		return ErrLogStreamNotFound
+	}
+	f.events = append(f.events, events...)
+	return nil
+}
+
+func (f *fakeLogs) CreateLogStream(context.Context, string, string) error {
+	f.created = true
+	return nil
+}
+
+func TestCloudWatchLogsSink(t *testing.T) {
+	now := time.Now()
+	client := &fakeLogs{}
+	sink := NewCloudWatchLogsSink(client, "group", "stream")
+	err := sink.Write(context.Background(), []Event{{ID: "late", Time: now}, {ID: "early", Time: now.Add(-time.Second)}})
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !client.created || len(client.events) != 2 {
+		t.Fatalf("created = %v, events = %d, want the stream created and 2 events", client.created, len(client.events))
+	}
+	if !strings.Contains(client.events[0].Message, `"id":"early"`) || client.events[0].Timestamp != now.Add(-time.Second).UnixMilli() {
+		t.Errorf("first event = %+v, want the earliest event", client.events[0])
+	}
+	if strings.HasSuffix(client.events[0].Message, "\n") {
+		t.Error("message ends in a newline")
+	}
+}
+
+func TestCloudWatchLogsClient(t *testing.T) {
+	var actions []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action := r.Header.Get("X-Amz-Target")
+		actions = append(actions, action)
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			t.Errorf("Authorization = %q, want a SigV4 signature", r.Header.Get("Authorization"))
+		}
+		var body map[string]any
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &body)
+		if body["logGroupName"] != "group" {
+			t.Errorf("logGroupName = %v", body["logGroupName"])
+		}
+
+		switch action {
+		case "Logs_20140328.PutLogEvents":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"The specified log stream does not exist."}`))
+		case "Logs_20140328.CreateLogStream":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceAlreadyExistsException","message":"exists"}`))
+		}
+	}))
+	defer srv.Close()
+
+	client := NewCloudWatchLogsClient(aws.Config{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(srv.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "secret", ""),
+	})
+	ctx := context.Background()
+	if err := client.PutLogEvents(ctx, "group", "stream", []LogEvent{{Timestamp: 1, Message: "{}"}}); !errors.Is(err, ErrLogStreamNotFound) {
+		t.Errorf("PutLogEvents() error = %v, want ErrLogStreamNotFound", err)
+	}
+	if err := client.CreateLogStream(ctx, "group", "stream"); err != nil {
+		t.Errorf("CreateLogStream() error = %v, want an existing stream ignored", err)
+	}
+	if len(actions) != 2 {
+		t.Errorf("actions = %v", actions)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package audit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/chainguard-dev/clog"
+)
+
+// Environment variables that configure the audit sink.
+const (
+	// EnvSink selects the sink: firehose, cloudwatch-logs, or s3. Auditing
+	// is disabled when it is unset.
+	EnvSink = "AUDIT_SINK"
+
+	// EnvFirehoseStream is the Firehose stream of the firehose sink.
+	EnvFirehoseStream = "AUDIT_FIREHOSE_STREAM"
+
+	// EnvLogGroup is the log group of the cloudwatch-logs sink.
+	EnvLogGroup = "AUDIT_LOG_GROUP"
+
+	// EnvLogStream is the log stream of the cloudwatch-logs sink (default:
+	// the Lambda log stream, else the host name).
+	EnvLogStream = "AUDIT_LOG_STREAM"
+
+	// EnvS3Bucket is the bucket of the s3 sink.
+	EnvS3Bucket = "AUDIT_S3_BUCKET"
+
+	// EnvS3Prefix is the key prefix of the s3 sink's objects (default:
+	// "audit/").
+	EnvS3Prefix = "AUDIT_S3_PREFIX"
+
+	// EnvBatchSize, EnvFlushInterval, and EnvQueueSize override the
+	// Options of the sink.
+	EnvBatchSize     = "AUDIT_BATCH_SIZE"
+	EnvFlushInterval = "AUDIT_FLUSH_INTERVAL"
+	EnvQueueSize     = "AUDIT_QUEUE_SIZE"
+)
+
+// Sinks selected by AUDIT_SINK.
+const (
+	SinkFirehose       = "firehose"
+	SinkCloudWatchLogs = "cloudwatch-logs"
+	SinkS3             = "s3"
+)
+
+// Defaults of the s3 sink, which writes fewer, larger objects.
+const (
+	defaultS3Prefix        = "audit/"
+	defaultS3BatchSize     = 1000
+	defaultS3FlushInterval = time.Minute
+)
+
+// Config is the audit configuration from the environment.
+type Config struct {
+	Sink           string
+	FirehoseStream string
+	LogGroup       string
+	LogStream      string
+	S3Bucket       string
+	S3Prefix       string
+	Options        Options
+}
+
+// ConfigFromEnv returns the audit configuration from the environment. An
+// empty Sink means auditing is disabled.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{Sink: os.Getenv(EnvSink)}
+	required := func(env string) (string, error) {
+		v := os.Getenv(env)
+		if v == "" {
+			return "", fmt.Errorf("%s is required with %s=%s", env, EnvSink, cfg.Sink)
+		}
+		return v, nil
+	}
+
+	var err error
+	switch cfg.Sink {
+	case "":
+		return cfg, nil
+	case SinkFirehose:
+		cfg.FirehoseStream, err = required(EnvFirehoseStream)
+	case SinkCloudWatchLogs:
+		cfg.LogGroup, err = required(EnvLogGroup)
+		cfg.LogStream = os.Getenv(EnvLogStream)
+		if cfg.LogStream == "" {
+			cfg.LogStream = os.Getenv("AWS_LAMBDA_LOG_STREAM_NAME")
+		}
+		if cfg.LogStream == "" {
+			cfg.LogStream, _ = os.Hostname()
+		}
+	case SinkS3:
+		cfg.S3Bucket, err = required(EnvS3Bucket)
+		cfg.S3Prefix = os.Getenv(EnvS3Prefix)
+		if cfg.S3Prefix == "" {
+			cfg.S3Prefix = defaultS3Prefix
+		}
+		cfg.Options = Options{BatchSize: defaultS3BatchSize, FlushInterval: defaultS3FlushInterval}
+	default:
+		return Config{}, fmt.Errorf("invalid %s %q: must be %s, %s, or %s",
+			EnvSink, cfg.Sink, SinkFirehose, SinkCloudWatchLogs, SinkS3)
+	}
+	if err != nil {
+		return Config{}, err
+	}
+
+	for env, dst := range map[string]*int{EnvBatchSize: &cfg.Options.BatchSize, EnvQueueSize: &cfg.Options.QueueSize} {
+		if v := os.Getenv(env); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return Config{}, fmt.Errorf("invalid %s %q: must be a positive integer", env, v)
+			}
+			*dst = n
+		}
+	}
+	if v := os.Getenv(EnvFlushInterval); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return Config{}, fmt.Errorf("invalid %s %q: must be a positive duration such as 5s", EnvFlushInterval, v)
+		}
+		cfg.Options.FlushInterval = d
+	}
+	return cfg, nil
+}
+
+// FromEnv starts a Recorder for the sink configured by the environment, or
+// returns nil if auditing is disabled. Close it on shutdown to write the
+// queued events.
+func FromEnv(ctx context.Context) (*Recorder, error) {
+	cfg, err := ConfigFromEnv()
+	if err != nil || cfg.Sink == "" {
+		return nil, err
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	var sink Sink
+	var dest string
+	switch cfg.Sink {
+	case SinkFirehose:
+		sink = NewFirehoseSink(firehose.NewFromConfig(awsCfg), cfg.FirehoseStream)
+		dest = "Firehose stream " + cfg.FirehoseStream
+	case SinkCloudWatchLogs:
+		stream := strings.NewReplacer(":", "_", "*", "_").Replace(cfg.LogStream)
+		sink = NewCloudWatchLogsSink(NewCloudWatchLogsClient(awsCfg), cfg.LogGroup, stream)
+		dest = "log group " + cfg.LogGroup
+	case SinkS3:
+		sink = NewS3Sink(s3.NewFromConfig(awsCfg), cfg.S3Bucket, cfg.S3Prefix)
+		dest = "s3://" + cfg.S3Bucket + "/" + cfg.S3Prefix
+	}
+	r := NewRecorder(ctx, sink, cfg.Options)
+	clog.FromContext(ctx).Infof("[audit] recording token exchanges to %s", dest)
+	return r, nil
+}
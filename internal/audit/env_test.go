@@ -0,0 +1,55 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    Config
+		wantErr bool
+	}{
+		{name: "disabled"},
+		{
+			name: "firehose",
+			env:  map[string]string{EnvSink: SinkFirehose, EnvFirehoseStream: "audit", EnvBatchSize: "50"},
+			want: Config{Sink: SinkFirehose, FirehoseStream: "audit", Options: Options{BatchSize: 50}},
+		},
+		{
+			name: "cloudwatch-logs defaults to the Lambda log stream",
+			env:  map[string]string{EnvSink: SinkCloudWatchLogs, EnvLogGroup: "/octo-sts/audit", "AWS_LAMBDA_LOG_STREAM_NAME": "2026/10/16/[$LATEST]abc"},
+			want: Config{Sink: SinkCloudWatchLogs, LogGroup: "/octo-sts/audit", LogStream: "2026/10/16/[$LATEST]abc"},
+		},
+		{
+			name: "s3 defaults",
+			env:  map[string]string{EnvSink: SinkS3, EnvS3Bucket: "logs", EnvFlushInterval: "30s"},
+			want: Config{Sink: SinkS3, S3Bucket: "logs", S3Prefix: "audit/", Options: Options{BatchSize: 1000, FlushInterval: 30 * time.Second}},
+		},
+		{name: "unknown sink", env: map[string]string{EnvSink: "kafka"}, wantErr: true},
+		{name: "missing stream", env: map[string]string{EnvSink: SinkFirehose}, wantErr: true},
+		{name: "missing bucket", env: map[string]string{EnvSink: SinkS3}, wantErr: true},
+		{name: "invalid batch size", env: map[string]string{EnvSink: SinkFirehose, EnvFirehoseStream: "audit", EnvBatchSize: "0"}, wantErr: true},
+		{name: "invalid flush interval", env: map[string]string{EnvSink: SinkFirehose, EnvFirehoseStream: "audit", EnvFlushInterval: "soon"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, env := range []string{EnvSink, EnvFirehoseStream, EnvLogGroup, EnvLogStream, EnvS3Bucket, EnvS3Prefix,
+				EnvBatchSize, EnvFlushInterval, EnvQueueSize, "AWS_LAMBDA_LOG_STREAM_NAME"} {
+				t.Setenv(env, tt.env[env])
+			}
+			got, err := ConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConfigFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ConfigFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
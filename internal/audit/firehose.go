@@ -0,0 +1,86 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go-v2/service/firehose/types"
+)
+
+// Limits of a Firehose PutRecordBatch request.
+const (
+	firehoseMaxRecords = 500
+	firehoseMaxBytes   = 4 << 20
+)
+
+// FirehoseClient is the subset of the Firehose API used by FirehoseSink.
+type FirehoseClient interface {
+	PutRecordBatch(ctx context.Context, in *firehose.PutRecordBatchInput,
+		optFns ...func(*firehose.Options)) (*firehose.PutRecordBatchOutput, error)
+}
+
+// FirehoseSink writes events to a Kinesis Data Firehose stream, one JSON
+// record per event, each ending in a newline so the records delivered to
+// S3 or a SIEM split into lines.
+type FirehoseSink struct {
+	client FirehoseClient
+	stream string
+}
+
+// NewFirehoseSink creates a sink that writes to stream.
+func NewFirehoseSink(client FirehoseClient, stream string) *FirehoseSink {
+	return &FirehoseSink{client: client, stream: stream}
+}
+
+// Write puts events in as few requests as the Firehose limits allow. Records
+// Firehose rejects, e.g. when throttled, are put again once before Write
+// fails.
+func (s *FirehoseSink) Write(ctx context.Context, events []Event) error {
+	lines, err := encodeLines(events)
+	if err != nil {
+		return err
+	}
+	records := make([]types.Record, len(lines))
+	for i, line := range lines {
+		records[i] = types.Record{Data: line}
+	}
+	size := func(r types.Record) int { return len(r.Data) }
+	for _, batch := range chunk(records, size, firehoseMaxRecords, firehoseMaxBytes) {
+		if err := s.put(ctx, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// put puts records, and those that failed a second time.
+func (s *FirehoseSink) put(ctx context.Context, records []types.Record) error {
+	var failed []types.Record
+	var lastErr string
+	for attempt := 0; attempt < 2 && len(records) > 0; attempt++ {
+		out, err := s.client.PutRecordBatch(ctx, &firehose.PutRecordBatchInput{
+			DeliveryStreamName: aws.String(s.stream),
+			Records:            records,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to put records to Firehose stream %s: %w", s.stream, err)
+		}
+		failed = failed[:0]
+		for i, resp := range out.RequestResponses {
+			if resp.ErrorCode != nil && i < len(records) {
+				failed = append(failed, records[i])
+				lastErr = aws.ToString(resp.ErrorCode) + ": " + aws.ToString(resp.ErrorMessage)
+			}
+		}
+		records = append([]types.Record(nil), failed...)
+	}
+	if len(records) > 0 {
+		return fmt.Errorf("firehose stream %s rejected %d records: %s", s.stream, len(records), lastErr)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package audit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go-v2/service/firehose/types"
+)
+
+// fakeFirehose rejects the first record of the first rejects calls.
+type fakeFirehose struct {
+	calls   [][]types.Record
+	rejects int
+}
+
+func (f *fakeFirehose) PutRecordBatch(_ context.Context, in *firehose.PutRecordBatchInput,
+	_ ...func(*firehose.Options)) (*firehose.PutRecordBatchOutput, error) {
+	f.calls = append(f.calls, in.Records)
+	out := &firehose.PutRecordBatchOutput{RequestResponses: make([]types.PutRecordBatchResponseEntry, len(in.Records))}
+	if f.rejects > 0 {
+		f.rejects--
+		out.RequestResponses[0] = types.PutRecordBatchResponseEntry{
+			ErrorCode:    aws.String("ServiceUnavailableException"),
+			ErrorMessage: aws.String("slow down"),
+		}
+		out.FailedPutCount = aws.Int32(1)
+	}
+	return out, nil
+}
+
+func TestFirehoseSink(t *testing.T) {
+	ctx := context.Background()
+	events := []Event{{ID: "1", Identity: "a"}, {ID: "2", Identity: "b"}}
+
+	client := &fakeFirehose{rejects: 1}
+	if err := NewFirehoseSink(client, "audit").Write(ctx, events); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if len(client.calls) != 2 || len(client.calls[1]) != 1 {
+		t.Fatalf("calls = %d, want the rejected record put again", len(client.calls))
+	}
+	if data := string(client.calls[1][0].Data); !strings.Contains(data, `"id":"1"`) || !strings.HasSuffix(data, "\n") {
+		t.Errorf("record = %q, want the rejected event as a JSON line", data)
+	}
+
+	client = &fakeFirehose{rejects: 2}
+	if err := NewFirehoseSink(client, "audit").Write(ctx, events); err == nil {
+		t.Error("Write() error = nil, want the records rejected twice reported")
+	}
+}
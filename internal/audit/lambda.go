@@ -0,0 +1,28 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// WrapLambda wraps a Lambda handler to write the events recorded by an
+// invocation before it returns, since the environment may be frozen, or
+// never thawed, once it does. A nil Recorder returns next as is.
+func WrapLambda(r *Recorder,
+	next func(ctx context.Context, payload json.RawMessage) (any, error)) func(ctx context.Context, payload json.RawMessage) (any, error) {
+	if r == nil {
+		return next
+	}
+	return func(ctx context.Context, payload json.RawMessage) (any, error) {
+		resp, err := next(ctx, payload)
+		if ferr := r.Flush(context.WithoutCancel(ctx)); ferr != nil {
+			clog.FromContext(ctx).Errorf("[audit] failed to write events: %v", ferr)
+		}
+		return resp, err
+	}
+}
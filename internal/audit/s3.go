@@ -0,0 +1,67 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package audit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3Client is the subset of the S3 API used by S3Sink.
+type S3Client interface {
+	PutObject(ctx context.Context, in *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// S3Sink writes each batch of events to an S3 object of gzipped JSON lines,
+// under prefix and the hour of the batch, e.g.
+// audit/2026/10/16/09/20261016T093000Z-<id>.jsonl.gz, a layout Athena and
+// most SIEM S3 inputs read as is.
+type S3Sink struct {
+	client S3Client
+	bucket string
+	prefix string
+	now    func() time.Time
+}
+
+// NewS3Sink creates a sink that writes objects to bucket under prefix.
+func NewS3Sink(client S3Client, bucket, prefix string) *S3Sink {
+	return &S3Sink{client: client, bucket: bucket, prefix: prefix, now: time.Now}
+}
+
+// Write puts events as one object.
+func (s *S3Sink) Write(ctx context.Context, events []Event) error {
+	lines, err := encodeLines(events)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	for _, line := range lines {
+		if _, err := zw.Write(line); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	key := fmt.Sprintf("%s%s/%s-%s.jsonl.gz", s.prefix, now.Format("2006/01/02/15"), now.Format("20060102T150405Z"), newID()[:12])
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(buf.Bytes()),
+		ContentType: aws.String("application/gzip"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put s3://%s/%s: %w", s.bucket, key, err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package audit
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type fakeS3 struct {
+	key  string
+	body []byte
+}
+
+func (f *fakeS3) PutObject(_ context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	f.key = aws.ToString(in.Key)
+	zr, err := gzip.NewReader(in.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.body, err = io.ReadAll(zr)
+	return &s3.PutObjectOutput{}, err
+}
+
+func TestS3Sink(t *testing.T) {
+	client := &fakeS3{}
+	sink := NewS3Sink(client, "bucket", "audit/")
+	sink.now = func() time.Time { return time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC) }
+
+	if err := sink.Write(context.Background(), []Event{{ID: "1"}, {ID: "2"}}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !regexp.MustCompile(`^audit/2026/10/16/09/20261016T093000Z-[0-9a-f]{12}\.jsonl\.gz$`).MatchString(client.key) {
+		t.Errorf("key = %q", client.key)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(client.body), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("object has %d lines, want 2", len(lines))
+	}
+	var e Event
+	if err := json.Unmarshal([]byte(lines[1]), &e); err != nil || e.ID != "2" {
+		t.Errorf("line = %q, want the second event", lines[1])
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package azure provides a minimal Azure Key Vault secrets client
+// authenticated with a managed identity.
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables for Azure managed identity.
+const (
+	// EnvAzureClientID selects a user-assigned managed identity.
+	EnvAzureClientID = "AZURE_CLIENT_ID"
+
+	// EnvIdentityEndpoint and EnvIdentityHeader are set by App Service and
+	// Azure Functions; when absent the instance metadata service is used.
+	EnvIdentityEndpoint = "IDENTITY_ENDPOINT"
+	EnvIdentityHeader   = "IDENTITY_HEADER"
+)
+
+const (
+	// KeyVaultResource is the token audience for Azure Key Vault.
+	KeyVaultResource = "https://vault.azure.net"
+
+	// KeyVaultAPIVersion is the Key Vault REST API version used.
+	KeyVaultAPIVersion = "7.4"
+
+	// DefaultTimeout is the default timeout for Azure HTTP requests.
+	DefaultTimeout = 10 * time.Second
+
+	imdsTokenURL = "http://169.254.169.254/metadata/identity/oauth2/token"
+)
+
+// ErrSecretNotFound is returned when a Key Vault secret does not exist.
+var ErrSecretNotFound = errors.New("key vault secret not found")
+
+// TokenSource provides bearer tokens for Key Vault requests.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// ManagedIdentity obtains tokens from the Azure managed identity endpoint.
+type ManagedIdentity struct {
+	// ClientID selects a user-assigned identity. Empty uses the system-assigned identity.
+	ClientID string
+
+	httpClient *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewManagedIdentity creates a managed identity token source for the given client ID.
+func NewManagedIdentity(clientID string) *ManagedIdentity {
+	return &ManagedIdentity{
+		ClientID:   clientID,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// Token returns a cached Key Vault access token, refreshing it shortly before expiry.
+func (m *ManagedIdentity) Token(ctx context.Context) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.token != "" && time.Until(m.expires) > time.Minute {
+		return m.token, nil
+	}
+
+	query := url.Values{"resource": {KeyVaultResource}}
+	endpoint := imdsTokenURL
+	header, headerValue := "Metadata", "true"
+	if e := os.Getenv(EnvIdentityEndpoint); e != "" {
+		endpoint = e
+		header, headerValue = "X-IDENTITY-HEADER", os.Getenv(EnvIdentityHeader)
+		query.Set("api-version", "2019-08-01")
+	} else {
+		query.Set("api-version", "2018-02-01")
+	}
+	if m.ClientID != "" {
+		query.Set("client_id", m.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(header, headerValue)
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("managed identity request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("managed identity returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tok struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   json.Number `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", fmt.Errorf("failed to decode managed identity token: %w", err)
+	}
+	if tok.AccessToken == "" {
+		return "", fmt.Errorf("managed identity returned no access token")
+	}
+
+	m.token = tok.AccessToken
+	m.expires = time.Now().Add(5 * time.Minute)
+	if secs, err := tok.ExpiresIn.Int64(); err == nil && secs > 0 {
+		m.expires = time.Now().Add(time.Duration(secs) * time.Second)
+	}
+	return m.token, nil
+}
+
+// KeyVaultClient reads and writes secrets in a single Azure Key Vault.
+type KeyVaultClient struct {
+	VaultURI string
+
+	tokens     TokenSource
+	httpClient *http.Client
+}
+
+// NewKeyVaultClient creates a client for the vault at vaultURI
+// (e.g. https://my-vault.vault.azure.net).
+func NewKeyVaultClient(vaultURI string, tokens TokenSource) (*KeyVaultClient, error) {
+	if vaultURI == "" {
+		return nil, fmt.Errorf("key vault URI cannot be empty")
+	}
+	if tokens == nil {
+		return nil, fmt.Errorf("token source cannot be nil")
+	}
+	return &KeyVaultClient{
+		VaultURI:   strings.TrimSuffix(vaultURI, "/"),
+		tokens:     tokens,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+	}, nil
+}
+
+// GetSecret returns the current value of a secret.
+// Returns ErrSecretNotFound if the secret does not exist.
+func (c *KeyVaultClient) GetSecret(ctx context.Context, name string) (string, error) {
+	var out struct {
+		Value string `json:"value"`
+	}
+	if err := c.do(ctx, http.MethodGet, name, nil, &out); err != nil {
+		return "", err
+	}
+	return out.Value, nil
+}
+
+// SetSecret creates a new version of a secret.
+func (c *KeyVaultClient) SetSecret(ctx context.Context, name, value, contentType string, tags map[string]string) error {
+	body := map[string]any{"value": value}
+	if contentType != "" {
+		body["contentType"] = contentType
+	}
+	if len(tags) > 0 {
+		body["tags"] = tags
+	}
+	return c.do(ctx, http.MethodPut, name, body, nil)
+}
+
+// DeleteSecret deletes a secret and all of its versions. On vaults with soft
+// delete enabled the secret remains recoverable until it is purged. Deleting
+// a secret that does not exist is not an error.
+func (c *KeyVaultClient) DeleteSecret(ctx context.Context, name string) error {
+	err := c.do(ctx, http.MethodDelete, name, nil, nil)
+	if errors.Is(err, ErrSecretNotFound) {
+		return nil
+	}
+	return err
+}
+
+// do sends an authenticated request for the named secret.
+func (c *KeyVaultClient) do(ctx context.Context, method, name string, body, out any) error {
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get key vault token: %w", err)
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	endpoint := c.VaultURI + "/secrets/" + url.PathEscape(name) + "?api-version=" + KeyVaultAPIVersion
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("key vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrSecretNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errBody struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&errBody) == nil && errBody.Error.Code != "" {
+			return fmt.Errorf("key vault returned status %d: %s: %s", resp.StatusCode, errBody.Error.Code, errBody.Error.Message)
+		}
+		return fmt.Errorf("key vault returned status %d", resp.StatusCode)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode key vault response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// staticToken is a TokenSource returning a fixed token.
+type staticToken string
+
+func (s staticToken) Token(context.Context) (string, error) { return string(s), nil }
+
+// newTestClient returns a client of a server answering with handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *KeyVaultClient {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := NewKeyVaultClient(srv.URL+"/", staticToken("kv-token"))
+	if err != nil {
+		t.Fatalf("NewKeyVaultClient() error = %v", err)
+	}
+	return c
+}
+
+func TestManagedIdentityAppService(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		q := r.URL.Query()
+		if got := r.Header.Get("X-IDENTITY-HEADER"); got != "identity-header" {
+			t.Errorf("X-IDENTITY-HEADER = %q, want identity-header", got)
+		}
+		if q.Get("resource") != KeyVaultResource || q.Get("api-version") != "2019-08-01" || q.Get("client_id") != "client" {
+			t.Errorf("query = %v, want the resource, API version and client ID", q)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "kv-token", "expires_in": "3600"})
+	}))
+	defer srv.Close()
+	t.Setenv(EnvIdentityEndpoint, srv.URL)
+	t.Setenv(EnvIdentityHeader, "identity-header")
+
+	m := NewManagedIdentity("client")
+	for range 2 {
+		token, err := m.Token(context.Background())
+		if err != nil || token != "kv-token" {
+			t.Fatalf("Token() = %q, %v, want kv-token", token, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("identity endpoint requested %d times, want the token cached", requests)
+	}
+}
+
+func TestManagedIdentityError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("identity not found\n"))
+	}))
+	defer srv.Close()
+	t.Setenv(EnvIdentityEndpoint, srv.URL)
+	t.Setenv(EnvIdentityHeader, "identity-header")
+
+	_, err := NewManagedIdentity("").Token(context.Background())
+	if err == nil || err.Error() != "managed identity returned status 400: identity not found" {
+		t.Errorf("Token() error = %v, want the status and the trimmed body", err)
+	}
+
+	c, _ := NewKeyVaultClient("https://example.vault.azure.net", NewManagedIdentity(""))
+	if _, err := c.GetSecret(context.Background(), "octo-sts"); err == nil || !strings.Contains(err.Error(), "failed to get key vault token") {
+		t.Errorf("GetSecret() error = %v, want the token error", err)
+	}
+}
+
+func TestSecretRequest(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer kv-token" {
+			t.Errorf("Authorization = %q, want the bearer token", got)
+		}
+		if r.URL.EscapedPath() != "/secrets/octo%20sts%2Fapp" {
+			t.Errorf("path = %q, want the name escaped", r.URL.EscapedPath())
+		}
+		if got := r.URL.Query().Get("api-version"); got != KeyVaultAPIVersion {
+			t.Errorf("api-version = %q, want %s", got, KeyVaultAPIVersion)
+		}
+		if r.Method == http.MethodPut {
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			tags, _ := body["tags"].(map[string]any)
+			if body["value"] != "v" || body["contentType"] != "application/json" || tags["team"] != "platform" {
+				t.Errorf("PUT body = %v, want the value, content type and tags", body)
+			}
+		}
+		w.Write([]byte(`{"value":"v"}`))
+	})
+	ctx := context.Background()
+
+	if value, err := c.GetSecret(ctx, "octo sts/app"); err != nil || value != "v" {
+		t.Errorf("GetSecret() = %q, %v, want v", value, err)
+	}
+	if err := c.SetSecret(ctx, "octo sts/app", "v", "application/json", map[string]string{"team": "platform"}); err != nil {
+		t.Errorf("SetSecret() error = %v", err)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":{"code":"SecretNotFound","message":"A secret with (name/id) octo-sts was not found in this key vault."}}`))
+	})
+	ctx := context.Background()
+
+	if _, err := c.GetSecret(ctx, "octo-sts"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("GetSecret() of a missing secret error = %v, want ErrSecretNotFound", err)
+	}
+	if err := c.DeleteSecret(ctx, "octo-sts"); err != nil {
+		t.Errorf("DeleteSecret() of a missing secret error = %v, want nil", err)
+	}
+}
+
+func TestErrorBody(t *testing.T) {
+	body := `{"error":{"code":"Forbidden","message":"The user does not have secrets get permission"}}`
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(body))
+	})
+
+	_, err := c.GetSecret(context.Background(), "octo-sts")
+	if err == nil || err.Error() != "key vault returned status 403: Forbidden: The user does not have secrets get permission" {
+		t.Errorf("GetSecret() error = %v, want the status, code and message", err)
+	}
+
+	body = "<html>gateway timeout</html>"
+	_, err = c.GetSecret(context.Background(), "octo-sts")
+	if err == nil || err.Error() != "key vault returned status 403" {
+		t.Errorf("GetSecret() with a non-JSON body error = %v, want the status only", err)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// Environment variables for retries and timeouts of the AWS stores (SSM,
+// Secrets Manager, and the DynamoDB status table).
+const (
+	EnvStorageMaxAttempts    = "STORAGE_MAX_ATTEMPTS"
+	EnvStorageRequestTimeout = "STORAGE_REQUEST_TIMEOUT"
+)
+
+// DefaultAWSMaxAttempts is the number of attempts the AWS stores make per
+// request when STORAGE_MAX_ATTEMPTS is not set. It is higher than the SDK's
+// default of three, so a brief throttle while the installer saves
+// credentials doesn't lose the credentials GitHub only hands over once.
+const DefaultAWSMaxAttempts = 5
+
+// AWSRetryOptions configures how the AWS stores retry failed requests.
+// Throttling, transient network errors, and 5xx responses are retried with
+// jittered exponential backoff.
+type AWSRetryOptions struct {
+	// MaxAttempts is the maximum number of attempts per request, including
+	// the first. Zero uses the SDK default.
+	MaxAttempts int
+	// RequestTimeout bounds each attempt, so a hung connection is retried
+	// instead of stalling the caller. Zero means no timeout.
+	RequestTimeout time.Duration
+}
+
+// awsRetryOptionsFromEnv reads STORAGE_MAX_ATTEMPTS and STORAGE_REQUEST_TIMEOUT.
+func awsRetryOptionsFromEnv() (AWSRetryOptions, error) {
+	opts := AWSRetryOptions{MaxAttempts: DefaultAWSMaxAttempts}
+
+	if value := os.Getenv(EnvStorageMaxAttempts); value != "" {
+		attempts, err := strconv.Atoi(value)
+		if err != nil || attempts < 1 {
+			return AWSRetryOptions{}, fmt.Errorf("invalid %s %q: must be a positive integer", EnvStorageMaxAttempts, value)
+		}
+		opts.MaxAttempts = attempts
+	}
+
+	if value := os.Getenv(EnvStorageRequestTimeout); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return AWSRetryOptions{}, fmt.Errorf("invalid %s %q: must be a positive duration such as 5s", EnvStorageRequestTimeout, value)
+		}
+		opts.RequestTimeout = timeout
+	}
+
+	return opts, nil
+}
+
+// loadAWSConfig loads the default AWS config with the retry options applied.
+func loadAWSConfig(ctx context.Context, opts AWSRetryOptions) (aws.Config, error) {
+	var loadOpts []func(*config.LoadOptions) error
+	if opts.MaxAttempts > 0 {
+		loadOpts = append(loadOpts, config.WithRetryer(func() aws.Retryer {
+			return retry.NewStandard(func(o *retry.StandardOptions) {
+				o.MaxAttempts = opts.MaxAttempts
+			})
+		}))
+	}
+	if opts.RequestTimeout > 0 {
+		loadOpts = append(loadOpts, config.WithHTTPClient(awshttp.NewBuildableClient().WithTimeout(opts.RequestTimeout)))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	return cfg, nil
+}
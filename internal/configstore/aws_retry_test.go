@@ -0,0 +1,60 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+)
+
+func TestAWSRetryOptionsFromEnv(t *testing.T) {
+	opts, err := awsRetryOptionsFromEnv()
+	if err != nil {
+		t.Fatalf("awsRetryOptionsFromEnv() error = %v", err)
+	}
+	if opts.MaxAttempts != DefaultAWSMaxAttempts || opts.RequestTimeout != 0 {
+		t.Errorf("unexpected defaults: %+v", opts)
+	}
+
+	t.Setenv(EnvStorageMaxAttempts, "8")
+	t.Setenv(EnvStorageRequestTimeout, "3s")
+	opts, err = awsRetryOptionsFromEnv()
+	if err != nil {
+		t.Fatalf("awsRetryOptionsFromEnv() error = %v", err)
+	}
+	if opts.MaxAttempts != 8 || opts.RequestTimeout != 3*time.Second {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	for _, tt := range []struct{ env, value string }{
+		{EnvStorageMaxAttempts, "0"},
+		{EnvStorageMaxAttempts, "many"},
+		{EnvStorageRequestTimeout, "-1s"},
+	} {
+		t.Run(tt.env+"="+tt.value, func(t *testing.T) {
+			t.Setenv(tt.env, tt.value)
+			if _, err := awsRetryOptionsFromEnv(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestLoadAWSConfigRetry(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	cfg, err := loadAWSConfig(context.Background(), AWSRetryOptions{MaxAttempts: 7, RequestTimeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("loadAWSConfig() error = %v", err)
+	}
+	if got := cfg.Retryer().MaxAttempts(); got != 7 {
+		t.Errorf("MaxAttempts = %d, want 7", got)
+	}
+	client, ok := cfg.HTTPClient.(*awshttp.BuildableClient)
+	if !ok || client.GetTimeout() != 2*time.Second {
+		t.Errorf("expected an HTTP client with a 2s timeout, got %#v", cfg.HTTPClient)
+	}
+}
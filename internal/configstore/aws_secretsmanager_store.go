@@ -0,0 +1,469 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+)
+
+// Environment variables for the AWS Secrets Manager store.
+const (
+	EnvAWSSecretsManagerSecretName = "AWS_SECRETS_MANAGER_SECRET_NAME"
+	EnvAWSSecretsManagerKMSKeyID   = "AWS_SECRETS_MANAGER_KMS_KEY_ID"
+	EnvAWSSecretsManagerTags       = "AWS_SECRETS_MANAGER_TAGS"
+	EnvAWSSecretsManagerIndividual = "AWS_SECRETS_MANAGER_INDIVIDUAL_SECRETS"
+)
+
+// StorageModeAWSSecretsManager saves credentials to AWS Secrets Manager.
+const StorageModeAWSSecretsManager = "aws-secretsmanager"
+
+// SecretsManagerClient defines the interface for AWS Secrets Manager operations.
+type SecretsManagerClient interface {
+	CreateSecret(ctx context.Context, params *secretsmanager.CreateSecretInput,
+		optFns ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error)
+	PutSecretValue(ctx context.Context, params *secretsmanager.PutSecretValueInput,
+		optFns ...func(*secretsmanager.Options)) (*secretsmanager.PutSecretValueOutput, error)
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput,
+		optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+	TagResource(ctx context.Context, params *secretsmanager.TagResourceInput,
+		optFns ...func(*secretsmanager.Options)) (*secretsmanager.TagResourceOutput, error)
+	DeleteSecret(ctx context.Context, params *secretsmanager.DeleteSecretInput,
+		optFns ...func(*secretsmanager.Options)) (*secretsmanager.DeleteSecretOutput, error)
+	ListSecretVersionIds(ctx context.Context, params *secretsmanager.ListSecretVersionIdsInput,
+		optFns ...func(*secretsmanager.Options)) (*secretsmanager.ListSecretVersionIdsOutput, error)
+}
+
+// AWSSecretsManagerStore saves credentials to AWS Secrets Manager.
+//
+// By default all values are stored as a single JSON secret keyed by the
+// environment variable names. With individual secrets enabled, SecretName is
+// used as a prefix and each value is stored as its own secret.
+type AWSSecretsManagerStore struct {
+	SecretName        string
+	KMSKeyID          string
+	Tags              map[string]string
+	IndividualSecrets bool
+	Retry             AWSRetryOptions
+	client            SecretsManagerClient
+}
+
+// SecretsManagerStoreOption is a functional option for configuring AWSSecretsManagerStore.
+type SecretsManagerStoreOption func(*AWSSecretsManagerStore)
+
+// WithSecretsManagerKMSKey sets a custom KMS key ID for secret encryption.
+func WithSecretsManagerKMSKey(keyID string) SecretsManagerStoreOption {
+	return func(s *AWSSecretsManagerStore) {
+		s.KMSKeyID = keyID
+	}
+}
+
+// WithSecretsManagerTags adds AWS tags to all created or updated secrets.
+func WithSecretsManagerTags(tags map[string]string) SecretsManagerStoreOption {
+	return func(s *AWSSecretsManagerStore) {
+		s.Tags = tags
+	}
+}
+
+// WithIndividualSecrets stores each credential as its own secret under the
+// configured name prefix instead of a single JSON secret.
+func WithIndividualSecrets() SecretsManagerStoreOption {
+	return func(s *AWSSecretsManagerStore) {
+		s.IndividualSecrets = true
+	}
+}
+
+// WithSecretsManagerRetry sets how failed Secrets Manager requests are
+// retried. It is ignored when a client is set with WithSecretsManagerClient.
+func WithSecretsManagerRetry(retry AWSRetryOptions) SecretsManagerStoreOption {
+	return func(s *AWSSecretsManagerStore) {
+		s.Retry = retry
+	}
+}
+
+// WithSecretsManagerClient sets a custom Secrets Manager client.
+func WithSecretsManagerClient(client SecretsManagerClient) SecretsManagerStoreOption {
+	return func(s *AWSSecretsManagerStore) {
+		s.client = client
+	}
+}
+
+// NewAWSSecretsManagerStore creates a new AWS Secrets Manager backend.
+// In individual secrets mode the name is normalized to end with a slash.
+func NewAWSSecretsManagerStore(name string, opts ...SecretsManagerStoreOption) (*AWSSecretsManagerStore, error) {
+	if name == "" {
+		return nil, fmt.Errorf("secret name cannot be empty")
+	}
+
+	store := &AWSSecretsManagerStore{
+		SecretName: name,
+	}
+
+	for _, opt := range opts {
+		opt(store)
+	}
+
+	if store.IndividualSecrets && !strings.HasSuffix(store.SecretName, "/") {
+		store.SecretName = store.SecretName + "/"
+	}
+
+	if store.client == nil {
+		cfg, err := loadAWSConfig(context.Background(), store.Retry)
+		if err != nil {
+			return nil, err
+		}
+		store.client = secretsmanager.NewFromConfig(cfg)
+	}
+
+	return store, nil
+}
+
+// newAWSSecretsManagerStoreFromEnv creates an AWSSecretsManagerStore from environment variables.
+func newAWSSecretsManagerStoreFromEnv() (*AWSSecretsManagerStore, error) {
+	name := os.Getenv(EnvAWSSecretsManagerSecretName)
+	if name == "" {
+		return nil, fmt.Errorf("%s is required when using %s storage mode", EnvAWSSecretsManagerSecretName, StorageModeAWSSecretsManager)
+	}
+
+	var opts []SecretsManagerStoreOption
+
+	if kmsKeyID := os.Getenv(EnvAWSSecretsManagerKMSKeyID); kmsKeyID != "" {
+		opts = append(opts, WithSecretsManagerKMSKey(kmsKeyID))
+	}
+
+	if tagsJSON := os.Getenv(EnvAWSSecretsManagerTags); tagsJSON != "" {
+		var tags map[string]string
+		if err := json.Unmarshal([]byte(tagsJSON), &tags); err != nil {
+			return nil, fmt.Errorf("failed to parse %s as JSON: %w", EnvAWSSecretsManagerTags, err)
+		}
+		opts = append(opts, WithSecretsManagerTags(tags))
+	}
+
+	if v, _ := strconv.ParseBool(os.Getenv(EnvAWSSecretsManagerIndividual)); v {
+		opts = append(opts, WithIndividualSecrets())
+	}
+
+	retry, err := awsRetryOptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, WithSecretsManagerRetry(retry))
+
+	return NewAWSSecretsManagerStore(name, opts...)
+}
+
+// Save writes credentials to AWS Secrets Manager, skipping values that are
+// already stored. In JSON mode, keys already present in the secret are preserved.
+func (s *AWSSecretsManagerStore) Save(ctx context.Context, creds *AppCredentials) error {
+	values := credentialValues(creds)
+
+	if s.IndividualSecrets {
+		existing, err := s.readValues(ctx)
+		if err != nil {
+			return err
+		}
+		for name, value := range changedValues(existing, values) {
+			if err := s.putSecret(ctx, s.SecretName+name, value); err != nil {
+				return fmt.Errorf("failed to save secret %s: %w", name, err)
+			}
+		}
+		return nil
+	}
+
+	return s.mergeJSONSecret(ctx, values)
+}
+
+// Status returns the current registration state by checking the stored secret values.
+func (s *AWSSecretsManagerStore) Status(ctx context.Context) (*InstallerStatus, error) {
+	values, err := s.readValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return statusFromValues(values), nil
+}
+
+// Load reads the stored credentials from Secrets Manager.
+func (s *AWSSecretsManagerStore) Load(ctx context.Context) (*AppCredentials, error) {
+	values, err := s.readValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return credentialsFromValues(values)
+}
+
+// DisableInstaller sets GITHUB_APP_INSTALLER_ENABLED=false in the secret.
+func (s *AWSSecretsManagerStore) DisableInstaller(ctx context.Context) error {
+	if s.IndividualSecrets {
+		return s.putSecret(ctx, s.SecretName+EnvGitHubAppInstallerEnabled, "false")
+	}
+	return s.mergeJSONSecret(ctx, map[string]string{EnvGitHubAppInstallerEnabled: "false"})
+}
+
+// Delete removes the stored secrets. Secrets are deleted without a recovery
+// window so the names can be reused by a later registration.
+func (s *AWSSecretsManagerStore) Delete(ctx context.Context) error {
+	if !s.IndividualSecrets {
+		return s.deleteSecret(ctx, s.SecretName)
+	}
+	for _, key := range knownKeys {
+		if err := s.deleteSecret(ctx, s.SecretName+key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ping reads the secret (the app ID secret in individual mode). A secret
+// that does not exist yet is not an error.
+func (s *AWSSecretsManagerStore) Ping(ctx context.Context) error {
+	name := s.SecretName
+	if s.IndividualSecrets {
+		name += EnvGitHubAppID
+	}
+	if _, err := s.getSecretString(ctx, name); err != nil && !isSecretNotFound(err) {
+		return fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+	return nil
+}
+
+// Rotate replaces the stored credentials with creds. In JSON mode this is a
+// single new secret version; in individual mode the new values are written
+// before credential secrets that creds does not set are deleted.
+func (s *AWSSecretsManagerStore) Rotate(ctx context.Context, creds *AppCredentials) error {
+	values := credentialValues(creds)
+	if !s.IndividualSecrets {
+		return s.mergeJSONSecret(ctx, values, staleKeys(values)...)
+	}
+
+	if err := s.Save(ctx, creds); err != nil {
+		return err
+	}
+	for _, key := range staleKeys(values) {
+		if err := s.deleteSecret(ctx, s.SecretName+key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// History returns the retained versions of the JSON secret, identified by
+// their Secrets Manager version ID. Secrets Manager keeps versions without a
+// staging label for a limited time only. Not supported with individual secrets.
+func (s *AWSSecretsManagerStore) History(ctx context.Context) ([]CredentialVersion, error) {
+	if s.IndividualSecrets {
+		return nil, fmt.Errorf("credential history is not supported with individual secrets")
+	}
+
+	var entries []types.SecretVersionsListEntry
+	var nextToken *string
+	for {
+		output, err := s.client.ListSecretVersionIds(ctx, &secretsmanager.ListSecretVersionIdsInput{
+			SecretId:          aws.String(s.SecretName),
+			IncludeDeprecated: aws.Bool(true),
+			NextToken:         nextToken,
+		})
+		if err != nil {
+			if isSecretNotFound(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to list versions of secret %s: %w", s.SecretName, err)
+		}
+		entries = append(entries, output.Versions...)
+		if output.NextToken == nil {
+			break
+		}
+		nextToken = output.NextToken
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return aws.ToTime(entries[i].CreatedDate).After(aws.ToTime(entries[j].CreatedDate))
+	})
+
+	var versions []CredentialVersion
+	for _, entry := range entries {
+		output, err := s.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+			SecretId:  aws.String(s.SecretName),
+			VersionId: entry.VersionId,
+		})
+		if err != nil {
+			if isSecretNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read version %s of secret %s: %w", aws.ToString(entry.VersionId), s.SecretName, err)
+		}
+
+		values := make(map[string]string)
+		if err := json.Unmarshal([]byte(aws.ToString(output.SecretString)), &values); err != nil {
+			continue
+		}
+		creds, err := credentialsFromValues(values)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, CredentialVersion{
+			ID:          aws.ToString(entry.VersionId),
+			CreatedAt:   aws.ToTime(entry.CreatedDate),
+			Current:     slices.Contains(entry.VersionStages, "AWSCURRENT"),
+			Credentials: creds,
+		})
+	}
+
+	return compactHistory(versions), nil
+}
+
+// Rollback writes the given version's credentials as a new secret version.
+func (s *AWSSecretsManagerStore) Rollback(ctx context.Context, id string) error {
+	return rollback(ctx, s, id)
+}
+
+// mergeJSONSecret merges values into the JSON secret, drops the keys listed
+// in remove, and writes it back unless nothing changed.
+func (s *AWSSecretsManagerStore) mergeJSONSecret(ctx context.Context, values map[string]string, remove ...string) error {
+	existing, err := s.readJSONSecret(ctx)
+	if err != nil {
+		return err
+	}
+	if !mergeValues(existing, values, remove) {
+		return nil
+	}
+
+	payload, err := json.Marshal(existing)
+	if err != nil {
+		return fmt.Errorf("failed to encode secret: %w", err)
+	}
+
+	if err := s.putSecret(ctx, s.SecretName, string(payload)); err != nil {
+		return fmt.Errorf("failed to save secret %s: %w", s.SecretName, err)
+	}
+	return nil
+}
+
+// readValues returns the stored values keyed by environment variable name.
+// Missing secrets are returned as absent keys rather than errors.
+func (s *AWSSecretsManagerStore) readValues(ctx context.Context) (map[string]string, error) {
+	if !s.IndividualSecrets {
+		return s.readJSONSecret(ctx)
+	}
+
+	values := make(map[string]string)
+	for _, key := range knownKeys {
+		value, err := s.getSecretString(ctx, s.SecretName+key)
+		if err != nil {
+			if isSecretNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// readJSONSecret reads and decodes the JSON secret, returning an empty map if it doesn't exist.
+func (s *AWSSecretsManagerStore) readJSONSecret(ctx context.Context) (map[string]string, error) {
+	values := make(map[string]string)
+
+	raw, err := s.getSecretString(ctx, s.SecretName)
+	if err != nil {
+		if isSecretNotFound(err) {
+			return values, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(raw), &values); err != nil {
+		return nil, fmt.Errorf("secret %s is not a JSON object: %w", s.SecretName, err)
+	}
+	return values, nil
+}
+
+// putSecret updates a secret value, creating the secret if it doesn't exist yet.
+func (s *AWSSecretsManagerStore) putSecret(ctx context.Context, name, value string) error {
+	_, err := s.client.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(name),
+		SecretString: aws.String(value),
+	})
+	if err == nil {
+		return s.tagSecret(ctx, name)
+	}
+	if !isSecretNotFound(err) {
+		return err
+	}
+
+	input := &secretsmanager.CreateSecretInput{
+		Name:         aws.String(name),
+		SecretString: aws.String(value),
+		Tags:         s.secretTags(),
+	}
+	if s.KMSKeyID != "" {
+		input.KmsKeyId = aws.String(s.KMSKeyID)
+	}
+
+	_, err = s.client.CreateSecret(ctx, input)
+	return err
+}
+
+// deleteSecret force-deletes a secret, ignoring secrets that don't exist.
+func (s *AWSSecretsManagerStore) deleteSecret(ctx context.Context, name string) error {
+	_, err := s.client.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{
+		SecretId:                   aws.String(name),
+		ForceDeleteWithoutRecovery: aws.Bool(true),
+	})
+	if err != nil && !isSecretNotFound(err) {
+		return fmt.Errorf("failed to delete secret %s: %w", name, err)
+	}
+	return nil
+}
+
+// tagSecret applies the configured tags to an existing secret.
+func (s *AWSSecretsManagerStore) tagSecret(ctx context.Context, name string) error {
+	if len(s.Tags) == 0 {
+		return nil
+	}
+	_, err := s.client.TagResource(ctx, &secretsmanager.TagResourceInput{
+		SecretId: aws.String(name),
+		Tags:     s.secretTags(),
+	})
+	return err
+}
+
+func (s *AWSSecretsManagerStore) secretTags() []types.Tag {
+	var tags []types.Tag
+	for key, value := range s.Tags {
+		tags = append(tags, types.Tag{
+			Key:   aws.String(key),
+			Value: aws.String(value),
+		})
+	}
+	return tags
+}
+
+func (s *AWSSecretsManagerStore) getSecretString(ctx context.Context, name string) (string, error) {
+	output, err := s.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(name),
+	})
+	if err != nil {
+		return "", err
+	}
+	if output.SecretString == nil {
+		return "", fmt.Errorf("secret %s missing string value", name)
+	}
+	return aws.ToString(output.SecretString), nil
+}
+
+func isSecretNotFound(err error) bool {
+	var notFound *types.ResourceNotFoundException
+	return errors.As(err, &notFound)
+}
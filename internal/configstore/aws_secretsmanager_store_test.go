@@ -0,0 +1,241 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+)
+
+// fakeSecretsManager is an in-memory SecretsManagerClient for tests.
+type fakeSecretsManager struct {
+	secrets map[string]string
+	kmsKeys map[string]string
+	tagged  map[string]int
+}
+
+func newFakeSecretsManager() *fakeSecretsManager {
+	return &fakeSecretsManager{
+		secrets: make(map[string]string),
+		kmsKeys: make(map[string]string),
+		tagged:  make(map[string]int),
+	}
+}
+
+func (f *fakeSecretsManager) CreateSecret(_ context.Context, in *secretsmanager.CreateSecretInput,
+	_ ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error) {
+	name := aws.ToString(in.Name)
+	f.secrets[name] = aws.ToString(in.SecretString)
+	f.kmsKeys[name] = aws.ToString(in.KmsKeyId)
+	if len(in.Tags) > 0 {
+		f.tagged[name]++
+	}
+	return &secretsmanager.CreateSecretOutput{Name: in.Name}, nil
+}
+
+func (f *fakeSecretsManager) PutSecretValue(_ context.Context, in *secretsmanager.PutSecretValueInput,
+	_ ...func(*secretsmanager.Options)) (*secretsmanager.PutSecretValueOutput, error) {
+	name := aws.ToString(in.SecretId)
+	if _, ok := f.secrets[name]; !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("not found")}
+	}
+	f.secrets[name] = aws.ToString(in.SecretString)
+	return &secretsmanager.PutSecretValueOutput{}, nil
+}
+
+func (f *fakeSecretsManager) GetSecretValue(_ context.Context, in *secretsmanager.GetSecretValueInput,
+	_ ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	value, ok := f.secrets[aws.ToString(in.SecretId)]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("not found")}
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(value)}, nil
+}
+
+func (f *fakeSecretsManager) TagResource(_ context.Context, in *secretsmanager.TagResourceInput,
+	_ ...func(*secretsmanager.Options)) (*secretsmanager.TagResourceOutput, error) {
+	f.tagged[aws.ToString(in.SecretId)]++
+	return &secretsmanager.TagResourceOutput{}, nil
+}
+
+func (f *fakeSecretsManager) DeleteSecret(_ context.Context, in *secretsmanager.DeleteSecretInput,
+	_ ...func(*secretsmanager.Options)) (*secretsmanager.DeleteSecretOutput, error) {
+	name := aws.ToString(in.SecretId)
+	if _, ok := f.secrets[name]; !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("not found")}
+	}
+	delete(f.secrets, name)
+	return &secretsmanager.DeleteSecretOutput{}, nil
+}
+
+func (f *fakeSecretsManager) ListSecretVersionIds(_ context.Context, in *secretsmanager.ListSecretVersionIdsInput,
+	_ ...func(*secretsmanager.Options)) (*secretsmanager.ListSecretVersionIdsOutput, error) {
+	name := aws.ToString(in.SecretId)
+	if _, ok := f.secrets[name]; !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("not found")}
+	}
+	return &secretsmanager.ListSecretVersionIdsOutput{
+		Versions: []types.SecretVersionsListEntry{{VersionId: aws.String("current"), VersionStages: []string{"AWSCURRENT"}}},
+	}, nil
+}
+
+func TestAWSSecretsManagerStoreJSON(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeSecretsManager()
+	client.secrets["octo-sts/app"] = `{"UNRELATED":"keep"}`
+
+	store, err := NewAWSSecretsManagerStore("octo-sts/app",
+		WithSecretsManagerClient(client),
+		WithSecretsManagerTags(map[string]string{"team": "platform"}),
+	)
+	if err != nil {
+		t.Fatalf("NewAWSSecretsManagerStore() error = %v", err)
+	}
+
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	var stored map[string]string
+	if err := json.Unmarshal([]byte(client.secrets["octo-sts/app"]), &stored); err != nil {
+		t.Fatalf("stored secret is not JSON: %v", err)
+	}
+	if stored["UNRELATED"] != "keep" {
+		t.Error("expected existing keys to be preserved")
+	}
+	if stored[EnvGitHubAppID] != "1234" || stored["STS_DOMAIN"] != "sts.example.com" {
+		t.Errorf("unexpected stored values: %v", stored)
+	}
+	if client.tagged["octo-sts/app"] == 0 {
+		t.Error("expected existing secret to be tagged")
+	}
+
+	status, err := store.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if !status.Registered || status.AppID != 1234 || status.InstallerDisabled {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	creds, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if creds.AppID != 1234 || creds.CustomFields[EnvSTSDomain] != "sts.example.com" {
+		t.Errorf("Load() = %+v, want the saved credentials and STS_DOMAIN", creds)
+	}
+
+	if err := store.DisableInstaller(ctx); err != nil {
+		t.Fatalf("DisableInstaller() error = %v", err)
+	}
+	status, err = store.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if !status.InstallerDisabled {
+		t.Error("expected installer to be disabled")
+	}
+}
+
+func TestAWSSecretsManagerStoreIndividual(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeSecretsManager()
+
+	store, err := NewAWSSecretsManagerStore("octo-sts",
+		WithSecretsManagerClient(client),
+		WithSecretsManagerKMSKey("alias/octo-sts"),
+		WithIndividualSecrets(),
+	)
+	if err != nil {
+		t.Fatalf("NewAWSSecretsManagerStore() error = %v", err)
+	}
+	if store.SecretName != "octo-sts/" {
+		t.Errorf("expected prefix to be normalized, got %q", store.SecretName)
+	}
+
+	status, err := store.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.Registered {
+		t.Error("expected empty store to be unregistered")
+	}
+
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	name := "octo-sts/" + EnvGitHubAppPrivateKey
+	if client.secrets[name] != testAppCredentials().PrivateKey {
+		t.Errorf("expected private key in %s, got %q", name, client.secrets[name])
+	}
+	if client.kmsKeys[name] != "alias/octo-sts" {
+		t.Errorf("expected KMS key on created secret, got %q", client.kmsKeys[name])
+	}
+
+	status, err = store.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if !status.Registered || status.AppSlug != "octo-sts-test" {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	creds, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if creds.PrivateKey != testAppCredentials().PrivateKey || creds.CustomFields[EnvSTSDomain] != "sts.example.com" {
+		t.Errorf("Load() = %+v, want the saved credentials and STS_DOMAIN", creds)
+	}
+}
+
+func TestNewFromEnvSecretsManagerRequiresName(t *testing.T) {
+	t.Setenv(EnvStorageMode, StorageModeAWSSecretsManager)
+	t.Setenv(EnvAWSSecretsManagerSecretName, "")
+
+	if _, err := NewFromEnv(); err == nil {
+		t.Fatal("expected error when secret name is missing")
+	}
+}
+
+func TestAWSSecretsManagerStoreRotateAndDelete(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeSecretsManager()
+
+	store, err := NewAWSSecretsManagerStore("/octo-sts/", WithIndividualSecrets(), WithSecretsManagerClient(client))
+	if err != nil {
+		t.Fatalf("NewAWSSecretsManagerStore() error = %v", err)
+	}
+
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	rotated := testAppCredentials()
+	rotated.AppSlug = ""
+	rotated.PrivateKey = "rotated-key"
+	if err := store.Rotate(ctx, rotated); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if _, ok := client.secrets["/octo-sts/"+EnvGitHubAppSlug]; ok {
+		t.Error("expected stale app slug secret to be deleted")
+	}
+	if got := client.secrets["/octo-sts/"+EnvGitHubAppPrivateKey]; got != "rotated-key" {
+		t.Errorf("expected rotated private key, got %q", got)
+	}
+
+	if err := store.Delete(ctx); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if len(client.secrets) != 0 {
+		t.Errorf("expected all secrets to be deleted, got %v", client.secrets)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// EnvAWSSSMChangeQueueURL is the URL of an SQS queue receiving the
+// EventBridge "Parameter Store Change" events of the credential parameters.
+// When set, Watch checks for changes as soon as an event arrives instead of
+// waiting for the next poll.
+const EnvAWSSSMChangeQueueURL = "AWS_SSM_CHANGE_QUEUE_URL"
+
+// sqsWaitTime is how long each ReceiveMessage call long-polls the queue, the
+// maximum SQS allows.
+const sqsWaitTime = 20
+
+// sqsRetryDelay is how long the queue consumer waits after a failed call.
+const sqsRetryDelay = 5 * time.Second
+
+// SQSClient defines the SQS operations used to consume change notifications.
+type SQSClient interface {
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput,
+		optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessageBatch(ctx context.Context, params *sqs.DeleteMessageBatchInput,
+		optFns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error)
+}
+
+// WithSSMChangeQueue consumes the EventBridge parameter change events
+// delivered to the SQS queue at queueURL, so Watch reports changes within
+// seconds. Polling continues at WatchInterval in case events are lost.
+func WithSSMChangeQueue(queueURL string) SSMStoreOption {
+	return func(s *AWSSSMStore) {
+		s.ChangeQueueURL = queueURL
+	}
+}
+
+// WithSQSClient sets a custom SQS client for WithSSMChangeQueue.
+func WithSQSClient(client SQSClient) SSMStoreOption {
+	return func(s *AWSSSMStore) {
+		s.sqsClient = client
+	}
+}
+
+// parameterChangeEvent is the part of an EventBridge "Parameter Store
+// Change" event that names the parameter.
+type parameterChangeEvent struct {
+	Source string `json:"source"`
+	Detail struct {
+		Name string `json:"name"`
+	} `json:"detail"`
+}
+
+// watchWithQueue checks for changes when a change event arrives, and every
+// WatchInterval in case events are lost.
+func (s *AWSSSMStore) watchWithQueue(ctx context.Context) <-chan struct{} {
+	interval := s.WatchInterval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	notifications := s.consumeChanges(ctx)
+
+	trigger := make(chan struct{}, 1)
+	go func() {
+		defer close(trigger)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case _, ok := <-notifications:
+				if !ok {
+					return
+				}
+			}
+			select {
+			case trigger <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	// Debounce, since a save emits one event per parameter
+	return watchChanges(ctx, trigger, watchDebounce, s.snapshot)
+}
+
+// consumeChanges long-polls the change queue and sends to the returned
+// channel whenever a message may concern the store's parameters. Messages
+// are deleted once read. The channel is closed when ctx is done.
+func (s *AWSSSMStore) consumeChanges(ctx context.Context) <-chan struct{} {
+	trigger := make(chan struct{}, 1)
+	go func() {
+		defer close(trigger)
+		for ctx.Err() == nil {
+			output, err := s.sqsClient.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+				QueueUrl:            aws.String(s.ChangeQueueURL),
+				MaxNumberOfMessages: 10,
+				WaitTimeSeconds:     sqsWaitTime,
+			})
+			if err != nil {
+				select {
+				case <-ctx.Done():
+				case <-time.After(sqsRetryDelay):
+				}
+				continue
+			}
+
+			changed := false
+			var entries []sqstypes.DeleteMessageBatchRequestEntry
+			for i, msg := range output.Messages {
+				if s.concernsParameters(aws.ToString(msg.Body)) {
+					changed = true
+				}
+				entries = append(entries, sqstypes.DeleteMessageBatchRequestEntry{
+					Id:            aws.String(strconv.Itoa(i)),
+					ReceiptHandle: msg.ReceiptHandle,
+				})
+			}
+			if len(entries) > 0 {
+				// A failed delete only redelivers the events, which is harmless
+				_, _ = s.sqsClient.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+					QueueUrl: aws.String(s.ChangeQueueURL),
+					Entries:  entries,
+				})
+			}
+			if changed {
+				select {
+				case trigger <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return trigger
+}
+
+// concernsParameters reports whether a change event may be about one of the
+// store's parameters. Messages that are not parameter change events are
+// assumed to be, since checking for changes is cheap.
+func (s *AWSSSMStore) concernsParameters(body string) bool {
+	var event parameterChangeEvent
+	if err := json.Unmarshal([]byte(body), &event); err != nil || event.Source != "aws.ssm" || event.Detail.Name == "" {
+		return true
+	}
+	name := event.Detail.Name
+	return strings.HasPrefix(name, s.ParameterPrefix) || slices.Contains(slices.Collect(maps.Values(s.ParameterNames)), name)
+}
@@ -0,0 +1,130 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// fakeSQS is an SQSClient whose ReceiveMessage returns the bodies sent to
+// messages, one batch per send, and blocks until then.
+type fakeSQS struct {
+	messages chan []string
+
+	mu      sync.Mutex
+	deleted int
+}
+
+func (f *fakeSQS) ReceiveMessage(ctx context.Context, _ *sqs.ReceiveMessageInput,
+	_ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case bodies := <-f.messages:
+		out := &sqs.ReceiveMessageOutput{}
+		for _, body := range bodies {
+			out.Messages = append(out.Messages, sqstypes.Message{
+				Body:          aws.String(body),
+				ReceiptHandle: aws.String("handle"),
+			})
+		}
+		return out, nil
+	}
+}
+
+func (f *fakeSQS) DeleteMessageBatch(_ context.Context, in *sqs.DeleteMessageBatchInput,
+	_ ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted += len(in.Entries)
+	return &sqs.DeleteMessageBatchOutput{}, nil
+}
+
+func (f *fakeSQS) deletedCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.deleted
+}
+
+func parameterChange(name string) string {
+	return `{"source":"aws.ssm","detail-type":"Parameter Store Change","detail":{"name":"` + name + `","operation":"Update"}}`
+}
+
+func TestAWSSSMStoreWatchChangeQueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := &fakeSSM{params: map[string]string{}}
+	queue := &fakeSQS{messages: make(chan []string)}
+	store, err := NewAWSSSMStore("/octo-sts",
+		WithSSMClient(client),
+		WithSSMWatchInterval(time.Hour),
+		WithSSMChangeQueue("https://sqs.us-east-1.amazonaws.com/123456789012/octo-sts-changes"),
+		WithSQSClient(queue),
+	)
+	if err != nil {
+		t.Fatalf("NewAWSSSMStore() error = %v", err)
+	}
+
+	changes, err := store.Watch(ctx)
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	// A rotation elsewhere is reported on its event, long before the next poll
+	if _, err := client.PutParameter(ctx, &ssm.PutParameterInput{
+		Name:  aws.String("/octo-sts/" + EnvGitHubWebhookSecret),
+		Value: aws.String("rotated"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	queue.messages <- []string{parameterChange("/octo-sts/" + EnvGitHubWebhookSecret), parameterChange("/other/param")}
+	waitForChange(t, changes)
+
+	if got := queue.deletedCount(); got != 2 {
+		t.Errorf("deleted %d messages, want 2", got)
+	}
+
+	cancel()
+	for range changes {
+		// wait for the watch to stop
+	}
+}
+
+func TestAWSSSMStoreConcernsParameters(t *testing.T) {
+	store, err := NewAWSSSMStore("/octo-sts",
+		WithSSMClient(&fakeSSM{params: map[string]string{}}),
+		WithParameterNames(map[string]string{EnvGitHubAppID: "/shared/github/app_id"}),
+	)
+	if err != nil {
+		t.Fatalf("NewAWSSSMStore() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{name: "prefixed parameter", body: parameterChange("/octo-sts/GITHUB_APP_PRIVATE_KEY"), want: true},
+		{name: "mapped parameter", body: parameterChange("/shared/github/app_id"), want: true},
+		{name: "other parameter", body: parameterChange("/octo-sts-staging/GITHUB_APP_ID"), want: false},
+		{name: "other event", body: `{"source":"custom.rotation"}`, want: true},
+		{name: "not JSON", body: "rotated", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := store.concernsParameters(tt.body); got != tt.want {
+				t.Errorf("concernsParameters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
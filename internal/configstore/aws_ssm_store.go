@@ -0,0 +1,935 @@
+// Copyright 2025 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// SSMClient defines the interface for AWS SSM operations.
+type SSMClient interface {
+	PutParameter(ctx context.Context, params *ssm.PutParameterInput,
+		optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput,
+		optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+	GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput,
+		optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error)
+	DeleteParameters(ctx context.Context, params *ssm.DeleteParametersInput,
+		optFns ...func(*ssm.Options)) (*ssm.DeleteParametersOutput, error)
+	GetParameterHistory(ctx context.Context, params *ssm.GetParameterHistoryInput,
+		optFns ...func(*ssm.Options)) (*ssm.GetParameterHistoryOutput, error)
+	AddTagsToResource(ctx context.Context, params *ssm.AddTagsToResourceInput,
+		optFns ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error)
+}
+
+// Environment variables for SSM parameter tiers and policies. Durations use
+// Go syntax, e.g. "2160h" for 90 days.
+const (
+	EnvAWSSSMTier                   = "AWS_SSM_TIER"
+	EnvAWSSSMExpiration             = "AWS_SSM_EXPIRATION"
+	EnvAWSSSMExpirationNotification = "AWS_SSM_EXPIRATION_NOTIFICATION"
+	EnvAWSSSMNoChangeNotification   = "AWS_SSM_NO_CHANGE_NOTIFICATION"
+)
+
+// Environment variables for writing parameters in another account by
+// assuming a role there.
+const (
+	EnvAWSSSMAssumeRoleARN        = "AWS_SSM_ASSUME_ROLE_ARN"
+	EnvAWSSSMAssumeRoleExternalID = "AWS_SSM_ASSUME_ROLE_EXTERNAL_ID"
+)
+
+// ssmAssumeRoleSessionName is the session name used when assuming the SSM role.
+const ssmAssumeRoleSessionName = "octo-sts-configstore"
+
+// EnvAWSSSMKMSKeyIDs maps credential keys to the KMS keys encrypting their
+// parameters as a JSON object, e.g. {"GITHUB_APP_PRIVATE_KEY": "alias/pem"}.
+const EnvAWSSSMKMSKeyIDs = "AWS_SSM_KMS_KEY_IDS"
+
+// EnvAWSSSMParameterNames maps credential keys to parameter names as a JSON
+// object, e.g. {"GITHUB_APP_ID": "/{prefix}/github/app_id"}.
+const EnvAWSSSMParameterNames = "AWS_SSM_PARAMETER_NAMES"
+
+// ssmDeleteBatchSize is the maximum number of names DeleteParameters accepts.
+const ssmDeleteBatchSize = 10
+
+// ssmStandardMaxValueSize is the largest value a Standard tier parameter holds.
+const ssmStandardMaxValueSize = 4096
+
+// AWSSSMStore saves credentials to AWS SSM Parameter Store with encryption.
+type AWSSSMStore struct {
+	ParameterPrefix string
+	KMSKeyID        string
+	KMSKeyIDs       map[string]string
+	Tags            map[string]string
+	Tier            types.ParameterTier
+	Policies        SSMParameterPolicies
+	ParameterNames  map[string]string
+	WatchInterval   time.Duration
+	ChangeQueueURL  string
+	AssumeRoleARN   string
+	ExternalID      string
+	Retry           AWSRetryOptions
+	ssmClient       SSMClient
+	sqsClient       SQSClient
+}
+
+// SSMParameterPolicies configures the parameter policies attached to each
+// saved parameter. Zero durations are omitted. Policies require the Advanced
+// tier.
+type SSMParameterPolicies struct {
+	// Expiration deletes the parameter this long after it is saved.
+	Expiration time.Duration
+	// ExpirationNotification emits an EventBridge event this long before
+	// the parameter expires. Requires Expiration.
+	ExpirationNotification time.Duration
+	// NoChangeNotification emits an EventBridge event when the parameter
+	// has not been changed for this long.
+	NoChangeNotification time.Duration
+}
+
+// empty reports whether no policies are configured.
+func (p SSMParameterPolicies) empty() bool {
+	return p.Expiration == 0 && p.ExpirationNotification == 0 && p.NoChangeNotification == 0
+}
+
+// document returns the policies as the JSON array PutParameter expects, with
+// the expiration timestamp computed from now.
+func (p SSMParameterPolicies) document(now time.Time) (string, error) {
+	var policies []map[string]any
+	if p.Expiration > 0 {
+		policies = append(policies, map[string]any{
+			"Type":       "Expiration",
+			"Version":    "1.0",
+			"Attributes": map[string]string{"Timestamp": now.Add(p.Expiration).UTC().Format(time.RFC3339)},
+		})
+	}
+	if p.ExpirationNotification > 0 {
+		policies = append(policies, map[string]any{
+			"Type":       "ExpirationNotification",
+			"Version":    "1.0",
+			"Attributes": ssmPolicyDuration("Before", p.ExpirationNotification),
+		})
+	}
+	if p.NoChangeNotification > 0 {
+		policies = append(policies, map[string]any{
+			"Type":       "NoChangeNotification",
+			"Version":    "1.0",
+			"Attributes": ssmPolicyDuration("After", p.NoChangeNotification),
+		})
+	}
+	data, err := json.Marshal(policies)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ssmPolicyDuration expresses d in whole days when possible, else in hours.
+func ssmPolicyDuration(field string, d time.Duration) map[string]string {
+	const day = 24 * time.Hour
+	if d%day == 0 {
+		return map[string]string{field: strconv.FormatInt(int64(d/day), 10), "Unit": "Days"}
+	}
+	hours := max(int64((d+time.Hour-1)/time.Hour), 1)
+	return map[string]string{field: strconv.FormatInt(hours, 10), "Unit": "Hours"}
+}
+
+// SSMStoreOption is a functional option for configuring AWSSSMStore.
+type SSMStoreOption func(*AWSSSMStore)
+
+// WithKMSKey sets a custom KMS key ID for parameter encryption.
+func WithKMSKey(keyID string) SSMStoreOption {
+	return func(s *AWSSSMStore) {
+		s.KMSKeyID = keyID
+	}
+}
+
+// WithKMSKeyFor encrypts the parameter holding key with keyID instead of the
+// key set with WithKMSKey, e.g. to keep GITHUB_APP_PRIVATE_KEY under a more
+// tightly scoped key than the webhook and client secrets. Only parameters
+// written after the key changed are encrypted with it.
+func WithKMSKeyFor(key, keyID string) SSMStoreOption {
+	return func(s *AWSSSMStore) {
+		if s.KMSKeyIDs == nil {
+			s.KMSKeyIDs = make(map[string]string)
+		}
+		s.KMSKeyIDs[key] = keyID
+	}
+}
+
+// WithTags adds AWS tags to all saved parameters, including existing
+// parameters that are overwritten.
+func WithTags(tags map[string]string) SSMStoreOption {
+	return func(s *AWSSSMStore) {
+		s.Tags = tags
+	}
+}
+
+// WithTier sets the parameter tier. Without it, parameters use the account's
+// default tier, except values too large for the Standard tier, which are saved
+// as Advanced parameters.
+func WithTier(tier types.ParameterTier) SSMStoreOption {
+	return func(s *AWSSSMStore) {
+		s.Tier = tier
+	}
+}
+
+// WithParameterPolicies attaches parameter policies to every saved parameter.
+// Parameters are saved in the Advanced tier unless another tier that
+// supports policies is set.
+func WithParameterPolicies(policies SSMParameterPolicies) SSMStoreOption {
+	return func(s *AWSSSMStore) {
+		s.Policies = policies
+	}
+}
+
+// WithParameterNames saves the given keys to the named parameters instead of
+// ParameterPrefix + key, to fit naming conventions of other systems reading
+// the parameters. In names, {prefix} is replaced by the parameter prefix
+// without leading or trailing slashes and {key} by the key, e.g.
+// "/{prefix}/github/app_id". Mapped parameters are read by name, so only the
+// app credentials and STS_DOMAIN can be mapped.
+func WithParameterNames(names map[string]string) SSMStoreOption {
+	return func(s *AWSSSMStore) {
+		s.ParameterNames = names
+	}
+}
+
+// WithSSMWatchInterval sets how often Watch polls the parameters (defaults
+// to one minute).
+func WithSSMWatchInterval(interval time.Duration) SSMStoreOption {
+	return func(s *AWSSSMStore) {
+		s.WatchInterval = interval
+	}
+}
+
+// WithAssumeRole accesses the parameters with credentials for roleARN,
+// assumed with the default AWS credentials, e.g. so an installer running in
+// a tooling account can write credentials into the workload account's
+// parameter store. It is ignored when a client is set with WithSSMClient.
+func WithAssumeRole(roleARN string) SSMStoreOption {
+	return func(s *AWSSSMStore) {
+		s.AssumeRoleARN = roleARN
+	}
+}
+
+// WithAssumeRoleExternalID sets the external ID required by the trust policy
+// of the role set with WithAssumeRole.
+func WithAssumeRoleExternalID(externalID string) SSMStoreOption {
+	return func(s *AWSSSMStore) {
+		s.ExternalID = externalID
+	}
+}
+
+// WithSSMRetry sets how failed SSM requests are retried. It is ignored when
+// a client is set with WithSSMClient.
+func WithSSMRetry(retry AWSRetryOptions) SSMStoreOption {
+	return func(s *AWSSSMStore) {
+		s.Retry = retry
+	}
+}
+
+// WithSSMClient sets a custom SSM client.
+func WithSSMClient(client SSMClient) SSMStoreOption {
+	return func(s *AWSSSMStore) {
+		s.ssmClient = client
+	}
+}
+
+// NewAWSSSMStore creates a new AWS SSM Parameter Store backend.
+// The prefix is normalized to always end with a slash.
+func NewAWSSSMStore(prefix string, opts ...SSMStoreOption) (*AWSSSMStore, error) {
+	if prefix == "" {
+		return nil, fmt.Errorf("parameter prefix cannot be empty")
+	}
+
+	if !strings.HasSuffix(prefix, "/") {
+		prefix = prefix + "/"
+	}
+
+	store := &AWSSSMStore{
+		ParameterPrefix: prefix,
+		WatchInterval:   DefaultWatchInterval,
+	}
+
+	for _, opt := range opts {
+		opt(store)
+	}
+
+	if !store.Policies.empty() {
+		switch store.Tier {
+		case "":
+			store.Tier = types.ParameterTierAdvanced
+		case types.ParameterTierStandard:
+			return nil, fmt.Errorf("parameter policies require the %s tier", types.ParameterTierAdvanced)
+		}
+	}
+	if store.Policies.ExpirationNotification > 0 && store.Policies.Expiration == 0 {
+		return nil, fmt.Errorf("an expiration notification requires an expiration policy")
+	}
+
+	for key, keyID := range store.KMSKeyIDs {
+		if !slices.Contains(knownKeys, key) {
+			return nil, fmt.Errorf("cannot set KMS key for %s: not a credential key", key)
+		}
+		if keyID == "" {
+			return nil, fmt.Errorf("KMS key for %s cannot be empty", key)
+		}
+	}
+
+	if len(store.ParameterNames) > 0 {
+		names, err := expandParameterNames(store.ParameterNames, prefix)
+		if err != nil {
+			return nil, err
+		}
+		store.ParameterNames = names
+	}
+
+	if store.AssumeRoleARN != "" {
+		if parsed, err := arn.Parse(store.AssumeRoleARN); err != nil || parsed.Service != "iam" || !strings.HasPrefix(parsed.Resource, "role/") {
+			return nil, fmt.Errorf("invalid role ARN to assume: %q", store.AssumeRoleARN)
+		}
+	}
+
+	if store.ssmClient == nil {
+		cfg, err := loadAWSConfig(context.Background(), store.Retry)
+		if err != nil {
+			return nil, err
+		}
+		if store.AssumeRoleARN != "" {
+			provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), store.AssumeRoleARN, func(o *stscreds.AssumeRoleOptions) {
+				o.RoleSessionName = ssmAssumeRoleSessionName
+				if store.ExternalID != "" {
+					o.ExternalID = aws.String(store.ExternalID)
+				}
+			})
+			cfg.Credentials = aws.NewCredentialsCache(provider)
+		}
+		store.ssmClient = ssm.NewFromConfig(cfg)
+	}
+
+	if store.ChangeQueueURL != "" && store.sqsClient == nil {
+		// Long polls outlast the request timeout, so only retries apply
+		cfg, err := loadAWSConfig(context.Background(), AWSRetryOptions{MaxAttempts: store.Retry.MaxAttempts})
+		if err != nil {
+			return nil, err
+		}
+		store.sqsClient = sqs.NewFromConfig(cfg)
+	}
+
+	return store, nil
+}
+
+// newAWSSSMStoreFromEnv creates an AWSSSMStore from environment variables.
+func newAWSSSMStoreFromEnv() (*AWSSSMStore, error) {
+	prefix := os.Getenv(EnvAWSSSMParameterPfx)
+	if prefix == "" {
+		return nil, fmt.Errorf("%s is required when using %s storage mode", EnvAWSSSMParameterPfx, StorageModeAWSSSM)
+	}
+
+	var opts []SSMStoreOption
+
+	if kmsKeyID := os.Getenv(EnvAWSSSMKMSKeyID); kmsKeyID != "" {
+		opts = append(opts, WithKMSKey(kmsKeyID))
+	}
+
+	if keysJSON := os.Getenv(EnvAWSSSMKMSKeyIDs); keysJSON != "" {
+		var keyIDs map[string]string
+		if err := json.Unmarshal([]byte(keysJSON), &keyIDs); err != nil {
+			return nil, fmt.Errorf("failed to parse %s as JSON: %w", EnvAWSSSMKMSKeyIDs, err)
+		}
+		for key, keyID := range keyIDs {
+			opts = append(opts, WithKMSKeyFor(key, keyID))
+		}
+	}
+
+	if tagsJSON := os.Getenv(EnvAWSSSMTags); tagsJSON != "" {
+		var tags map[string]string
+		if err := json.Unmarshal([]byte(tagsJSON), &tags); err != nil {
+			return nil, fmt.Errorf("failed to parse %s as JSON: %w", EnvAWSSSMTags, err)
+		}
+		opts = append(opts, WithTags(tags))
+	}
+
+	if tier := os.Getenv(EnvAWSSSMTier); tier != "" {
+		parsed, err := parseSSMTier(tier)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithTier(parsed))
+	}
+
+	var policies SSMParameterPolicies
+	for _, p := range []struct {
+		env    string
+		target *time.Duration
+	}{
+		{EnvAWSSSMExpiration, &policies.Expiration},
+		{EnvAWSSSMExpirationNotification, &policies.ExpirationNotification},
+		{EnvAWSSSMNoChangeNotification, &policies.NoChangeNotification},
+	} {
+		env, target := p.env, p.target
+		value := os.Getenv(env)
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive duration such as 2160h", env, value)
+		}
+		*target = d
+	}
+	if !policies.empty() {
+		opts = append(opts, WithParameterPolicies(policies))
+	}
+
+	if roleARN := os.Getenv(EnvAWSSSMAssumeRoleARN); roleARN != "" {
+		opts = append(opts, WithAssumeRole(roleARN))
+		if externalID := os.Getenv(EnvAWSSSMAssumeRoleExternalID); externalID != "" {
+			opts = append(opts, WithAssumeRoleExternalID(externalID))
+		}
+	}
+
+	if namesJSON := os.Getenv(EnvAWSSSMParameterNames); namesJSON != "" {
+		var names map[string]string
+		if err := json.Unmarshal([]byte(namesJSON), &names); err != nil {
+			return nil, fmt.Errorf("failed to parse %s as JSON: %w", EnvAWSSSMParameterNames, err)
+		}
+		opts = append(opts, WithParameterNames(names))
+	}
+
+	interval, err := watchIntervalFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, WithSSMWatchInterval(interval))
+
+	if queueURL := os.Getenv(EnvAWSSSMChangeQueueURL); queueURL != "" {
+		opts = append(opts, WithSSMChangeQueue(queueURL))
+	}
+
+	retry, err := awsRetryOptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, WithSSMRetry(retry))
+
+	return NewAWSSSMStore(prefix, opts...)
+}
+
+// expandParameterNames fills in the {prefix} and {key} placeholders of the
+// mapped parameter names.
+func expandParameterNames(names map[string]string, prefix string) (map[string]string, error) {
+	expanded := make(map[string]string, len(names))
+	seen := make(map[string]string, len(names))
+	for key, name := range names {
+		if !slices.Contains(knownKeys, key) {
+			return nil, fmt.Errorf("cannot map parameter name for %s: only the app credentials and %s can be mapped", key, EnvSTSDomain)
+		}
+		name = strings.NewReplacer("{prefix}", strings.Trim(prefix, "/"), "{key}", key).Replace(name)
+		if name == "" {
+			return nil, fmt.Errorf("parameter name for %s cannot be empty", key)
+		}
+		if other, ok := seen[name]; ok {
+			return nil, fmt.Errorf("%s and %s are mapped to the same parameter %s", other, key, name)
+		}
+		seen[name] = key
+		expanded[key] = name
+	}
+	return expanded, nil
+}
+
+// parameterName returns the name of the parameter holding key.
+func (s *AWSSSMStore) parameterName(key string) string {
+	if name, ok := s.ParameterNames[key]; ok {
+		return name
+	}
+	return s.ParameterPrefix + key
+}
+
+// parseSSMTier matches a tier name case-insensitively.
+func parseSSMTier(tier string) (types.ParameterTier, error) {
+	for _, valid := range types.ParameterTierStandard.Values() {
+		if strings.EqualFold(tier, string(valid)) {
+			return valid, nil
+		}
+	}
+	return "", fmt.Errorf("invalid %s %q: expected Standard, Advanced, or Intelligent-Tiering", EnvAWSSSMTier, tier)
+}
+
+// Save writes credentials to AWS SSM as encrypted SecureString parameters.
+// Parameters that already hold their value are skipped, so unchanged
+// credentials keep their parameter version. A changed private key is written
+// first so that each save replacing it starts with a new private key
+// parameter version, which History uses to delimit versions.
+func (s *AWSSSMStore) Save(ctx context.Context, creds *AppCredentials) error {
+	existing, err := s.readParameters(ctx)
+	if err != nil {
+		return err
+	}
+	parameters := changedValues(existing, credentialValues(creds))
+
+	if value, ok := parameters[EnvGitHubAppPrivateKey]; ok {
+		if err := s.putParameter(ctx, EnvGitHubAppPrivateKey, value); err != nil {
+			return fmt.Errorf("failed to save parameter %s: %w", EnvGitHubAppPrivateKey, err)
+		}
+		delete(parameters, EnvGitHubAppPrivateKey)
+	}
+
+	for name, value := range parameters {
+		if err := s.putParameter(ctx, name, value); err != nil {
+			return fmt.Errorf("failed to save parameter %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// putParameter creates or updates a single SSM parameter. PutParameter
+// rejects Tags together with Overwrite, so when tags are configured the
+// parameter is first created with them; if it already exists, it is
+// overwritten and the tags are applied with AddTagsToResource.
+func (s *AWSSSMStore) putParameter(ctx context.Context, name, value string) error {
+	input := &ssm.PutParameterInput{
+		Name:      aws.String(s.parameterName(name)),
+		Value:     aws.String(value),
+		Type:      types.ParameterTypeSecureString,
+		Overwrite: aws.Bool(true),
+		DataType:  aws.String("text"),
+	}
+
+	if keyID := s.kmsKeyID(name); keyID != "" {
+		input.KeyId = aws.String(keyID)
+	}
+
+	input.Tier = s.Tier
+	if input.Tier == "" && len(value) > ssmStandardMaxValueSize {
+		input.Tier = types.ParameterTierAdvanced
+	}
+
+	if !s.Policies.empty() {
+		policies, err := s.Policies.document(time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to encode parameter policies: %w", err)
+		}
+		input.Policies = aws.String(policies)
+	}
+
+	if len(s.Tags) == 0 {
+		_, err := s.ssmClient.PutParameter(ctx, input)
+		return err
+	}
+
+	tags := s.tags()
+	create := *input
+	create.Overwrite = aws.Bool(false)
+	create.Tags = tags
+	_, err := s.ssmClient.PutParameter(ctx, &create)
+	var exists *types.ParameterAlreadyExists
+	if !errors.As(err, &exists) {
+		return err
+	}
+
+	if _, err := s.ssmClient.PutParameter(ctx, input); err != nil {
+		return err
+	}
+	if _, err := s.ssmClient.AddTagsToResource(ctx, &ssm.AddTagsToResourceInput{
+		ResourceType: types.ResourceTypeForTaggingParameter,
+		ResourceId:   input.Name,
+		Tags:         tags,
+	}); err != nil {
+		return fmt.Errorf("failed to tag parameter: %w", err)
+	}
+	return nil
+}
+
+// kmsKeyID returns the KMS key encrypting the parameter holding key, or ""
+// for the account's default key.
+func (s *AWSSSMStore) kmsKeyID(key string) string {
+	if keyID, ok := s.KMSKeyIDs[key]; ok {
+		return keyID
+	}
+	return s.KMSKeyID
+}
+
+// tags returns the configured tags in SSM form, sorted by key.
+func (s *AWSSSMStore) tags() []types.Tag {
+	keys := make([]string, 0, len(s.Tags))
+	for key := range s.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tags := make([]types.Tag, 0, len(keys))
+	for _, key := range keys {
+		tags = append(tags, types.Tag{Key: aws.String(key), Value: aws.String(s.Tags[key])})
+	}
+	return tags
+}
+
+// Status returns the current registration state by checking required SSM parameters.
+func (s *AWSSSMStore) Status(ctx context.Context) (*InstallerStatus, error) {
+	status := &InstallerStatus{}
+	required := []string{
+		EnvGitHubAppID,
+		EnvGitHubWebhookSecret,
+		EnvGitHubClientID,
+		EnvGitHubClientSecret,
+		EnvGitHubAppPrivateKey,
+	}
+
+	values := make(map[string]string)
+	for _, key := range required {
+		value, err := s.getParameterValue(ctx, key)
+		if err != nil {
+			if isParameterNotFound(err) {
+				return status, nil
+			}
+			return nil, err
+		}
+		values[key] = value
+	}
+
+	status.Registered = true
+	if id, err := strconv.ParseInt(strings.TrimSpace(values[EnvGitHubAppID]), 10, 64); err == nil {
+		status.AppID = id
+	}
+
+	if slug, err := s.getParameterValue(ctx, EnvGitHubAppSlug); err == nil {
+		status.AppSlug = slug
+	} else if !isParameterNotFound(err) {
+		return nil, err
+	}
+
+	if html, err := s.getParameterValue(ctx, EnvGitHubAppHTMLURL); err == nil {
+		status.HTMLURL = html
+	} else if !isParameterNotFound(err) {
+		return nil, err
+	}
+
+	if flag, err := s.getParameterValue(ctx, EnvGitHubAppInstallerEnabled); err == nil {
+		status.InstallerDisabled = isFalseString(flag)
+	} else if !isParameterNotFound(err) {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// DisableInstaller sets a parameter to disable the installer.
+func (s *AWSSSMStore) DisableInstaller(ctx context.Context) error {
+	return s.putParameter(ctx, EnvGitHubAppInstallerEnabled, "false")
+}
+
+// Load reads all parameters under the prefix and returns the stored credentials.
+func (s *AWSSSMStore) Load(ctx context.Context) (*AppCredentials, error) {
+	values, err := s.readParameters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return credentialsFromValues(values)
+}
+
+// Delete removes every parameter directly under the prefix and the mapped
+// parameters.
+func (s *AWSSSMStore) Delete(ctx context.Context) error {
+	values, err := s.readParameters(ctx)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	return s.deleteParameters(ctx, names)
+}
+
+// Ping reads one parameter under the prefix with decryption, which checks
+// the IAM permissions for reading and for the KMS key.
+func (s *AWSSSMStore) Ping(ctx context.Context) error {
+	path := strings.TrimSuffix(s.ParameterPrefix, "/")
+	if path == "" {
+		path = "/"
+	}
+	if _, err := s.ssmClient.GetParametersByPath(ctx, &ssm.GetParametersByPathInput{
+		Path:           aws.String(path),
+		WithDecryption: aws.Bool(true),
+		MaxResults:     aws.Int32(1),
+	}); err != nil {
+		return fmt.Errorf("failed to read parameters under %s: %w", s.ParameterPrefix, err)
+	}
+	return nil
+}
+
+// Rotate writes creds and then deletes credential parameters that creds does
+// not set. The new values are written first so a failure part-way through
+// never leaves the store without a complete set of credentials.
+func (s *AWSSSMStore) Rotate(ctx context.Context, creds *AppCredentials) error {
+	if err := s.Save(ctx, creds); err != nil {
+		return err
+	}
+	return s.deleteParameters(ctx, staleKeys(credentialValues(creds)))
+}
+
+// deleteParameters deletes the named parameters in batches. Names that don't
+// exist are ignored.
+func (s *AWSSSMStore) deleteParameters(ctx context.Context, names []string) error {
+	for start := 0; start < len(names); start += ssmDeleteBatchSize {
+		end := min(start+ssmDeleteBatchSize, len(names))
+
+		batch := make([]string, 0, end-start)
+		for _, name := range names[start:end] {
+			batch = append(batch, s.parameterName(name))
+		}
+
+		if _, err := s.ssmClient.DeleteParameters(ctx, &ssm.DeleteParametersInput{Names: batch}); err != nil {
+			return fmt.Errorf("failed to delete parameters under %s: %w", s.ParameterPrefix, err)
+		}
+	}
+	return nil
+}
+
+// readParameters returns the decrypted values of all parameters directly under
+// the prefix, keyed by their name relative to the prefix, together with the
+// mapped parameters, keyed by their key.
+func (s *AWSSSMStore) readParameters(ctx context.Context) (map[string]string, error) {
+	params, err := s.parameters(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(params))
+	for key, param := range params {
+		values[key] = aws.ToString(param.Value)
+	}
+	return values, nil
+}
+
+// parameters returns the parameters directly under the prefix and the mapped
+// parameters, keyed like readParameters. Parameters under the prefix named
+// after a mapped key are ignored, since the key is stored elsewhere.
+func (s *AWSSSMStore) parameters(ctx context.Context, decrypt bool) (map[string]types.Parameter, error) {
+	listed, err := s.listParameters(ctx, decrypt)
+	if err != nil {
+		return nil, err
+	}
+
+	mapped := make(map[string]string, len(s.ParameterNames))
+	for key, name := range s.ParameterNames {
+		mapped[name] = key
+	}
+
+	params := make(map[string]types.Parameter, len(listed))
+	for _, param := range listed {
+		name := aws.ToString(param.Name)
+		if key, ok := mapped[name]; ok {
+			params[key] = param
+			continue
+		}
+		key := strings.TrimPrefix(name, s.ParameterPrefix)
+		if _, ok := s.ParameterNames[key]; !ok {
+			params[key] = param
+		}
+	}
+
+	for key := range s.ParameterNames {
+		if _, ok := params[key]; ok {
+			continue
+		}
+		param, err := s.getParameter(ctx, key, decrypt)
+		if isParameterNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read parameter %s: %w", s.parameterName(key), err)
+		}
+		params[key] = *param
+	}
+
+	return params, nil
+}
+
+// listParameters returns all parameters directly under the prefix.
+func (s *AWSSSMStore) listParameters(ctx context.Context, decrypt bool) ([]types.Parameter, error) {
+	path := strings.TrimSuffix(s.ParameterPrefix, "/")
+	if path == "" {
+		path = "/"
+	}
+
+	var params []types.Parameter
+	var nextToken *string
+	for {
+		output, err := s.ssmClient.GetParametersByPath(ctx, &ssm.GetParametersByPathInput{
+			Path:           aws.String(path),
+			WithDecryption: aws.Bool(decrypt),
+			NextToken:      nextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read parameters under %s: %w", s.ParameterPrefix, err)
+		}
+		params = append(params, output.Parameters...)
+		if output.NextToken == nil {
+			break
+		}
+		nextToken = output.NextToken
+	}
+
+	return params, nil
+}
+
+// Watch polls the parameter versions every WatchInterval, and with
+// WithSSMChangeQueue also as soon as a change event arrives. Values are not
+// decrypted, so polling makes no KMS calls.
+func (s *AWSSSMStore) Watch(ctx context.Context) (<-chan struct{}, error) {
+	if s.ChangeQueueURL != "" {
+		return s.watchWithQueue(ctx), nil
+	}
+	return pollChanges(ctx, s.WatchInterval, s.snapshot), nil
+}
+
+// snapshot fingerprints the names and versions of the parameters.
+func (s *AWSSSMStore) snapshot(ctx context.Context) (string, error) {
+	params, err := s.parameters(ctx, false)
+	if err != nil {
+		return "", err
+	}
+	versions := make(map[string]string, len(params))
+	for _, param := range params {
+		versions[aws.ToString(param.Name)] = strconv.FormatInt(param.Version, 10)
+	}
+	return fingerprint(versions), nil
+}
+
+// History reconstructs credential versions from the SSM parameter history.
+// Each version is identified by the private key parameter version it starts
+// with and holds the latest value of every parameter written before the
+// private key next changed. SSM retains the last 100 versions of each
+// parameter.
+func (s *AWSSSMStore) History(ctx context.Context) ([]CredentialVersion, error) {
+	histories := make(map[string][]types.ParameterHistory)
+	for _, key := range knownKeys {
+		if key == EnvGitHubAppInstallerEnabled {
+			continue
+		}
+		history, err := s.parameterHistory(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		histories[key] = history
+	}
+
+	anchors := histories[EnvGitHubAppPrivateKey]
+	var versions []CredentialVersion
+	for i := len(anchors) - 1; i >= 0; i-- {
+		var cutoff *time.Time
+		if i+1 < len(anchors) {
+			cutoff = anchors[i+1].LastModifiedDate
+		}
+
+		values := make(map[string]string)
+		for key, history := range histories {
+			for _, entry := range history {
+				if cutoff != nil && !aws.ToTime(entry.LastModifiedDate).Before(*cutoff) {
+					break
+				}
+				values[key] = aws.ToString(entry.Value)
+			}
+		}
+
+		creds, err := credentialsFromValues(values)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, CredentialVersion{
+			ID:          strconv.FormatInt(anchors[i].Version, 10),
+			CreatedAt:   aws.ToTime(anchors[i].LastModifiedDate),
+			Current:     i == len(anchors)-1,
+			Credentials: creds,
+		})
+	}
+
+	return compactHistory(versions), nil
+}
+
+// Rollback rewrites the parameters with the values of the given version,
+// identified by its private key parameter version.
+func (s *AWSSSMStore) Rollback(ctx context.Context, id string) error {
+	return rollback(ctx, s, id)
+}
+
+// parameterHistory returns every retained version of a parameter, oldest
+// first. A missing parameter has no history.
+func (s *AWSSSMStore) parameterHistory(ctx context.Context, name string) ([]types.ParameterHistory, error) {
+	var history []types.ParameterHistory
+	var nextToken *string
+	for {
+		output, err := s.ssmClient.GetParameterHistory(ctx, &ssm.GetParameterHistoryInput{
+			Name:           aws.String(s.parameterName(name)),
+			WithDecryption: aws.Bool(true),
+			NextToken:      nextToken,
+		})
+		if err != nil {
+			if isParameterNotFound(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to read history of parameter %s: %w", name, err)
+		}
+		history = append(history, output.Parameters...)
+		if output.NextToken == nil {
+			break
+		}
+		nextToken = output.NextToken
+	}
+
+	sort.Slice(history, func(i, j int) bool { return history[i].Version < history[j].Version })
+	return history, nil
+}
+
+func (s *AWSSSMStore) getParameterValue(ctx context.Context, name string) (string, error) {
+	param, err := s.getParameter(ctx, name, true)
+	if err != nil {
+		return "", err
+	}
+	if param.Value == nil {
+		return "", fmt.Errorf("parameter %s missing value", name)
+	}
+	return aws.ToString(param.Value), nil
+}
+
+// getParameter reads the parameter holding key.
+func (s *AWSSSMStore) getParameter(ctx context.Context, key string, decrypt bool) (*types.Parameter, error) {
+	output, err := s.ssmClient.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(s.parameterName(key)),
+		WithDecryption: aws.Bool(decrypt),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if output.Parameter == nil {
+		return nil, fmt.Errorf("parameter %s missing value", key)
+	}
+	return output.Parameter, nil
+}
+
+func isParameterNotFound(err error) bool {
+	var notFound *types.ParameterNotFound
+	return errors.As(err, &notFound)
+}
@@ -0,0 +1,510 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// fakeSSM is an in-memory SSMClient for tests. GetParametersByPath returns
+// one parameter per page to exercise pagination. Each write advances a fake
+// clock by one second.
+type fakeSSM struct {
+	params  map[string]string
+	history map[string][]types.ParameterHistory
+	tags    map[string]map[string]string
+	puts    []*ssm.PutParameterInput
+	now     time.Time
+}
+
+func (f *fakeSSM) PutParameter(_ context.Context, in *ssm.PutParameterInput,
+	_ ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	name := aws.ToString(in.Name)
+	overwrite := aws.ToBool(in.Overwrite)
+	if overwrite && len(in.Tags) > 0 {
+		return nil, errors.New("ValidationException: tags and overwrite can't be used together")
+	}
+	if _, ok := f.params[name]; ok && !overwrite {
+		return nil, &types.ParameterAlreadyExists{}
+	}
+	f.params[name] = aws.ToString(in.Value)
+	f.puts = append(f.puts, in)
+	if len(in.Tags) > 0 {
+		f.addTags(name, in.Tags)
+	}
+
+	if f.history == nil {
+		f.history = make(map[string][]types.ParameterHistory)
+	}
+	f.now = f.now.Add(time.Second)
+	f.history[name] = append(f.history[name], types.ParameterHistory{
+		Name:             in.Name,
+		Value:            in.Value,
+		Version:          int64(len(f.history[name]) + 1),
+		LastModifiedDate: aws.Time(f.now),
+	})
+	return &ssm.PutParameterOutput{}, nil
+}
+
+func (f *fakeSSM) AddTagsToResource(_ context.Context, in *ssm.AddTagsToResourceInput,
+	_ ...func(*ssm.Options)) (*ssm.AddTagsToResourceOutput, error) {
+	if _, ok := f.params[aws.ToString(in.ResourceId)]; !ok {
+		return nil, &types.InvalidResourceId{}
+	}
+	f.addTags(aws.ToString(in.ResourceId), in.Tags)
+	return &ssm.AddTagsToResourceOutput{}, nil
+}
+
+func (f *fakeSSM) addTags(name string, tags []types.Tag) {
+	if f.tags == nil {
+		f.tags = make(map[string]map[string]string)
+	}
+	if f.tags[name] == nil {
+		f.tags[name] = make(map[string]string)
+	}
+	for _, tag := range tags {
+		f.tags[name][aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+}
+
+func (f *fakeSSM) GetParameterHistory(_ context.Context, in *ssm.GetParameterHistoryInput,
+	_ ...func(*ssm.Options)) (*ssm.GetParameterHistoryOutput, error) {
+	history, ok := f.history[aws.ToString(in.Name)]
+	if !ok {
+		return nil, &types.ParameterNotFound{}
+	}
+	return &ssm.GetParameterHistoryOutput{Parameters: history}, nil
+}
+
+func (f *fakeSSM) GetParameter(_ context.Context, in *ssm.GetParameterInput,
+	_ ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	value, ok := f.params[aws.ToString(in.Name)]
+	if !ok {
+		return nil, &types.ParameterNotFound{}
+	}
+	return &ssm.GetParameterOutput{Parameter: &types.Parameter{
+		Name:    in.Name,
+		Value:   aws.String(value),
+		Version: int64(len(f.history[aws.ToString(in.Name)])),
+	}}, nil
+}
+
+func (f *fakeSSM) GetParametersByPath(_ context.Context, in *ssm.GetParametersByPathInput,
+	_ ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	var names []string
+	for name := range f.params {
+		rest, ok := strings.CutPrefix(name, aws.ToString(in.Path)+"/")
+		if ok && (aws.ToBool(in.Recursive) || !strings.Contains(rest, "/")) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	start := 0
+	if in.NextToken != nil {
+		for i, name := range names {
+			if name == aws.ToString(in.NextToken) {
+				start = i
+			}
+		}
+	}
+
+	out := &ssm.GetParametersByPathOutput{}
+	if start < len(names) {
+		name := names[start]
+		out.Parameters = []types.Parameter{{
+			Name:    aws.String(name),
+			Value:   aws.String(f.params[name]),
+			Version: int64(len(f.history[name])),
+		}}
+	}
+	if start+1 < len(names) {
+		out.NextToken = aws.String(names[start+1])
+	}
+	return out, nil
+}
+
+func (f *fakeSSM) DeleteParameters(_ context.Context, in *ssm.DeleteParametersInput,
+	_ ...func(*ssm.Options)) (*ssm.DeleteParametersOutput, error) {
+	if len(in.Names) > ssmDeleteBatchSize {
+		return nil, errors.New("too many names")
+	}
+	out := &ssm.DeleteParametersOutput{}
+	for _, name := range in.Names {
+		if _, ok := f.params[name]; !ok {
+			out.InvalidParameters = append(out.InvalidParameters, name)
+			continue
+		}
+		delete(f.params, name)
+		delete(f.history, name)
+		out.DeletedParameters = append(out.DeletedParameters, name)
+	}
+	return out, nil
+}
+
+func TestAWSSSMStoreLoad(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeSSM{params: map[string]string{}}
+
+	store, err := NewAWSSSMStore("/octo-sts", WithSSMClient(client))
+	if err != nil {
+		t.Fatalf("NewAWSSSMStore() error = %v", err)
+	}
+
+	if _, err := store.Load(ctx); !errors.Is(err, ErrNotRegistered) {
+		t.Fatalf("expected ErrNotRegistered before save, got %v", err)
+	}
+
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	creds, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if creds.AppID != 1234 || creds.PrivateKey != testAppCredentials().PrivateKey {
+		t.Errorf("unexpected credentials: %+v", creds)
+	}
+	if creds.CustomFields[EnvSTSDomain] != "sts.example.com" {
+		t.Errorf("expected STS_DOMAIN custom field, got %v", creds.CustomFields)
+	}
+}
+
+func TestAWSSSMStoreRotateAndDelete(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeSSM{params: map[string]string{}}
+
+	store, err := NewAWSSSMStore("/octo-sts", WithSSMClient(client))
+	if err != nil {
+		t.Fatalf("NewAWSSSMStore() error = %v", err)
+	}
+
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	rotated := testAppCredentials()
+	rotated.CustomFields = nil
+	rotated.ClientSecret = "rotated-secret"
+	if err := store.Rotate(ctx, rotated); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+
+	creds, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if creds.ClientSecret != "rotated-secret" {
+		t.Errorf("expected rotated client secret, got %q", creds.ClientSecret)
+	}
+	if _, ok := creds.CustomFields[EnvSTSDomain]; ok {
+		t.Error("expected stale STS_DOMAIN to be deleted")
+	}
+
+	if err := store.Delete(ctx); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if len(client.params) != 0 {
+		t.Errorf("expected all parameters to be deleted, got %v", client.params)
+	}
+}
+
+func TestAWSSSMStoreHistory(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeSSM{params: map[string]string{}}
+
+	store, err := NewAWSSSMStore("/octo-sts", WithSSMClient(client))
+	if err != nil {
+		t.Fatalf("NewAWSSSMStore() error = %v", err)
+	}
+
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := store.DisableInstaller(ctx); err != nil {
+		t.Fatalf("DisableInstaller() error = %v", err)
+	}
+
+	rotated := testAppCredentials()
+	rotated.PrivateKey = "rotated-key"
+	rotated.WebhookSecret = "rotated-webhook-secret"
+	if err := store.Rotate(ctx, rotated); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+
+	versions, err := store.History(ctx)
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(versions))
+	}
+	if !versions[0].Current || versions[0].Credentials.PrivateKey != "rotated-key" {
+		t.Errorf("expected current version first, got %+v", versions[0])
+	}
+	if versions[1].ID != "1" || versions[1].Credentials.WebhookSecret != "webhook-secret" {
+		t.Errorf("expected original version second, got %+v", versions[1])
+	}
+
+	if err := store.Rollback(ctx, "1"); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	creds, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if creds.PrivateKey != testAppCredentials().PrivateKey || creds.WebhookSecret != "webhook-secret" {
+		t.Errorf("expected original credentials after rollback, got %+v", creds)
+	}
+
+	if err := store.Rollback(ctx, "42"); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("expected ErrVersionNotFound, got %v", err)
+	}
+}
+
+func TestAWSSSMStoreTagsExistingParameters(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeSSM{params: map[string]string{"/octo-sts/GITHUB_APP_ID": "1"}}
+
+	store, err := NewAWSSSMStore("/octo-sts", WithSSMClient(client), WithTags(map[string]string{"team": "platform"}))
+	if err != nil {
+		t.Fatalf("NewAWSSSMStore() error = %v", err)
+	}
+
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if got := client.params["/octo-sts/GITHUB_APP_ID"]; got != "1234" {
+		t.Errorf("existing parameter not overwritten, got %q", got)
+	}
+	for _, name := range []string{"/octo-sts/GITHUB_APP_ID", "/octo-sts/GITHUB_APP_PRIVATE_KEY"} {
+		if got := client.tags[name]["team"]; got != "platform" {
+			t.Errorf("%s team tag = %q, want platform", name, got)
+		}
+	}
+
+	// Saving again overwrites every parameter and keeps them tagged.
+	if err := store.DisableInstaller(ctx); err != nil {
+		t.Fatalf("DisableInstaller() error = %v", err)
+	}
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("second Save() error = %v", err)
+	}
+	if got := client.tags["/octo-sts/GITHUB_APP_INSTALLER_ENABLED"]["team"]; got != "platform" {
+		t.Errorf("installer flag team tag = %q, want platform", got)
+	}
+}
+
+func TestAWSSSMStoreTierAndPolicies(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := NewAWSSSMStore("/octo-sts", WithSSMClient(&fakeSSM{}), WithTier(types.ParameterTierStandard),
+		WithParameterPolicies(SSMParameterPolicies{NoChangeNotification: 24 * time.Hour})); err == nil {
+		t.Error("expected error for policies on the Standard tier")
+	}
+
+	client := &fakeSSM{params: map[string]string{}}
+	store, err := NewAWSSSMStore("/octo-sts", WithSSMClient(client), WithParameterPolicies(SSMParameterPolicies{
+		Expiration:             90 * 24 * time.Hour,
+		ExpirationNotification: 14 * 24 * time.Hour,
+		NoChangeNotification:   36 * time.Hour,
+	}))
+	if err != nil {
+		t.Fatalf("NewAWSSSMStore() error = %v", err)
+	}
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	put := client.puts[0]
+	if put.Tier != types.ParameterTierAdvanced {
+		t.Errorf("Tier = %q, want Advanced", put.Tier)
+	}
+	var policies []struct {
+		Type       string
+		Attributes map[string]string
+	}
+	if err := json.Unmarshal([]byte(aws.ToString(put.Policies)), &policies); err != nil {
+		t.Fatalf("invalid policies %q: %v", aws.ToString(put.Policies), err)
+	}
+	if len(policies) != 3 {
+		t.Fatalf("expected 3 policies, got %+v", policies)
+	}
+	if policies[1].Attributes["Before"] != "14" || policies[1].Attributes["Unit"] != "Days" {
+		t.Errorf("unexpected expiration notification: %+v", policies[1])
+	}
+	if policies[2].Attributes["After"] != "36" || policies[2].Attributes["Unit"] != "Hours" {
+		t.Errorf("unexpected no-change notification: %+v", policies[2])
+	}
+}
+
+func TestAWSSSMStoreLargeValueTier(t *testing.T) {
+	client := &fakeSSM{params: map[string]string{}}
+	store, err := NewAWSSSMStore("/octo-sts", WithSSMClient(client))
+	if err != nil {
+		t.Fatalf("NewAWSSSMStore() error = %v", err)
+	}
+
+	creds := testAppCredentials()
+	creds.PrivateKey = strings.Repeat("a", ssmStandardMaxValueSize+1)
+	if err := store.Save(context.Background(), creds); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	for _, put := range client.puts {
+		want := types.ParameterTier("")
+		if aws.ToString(put.Name) == "/octo-sts/"+EnvGitHubAppPrivateKey {
+			want = types.ParameterTierAdvanced
+		}
+		if put.Tier != want {
+			t.Errorf("%s Tier = %q, want %q", aws.ToString(put.Name), put.Tier, want)
+		}
+	}
+}
+
+func TestAWSSSMStoreKMSKeyFor(t *testing.T) {
+	if _, err := NewAWSSSMStore("/octo-sts", WithSSMClient(&fakeSSM{}), WithKMSKeyFor("UNRELATED", "alias/other")); err == nil {
+		t.Error("expected error for a KMS key on an unknown key")
+	}
+
+	client := &fakeSSM{params: map[string]string{}}
+	store, err := NewAWSSSMStore("/octo-sts", WithSSMClient(client),
+		WithKMSKey("alias/secrets"),
+		WithKMSKeyFor(EnvGitHubAppPrivateKey, "alias/pem"),
+	)
+	if err != nil {
+		t.Fatalf("NewAWSSSMStore() error = %v", err)
+	}
+	if err := store.Save(context.Background(), testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	for _, put := range client.puts {
+		want := "alias/secrets"
+		if aws.ToString(put.Name) == "/octo-sts/"+EnvGitHubAppPrivateKey {
+			want = "alias/pem"
+		}
+		if got := aws.ToString(put.KeyId); got != want {
+			t.Errorf("%s KeyId = %q, want %q", aws.ToString(put.Name), got, want)
+		}
+	}
+}
+
+func TestAWSSSMStoreParameterNames(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeSSM{params: map[string]string{}}
+
+	store, err := NewAWSSSMStore("/octo-sts", WithSSMClient(client), WithParameterNames(map[string]string{
+		EnvGitHubAppID:         "/{prefix}/github/app_id",
+		EnvGitHubAppPrivateKey: "/shared/{key}",
+	}))
+	if err != nil {
+		t.Fatalf("NewAWSSSMStore() error = %v", err)
+	}
+
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if client.params["/octo-sts/github/app_id"] != "1234" {
+		t.Errorf("expected app ID in mapped parameter, got %v", client.params)
+	}
+	if _, ok := client.params["/shared/GITHUB_APP_PRIVATE_KEY"]; !ok {
+		t.Error("expected private key in mapped parameter")
+	}
+	if _, ok := client.params["/octo-sts/GITHUB_APP_ID"]; ok {
+		t.Error("expected no parameter at the default name")
+	}
+	if client.params["/octo-sts/GITHUB_CLIENT_ID"] != "Iv1.abc" {
+		t.Error("expected unmapped keys to keep the default name")
+	}
+
+	creds, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if creds.AppID != 1234 || creds.PrivateKey != testAppCredentials().PrivateKey {
+		t.Errorf("unexpected credentials: %+v", creds)
+	}
+	if status, err := store.Status(ctx); err != nil || !status.Registered {
+		t.Errorf("Status() = %+v, %v", status, err)
+	}
+
+	if err := store.Delete(ctx); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if len(client.params) != 0 {
+		t.Errorf("expected all parameters to be deleted, got %v", client.params)
+	}
+}
+
+func TestAWSSSMStoreParameterNamesInvalid(t *testing.T) {
+	client := &fakeSSM{params: map[string]string{}}
+	for _, names := range []map[string]string{
+		{"CUSTOM_FIELD": "/custom"},
+		{EnvGitHubAppID: "/same", EnvGitHubClientID: "/same"},
+	} {
+		if _, err := NewAWSSSMStore("/octo-sts", WithSSMClient(client), WithParameterNames(names)); err == nil {
+			t.Errorf("expected error for names %v", names)
+		}
+	}
+}
+
+func TestAWSSSMStoreAssumeRole(t *testing.T) {
+	t.Setenv(EnvAWSSSMParameterPfx, "/octo-sts")
+	t.Setenv("AWS_REGION", "us-east-1")
+
+	t.Setenv(EnvAWSSSMAssumeRoleARN, "arn:aws:iam::123456789012:role/octo-sts-installer")
+	t.Setenv(EnvAWSSSMAssumeRoleExternalID, "external")
+	store, err := newAWSSSMStoreFromEnv()
+	if err != nil {
+		t.Fatalf("newAWSSSMStoreFromEnv() error = %v", err)
+	}
+	if store.AssumeRoleARN != "arn:aws:iam::123456789012:role/octo-sts-installer" || store.ExternalID != "external" {
+		t.Errorf("unexpected role settings: %q, %q", store.AssumeRoleARN, store.ExternalID)
+	}
+
+	t.Setenv(EnvAWSSSMAssumeRoleARN, "arn:aws:iam::123456789012:user/someone")
+	if _, err := newAWSSSMStoreFromEnv(); err == nil {
+		t.Error("expected error for a non-role ARN")
+	}
+}
+
+func TestAWSSSMStoreSaveSkipsUnchanged(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeSSM{params: map[string]string{}}
+	store, err := NewAWSSSMStore("/octo-sts", WithSSMClient(client))
+	if err != nil {
+		t.Fatalf("NewAWSSSMStore() error = %v", err)
+	}
+
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	written := len(client.puts)
+
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if len(client.puts) != written {
+		t.Errorf("expected no writes for unchanged credentials, got %d", len(client.puts)-written)
+	}
+
+	rotated := testAppCredentials()
+	rotated.WebhookSecret = "rotated-secret"
+	if err := store.Save(ctx, rotated); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if len(client.puts) != written+1 || aws.ToString(client.puts[written].Name) != "/octo-sts/"+EnvGitHubWebhookSecret {
+		t.Errorf("expected only the webhook secret to be written, got %d writes", len(client.puts)-written)
+	}
+}
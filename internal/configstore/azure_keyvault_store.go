@@ -0,0 +1,216 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/cruxstack/octo-sts-distros/internal/azure"
+)
+
+// Environment variables for the Azure Key Vault store.
+const (
+	EnvAzureKeyVaultURI        = "AZURE_KEY_VAULT_URI"
+	EnvAzureKeyVaultSecretName = "AZURE_KEY_VAULT_SECRET_NAME"
+	EnvAzureKeyVaultTags       = "AZURE_KEY_VAULT_TAGS"
+)
+
+// StorageModeAzureKeyVault saves credentials to Azure Key Vault.
+const StorageModeAzureKeyVault = "azure-keyvault"
+
+// DefaultAzureKeyVaultSecretName is the default Key Vault secret name.
+const DefaultAzureKeyVaultSecretName = "octo-sts"
+
+// keyVaultSecretName matches the names Key Vault accepts for secrets.
+var keyVaultSecretName = regexp.MustCompile(`^[0-9a-zA-Z-]{1,127}$`)
+
+// KeyVaultClient defines the interface for Azure Key Vault secret operations.
+type KeyVaultClient interface {
+	GetSecret(ctx context.Context, name string) (string, error)
+	SetSecret(ctx context.Context, name, value, contentType string, tags map[string]string) error
+	DeleteSecret(ctx context.Context, name string) error
+}
+
+// AzureKeyVaultStore saves credentials to Azure Key Vault as a single JSON
+// secret keyed by the environment variable names. Key Vault secret names
+// cannot contain underscores, so the values are not stored individually.
+type AzureKeyVaultStore struct {
+	VaultURI   string
+	SecretName string
+	Tags       map[string]string
+	client     KeyVaultClient
+}
+
+// AzureKeyVaultStoreOption is a functional option for configuring AzureKeyVaultStore.
+type AzureKeyVaultStoreOption func(*AzureKeyVaultStore)
+
+// WithKeyVaultSecretName sets the secret name (defaults to "octo-sts").
+func WithKeyVaultSecretName(name string) AzureKeyVaultStoreOption {
+	return func(s *AzureKeyVaultStore) {
+		s.SecretName = name
+	}
+}
+
+// WithKeyVaultTags sets tags applied to each new secret version.
+func WithKeyVaultTags(tags map[string]string) AzureKeyVaultStoreOption {
+	return func(s *AzureKeyVaultStore) {
+		s.Tags = tags
+	}
+}
+
+// WithKeyVaultClient sets a custom Key Vault client.
+func WithKeyVaultClient(client KeyVaultClient) AzureKeyVaultStoreOption {
+	return func(s *AzureKeyVaultStore) {
+		s.client = client
+	}
+}
+
+// NewAzureKeyVaultStore creates a new Azure Key Vault backend. Unless a client
+// is supplied, requests are authenticated with the managed identity selected
+// by AZURE_CLIENT_ID (or the system-assigned identity if unset).
+func NewAzureKeyVaultStore(vaultURI string, opts ...AzureKeyVaultStoreOption) (*AzureKeyVaultStore, error) {
+	if vaultURI == "" {
+		return nil, fmt.Errorf("key vault URI cannot be empty")
+	}
+
+	store := &AzureKeyVaultStore{
+		VaultURI:   vaultURI,
+		SecretName: DefaultAzureKeyVaultSecretName,
+	}
+
+	for _, opt := range opts {
+		opt(store)
+	}
+
+	if !keyVaultSecretName.MatchString(store.SecretName) {
+		return nil, fmt.Errorf("invalid key vault secret name %q: only alphanumerics and dashes are allowed", store.SecretName)
+	}
+
+	if store.client == nil {
+		client, err := azure.NewKeyVaultClient(vaultURI, azure.NewManagedIdentity(os.Getenv(azure.EnvAzureClientID)))
+		if err != nil {
+			return nil, err
+		}
+		store.client = client
+	}
+
+	return store, nil
+}
+
+// newAzureKeyVaultStoreFromEnv creates an AzureKeyVaultStore from environment variables.
+func newAzureKeyVaultStoreFromEnv() (*AzureKeyVaultStore, error) {
+	vaultURI := os.Getenv(EnvAzureKeyVaultURI)
+	if vaultURI == "" {
+		return nil, fmt.Errorf("%s is required when using %s storage mode", EnvAzureKeyVaultURI, StorageModeAzureKeyVault)
+	}
+
+	opts := []AzureKeyVaultStoreOption{
+		WithKeyVaultSecretName(GetEnvDefault(EnvAzureKeyVaultSecretName, DefaultAzureKeyVaultSecretName)),
+	}
+
+	if tagsJSON := os.Getenv(EnvAzureKeyVaultTags); tagsJSON != "" {
+		var tags map[string]string
+		if err := json.Unmarshal([]byte(tagsJSON), &tags); err != nil {
+			return nil, fmt.Errorf("failed to parse %s as JSON: %w", EnvAzureKeyVaultTags, err)
+		}
+		opts = append(opts, WithKeyVaultTags(tags))
+	}
+
+	return NewAzureKeyVaultStore(vaultURI, opts...)
+}
+
+// Save writes credentials to the Key Vault secret, preserving unrelated keys.
+func (s *AzureKeyVaultStore) Save(ctx context.Context, creds *AppCredentials) error {
+	return s.merge(ctx, credentialValues(creds))
+}
+
+// Status returns the current registration state by reading the Key Vault secret.
+func (s *AzureKeyVaultStore) Status(ctx context.Context) (*InstallerStatus, error) {
+	values, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return statusFromValues(values), nil
+}
+
+// Load reads the stored credentials from the Key Vault secret.
+func (s *AzureKeyVaultStore) Load(ctx context.Context) (*AppCredentials, error) {
+	values, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return credentialsFromValues(values)
+}
+
+// DisableInstaller sets GITHUB_APP_INSTALLER_ENABLED=false in the Key Vault secret.
+func (s *AzureKeyVaultStore) DisableInstaller(ctx context.Context) error {
+	return s.merge(ctx, map[string]string{EnvGitHubAppInstallerEnabled: "false"})
+}
+
+// Delete deletes the Key Vault secret. On vaults with soft delete enabled the
+// secret remains recoverable until it is purged.
+func (s *AzureKeyVaultStore) Delete(ctx context.Context) error {
+	if err := s.client.DeleteSecret(ctx, s.SecretName); err != nil {
+		return fmt.Errorf("failed to delete key vault secret %s: %w", s.SecretName, err)
+	}
+	return nil
+}
+
+// Ping reads the Key Vault secret, which also checks authentication.
+func (s *AzureKeyVaultStore) Ping(ctx context.Context) error {
+	_, err := s.read(ctx)
+	return err
+}
+
+// Rotate writes creds as a single new version of the Key Vault secret,
+// dropping credential keys that creds does not set.
+func (s *AzureKeyVaultStore) Rotate(ctx context.Context, creds *AppCredentials) error {
+	values := credentialValues(creds)
+	return s.merge(ctx, values, staleKeys(values)...)
+}
+
+// merge writes values into the secret as a new version, keeping existing
+// keys other than those listed in remove. Nothing is written if the secret
+// already holds the result.
+func (s *AzureKeyVaultStore) merge(ctx context.Context, values map[string]string, remove ...string) error {
+	existing, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+	if !mergeValues(existing, values, remove) {
+		return nil
+	}
+
+	payload, err := json.Marshal(existing)
+	if err != nil {
+		return fmt.Errorf("failed to encode secret: %w", err)
+	}
+	if err := s.client.SetSecret(ctx, s.SecretName, string(payload), "application/json", s.Tags); err != nil {
+		return fmt.Errorf("failed to save key vault secret %s: %w", s.SecretName, err)
+	}
+	return nil
+}
+
+// read returns the decoded secret, or an empty map if it doesn't exist.
+func (s *AzureKeyVaultStore) read(ctx context.Context) (map[string]string, error) {
+	values := make(map[string]string)
+
+	raw, err := s.client.GetSecret(ctx, s.SecretName)
+	if errors.Is(err, azure.ErrSecretNotFound) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key vault secret %s: %w", s.SecretName, err)
+	}
+
+	if err := json.Unmarshal([]byte(raw), &values); err != nil {
+		return nil, fmt.Errorf("key vault secret %s is not a JSON object: %w", s.SecretName, err)
+	}
+	return values, nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cruxstack/octo-sts-distros/internal/azure"
+)
+
+// fakeKeyVault serves a managed identity token endpoint and the Key Vault
+// secrets API from a single test server.
+type fakeKeyVault struct {
+	mu      sync.Mutex
+	secrets map[string]string
+	tags    map[string]map[string]string
+	tokens  int
+}
+
+func (f *fakeKeyVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/identity" {
+		if r.Header.Get("X-IDENTITY-HEADER") != "identity-header" || r.URL.Query().Get("resource") != azure.KeyVaultResource {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.tokens++
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "kv-token", "expires_in": "3600"})
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer kv-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/secrets/")
+	switch r.Method {
+	case http.MethodGet:
+		value, ok := f.secrets[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"error": map[string]string{"code": "SecretNotFound"}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"value": value})
+	case http.MethodPut:
+		var body struct {
+			Value string            `json:"value"`
+			Tags  map[string]string `json:"tags"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.secrets[name] = body.Value
+		f.tags[name] = body.Tags
+		json.NewEncoder(w).Encode(map[string]string{"value": body.Value})
+	}
+}
+
+func TestAzureKeyVaultStore(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeKeyVault{secrets: map[string]string{}, tags: map[string]map[string]string{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	t.Setenv(azure.EnvIdentityEndpoint, srv.URL+"/identity")
+	t.Setenv(azure.EnvIdentityHeader, "identity-header")
+
+	store, err := NewAzureKeyVaultStore(srv.URL, WithKeyVaultTags(map[string]string{"team": "platform"}))
+	if err != nil {
+		t.Fatalf("NewAzureKeyVaultStore() error = %v", err)
+	}
+
+	status, err := store.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.Registered {
+		t.Error("expected missing secret to be unregistered")
+	}
+	if _, err := store.Load(ctx); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("Load() of a missing secret error = %v, want ErrNotRegistered", err)
+	}
+
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	var stored map[string]string
+	if err := json.Unmarshal([]byte(fake.secrets[DefaultAzureKeyVaultSecretName]), &stored); err != nil {
+		t.Fatalf("stored secret is not JSON: %v", err)
+	}
+	if stored[EnvGitHubClientID] != "Iv1.abc" || stored["STS_DOMAIN"] != "sts.example.com" {
+		t.Errorf("unexpected stored values: %v", stored)
+	}
+	if fake.tags[DefaultAzureKeyVaultSecretName]["team"] != "platform" {
+		t.Error("expected tags on the secret version")
+	}
+	if fake.tokens != 1 {
+		t.Errorf("expected token to be cached, got %d token requests", fake.tokens)
+	}
+
+	if err := store.DisableInstaller(ctx); err != nil {
+		t.Fatalf("DisableInstaller() error = %v", err)
+	}
+	status, err = store.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if !status.Registered || !status.InstallerDisabled {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
+
+func TestNewAzureKeyVaultStoreInvalidName(t *testing.T) {
+	if _, err := NewAzureKeyVaultStore("https://example.vault.azure.net", WithKeyVaultSecretName("octo_sts")); err == nil {
+		t.Fatal("expected error for secret name with underscore")
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cruxstack/octo-sts-distros/internal/docker"
+)
+
+// Environment variables for the Docker secrets store.
+const (
+	EnvDockerSecretsDir    = "DOCKER_SECRETS_DIR"
+	EnvDockerSecretsPrefix = "DOCKER_SECRETS_PREFIX"
+	EnvDockerSecretsAPI    = "DOCKER_SECRETS_API"
+)
+
+// StorageModeDockerSecrets saves each credential as a Docker/Podman secret.
+const StorageModeDockerSecrets = "docker-secrets"
+
+// DefaultDockerSecretsDir is where Docker and Podman mount secrets in a container.
+const DefaultDockerSecretsDir = "/run/secrets"
+
+// DockerSecretsClient defines the interface for Docker Engine secret operations.
+type DockerSecretsClient interface {
+	GetSecret(ctx context.Context, name string) (*docker.Secret, error)
+	CreateSecret(ctx context.Context, name string, data []byte, labels map[string]string) (string, error)
+	RemoveSecret(ctx context.Context, id string) error
+}
+
+// DockerSecretsStore saves each credential as a secret named after its
+// lowercased environment variable (GITHUB_APP_ID -> github_app_id), so
+// docker-compose services can consume them without an .env file.
+//
+// Without a client, secrets are written as files in Dir, suitable as
+// file-based compose secrets. With a client, they are created through the
+// Engine API (swarm or Podman) and read back from Dir, where the engine
+// mounts them once the services are redeployed with the new secrets.
+type DockerSecretsStore struct {
+	Dir    string
+	Prefix string
+	client DockerSecretsClient
+}
+
+// DockerSecretsStoreOption is a functional option for configuring DockerSecretsStore.
+type DockerSecretsStoreOption func(*DockerSecretsStore)
+
+// WithDockerSecretsPrefix sets a prefix for the secret names.
+func WithDockerSecretsPrefix(prefix string) DockerSecretsStoreOption {
+	return func(s *DockerSecretsStore) {
+		s.Prefix = prefix
+	}
+}
+
+// WithDockerSecretsClient creates secrets through the Engine API instead of
+// writing them to the secrets directory.
+func WithDockerSecretsClient(client DockerSecretsClient) DockerSecretsStoreOption {
+	return func(s *DockerSecretsStore) {
+		s.client = client
+	}
+}
+
+// NewDockerSecretsStore creates a Docker secrets backend reading from dir.
+func NewDockerSecretsStore(dir string, opts ...DockerSecretsStoreOption) (*DockerSecretsStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("secrets directory cannot be empty")
+	}
+
+	store := &DockerSecretsStore{Dir: dir}
+	for _, opt := range opts {
+		opt(store)
+	}
+	return store, nil
+}
+
+// newDockerSecretsStoreFromEnv creates a DockerSecretsStore from environment variables.
+func newDockerSecretsStoreFromEnv() (*DockerSecretsStore, error) {
+	opts := []DockerSecretsStoreOption{
+		WithDockerSecretsPrefix(os.Getenv(EnvDockerSecretsPrefix)),
+	}
+
+	if strings.EqualFold(os.Getenv(EnvDockerSecretsAPI), "true") {
+		client, err := docker.NewClientFromEnv()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create docker client: %w", err)
+		}
+		opts = append(opts, WithDockerSecretsClient(client))
+	}
+
+	return NewDockerSecretsStore(GetEnvDefault(EnvDockerSecretsDir, DefaultDockerSecretsDir), opts...)
+}
+
+// Save writes each credential as a secret, replacing existing secrets.
+func (s *DockerSecretsStore) Save(ctx context.Context, creds *AppCredentials) error {
+	for key, value := range credentialValues(creds) {
+		if err := s.put(ctx, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Status returns the current registration state. With the Engine API,
+// registration is determined by which secrets exist, since their values
+// cannot be read back through the API.
+func (s *DockerSecretsStore) Status(ctx context.Context) (*InstallerStatus, error) {
+	values, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	status := statusFromValues(values)
+
+	if s.client != nil {
+		status.Registered = true
+		for _, key := range requiredKeys {
+			exists, err := s.exists(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			status.Registered = status.Registered && exists
+		}
+		// The installer flag secret is only ever created with "false".
+		if status.InstallerDisabled, err = s.exists(ctx, EnvGitHubAppInstallerEnabled); err != nil {
+			return nil, err
+		}
+	}
+
+	return status, nil
+}
+
+// Load reads the credentials from the secrets directory.
+func (s *DockerSecretsStore) Load(ctx context.Context) (*AppCredentials, error) {
+	values, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return credentialsFromValues(values)
+}
+
+// DisableInstaller writes a github_app_installer_enabled secret set to false.
+func (s *DockerSecretsStore) DisableInstaller(ctx context.Context) error {
+	return s.put(ctx, EnvGitHubAppInstallerEnabled, "false")
+}
+
+// Delete removes the credential and installer flag secrets. Through the
+// Engine API, secrets still used by a service cannot be removed.
+func (s *DockerSecretsStore) Delete(ctx context.Context) error {
+	for _, key := range knownKeys {
+		if err := s.remove(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ping checks that the secrets directory can be written, or with the Engine
+// API, that secrets can be listed.
+func (s *DockerSecretsStore) Ping(ctx context.Context) error {
+	if s.client == nil {
+		return checkWritableDir(s.Dir)
+	}
+	_, err := s.exists(ctx, EnvGitHubAppID)
+	return err
+}
+
+// Rotate writes creds and then removes the secrets of credential values that
+// creds does not set.
+func (s *DockerSecretsStore) Rotate(ctx context.Context, creds *AppCredentials) error {
+	if err := s.Save(ctx, creds); err != nil {
+		return err
+	}
+	for _, key := range staleKeys(credentialValues(creds)) {
+		if err := s.remove(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// secretName returns the secret name for an environment variable.
+func (s *DockerSecretsStore) secretName(key string) string {
+	return s.Prefix + strings.ToLower(key)
+}
+
+// put creates or replaces a secret. Engine API secrets are immutable, so an
+// existing secret is removed and recreated.
+func (s *DockerSecretsStore) put(ctx context.Context, key, value string) error {
+	name := s.secretName(key)
+
+	if s.client == nil {
+		if err := os.MkdirAll(s.Dir, 0700); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", s.Dir, err)
+		}
+		if err := writeFileAtomic(filepath.Join(s.Dir, name), []byte(value), 0600); err != nil {
+			return fmt.Errorf("failed to write secret %s: %w", name, err)
+		}
+		return nil
+	}
+
+	if err := s.remove(ctx, key); err != nil {
+		return err
+	}
+	labels := map[string]string{"managed-by": "octo-sts-installer"}
+	if _, err := s.client.CreateSecret(ctx, name, []byte(value), labels); err != nil {
+		return fmt.Errorf("failed to create secret %s: %w", name, err)
+	}
+	return nil
+}
+
+// remove deletes a secret if it exists.
+func (s *DockerSecretsStore) remove(ctx context.Context, key string) error {
+	name := s.secretName(key)
+
+	if s.client == nil {
+		if err := os.Remove(filepath.Join(s.Dir, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove secret %s: %w", name, err)
+		}
+		return nil
+	}
+
+	secret, err := s.client.GetSecret(ctx, name)
+	if errors.Is(err, docker.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up secret %s: %w", name, err)
+	}
+	if err := s.client.RemoveSecret(ctx, secret.ID); err != nil && !errors.Is(err, docker.ErrNotFound) {
+		return fmt.Errorf("failed to remove secret %s: %w", name, err)
+	}
+	return nil
+}
+
+// exists reports whether the secret for key exists in the engine.
+func (s *DockerSecretsStore) exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.client.GetSecret(ctx, s.secretName(key))
+	if errors.Is(err, docker.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up secret %s: %w", s.secretName(key), err)
+	}
+	return true, nil
+}
+
+// read returns the known credential values present in the secrets directory.
+func (s *DockerSecretsStore) read() (map[string]string, error) {
+	values := make(map[string]string)
+	for _, key := range knownKeys {
+		data, err := os.ReadFile(filepath.Join(s.Dir, s.secretName(key)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if key == EnvGitHubAppPrivateKey {
+			values[key] = string(data)
+		} else {
+			values[key] = strings.TrimSpace(string(data))
+		}
+	}
+	return values, nil
+}
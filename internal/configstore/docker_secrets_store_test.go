@@ -0,0 +1,106 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cruxstack/octo-sts-distros/internal/docker"
+)
+
+// fakeDockerSecrets is an in-memory DockerSecretsClient for tests.
+type fakeDockerSecrets struct {
+	secrets map[string][]byte
+	created int
+}
+
+func (f *fakeDockerSecrets) GetSecret(_ context.Context, name string) (*docker.Secret, error) {
+	if _, ok := f.secrets[name]; !ok {
+		return nil, docker.ErrNotFound
+	}
+	return &docker.Secret{ID: "id-" + name, Name: name}, nil
+}
+
+func (f *fakeDockerSecrets) CreateSecret(_ context.Context, name string, data []byte, _ map[string]string) (string, error) {
+	if _, ok := f.secrets[name]; ok {
+		return "", errors.New("secret already exists")
+	}
+	f.secrets[name] = data
+	f.created++
+	return "id-" + name, nil
+}
+
+func (f *fakeDockerSecrets) RemoveSecret(_ context.Context, id string) error {
+	delete(f.secrets, id[len("id-"):])
+	return nil
+}
+
+func TestDockerSecretsStoreDir(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, err := NewDockerSecretsStore(dir, WithDockerSecretsPrefix("octo_sts_"))
+	if err != nil {
+		t.Fatalf("NewDockerSecretsStore() error = %v", err)
+	}
+
+	want := testAppCredentials()
+	if err := store.Save(ctx, want); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "octo_sts_github_app_private_key")); err != nil {
+		t.Errorf("expected private key secret file: %v", err)
+	}
+
+	got, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got.PrivateKey != want.PrivateKey || got.CustomFields[EnvSTSDomain] != "sts.example.com" {
+		t.Errorf("unexpected credentials: %+v", got)
+	}
+
+	if err := store.Delete(ctx); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Load(ctx); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("expected ErrNotRegistered after delete, got %v", err)
+	}
+}
+
+func TestDockerSecretsStoreAPI(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeDockerSecrets{secrets: make(map[string][]byte)}
+
+	store, err := NewDockerSecretsStore(t.TempDir(), WithDockerSecretsClient(client))
+	if err != nil {
+		t.Fatalf("NewDockerSecretsStore() error = %v", err)
+	}
+
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	// Saving again must replace the immutable secrets rather than fail.
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("second Save() error = %v", err)
+	}
+	if err := store.DisableInstaller(ctx); err != nil {
+		t.Fatalf("DisableInstaller() error = %v", err)
+	}
+
+	status, err := store.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if !status.Registered || !status.InstallerDisabled {
+		t.Errorf("expected registered with installer disabled, got %+v", status)
+	}
+	if got := string(client.secrets["github_client_id"]); got != "Iv1.abc" {
+		t.Errorf("expected client id secret, got %q", got)
+	}
+}
@@ -0,0 +1,426 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Environment variables for keeping the installer status in DynamoDB.
+const (
+	EnvAWSDynamoDBStatusTable = "AWS_DYNAMODB_STATUS_TABLE"
+	EnvAWSDynamoDBStatusID    = "AWS_DYNAMODB_STATUS_ID"
+	EnvAWSDynamoDBLockTTL     = "AWS_DYNAMODB_LOCK_TTL"
+)
+
+// Defaults for DynamoDBStatusStore.
+const (
+	DefaultDynamoDBStatusID = "octo-sts"
+	DefaultDynamoDBLockTTL  = 30 * time.Second
+)
+
+// Attributes of the DynamoDB status item. The table's partition key must be
+// a string attribute named "id".
+const (
+	ddbAttrID                = "id"
+	ddbAttrRegistered        = "registered"
+	ddbAttrInstallerDisabled = "installer_disabled"
+	ddbAttrAppID             = "app_id"
+	ddbAttrAppSlug           = "app_slug"
+	ddbAttrHTMLURL           = "html_url"
+	ddbAttrUpdatedAt         = "updated_at"
+	ddbAttrLockOwner         = "lock_owner"
+	ddbAttrLockExpires       = "lock_expires"
+)
+
+// ErrLocked is returned when another instance holds the setup lock.
+var ErrLocked = errors.New("another setup or disable is in progress")
+
+// ErrInstallerDisabled is returned when saving credentials after the
+// installer was disabled.
+var ErrInstallerDisabled = errors.New("installer is disabled")
+
+// DynamoDBClient defines the interface for the DynamoDB operations used by
+// DynamoDBStatusStore.
+type DynamoDBClient interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput,
+		optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput,
+		optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+}
+
+// DynamoDBStatusStore wraps a Store and keeps the installer status in a
+// DynamoDB item, read with strong consistency. Writes take a lock on the
+// item first, so concurrent setups and disables across Lambda instances
+// are serialized, and a setup can't complete after the installer was
+// disabled. Credentials are still saved to the wrapped store.
+type DynamoDBStatusStore struct {
+	Store
+
+	TableName string
+	ItemID    string
+	LockTTL   time.Duration
+	Retry     AWSRetryOptions
+	client    DynamoDBClient
+	now       func() time.Time
+}
+
+// DynamoDBStatusStoreOption is a functional option for configuring
+// DynamoDBStatusStore.
+type DynamoDBStatusStoreOption func(*DynamoDBStatusStore)
+
+// WithDynamoDBStatusID sets the partition key of the status item, so several
+// deployments can share a table (defaults to "octo-sts").
+func WithDynamoDBStatusID(id string) DynamoDBStatusStoreOption {
+	return func(s *DynamoDBStatusStore) {
+		s.ItemID = id
+	}
+}
+
+// WithDynamoDBLockTTL sets how long a lock is held before another instance
+// may take it over, e.g. after a Lambda timed out mid-setup (defaults to 30
+// seconds).
+func WithDynamoDBLockTTL(ttl time.Duration) DynamoDBStatusStoreOption {
+	return func(s *DynamoDBStatusStore) {
+		s.LockTTL = ttl
+	}
+}
+
+// WithDynamoDBRetry sets how failed DynamoDB requests are retried. It is
+// ignored when a client is set with WithDynamoDBClient. Lock conflicts are
+// not retried; they fail with ErrLocked.
+func WithDynamoDBRetry(retry AWSRetryOptions) DynamoDBStatusStoreOption {
+	return func(s *DynamoDBStatusStore) {
+		s.Retry = retry
+	}
+}
+
+// WithDynamoDBClient sets a custom DynamoDB client.
+func WithDynamoDBClient(client DynamoDBClient) DynamoDBStatusStoreOption {
+	return func(s *DynamoDBStatusStore) {
+		s.client = client
+	}
+}
+
+// NewDynamoDBStatusStore wraps store, keeping its installer status in table.
+func NewDynamoDBStatusStore(store Store, table string, opts ...DynamoDBStatusStoreOption) (*DynamoDBStatusStore, error) {
+	if table == "" {
+		return nil, fmt.Errorf("table name cannot be empty")
+	}
+
+	s := &DynamoDBStatusStore{
+		Store:     store,
+		TableName: table,
+		ItemID:    DefaultDynamoDBStatusID,
+		LockTTL:   DefaultDynamoDBLockTTL,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.ItemID == "" {
+		return nil, fmt.Errorf("status item id cannot be empty")
+	}
+	if s.LockTTL <= 0 {
+		return nil, fmt.Errorf("lock ttl must be positive, got %s", s.LockTTL)
+	}
+
+	if s.client == nil {
+		cfg, err := loadAWSConfig(context.Background(), s.Retry)
+		if err != nil {
+			return nil, err
+		}
+		s.client = dynamodb.NewFromConfig(cfg)
+	}
+
+	return s, nil
+}
+
+// newDynamoDBStatusStoreFromEnv wraps store with a DynamoDBStatusStore when
+// AWS_DYNAMODB_STATUS_TABLE is set, and returns store unchanged otherwise.
+func newDynamoDBStatusStoreFromEnv(store Store) (Store, error) {
+	table := os.Getenv(EnvAWSDynamoDBStatusTable)
+	if table == "" {
+		return store, nil
+	}
+
+	var opts []DynamoDBStatusStoreOption
+	if id := os.Getenv(EnvAWSDynamoDBStatusID); id != "" {
+		opts = append(opts, WithDynamoDBStatusID(id))
+	}
+	if value := os.Getenv(EnvAWSDynamoDBLockTTL); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %q", EnvAWSDynamoDBLockTTL, value)
+		}
+		opts = append(opts, WithDynamoDBLockTTL(ttl))
+	}
+
+	retry, err := awsRetryOptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, WithDynamoDBRetry(retry))
+
+	return NewDynamoDBStatusStore(store, table, opts...)
+}
+
+// Unwrap returns the wrapped store.
+func (s *DynamoDBStatusStore) Unwrap() Store {
+	return s.Store
+}
+
+// Watch watches the wrapped store.
+func (s *DynamoDBStatusStore) Watch(ctx context.Context) (<-chan struct{}, error) {
+	return Watch(ctx, s.Store)
+}
+
+// History returns the credential versions of the wrapped store.
+func (s *DynamoDBStatusStore) History(ctx context.Context) ([]CredentialVersion, error) {
+	return History(ctx, s.Store)
+}
+
+// Rollback rotates the wrapped store back to the version with the given ID
+// while holding the lock.
+func (s *DynamoDBStatusStore) Rollback(ctx context.Context, id string) error {
+	return rollback(ctx, s, id)
+}
+
+// Status reads the installer status from DynamoDB. Until the first write
+// creates the item, the status of the wrapped store is returned, so an
+// existing deployment can adopt the table without a migration.
+func (s *DynamoDBStatusStore) Status(ctx context.Context) (*InstallerStatus, error) {
+	item, err := s.getItem(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := item[ddbAttrRegistered]; !ok {
+		return s.Store.Status(ctx)
+	}
+
+	status := &InstallerStatus{
+		Registered:        ddbBool(item[ddbAttrRegistered]),
+		InstallerDisabled: ddbBool(item[ddbAttrInstallerDisabled]),
+		AppSlug:           ddbString(item[ddbAttrAppSlug]),
+		HTMLURL:           ddbString(item[ddbAttrHTMLURL]),
+	}
+	if id, err := strconv.ParseInt(ddbNumber(item[ddbAttrAppID]), 10, 64); err == nil {
+		status.AppID = id
+	}
+	return status, nil
+}
+
+// Save saves creds to the wrapped store while holding the lock. Returns
+// ErrInstallerDisabled if the installer was disabled in the meantime.
+func (s *DynamoDBStatusStore) Save(ctx context.Context, creds *AppCredentials) error {
+	return s.withLock(ctx, true, func() error {
+		return s.Store.Save(ctx, creds)
+	})
+}
+
+// Rotate rotates creds into the wrapped store while holding the lock.
+// Unlike Save, it is allowed once the installer is disabled.
+func (s *DynamoDBStatusStore) Rotate(ctx context.Context, creds *AppCredentials) error {
+	return s.withLock(ctx, false, func() error {
+		return s.Store.Rotate(ctx, creds)
+	})
+}
+
+// Delete deletes the credentials from the wrapped store while holding the
+// lock.
+func (s *DynamoDBStatusStore) Delete(ctx context.Context) error {
+	return s.withLock(ctx, false, func() error {
+		return s.Store.Delete(ctx)
+	})
+}
+
+// DisableInstaller disables the installer in the wrapped store while
+// holding the lock.
+func (s *DynamoDBStatusStore) DisableInstaller(ctx context.Context) error {
+	return s.withLock(ctx, false, func() error {
+		return s.Store.DisableInstaller(ctx)
+	})
+}
+
+// Ping checks that the status item can be read and pings the wrapped store.
+func (s *DynamoDBStatusStore) Ping(ctx context.Context) error {
+	if _, err := s.getItem(ctx); err != nil {
+		return err
+	}
+	return s.Store.Ping(ctx)
+}
+
+// withLock takes the lock, runs fn, records the resulting status of the
+// wrapped store in the item, and releases the lock. With enabledOnly, the
+// lock is refused while the installer is disabled.
+func (s *DynamoDBStatusStore) withLock(ctx context.Context, enabledOnly bool, fn func() error) error {
+	owner, err := s.lock(ctx, enabledOnly)
+	if err != nil {
+		return err
+	}
+
+	fnErr := fn()
+	var status *InstallerStatus
+	if fnErr == nil {
+		status, err = s.Store.Status(ctx)
+		if err != nil {
+			fnErr = fmt.Errorf("failed to read status after write: %w", err)
+		}
+	}
+	// Release even when the request was canceled, so the next attempt
+	// doesn't have to wait out the lock.
+	if err := s.unlock(context.WithoutCancel(ctx), owner, status); err != nil && fnErr == nil {
+		return err
+	}
+	return fnErr
+}
+
+// lock takes the lock on the status item, creating the item if needed, and
+// returns the owner token to release it with.
+func (s *DynamoDBStatusStore) lock(ctx context.Context, enabledOnly bool) (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate lock owner: %w", err)
+	}
+	owner := hex.EncodeToString(token)
+	now := s.now()
+
+	condition := "(attribute_not_exists(#owner) OR #expires < :now)"
+	values := map[string]types.AttributeValue{
+		":owner":   &types.AttributeValueMemberS{Value: owner},
+		":expires": ddbTime(now.Add(s.LockTTL)),
+		":now":     ddbTime(now),
+	}
+	names := map[string]string{
+		"#owner":   ddbAttrLockOwner,
+		"#expires": ddbAttrLockExpires,
+	}
+	if enabledOnly {
+		condition += " AND (attribute_not_exists(#disabled) OR #disabled = :false)"
+		values[":false"] = &types.AttributeValueMemberBOOL{Value: false}
+		names["#disabled"] = ddbAttrInstallerDisabled
+	}
+
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                           aws.String(s.TableName),
+		Key:                                 s.key(),
+		UpdateExpression:                    aws.String("SET #owner = :owner, #expires = :expires"),
+		ConditionExpression:                 aws.String(condition),
+		ExpressionAttributeNames:            names,
+		ExpressionAttributeValues:           values,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	var failed *types.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		if enabledOnly && ddbBool(failed.Item[ddbAttrInstallerDisabled]) {
+			return "", ErrInstallerDisabled
+		}
+		return "", ErrLocked
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to lock status item in %s: %w", s.TableName, err)
+	}
+	return owner, nil
+}
+
+// unlock releases the lock held by owner and, when status is set, records it.
+func (s *DynamoDBStatusStore) unlock(ctx context.Context, owner string, status *InstallerStatus) error {
+	update := "REMOVE #owner, #expires"
+	names := map[string]string{
+		"#owner":   ddbAttrLockOwner,
+		"#expires": ddbAttrLockExpires,
+	}
+	values := map[string]types.AttributeValue{
+		":owner": &types.AttributeValueMemberS{Value: owner},
+	}
+	if status != nil {
+		update = "SET #registered = :registered, #disabled = :disabled, #app_id = :app_id, " +
+			"#slug = :slug, #html_url = :html_url, #updated = :updated " + update
+		names["#registered"] = ddbAttrRegistered
+		names["#disabled"] = ddbAttrInstallerDisabled
+		names["#app_id"] = ddbAttrAppID
+		names["#slug"] = ddbAttrAppSlug
+		names["#html_url"] = ddbAttrHTMLURL
+		names["#updated"] = ddbAttrUpdatedAt
+		values[":registered"] = &types.AttributeValueMemberBOOL{Value: status.Registered}
+		values[":disabled"] = &types.AttributeValueMemberBOOL{Value: status.InstallerDisabled}
+		values[":app_id"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(status.AppID, 10)}
+		values[":slug"] = &types.AttributeValueMemberS{Value: status.AppSlug}
+		values[":html_url"] = &types.AttributeValueMemberS{Value: status.HTMLURL}
+		values[":updated"] = ddbTime(s.now())
+	}
+
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.TableName),
+		Key:                       s.key(),
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("#owner = :owner"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	var failed *types.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		return fmt.Errorf("lock on status item in %s expired before the write finished", s.TableName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unlock status item in %s: %w", s.TableName, err)
+	}
+	return nil
+}
+
+// getItem reads the status item with a strongly consistent read. Returns an
+// empty item if it doesn't exist yet.
+func (s *DynamoDBStatusStore) getItem(ctx context.Context) (map[string]types.AttributeValue, error) {
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.TableName),
+		Key:            s.key(),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status item from %s: %w", s.TableName, err)
+	}
+	return out.Item, nil
+}
+
+// key returns the primary key of the status item.
+func (s *DynamoDBStatusStore) key() map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		ddbAttrID: &types.AttributeValueMemberS{Value: s.ItemID},
+	}
+}
+
+// ddbTime encodes t as a number of Unix seconds.
+func ddbTime(t time.Time) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(t.Unix(), 10)}
+}
+
+func ddbBool(v types.AttributeValue) bool {
+	b, ok := v.(*types.AttributeValueMemberBOOL)
+	return ok && b.Value
+}
+
+func ddbString(v types.AttributeValue) string {
+	if s, ok := v.(*types.AttributeValueMemberS); ok {
+		return s.Value
+	}
+	return ""
+}
+
+func ddbNumber(v types.AttributeValue) string {
+	if n, ok := v.(*types.AttributeValueMemberN); ok {
+		return n.Value
+	}
+	return ""
+}
@@ -0,0 +1,156 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// fakeDynamoDB is an in-memory DynamoDBClient for tests holding a single
+// item. It only understands the lock and unlock updates DynamoDBStatusStore
+// makes.
+type fakeDynamoDB struct {
+	mu   sync.Mutex
+	item map[string]types.AttributeValue
+}
+
+func (f *fakeDynamoDB) GetItem(_ context.Context, in *dynamodb.GetItemInput,
+	_ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !aws.ToBool(in.ConsistentRead) {
+		return nil, errors.New("expected a consistent read")
+	}
+	return &dynamodb.GetItemOutput{Item: maps.Clone(f.item)}, nil
+}
+
+func (f *fakeDynamoDB) UpdateItem(_ context.Context, in *dynamodb.UpdateItemInput,
+	_ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.item == nil {
+		f.item = maps.Clone(in.Key)
+	}
+	values := in.ExpressionAttributeValues
+	condition := aws.ToString(in.ConditionExpression)
+
+	if strings.HasPrefix(condition, "(attribute_not_exists(#owner)") {
+		if owner, ok := f.item[ddbAttrLockOwner]; ok && owner != nil {
+			expires, _ := strconv.ParseInt(ddbNumber(f.item[ddbAttrLockExpires]), 10, 64)
+			now, _ := strconv.ParseInt(ddbNumber(values[":now"]), 10, 64)
+			if expires >= now {
+				return nil, &types.ConditionalCheckFailedException{Item: maps.Clone(f.item)}
+			}
+		}
+		if strings.Contains(condition, "#disabled") && ddbBool(f.item[ddbAttrInstallerDisabled]) {
+			return nil, &types.ConditionalCheckFailedException{Item: maps.Clone(f.item)}
+		}
+		f.item[ddbAttrLockOwner] = values[":owner"]
+		f.item[ddbAttrLockExpires] = values[":expires"]
+		return &dynamodb.UpdateItemOutput{}, nil
+	}
+
+	if ddbString(f.item[ddbAttrLockOwner]) != ddbString(values[":owner"]) {
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	// Placeholders are named after their values, e.g. #slug = :slug.
+	for placeholder, attr := range in.ExpressionAttributeNames {
+		if value, ok := values[":"+strings.TrimPrefix(placeholder, "#")]; ok && attr != ddbAttrLockOwner {
+			f.item[attr] = value
+		}
+	}
+	delete(f.item, ddbAttrLockOwner)
+	delete(f.item, ddbAttrLockExpires)
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func newTestDynamoDBStatusStore(t *testing.T, client *fakeDynamoDB) *DynamoDBStatusStore {
+	t.Helper()
+	inner := NewLocalFileStore(filepath.Join(t.TempDir(), "files"))
+	store, err := NewDynamoDBStatusStore(inner, "octo-sts-status", WithDynamoDBClient(client))
+	if err != nil {
+		t.Fatalf("NewDynamoDBStatusStore() error = %v", err)
+	}
+	return store
+}
+
+func TestDynamoDBStatusStore(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeDynamoDB{}
+	store := newTestDynamoDBStatusStore(t, client)
+
+	status, err := store.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.Registered {
+		t.Error("expected unregistered status before the first save")
+	}
+
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, ok := client.item[ddbAttrLockOwner]; ok {
+		t.Error("expected lock to be released after Save")
+	}
+	status, err = store.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if !status.Registered || status.AppID != 1234 || status.AppSlug != "octo-sts-test" {
+		t.Errorf("unexpected status after Save: %+v", status)
+	}
+
+	if err := store.DisableInstaller(ctx); err != nil {
+		t.Fatalf("DisableInstaller() error = %v", err)
+	}
+	if status, _ := store.Status(ctx); !status.InstallerDisabled {
+		t.Error("expected installer to be disabled")
+	}
+	if err := store.Save(ctx, testAppCredentials()); !errors.Is(err, ErrInstallerDisabled) {
+		t.Errorf("expected ErrInstallerDisabled from Save, got %v", err)
+	}
+	rotated := testAppCredentials()
+	rotated.WebhookSecret = "rotated-secret"
+	if err := store.Rotate(ctx, rotated); err != nil {
+		t.Errorf("Rotate() after disable error = %v", err)
+	}
+}
+
+func TestDynamoDBStatusStoreLocked(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+	client := &fakeDynamoDB{item: map[string]types.AttributeValue{
+		ddbAttrID:          &types.AttributeValueMemberS{Value: DefaultDynamoDBStatusID},
+		ddbAttrLockOwner:   &types.AttributeValueMemberS{Value: "other-instance"},
+		ddbAttrLockExpires: ddbTime(now.Add(10 * time.Second)),
+	}}
+	store := newTestDynamoDBStatusStore(t, client)
+	store.now = func() time.Time { return now }
+
+	if err := store.Save(ctx, testAppCredentials()); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked while another instance holds the lock, got %v", err)
+	}
+	if status, _ := store.Unwrap().Status(ctx); status.Registered {
+		t.Error("expected credentials not to be saved without the lock")
+	}
+
+	// An expired lock is taken over.
+	store.now = func() time.Time { return now.Add(time.Minute) }
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() after lock expiry error = %v", err)
+	}
+}
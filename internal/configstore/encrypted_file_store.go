@@ -0,0 +1,374 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// Environment variables for the encrypted file store.
+const (
+	EnvEncryptedFileAgeRecipients   = "ENCRYPTED_FILE_AGE_RECIPIENTS"
+	EnvEncryptedFileAgeIdentityFile = "ENCRYPTED_FILE_AGE_IDENTITY_FILE"
+	EnvEncryptedFileKMSKeyID        = "ENCRYPTED_FILE_KMS_KEY_ID"
+)
+
+// StorageModeEncryptedFile saves credentials to a local file encrypted with
+// age or AWS KMS.
+const StorageModeEncryptedFile = "encrypted-file"
+
+// DefaultEncryptedFilePath is the default path of the encrypted file.
+const DefaultEncryptedFilePath = "./.env.enc"
+
+// Encrypter encrypts and decrypts the contents of an encrypted file store.
+type Encrypter interface {
+	Encrypt(ctx context.Context, plaintext []byte) ([]byte, error)
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// EncryptedFileStore saves credentials to a single local file as an
+// encrypted JSON object keyed by the environment variable names, for hosts
+// where plaintext key files are not acceptable.
+type EncryptedFileStore struct {
+	FilePath  string
+	encrypter Encrypter
+}
+
+// NewEncryptedFileStore creates a store that saves credentials to path,
+// encrypted with encrypter.
+func NewEncryptedFileStore(path string, encrypter Encrypter) (*EncryptedFileStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("encrypted file path cannot be empty")
+	}
+	if encrypter == nil {
+		return nil, fmt.Errorf("encrypter cannot be nil")
+	}
+	return &EncryptedFileStore{FilePath: path, encrypter: encrypter}, nil
+}
+
+// newEncryptedFileStoreFromEnv creates an EncryptedFileStore from environment
+// variables. Exactly one of age recipients or a KMS key must be configured.
+func newEncryptedFileStoreFromEnv() (*EncryptedFileStore, error) {
+	path := GetEnvDefault(EnvStorageDir, DefaultEncryptedFilePath)
+	recipients := os.Getenv(EnvEncryptedFileAgeRecipients)
+	kmsKeyID := os.Getenv(EnvEncryptedFileKMSKeyID)
+
+	switch {
+	case recipients != "" && kmsKeyID != "":
+		return nil, fmt.Errorf("only one of %s or %s may be set", EnvEncryptedFileAgeRecipients, EnvEncryptedFileKMSKeyID)
+	case recipients != "":
+		encrypter, err := NewAgeEncrypter(recipients, os.Getenv(EnvEncryptedFileAgeIdentityFile))
+		if err != nil {
+			return nil, err
+		}
+		return NewEncryptedFileStore(path, encrypter)
+	case kmsKeyID != "":
+		encrypter, err := NewKMSEncrypter(kmsKeyID)
+		if err != nil {
+			return nil, err
+		}
+		return NewEncryptedFileStore(path, encrypter)
+	default:
+		return nil, fmt.Errorf("%s or %s is required when using %s storage mode",
+			EnvEncryptedFileAgeRecipients, EnvEncryptedFileKMSKeyID, StorageModeEncryptedFile)
+	}
+}
+
+// Save writes credentials to the encrypted file, preserving unrelated keys.
+func (s *EncryptedFileStore) Save(ctx context.Context, creds *AppCredentials) error {
+	return s.merge(ctx, credentialValues(creds))
+}
+
+// Status returns the current registration state by decrypting the file.
+func (s *EncryptedFileStore) Status(ctx context.Context) (*InstallerStatus, error) {
+	values, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return statusFromValues(values), nil
+}
+
+// Load reads the stored credentials from the encrypted file.
+func (s *EncryptedFileStore) Load(ctx context.Context) (*AppCredentials, error) {
+	values, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return credentialsFromValues(values)
+}
+
+// DisableInstaller sets GITHUB_APP_INSTALLER_ENABLED=false in the encrypted file.
+func (s *EncryptedFileStore) DisableInstaller(ctx context.Context) error {
+	return s.merge(ctx, map[string]string{EnvGitHubAppInstallerEnabled: "false"})
+}
+
+// Delete removes the encrypted file.
+func (s *EncryptedFileStore) Delete(ctx context.Context) error {
+	if err := os.Remove(s.FilePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", s.FilePath, err)
+	}
+	return nil
+}
+
+// Ping decrypts the file, if it exists, and checks that its directory can be
+// written.
+func (s *EncryptedFileStore) Ping(ctx context.Context) error {
+	if _, err := s.read(ctx); err != nil {
+		return err
+	}
+	return checkWritableDir(filepath.Dir(s.FilePath))
+}
+
+// Rotate replaces the credentials in the encrypted file in a single write,
+// removing credential keys that creds does not set.
+func (s *EncryptedFileStore) Rotate(ctx context.Context, creds *AppCredentials) error {
+	values := credentialValues(creds)
+	return s.merge(ctx, values, staleKeys(values)...)
+}
+
+// merge writes values into the file, keeping existing keys other than those
+// listed in remove. The file is left untouched if nothing changes.
+func (s *EncryptedFileStore) merge(ctx context.Context, values map[string]string, remove ...string) error {
+	existing, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+	if !mergeValues(existing, values, remove) {
+		return nil
+	}
+
+	plaintext, err := json.Marshal(existing)
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+	ciphertext, err := s.encrypter.Encrypt(ctx, plaintext)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt credentials: %w", err)
+	}
+
+	dir := filepath.Dir(s.FilePath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	if err := writeFileAtomic(s.FilePath, ciphertext, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", s.FilePath, err)
+	}
+	return nil
+}
+
+// read decrypts the file, returning an empty map if it doesn't exist.
+func (s *EncryptedFileStore) read(ctx context.Context) (map[string]string, error) {
+	values := make(map[string]string)
+
+	ciphertext, err := os.ReadFile(s.FilePath)
+	if os.IsNotExist(err) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := s.encrypter.Decrypt(ctx, ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", s.FilePath, err)
+	}
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, fmt.Errorf("%s does not contain a JSON object: %w", s.FilePath, err)
+	}
+	return values, nil
+}
+
+// AgeEncrypter encrypts to one or more age recipients and writes the
+// ASCII-armored age format, so the file can also be read with the age CLI.
+type AgeEncrypter struct {
+	recipients []age.Recipient
+	identities []age.Identity
+}
+
+// NewAgeEncrypter creates an AgeEncrypter from comma- or newline-separated
+// recipients (age1... public keys). identityFile holds the private keys used
+// to decrypt; without it the store can save credentials but not read them.
+func NewAgeEncrypter(recipients, identityFile string) (*AgeEncrypter, error) {
+	parsed, err := age.ParseRecipients(strings.NewReader(strings.ReplaceAll(recipients, ",", "\n")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse age recipients: %w", err)
+	}
+
+	encrypter := &AgeEncrypter{recipients: parsed}
+	if identityFile != "" {
+		f, err := os.Open(identityFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open age identity file: %w", err)
+		}
+		defer f.Close()
+
+		if encrypter.identities, err = age.ParseIdentities(f); err != nil {
+			return nil, fmt.Errorf("failed to parse age identity file: %w", err)
+		}
+	}
+	return encrypter, nil
+}
+
+// Encrypt encrypts plaintext to the configured recipients.
+func (e *AgeEncrypter) Encrypt(_ context.Context, plaintext []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	armored := armor.NewWriter(&buf)
+	w, err := age.Encrypt(armored, e.recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := armored.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decrypt decrypts ciphertext with the configured identities.
+func (e *AgeEncrypter) Decrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
+	if len(e.identities) == 0 {
+		return nil, fmt.Errorf("no age identity configured (set %s)", EnvEncryptedFileAgeIdentityFile)
+	}
+	r, err := age.Decrypt(armor.NewReader(bytes.NewReader(ciphertext)), e.identities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// KMSClient defines the interface for the AWS KMS operations used for
+// envelope encryption.
+type KMSClient interface {
+	GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput,
+		optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput,
+		optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// KMSEncrypter performs envelope encryption: each write generates a fresh
+// AES-256 data key under the KMS key, encrypts the payload locally with
+// AES-GCM, and stores the KMS-encrypted data key alongside it.
+type KMSEncrypter struct {
+	KeyID  string
+	client KMSClient
+}
+
+// kmsEnvelope is the on-disk format written by KMSEncrypter.
+type kmsEnvelope struct {
+	KeyID        string `json:"kms_key_id"`
+	EncryptedKey []byte `json:"encrypted_key"`
+	Nonce        []byte `json:"nonce"`
+	Ciphertext   []byte `json:"ciphertext"`
+}
+
+// KMSEncrypterOption is a functional option for configuring KMSEncrypter.
+type KMSEncrypterOption func(*KMSEncrypter)
+
+// WithKMSClient sets a custom KMS client.
+func WithKMSClient(client KMSClient) KMSEncrypterOption {
+	return func(e *KMSEncrypter) {
+		e.client = client
+	}
+}
+
+// NewKMSEncrypter creates a KMSEncrypter for the given key ID, ARN, or alias.
+func NewKMSEncrypter(keyID string, opts ...KMSEncrypterOption) (*KMSEncrypter, error) {
+	if keyID == "" {
+		return nil, fmt.Errorf("kms key id cannot be empty")
+	}
+
+	encrypter := &KMSEncrypter{KeyID: keyID}
+	for _, opt := range opts {
+		opt(encrypter)
+	}
+
+	if encrypter.client == nil {
+		cfg, err := config.LoadDefaultConfig(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		}
+		encrypter.client = kms.NewFromConfig(cfg)
+	}
+
+	return encrypter, nil
+}
+
+// Encrypt encrypts plaintext under a new data key.
+func (e *KMSEncrypter) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	dataKey, err := e.client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   aws.String(e.KeyID),
+		KeySpec: kmstypes.DataKeySpecAes256,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	gcm, err := newGCM(dataKey.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(kmsEnvelope{
+		KeyID:        aws.ToString(dataKey.KeyId),
+		EncryptedKey: dataKey.CiphertextBlob,
+		Nonce:        nonce,
+		Ciphertext:   gcm.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+}
+
+// Decrypt decrypts the data key with KMS and then the payload.
+func (e *KMSEncrypter) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	var envelope kmsEnvelope
+	if err := json.Unmarshal(ciphertext, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid kms envelope: %w", err)
+	}
+
+	dataKey, err := e.client.Decrypt(ctx, &kms.DecryptInput{
+		CiphertextBlob: envelope.EncryptedKey,
+		KeyId:          aws.String(envelope.KeyID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key: %w", err)
+	}
+
+	gcm, err := newGCM(dataKey.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, envelope.Nonce, envelope.Ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,116 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// fakeKMS is a KMSClient that "encrypts" data keys by reversing them.
+type fakeKMS struct{}
+
+func (fakeKMS) GenerateDataKey(_ context.Context, in *kms.GenerateDataKeyInput,
+	_ ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error) {
+	key := bytes.Repeat([]byte{7}, 31)
+	key = append(key, 9)
+	return &kms.GenerateDataKeyOutput{KeyId: in.KeyId, Plaintext: key, CiphertextBlob: reverse(key)}, nil
+}
+
+func (fakeKMS) Decrypt(_ context.Context, in *kms.DecryptInput,
+	_ ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	return &kms.DecryptOutput{KeyId: in.KeyId, Plaintext: reverse(in.CiphertextBlob)}, nil
+}
+
+func TestEncryptedFileStore(t *testing.T) {
+	dir := t.TempDir()
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("GenerateX25519Identity() error = %v", err)
+	}
+	identityFile := filepath.Join(dir, "key.txt")
+	if err := os.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ageEncrypter, err := NewAgeEncrypter(identity.Recipient().String(), identityFile)
+	if err != nil {
+		t.Fatalf("NewAgeEncrypter() error = %v", err)
+	}
+
+	kmsEncrypter, err := NewKMSEncrypter("alias/octo-sts", WithKMSClient(fakeKMS{}))
+	if err != nil {
+		t.Fatalf("NewKMSEncrypter() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		encrypter Encrypter
+	}{
+		{name: "age", encrypter: ageEncrypter},
+		{name: "kms", encrypter: kmsEncrypter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			path := filepath.Join(dir, tt.name, "credentials.enc")
+
+			store, err := NewEncryptedFileStore(path, tt.encrypter)
+			if err != nil {
+				t.Fatalf("NewEncryptedFileStore() error = %v", err)
+			}
+			if _, err := store.Load(ctx); !errors.Is(err, ErrNotRegistered) {
+				t.Fatalf("expected ErrNotRegistered before save, got %v", err)
+			}
+
+			want := testAppCredentials()
+			if err := store.Save(ctx, want); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Contains(raw, []byte("PRIVATE KEY")) || bytes.Contains(raw, []byte(want.ClientSecret)) {
+				t.Fatal("expected credentials to be encrypted at rest")
+			}
+
+			got, err := store.Load(ctx)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if got.PrivateKey != want.PrivateKey || got.ClientSecret != want.ClientSecret {
+				t.Errorf("unexpected credentials: %+v", got)
+			}
+		})
+	}
+}
+
+func TestAgeEncrypterWithoutIdentity(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypter, err := NewAgeEncrypter(identity.Recipient().String(), "")
+	if err != nil {
+		t.Fatalf("NewAgeEncrypter() error = %v", err)
+	}
+
+	ciphertext, err := encrypter.Encrypt(context.Background(), []byte(`{}`))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if _, err := encrypter.Decrypt(context.Background(), ciphertext); err == nil {
+		t.Error("expected decrypt to fail without an identity")
+	}
+}
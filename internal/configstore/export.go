@@ -0,0 +1,95 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Formats supported by Export.
+const (
+	ExportFormatEnv  = "env"
+	ExportFormatJSON = "json"
+	ExportFormatYAML = "yaml"
+)
+
+// RedactedValue replaces values left out of an export.
+const RedactedValue = "REDACTED"
+
+// ExportOptions configures Export.
+type ExportOptions struct {
+	// Format is one of ExportFormatEnv (the default), ExportFormatJSON, or
+	// ExportFormatYAML.
+	Format string
+
+	// RedactPrivateKey replaces the private key with RedactedValue, e.g.
+	// when sharing the export for debugging.
+	RedactPrivateKey bool
+
+	// AllValues also exports values other than the app credentials,
+	// STS_DOMAIN, and the setup metadata.
+	AllValues bool
+}
+
+// Export writes the credentials in store to w, keyed by their environment
+// variable names, e.g. to seed another environment. A disabled installer is
+// exported as GITHUB_APP_INSTALLER_ENABLED=false. Returns ErrNotRegistered if
+// the store holds no credentials.
+func Export(ctx context.Context, store Store, w io.Writer, opts ExportOptions) error {
+	creds, err := store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	status, err := store.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	values := credentialValues(creds)
+	if !opts.AllValues {
+		maps.DeleteFunc(values, func(key, _ string) bool {
+			return !slices.Contains(knownKeys, key)
+		})
+	}
+	if status.InstallerDisabled {
+		values[EnvGitHubAppInstallerEnabled] = "false"
+	}
+	if opts.RedactPrivateKey {
+		values[EnvGitHubAppPrivateKey] = RedactedValue
+	}
+
+	var data []byte
+	switch opts.Format {
+	case ExportFormatEnv, "":
+		var b strings.Builder
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			// Same single-line PEM encoding as the .env file store.
+			b.WriteString(formatEnvLine(key, strings.ReplaceAll(values[key], "\n", "\\n")))
+			b.WriteByte('\n')
+		}
+		data = []byte(b.String())
+	case ExportFormatJSON:
+		data, err = json.MarshalIndent(values, "", "  ")
+		data = append(data, '\n')
+	case ExportFormatYAML:
+		data, err = yaml.Marshal(values)
+	default:
+		return fmt.Errorf("unsupported export format %q: expected %s, %s, or %s",
+			opts.Format, ExportFormatEnv, ExportFormatJSON, ExportFormatYAML)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,76 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), ".env")
+	store := NewLocalEnvFileStore(path)
+
+	if err := Export(ctx, store, &bytes.Buffer{}, ExportOptions{}); !errors.Is(err, ErrNotRegistered) {
+		t.Fatalf("expected ErrNotRegistered from an empty store, got %v", err)
+	}
+
+	creds := testAppCredentials()
+	creds.CustomFields["UNRELATED"] = "config"
+	if err := store.Save(ctx, creds); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := store.DisableInstaller(ctx); err != nil {
+		t.Fatalf("DisableInstaller() error = %v", err)
+	}
+
+	for _, format := range []string{ExportFormatJSON, ExportFormatYAML} {
+		t.Run(format, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := Export(ctx, store, &out, ExportOptions{Format: format}); err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+			var values map[string]string
+			if err := yaml.Unmarshal(out.Bytes(), &values); err != nil {
+				t.Fatalf("invalid %s output: %v", format, err)
+			}
+			if values[EnvGitHubAppPrivateKey] != creds.PrivateKey {
+				t.Errorf("private key = %q, want %q", values[EnvGitHubAppPrivateKey], creds.PrivateKey)
+			}
+			if values[EnvGitHubAppInstallerEnabled] != "false" || values[EnvSTSDomain] != "sts.example.com" {
+				t.Errorf("unexpected values: %v", values)
+			}
+			if _, ok := values["UNRELATED"]; ok {
+				t.Error("expected unrelated values to be left out")
+			}
+		})
+	}
+
+	t.Run("env redacted", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := Export(ctx, store, &out, ExportOptions{RedactPrivateKey: true, AllValues: true}); err != nil {
+			t.Fatalf("Export() error = %v", err)
+		}
+		if !strings.Contains(out.String(), EnvGitHubAppPrivateKey+"="+RedactedValue+"\n") {
+			t.Errorf("expected redacted private key, got:\n%s", out.String())
+		}
+		if strings.Contains(out.String(), "BEGIN RSA PRIVATE KEY") {
+			t.Error("expected no PEM in the redacted export")
+		}
+		if !strings.Contains(out.String(), "UNRELATED=config\n") {
+			t.Error("expected unrelated values with AllValues")
+		}
+	})
+
+	if err := Export(ctx, store, &bytes.Buffer{}, ExportOptions{Format: "toml"}); err == nil {
+		t.Error("expected error for an unsupported format")
+	}
+}
@@ -0,0 +1,15 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+//go:build !unix
+
+package configstore
+
+import "context"
+
+// lockFile is a no-op on platforms without flock. Writes are still atomic,
+// but concurrent read-modify-write cycles from several processes may lose
+// updates.
+func lockFile(context.Context, string) (func(), error) {
+	return func() {}, nil
+}
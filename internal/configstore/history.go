@@ -0,0 +1,189 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// ErrVersionNotFound is returned when rolling back to an unknown version.
+var ErrVersionNotFound = errors.New("credential version not found")
+
+// ErrHistoryNotSupported is returned by History and Rollback for stores that
+// don't keep prior versions of the credentials.
+var ErrHistoryNotSupported = errors.New("store does not keep credential history")
+
+// CurrentVersionID identifies the current credentials in stores that don't
+// assign their own version IDs.
+const CurrentVersionID = "current"
+
+// historyLimit is the number of prior versions kept by stores that archive
+// versions themselves (the local file stores).
+const historyLimit = 10
+
+// historyTimeFormat names archived versions so they sort chronologically.
+const historyTimeFormat = "20060102T150405.000000000Z"
+
+// CredentialVersion is one version of the stored credentials.
+type CredentialVersion struct {
+	ID          string
+	CreatedAt   time.Time
+	Current     bool
+	Credentials *AppCredentials
+}
+
+// VersionedStore is a Store that keeps prior versions of the credentials so
+// a botched rotation can be rolled back.
+type VersionedStore interface {
+	Store
+
+	// History returns the stored credential versions, newest first. Versions
+	// with incomplete credentials are omitted, as are versions identical to
+	// the next newer one (such as those written by DisableInstaller).
+	History(ctx context.Context) ([]CredentialVersion, error)
+
+	// Rollback rotates the credentials back to the version with the given ID.
+	// Returns ErrVersionNotFound if no such version exists.
+	Rollback(ctx context.Context, id string) error
+}
+
+// History returns the credential versions of store, newest first. Returns
+// ErrHistoryNotSupported if the store doesn't keep prior versions.
+func History(ctx context.Context, store Store) ([]CredentialVersion, error) {
+	if vs, ok := store.(VersionedStore); ok {
+		return vs.History(ctx)
+	}
+	return nil, ErrHistoryNotSupported
+}
+
+// Rollback rotates the credentials of store back to the version with the
+// given ID. Returns ErrHistoryNotSupported if the store doesn't keep prior
+// versions.
+func Rollback(ctx context.Context, store Store, id string) error {
+	if vs, ok := store.(VersionedStore); ok {
+		return vs.Rollback(ctx, id)
+	}
+	return ErrHistoryNotSupported
+}
+
+// rollback finds the version with the given ID and rotates the store to it.
+//
+// Since Rotate removes the keys a version lacks, the values a version saved
+// before they existed are carried over from the current version: STS_DOMAIN,
+// which configures the deployment rather than the app, and the metadata
+// keys when the version is of the same app. Rolling back to another app
+// drops the metadata, as it describes the app being replaced.
+func rollback(ctx context.Context, store VersionedStore, id string) error {
+	versions, err := store.History(ctx)
+	if err != nil {
+		return err
+	}
+
+	var current *AppCredentials
+	for _, version := range versions {
+		if version.Current {
+			current = version.Credentials
+		}
+	}
+
+	for _, version := range versions {
+		if version.ID != id {
+			continue
+		}
+		if version.Current {
+			return nil
+		}
+		creds := version.Credentials
+		if current != nil {
+			keep := []string{EnvSTSDomain}
+			if creds.AppID == current.AppID {
+				keep = append(keep, metadataKeys...)
+			}
+			creds = carryOver(creds, current, keep)
+		}
+		if err := store.Rotate(ctx, creds); err != nil {
+			return fmt.Errorf("failed to roll back to version %s: %w", id, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrVersionNotFound, id)
+}
+
+// carryOver returns a copy of creds with the given custom fields it lacks
+// set from current.
+func carryOver(creds, current *AppCredentials, keys []string) *AppCredentials {
+	c := *creds
+	c.CustomFields = maps.Clone(creds.CustomFields)
+	if c.CustomFields == nil {
+		c.CustomFields = make(map[string]string)
+	}
+	for _, key := range keys {
+		if c.CustomFields[key] == "" && current.CustomFields[key] != "" {
+			c.CustomFields[key] = current.CustomFields[key]
+		}
+	}
+	return &c
+}
+
+// compactHistory drops versions whose credentials match the next newer
+// version. versions must be sorted newest first.
+func compactHistory(versions []CredentialVersion) []CredentialVersion {
+	compacted := make([]CredentialVersion, 0, len(versions))
+	for _, version := range versions {
+		if n := len(compacted); n > 0 &&
+			maps.Equal(credentialValues(compacted[n-1].Credentials), credentialValues(version.Credentials)) {
+			continue
+		}
+		compacted = append(compacted, version)
+	}
+	return compacted
+}
+
+// archiveEntries returns the archived version names in dir, newest first,
+// with the time each was archived. A missing directory has no entries.
+func archiveEntries(dir string) ([]string, []time.Time, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if _, err := time.Parse(historyTimeFormat, entry.Name()); err == nil {
+			names = append(names, entry.Name())
+		}
+	}
+	slices.Sort(names)
+	slices.Reverse(names)
+
+	times := make([]time.Time, len(names))
+	for i, name := range names {
+		times[i], _ = time.Parse(historyTimeFormat, name)
+	}
+	return names, times, nil
+}
+
+// pruneArchives removes all but the newest historyLimit archives in dir.
+func pruneArchives(dir string) error {
+	names, _, err := archiveEntries(dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range names[min(len(names), historyLimit):] {
+		if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/cruxstack/octo-sts-distros/internal/kubernetes"
+)
+
+// Environment variables for the Kubernetes Secret store.
+const (
+	EnvKubernetesSecretNamespace = "KUBERNETES_SECRET_NAMESPACE"
+	EnvKubernetesSecretName      = "KUBERNETES_SECRET_NAME"
+)
+
+// StorageModeKubernetes saves credentials to a Kubernetes Secret.
+const StorageModeKubernetes = "kubernetes"
+
+// DefaultKubernetesSecretName is the default Kubernetes Secret name.
+const DefaultKubernetesSecretName = "octo-sts"
+
+// KubernetesClient defines the interface for Kubernetes Secret operations.
+type KubernetesClient interface {
+	GetSecret(ctx context.Context, namespace, name string) (*kubernetes.Secret, error)
+	ApplySecret(ctx context.Context, secret *kubernetes.Secret) error
+	DeleteSecret(ctx context.Context, namespace, name string) error
+}
+
+// KubernetesSecretStore saves credentials to a Kubernetes Secret keyed by the
+// environment variable names, so the Secret can be consumed via envFrom.
+type KubernetesSecretStore struct {
+	Namespace string
+	Name      string
+	client    KubernetesClient
+}
+
+// KubernetesStoreOption is a functional option for configuring KubernetesSecretStore.
+type KubernetesStoreOption func(*KubernetesSecretStore)
+
+// WithKubernetesNamespace sets the Secret namespace. Defaults to the
+// namespace of the service account or kubeconfig context.
+func WithKubernetesNamespace(namespace string) KubernetesStoreOption {
+	return func(s *KubernetesSecretStore) {
+		s.Namespace = namespace
+	}
+}
+
+// WithKubernetesClient sets a custom Kubernetes client.
+func WithKubernetesClient(client KubernetesClient) KubernetesStoreOption {
+	return func(s *KubernetesSecretStore) {
+		s.client = client
+	}
+}
+
+// NewKubernetesSecretStore creates a new Kubernetes Secret backend. Unless a
+// client is supplied, the in-cluster service account is used when running in
+// a pod and the kubeconfig otherwise.
+func NewKubernetesSecretStore(name string, opts ...KubernetesStoreOption) (*KubernetesSecretStore, error) {
+	if name == "" {
+		return nil, fmt.Errorf("secret name cannot be empty")
+	}
+
+	store := &KubernetesSecretStore{
+		Name: name,
+	}
+
+	for _, opt := range opts {
+		opt(store)
+	}
+
+	if store.client == nil {
+		client, err := kubernetes.NewClient()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+		}
+		store.client = client
+		if store.Namespace == "" {
+			store.Namespace = client.Namespace
+		}
+	}
+
+	if store.Namespace == "" {
+		return nil, fmt.Errorf("secret namespace cannot be empty")
+	}
+
+	return store, nil
+}
+
+// newKubernetesSecretStoreFromEnv creates a KubernetesSecretStore from environment variables.
+func newKubernetesSecretStoreFromEnv() (*KubernetesSecretStore, error) {
+	var opts []KubernetesStoreOption
+	if ns := os.Getenv(EnvKubernetesSecretNamespace); ns != "" {
+		opts = append(opts, WithKubernetesNamespace(ns))
+	}
+	return NewKubernetesSecretStore(GetEnvDefault(EnvKubernetesSecretName, DefaultKubernetesSecretName), opts...)
+}
+
+// Save writes credentials to the Secret, creating it if needed. Keys not
+// written by the installer are left untouched.
+func (s *KubernetesSecretStore) Save(ctx context.Context, creds *AppCredentials) error {
+	return s.apply(ctx, credentialValues(creds))
+}
+
+// Status returns the current registration state by reading the Secret.
+func (s *KubernetesSecretStore) Status(ctx context.Context) (*InstallerStatus, error) {
+	values, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return statusFromValues(values), nil
+}
+
+// Load reads the stored credentials from the Secret.
+func (s *KubernetesSecretStore) Load(ctx context.Context) (*AppCredentials, error) {
+	values, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return credentialsFromValues(values)
+}
+
+// read returns the Secret data as strings, or an empty map if it doesn't exist.
+func (s *KubernetesSecretStore) read(ctx context.Context) (map[string]string, error) {
+	values := make(map[string]string)
+
+	secret, err := s.client.GetSecret(ctx, s.Namespace, s.Name)
+	if errors.Is(err, kubernetes.ErrNotFound) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret %s/%s: %w", s.Namespace, s.Name, err)
+	}
+
+	for key, value := range secret.Data {
+		values[key] = string(value)
+	}
+	return values, nil
+}
+
+// DisableInstaller sets GITHUB_APP_INSTALLER_ENABLED=false in the Secret.
+func (s *KubernetesSecretStore) DisableInstaller(ctx context.Context) error {
+	return s.apply(ctx, map[string]string{EnvGitHubAppInstallerEnabled: "false"})
+}
+
+// Delete deletes the Secret.
+func (s *KubernetesSecretStore) Delete(ctx context.Context) error {
+	if err := s.client.DeleteSecret(ctx, s.Namespace, s.Name); err != nil {
+		return fmt.Errorf("failed to delete secret %s/%s: %w", s.Namespace, s.Name, err)
+	}
+	return nil
+}
+
+// Ping reads the Secret, which checks the service account's access to it.
+func (s *KubernetesSecretStore) Ping(ctx context.Context) error {
+	_, err := s.read(ctx)
+	return err
+}
+
+// Rotate writes creds to the Secret in a single patch, removing credential
+// keys that creds does not set.
+func (s *KubernetesSecretStore) Rotate(ctx context.Context, creds *AppCredentials) error {
+	values := credentialValues(creds)
+	return s.apply(ctx, values, staleKeys(values)...)
+}
+
+// apply merges values into the Secret and removes the keys listed in remove.
+// Only keys that change are sent, and nothing is sent if none do.
+func (s *KubernetesSecretStore) apply(ctx context.Context, values map[string]string, remove ...string) error {
+	existing, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	data := make(map[string][]byte, len(values)+len(remove))
+	for _, key := range remove {
+		if _, ok := existing[key]; ok {
+			data[key] = nil
+		}
+	}
+	for key, value := range changedValues(existing, values) {
+		data[key] = []byte(value)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	if err := s.client.ApplySecret(ctx, &kubernetes.Secret{
+		Name:      s.Name,
+		Namespace: s.Namespace,
+		Labels: map[string]string{
+			"app.kubernetes.io/name":       "octo-sts",
+			"app.kubernetes.io/managed-by": "octo-sts-installer",
+		},
+		Data: data,
+	}); err != nil {
+		return fmt.Errorf("failed to save secret %s/%s: %w", s.Namespace, s.Name, err)
+	}
+	return nil
+}
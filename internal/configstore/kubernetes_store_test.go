@@ -0,0 +1,155 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeKubernetesAPI serves Secret get, create, and merge-patch requests.
+type fakeKubernetesAPI struct {
+	mu      sync.Mutex
+	secrets map[string]map[string][]byte
+}
+
+func (f *fakeKubernetesAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer k8s-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var body struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Data map[string][]byte `json:"data"`
+	}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/api/v1/namespaces/")
+	switch r.Method {
+	case http.MethodGet:
+		data, ok := f.secrets[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	case http.MethodPatch:
+		if r.Header.Get("Content-Type") != "application/merge-patch+json" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		data, ok := f.secrets[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		for k, v := range body.Data {
+			data[k] = v
+		}
+		w.Write([]byte(`{}`))
+	case http.MethodPost:
+		f.secrets[key+"/"+body.Metadata.Name] = body.Data
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`))
+	}
+}
+
+func TestKubernetesSecretStore(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeKubernetesAPI{secrets: map[string]map[string][]byte{}}
+	srv := httptest.NewTLSServer(fake)
+	defer srv.Close()
+
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: test
+clusters:
+- name: test
+  cluster:
+    server: %s
+    certificate-authority-data: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+    namespace: platform
+users:
+- name: test
+  user:
+    token: k8s-token
+`, srv.URL, base64.StdEncoding.EncodeToString(ca))), 0600); err != nil {
+		t.Fatalf("failed to write kubeconfig: %v", err)
+	}
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("KUBECONFIG", kubeconfig)
+	t.Setenv(EnvKubernetesSecretNamespace, "")
+	t.Setenv(EnvKubernetesSecretName, "")
+
+	store, err := newKubernetesSecretStoreFromEnv()
+	if err != nil {
+		t.Fatalf("newKubernetesSecretStoreFromEnv() error = %v", err)
+	}
+	if store.Namespace != "platform" || store.Name != DefaultKubernetesSecretName {
+		t.Fatalf("expected platform/%s, got %s/%s", DefaultKubernetesSecretName, store.Namespace, store.Name)
+	}
+
+	status, err := store.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.Registered {
+		t.Error("expected missing secret to be unregistered")
+	}
+	if _, err := store.Load(ctx); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("Load() of a missing secret error = %v, want ErrNotRegistered", err)
+	}
+
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	stored := fake.secrets["platform/secrets/octo-sts"]
+	if string(stored[EnvGitHubAppPrivateKey]) != testAppCredentials().PrivateKey {
+		t.Errorf("unexpected private key: %q", stored[EnvGitHubAppPrivateKey])
+	}
+
+	// Existing secrets are patched rather than replaced.
+	stored["UNRELATED"] = []byte("keep")
+	if err := store.DisableInstaller(ctx); err != nil {
+		t.Fatalf("DisableInstaller() error = %v", err)
+	}
+	if string(stored["UNRELATED"]) != "keep" {
+		t.Error("expected unrelated keys to be preserved")
+	}
+
+	status, err = store.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if !status.Registered || !status.InstallerDisabled || status.AppID != 1234 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cruxstack/octo-sts-distros/internal/consul"
+	"github.com/cruxstack/octo-sts-distros/internal/etcd"
+	"github.com/cruxstack/octo-sts-distros/internal/vault"
+)
+
+// Environment variables for the Consul and etcd KV stores.
+const (
+	EnvKVPrefix       = "KV_PREFIX"
+	EnvKVTransitKey   = "KV_TRANSIT_KEY"
+	EnvKVTransitMount = "KV_TRANSIT_MOUNT"
+)
+
+// Storage modes for the KV stores.
+const (
+	// StorageModeConsul saves each credential as a key in Consul KV.
+	StorageModeConsul = "consul"
+	// StorageModeEtcd saves each credential as a key in etcd.
+	StorageModeEtcd = "etcd"
+)
+
+// DefaultKVPrefix is the default key prefix for the KV stores.
+const DefaultKVPrefix = "octo-sts/"
+
+// DefaultTransitMount is the default Vault transit engine mount path.
+const DefaultTransitMount = "transit"
+
+// KVClient defines the interface for key-value operations shared by Consul
+// and etcd.
+type KVClient interface {
+	List(ctx context.Context, prefix string) (map[string][]byte, error)
+	Put(ctx context.Context, key string, value []byte) error
+	Delete(ctx context.Context, key string) error
+}
+
+// KVStore saves each credential as a key under Prefix, named after its
+// environment variable (e.g. octo-sts/GITHUB_APP_ID), in Consul KV or etcd.
+// With an encrypter, values are encrypted before they are written, so
+// operators with KV read access cannot see the credentials.
+type KVStore struct {
+	Prefix    string
+	client    KVClient
+	encrypter Encrypter
+}
+
+// KVStoreOption is a functional option for configuring KVStore.
+type KVStoreOption func(*KVStore)
+
+// WithKVEncrypter encrypts each value with encrypter before writing it.
+func WithKVEncrypter(encrypter Encrypter) KVStoreOption {
+	return func(s *KVStore) {
+		s.encrypter = encrypter
+	}
+}
+
+// NewKVStore creates a KV backend saving keys under prefix. A trailing slash
+// is added to a non-empty prefix that lacks one.
+func NewKVStore(client KVClient, prefix string, opts ...KVStoreOption) (*KVStore, error) {
+	if client == nil {
+		return nil, fmt.Errorf("kv client cannot be nil")
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	store := &KVStore{Prefix: prefix, client: client}
+	for _, opt := range opts {
+		opt(store)
+	}
+	return store, nil
+}
+
+// newKVStoreFromEnv creates a Consul or etcd KVStore from environment
+// variables. Setting KV_TRANSIT_KEY encrypts values with that Vault transit
+// key, using a client configured from the VAULT_* environment variables.
+func newKVStoreFromEnv(mode string) (*KVStore, error) {
+	prefix := GetEnvDefault(EnvKVPrefix, DefaultKVPrefix)
+
+	var client KVClient
+	switch mode {
+	case StorageModeConsul:
+		c, err := consul.NewClientFromEnv()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create consul client: %w", err)
+		}
+		// Consul keys never start with a slash.
+		prefix = strings.TrimPrefix(prefix, "/")
+		client = c
+	case StorageModeEtcd:
+		c, err := etcd.NewClientFromEnv()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create etcd client: %w", err)
+		}
+		client = c
+	default:
+		return nil, fmt.Errorf("unsupported kv storage mode: %s", mode)
+	}
+
+	var opts []KVStoreOption
+	if key := os.Getenv(EnvKVTransitKey); key != "" {
+		vc, err := vault.NewClientFromEnv()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create vault client: %w", err)
+		}
+		opts = append(opts, WithKVEncrypter(NewTransitEncrypter(vc, GetEnvDefault(EnvKVTransitMount, DefaultTransitMount), key)))
+	}
+
+	return NewKVStore(client, prefix, opts...)
+}
+
+// Save writes each credential to its key.
+func (s *KVStore) Save(ctx context.Context, creds *AppCredentials) error {
+	return s.merge(ctx, credentialValues(creds))
+}
+
+// Status returns the current registration state by reading the keys under the prefix.
+func (s *KVStore) Status(ctx context.Context) (*InstallerStatus, error) {
+	values, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return statusFromValues(values), nil
+}
+
+// Load reads the stored credentials from the keys under the prefix.
+func (s *KVStore) Load(ctx context.Context) (*AppCredentials, error) {
+	values, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return credentialsFromValues(values)
+}
+
+// DisableInstaller sets the GITHUB_APP_INSTALLER_ENABLED key to false.
+func (s *KVStore) DisableInstaller(ctx context.Context) error {
+	return s.merge(ctx, map[string]string{EnvGitHubAppInstallerEnabled: "false"})
+}
+
+// Delete removes the credential and installer flag keys. Other keys under
+// the prefix are left alone.
+func (s *KVStore) Delete(ctx context.Context) error {
+	return s.merge(ctx, nil, knownKeys...)
+}
+
+// Ping lists and decrypts the keys under the prefix.
+func (s *KVStore) Ping(ctx context.Context) error {
+	_, err := s.read(ctx)
+	return err
+}
+
+// Rotate writes creds and then removes the keys of credential values that
+// creds does not set.
+func (s *KVStore) Rotate(ctx context.Context, creds *AppCredentials) error {
+	values := credentialValues(creds)
+	return s.merge(ctx, values, staleKeys(values)...)
+}
+
+// merge writes values to their keys and then deletes the keys listed in
+// remove. Keys that already hold their value, and missing keys to remove,
+// are skipped.
+func (s *KVStore) merge(ctx context.Context, values map[string]string, remove ...string) error {
+	existing, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range changedValues(existing, values) {
+		data := []byte(value)
+		if s.encrypter != nil {
+			var err error
+			if data, err = s.encrypter.Encrypt(ctx, data); err != nil {
+				return fmt.Errorf("failed to encrypt %s: %w", key, err)
+			}
+		}
+		if err := s.client.Put(ctx, s.Prefix+key, data); err != nil {
+			return fmt.Errorf("failed to write key %s: %w", s.Prefix+key, err)
+		}
+	}
+	for _, key := range remove {
+		if _, ok := existing[key]; !ok {
+			continue
+		}
+		if err := s.client.Delete(ctx, s.Prefix+key); err != nil {
+			return fmt.Errorf("failed to delete key %s: %w", s.Prefix+key, err)
+		}
+	}
+	return nil
+}
+
+// read returns the values of the keys directly under the prefix, keyed by
+// their name relative to the prefix. Nested keys are ignored.
+func (s *KVStore) read(ctx context.Context) (map[string]string, error) {
+	entries, err := s.client.List(ctx, s.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys under %s: %w", s.Prefix, err)
+	}
+
+	values := make(map[string]string, len(entries))
+	for fullKey, data := range entries {
+		key := strings.TrimPrefix(fullKey, s.Prefix)
+		if key == "" || strings.Contains(key, "/") {
+			continue
+		}
+		if s.encrypter != nil {
+			if data, err = s.encrypter.Decrypt(ctx, data); err != nil {
+				return nil, fmt.Errorf("failed to decrypt %s: %w", fullKey, err)
+			}
+		}
+		values[key] = string(data)
+	}
+	return values, nil
+}
+
+// TransitClient defines the interface for Vault transit engine operations.
+type TransitClient interface {
+	TransitEncrypt(ctx context.Context, mount, key string, plaintext []byte) (string, error)
+	TransitDecrypt(ctx context.Context, mount, key, ciphertext string) ([]byte, error)
+}
+
+// TransitEncrypter is an Encrypter backed by a Vault transit engine key, so
+// the encryption key never leaves Vault. Ciphertexts keep Vault's
+// "vault:v<n>:..." format and can be rewrapped after a key rotation.
+type TransitEncrypter struct {
+	Mount  string
+	Key    string
+	client TransitClient
+}
+
+// NewTransitEncrypter creates a TransitEncrypter using the transit key at mount.
+func NewTransitEncrypter(client TransitClient, mount, key string) *TransitEncrypter {
+	return &TransitEncrypter{Mount: mount, Key: key, client: client}
+}
+
+// Encrypt encrypts plaintext with the transit key.
+func (e *TransitEncrypter) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	ciphertext, err := e.client.TransitEncrypt(ctx, e.Mount, e.Key, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(ciphertext), nil
+}
+
+// Decrypt decrypts a transit ciphertext.
+func (e *TransitEncrypter) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	return e.client.TransitDecrypt(ctx, e.Mount, e.Key, string(ciphertext))
+}
@@ -0,0 +1,139 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeKV is an in-memory KVClient for tests.
+type fakeKV struct {
+	values map[string][]byte
+}
+
+func (f *fakeKV) List(_ context.Context, prefix string) (map[string][]byte, error) {
+	values := make(map[string][]byte)
+	for key, value := range f.values {
+		if strings.HasPrefix(key, prefix) {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+func (f *fakeKV) Put(_ context.Context, key string, value []byte) error {
+	f.values[key] = value
+	return nil
+}
+
+func (f *fakeKV) Delete(_ context.Context, key string) error {
+	delete(f.values, key)
+	return nil
+}
+
+// fakeTransit "encrypts" by reversing the plaintext behind a vault-style prefix.
+type fakeTransit struct{}
+
+func (fakeTransit) TransitEncrypt(_ context.Context, _, key string, plaintext []byte) (string, error) {
+	return "vault:v1:" + key + ":" + string(reverse(plaintext)), nil
+}
+
+func (fakeTransit) TransitDecrypt(_ context.Context, _, key, ciphertext string) ([]byte, error) {
+	body, ok := strings.CutPrefix(ciphertext, "vault:v1:"+key+":")
+	if !ok {
+		return nil, errors.New("invalid ciphertext")
+	}
+	return reverse([]byte(body)), nil
+}
+
+// reverse returns b reversed, the "encryption" of the fake KMS and Transit.
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
+
+func TestKVStore(t *testing.T) {
+	ctx := context.Background()
+	kv := &fakeKV{values: map[string][]byte{"octo-sts/nested/OTHER": []byte("keep")}}
+
+	store, err := NewKVStore(kv, "octo-sts")
+	if err != nil {
+		t.Fatalf("NewKVStore() error = %v", err)
+	}
+	if store.Prefix != "octo-sts/" {
+		t.Errorf("Prefix = %q, want trailing slash", store.Prefix)
+	}
+
+	want := testAppCredentials()
+	if err := store.Save(ctx, want); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if got := string(kv.values["octo-sts/"+EnvGitHubAppID]); got != "1234" {
+		t.Errorf("app id key = %q, want 1234", got)
+	}
+
+	got, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got.PrivateKey != want.PrivateKey || got.CustomFields[EnvSTSDomain] != "sts.example.com" {
+		t.Errorf("unexpected credentials: %+v", got)
+	}
+
+	if err := store.DisableInstaller(ctx); err != nil {
+		t.Fatalf("DisableInstaller() error = %v", err)
+	}
+	status, err := store.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if !status.Registered || !status.InstallerDisabled {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	if err := store.Delete(ctx); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Load(ctx); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("expected ErrNotRegistered after delete, got %v", err)
+	}
+	if _, ok := kv.values["octo-sts/nested/OTHER"]; !ok {
+		t.Error("Delete() removed an unrelated key")
+	}
+}
+
+func TestKVStoreTransitEncryption(t *testing.T) {
+	ctx := context.Background()
+	kv := &fakeKV{values: make(map[string][]byte)}
+
+	store, err := NewKVStore(kv, "/octo-sts/", WithKVEncrypter(NewTransitEncrypter(fakeTransit{}, DefaultTransitMount, "octo-sts")))
+	if err != nil {
+		t.Fatalf("NewKVStore() error = %v", err)
+	}
+
+	want := testAppCredentials()
+	if err := store.Save(ctx, want); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	stored := kv.values["/octo-sts/"+EnvGitHubAppPrivateKey]
+	if !bytes.HasPrefix(stored, []byte("vault:v1:")) || bytes.Contains(stored, []byte(want.PrivateKey)) {
+		t.Errorf("private key stored unencrypted: %q", stored)
+	}
+
+	got, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got.PrivateKey != want.PrivateKey || got.ClientSecret != want.ClientSecret {
+		t.Errorf("unexpected credentials: %+v", got)
+	}
+}
@@ -0,0 +1,518 @@
+// Copyright 2025 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// envFileLockTimeout bounds how long a write waits for another process
+// writing the same .env file.
+const envFileLockTimeout = 10 * time.Second
+
+// LocalEnvFileStore saves credentials to a .env file. Writes replace the file
+// atomically and hold an advisory lock on a sibling lock file, so concurrent
+// saves from several processes don't lose each other's changes.
+type LocalEnvFileStore struct {
+	FilePath string
+}
+
+// NewLocalEnvFileStore creates a store that saves credentials to the given path.
+func NewLocalEnvFileStore(filepath string) *LocalEnvFileStore {
+	return &LocalEnvFileStore{FilePath: filepath}
+}
+
+// Save writes credentials to .env format, preserving existing content. A copy
+// of the previous file is archived first if it holds credentials.
+// It also sets the environment variables in the current process so they
+// are immediately available to the application.
+func (s *LocalEnvFileStore) Save(ctx context.Context, creds *AppCredentials) error {
+	return s.save(ctx, creds, nil)
+}
+
+// Rotate replaces the credentials in the .env file with creds in a single
+// write, removing credential keys that creds does not set.
+func (s *LocalEnvFileStore) Rotate(ctx context.Context, creds *AppCredentials) error {
+	return s.save(ctx, creds, staleKeys(credentialValues(creds)))
+}
+
+// Delete removes the credential keys and installer flag from the .env file,
+// leaving other configuration in place, and unsets them in the current process.
+// Archived versions are removed as well.
+func (s *LocalEnvFileStore) Delete(ctx context.Context) error {
+	if _, err := os.Stat(filepath.Dir(s.FilePath)); os.IsNotExist(err) {
+		return nil
+	}
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := os.RemoveAll(s.historyDir()); err != nil {
+		return fmt.Errorf("failed to remove credential history: %w", err)
+	}
+
+	values, originalLines, err := parseEnvFile(s.FilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read existing .env file: %w", err)
+	}
+
+	for _, key := range knownKeys {
+		delete(values, key)
+		os.Unsetenv(key)
+	}
+
+	if err := writeEnvFile(s.FilePath, values, removeEnvLines(originalLines, knownKeys)); err != nil {
+		return fmt.Errorf("failed to write .env file: %w", err)
+	}
+	return nil
+}
+
+// Ping checks that the .env file can be read and its directory written.
+func (s *LocalEnvFileStore) Ping(ctx context.Context) error {
+	if _, err := os.ReadFile(s.FilePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", s.FilePath, err)
+	}
+	return checkWritableDir(filepath.Dir(s.FilePath))
+}
+
+// Watch reports changes to the .env file made by other processes, such as
+// a config management tool replacing it.
+func (s *LocalEnvFileStore) Watch(ctx context.Context) (<-chan struct{}, error) {
+	return watchFile(ctx, s.FilePath, storeSnapshot(s))
+}
+
+// History returns the current credentials followed by the archived copies of
+// the .env file. Archived versions are identified by the time they were replaced.
+func (s *LocalEnvFileStore) History(ctx context.Context) ([]CredentialVersion, error) {
+	var versions []CredentialVersion
+
+	current, err := s.Load(ctx)
+	if err != nil && !errors.Is(err, ErrNotRegistered) {
+		return nil, err
+	}
+	if current != nil {
+		version := CredentialVersion{ID: CurrentVersionID, Current: true, Credentials: current}
+		if info, err := os.Stat(s.FilePath); err == nil {
+			version.CreatedAt = info.ModTime()
+		}
+		versions = append(versions, version)
+	}
+
+	names, times, err := archiveEntries(s.historyDir())
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range names {
+		creds, err := NewLocalEnvFileStore(filepath.Join(s.historyDir(), name)).Load(ctx)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, CredentialVersion{ID: name, CreatedAt: times[i], Credentials: creds})
+	}
+
+	return compactHistory(versions), nil
+}
+
+// Rollback restores the archived version with the given ID. The credentials
+// being replaced are archived in turn.
+func (s *LocalEnvFileStore) Rollback(ctx context.Context, id string) error {
+	return rollback(ctx, s, id)
+}
+
+// lock takes the advisory lock guarding writes to the .env file. The lock is
+// held on a separate file, since writes replace the .env file itself.
+func (s *LocalEnvFileStore) lock(ctx context.Context) (func(), error) {
+	ctx, cancel := context.WithTimeout(ctx, envFileLockTimeout)
+	defer cancel()
+	return lockFile(ctx, filepath.Join(filepath.Dir(s.FilePath), "."+filepath.Base(s.FilePath)+".lock"))
+}
+
+// historyDir returns the hidden directory next to the .env file that holds
+// archived copies of it.
+func (s *LocalEnvFileStore) historyDir() string {
+	return filepath.Join(filepath.Dir(s.FilePath), "."+filepath.Base(s.FilePath)+".history")
+}
+
+// archive copies the .env file into the history directory and prunes old
+// copies. It does nothing unless the file holds complete credentials.
+func (s *LocalEnvFileStore) archive() error {
+	values, _, err := parseEnvFile(s.FilePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !hasAllValues(values, requiredKeys...) {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.FilePath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.historyDir(), 0700); err != nil {
+		return err
+	}
+	name := time.Now().UTC().Format(historyTimeFormat)
+	if err := writeFileAtomic(filepath.Join(s.historyDir(), name), data, 0600); err != nil {
+		return err
+	}
+	return pruneArchives(s.historyDir())
+}
+
+// save writes creds to the .env file after dropping the keys listed in
+// remove. The file is left untouched if it already holds the result.
+func (s *LocalEnvFileStore) save(ctx context.Context, creds *AppCredentials, remove []string) error {
+	dir := filepath.Dir(s.FilePath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	existingValues, originalLines, err := parseEnvFile(s.FilePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read existing .env file: %w", err)
+	}
+	if existingValues == nil {
+		existingValues = make(map[string]string)
+	}
+	previousValues := maps.Clone(existingValues)
+
+	for _, key := range remove {
+		delete(existingValues, key)
+		os.Unsetenv(key)
+	}
+	originalLines = removeEnvLines(originalLines, remove)
+
+	for key, value := range creds.CustomFields {
+		if value != "" {
+			existingValues[key] = value
+		}
+	}
+
+	singleLinePEM := strings.ReplaceAll(creds.PrivateKey, "\n", "\\n")
+
+	existingValues[EnvGitHubAppID] = fmt.Sprintf("%d", creds.AppID)
+	existingValues[EnvGitHubWebhookSecret] = creds.WebhookSecret
+	existingValues[EnvGitHubClientID] = creds.ClientID
+	existingValues[EnvGitHubClientSecret] = creds.ClientSecret
+	existingValues[EnvGitHubAppPrivateKey] = singleLinePEM
+	if creds.AppSlug != "" {
+		existingValues[EnvGitHubAppSlug] = creds.AppSlug
+	}
+	if creds.HTMLURL != "" {
+		existingValues[EnvGitHubAppHTMLURL] = creds.HTMLURL
+	}
+
+	if !maps.Equal(previousValues, existingValues) {
+		if err := s.archive(); err != nil {
+			return fmt.Errorf("failed to archive previous credentials: %w", err)
+		}
+		if err := writeEnvFile(s.FilePath, existingValues, originalLines); err != nil {
+			return fmt.Errorf("failed to write .env file: %w", err)
+		}
+	}
+
+	// Set environment variables in the current process so they are
+	// immediately available for configuration reload.
+	os.Setenv(EnvGitHubAppID, fmt.Sprintf("%d", creds.AppID))
+	os.Setenv(EnvGitHubWebhookSecret, creds.WebhookSecret)
+	os.Setenv(EnvGitHubClientID, creds.ClientID)
+	os.Setenv(EnvGitHubClientSecret, creds.ClientSecret)
+	os.Setenv(EnvGitHubAppPrivateKey, singleLinePEM)
+	if creds.AppSlug != "" {
+		os.Setenv(EnvGitHubAppSlug, creds.AppSlug)
+	}
+	if creds.HTMLURL != "" {
+		os.Setenv(EnvGitHubAppHTMLURL, creds.HTMLURL)
+	}
+	for key, value := range creds.CustomFields {
+		if value != "" {
+			os.Setenv(key, value)
+		}
+	}
+
+	return nil
+}
+
+func parseEnvFile(path string) (map[string]string, []string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	var lines []string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		lines = append(lines, line)
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		idx := strings.Index(line, "=")
+		if idx == -1 {
+			continue
+		}
+
+		key := strings.TrimSpace(line[:idx])
+		value := strings.TrimSpace(line[idx+1:])
+
+		if len(value) >= 2 {
+			if (strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"")) ||
+				(strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'")) {
+				value = value[1 : len(value)-1]
+			}
+		}
+
+		values[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return values, lines, nil
+}
+
+// removeEnvLines returns lines without the assignments to the given keys.
+func removeEnvLines(lines []string, keys []string) []string {
+	if len(keys) == 0 {
+		return lines
+	}
+
+	remove := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		remove[key] = true
+	}
+
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if idx := strings.Index(line, "="); idx != -1 && !strings.HasPrefix(trimmed, "#") &&
+			remove[strings.TrimSpace(line[:idx])] {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return kept
+}
+
+// writeEnvFile writes the file via a temporary file and rename so readers
+// never observe a partially written file.
+func writeEnvFile(path string, values map[string]string, originalLines []string) error {
+	var outputLines []string
+	writtenKeys := make(map[string]bool)
+
+	for _, line := range originalLines {
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			outputLines = append(outputLines, line)
+			continue
+		}
+
+		idx := strings.Index(line, "=")
+		if idx == -1 {
+			outputLines = append(outputLines, line)
+			continue
+		}
+
+		key := strings.TrimSpace(line[:idx])
+
+		if newValue, ok := values[key]; ok {
+			outputLines = append(outputLines, formatEnvLine(key, newValue))
+			writtenKeys[key] = true
+		} else {
+			outputLines = append(outputLines, line)
+		}
+	}
+
+	for key, value := range values {
+		if !writtenKeys[key] {
+			outputLines = append(outputLines, formatEnvLine(key, value))
+		}
+	}
+
+	content := strings.Join(outputLines, "\n")
+	if len(outputLines) > 0 {
+		content += "\n"
+	}
+
+	return writeFileAtomic(path, []byte(content), 0600)
+}
+
+// renameFile is os.Rename, replaced in tests.
+var renameFile = os.Rename
+
+// writeFileAtomic writes data to a temporary file in the same directory,
+// flushes it to disk, and renames it over path. A file that can't be
+// replaced, such as one bind-mounted on its own like the .env file of
+// docker-compose.yml, is rewritten in place instead.
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	err = renameFile(tmp.Name(), path)
+	if errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.EXDEV) {
+		return writeFileInPlace(path, data, mode)
+	}
+	return err
+}
+
+// writeFileInPlace truncates path, writes data, and flushes it to disk.
+// Unlike writeFileAtomic, a reader may see the file partly written.
+func writeFileInPlace(path string, data []byte, mode os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func formatEnvLine(key, value string) string {
+	needsQuotes := strings.ContainsAny(value, " \t\n\r\"'\\#") || strings.Contains(value, "\\n")
+
+	if needsQuotes {
+		escaped := strings.ReplaceAll(value, "\"", "\\\"")
+		return fmt.Sprintf("%s=\"%s\"", key, escaped)
+	}
+
+	return fmt.Sprintf("%s=%s", key, value)
+}
+
+// Status returns the current registration state by checking the .env file.
+func (s *LocalEnvFileStore) Status(ctx context.Context) (*InstallerStatus, error) {
+	values, _, err := parseEnvFile(s.FilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &InstallerStatus{}, nil
+		}
+		return nil, err
+	}
+
+	status := &InstallerStatus{
+		AppSlug: values[EnvGitHubAppSlug],
+		HTMLURL: values[EnvGitHubAppHTMLURL],
+	}
+
+	if idStr := strings.TrimSpace(values[EnvGitHubAppID]); idStr != "" {
+		if id, err := strconv.ParseInt(idStr, 10, 64); err == nil {
+			status.AppID = id
+		}
+	}
+
+	status.Registered = hasAllValues(values,
+		EnvGitHubAppID,
+		EnvGitHubWebhookSecret,
+		EnvGitHubClientID,
+		EnvGitHubClientSecret,
+		EnvGitHubAppPrivateKey,
+	)
+
+	status.InstallerDisabled = isFalseString(values[EnvGitHubAppInstallerEnabled])
+
+	return status, nil
+}
+
+// Load reads credentials from the .env file. The private key's escaped
+// newlines are restored.
+func (s *LocalEnvFileStore) Load(ctx context.Context) (*AppCredentials, error) {
+	values, _, err := parseEnvFile(s.FilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotRegistered
+		}
+		return nil, err
+	}
+
+	creds, err := credentialsFromValues(values)
+	if err != nil {
+		return nil, err
+	}
+	creds.PrivateKey = strings.ReplaceAll(creds.PrivateKey, "\\n", "\n")
+	return creds, nil
+}
+
+// DisableInstaller sets GITHUB_APP_INSTALLER_ENABLED=false in the .env file.
+func (s *LocalEnvFileStore) DisableInstaller(ctx context.Context) error {
+	dir := filepath.Dir(s.FilePath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	values, originalLines, err := parseEnvFile(s.FilePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if values == nil {
+		values = make(map[string]string)
+	}
+
+	values[EnvGitHubAppInstallerEnabled] = "false"
+
+	if err := writeEnvFile(s.FilePath, values, originalLines); err != nil {
+		return fmt.Errorf("failed to persist installer flag: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,346 @@
+// Copyright 2025 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalFileStore saves credentials as individual files in a directory.
+type LocalFileStore struct {
+	Dir string
+}
+
+// NewLocalFileStore creates a store that saves credentials as files in dir.
+func NewLocalFileStore(dir string) *LocalFileStore {
+	return &LocalFileStore{Dir: dir}
+}
+
+// localHistoryDir is the subdirectory holding archived credential versions.
+const localHistoryDir = ".history"
+
+// Save writes credentials to individual files in the store directory. The
+// previous credentials, if any, are archived first. Nothing is written if the
+// files already hold the credentials, and files already holding their value
+// are left untouched.
+func (s *LocalFileStore) Save(ctx context.Context, creds *AppCredentials) error {
+	if existing, err := s.Load(ctx); err == nil && len(changedValues(credentialValues(existing), credentialValues(creds))) == 0 {
+		return nil
+	}
+	if err := s.archive(ctx); err != nil {
+		return fmt.Errorf("failed to archive previous credentials: %w", err)
+	}
+	return s.write(creds)
+}
+
+// write writes credentials to individual files in the store directory,
+// skipping files that already hold their value.
+func (s *LocalFileStore) write(creds *AppCredentials) error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", s.Dir, err)
+	}
+
+	files := map[string]struct {
+		content string
+		mode    os.FileMode
+	}{
+		"app-id":          {content: fmt.Sprintf("%d", creds.AppID), mode: 0644},
+		"private-key.pem": {content: creds.PrivateKey, mode: 0600},
+		"webhook-secret":  {content: creds.WebhookSecret, mode: 0600},
+		"client-id":       {content: creds.ClientID, mode: 0644},
+		"client-secret":   {content: creds.ClientSecret, mode: 0600},
+	}
+
+	if creds.AppSlug != "" {
+		files["app-slug"] = struct {
+			content string
+			mode    os.FileMode
+		}{content: creds.AppSlug, mode: 0644}
+	}
+	if creds.HTMLURL != "" {
+		files["app-html-url"] = struct {
+			content string
+			mode    os.FileMode
+		}{content: creds.HTMLURL, mode: 0644}
+	}
+
+	for key, value := range creds.CustomFields {
+		if value != "" {
+			files[localFileName(key)] = struct {
+				content string
+				mode    os.FileMode
+			}{content: value, mode: 0644}
+		}
+	}
+
+	for name, file := range files {
+		path := filepath.Join(s.Dir, name)
+		if current, err := os.ReadFile(path); err == nil && string(current) == file.content {
+			continue
+		}
+		if err := writeFileAtomic(path, []byte(file.content), file.mode); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// Status returns the current registration state by checking required files.
+func (s *LocalFileStore) Status(ctx context.Context) (*InstallerStatus, error) {
+	status := &InstallerStatus{}
+
+	appIDBytes, err := os.ReadFile(filepath.Join(s.Dir, "app-id"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return status, nil
+		}
+		return nil, err
+	}
+
+	if id, err := strconv.ParseInt(strings.TrimSpace(string(appIDBytes)), 10, 64); err == nil {
+		status.AppID = id
+	}
+
+	required := []string{"client-id", "client-secret", "webhook-secret", "private-key.pem"}
+	for _, name := range required {
+		if _, err := os.Stat(filepath.Join(s.Dir, name)); err != nil {
+			if os.IsNotExist(err) {
+				return status, nil
+			}
+			return nil, err
+		}
+	}
+	status.Registered = true
+
+	if slug, err := readTrimmedFile(filepath.Join(s.Dir, "app-slug")); err == nil {
+		status.AppSlug = slug
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if html, err := readTrimmedFile(filepath.Join(s.Dir, "app-html-url")); err == nil {
+		status.HTMLURL = html
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if _, err := os.Stat(filepath.Join(s.Dir, "installer-disabled")); err == nil {
+		status.InstallerDisabled = true
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// Load reads credentials from the files in the store directory. Files other
+// than the known credential files are returned as custom fields, with their
+// names mapped back to environment variable form (sts-domain -> STS_DOMAIN).
+func (s *LocalFileStore) Load(ctx context.Context) (*AppCredentials, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotRegistered
+		}
+		return nil, err
+	}
+
+	files := make(map[string]string, len(localFileNames))
+	for key, name := range localFileNames {
+		files[name] = key
+	}
+
+	values := make(map[string]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if !isCredentialFile(entry) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.Dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok := files[name]
+		if !ok {
+			key = strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		}
+		if key == EnvGitHubAppPrivateKey {
+			values[key] = string(data)
+		} else {
+			values[key] = strings.TrimSpace(string(data))
+		}
+	}
+
+	return credentialsFromValues(values)
+}
+
+// DisableInstaller creates a marker file to disable the installer.
+func (s *LocalFileStore) DisableInstaller(ctx context.Context) error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", s.Dir, err)
+	}
+
+	path := filepath.Join(s.Dir, "installer-disabled")
+	if err := os.WriteFile(path, []byte("disabled"), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Rotate writes creds and then removes the files of credential values that
+// creds does not set. Each file is replaced atomically.
+func (s *LocalFileStore) Rotate(ctx context.Context, creds *AppCredentials) error {
+	if err := s.Save(ctx, creds); err != nil {
+		return err
+	}
+
+	for _, key := range staleKeys(credentialValues(creds)) {
+		path := filepath.Join(s.Dir, localFileName(key))
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Delete removes the credential files, custom field files, installer marker,
+// and archived versions from the store directory.
+func (s *LocalFileStore) Delete(ctx context.Context) error {
+	if err := os.RemoveAll(filepath.Join(s.Dir, localHistoryDir)); err != nil {
+		return fmt.Errorf("failed to remove credential history: %w", err)
+	}
+
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		if !isCredentialFile(entry) && entry.Name() != "installer-disabled" {
+			continue
+		}
+		path := filepath.Join(s.Dir, entry.Name())
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Ping checks that the store directory can be written.
+func (s *LocalFileStore) Ping(ctx context.Context) error {
+	return checkWritableDir(s.Dir)
+}
+
+// Watch reports changes to the credential files made by other processes,
+// such as a mounted Kubernetes Secret being updated.
+func (s *LocalFileStore) Watch(ctx context.Context) (<-chan struct{}, error) {
+	return watchDir(ctx, s.Dir, func(string) bool { return true }, storeSnapshot(s))
+}
+
+// History returns the current credentials followed by the archived versions.
+// Archived versions are identified by the time they were replaced.
+func (s *LocalFileStore) History(ctx context.Context) ([]CredentialVersion, error) {
+	var versions []CredentialVersion
+
+	current, err := s.Load(ctx)
+	if err != nil && !errors.Is(err, ErrNotRegistered) {
+		return nil, err
+	}
+	if current != nil {
+		version := CredentialVersion{ID: CurrentVersionID, Current: true, Credentials: current}
+		if info, err := os.Stat(filepath.Join(s.Dir, localFileName(EnvGitHubAppPrivateKey))); err == nil {
+			version.CreatedAt = info.ModTime()
+		}
+		versions = append(versions, version)
+	}
+
+	historyDir := filepath.Join(s.Dir, localHistoryDir)
+	names, times, err := archiveEntries(historyDir)
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range names {
+		creds, err := NewLocalFileStore(filepath.Join(historyDir, name)).Load(ctx)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, CredentialVersion{ID: name, CreatedAt: times[i], Credentials: creds})
+	}
+
+	return compactHistory(versions), nil
+}
+
+// Rollback restores the archived version with the given ID. The credentials
+// being replaced are archived in turn.
+func (s *LocalFileStore) Rollback(ctx context.Context, id string) error {
+	return rollback(ctx, s, id)
+}
+
+// archive copies the current credentials into the history directory and
+// prunes old versions. It does nothing if no credentials are stored.
+func (s *LocalFileStore) archive(ctx context.Context) error {
+	current, err := s.Load(ctx)
+	if errors.Is(err, ErrNotRegistered) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	historyDir := filepath.Join(s.Dir, localHistoryDir)
+	archived := NewLocalFileStore(filepath.Join(historyDir, time.Now().UTC().Format(historyTimeFormat)))
+	if err := archived.write(current); err != nil {
+		return err
+	}
+	return pruneArchives(historyDir)
+}
+
+// localFileNames maps credential keys to their file names. Other keys are
+// stored in lowercase-dashed files (STS_DOMAIN -> sts-domain).
+var localFileNames = map[string]string{
+	EnvGitHubAppID:         "app-id",
+	EnvGitHubAppPrivateKey: "private-key.pem",
+	EnvGitHubWebhookSecret: "webhook-secret",
+	EnvGitHubClientID:      "client-id",
+	EnvGitHubClientSecret:  "client-secret",
+	EnvGitHubAppSlug:       "app-slug",
+	EnvGitHubAppHTMLURL:    "app-html-url",
+}
+
+// localFileName returns the file name used for key.
+func localFileName(key string) string {
+	if name, ok := localFileNames[key]; ok {
+		return name
+	}
+	return strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+}
+
+// isCredentialFile reports whether a directory entry holds a credential value.
+func isCredentialFile(entry os.DirEntry) bool {
+	name := entry.Name()
+	return !entry.IsDir() && name != "installer-disabled" && !strings.HasPrefix(name, ".")
+}
+
+func readTrimmedFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Keys of the metadata saved alongside the credentials.
+const (
+	EnvGitHubAppCreatedAt        = "GITHUB_APP_CREATED_AT"
+	EnvGitHubAppCreatedBy        = "GITHUB_APP_CREATED_BY"
+	EnvGitHubAppInstallerVersion = "GITHUB_APP_INSTALLER_VERSION"
+	EnvGitHubAppHost             = "GITHUB_APP_HOST"
+)
+
+// metadataKeys are the keys of Metadata.
+var metadataKeys = []string{
+	EnvGitHubAppCreatedAt,
+	EnvGitHubAppCreatedBy,
+	EnvGitHubAppInstallerVersion,
+	EnvGitHubAppHost,
+}
+
+// Metadata records which setup produced the stored credentials.
+type Metadata struct {
+	// CreatedAt is when the credentials were created.
+	CreatedAt time.Time
+	// CreatedBy identifies who ran the setup: the authenticated principal
+	// if a proxy reported one, else the client IP.
+	CreatedBy string
+	// InstallerVersion is the version of the installer that ran the setup.
+	InstallerVersion string
+	// GitHubHost is the host of the GitHub instance the app belongs to,
+	// e.g. github.com.
+	GitHubHost string
+}
+
+// IsZero reports whether no metadata is set.
+func (m Metadata) IsZero() bool {
+	return m == Metadata{}
+}
+
+// SetMetadata records m in the CustomFields of creds, so it is saved with
+// them. Unset fields are left out.
+func SetMetadata(creds *AppCredentials, m Metadata) {
+	if creds.CustomFields == nil {
+		creds.CustomFields = make(map[string]string)
+	}
+	if !m.CreatedAt.IsZero() {
+		creds.CustomFields[EnvGitHubAppCreatedAt] = m.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	for key, value := range map[string]string{
+		EnvGitHubAppCreatedBy:        m.CreatedBy,
+		EnvGitHubAppInstallerVersion: m.InstallerVersion,
+		EnvGitHubAppHost:             m.GitHubHost,
+	} {
+		if value != "" {
+			creds.CustomFields[key] = value
+		}
+	}
+}
+
+// metadataFromValues reads the metadata from stored values. An unparsable
+// creation time is ignored.
+func metadataFromValues(values map[string]string) Metadata {
+	m := Metadata{
+		CreatedBy:        values[EnvGitHubAppCreatedBy],
+		InstallerVersion: values[EnvGitHubAppInstallerVersion],
+		GitHubHost:       values[EnvGitHubAppHost],
+	}
+	if t, err := time.Parse(time.RFC3339, values[EnvGitHubAppCreatedAt]); err == nil {
+		m.CreatedAt = t
+	}
+	return m
+}
+
+// Status is the installer status together with the metadata of the stored
+// credentials.
+type Status struct {
+	InstallerStatus
+	Metadata Metadata
+}
+
+// ReadStatus returns the installer status of store along with the metadata
+// saved with its credentials. The metadata is empty if the store holds no
+// credentials or they were saved without it.
+func ReadStatus(ctx context.Context, store Store) (*Status, error) {
+	status, err := store.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &Status{InstallerStatus: *status}
+
+	creds, err := store.Load(ctx)
+	switch {
+	case err == nil:
+		result.Metadata = metadataFromValues(creds.CustomFields)
+	case !errors.Is(err, ErrNotRegistered):
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadStatusMetadata(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalEnvFileStore(filepath.Join(t.TempDir(), ".env"))
+
+	status, err := ReadStatus(ctx, store)
+	if err != nil {
+		t.Fatalf("ReadStatus() error = %v", err)
+	}
+	if status.Registered || !status.Metadata.IsZero() {
+		t.Errorf("expected an unregistered status without metadata, got %+v", status)
+	}
+
+	want := Metadata{
+		CreatedAt:        time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		CreatedBy:        "octocat@example.com",
+		InstallerVersion: "v1.2.3",
+		GitHubHost:       "github.com",
+	}
+	creds := testAppCredentials()
+	SetMetadata(creds, want)
+	if err := store.Save(ctx, creds); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	status, err = ReadStatus(ctx, store)
+	if err != nil {
+		t.Fatalf("ReadStatus() error = %v", err)
+	}
+	if !status.Registered || status.AppID != creds.AppID {
+		t.Errorf("unexpected status: %+v", status.InstallerStatus)
+	}
+	if !status.Metadata.CreatedAt.Equal(want.CreatedAt) || status.Metadata.CreatedBy != want.CreatedBy ||
+		status.Metadata.InstallerVersion != want.InstallerVersion || status.Metadata.GitHubHost != want.GitHubHost {
+		t.Errorf("metadata = %+v, want %+v", status.Metadata, want)
+	}
+
+	// Rotating in credentials from elsewhere drops the old metadata.
+	if err := store.Rotate(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if status, _ := ReadStatus(ctx, store); !status.Metadata.IsZero() {
+		t.Errorf("expected metadata to be removed by Rotate, got %+v", status.Metadata)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// ErrDestinationRegistered is returned by Migrate when the destination
+// already holds credentials and overwriting was not requested.
+var ErrDestinationRegistered = errors.New("destination store already holds credentials")
+
+// MigrateOptions configures Migrate.
+type MigrateOptions struct {
+	// Overwrite replaces credentials already in the destination. Values the
+	// source does not have are removed from the destination.
+	Overwrite bool
+
+	// DryRun checks both stores without writing to the destination.
+	DryRun bool
+
+	// AllValues also copies values other than the app credentials and
+	// STS_DOMAIN. Stores such as the .env file return every variable they
+	// hold, so by default those are left behind.
+	AllValues bool
+}
+
+// MigrateResult describes the credentials copied by Migrate.
+type MigrateResult struct {
+	AppID             int64
+	Keys              []string
+	InstallerDisabled bool
+}
+
+// Migrate copies the credentials and the installer flag from one store to
+// another, e.g. from an .env file used for a proof of concept into AWS SSM.
+// The destination is read back afterwards to verify the copy.
+func Migrate(ctx context.Context, from, to Store, opts MigrateOptions) (*MigrateResult, error) {
+	creds, err := from.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load source credentials: %w", err)
+	}
+	if !opts.AllValues {
+		maps.DeleteFunc(creds.CustomFields, func(key, _ string) bool {
+			return !slices.Contains(knownKeys, key)
+		})
+	}
+	status, err := from.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source status: %w", err)
+	}
+
+	values := credentialValues(creds)
+	result := &MigrateResult{
+		AppID:             creds.AppID,
+		Keys:              slices.Sorted(maps.Keys(values)),
+		InstallerDisabled: status.InstallerDisabled,
+	}
+
+	if err := to.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("destination store is unavailable: %w", err)
+	}
+	existing, err := to.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read destination status: %w", err)
+	}
+	if existing.Registered && !opts.Overwrite {
+		return nil, fmt.Errorf("%w (app %d)", ErrDestinationRegistered, existing.AppID)
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if opts.Overwrite {
+		err = to.Rotate(ctx, creds)
+	} else {
+		err = to.Save(ctx, creds)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save credentials to destination: %w", err)
+	}
+	if status.InstallerDisabled {
+		if err := to.DisableInstaller(ctx); err != nil {
+			return nil, fmt.Errorf("failed to disable installer in destination: %w", err)
+		}
+	}
+
+	copied, err := to.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify destination credentials: %w", err)
+	}
+	copiedValues := credentialValues(copied)
+	for key, value := range values {
+		if copiedValues[key] != value {
+			return nil, fmt.Errorf("destination value %s does not match the source after migration", key)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	from := NewLocalEnvFileStore(filepath.Join(dir, ".env"))
+	if err := os.WriteFile(from.FilePath, []byte("PORT=8080\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := from.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := from.DisableInstaller(ctx); err != nil {
+		t.Fatalf("DisableInstaller() error = %v", err)
+	}
+
+	to := NewLocalFileStore(filepath.Join(dir, "files"))
+
+	result, err := Migrate(ctx, from, to, MigrateOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Migrate(dry run) error = %v", err)
+	}
+	if result.AppID != 1234 || !result.InstallerDisabled {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if _, err := to.Load(ctx); !errors.Is(err, ErrNotRegistered) {
+		t.Fatalf("dry run wrote credentials: %v", err)
+	}
+
+	if _, err := Migrate(ctx, from, to, MigrateOptions{}); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	got, err := to.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got.PrivateKey != testAppCredentials().PrivateKey || got.CustomFields[EnvSTSDomain] != "sts.example.com" {
+		t.Errorf("unexpected migrated credentials: %+v", got)
+	}
+	if _, ok := got.CustomFields["PORT"]; ok {
+		t.Error("unrelated .env values should not be migrated by default")
+	}
+	status, err := to.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if !status.InstallerDisabled {
+		t.Error("installer flag was not migrated")
+	}
+
+	if _, err := Migrate(ctx, from, to, MigrateOptions{}); !errors.Is(err, ErrDestinationRegistered) {
+		t.Errorf("expected ErrDestinationRegistered, got %v", err)
+	}
+	if _, err := Migrate(ctx, from, to, MigrateOptions{Overwrite: true}); err != nil {
+		t.Errorf("Migrate(overwrite) error = %v", err)
+	}
+}
+
+func TestMigrateUnregisteredSource(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	_, err := Migrate(ctx, NewLocalFileStore(filepath.Join(dir, "a")), NewLocalFileStore(filepath.Join(dir, "b")), MigrateOptions{})
+	if !errors.Is(err, ErrNotRegistered) || !strings.Contains(err.Error(), "source") {
+		t.Errorf("expected ErrNotRegistered for the source, got %v", err)
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// EnvStorageMultiModes lists the storage modes used by the multi store, in order.
+const EnvStorageMultiModes = "STORAGE_MULTI_MODES"
+
+// StorageModeMulti saves credentials to several backends at once.
+const StorageModeMulti = "multi"
+
+// StoreBackend is a named child of a MultiStore.
+type StoreBackend struct {
+	Name  string
+	Store Store
+}
+
+// BackendError is the error returned by a single backend of a MultiStore.
+type BackendError struct {
+	Backend string
+	Err     error
+}
+
+func (e *BackendError) Error() string {
+	return e.Backend + ": " + e.Err.Error()
+}
+
+func (e *BackendError) Unwrap() error {
+	return e.Err
+}
+
+// MultiStoreError reports the backends of a MultiStore that failed.
+type MultiStoreError struct {
+	Op     string
+	Errors []*BackendError
+}
+
+func (e *MultiStoreError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%s failed for %d backend(s): %s", e.Op, len(e.Errors), strings.Join(msgs, "; "))
+}
+
+func (e *MultiStoreError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// MultiStore saves credentials to an ordered list of backends, e.g. AWS SSM
+// for the deployment plus an .env file for local development. Writes are
+// all-or-nothing: if a backend fails, the backends already written are
+// restored to their previous credentials. Reads use the first backend that
+// has credentials.
+type MultiStore struct {
+	backends []StoreBackend
+}
+
+// NewMultiStore creates a MultiStore from the given backends, in order.
+func NewMultiStore(backends ...StoreBackend) (*MultiStore, error) {
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("multi store requires at least one backend")
+	}
+	return &MultiStore{backends: backends}, nil
+}
+
+// newMultiStoreFromEnv creates a MultiStore from the modes in STORAGE_MULTI_MODES.
+// Each backend is configured by its usual environment variables.
+func newMultiStoreFromEnv() (*MultiStore, error) {
+	raw := os.Getenv(EnvStorageMultiModes)
+	if raw == "" {
+		return nil, fmt.Errorf("%s is required when using %s storage mode", EnvStorageMultiModes, StorageModeMulti)
+	}
+
+	var backends []StoreBackend
+	seen := make(map[string]bool)
+	for _, mode := range strings.Split(raw, ",") {
+		mode = strings.TrimSpace(mode)
+		if mode == "" {
+			continue
+		}
+		if mode == StorageModeMulti {
+			return nil, fmt.Errorf("%s cannot include %s", EnvStorageMultiModes, StorageModeMulti)
+		}
+		if seen[mode] {
+			return nil, fmt.Errorf("%s lists %s more than once", EnvStorageMultiModes, mode)
+		}
+		seen[mode] = true
+
+		store, err := newStoreFromEnv(mode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s backend: %w", mode, err)
+		}
+		backends = append(backends, StoreBackend{Name: mode, Store: store})
+	}
+
+	return NewMultiStore(backends...)
+}
+
+// Backends returns the configured backends, in order.
+func (s *MultiStore) Backends() []StoreBackend {
+	return s.backends
+}
+
+// Save writes credentials to every backend, restoring the previous
+// credentials on the backends already written if any backend fails.
+func (s *MultiStore) Save(ctx context.Context, creds *AppCredentials) error {
+	return s.transact(ctx, "save", func(store Store) error {
+		return store.Save(ctx, creds)
+	})
+}
+
+// Rotate rotates the credentials on every backend, restoring the previous
+// credentials on the backends already rotated if any backend fails.
+func (s *MultiStore) Rotate(ctx context.Context, creds *AppCredentials) error {
+	return s.transact(ctx, "rotate", func(store Store) error {
+		return store.Rotate(ctx, creds)
+	})
+}
+
+// Delete removes the credentials from every backend, restoring them on the
+// backends already cleared if any backend fails.
+func (s *MultiStore) Delete(ctx context.Context) error {
+	return s.transact(ctx, "delete", func(store Store) error {
+		return store.Delete(ctx)
+	})
+}
+
+// DisableInstaller disables the installer on every backend. The installer
+// flag cannot be re-enabled through the Store interface, so failures are
+// reported without undoing the backends that succeeded.
+func (s *MultiStore) DisableInstaller(ctx context.Context) error {
+	var errs []*BackendError
+	for _, backend := range s.backends {
+		if err := backend.Store.DisableInstaller(ctx); err != nil {
+			errs = append(errs, &BackendError{Backend: backend.Name, Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return &MultiStoreError{Op: "disable installer", Errors: errs}
+	}
+	return nil
+}
+
+// Ping checks every backend.
+func (s *MultiStore) Ping(ctx context.Context) error {
+	var errs []*BackendError
+	for _, backend := range s.backends {
+		if err := backend.Store.Ping(ctx); err != nil {
+			errs = append(errs, &BackendError{Backend: backend.Name, Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return &MultiStoreError{Op: "ping", Errors: errs}
+	}
+	return nil
+}
+
+// Watch reports changes in any backend that supports watching. Returns
+// ErrWatchNotSupported if none does.
+func (s *MultiStore) Watch(ctx context.Context) (<-chan struct{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	var watches []<-chan struct{}
+	for _, backend := range s.backends {
+		ch, err := Watch(ctx, backend.Store)
+		if errors.Is(err, ErrWatchNotSupported) {
+			continue
+		}
+		if err != nil {
+			cancel()
+			return nil, &MultiStoreError{Op: "watch", Errors: []*BackendError{{Backend: backend.Name, Err: err}}}
+		}
+		watches = append(watches, ch)
+	}
+	if len(watches) == 0 {
+		cancel()
+		return nil, ErrWatchNotSupported
+	}
+
+	changes := make(chan struct{}, 1)
+	var wg sync.WaitGroup
+	for _, ch := range watches {
+		wg.Go(func() {
+			for range ch {
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			}
+		})
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+		close(changes)
+	}()
+	return changes, nil
+}
+
+// Status returns the status of the first backend that reports one.
+func (s *MultiStore) Status(ctx context.Context) (*InstallerStatus, error) {
+	var errs []*BackendError
+	for _, backend := range s.backends {
+		status, err := backend.Store.Status(ctx)
+		if err == nil {
+			return status, nil
+		}
+		errs = append(errs, &BackendError{Backend: backend.Name, Err: err})
+	}
+	return nil, &MultiStoreError{Op: "status", Errors: errs}
+}
+
+// Load returns the credentials from the first backend that has them.
+// Returns ErrNotRegistered if no backend has credentials.
+func (s *MultiStore) Load(ctx context.Context) (*AppCredentials, error) {
+	var errs []*BackendError
+	for _, backend := range s.backends {
+		creds, err := backend.Store.Load(ctx)
+		if err == nil {
+			return creds, nil
+		}
+		if !errors.Is(err, ErrNotRegistered) {
+			errs = append(errs, &BackendError{Backend: backend.Name, Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, &MultiStoreError{Op: "load", Errors: errs}
+	}
+	return nil, ErrNotRegistered
+}
+
+// transact applies op to each backend in order. If a backend fails, the
+// backends already changed are restored to the credentials they held before,
+// and the returned error lists the failed backend and any failed restores.
+func (s *MultiStore) transact(ctx context.Context, opName string, op func(Store) error) error {
+	previous := make([]*AppCredentials, len(s.backends))
+	for i, backend := range s.backends {
+		creds, err := backend.Store.Load(ctx)
+		if err != nil && !errors.Is(err, ErrNotRegistered) {
+			return &MultiStoreError{Op: opName, Errors: []*BackendError{{Backend: backend.Name, Err: err}}}
+		}
+		previous[i] = creds
+	}
+
+	for i, backend := range s.backends {
+		err := op(backend.Store)
+		if err == nil {
+			continue
+		}
+
+		errs := []*BackendError{{Backend: backend.Name, Err: err}}
+		for j := i - 1; j >= 0; j-- {
+			if err := restore(ctx, s.backends[j].Store, previous[j]); err != nil {
+				errs = append(errs, &BackendError{
+					Backend: s.backends[j].Name,
+					Err:     fmt.Errorf("failed to restore previous credentials: %w", err),
+				})
+			}
+		}
+		return &MultiStoreError{Op: opName, Errors: errs}
+	}
+	return nil
+}
+
+// restore returns a backend to the given credentials, deleting them if the
+// backend had none.
+func restore(ctx context.Context, store Store, creds *AppCredentials) error {
+	if creds == nil {
+		return store.Delete(ctx)
+	}
+	return store.Rotate(ctx, creds)
+}
@@ -0,0 +1,122 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// failingStore is a Store whose writes fail.
+type failingStore struct {
+	*LocalFileStore
+}
+
+var errBackendDown = errors.New("backend down")
+
+func (failingStore) Save(context.Context, *AppCredentials) error {
+	return errBackendDown
+}
+
+func TestMultiStoreSave(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	primary := NewLocalFileStore(filepath.Join(dir, "primary"))
+	secondary := NewLocalEnvFileStore(filepath.Join(dir, "app.env"))
+
+	store, err := NewMultiStore(
+		StoreBackend{Name: "files", Store: primary},
+		StoreBackend{Name: "envfile", Store: secondary},
+	)
+	if err != nil {
+		t.Fatalf("NewMultiStore() error = %v", err)
+	}
+
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	for _, backend := range store.Backends() {
+		if _, err := backend.Store.Load(ctx); err != nil {
+			t.Errorf("%s: Load() error = %v", backend.Name, err)
+		}
+	}
+
+	creds, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if creds.AppID != 1234 {
+		t.Errorf("unexpected credentials: %+v", creds)
+	}
+}
+
+func TestMultiStoreSaveRollsBack(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	first := NewLocalEnvFileStore(filepath.Join(dir, "app.env"))
+	second := NewLocalFileStore(filepath.Join(dir, "second"))
+	if err := second.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewMultiStore(
+		StoreBackend{Name: "envfile", Store: first},
+		StoreBackend{Name: "files", Store: second},
+		StoreBackend{Name: "broken", Store: failingStore{NewLocalFileStore(filepath.Join(dir, "broken"))}},
+	)
+	if err != nil {
+		t.Fatalf("NewMultiStore() error = %v", err)
+	}
+
+	rotated := testAppCredentials()
+	rotated.PrivateKey = "rotated-key"
+	err = store.Save(ctx, rotated)
+
+	var multiErr *MultiStoreError
+	if !errors.As(err, &multiErr) || len(multiErr.Errors) != 1 || multiErr.Errors[0].Backend != "broken" {
+		t.Fatalf("expected error for the broken backend, got %v", err)
+	}
+	if !errors.Is(err, errBackendDown) {
+		t.Errorf("expected error to wrap the backend error, got %v", err)
+	}
+
+	if _, err := first.Load(ctx); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("expected new backend to be cleared after rollback, got %v", err)
+	}
+	creds, err := second.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if creds.PrivateKey != testAppCredentials().PrivateKey {
+		t.Errorf("expected previous private key to be restored, got %q", creds.PrivateKey)
+	}
+}
+
+func TestNewMultiStoreFromEnv(t *testing.T) {
+	t.Setenv(EnvStorageMode, StorageModeMulti)
+
+	t.Setenv(EnvStorageMultiModes, "")
+	if _, err := NewFromEnv(); err == nil {
+		t.Error("expected error when no modes are listed")
+	}
+
+	t.Setenv(EnvStorageMultiModes, "envfile,multi")
+	if _, err := NewFromEnv(); err == nil {
+		t.Error("expected error for nested multi store")
+	}
+
+	t.Setenv(EnvStorageDir, filepath.Join(t.TempDir(), "app.env"))
+	t.Setenv(EnvStorageMultiModes, "envfile, files")
+	store, err := NewFromEnv()
+	if err != nil {
+		t.Fatalf("NewFromEnv() error = %v", err)
+	}
+	if got := len(store.(*ValidatingStore).Unwrap().(*MultiStore).Backends()); got != 2 {
+		t.Errorf("expected 2 backends, got %d", got)
+	}
+}
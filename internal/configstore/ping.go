@@ -0,0 +1,42 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// checkWritableDir checks that files can be created in dir. If dir does not
+// exist yet, its nearest existing ancestor is checked instead, since saving
+// creates the missing directories.
+func checkWritableDir(dir string) error {
+	existing := dir
+	for {
+		info, err := os.Stat(existing)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", existing)
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return fmt.Errorf("no existing parent directory for %s", dir)
+		}
+		existing = parent
+	}
+
+	f, err := os.CreateTemp(existing, ".ping-*")
+	if err != nil {
+		return fmt.Errorf("directory %s is not writable: %w", existing, err)
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+// EnvStorageReadOnly makes the config store read-only when set to true.
+const EnvStorageReadOnly = "STORAGE_READ_ONLY"
+
+// ErrReadOnly is returned when writing to a read-only store.
+var ErrReadOnly = errors.New("config store is read-only")
+
+// ReadOnlyStore wraps a Store so its credentials can be read but never
+// written, e.g. in production deployments where the installer must not be
+// able to overwrite live credentials.
+type ReadOnlyStore struct {
+	Store
+}
+
+// NewReadOnlyStore wraps store so every write fails with ErrReadOnly.
+func NewReadOnlyStore(store Store) *ReadOnlyStore {
+	return &ReadOnlyStore{Store: store}
+}
+
+// ReadOnlyEnabled reports whether STORAGE_READ_ONLY is set to true.
+func ReadOnlyEnabled() bool {
+	readOnly, _ := strconv.ParseBool(GetEnvDefault(EnvStorageReadOnly, "false"))
+	return readOnly
+}
+
+// Unwrap returns the wrapped store.
+func (s *ReadOnlyStore) Unwrap() Store {
+	return s.Store
+}
+
+// ReadOnly reports that the store rejects writes.
+func (s *ReadOnlyStore) ReadOnly() bool {
+	return true
+}
+
+// Save returns ErrReadOnly.
+func (s *ReadOnlyStore) Save(context.Context, *AppCredentials) error {
+	return ErrReadOnly
+}
+
+// Rotate returns ErrReadOnly.
+func (s *ReadOnlyStore) Rotate(context.Context, *AppCredentials) error {
+	return ErrReadOnly
+}
+
+// Delete returns ErrReadOnly.
+func (s *ReadOnlyStore) Delete(context.Context) error {
+	return ErrReadOnly
+}
+
+// DisableInstaller returns ErrReadOnly.
+func (s *ReadOnlyStore) DisableInstaller(context.Context) error {
+	return ErrReadOnly
+}
+
+// History returns the credential versions of the wrapped store.
+func (s *ReadOnlyStore) History(ctx context.Context) ([]CredentialVersion, error) {
+	return History(ctx, s.Store)
+}
+
+// Rollback returns ErrReadOnly.
+func (s *ReadOnlyStore) Rollback(context.Context, string) error {
+	return ErrReadOnly
+}
+
+// Ping checks that the status can be read. Unlike the Ping of some wrapped
+// stores, it doesn't require write access.
+func (s *ReadOnlyStore) Ping(ctx context.Context) error {
+	_, err := s.Store.Status(ctx)
+	return err
+}
+
+// Watch watches the wrapped store.
+func (s *ReadOnlyStore) Watch(ctx context.Context) (<-chan struct{}, error) {
+	return Watch(ctx, s.Store)
+}
@@ -0,0 +1,130 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/cruxstack/octo-sts-distros/internal/sops"
+)
+
+// Environment variables for the SOPS file store. SOPS_KMS_ARN,
+// SOPS_AGE_RECIPIENTS, and SOPS_AGE_KEY_FILE match the sops CLI.
+const (
+	EnvSOPSKMSARN        = "SOPS_KMS_ARN"
+	EnvSOPSAgeRecipients = "SOPS_AGE_RECIPIENTS"
+	EnvSOPSAgeKeyFile    = "SOPS_AGE_KEY_FILE"
+	EnvSOPSPGPKeyFile    = "SOPS_PGP_KEY_FILE"
+)
+
+// StorageModeSOPS saves credentials to a SOPS-encrypted YAML or JSON file.
+const StorageModeSOPS = "sops"
+
+// DefaultSOPSFilePath is the default path of the SOPS file.
+const DefaultSOPSFilePath = "./octo-sts.sops.yaml"
+
+// SOPSEncrypter is an Encrypter that writes sops documents, so an
+// EncryptedFileStore using it produces files that can be committed to a
+// GitOps repository and decrypted with the sops CLI.
+type SOPSEncrypter struct {
+	Format sops.Format
+	Keys   sops.Keys
+}
+
+// Encrypt encodes the JSON object plaintext as a sops document.
+func (e *SOPSEncrypter) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	var values map[string]string
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, err
+	}
+	return sops.Encrypt(ctx, values, e.Format, e.Keys)
+}
+
+// Decrypt decodes a sops document into a JSON object.
+func (e *SOPSEncrypter) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	values, err := sops.Decrypt(ctx, ciphertext, e.Keys)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(values)
+}
+
+// NewSOPSFileStore creates a store that saves credentials to a sops file at
+// path. The format is JSON for .json files and YAML otherwise.
+func NewSOPSFileStore(path string, keys sops.Keys) (*EncryptedFileStore, error) {
+	return NewEncryptedFileStore(path, &SOPSEncrypter{Format: sops.FormatForPath(path), Keys: keys})
+}
+
+// newSOPSFileStoreFromEnv creates a SOPS file store from environment
+// variables. Saving requires at least one KMS ARN, age recipient, or PGP key;
+// reading an existing file also requires a key able to decrypt it.
+func newSOPSFileStoreFromEnv() (*EncryptedFileStore, error) {
+	var keys sops.Keys
+
+	if arns := splitList(os.Getenv(EnvSOPSKMSARN)); len(arns) > 0 {
+		cfg, err := config.LoadDefaultConfig(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		}
+		keys.KMSARNs = arns
+		keys.KMSClient = kms.NewFromConfig(cfg)
+	}
+
+	if recipients := os.Getenv(EnvSOPSAgeRecipients); recipients != "" {
+		parsed, err := age.ParseRecipients(strings.NewReader(strings.ReplaceAll(recipients, ",", "\n")))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", EnvSOPSAgeRecipients, err)
+		}
+		keys.AgeRecipients = parsed
+	}
+
+	if path := os.Getenv(EnvSOPSAgeKeyFile); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", EnvSOPSAgeKeyFile, err)
+		}
+		defer f.Close()
+		if keys.AgeIdentities, err = age.ParseIdentities(f); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", EnvSOPSAgeKeyFile, err)
+		}
+	}
+
+	if path := os.Getenv(EnvSOPSPGPKeyFile); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", EnvSOPSPGPKeyFile, err)
+		}
+		defer f.Close()
+		if keys.PGPKeys, err = openpgp.ReadArmoredKeyRing(f); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", EnvSOPSPGPKeyFile, err)
+		}
+	}
+
+	if len(keys.KMSARNs)+len(keys.AgeRecipients)+len(keys.PGPKeys) == 0 {
+		return nil, fmt.Errorf("%s, %s, or %s is required when using %s storage mode",
+			EnvSOPSKMSARN, EnvSOPSAgeRecipients, EnvSOPSPGPKeyFile, StorageModeSOPS)
+	}
+
+	return NewSOPSFileStore(GetEnvDefault(EnvStorageDir, DefaultSOPSFilePath), keys)
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
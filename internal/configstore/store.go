@@ -1,12 +1,19 @@
 // Copyright 2025 CruxStack
 // SPDX-License-Identifier: MIT
 
-// Package configstore re-exports the configstore package from the ghappsetup library
-// and adds octo-sts specific functionality.
+// Package configstore provides the storage backends for GitHub App credentials.
+// It re-exports the shared types from the ghappsetup library's configstore
+// package and extends its Store interface with Load.
 package configstore
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/url"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/cruxstack/github-app-setup-go/configstore"
@@ -14,13 +21,9 @@ import (
 
 // Re-export types from the library
 type (
-	Store           = configstore.Store
 	AppCredentials  = configstore.AppCredentials
 	InstallerStatus = configstore.InstallerStatus
 	HookConfig      = configstore.HookConfig
-	AWSSSMStore     = configstore.AWSSSMStore
-	SSMStoreOption  = configstore.SSMStoreOption
-	SSMClient       = configstore.SSMClient
 )
 
 // Re-export constants from the library
@@ -50,17 +53,346 @@ const (
 
 // Re-export functions from the library
 var (
-	NewFromEnv           = configstore.NewFromEnv
-	InstallerEnabled     = configstore.InstallerEnabled
-	NewAWSSSMStore       = configstore.NewAWSSSMStore
-	NewLocalFileStore    = configstore.NewLocalFileStore
-	NewLocalEnvFileStore = configstore.NewLocalEnvFileStore
-	WithKMSKey           = configstore.WithKMSKey
-	WithTags             = configstore.WithTags
-	WithSSMClient        = configstore.WithSSMClient
-	GetEnvDefault        = configstore.GetEnvDefault
+	InstallerEnabled = configstore.InstallerEnabled
+	GetEnvDefault    = configstore.GetEnvDefault
 )
 
+// ErrNotRegistered is returned by Load when the store does not hold a
+// complete set of app credentials yet.
+var ErrNotRegistered = errors.New("app credentials not found in store")
+
+// Store saves and loads app credentials. It extends the library's Store, so
+// every implementation can also be passed to the installer and runtime.
+type Store interface {
+	configstore.Store
+
+	// Load reads the stored credentials. Values other than the app
+	// credentials (such as STS_DOMAIN) are returned in CustomFields.
+	// Returns ErrNotRegistered if the credentials are incomplete.
+	Load(ctx context.Context) (*AppCredentials, error)
+
+	// Delete removes the stored credentials, e.g. when decommissioning an app.
+	Delete(ctx context.Context) error
+
+	// Rotate replaces the stored credentials with creds. Unlike Save, values
+	// from the previous credentials that creds does not set are removed.
+	Rotate(ctx context.Context, creds *AppCredentials) error
+
+	// Ping checks that the backend is reachable and the configured identity
+	// can read from it, without modifying stored credentials. It lets a
+	// misconfiguration surface before the installer tries to save.
+	Ping(ctx context.Context) error
+}
+
+// requiredKeys are the values that must be present for an app to be registered.
+var requiredKeys = []string{
+	EnvGitHubAppID,
+	EnvGitHubWebhookSecret,
+	EnvGitHubClientID,
+	EnvGitHubClientSecret,
+	EnvGitHubAppPrivateKey,
+}
+
+// knownKeys are the values read by stores that cannot enumerate their keys.
+var knownKeys = slices.Concat([]string{
+	EnvGitHubAppSlug,
+	EnvGitHubAppHTMLURL,
+	EnvGitHubAppInstallerEnabled,
+	EnvSTSDomain,
+}, requiredKeys, metadataKeys)
+
+// NewFromEnv creates a Store based on environment variable configuration.
+// It reads STORAGE_MODE to determine the backend type:
+//   - "envfile" (default): saves to a .env file at STORAGE_DIR (default: ./.env)
+//   - "files": saves to individual files in STORAGE_DIR directory
+//   - "encrypted-file": saves to an age or KMS encrypted file at STORAGE_DIR (default: ./.env.enc)
+//   - "sops": saves to a SOPS-encrypted file at STORAGE_DIR (default: ./octo-sts.sops.yaml)
+//   - "aws-ssm": saves to AWS SSM Parameter Store with AWS_SSM_PARAMETER_PREFIX
+//   - "aws-secretsmanager": saves to AWS Secrets Manager under AWS_SECRETS_MANAGER_SECRET_NAME
+//   - "vault": saves to the HashiCorp Vault KV v2 secret at VAULT_KV_MOUNT/VAULT_KV_PATH
+//   - "azure-keyvault": saves to the Azure Key Vault at AZURE_KEY_VAULT_URI
+//   - "kubernetes": saves to the Kubernetes Secret KUBERNETES_SECRET_NAMESPACE/KUBERNETES_SECRET_NAME
+//   - "docker-secrets": saves Docker/Podman secrets to DOCKER_SECRETS_DIR or through the Engine API
+//   - "consul": saves to Consul KV keys under KV_PREFIX (default: octo-sts/)
+//   - "etcd": saves to etcd keys under KV_PREFIX (default: octo-sts/)
+//   - "multi": saves to each of the comma-separated modes in STORAGE_MULTI_MODES
+//
+// Credentials are validated before they are saved unless STORAGE_VALIDATE=false;
+// see ValidatingStore.
+// With STORAGE_READ_ONLY=true, the store rejects every write; see ReadOnlyStore.
+// With AWS_DYNAMODB_STATUS_TABLE set, the installer status is kept in that
+// DynamoDB table; see DynamoDBStatusStore.
+//
+// Returns an error if configuration is invalid or store creation fails.
+func NewFromEnv() (Store, error) {
+	return NewForMode(GetEnvDefault(EnvStorageMode, StorageModeEnvFile))
+}
+
+// NewForMode creates a Store like NewFromEnv, but for the given storage mode
+// instead of STORAGE_MODE. The backend is still configured by its usual
+// environment variables.
+func NewForMode(mode string) (Store, error) {
+	store, err := newStoreFromEnv(mode)
+	if err != nil {
+		return nil, err
+	}
+	store, err = newDynamoDBStatusStoreFromEnv(store)
+	if err != nil {
+		return nil, err
+	}
+	if ReadOnlyEnabled() {
+		return NewReadOnlyStore(store), nil
+	}
+	return newValidatingStoreFromEnv(store), nil
+}
+
+// NewForModeInDir creates a Store like NewForMode, with STORAGE_DIR set to
+// dir while the store is created, which is when the local stores read it.
+// An empty dir leaves STORAGE_DIR as is. It lets one process open two local
+// stores, e.g. to migrate between them.
+func NewForModeInDir(mode, dir string) (Store, error) {
+	if dir == "" {
+		return NewForMode(mode)
+	}
+	return newForModeWithEnv(mode, EnvStorageDir, dir)
+}
+
+// NewForModeWithPrefix creates a Store like NewForMode, with the variable
+// that locates the credentials of mode (see PrefixEnv) set to prefix while
+// the store is created. It lets one process keep the credentials of several
+// GitHub Apps in one backend, each under its own prefix.
+func NewForModeWithPrefix(mode, prefix string) (Store, error) {
+	env, ok := PrefixEnv(mode)
+	if !ok {
+		return nil, fmt.Errorf("%s %q does not support a prefix", EnvStorageMode, mode)
+	}
+	return newForModeWithEnv(mode, env, prefix)
+}
+
+// PrefixEnv returns the variable that locates the credentials of mode, such
+// as STORAGE_DIR for the local stores or AWS_SSM_PARAMETER_PREFIX for SSM.
+// It reports false for modes without one, such as multi.
+func PrefixEnv(mode string) (string, bool) {
+	switch mode {
+	case StorageModeFiles, StorageModeEnvFile, StorageModeEncryptedFile, StorageModeSOPS:
+		return EnvStorageDir, true
+	case StorageModeAWSSSM:
+		return EnvAWSSSMParameterPfx, true
+	case StorageModeAWSSecretsManager:
+		return EnvAWSSecretsManagerSecretName, true
+	case StorageModeVault:
+		return EnvVaultKVPath, true
+	case StorageModeAzureKeyVault:
+		return EnvAzureKeyVaultSecretName, true
+	case StorageModeKubernetes:
+		return EnvKubernetesSecretName, true
+	case StorageModeDockerSecrets:
+		return EnvDockerSecretsPrefix, true
+	case StorageModeConsul, StorageModeEtcd:
+		return EnvKVPrefix, true
+	}
+	return "", false
+}
+
+// newForModeWithEnv creates the Store of mode with env set to value while
+// the store is created, restoring it afterwards.
+func newForModeWithEnv(mode, env, value string) (Store, error) {
+	if prev, ok := os.LookupEnv(env); ok {
+		defer os.Setenv(env, prev)
+	} else {
+		defer os.Unsetenv(env)
+	}
+	os.Setenv(env, value)
+	return NewForMode(mode)
+}
+
+// newStoreFromEnv creates the Store for a single storage mode.
+func newStoreFromEnv(mode string) (Store, error) {
+	switch mode {
+	case StorageModeFiles:
+		dir := GetEnvDefault(EnvStorageDir, "./.env")
+		return NewLocalFileStore(dir), nil
+	case StorageModeEnvFile:
+		path := GetEnvDefault(EnvStorageDir, "./.env")
+		return NewLocalEnvFileStore(path), nil
+	case StorageModeAWSSSM:
+		return newAWSSSMStoreFromEnv()
+	case StorageModeAWSSecretsManager:
+		return newAWSSecretsManagerStoreFromEnv()
+	case StorageModeVault:
+		return newVaultStoreFromEnv()
+	case StorageModeAzureKeyVault:
+		return newAzureKeyVaultStoreFromEnv()
+	case StorageModeKubernetes:
+		return newKubernetesSecretStoreFromEnv()
+	case StorageModeEncryptedFile:
+		return newEncryptedFileStoreFromEnv()
+	case StorageModeSOPS:
+		return newSOPSFileStoreFromEnv()
+	case StorageModeDockerSecrets:
+		return newDockerSecretsStoreFromEnv()
+	case StorageModeConsul, StorageModeEtcd:
+		return newKVStoreFromEnv(mode)
+	case StorageModeMulti:
+		return newMultiStoreFromEnv()
+	default:
+		return nil, fmt.Errorf("unknown %s: %s (expected '%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', '%s', or '%s')",
+			EnvStorageMode, mode, StorageModeEnvFile, StorageModeFiles, StorageModeEncryptedFile, StorageModeSOPS, StorageModeAWSSSM,
+			StorageModeAWSSecretsManager, StorageModeVault, StorageModeAzureKeyVault, StorageModeKubernetes,
+			StorageModeDockerSecrets, StorageModeConsul, StorageModeEtcd, StorageModeMulti)
+	}
+}
+
+// credentialValues flattens credentials into values keyed by environment
+// variable name, matching the layout used by the library's stores.
+func credentialValues(creds *AppCredentials) map[string]string {
+	values := map[string]string{
+		EnvGitHubAppID:         fmt.Sprintf("%d", creds.AppID),
+		EnvGitHubWebhookSecret: creds.WebhookSecret,
+		EnvGitHubClientID:      creds.ClientID,
+		EnvGitHubClientSecret:  creds.ClientSecret,
+		EnvGitHubAppPrivateKey: creds.PrivateKey,
+	}
+
+	if creds.AppSlug != "" {
+		values[EnvGitHubAppSlug] = creds.AppSlug
+	}
+	if creds.HTMLURL != "" {
+		values[EnvGitHubAppHTMLURL] = creds.HTMLURL
+	}
+
+	for key, value := range creds.CustomFields {
+		if value != "" {
+			values[key] = value
+		}
+	}
+
+	return values
+}
+
+// staleKeys returns the known credential keys that are not set in values.
+// The installer flag is never considered stale.
+func staleKeys(values map[string]string) []string {
+	var stale []string
+	for _, key := range knownKeys {
+		if key == EnvGitHubAppInstallerEnabled {
+			continue
+		}
+		if _, ok := values[key]; !ok {
+			stale = append(stale, key)
+		}
+	}
+	return stale
+}
+
+// changedValues returns the entries of values that are missing from or
+// differ in existing, so stores only write what changed. Rewriting unchanged
+// values would bump versions and trigger change detection downstream.
+func changedValues(existing, values map[string]string) map[string]string {
+	changed := make(map[string]string)
+	for key, value := range values {
+		if current, ok := existing[key]; !ok || current != value {
+			changed[key] = value
+		}
+	}
+	return changed
+}
+
+// mergeValues sets values in existing and drops the keys listed in remove,
+// reporting whether existing changed.
+func mergeValues(existing, values map[string]string, remove []string) bool {
+	changed := false
+	for _, key := range remove {
+		if _, ok := existing[key]; ok {
+			delete(existing, key)
+			changed = true
+		}
+	}
+	for key, value := range changedValues(existing, values) {
+		existing[key] = value
+		changed = true
+	}
+	return changed
+}
+
+// statusFromValues derives the installer status from values keyed by
+// environment variable name.
+func statusFromValues(values map[string]string) *InstallerStatus {
+	status := &InstallerStatus{
+		AppSlug: values[EnvGitHubAppSlug],
+		HTMLURL: values[EnvGitHubAppHTMLURL],
+	}
+
+	if idStr := strings.TrimSpace(values[EnvGitHubAppID]); idStr != "" {
+		if id, err := strconv.ParseInt(idStr, 10, 64); err == nil {
+			status.AppID = id
+		}
+	}
+
+	status.Registered = hasAllValues(values, requiredKeys...)
+	status.InstallerDisabled = isFalseString(values[EnvGitHubAppInstallerEnabled])
+
+	return status
+}
+
+// credentialsFromValues builds credentials from values keyed by environment
+// variable name. It is the inverse of credentialValues.
+func credentialsFromValues(values map[string]string) (*AppCredentials, error) {
+	if !hasAllValues(values, requiredKeys...) {
+		return nil, ErrNotRegistered
+	}
+
+	appID, err := strconv.ParseInt(strings.TrimSpace(values[EnvGitHubAppID]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", EnvGitHubAppID, err)
+	}
+
+	creds := &AppCredentials{
+		AppID:         appID,
+		AppSlug:       values[EnvGitHubAppSlug],
+		ClientID:      values[EnvGitHubClientID],
+		ClientSecret:  values[EnvGitHubClientSecret],
+		WebhookSecret: values[EnvGitHubWebhookSecret],
+		PrivateKey:    values[EnvGitHubAppPrivateKey],
+		HTMLURL:       values[EnvGitHubAppHTMLURL],
+		CustomFields:  make(map[string]string),
+	}
+
+	for key, value := range values {
+		switch key {
+		case EnvGitHubAppID, EnvGitHubAppSlug, EnvGitHubClientID, EnvGitHubClientSecret,
+			EnvGitHubWebhookSecret, EnvGitHubAppPrivateKey, EnvGitHubAppHTMLURL, EnvGitHubAppInstallerEnabled:
+			continue
+		}
+		if value != "" {
+			creds.CustomFields[key] = value
+		}
+	}
+
+	return creds, nil
+}
+
+func hasAllValues(values map[string]string, keys ...string) bool {
+	if len(values) == 0 {
+		return false
+	}
+	for _, key := range keys {
+		if strings.TrimSpace(values[key]) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func isFalseString(v string) bool {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "false", "0", "no", "off":
+		return true
+	default:
+		return false
+	}
+}
+
 // ExtractSTSDomainFromWebhookURL extracts the STS domain from a webhook URL.
 // This is an octo-sts specific helper function.
 func ExtractSTSDomainFromWebhookURL(webhookURL string) string {
@@ -0,0 +1,487 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+)
+
+func TestLoadLocalStores(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name  string
+		store Store
+	}{
+		{name: "envfile", store: NewLocalEnvFileStore(filepath.Join(dir, "app.env"))},
+		{name: "files", store: NewLocalFileStore(filepath.Join(dir, "files"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if _, err := tt.store.Load(ctx); !errors.Is(err, ErrNotRegistered) {
+				t.Fatalf("expected ErrNotRegistered before save, got %v", err)
+			}
+
+			want := testAppCredentials()
+			if err := tt.store.Save(ctx, want); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			if err := tt.store.DisableInstaller(ctx); err != nil {
+				t.Fatalf("DisableInstaller() error = %v", err)
+			}
+
+			got, err := tt.store.Load(ctx)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if got.AppID != want.AppID || got.AppSlug != want.AppSlug || got.ClientID != want.ClientID ||
+				got.ClientSecret != want.ClientSecret || got.WebhookSecret != want.WebhookSecret {
+				t.Errorf("unexpected credentials: %+v", got)
+			}
+			if got.PrivateKey != want.PrivateKey {
+				t.Errorf("expected private key to round-trip, got %q", got.PrivateKey)
+			}
+			if got.CustomFields[EnvSTSDomain] != "sts.example.com" {
+				t.Errorf("expected STS_DOMAIN custom field, got %v", got.CustomFields)
+			}
+			if _, ok := got.CustomFields[EnvGitHubAppInstallerEnabled]; ok {
+				t.Error("expected installer flag to be excluded from custom fields")
+			}
+		})
+	}
+}
+
+func TestRotateAndDeleteLocalStores(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name  string
+		store Store
+	}{
+		{name: "envfile", store: NewLocalEnvFileStore(filepath.Join(dir, "app.env"))},
+		{name: "files", store: NewLocalFileStore(filepath.Join(dir, "files"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if err := tt.store.Save(ctx, testAppCredentials()); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			rotated := testAppCredentials()
+			rotated.AppSlug = ""
+			rotated.PrivateKey = "rotated-key"
+			if err := tt.store.Rotate(ctx, rotated); err != nil {
+				t.Fatalf("Rotate() error = %v", err)
+			}
+
+			got, err := tt.store.Load(ctx)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if got.PrivateKey != "rotated-key" {
+				t.Errorf("expected rotated private key, got %q", got.PrivateKey)
+			}
+			if got.AppSlug != "" {
+				t.Errorf("expected stale app slug to be removed, got %q", got.AppSlug)
+			}
+
+			if err := tt.store.Delete(ctx); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := tt.store.Load(ctx); !errors.Is(err, ErrNotRegistered) {
+				t.Errorf("expected ErrNotRegistered after delete, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLocalStoreHistory(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name  string
+		store VersionedStore
+	}{
+		{name: "envfile", store: NewLocalEnvFileStore(filepath.Join(dir, "app.env"))},
+		{name: "files", store: NewLocalFileStore(filepath.Join(dir, "files"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if err := tt.store.Save(ctx, testAppCredentials()); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			rotated := testAppCredentials()
+			rotated.PrivateKey = "rotated-key"
+			if err := tt.store.Rotate(ctx, rotated); err != nil {
+				t.Fatalf("Rotate() error = %v", err)
+			}
+
+			versions, err := tt.store.History(ctx)
+			if err != nil {
+				t.Fatalf("History() error = %v", err)
+			}
+			if len(versions) != 2 {
+				t.Fatalf("expected 2 versions, got %d", len(versions))
+			}
+			if !versions[0].Current || versions[0].Credentials.PrivateKey != "rotated-key" {
+				t.Errorf("expected current version first, got %+v", versions[0])
+			}
+
+			if err := tt.store.Rollback(ctx, versions[1].ID); err != nil {
+				t.Fatalf("Rollback() error = %v", err)
+			}
+			got, err := tt.store.Load(ctx)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if got.PrivateKey != testAppCredentials().PrivateKey {
+				t.Errorf("expected original private key after rollback, got %q", got.PrivateKey)
+			}
+		})
+	}
+}
+
+func TestRollbackCarriesOverKeys(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalFileStore(t.TempDir())
+
+	// The first version predates STS_DOMAIN and the metadata
+	old := testAppCredentials()
+	old.CustomFields = nil
+	if err := store.Save(ctx, old); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	rotated := testAppCredentials()
+	rotated.PrivateKey = "rotated-key"
+	rotated.CustomFields[EnvGitHubAppCreatedBy] = "installer"
+	if err := store.Rotate(ctx, rotated); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+
+	versions, err := History(ctx, store)
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("History() = %d versions, %v, want 2", len(versions), err)
+	}
+	if err := Rollback(ctx, store, versions[1].ID); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	got, err := store.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.PrivateKey != old.PrivateKey || got.CustomFields[EnvSTSDomain] != "sts.example.com" || got.CustomFields[EnvGitHubAppCreatedBy] != "installer" {
+		t.Errorf("after rollback = %+v, want the old key with STS_DOMAIN and the metadata kept", got)
+	}
+
+	// Rolling back to another app drops the metadata of the current one
+	other := testAppCredentials()
+	other.AppID = 5678
+	other.CustomFields = nil
+	if err := store.Rotate(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Rotate(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	versions, _ = History(ctx, store)
+	if err := Rollback(ctx, store, versions[1].ID); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	got, _ = store.Load(ctx)
+	if got.AppID != 5678 || got.CustomFields[EnvSTSDomain] != "sts.example.com" || got.CustomFields[EnvGitHubAppCreatedBy] != "" {
+		t.Errorf("after rollback = %+v, want app 5678 with STS_DOMAIN and no metadata", got)
+	}
+}
+
+func TestHistoryWrappedStores(t *testing.T) {
+	ctx := context.Background()
+	inner := NewLocalFileStore(t.TempDir())
+	if err := inner.Save(ctx, validAppCredentials(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := inner.Rotate(ctx, testAppCredentials()); err != nil {
+		t.Fatal(err)
+	}
+
+	readOnly := NewReadOnlyStore(inner)
+	versions, err := History(ctx, readOnly)
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("History() of a read-only store = %d versions, %v, want 2", len(versions), err)
+	}
+	if err := Rollback(ctx, readOnly, versions[1].ID); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Rollback() of a read-only store error = %v, want ErrReadOnly", err)
+	}
+
+	// The rolled back credentials are validated like any others
+	validating := NewValidatingStore(inner)
+	if err := Rollback(ctx, validating, versions[1].ID); err != nil {
+		t.Errorf("Rollback() to valid credentials error = %v", err)
+	}
+	versions, _ = History(ctx, validating)
+	if err := Rollback(ctx, validating, versions[1].ID); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Rollback() to invalid credentials error = %v, want ErrInvalidCredentials", err)
+	}
+
+	multi, _ := NewMultiStore(StoreBackend{Name: "files", Store: inner})
+	if _, err := History(ctx, multi); !errors.Is(err, ErrHistoryNotSupported) {
+		t.Errorf("History() of a multi store error = %v, want ErrHistoryNotSupported", err)
+	}
+}
+
+func TestLocalStoreSaveSkipsUnchanged(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name  string
+		store VersionedStore
+		path  string
+	}{
+		{name: "envfile", store: NewLocalEnvFileStore(filepath.Join(dir, "app.env")), path: filepath.Join(dir, "app.env")},
+		{name: "files", store: NewLocalFileStore(filepath.Join(dir, "files")), path: filepath.Join(dir, "files", "client-id")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if err := tt.store.Save(ctx, testAppCredentials()); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			before, err := os.Stat(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tt.store.Save(ctx, testAppCredentials()); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			rotated := testAppCredentials()
+			rotated.WebhookSecret = "rotated-secret"
+			if err := tt.store.Save(ctx, rotated); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			versions, err := tt.store.History(ctx)
+			if err != nil {
+				t.Fatalf("History() error = %v", err)
+			}
+			if len(versions) != 2 {
+				t.Errorf("expected the unchanged save to add no version, got %d versions", len(versions))
+			}
+			if tt.name == "files" {
+				after, err := os.Stat(tt.path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !os.SameFile(before, after) {
+					t.Error("expected unchanged file to be left in place")
+				}
+			}
+		})
+	}
+}
+
+func TestLocalEnvFileStoreConcurrentSaves(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "app.env")
+
+	// Each save adds its own value; without the lock, concurrent
+	// read-modify-write cycles would drop some of them.
+	const saves = 50
+	var wg sync.WaitGroup
+	for i := range saves {
+		wg.Go(func() {
+			creds := testAppCredentials()
+			creds.CustomFields = map[string]string{fmt.Sprintf("EXTRA_%d", i): "set"}
+			if err := NewLocalEnvFileStore(path).Save(ctx, creds); err != nil {
+				t.Errorf("Save() error = %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	values, _, err := parseEnvFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range saves {
+		if key := fmt.Sprintf("EXTRA_%d", i); values[key] != "set" {
+			t.Errorf("expected %s to survive concurrent saves", key)
+		}
+	}
+}
+
+func TestWriteFileAtomicBindMount(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.env")
+	if err := os.WriteFile(path, []byte("OLD=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Renaming over a file bind-mounted on its own fails with EBUSY
+	defer func(rename func(string, string) error) { renameFile = rename }(renameFile)
+	renameFile = func(string, string) error {
+		return &os.LinkError{Op: "rename", Err: syscall.EBUSY}
+	}
+	if err := writeFileAtomic(path, []byte("NEW=1\n"), 0600); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "NEW=1\n" {
+		t.Errorf("file = %q, want it rewritten in place", got)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("directory holds %d files, want the temporary file removed", len(entries))
+	}
+
+	// Other rename errors are returned
+	renameFile = func(string, string) error {
+		return &os.LinkError{Op: "rename", Err: syscall.EACCES}
+	}
+	if err := writeFileAtomic(path, []byte("OTHER=1\n"), 0600); !errors.Is(err, syscall.EACCES) {
+		t.Errorf("writeFileAtomic() error = %v, want EACCES", err)
+	}
+}
+
+func TestCredentialsFromValues(t *testing.T) {
+	values := credentialValues(testAppCredentials())
+
+	creds, err := credentialsFromValues(values)
+	if err != nil {
+		t.Fatalf("credentialsFromValues() error = %v", err)
+	}
+	if creds.AppID != 1234 || creds.CustomFields[EnvSTSDomain] != "sts.example.com" {
+		t.Errorf("unexpected credentials: %+v", creds)
+	}
+
+	delete(values, EnvGitHubClientSecret)
+	if _, err := credentialsFromValues(values); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("expected ErrNotRegistered for incomplete values, got %v", err)
+	}
+
+	values[EnvGitHubClientSecret] = "secret"
+	values[EnvGitHubAppID] = "not-a-number"
+	if _, err := credentialsFromValues(values); err == nil || errors.Is(err, ErrNotRegistered) {
+		t.Errorf("expected parse error for invalid app ID, got %v", err)
+	}
+}
+
+func TestNewForModeInDir(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	t.Setenv(EnvStorageDir, "./.env")
+
+	store, err := NewForModeInDir(StorageModeFiles, dir)
+	if err != nil {
+		t.Fatalf("NewForModeInDir() error = %v", err)
+	}
+	if got := os.Getenv(EnvStorageDir); got != "./.env" {
+		t.Errorf("STORAGE_DIR = %q after NewForModeInDir(), want it restored", got)
+	}
+
+	if err := NewLocalFileStore(dir).Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := store.Load(ctx); err != nil {
+		t.Errorf("expected the store to read %s, Load() error = %v", dir, err)
+	}
+}
+
+func TestNewForModeWithPrefix(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	t.Setenv(EnvStorageDir, "./.env")
+
+	if err := NewLocalFileStore(dir).Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	store, err := NewForModeWithPrefix(StorageModeFiles, dir)
+	if err != nil {
+		t.Fatalf("NewForModeWithPrefix() error = %v", err)
+	}
+	if _, err := store.Load(ctx); err != nil {
+		t.Errorf("expected the store to read %s, Load() error = %v", dir, err)
+	}
+	if got := os.Getenv(EnvStorageDir); got != "./.env" {
+		t.Errorf("STORAGE_DIR = %q after NewForModeWithPrefix(), want it restored", got)
+	}
+
+	if _, err := NewForModeWithPrefix(StorageModeMulti, "tenant"); err == nil {
+		t.Errorf("NewForModeWithPrefix(%q) error = nil, want an error", StorageModeMulti)
+	}
+}
+
+func TestPingLocalStores(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	stores := map[string]Store{
+		"envfile": NewLocalEnvFileStore(filepath.Join(dir, "missing", "app.env")),
+		"files":   NewLocalFileStore(filepath.Join(dir, "a", "b")),
+	}
+	for name, store := range stores {
+		if err := store.Ping(ctx); err != nil {
+			t.Errorf("%s: Ping() error = %v", name, err)
+		}
+	}
+
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewLocalFileStore(filepath.Join(file, "creds")).Ping(ctx); err == nil {
+		t.Error("expected Ping() error when the store directory is under a file")
+	}
+}
+
+func TestReadOnlyStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "app.env")
+	if err := NewLocalEnvFileStore(path).Save(ctx, testAppCredentials()); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(EnvStorageMode, StorageModeEnvFile)
+	t.Setenv(EnvStorageDir, path)
+	t.Setenv(EnvStorageReadOnly, "true")
+	store, err := NewFromEnv()
+	if err != nil {
+		t.Fatalf("NewFromEnv() error = %v", err)
+	}
+
+	if creds, err := store.Load(ctx); err != nil || creds.AppID != 1234 {
+		t.Errorf("Load() = %+v, %v", creds, err)
+	}
+	if status, err := store.Status(ctx); err != nil || !status.Registered {
+		t.Errorf("Status() = %+v, %v", status, err)
+	}
+	if err := store.Ping(ctx); err != nil {
+		t.Errorf("Ping() error = %v", err)
+	}
+
+	for name, write := range map[string]func() error{
+		"Save":             func() error { return store.Save(ctx, testAppCredentials()) },
+		"Rotate":           func() error { return store.Rotate(ctx, testAppCredentials()) },
+		"Delete":           func() error { return store.Delete(ctx) },
+		"DisableInstaller": func() error { return store.DisableInstaller(ctx) },
+	} {
+		if err := write(); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s() error = %v, want ErrReadOnly", name, err)
+		}
+	}
+}
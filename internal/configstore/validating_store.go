@@ -0,0 +1,232 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Environment variables for credential validation.
+const (
+	EnvStorageValidate          = "STORAGE_VALIDATE"
+	EnvStorageValidateGitHubAPI = "STORAGE_VALIDATE_GITHUB_API"
+	EnvGitHubURL                = "GITHUB_URL"
+)
+
+// DefaultGitHubURL is the GitHub web URL used when GITHUB_URL is not set.
+const DefaultGitHubURL = "https://github.com"
+
+// ErrInvalidCredentials is returned when credentials fail validation and are
+// not saved.
+var ErrInvalidCredentials = errors.New("invalid github app credentials")
+
+// ValidatingStore wraps a Store and validates credentials before they are
+// saved or rotated in, so the runtime never reloads credentials it cannot
+// use. The private key must parse and sign an App JWT that verifies against
+// its public key; optionally, the JWT is also checked against GET /app.
+type ValidatingStore struct {
+	Store
+
+	apiURL     string
+	httpClient *http.Client
+}
+
+// ValidatingStoreOption is a functional option for configuring ValidatingStore.
+type ValidatingStoreOption func(*ValidatingStore)
+
+// WithGitHubAPIValidation also authenticates as the app against the GitHub
+// REST API at apiURL (e.g. https://api.github.com) before saving, and checks
+// that the returned app ID matches.
+func WithGitHubAPIValidation(apiURL string) ValidatingStoreOption {
+	return func(s *ValidatingStore) {
+		s.apiURL = strings.TrimSuffix(apiURL, "/")
+	}
+}
+
+// WithValidationHTTPClient sets the HTTP client used for GitHub API validation.
+func WithValidationHTTPClient(client *http.Client) ValidatingStoreOption {
+	return func(s *ValidatingStore) {
+		s.httpClient = client
+	}
+}
+
+// NewValidatingStore wraps store with credential validation.
+func NewValidatingStore(store Store, opts ...ValidatingStoreOption) *ValidatingStore {
+	s := &ValidatingStore{
+		Store:      store,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Unwrap returns the wrapped store.
+func (s *ValidatingStore) Unwrap() Store {
+	return s.Store
+}
+
+// Watch watches the wrapped store.
+func (s *ValidatingStore) Watch(ctx context.Context) (<-chan struct{}, error) {
+	return Watch(ctx, s.Store)
+}
+
+// History returns the credential versions of the wrapped store.
+func (s *ValidatingStore) History(ctx context.Context) ([]CredentialVersion, error) {
+	return History(ctx, s.Store)
+}
+
+// Rollback validates the credentials of the version with the given ID and
+// then rotates them into the wrapped store.
+func (s *ValidatingStore) Rollback(ctx context.Context, id string) error {
+	return rollback(ctx, s, id)
+}
+
+// Save validates creds and then saves them to the wrapped store.
+func (s *ValidatingStore) Save(ctx context.Context, creds *AppCredentials) error {
+	if err := s.Validate(ctx, creds); err != nil {
+		return err
+	}
+	return s.Store.Save(ctx, creds)
+}
+
+// Rotate validates creds and then rotates them into the wrapped store.
+func (s *ValidatingStore) Rotate(ctx context.Context, creds *AppCredentials) error {
+	if err := s.Validate(ctx, creds); err != nil {
+		return err
+	}
+	return s.Store.Rotate(ctx, creds)
+}
+
+// Validate checks creds without saving them. Errors wrap ErrInvalidCredentials
+// when the credentials themselves are at fault.
+func (s *ValidatingStore) Validate(ctx context.Context, creds *AppCredentials) error {
+	token, err := ValidateCredentials(creds)
+	if err != nil {
+		return err
+	}
+	if s.apiURL == "" {
+		return nil
+	}
+	return s.checkApp(ctx, creds.AppID, token)
+}
+
+// ValidateCredentials checks that creds have every required value and a
+// private key that signs a valid App JWT. It returns the signed JWT.
+func ValidateCredentials(creds *AppCredentials) (string, error) {
+	if creds == nil {
+		return "", fmt.Errorf("%w: no credentials", ErrInvalidCredentials)
+	}
+	if creds.AppID <= 0 {
+		return "", fmt.Errorf("%w: app id must be positive, got %d", ErrInvalidCredentials, creds.AppID)
+	}
+	values := credentialValues(creds)
+	for _, key := range requiredKeys {
+		if strings.TrimSpace(values[key]) == "" {
+			return "", fmt.Errorf("%w: %s is empty", ErrInvalidCredentials, key)
+		}
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(creds.PrivateKey))
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to parse private key: %w", ErrInvalidCredentials, err)
+	}
+	if err := key.Validate(); err != nil {
+		return "", fmt.Errorf("%w: private key is inconsistent: %w", ErrInvalidCredentials, err)
+	}
+
+	// Same claims GitHub expects for app authentication: backdated issue time
+	// to allow for clock drift and a lifetime under ten minutes.
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Issuer:    strconv.FormatInt(creds.AppID, 10),
+		IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+		ExpiresAt: jwt.NewNumericDate(now.Add(9 * time.Minute)),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to sign app jwt: %w", ErrInvalidCredentials, err)
+	}
+
+	parsed, err := jwt.ParseWithClaims(token, &jwt.RegisteredClaims{}, func(t *jwt.Token) (any, error) {
+		return &key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}))
+	if err != nil || !parsed.Valid {
+		return "", fmt.Errorf("%w: app jwt does not verify: %w", ErrInvalidCredentials, err)
+	}
+
+	return token, nil
+}
+
+// checkApp calls GET /app with the App JWT and checks the returned app ID.
+func (s *ValidatingStore) checkApp(ctx context.Context, appID int64, token string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.apiURL+"/app", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to validate credentials with github: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: github rejected the app jwt (status %d)", ErrInvalidCredentials, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to validate credentials with github: status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var app struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&app); err != nil {
+		return fmt.Errorf("failed to decode github app response: %w", err)
+	}
+	if app.ID != appID {
+		return fmt.Errorf("%w: private key belongs to app %d, not %d", ErrInvalidCredentials, app.ID, appID)
+	}
+	return nil
+}
+
+// GitHubAPIURL returns the REST API base URL for a GitHub web URL:
+// https://api.github.com for github.com, and <url>/api/v3 for GitHub
+// Enterprise Server.
+func GitHubAPIURL(webURL string) string {
+	webURL = strings.TrimSuffix(webURL, "/")
+	if webURL == "" || webURL == DefaultGitHubURL || webURL == "https://www.github.com" {
+		return "https://api.github.com"
+	}
+	return webURL + "/api/v3"
+}
+
+// newValidatingStoreFromEnv wraps store with validation unless
+// STORAGE_VALIDATE=false. STORAGE_VALIDATE_GITHUB_API=true also checks the
+// credentials against the GitHub API at GITHUB_URL.
+func newValidatingStoreFromEnv(store Store) Store {
+	if isFalseString(GetEnvDefault(EnvStorageValidate, "true")) {
+		return store
+	}
+	var opts []ValidatingStoreOption
+	if strings.EqualFold(GetEnvDefault(EnvStorageValidateGitHubAPI, "false"), "true") {
+		opts = append(opts, WithGitHubAPIValidation(GitHubAPIURL(GetEnvDefault(EnvGitHubURL, DefaultGitHubURL))))
+	}
+	return NewValidatingStore(store, opts...)
+}
@@ -0,0 +1,284 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cruxstack/octo-sts-distros/internal/vault"
+)
+
+// Environment variables for the Vault store.
+const (
+	EnvVaultKVMount = "VAULT_KV_MOUNT"
+	EnvVaultKVPath  = "VAULT_KV_PATH"
+)
+
+// StorageModeVault saves credentials to a HashiCorp Vault KV v2 secret.
+const StorageModeVault = "vault"
+
+// DefaultVaultKVMount is the default KV v2 mount path.
+const DefaultVaultKVMount = "secret"
+
+// VaultClient defines the interface for Vault KV v2 operations.
+type VaultClient interface {
+	ReadKV(ctx context.Context, mount, path string) (map[string]string, error)
+	WriteKV(ctx context.Context, mount, path string, data map[string]string) error
+	DeleteKV(ctx context.Context, mount, path string) error
+	ListKVVersions(ctx context.Context, mount, path string) ([]vault.KVVersion, error)
+	ReadKVVersion(ctx context.Context, mount, path string, version int) (map[string]string, error)
+}
+
+// VaultTokenRenewer is implemented by Vault clients that can renew the
+// lease of their token.
+type VaultTokenRenewer interface {
+	RenewToken(ctx context.Context) (time.Duration, error)
+}
+
+// VaultStore saves credentials to a single HashiCorp Vault KV v2 secret,
+// keyed by the environment variable names.
+type VaultStore struct {
+	Mount         string
+	Path          string
+	WatchInterval time.Duration
+	client        VaultClient
+}
+
+// VaultStoreOption is a functional option for configuring VaultStore.
+type VaultStoreOption func(*VaultStore)
+
+// WithVaultMount sets the KV v2 mount path (defaults to "secret").
+func WithVaultMount(mount string) VaultStoreOption {
+	return func(s *VaultStore) {
+		s.Mount = mount
+	}
+}
+
+// WithVaultWatchInterval sets how often Watch polls the secret (defaults to
+// one minute).
+func WithVaultWatchInterval(interval time.Duration) VaultStoreOption {
+	return func(s *VaultStore) {
+		s.WatchInterval = interval
+	}
+}
+
+// WithVaultClient sets a custom Vault client.
+func WithVaultClient(client VaultClient) VaultStoreOption {
+	return func(s *VaultStore) {
+		s.client = client
+	}
+}
+
+// NewVaultStore creates a new Vault KV v2 backend. Unless a client is
+// supplied, one is created from the VAULT_* environment variables.
+func NewVaultStore(path string, opts ...VaultStoreOption) (*VaultStore, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil, fmt.Errorf("vault secret path cannot be empty")
+	}
+
+	store := &VaultStore{
+		Mount:         DefaultVaultKVMount,
+		Path:          path,
+		WatchInterval: DefaultWatchInterval,
+	}
+
+	for _, opt := range opts {
+		opt(store)
+	}
+
+	if store.client == nil {
+		client, err := vault.NewClientFromEnv()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create vault client: %w", err)
+		}
+		store.client = client
+	}
+
+	return store, nil
+}
+
+// newVaultStoreFromEnv creates a VaultStore from environment variables.
+func newVaultStoreFromEnv() (*VaultStore, error) {
+	path := os.Getenv(EnvVaultKVPath)
+	if path == "" {
+		return nil, fmt.Errorf("%s is required when using %s storage mode", EnvVaultKVPath, StorageModeVault)
+	}
+	interval, err := watchIntervalFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewVaultStore(path,
+		WithVaultMount(GetEnvDefault(EnvVaultKVMount, DefaultVaultKVMount)),
+		WithVaultWatchInterval(interval))
+}
+
+// Save writes credentials to the Vault secret, preserving unrelated keys.
+func (s *VaultStore) Save(ctx context.Context, creds *AppCredentials) error {
+	return s.merge(ctx, credentialValues(creds))
+}
+
+// Status returns the current registration state by reading the Vault secret.
+func (s *VaultStore) Status(ctx context.Context) (*InstallerStatus, error) {
+	values, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return statusFromValues(values), nil
+}
+
+// Load reads the stored credentials from the Vault secret.
+func (s *VaultStore) Load(ctx context.Context) (*AppCredentials, error) {
+	values, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return credentialsFromValues(values)
+}
+
+// DisableInstaller sets GITHUB_APP_INSTALLER_ENABLED=false in the Vault secret.
+func (s *VaultStore) DisableInstaller(ctx context.Context) error {
+	return s.merge(ctx, map[string]string{EnvGitHubAppInstallerEnabled: "false"})
+}
+
+// Delete permanently deletes the Vault secret, including all of its versions.
+func (s *VaultStore) Delete(ctx context.Context) error {
+	if err := s.client.DeleteKV(ctx, s.Mount, s.Path); err != nil {
+		return fmt.Errorf("failed to delete vault secret %s/%s: %w", s.Mount, s.Path, err)
+	}
+	return nil
+}
+
+// Ping reads the Vault secret, which also checks authentication.
+func (s *VaultStore) Ping(ctx context.Context) error {
+	_, err := s.read(ctx)
+	return err
+}
+
+// Watch polls the Vault secret every WatchInterval. While watching, the
+// client token's lease is renewed at half its TTL so a long-running process
+// keeps access to the secret.
+func (s *VaultStore) Watch(ctx context.Context) (<-chan struct{}, error) {
+	interval := s.WatchInterval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	if renewer, ok := s.client.(VaultTokenRenewer); ok {
+		go renewVaultToken(ctx, renewer, interval)
+	}
+	return pollChanges(ctx, interval, func(ctx context.Context) (string, error) {
+		values, err := s.read(ctx)
+		if err != nil {
+			return "", err
+		}
+		return fingerprint(values), nil
+	}), nil
+}
+
+// renewVaultToken renews the token lease at half its TTL until ctx is done
+// or the token turns out not to be renewable. Failed renewals are retried
+// every retry interval.
+func renewVaultToken(ctx context.Context, renewer VaultTokenRenewer, retry time.Duration) {
+	for {
+		ttl, err := renewer.RenewToken(ctx)
+		wait := ttl / 2
+		switch {
+		case err != nil:
+			wait = retry
+		case ttl <= 0:
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Rotate writes creds as a single new version of the Vault secret, dropping
+// credential keys that creds does not set.
+func (s *VaultStore) Rotate(ctx context.Context, creds *AppCredentials) error {
+	values := credentialValues(creds)
+	return s.merge(ctx, values, staleKeys(values)...)
+}
+
+// History returns the retained KV v2 versions of the secret, identified by
+// their Vault version number. Deleted and destroyed versions are skipped.
+func (s *VaultStore) History(ctx context.Context) ([]CredentialVersion, error) {
+	kvVersions, err := s.client.ListKVVersions(ctx, s.Mount, s.Path)
+	if errors.Is(err, vault.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions of vault secret %s/%s: %w", s.Mount, s.Path, err)
+	}
+
+	var versions []CredentialVersion
+	for i, kv := range kvVersions {
+		if kv.Deleted {
+			continue
+		}
+		values, err := s.client.ReadKVVersion(ctx, s.Mount, s.Path, kv.Version)
+		if errors.Is(err, vault.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read version %d of vault secret %s/%s: %w", kv.Version, s.Mount, s.Path, err)
+		}
+		creds, err := credentialsFromValues(values)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, CredentialVersion{
+			ID:          strconv.Itoa(kv.Version),
+			CreatedAt:   kv.CreatedAt,
+			Current:     i == 0,
+			Credentials: creds,
+		})
+	}
+
+	return compactHistory(versions), nil
+}
+
+// Rollback writes the given version's credentials as a new version.
+func (s *VaultStore) Rollback(ctx context.Context, id string) error {
+	return rollback(ctx, s, id)
+}
+
+// merge writes values into the secret as a new version, keeping existing
+// keys other than those listed in remove. Nothing is written if the secret
+// already holds the result.
+func (s *VaultStore) merge(ctx context.Context, values map[string]string, remove ...string) error {
+	existing, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+	if !mergeValues(existing, values, remove) {
+		return nil
+	}
+	if err := s.client.WriteKV(ctx, s.Mount, s.Path, existing); err != nil {
+		return fmt.Errorf("failed to write vault secret %s/%s: %w", s.Mount, s.Path, err)
+	}
+	return nil
+}
+
+// read returns the secret values, or an empty map if the secret doesn't exist.
+func (s *VaultStore) read(ctx context.Context) (map[string]string, error) {
+	values, err := s.client.ReadKV(ctx, s.Mount, s.Path)
+	if errors.Is(err, vault.ErrNotFound) {
+		return make(map[string]string), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault secret %s/%s: %w", s.Mount, s.Path, err)
+	}
+	return values, nil
+}
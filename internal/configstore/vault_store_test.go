@@ -0,0 +1,153 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cruxstack/octo-sts-distros/internal/vault"
+)
+
+// fakeVaultServer serves the subset of the Vault API used by the store:
+// AppRole login and KV v2 reads and writes.
+type fakeVaultServer struct {
+	mu      sync.Mutex
+	secrets map[string]map[string]string
+	logins  int
+}
+
+func (f *fakeVaultServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/v1/auth/approle/login" {
+		f.logins++
+		json.NewEncoder(w).Encode(map[string]any{"auth": map[string]any{"client_token": "s.test"}})
+		return
+	}
+	if r.Header.Get("X-Vault-Token") != "s.test" {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]any{"errors": []string{"permission denied"}})
+		return
+	}
+
+	path := strings.Replace(strings.TrimPrefix(r.URL.Path, "/v1/"), "/data/", "/", 1)
+	switch r.Method {
+	case http.MethodGet:
+		data, ok := f.secrets[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": data}})
+	case http.MethodPost:
+		var body struct {
+			Data map[string]string `json:"data"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.secrets[path] = body.Data
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":{"version":1}}`))
+	}
+}
+
+func TestVaultStore(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeVaultServer{
+		secrets: map[string]map[string]string{
+			"kv/octo-sts": {"UNRELATED": "keep"},
+		},
+	}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	client, err := vault.NewClient(srv.URL, vault.AppRoleAuth{RoleID: "role", SecretID: "secret"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	store, err := NewVaultStore("/octo-sts/", WithVaultMount("kv"), WithVaultClient(client))
+	if err != nil {
+		t.Fatalf("NewVaultStore() error = %v", err)
+	}
+
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	stored := fake.secrets["kv/octo-sts"]
+	if stored["UNRELATED"] != "keep" {
+		t.Error("expected existing keys to be preserved")
+	}
+	if stored[EnvGitHubAppPrivateKey] != testAppCredentials().PrivateKey {
+		t.Errorf("unexpected private key: %q", stored[EnvGitHubAppPrivateKey])
+	}
+	if fake.logins != 1 {
+		t.Errorf("expected a single login, got %d", fake.logins)
+	}
+
+	status, err := store.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if !status.Registered || status.AppID != 1234 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	if err := store.DisableInstaller(ctx); err != nil {
+		t.Fatalf("DisableInstaller() error = %v", err)
+	}
+	if status, _ := store.Status(ctx); !status.InstallerDisabled {
+		t.Error("expected installer to be disabled")
+	}
+}
+
+func TestVaultStoreMissingSecret(t *testing.T) {
+	srv := httptest.NewServer(&fakeVaultServer{secrets: map[string]map[string]string{}})
+	defer srv.Close()
+
+	client, err := vault.NewClient(srv.URL, vault.TokenAuth{Token: "s.test"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	store, err := NewVaultStore("octo-sts", WithVaultClient(client))
+	if err != nil {
+		t.Fatalf("NewVaultStore() error = %v", err)
+	}
+
+	status, err := store.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.Registered {
+		t.Error("expected missing secret to be unregistered")
+	}
+	if _, err := store.Load(context.Background()); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("Load() of a missing secret error = %v, want ErrNotRegistered", err)
+	}
+}
+
+func TestVaultStoreRejectedToken(t *testing.T) {
+	srv := httptest.NewServer(&fakeVaultServer{secrets: map[string]map[string]string{}})
+	defer srv.Close()
+
+	client, err := vault.NewClient(srv.URL, vault.TokenAuth{Token: "s.wrong"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	store, err := NewVaultStore("octo-sts", WithVaultClient(client))
+	if err != nil {
+		t.Fatalf("NewVaultStore() error = %v", err)
+	}
+
+	if _, err := store.Status(context.Background()); err == nil {
+		t.Fatal("expected permission error")
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Environment variables for watching stores for changes.
+const (
+	EnvStorageWatch         = "STORAGE_WATCH"
+	EnvStorageWatchInterval = "STORAGE_WATCH_INTERVAL"
+)
+
+// DefaultWatchInterval is how often stores without change notifications
+// (AWS SSM, Vault) are polled.
+const DefaultWatchInterval = time.Minute
+
+// watchDebounce is how long file watches wait for writes to settle, so a
+// save touching several files triggers a single check.
+const watchDebounce = 250 * time.Millisecond
+
+// ErrWatchNotSupported is returned by Watch for stores that can't report changes.
+var ErrWatchNotSupported = errors.New("store does not support watching for changes")
+
+// WatchableStore is a Store that reports changes made to the credentials by
+// other processes, such as an out-of-band rotation.
+type WatchableStore interface {
+	Store
+
+	// Watch returns a channel that receives a value whenever the stored
+	// credentials change. Changes made while a value is pending are
+	// coalesced. The channel is closed when ctx is done.
+	Watch(ctx context.Context) (<-chan struct{}, error)
+}
+
+// Watch watches store for changes. Returns ErrWatchNotSupported if the store
+// can't report them.
+func Watch(ctx context.Context, store Store) (<-chan struct{}, error) {
+	if ws, ok := store.(WatchableStore); ok {
+		return ws.Watch(ctx)
+	}
+	return nil, ErrWatchNotSupported
+}
+
+// WatchEnabled reports whether STORAGE_WATCH allows watching the store for
+// changes (the default).
+func WatchEnabled() bool {
+	enabled, err := strconv.ParseBool(GetEnvDefault(EnvStorageWatch, "true"))
+	return err != nil || enabled
+}
+
+// watchIntervalFromEnv returns the polling interval from STORAGE_WATCH_INTERVAL.
+func watchIntervalFromEnv() (time.Duration, error) {
+	value := os.Getenv(EnvStorageWatchInterval)
+	if value == "" {
+		return DefaultWatchInterval, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		return 0, fmt.Errorf("invalid %s: %q", EnvStorageWatchInterval, value)
+	}
+	return interval, nil
+}
+
+// snapshotFunc returns a value that changes whenever the stored credentials do.
+type snapshotFunc func(ctx context.Context) (string, error)
+
+// watchChanges takes a snapshot after each trigger, once no further trigger
+// has arrived for delay, and reports snapshots that differ from the last
+// one. Failed snapshots are skipped, so the next trigger tries again. The
+// returned channel is closed when ctx is done or trigger is closed.
+func watchChanges[T any](ctx context.Context, trigger <-chan T, delay time.Duration, snapshot snapshotFunc) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	last, err := snapshot(ctx)
+	known := err == nil
+
+	go func() {
+		defer close(changes)
+
+		timer := time.NewTimer(delay)
+		timer.Stop()
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-trigger:
+				if !ok {
+					return
+				}
+				timer.Reset(delay)
+			case <-timer.C:
+				current, err := snapshot(ctx)
+				if err != nil {
+					continue
+				}
+				if known && current != last {
+					select {
+					case changes <- struct{}{}:
+					default:
+					}
+				}
+				last, known = current, true
+			}
+		}
+	}()
+
+	return changes
+}
+
+// pollChanges checks for changes every interval.
+func pollChanges(ctx context.Context, interval time.Duration, snapshot snapshotFunc) <-chan struct{} {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	context.AfterFunc(ctx, ticker.Stop)
+	return watchChanges(ctx, ticker.C, 0, snapshot)
+}
+
+// watchDir checks for changes whenever an entry in dir accepted by match is
+// written, created, renamed, or removed. dir is created if it doesn't exist.
+func watchDir(ctx context.Context, dir string, match func(name string) bool, snapshot snapshotFunc) (<-chan struct{}, error) {
+	return watchPaths(ctx, dir, "", match, snapshot)
+}
+
+// watchFile checks for changes whenever the file at path is written,
+// created, renamed, or removed. The file is watched through its directory
+// and by itself: a file bind-mounted into a container, such as an .env file
+// mounted by Docker Compose, is written through a directory outside the
+// container, so only the file's own watch sees the writes. The file's watch
+// is renewed whenever the file is replaced.
+func watchFile(ctx context.Context, path string, snapshot snapshotFunc) (<-chan struct{}, error) {
+	name := filepath.Base(path)
+	return watchPaths(ctx, filepath.Dir(path), path, func(n string) bool { return n == name }, snapshot)
+}
+
+// watchPaths watches dir for entries accepted by match, and file, if set,
+// by itself.
+func watchPaths(ctx context.Context, dir, file string, match func(name string) bool, snapshot snapshotFunc) (<-chan struct{}, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	if file != "" {
+		// The file may not exist yet; its creation renews the watch
+		_ = watcher.Add(file)
+	}
+
+	trigger := make(chan struct{}, 1)
+	go func() {
+		defer close(trigger)
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Has(fsnotify.Chmod) || !match(filepath.Base(event.Name)) {
+					continue
+				}
+				if file != "" && event.Has(fsnotify.Create) {
+					_ = watcher.Add(file)
+				}
+				select {
+				case trigger <- struct{}{}:
+				default:
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	context.AfterFunc(ctx, func() { watcher.Close() })
+
+	return watchChanges(ctx, trigger, watchDebounce, snapshot), nil
+}
+
+// storeSnapshot fingerprints the credentials and installer flag of store.
+func storeSnapshot(store Store) snapshotFunc {
+	return func(ctx context.Context) (string, error) {
+		values := make(map[string]string)
+		creds, err := store.Load(ctx)
+		switch {
+		case err == nil:
+			values = credentialValues(creds)
+		case !errors.Is(err, ErrNotRegistered):
+			return "", err
+		}
+		status, err := store.Status(ctx)
+		if err != nil {
+			return "", err
+		}
+		if status.InstallerDisabled {
+			values[EnvGitHubAppInstallerEnabled] = "false"
+		}
+		return fingerprint(values), nil
+	}
+}
+
+// fingerprint returns a digest of values.
+func fingerprint(values map[string]string) string {
+	h := sha256.New()
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		fmt.Fprintf(h, "%s=%q\n", key, values[key])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,182 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package configstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var current atomic.Value
+	current.Store("v1")
+	snapshots := make(chan struct{}, 10)
+	snapshot := func(context.Context) (string, error) {
+		snapshots <- struct{}{}
+		return current.Load().(string), nil
+	}
+
+	trigger := make(chan struct{})
+	changes := watchChanges(ctx, trigger, 0, snapshot)
+	<-snapshots
+
+	trigger <- struct{}{}
+	<-snapshots
+	select {
+	case <-changes:
+		t.Fatal("expected no change for an identical snapshot")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	current.Store("v2")
+	trigger <- struct{}{}
+	waitForChange(t, changes)
+
+	cancel()
+	if _, ok := <-changes; ok {
+		t.Error("expected changes to be closed after cancel")
+	}
+}
+
+func TestLocalStoresWatch(t *testing.T) {
+	dir := t.TempDir()
+	for _, tt := range []struct {
+		name  string
+		store func() Store
+	}{
+		{"files", func() Store { return NewLocalFileStore(filepath.Join(dir, "files")) }},
+		{"envfile", func() Store { return NewLocalEnvFileStore(filepath.Join(dir, "env", "app.env")) }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if err := tt.store().Save(ctx, testAppCredentials()); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			changes, err := Watch(ctx, tt.store())
+			if err != nil {
+				t.Fatalf("Watch() error = %v", err)
+			}
+
+			rotated := testAppCredentials()
+			rotated.WebhookSecret = "rotated-secret"
+			if err := tt.store().Rotate(ctx, rotated); err != nil {
+				t.Fatalf("Rotate() error = %v", err)
+			}
+			waitForChange(t, changes)
+		})
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("A=1\n")
+	snapshot := func(context.Context) (string, error) {
+		b, err := os.ReadFile(path)
+		return string(b), err
+	}
+	changes, err := watchFile(ctx, path, snapshot)
+	if err != nil {
+		t.Fatalf("watchFile() error = %v", err)
+	}
+
+	// An edit in place
+	write("A=2\n")
+	waitForChange(t, changes)
+
+	// A replacement, as editors and config management tools save
+	tmp := filepath.Join(dir, ".env.tmp")
+	if err := os.WriteFile(tmp, []byte("A=3\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	waitForChange(t, changes)
+
+	// An edit in place of the replaced file
+	write("A=4\n")
+	waitForChange(t, changes)
+
+	// Other files in the directory are ignored
+	if err := os.WriteFile(filepath.Join(dir, "other"), []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changes:
+		t.Error("expected no change for another file")
+	case <-time.After(2 * watchDebounce):
+	}
+}
+
+func TestAWSSSMStoreSnapshot(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewAWSSSMStore("/octo-sts", WithSSMClient(&fakeSSM{params: map[string]string{}}))
+	if err != nil {
+		t.Fatalf("NewAWSSSMStore() error = %v", err)
+	}
+	if err := store.Save(ctx, testAppCredentials()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	before, err := store.snapshot(ctx)
+	if err != nil {
+		t.Fatalf("snapshot() error = %v", err)
+	}
+	if after, _ := store.snapshot(ctx); after != before {
+		t.Error("expected snapshot to be stable without writes")
+	}
+
+	rotated := testAppCredentials()
+	rotated.WebhookSecret = "rotated-secret"
+	if err := store.Rotate(ctx, rotated); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if after, _ := store.snapshot(ctx); after == before {
+		t.Error("expected snapshot to change after a rotation")
+	}
+}
+
+func TestMultiStoreWatchUnsupported(t *testing.T) {
+	store, err := NewMultiStore(StoreBackend{Name: "plain", Store: plainStore{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Watch(context.Background()); !errors.Is(err, ErrWatchNotSupported) {
+		t.Errorf("expected ErrWatchNotSupported, got %v", err)
+	}
+}
+
+// plainStore is a Store that can't be watched.
+type plainStore struct {
+	Store
+}
+
+func waitForChange(t *testing.T, changes <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a change")
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package consul provides a minimal Consul KV client.
+package consul
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Environment variables for Consul configuration, matching the consul CLI.
+const (
+	EnvConsulHTTPAddr  = "CONSUL_HTTP_ADDR"
+	EnvConsulHTTPToken = "CONSUL_HTTP_TOKEN"
+	EnvConsulNamespace = "CONSUL_NAMESPACE"
+)
+
+// DefaultAddr is the default Consul agent address.
+const DefaultAddr = "http://127.0.0.1:8500"
+
+// DefaultTimeout is the default timeout for Consul API requests.
+const DefaultTimeout = 10 * time.Second
+
+// Client is a minimal Consul KV client.
+type Client struct {
+	Address   string
+	Namespace string
+
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the agent at address, authenticated with
+// the given ACL token (which may be empty).
+func NewClient(address, token string) (*Client, error) {
+	if address == "" {
+		return nil, fmt.Errorf("consul address cannot be empty")
+	}
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+	return &Client{
+		Address:    strings.TrimSuffix(address, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+	}, nil
+}
+
+// NewClientFromEnv creates a client from CONSUL_HTTP_ADDR (default
+// http://127.0.0.1:8500), CONSUL_HTTP_TOKEN, and CONSUL_NAMESPACE.
+func NewClientFromEnv() (*Client, error) {
+	addr := os.Getenv(EnvConsulHTTPAddr)
+	if addr == "" {
+		addr = DefaultAddr
+	}
+	client, err := NewClient(addr, os.Getenv(EnvConsulHTTPToken))
+	if err != nil {
+		return nil, err
+	}
+	client.Namespace = os.Getenv(EnvConsulNamespace)
+	return client, nil
+}
+
+// List returns all keys under prefix with their values.
+func (c *Client) List(ctx context.Context, prefix string) (map[string][]byte, error) {
+	var entries []struct {
+		Key   string `json:"Key"`
+		Value []byte `json:"Value"`
+	}
+	err := c.do(ctx, http.MethodGet, prefix, url.Values{"recurse": {"true"}}, nil, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		values[entry.Key] = entry.Value
+	}
+	return values, nil
+}
+
+// Put writes a key.
+func (c *Client) Put(ctx context.Context, key string, value []byte) error {
+	var ok bool
+	if err := c.do(ctx, http.MethodPut, key, nil, value, &ok); err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("consul rejected write to %s", key)
+	}
+	return nil
+}
+
+// Delete removes a key. Deleting a missing key is not an error.
+func (c *Client) Delete(ctx context.Context, key string) error {
+	return c.do(ctx, http.MethodDelete, key, nil, nil, nil)
+}
+
+// do sends a KV API request for key. Each segment of the key is escaped,
+// keeping the slashes that separate them.
+func (c *Client) do(ctx context.Context, method, key string, query url.Values, body []byte, out any) error {
+	segments := strings.Split(strings.TrimPrefix(key, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	req, err := c.newRequest(ctx, method, "/v1/kv/"+strings.Join(segments, "/"), query, body)
+	if err != nil {
+		return err
+	}
+	return c.send(req, out)
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Request, error) {
+	if c.Namespace != "" {
+		if query == nil {
+			query = url.Values{}
+		}
+		query.Set("ns", c.Namespace)
+	}
+
+	endpoint := c.Address + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+	return req, nil
+}
+
+func (c *Client) send(req *http.Request, out any) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("consul request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// A recursive read of a missing prefix returns 404; treat it as empty.
+	if resp.StatusCode == http.StatusNotFound && req.Method == http.MethodGet {
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if len(bytes.TrimSpace(msg)) > 0 {
+			return fmt.Errorf("consul returned status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+		}
+		return fmt.Errorf("consul returned status %d", resp.StatusCode)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode consul response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestClient returns a client of a server answering with handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	t.Setenv(EnvConsulHTTPAddr, strings.TrimPrefix(srv.URL, "http://"))
+	t.Setenv(EnvConsulHTTPToken, "acl-token")
+	t.Setenv(EnvConsulNamespace, "team")
+	c, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("NewClientFromEnv() error = %v", err)
+	}
+	return c
+}
+
+func TestRequests(t *testing.T) {
+	var put string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Consul-Token"); got != "acl-token" {
+			t.Errorf("X-Consul-Token = %q, want the ACL token", got)
+		}
+		if got := r.URL.Query().Get("ns"); got != "team" {
+			t.Errorf("ns = %q, want the namespace", got)
+		}
+		switch r.Method {
+		case http.MethodGet:
+			if r.URL.Path != "/v1/kv/octo-sts/" || r.URL.Query().Get("recurse") != "true" {
+				t.Errorf("list request = %s, want a recursive read of the prefix", r.URL)
+			}
+			json.NewEncoder(w).Encode([]map[string]any{{"Key": "octo-sts/GITHUB_APP_ID", "Value": []byte("123")}})
+		case http.MethodPut:
+			if want := "/v1/kv/octo-sts/app%3Fid%231%25"; r.URL.EscapedPath() != want {
+				t.Errorf("put path = %q, want %q", r.URL.EscapedPath(), want)
+			}
+			body, _ := io.ReadAll(r.Body)
+			put = string(body)
+			w.Write([]byte("true"))
+		}
+	})
+	ctx := context.Background()
+
+	values, err := c.List(ctx, "/octo-sts/")
+	if err != nil || string(values["octo-sts/GITHUB_APP_ID"]) != "123" {
+		t.Errorf("List() = %q, %v, want the decoded value", values, err)
+	}
+	if err := c.Put(ctx, "octo-sts/app?id#1%", []byte("value")); err != nil || put != "value" {
+		t.Errorf("Put() error = %v with body %q, want the raw value written", err, put)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	ctx := context.Background()
+
+	values, err := c.List(ctx, "octo-sts/")
+	if err != nil || len(values) != 0 {
+		t.Errorf("List() of a missing prefix = %v, %v, want no values", values, err)
+	}
+	if err := c.Put(ctx, "octo-sts/key", nil); err == nil || err.Error() != "consul returned status 404" {
+		t.Errorf("Put() error = %v, want the status", err)
+	}
+}
+
+func TestErrorBody(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			// A failed check-and-set or a session lock answers false
+			w.Write([]byte("false"))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("Permission denied: token with AccessorID 'abc' lacks permission 'key:read'\n"))
+	})
+	ctx := context.Background()
+
+	_, err := c.List(ctx, "octo-sts/")
+	if err == nil || err.Error() != "consul returned status 403: Permission denied: token with AccessorID 'abc' lacks permission 'key:read'" {
+		t.Errorf("List() error = %v, want the status and trimmed body", err)
+	}
+	if err := c.Put(ctx, "octo-sts/key", nil); err == nil || !strings.Contains(err.Error(), "rejected write") {
+		t.Errorf("Put() error = %v, want the rejected write", err)
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package docker provides a minimal Docker Engine API client for managing
+// swarm secrets. It also works against Podman's Docker-compatible API.
+package docker
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Environment variables for Docker client configuration, matching the docker CLI.
+const (
+	EnvDockerHost      = "DOCKER_HOST"
+	EnvDockerCertPath  = "DOCKER_CERT_PATH"
+	EnvDockerTLSVerify = "DOCKER_TLS_VERIFY"
+)
+
+// DefaultHost is the default Docker Engine socket.
+const DefaultHost = "unix:///var/run/docker.sock"
+
+// APIVersion is the Engine API version requested. Secrets are available
+// from 1.25; Podman's compatible API accepts it as well.
+const APIVersion = "v1.41"
+
+// DefaultTimeout is the default timeout for Engine API requests.
+const DefaultTimeout = 10 * time.Second
+
+// ErrNotFound is returned when a secret does not exist.
+var ErrNotFound = errors.New("docker secret not found")
+
+// Secret describes a swarm secret. The Engine API never returns secret data.
+type Secret struct {
+	ID     string
+	Name   string
+	Labels map[string]string
+}
+
+// Client is a minimal Docker Engine API client.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClientFromEnv creates a client for DOCKER_HOST (default
+// unix:///var/run/docker.sock). TLS is used for tcp hosts when
+// DOCKER_TLS_VERIFY is set, with certificates from DOCKER_CERT_PATH.
+func NewClientFromEnv() (*Client, error) {
+	host := os.Getenv(EnvDockerHost)
+	if host == "" {
+		host = DefaultHost
+	}
+
+	var tlsConfig *tls.Config
+	if os.Getenv(EnvDockerTLSVerify) != "" {
+		var err error
+		if tlsConfig, err = loadTLSConfig(os.Getenv(EnvDockerCertPath)); err != nil {
+			return nil, err
+		}
+	}
+	return NewClient(host, tlsConfig)
+}
+
+// NewClient creates a client for a unix:// or tcp:// host.
+func NewClient(host string, tlsConfig *tls.Config) (*Client, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", host, err)
+	}
+
+	transport := &http.Transport{TLSClientConfig: tlsConfig}
+	c := &Client{httpClient: &http.Client{Timeout: DefaultTimeout, Transport: transport}}
+
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
+		c.baseURL = "http://docker"
+	case "tcp", "http", "https":
+		scheme := "http"
+		if tlsConfig != nil || u.Scheme == "https" {
+			scheme = "https"
+		}
+		c.baseURL = scheme + "://" + u.Host
+	default:
+		return nil, fmt.Errorf("unsupported docker host scheme %q", u.Scheme)
+	}
+
+	return c, nil
+}
+
+func loadTLSConfig(certPath string) (*tls.Config, error) {
+	if certPath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		certPath = filepath.Join(home, ".docker")
+	}
+
+	ca, err := os.ReadFile(filepath.Join(certPath, "ca.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read docker CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("invalid docker CA certificate")
+	}
+
+	cert, err := tls.LoadX509KeyPair(filepath.Join(certPath, "cert.pem"), filepath.Join(certPath, "key.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load docker client certificate: %w", err)
+	}
+
+	return &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+}
+
+// GetSecret looks up a secret by name. Returns ErrNotFound if it does not exist.
+func (c *Client) GetSecret(ctx context.Context, name string) (*Secret, error) {
+	filters, err := json.Marshal(map[string][]string{"name": {name}})
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets []struct {
+		ID   string `json:"ID"`
+		Spec struct {
+			Name   string            `json:"Name"`
+			Labels map[string]string `json:"Labels"`
+		} `json:"Spec"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/secrets?filters="+url.QueryEscape(string(filters)), nil, &secrets); err != nil {
+		return nil, err
+	}
+
+	// The name filter matches prefixes, so look for an exact match.
+	for _, s := range secrets {
+		if s.Spec.Name == name {
+			return &Secret{ID: s.ID, Name: s.Spec.Name, Labels: s.Spec.Labels}, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// CreateSecret creates a secret and returns its ID.
+func (c *Client) CreateSecret(ctx context.Context, name string, data []byte, labels map[string]string) (string, error) {
+	body := map[string]any{
+		"Name":   name,
+		"Data":   base64.StdEncoding.EncodeToString(data),
+		"Labels": labels,
+	}
+	var resp struct {
+		ID string `json:"ID"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/secrets/create", body, &resp); err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+// RemoveSecret removes a secret by ID or name. Secrets in use by a service
+// cannot be removed.
+func (c *Client) RemoveSecret(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/secrets/"+url.PathEscape(id), nil, nil)
+}
+
+// do sends a request to the Engine API.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+"/"+APIVersion+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("docker request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errBody struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&errBody) == nil && errBody.Message != "" {
+			return fmt.Errorf("docker returned status %d: %s", resp.StatusCode, strings.TrimSpace(errBody.Message))
+		}
+		return fmt.Errorf("docker returned status %d", resp.StatusCode)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode docker response: %w", err)
+	}
+	return nil
+}
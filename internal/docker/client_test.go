@@ -0,0 +1,189 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package docker
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client of a server answering with handler over a
+// unix socket, as with the default DOCKER_HOST.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(handler)
+	srv.Listener = l
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	t.Setenv(EnvDockerHost, "unix://"+socket)
+	t.Setenv(EnvDockerTLSVerify, "")
+	c, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("NewClientFromEnv() error = %v", err)
+	}
+	return c
+}
+
+// writePEM writes a PEM block to dir/name.
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTLSClientCertificate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	clientCert, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _ := x509.ParseCertificate(clientCert)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(parsed)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"ID":"abc","Spec":{"Name":"octo-sts"}}]`))
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+
+	certPath := t.TempDir()
+	writePEM(t, certPath, "ca.pem", "CERTIFICATE", srv.Certificate().Raw)
+	writePEM(t, certPath, "cert.pem", "CERTIFICATE", clientCert)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	writePEM(t, certPath, "key.pem", "EC PRIVATE KEY", keyDER)
+
+	t.Setenv(EnvDockerHost, "tcp://"+srv.Listener.Addr().String())
+	t.Setenv(EnvDockerTLSVerify, "1")
+	t.Setenv(EnvDockerCertPath, certPath)
+	c, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("NewClientFromEnv() error = %v", err)
+	}
+	if secret, err := c.GetSecret(context.Background(), "octo-sts"); err != nil || secret.ID != "abc" {
+		t.Errorf("GetSecret() = %+v, %v, want abc over TLS", secret, err)
+	}
+
+	t.Setenv(EnvDockerCertPath, t.TempDir())
+	if _, err := NewClientFromEnv(); err == nil || !strings.Contains(err.Error(), "docker CA") {
+		t.Errorf("NewClientFromEnv() without certificates error = %v, want the CA error", err)
+	}
+}
+
+func TestSecretRequests(t *testing.T) {
+	var created map[string]any
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			var filters map[string][]string
+			if err := json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters); err != nil || filters["name"][0] != "octo sts&app" {
+				t.Errorf("filters = %q, want the name filter escaped", r.URL.Query().Get("filters"))
+			}
+			// The name filter matches prefixes
+			w.Write([]byte(`[{"ID":"other","Spec":{"Name":"octo sts&app-old"}},{"ID":"abc","Spec":{"Name":"octo sts&app","Labels":{"app":"octo-sts"}}}]`))
+		case http.MethodPost:
+			if r.URL.Path != "/"+APIVersion+"/secrets/create" {
+				t.Errorf("create path = %q", r.URL.Path)
+			}
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"ID":"def"}`))
+		case http.MethodDelete:
+			if r.URL.EscapedPath() != "/"+APIVersion+"/secrets/a%2Fb" {
+				t.Errorf("remove path = %q, want the ID escaped", r.URL.EscapedPath())
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	ctx := context.Background()
+
+	secret, err := c.GetSecret(ctx, "octo sts&app")
+	if err != nil || secret.ID != "abc" || secret.Labels["app"] != "octo-sts" {
+		t.Errorf("GetSecret() = %+v, %v, want the exact match", secret, err)
+	}
+
+	id, err := c.CreateSecret(ctx, "octo-sts", []byte("value"), map[string]string{"app": "octo-sts"})
+	if err != nil || id != "def" {
+		t.Errorf("CreateSecret() = %q, %v, want def", id, err)
+	}
+	if created["Name"] != "octo-sts" || created["Data"] != base64.StdEncoding.EncodeToString([]byte("value")) {
+		t.Errorf("created secret = %v, want the name and base64 data", created)
+	}
+
+	if err := c.RemoveSecret(ctx, "a/b"); err != nil {
+		t.Errorf("RemoveSecret() error = %v", err)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`[{"ID":"other","Spec":{"Name":"octo-sts-old"}}]`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"secret abc not found"}`))
+	})
+	ctx := context.Background()
+
+	if _, err := c.GetSecret(ctx, "octo-sts"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetSecret() without an exact match error = %v, want ErrNotFound", err)
+	}
+	if err := c.RemoveSecret(ctx, "abc"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RemoveSecret() of a missing secret error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	body := `{"message":"This node is not a swarm manager.\n"}`
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(body))
+	})
+
+	_, err := c.GetSecret(context.Background(), "octo-sts")
+	if err == nil || err.Error() != "docker returned status 503: This node is not a swarm manager." {
+		t.Errorf("GetSecret() error = %v, want the status and trimmed message", err)
+	}
+
+	body = "service unavailable"
+	_, err = c.GetSecret(context.Background(), "octo-sts")
+	if err == nil || err.Error() != "docker returned status 503" {
+		t.Errorf("GetSecret() with a non-JSON body error = %v, want the status only", err)
+	}
+}
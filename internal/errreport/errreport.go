@@ -0,0 +1,266 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package errreport reports panics and server errors to Sentry, or to any
+// service that accepts Sentry events such as GlitchTip, with a stack trace
+// and the context of the request that failed. Reporting is enabled by
+// SENTRY_DSN; without it, every function here is a no-op.
+//
+// Reports never carry secrets: request bodies, cookies, and credential
+// headers are left out, and query parameters that may hold a credential
+// are filtered.
+package errreport
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/getsentry/sentry-go"
+
+	"github.com/cruxstack/octo-sts-distros/internal/version"
+)
+
+// Environment variables that configure error reporting.
+const (
+	// EnvDSN is the Sentry DSN to report to. Reporting is disabled when it
+	// is unset.
+	EnvDSN = "SENTRY_DSN"
+
+	// EnvEnvironment is the environment of the reports, e.g. "production".
+	EnvEnvironment = "SENTRY_ENVIRONMENT"
+
+	// EnvRelease is the release of the reports (default: the version of the
+	// build).
+	EnvRelease = "SENTRY_RELEASE"
+
+	// EnvSampleRate is the fraction of errors reported, from 0 to 1
+	// (default: 1).
+	EnvSampleRate = "SENTRY_SAMPLE_RATE"
+)
+
+// FlushTimeout bounds how long Flush waits for the reports to be sent.
+const FlushTimeout = 2 * time.Second
+
+// Config configures error reporting.
+type Config struct {
+	DSN         string
+	Environment string
+	Release     string
+	SampleRate  float64
+}
+
+// ConfigFromEnv returns the error reporting configuration from the
+// environment, and false if SENTRY_DSN is unset.
+func ConfigFromEnv() (Config, bool, error) {
+	cfg := Config{
+		DSN:         os.Getenv(EnvDSN),
+		Environment: os.Getenv(EnvEnvironment),
+		Release:     os.Getenv(EnvRelease),
+		SampleRate:  1,
+	}
+	if cfg.DSN == "" {
+		return Config{}, false, nil
+	}
+	if _, err := sentry.NewDsn(cfg.DSN); err != nil {
+		// The DSN holds the project key, so it isn't echoed
+		return Config{}, false, fmt.Errorf("invalid %s: %w", EnvDSN, err)
+	}
+	if cfg.Release == "" {
+		cfg.Release = version.Get().Version
+	}
+	if v := os.Getenv(EnvSampleRate); v != "" {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return Config{}, false, fmt.Errorf("invalid %s %q: must be a number from 0 to 1", EnvSampleRate, v)
+		}
+		cfg.SampleRate = rate
+	}
+	return cfg, true, nil
+}
+
+// enabled is set once Init configured a client.
+var enabled atomic.Bool
+
+// Enabled reports whether errors are reported.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Init enables error reporting when SENTRY_DSN is set, tagging the reports
+// with service, e.g. "sts". Call Flush before the process exits.
+func Init(ctx context.Context, service string) error {
+	cfg, ok, err := ConfigFromEnv()
+	if err != nil || !ok {
+		return err
+	}
+	if err := initClient(cfg, service, nil); err != nil {
+		return err
+	}
+	dsn, _ := sentry.NewDsn(cfg.DSN)
+	clog.FromContext(ctx).Infof("[errreport] reporting errors to %s", dsn.GetHost())
+	return nil
+}
+
+// initClient configures the client of cfg, sending with transport if set.
+func initClient(cfg Config, service string, transport sentry.Transport) error {
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:              cfg.DSN,
+		Environment:      cfg.Environment,
+		Release:          cfg.Release,
+		SampleRate:       cfg.SampleRate,
+		AttachStacktrace: true,
+		Transport:        transport,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize error reporting: %w", err)
+	}
+	sentry.CurrentHub().ConfigureScope(func(scope *sentry.Scope) {
+		scope.SetTag("service", service)
+	})
+	enabled.Store(true)
+	return nil
+}
+
+// Flush waits up to FlushTimeout for the reports to be sent.
+func Flush() {
+	if Enabled() {
+		sentry.Flush(FlushTimeout)
+	}
+}
+
+// reportKey is the context key of the report state of a request.
+type reportKey struct{}
+
+// report is the state of a request, shared by the middleware and the
+// handlers it wraps.
+type report struct {
+	// captured is set once an error of the request was reported, so the
+	// error response it produces isn't reported again.
+	captured atomic.Bool
+}
+
+// withReport returns ctx with hub and a new report state.
+func withReport(ctx context.Context, hub *sentry.Hub) (context.Context, *report) {
+	r := &report{}
+	ctx = sentry.SetHubOnContext(ctx, hub)
+	return context.WithValue(ctx, reportKey{}, r), r
+}
+
+// hubFrom returns the hub of the request of ctx, or the global hub.
+func hubFrom(ctx context.Context) *sentry.Hub {
+	if hub := sentry.GetHubFromContext(ctx); hub != nil {
+		return hub
+	}
+	return sentry.CurrentHub()
+}
+
+// CaptureError reports err with the context of the request of ctx. Handlers
+// call it where they turn an error into a server error response, so the
+// report carries the error itself rather than the response.
+func CaptureError(ctx context.Context, err error) {
+	if !Enabled() || err == nil {
+		return
+	}
+	hubFrom(ctx).CaptureException(err)
+	if r, ok := ctx.Value(reportKey{}).(*report); ok {
+		r.captured.Store(true)
+	}
+}
+
+// captured reports whether an error of the request of ctx was reported.
+func captured(ctx context.Context) bool {
+	r, ok := ctx.Value(reportKey{}).(*report)
+	return ok && r.captured.Load()
+}
+
+// reportedStatus reports whether a response with status is reported. Busy
+// and not-yet-ready responses (503) are expected and left out.
+func reportedStatus(status int) bool {
+	return status >= 500 && status != 503
+}
+
+// captureStatus reports a server error response that no handler reported.
+func captureStatus(ctx context.Context, hub *sentry.Hub, status int, method, path, body string) {
+	if captured(ctx) {
+		return
+	}
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetLevel(sentry.LevelError)
+		scope.SetTag("status", strconv.Itoa(status))
+		scope.SetFingerprint([]string{strconv.Itoa(status), method, path})
+		if body != "" {
+			scope.SetExtra("response", body)
+		}
+		hub.CaptureMessage(fmt.Sprintf("%d %s %s", status, method, path))
+	})
+}
+
+// reportedHeaders are the request headers included in reports; the others,
+// e.g. Authorization and the webhook signature, are left out.
+var reportedHeaders = []string{
+	"Content-Length",
+	"Content-Type",
+	"User-Agent",
+	"X-Amzn-Trace-Id",
+	"X-Cloud-Trace-Context",
+	"X-Github-Delivery",
+	"X-Github-Event",
+	"X-Request-Id",
+}
+
+// filteredParams are substrings of the names of query parameters whose
+// values are filtered from reports.
+var filteredParams = []string{"code", "key", "password", "secret", "signature", "state", "token"}
+
+// newRequest returns the request context of a report, without secrets;
+// header returns the value of a header by its canonical name.
+func newRequest(method, scheme, host, path string, query url.Values, header func(string) string) *sentry.Request {
+	req := &sentry.Request{
+		URL:     scheme + "://" + host + path,
+		Method:  method,
+		Headers: map[string]string{},
+	}
+	for _, name := range reportedHeaders {
+		if v := header(name); v != "" {
+			req.Headers[name] = v
+		}
+	}
+	filtered := url.Values{}
+	for name, values := range query {
+		if filteredParam(name) {
+			values = []string{"[Filtered]"}
+		}
+		filtered[name] = values
+	}
+	req.QueryString = filtered.Encode()
+	return req
+}
+
+// filteredParam reports whether the value of the query parameter name is
+// filtered from reports.
+func filteredParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, f := range filteredParams {
+		if strings.Contains(name, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// withRequest returns a clone of the global hub whose reports carry req.
+func withRequest(req *sentry.Request) *sentry.Hub {
+	hub := sentry.CurrentHub().Clone()
+	hub.Scope().AddEventProcessor(func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+		event.Request = req
+		return event
+	})
+	return hub
+}
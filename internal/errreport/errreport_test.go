@@ -0,0 +1,115 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package errreport
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const testDSN = "https://public@o0.ingest.sentry.io/1"
+
+// newTestClient enables reporting to a transport that keeps the reports.
+func newTestClient(t *testing.T) *sentry.MockTransport {
+	t.Helper()
+	transport := &sentry.MockTransport{}
+	if err := initClient(Config{DSN: testDSN, SampleRate: 1}, "sts", transport); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		enabled.Store(false)
+		sentry.CurrentHub().BindClient(nil)
+	})
+	return transport
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    Config
+		wantOK  bool
+		wantErr bool
+	}{
+		{name: "disabled"},
+		{
+			name:   "dsn",
+			env:    map[string]string{EnvDSN: testDSN, EnvEnvironment: "prod", EnvRelease: "v1.2.3"},
+			want:   Config{DSN: testDSN, Environment: "prod", Release: "v1.2.3", SampleRate: 1},
+			wantOK: true,
+		},
+		{
+			name:   "sample rate",
+			env:    map[string]string{EnvDSN: testDSN, EnvRelease: "v1.2.3", EnvSampleRate: "0.25"},
+			want:   Config{DSN: testDSN, Release: "v1.2.3", SampleRate: 0.25},
+			wantOK: true,
+		},
+		{name: "invalid dsn", env: map[string]string{EnvDSN: "not a dsn"}, wantErr: true},
+		{name: "invalid sample rate", env: map[string]string{EnvDSN: testDSN, EnvSampleRate: "2"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, env := range []string{EnvDSN, EnvEnvironment, EnvRelease, EnvSampleRate} {
+				t.Setenv(env, tt.env[env])
+			}
+			got, ok, err := ConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConfigFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ConfigFromEnv() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewRequest(t *testing.T) {
+	headers := map[string]string{
+		"Authorization":       "Bearer eyJhbGciOi",
+		"User-Agent":          "actions/1.0",
+		"X-Hub-Signature-256": "sha256=abc",
+		"X-Github-Event":      "push",
+	}
+	query := url.Values{"scope": {"org/repo"}, "access_token": {"ghs_secret"}, "code": {"abc"}}
+	req := newRequest("GET", "https", "sts.example.com", "/sts/exchange", query, func(name string) string { return headers[name] })
+
+	if req.URL != "https://sts.example.com/sts/exchange" || req.Method != "GET" {
+		t.Errorf("request = %s %s", req.Method, req.URL)
+	}
+	if _, ok := req.Headers["Authorization"]; ok {
+		t.Error("Authorization header reported")
+	}
+	if _, ok := req.Headers["X-Hub-Signature-256"]; ok {
+		t.Error("webhook signature reported")
+	}
+	if req.Headers["User-Agent"] != "actions/1.0" || req.Headers["X-Github-Event"] != "push" {
+		t.Errorf("headers = %v, want the context headers", req.Headers)
+	}
+	if want := "access_token=%5BFiltered%5D&code=%5BFiltered%5D&scope=org%2Frepo"; req.QueryString != want {
+		t.Errorf("QueryString = %q, want %q", req.QueryString, want)
+	}
+}
+
+func TestCaptureError(t *testing.T) {
+	// Disabled reporting drops errors
+	CaptureError(context.Background(), errors.New("dropped"))
+
+	transport := newTestClient(t)
+	ctx, r := withReport(context.Background(), sentry.CurrentHub().Clone())
+	CaptureError(ctx, errors.New("failed to get token"))
+	if !r.captured.Load() {
+		t.Error("request not marked as reported")
+	}
+	events := transport.Events()
+	if len(events) != 1 || events[0].Exception[0].Value != "failed to get token" {
+		t.Fatalf("events = %+v, want the error reported", events)
+	}
+	if events[0].Tags["service"] != "sts" {
+		t.Errorf("tags = %v, want the service", events[0].Tags)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package errreport
+
+import (
+	"net/http"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// maxResponseExcerpt bounds the response body included in the report of a
+// server error.
+const maxResponseExcerpt = 1 << 10
+
+// Handler wraps next to report its panics and server error responses. A
+// panic is answered with a 500 once reported, rather than dropping the
+// connection. Without SENTRY_DSN, next is returned as is.
+func Handler(next http.Handler) http.Handler {
+	if !Enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme := "http"
+		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		hub := withRequest(newRequest(r.Method, scheme, r.Host, r.URL.Path, r.URL.Query(), r.Header.Get))
+		ctx, _ := withReport(r.Context(), hub)
+		rec := &statusRecorder{ResponseWriter: w}
+
+		defer func() {
+			if v := recover(); v != nil {
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				hub.RecoverWithContext(ctx, v)
+				clog.FromContext(ctx).Errorf("[errreport] panic serving %s %s: %v", r.Method, r.URL.Path, v)
+				if rec.status == 0 {
+					http.Error(rec, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+				return
+			}
+			if reportedStatus(rec.status) {
+				captureStatus(ctx, hub, rec.status, r.Method, r.URL.Path, string(rec.excerpt))
+			}
+		}()
+		next.ServeHTTP(rec, r.WithContext(ctx))
+	})
+}
+
+// statusRecorder records the status of a response, and the start of its
+// body if it is a server error.
+type statusRecorder struct {
+	http.ResponseWriter
+	status  int
+	excerpt []byte
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if reportedStatus(w.status) && len(w.excerpt) < maxResponseExcerpt {
+		n := min(len(b), maxResponseExcerpt-len(w.excerpt))
+		w.excerpt = append(w.excerpt, b[:n]...)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
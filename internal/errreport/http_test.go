@@ -0,0 +1,75 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package errreport
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	transport := newTestClient(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panic", func(http.ResponseWriter, *http.Request) { panic("boom") })
+	mux.HandleFunc("/error", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error":"upstream failed"}`, http.StatusBadGateway)
+	})
+	mux.HandleFunc("/captured", func(w http.ResponseWriter, r *http.Request) {
+		CaptureError(r.Context(), errors.New("failed to get token"))
+		http.Error(w, "failed to get token", http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/unavailable", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {})
+	h := Handler(mux)
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantEvent  string
+	}{
+		{path: "/panic", wantStatus: http.StatusInternalServerError, wantEvent: "boom"},
+		{path: "/error", wantStatus: http.StatusBadGateway, wantEvent: "502 GET /error"},
+		{path: "/captured", wantStatus: http.StatusInternalServerError, wantEvent: "failed to get token"},
+		{path: "/unavailable", wantStatus: http.StatusServiceUnavailable},
+		{path: "/ok", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			before := len(transport.Events())
+			req := httptest.NewRequest(http.MethodGet, tt.path+"?token=secret", nil)
+			req.Header.Set("Authorization", "Bearer secret")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			events := transport.Events()[before:]
+			if tt.wantEvent == "" {
+				if len(events) != 0 {
+					t.Errorf("reported %d events, want none", len(events))
+				}
+				return
+			}
+			if len(events) != 1 {
+				t.Fatalf("reported %d events, want 1", len(events))
+			}
+			e := events[0]
+			got := e.Message
+			if len(e.Exception) > 0 {
+				got = e.Exception[len(e.Exception)-1].Value
+			}
+			if got != tt.wantEvent {
+				t.Errorf("event = %q, want %q", got, tt.wantEvent)
+			}
+			if e.Request == nil || e.Request.QueryString != "token=%5BFiltered%5D" || e.Request.Headers["Authorization"] != "" {
+				t.Errorf("request = %+v, want the request context without secrets", e.Request)
+			}
+		})
+	}
+}
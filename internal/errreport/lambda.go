@@ -0,0 +1,84 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package errreport
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/getsentry/sentry-go"
+
+	"github.com/cruxstack/octo-sts-distros/internal/lambdaevent"
+)
+
+// WrapLambda wraps a Lambda handler to report its panics and errors, and to
+// send the reports of an invocation before it returns, since the
+// environment may be frozen once it does. A panic is reported, then
+// re-raised for Lambda to fail the invocation. Without SENTRY_DSN, next is
+// returned as is.
+func WrapLambda(next func(ctx context.Context, payload json.RawMessage) (any, error)) func(ctx context.Context, payload json.RawMessage) (any, error) {
+	if !Enabled() {
+		return next
+	}
+	return func(ctx context.Context, payload json.RawMessage) (resp any, err error) {
+		ctx, _ = withReport(ctx, sentry.CurrentHub().Clone())
+		defer func() {
+			if v := recover(); v != nil {
+				hubFrom(ctx).RecoverWithContext(ctx, v)
+				Flush()
+				panic(v)
+			}
+			if err != nil && !captured(ctx) {
+				CaptureError(ctx, err)
+			}
+			Flush()
+		}()
+		return next(ctx, payload)
+	}
+}
+
+// WrapEvent wraps the handler of HTTP events to report server error
+// responses with the context of the request. Use it inside WrapLambda, whose
+// invocation it reports to.
+func WrapEvent(h lambdaevent.Handler) lambdaevent.Handler {
+	if !Enabled() {
+		return h
+	}
+	return func(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		header := func(name string) string {
+			for k, v := range req.Headers {
+				if strings.EqualFold(k, name) {
+					return v
+				}
+			}
+			return ""
+		}
+		query, _ := url.ParseQuery(req.RawQueryString)
+		method := req.RequestContext.HTTP.Method
+		hub := withRequest(newRequest(method, "https", req.RequestContext.DomainName, req.RawPath, query, header))
+
+		// Keep the report state of the invocation, so an error a handler
+		// reported isn't reported again by WrapLambda
+		ctx = sentry.SetHubOnContext(ctx, hub)
+		if _, ok := ctx.Value(reportKey{}).(*report); !ok {
+			ctx, _ = withReport(ctx, hub)
+		}
+
+		resp, err := h(ctx, req)
+		if err == nil && reportedStatus(resp.StatusCode) {
+			body := resp.Body
+			if len(body) > maxResponseExcerpt {
+				body = body[:maxResponseExcerpt]
+			}
+			if resp.IsBase64Encoded {
+				body = ""
+			}
+			captureStatus(ctx, hub, resp.StatusCode, method, req.RawPath, body)
+		}
+		return resp, err
+	}
+}
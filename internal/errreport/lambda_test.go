@@ -0,0 +1,70 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package errreport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestWrapLambda(t *testing.T) {
+	transport := newTestClient(t)
+
+	var resp events.APIGatewayV2HTTPResponse
+	handler := WrapEvent(func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		return resp, nil
+	})
+	invoke := WrapLambda(func(ctx context.Context, _ json.RawMessage) (any, error) {
+		req := events.APIGatewayV2HTTPRequest{RawPath: "/", Headers: map[string]string{"user-agent": "actions/1.0"}}
+		req.RequestContext.HTTP.Method = "POST"
+		req.RequestContext.DomainName = "sts.example.com"
+		return handler(ctx, req)
+	})
+
+	resp = events.APIGatewayV2HTTPResponse{StatusCode: 200}
+	if _, err := invoke(context.Background(), nil); err != nil || len(transport.Events()) != 0 {
+		t.Fatalf("error = %v, events = %d, want nothing reported", err, len(transport.Events()))
+	}
+
+	resp = events.APIGatewayV2HTTPResponse{StatusCode: 500, Body: `{"error":"failed to get token"}`}
+	if _, err := invoke(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	events := transport.Events()
+	if len(events) != 1 || events[0].Message != "500 POST /" {
+		t.Fatalf("events = %+v, want the server error reported", events)
+	}
+	if e := events[0]; e.Request.URL != "https://sts.example.com/" || e.Request.Headers["User-Agent"] != "actions/1.0" {
+		t.Errorf("request = %+v", e.Request)
+	}
+}
+
+func TestWrapLambdaErrors(t *testing.T) {
+	transport := newTestClient(t)
+
+	failing := WrapLambda(func(context.Context, json.RawMessage) (any, error) {
+		return nil, errors.New("invalid payload")
+	})
+	if _, err := failing(context.Background(), nil); err == nil {
+		t.Fatal("error = nil, want the handler's error")
+	}
+	if len(transport.Events()) != 1 {
+		t.Errorf("events = %d, want the error reported", len(transport.Events()))
+	}
+
+	panicking := WrapLambda(func(context.Context, json.RawMessage) (any, error) { panic("boom") })
+	defer func() {
+		if v := recover(); v != "boom" {
+			t.Errorf("recover() = %v, want the panic re-raised", v)
+		}
+		if len(transport.Events()) != 2 {
+			t.Errorf("events = %d, want the panic reported", len(transport.Events()))
+		}
+	}()
+	_, _ = panicking(context.Background(), nil)
+}
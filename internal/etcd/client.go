@@ -0,0 +1,309 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package etcd provides a minimal etcd v3 KV client using the JSON gRPC
+// gateway, so no gRPC dependency is needed.
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables for etcd configuration.
+const (
+	EnvEtcdEndpoints = "ETCD_ENDPOINTS"
+	EnvEtcdUsername  = "ETCD_USERNAME"
+	EnvEtcdPassword  = "ETCD_PASSWORD"
+	EnvEtcdCACert    = "ETCD_CACERT"
+	EnvEtcdCert      = "ETCD_CERT"
+	EnvEtcdKey       = "ETCD_KEY"
+)
+
+// DefaultEndpoint is the default etcd client endpoint.
+const DefaultEndpoint = "http://127.0.0.1:2379"
+
+// DefaultTimeout is the default timeout for etcd requests.
+const DefaultTimeout = 10 * time.Second
+
+// Client is a minimal etcd v3 KV client. Requests are sent to each endpoint
+// in turn until one responds.
+type Client struct {
+	Endpoints []string
+
+	username   string
+	password   string
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	token string
+}
+
+// Option is a functional option for configuring Client.
+type Option func(*Client)
+
+// WithAuth authenticates requests as the given etcd user.
+func WithAuth(username, password string) Option {
+	return func(c *Client) {
+		c.username = username
+		c.password = password
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used for https endpoints.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *Client) {
+		c.httpClient.Transport = &http.Transport{TLSClientConfig: cfg}
+	}
+}
+
+// NewClient creates a client for the given endpoints.
+func NewClient(endpoints []string, opts ...Option) (*Client, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("at least one etcd endpoint is required")
+	}
+
+	c := &Client{httpClient: &http.Client{Timeout: DefaultTimeout}}
+	for _, endpoint := range endpoints {
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "http://" + endpoint
+		}
+		c.Endpoints = append(c.Endpoints, strings.TrimSuffix(endpoint, "/"))
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// NewClientFromEnv creates a client from ETCD_ENDPOINTS (comma-separated,
+// default http://127.0.0.1:2379), ETCD_USERNAME and ETCD_PASSWORD, and the
+// ETCD_CACERT, ETCD_CERT, and ETCD_KEY TLS files.
+func NewClientFromEnv() (*Client, error) {
+	var endpoints []string
+	for _, endpoint := range strings.Split(os.Getenv(EnvEtcdEndpoints), ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	if len(endpoints) == 0 {
+		endpoints = []string{DefaultEndpoint}
+	}
+
+	var opts []Option
+	if username := os.Getenv(EnvEtcdUsername); username != "" {
+		opts = append(opts, WithAuth(username, os.Getenv(EnvEtcdPassword)))
+	}
+
+	caFile, certFile, keyFile := os.Getenv(EnvEtcdCACert), os.Getenv(EnvEtcdCert), os.Getenv(EnvEtcdKey)
+	if caFile != "" || certFile != "" {
+		cfg, err := loadTLSConfig(caFile, certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithTLSConfig(cfg))
+	}
+
+	return NewClient(endpoints, opts...)
+}
+
+func loadTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		ca, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read etcd CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("invalid etcd CA certificate")
+		}
+		cfg.RootCAs = pool
+	}
+
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load etcd client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// List returns all keys under prefix with their values.
+func (c *Client) List(ctx context.Context, prefix string) (map[string][]byte, error) {
+	body := map[string]any{"key": []byte(prefix), "range_end": prefixEnd(prefix)}
+	var resp struct {
+		KVs []struct {
+			Key   []byte `json:"key"`
+			Value []byte `json:"value"`
+		} `json:"kvs"`
+	}
+	if err := c.do(ctx, "/v3/kv/range", body, &resp); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string][]byte, len(resp.KVs))
+	for _, kv := range resp.KVs {
+		values[string(kv.Key)] = kv.Value
+	}
+	return values, nil
+}
+
+// Put writes a key.
+func (c *Client) Put(ctx context.Context, key string, value []byte) error {
+	return c.do(ctx, "/v3/kv/put", map[string]any{"key": []byte(key), "value": value}, nil)
+}
+
+// Delete removes a key. Deleting a missing key is not an error.
+func (c *Client) Delete(ctx context.Context, key string) error {
+	return c.do(ctx, "/v3/kv/deleterange", map[string]any{"key": []byte(key)}, nil)
+}
+
+// prefixEnd returns the range end covering every key with the given prefix.
+func prefixEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// All bytes are 0xff (or the prefix is empty): range to the end of the keyspace.
+	return []byte{0}
+}
+
+// APIError is returned for non-successful etcd gateway responses.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("etcd returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("etcd returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// do sends an authenticated request, authenticating again once if the token
+// has expired, which the gateway reports as 401 Unauthorized.
+func (c *Client) do(ctx context.Context, path string, body, out any) error {
+	token, err := c.currentToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = c.send(ctx, path, token, body, out)
+	var apiErr *APIError
+	if c.username != "" && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+		c.mu.Lock()
+		c.token = ""
+		c.mu.Unlock()
+		if token, err = c.currentToken(ctx); err != nil {
+			return err
+		}
+		err = c.send(ctx, path, token, body, out)
+	}
+	return err
+}
+
+// currentToken returns the cached auth token, authenticating if necessary.
+// Without a username, requests are sent unauthenticated.
+func (c *Client) currentToken(ctx context.Context) (string, error) {
+	if c.username == "" {
+		return "", nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" {
+		return c.token, nil
+	}
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+	body := map[string]string{"name": c.username, "password": c.password}
+	if err := c.send(ctx, "/v3/auth/authenticate", "", body, &resp); err != nil {
+		return "", fmt.Errorf("etcd authentication failed: %w", err)
+	}
+	if resp.Token == "" {
+		return "", fmt.Errorf("etcd authentication returned no token")
+	}
+	c.token = resp.Token
+	return c.token, nil
+}
+
+// send posts a request to the first endpoint that responds.
+func (c *Client) send(ctx context.Context, path, token string, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	var lastErr error
+	for _, endpoint := range c.Endpoints {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+path, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("etcd request to %s failed: %w", endpoint, err)
+			if ctx.Err() != nil {
+				return lastErr
+			}
+			continue
+		}
+		return decodeResponse(resp, out)
+	}
+	return lastErr
+}
+
+func decodeResponse(resp *http.Response, out any) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		var errBody struct {
+			Message string `json:"message"`
+			Error   string `json:"error"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(data, &errBody) == nil {
+			apiErr.Message = errBody.Message
+			if apiErr.Message == "" {
+				apiErr.Message = errBody.Error
+			}
+		}
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode etcd response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestClient returns a client of a server answering with handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := NewClient([]string{srv.URL + "/"}, opts...)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return c
+}
+
+func TestAuthRenewal(t *testing.T) {
+	var logins int
+	token := "first"
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v3/auth/authenticate" {
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["name"] != "octo-sts" || body["password"] != "secret" {
+				t.Errorf("authenticate body = %v, want the user and password", body)
+			}
+			logins++
+			json.NewEncoder(w).Encode(map[string]string{"token": token})
+			return
+		}
+		// The first token expires after the first request
+		if r.Header.Get("Authorization") != "second" {
+			token = "second"
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"etcdserver: invalid auth token","code":16}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}, WithAuth("octo-sts", "secret"))
+
+	if err := c.Put(context.Background(), "octo-sts/key", []byte("value")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if logins != 2 {
+		t.Errorf("authenticated %d times, want once more after the token expired", logins)
+	}
+}
+
+func TestKeyEncoding(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Key      []byte `json:"key"`
+			RangeEnd []byte `json:"range_end"`
+			Value    []byte `json:"value"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/v3/kv/range":
+			if string(body.Key) != "octo sts/" || string(body.RangeEnd) != "octo sts0" {
+				t.Errorf("range = [%q, %q), want the prefix range", body.Key, body.RangeEnd)
+			}
+			json.NewEncoder(w).Encode(map[string]any{"kvs": []map[string][]byte{{"key": []byte("octo sts/KEY"), "value": []byte("v")}}})
+		case "/v3/kv/put":
+			if string(body.Key) != "octo sts/a?b#c" || string(body.Value) != "v" {
+				t.Errorf("put = %q=%q, want the key and value unchanged", body.Key, body.Value)
+			}
+			w.Write([]byte(`{}`))
+		}
+	})
+	ctx := context.Background()
+
+	values, err := c.List(ctx, "octo sts/")
+	if err != nil || string(values["octo sts/KEY"]) != "v" {
+		t.Errorf("List() = %q, %v", values, err)
+	}
+	if err := c.Put(ctx, "octo sts/a?b#c", []byte("v")); err != nil {
+		t.Errorf("Put() error = %v", err)
+	}
+
+	if got := prefixEnd("a\xff\xff"); !bytes.Equal(got, []byte("b")) {
+		t.Errorf("prefixEnd() = %q, want b", got)
+	}
+	if got := prefixEnd(""); !bytes.Equal(got, []byte{0}) {
+		t.Errorf("prefixEnd(\"\") = %q, want the end of the keyspace", got)
+	}
+}
+
+func TestEndpointFailover(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"kvs": []map[string][]byte{{"key": []byte("k"), "value": []byte("v")}}})
+	}))
+	defer srv.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	c, err := NewClient([]string{down.URL, srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values, err := c.List(context.Background(), "k"); err != nil || string(values["k"]) != "v" {
+		t.Errorf("List() = %q, %v, want the second endpoint used", values, err)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	body := `{"error":"etcdserver: permission denied","code":7,"message":"etcdserver: permission denied"}`
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(body))
+	})
+
+	_, err := c.List(context.Background(), "octo-sts/")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden || apiErr.Message != "etcdserver: permission denied" {
+		t.Fatalf("List() error = %v, want an APIError with the message", err)
+	}
+
+	body = "<html>bad gateway</html>"
+	_, err = c.List(context.Background(), "octo-sts/")
+	if err == nil || err.Error() != "etcd returned status 403" {
+		t.Errorf("List() with a non-JSON body error = %v, want the status only", err)
+	}
+
+	// A missing key is an empty range, not an error
+	c = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"header":{}}`))
+	})
+	if values, err := c.List(context.Background(), "missing/"); err != nil || len(values) != 0 {
+		t.Errorf("List() of a missing prefix = %v, %v, want no values", values, err)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package gcp provides a minimal Google Secret Manager client authenticated
+// with the service account of the metadata server.
+package gcp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnvMetadataHost overrides the metadata server host, as with the Google
+// Cloud client libraries.
+const EnvMetadataHost = "GCE_METADATA_HOST"
+
+const (
+	// SecretManagerEndpoint is the Secret Manager REST endpoint.
+	SecretManagerEndpoint = "https://secretmanager.googleapis.com/v1"
+	// DefaultTimeout is the default timeout for Google Cloud HTTP requests.
+	DefaultTimeout = 10 * time.Second
+
+	defaultMetadataHost = "metadata.google.internal"
+	tokenPath           = "/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// ErrSecretNotFound is returned when a secret or secret version does not exist.
+var ErrSecretNotFound = errors.New("secret manager secret not found")
+
+// TokenSource provides bearer tokens for Secret Manager requests.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// MetadataServer obtains tokens for the default service account from the
+// metadata server of Compute Engine, Cloud Run, and GKE workload identity.
+type MetadataServer struct {
+	httpClient *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewMetadataServer creates a metadata server token source.
+func NewMetadataServer() *MetadataServer {
+	return &MetadataServer{httpClient: &http.Client{Timeout: DefaultTimeout}}
+}
+
+// Token returns a cached access token, refreshing it shortly before expiry.
+func (m *MetadataServer) Token(ctx context.Context) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.token != "" && time.Until(m.expires) > time.Minute {
+		return m.token, nil
+	}
+
+	host := os.Getenv(EnvMetadataHost)
+	if host == "" {
+		host = defaultMetadataHost
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+host+tokenPath, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("metadata server request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("metadata server returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", fmt.Errorf("failed to decode metadata server token: %w", err)
+	}
+	if tok.AccessToken == "" {
+		return "", fmt.Errorf("metadata server returned no access token")
+	}
+
+	m.token = tok.AccessToken
+	m.expires = time.Now().Add(5 * time.Minute)
+	if tok.ExpiresIn > 0 {
+		m.expires = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	}
+	return m.token, nil
+}
+
+// SecretManagerClient reads secrets from Google Secret Manager.
+type SecretManagerClient struct {
+	Endpoint string
+
+	tokens     TokenSource
+	httpClient *http.Client
+}
+
+// NewSecretManagerClient creates a Secret Manager client.
+func NewSecretManagerClient(tokens TokenSource) (*SecretManagerClient, error) {
+	if tokens == nil {
+		return nil, fmt.Errorf("token source cannot be nil")
+	}
+	return &SecretManagerClient{
+		Endpoint:   SecretManagerEndpoint,
+		tokens:     tokens,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+	}, nil
+}
+
+// AccessSecretVersion returns the payload of a secret version, named
+// projects/<project>/secrets/<secret>/versions/<version>.
+// Returns ErrSecretNotFound if the secret or version does not exist.
+func (c *SecretManagerClient) AccessSecretVersion(ctx context.Context, name string) ([]byte, error) {
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret manager token: %w", err)
+	}
+
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Endpoint+"/"+strings.Join(segments, "/")+":access", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("secret manager request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrSecretNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errBody struct {
+			Error struct {
+				Status  string `json:"status"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&errBody) == nil && errBody.Error.Status != "" {
+			return nil, fmt.Errorf("secret manager returned status %d: %s: %s", resp.StatusCode, errBody.Error.Status, errBody.Error.Message)
+		}
+		return nil, fmt.Errorf("secret manager returned status %d", resp.StatusCode)
+	}
+
+	var out struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode secret manager response: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(out.Payload.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret payload: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package gcp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// staticToken is a TokenSource returning a fixed token.
+type staticToken string
+
+func (s staticToken) Token(context.Context) (string, error) { return string(s), nil }
+
+// newTestClient returns a client of a server answering with handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *SecretManagerClient {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := NewSecretManagerClient(staticToken("sm-token"))
+	if err != nil {
+		t.Fatalf("NewSecretManagerClient() error = %v", err)
+	}
+	c.Endpoint = srv.URL + "/v1"
+	return c
+}
+
+func TestMetadataServerToken(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if got := r.Header.Get("Metadata-Flavor"); got != "Google" {
+			t.Errorf("Metadata-Flavor = %q, want Google", got)
+		}
+		if r.URL.Path != tokenPath {
+			t.Errorf("path = %q, want the default service account token", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "sm-token", "expires_in": 3599})
+	}))
+	defer srv.Close()
+	t.Setenv(EnvMetadataHost, strings.TrimPrefix(srv.URL, "http://"))
+
+	m := NewMetadataServer()
+	for range 2 {
+		token, err := m.Token(context.Background())
+		if err != nil || token != "sm-token" {
+			t.Fatalf("Token() = %q, %v, want sm-token", token, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("metadata server requested %d times, want the token cached", requests)
+	}
+}
+
+func TestMetadataServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("service account not enabled\n"))
+	}))
+	defer srv.Close()
+	t.Setenv(EnvMetadataHost, strings.TrimPrefix(srv.URL, "http://"))
+
+	c, _ := NewSecretManagerClient(NewMetadataServer())
+	_, err := c.AccessSecretVersion(context.Background(), "projects/p/secrets/s/versions/latest")
+	if err == nil || !strings.Contains(err.Error(), "metadata server returned status 404: service account not enabled") {
+		t.Errorf("AccessSecretVersion() error = %v, want the token error", err)
+	}
+}
+
+func TestAccessSecretVersion(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer sm-token" {
+			t.Errorf("Authorization = %q, want the bearer token", got)
+		}
+		if want := "/v1/projects/my-project/secrets/octo%3Fsts/versions/latest:access"; r.URL.EscapedPath() != want {
+			t.Errorf("path = %q, want %q", r.URL.EscapedPath(), want)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"payload": map[string]string{"data": base64.StdEncoding.EncodeToString([]byte("value"))}})
+	})
+
+	data, err := c.AccessSecretVersion(context.Background(), "projects/my-project/secrets/octo?sts/versions/latest")
+	if err != nil || string(data) != "value" {
+		t.Errorf("AccessSecretVersion() = %q, %v, want the decoded payload", data, err)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":{"code":404,"status":"NOT_FOUND","message":"Secret [projects/1/secrets/s] not found or has no versions."}}`))
+	})
+
+	_, err := c.AccessSecretVersion(context.Background(), "projects/p/secrets/s/versions/latest")
+	if !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("AccessSecretVersion() of a missing secret error = %v, want ErrSecretNotFound", err)
+	}
+}
+
+func TestErrorBody(t *testing.T) {
+	body := `{"error":{"code":403,"status":"PERMISSION_DENIED","message":"Permission 'secretmanager.versions.access' denied"}}`
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(body))
+	})
+	name := "projects/p/secrets/s/versions/latest"
+
+	_, err := c.AccessSecretVersion(context.Background(), name)
+	if err == nil || err.Error() != "secret manager returned status 403: PERMISSION_DENIED: Permission 'secretmanager.versions.access' denied" {
+		t.Errorf("AccessSecretVersion() error = %v, want the status and message", err)
+	}
+
+	body = "<html>bad gateway</html>"
+	_, err = c.AccessSecretVersion(context.Background(), name)
+	if err == nil || err.Error() != "secret manager returned status 403" {
+		t.Errorf("AccessSecretVersion() with a non-JSON body error = %v, want the status only", err)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package generate renders the deployment artifacts of a distro, wired for
+// one of the config stores, so adopters start from a working configuration
+// instead of writing the integration by hand.
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+)
+
+// Targets rendered by Generate.
+const (
+	// TargetTerraform is a root Terraform configuration using the aws-lambda
+	// module: API Gateway, the Lambda functions, and their SSM or Secrets
+	// Manager access.
+	TargetTerraform = "terraform"
+
+	// TargetKubernetes is a set of Kubernetes manifests running the
+	// container image with `octo-sts serve all`.
+	TargetKubernetes = "kubernetes"
+)
+
+// Defaults of Options.
+const (
+	DefaultName = "octo-sts"
+	DefaultRef  = "main"
+)
+
+// ErrInvalid is returned when the options cannot be rendered.
+var ErrInvalid = errors.New("invalid options")
+
+// terraformModes are the storage modes the aws-lambda module can read.
+var terraformModes = []string{configstore.StorageModeAWSSSM, configstore.StorageModeAWSSecretsManager}
+
+// kubernetesModes are the storage modes that work from a pod. The local
+// file stores are mounted from a Secret, and so are read-only.
+var kubernetesModes = []string{
+	configstore.StorageModeKubernetes,
+	configstore.StorageModeAWSSSM,
+	configstore.StorageModeAWSSecretsManager,
+	configstore.StorageModeVault,
+	configstore.StorageModeAzureKeyVault,
+	configstore.StorageModeConsul,
+	configstore.StorageModeEtcd,
+	configstore.StorageModeEnvFile,
+	configstore.StorageModeFiles,
+}
+
+var namePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Options selects what Generate renders.
+type Options struct {
+	// Target is TargetTerraform or TargetKubernetes.
+	Target string
+
+	// StorageMode is the config store of the GitHub App credentials
+	// (default: aws-ssm for Terraform, kubernetes for Kubernetes).
+	StorageMode string
+
+	// Name prefixes the resources (default: octo-sts).
+	Name string
+
+	// Domain is the STS domain, checked as the audience of the exchanged
+	// tokens. For Terraform it defaults to the API Gateway hostname.
+	Domain string
+
+	// Prefix is where the store keeps the credentials, e.g. the SSM
+	// parameter prefix or the Kubernetes Secret name (default: derived
+	// from Name).
+	Prefix string
+
+	// Namespace is the Kubernetes namespace (default: Name).
+	Namespace string
+
+	// Image is the container image for Kubernetes.
+	Image string
+
+	// Ref is the git ref of this repository the Terraform module is
+	// sourced from (default: main).
+	Ref string
+}
+
+// Generate renders the artifacts selected by opts to w.
+func Generate(w io.Writer, opts Options) error {
+	data, tmpl, err := prepare(opts)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, data)
+}
+
+// templateData is what the templates are executed with.
+type templateData struct {
+	Options
+
+	// PrefixEnv is the variable that sets Prefix for the storage mode.
+	PrefixEnv string
+
+	// SecretName is the Kubernetes Secret of the GitHub App credentials
+	// for the file stores, and of their environment for the others.
+	SecretName string
+
+	// FileStore is set for the local file stores, which read a mounted
+	// Secret.
+	FileStore bool
+}
+
+func prepare(opts Options) (templateData, *template.Template, error) {
+	if opts.Name == "" {
+		opts.Name = DefaultName
+	}
+	if !namePattern.MatchString(opts.Name) {
+		return templateData{}, nil, fmt.Errorf("%w: name %q must be lowercase letters, digits, and dashes", ErrInvalid, opts.Name)
+	}
+	if opts.Ref == "" {
+		opts.Ref = DefaultRef
+	}
+
+	switch opts.Target {
+	case TargetTerraform:
+		if opts.StorageMode == "" {
+			opts.StorageMode = configstore.StorageModeAWSSSM
+		}
+		if err := checkMode(opts.StorageMode, terraformModes); err != nil {
+			return templateData{}, nil, err
+		}
+		if opts.Prefix == "" {
+			opts.Prefix = defaultPrefix(opts.StorageMode, opts.Name)
+		}
+		if opts.StorageMode == configstore.StorageModeAWSSSM && !strings.HasPrefix(opts.Prefix, "/") {
+			return templateData{}, nil, fmt.Errorf("%w: SSM parameter prefix %q must start with /", ErrInvalid, opts.Prefix)
+		}
+		return templateData{Options: opts}, terraformTemplate, nil
+
+	case TargetKubernetes:
+		if opts.StorageMode == "" {
+			opts.StorageMode = configstore.StorageModeKubernetes
+		}
+		if err := checkMode(opts.StorageMode, kubernetesModes); err != nil {
+			return templateData{}, nil, err
+		}
+		if opts.Image == "" {
+			return templateData{}, nil, fmt.Errorf("%w: an image is required for %s", ErrInvalid, TargetKubernetes)
+		}
+		if opts.Domain == "" {
+			return templateData{}, nil, fmt.Errorf("%w: a domain is required for %s", ErrInvalid, TargetKubernetes)
+		}
+		if opts.Namespace == "" {
+			opts.Namespace = opts.Name
+		}
+		data := templateData{SecretName: opts.Name + "-config"}
+		switch opts.StorageMode {
+		case configstore.StorageModeEnvFile:
+			data.FileStore = true
+			opts.Prefix = "/config/.env"
+		case configstore.StorageModeFiles:
+			data.FileStore = true
+			opts.Prefix = "/config"
+		default:
+			if opts.Prefix == "" {
+				opts.Prefix = defaultPrefix(opts.StorageMode, opts.Name)
+			}
+		}
+		data.Options = opts
+		data.PrefixEnv, _ = configstore.PrefixEnv(opts.StorageMode)
+		return data, kubernetesTemplate, nil
+
+	case "":
+		return templateData{}, nil, fmt.Errorf("%w: a target is required (%s or %s)", ErrInvalid, TargetTerraform, TargetKubernetes)
+	default:
+		return templateData{}, nil, fmt.Errorf("%w: unknown target %q (expected %s or %s)", ErrInvalid, opts.Target, TargetTerraform, TargetKubernetes)
+	}
+}
+
+// checkMode returns an error unless mode is one of supported.
+func checkMode(mode string, supported []string) error {
+	for _, m := range supported {
+		if m == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: storage mode %q is not supported for this target (expected one of %s)",
+		ErrInvalid, mode, strings.Join(supported, ", "))
+}
+
+// defaultPrefix returns where the store of mode keeps the credentials of
+// the deployment name.
+func defaultPrefix(mode, name string) string {
+	switch mode {
+	case configstore.StorageModeAWSSSM:
+		return "/" + name + "/"
+	case configstore.StorageModeVault, configstore.StorageModeConsul, configstore.StorageModeEtcd:
+		return name
+	default:
+		return name + "-app"
+	}
+}
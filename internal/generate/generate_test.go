@@ -0,0 +1,162 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package generate
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestGenerateTerraform(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "ssm",
+			opts: Options{Target: TargetTerraform, Name: "sts-prod", Ref: "v1.2.3"},
+			want: []string{
+				`module "sts_prod"`,
+				"//distros/aws-lambda/terraform?ref=v1.2.3",
+				`ssm_prefix = "/sts-prod/"`,
+				"${local.ssm_arn}GITHUB_APP_PRIVATE_KEY",
+				"enabled              = true",
+				"module.sts_prod.setup_url",
+			},
+			notWant: []string{"secretsmanager"},
+		},
+		{
+			name: "secrets manager",
+			opts: Options{Target: TargetTerraform, StorageMode: "aws-secretsmanager", Prefix: "octo/app", Domain: "sts.example.com"},
+			want: []string{
+				`name = "octo/app"`,
+				"${data.aws_secretsmanager_secret.app.arn}#GITHUB_APP_ID",
+				"secretsmanager_secret_arns",
+				`domain = "sts.example.com"`,
+			},
+			notWant: []string{"installer_config", "setup_url"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Generate(&buf, tt.opts); err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(buf.String(), s) {
+					t.Errorf("output does not contain %q:\n%s", s, buf.String())
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(buf.String(), s) {
+					t.Errorf("output contains %q:\n%s", s, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateKubernetes(t *testing.T) {
+	tests := []struct {
+		mode      string
+		wantKinds []string
+		wantEnv   map[string]string
+	}{
+		{
+			mode:      "",
+			wantKinds: []string{"Namespace", "ServiceAccount", "Role", "RoleBinding", "Deployment", "Service"},
+			wantEnv:   map[string]string{"STORAGE_MODE": "kubernetes", "KUBERNETES_SECRET_NAME": "octo-sts-app", "GITHUB_APP_INSTALLER_ENABLED": "true"},
+		},
+		{
+			mode:      "envfile",
+			wantKinds: []string{"Namespace", "ServiceAccount", "Secret", "Deployment", "Service"},
+			wantEnv:   map[string]string{"STORAGE_MODE": "envfile", "STORAGE_DIR": "/config/.env"},
+		},
+		{
+			mode:      "aws-ssm",
+			wantKinds: []string{"Namespace", "ServiceAccount", "Deployment", "Service"},
+			wantEnv:   map[string]string{"AWS_SSM_PARAMETER_PREFIX": "/octo-sts/", "STS_DOMAIN": "sts.example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			var buf bytes.Buffer
+			opts := Options{Target: TargetKubernetes, StorageMode: tt.mode, Image: "octo-sts:test", Domain: "sts.example.com"}
+			if err := Generate(&buf, opts); err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			var kinds []string
+			env := map[string]string{}
+			for _, doc := range strings.Split(buf.String(), "\n---\n") {
+				var obj struct {
+					Kind string `json:"kind"`
+					Spec struct {
+						Template struct {
+							Spec struct {
+								Containers []struct {
+									Env []struct {
+										Name  string `json:"name"`
+										Value string `json:"value"`
+									} `json:"env"`
+								} `json:"containers"`
+							} `json:"spec"`
+						} `json:"template"`
+					} `json:"spec"`
+				}
+				if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+					t.Fatalf("invalid manifest: %v\n%s", err, doc)
+				}
+				kinds = append(kinds, obj.Kind)
+				for _, c := range obj.Spec.Template.Spec.Containers {
+					for _, e := range c.Env {
+						env[e.Name] = e.Value
+					}
+				}
+			}
+			if strings.Join(kinds, ",") != strings.Join(tt.wantKinds, ",") {
+				t.Errorf("kinds = %v, want %v", kinds, tt.wantKinds)
+			}
+			for k, v := range tt.wantEnv {
+				if env[k] != v {
+					t.Errorf("env %s = %q, want %q", k, env[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "no target", opts: Options{}},
+		{name: "unknown target", opts: Options{Target: "helm"}},
+		{name: "terraform mode", opts: Options{Target: TargetTerraform, StorageMode: "vault"}},
+		{name: "relative ssm prefix", opts: Options{Target: TargetTerraform, Prefix: "octo-sts"}},
+		{name: "bad name", opts: Options{Target: TargetTerraform, Name: "Octo STS"}},
+		{name: "no image", opts: Options{Target: TargetKubernetes, Domain: "sts.example.com"}},
+		{name: "no domain", opts: Options{Target: TargetKubernetes, Image: "octo-sts:test"}},
+		{name: "kubernetes mode", opts: Options{Target: TargetKubernetes, Image: "octo-sts:test", Domain: "d", StorageMode: "sops"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Generate(&buf, tt.opts); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Generate() error = %v, want %v", err, ErrInvalid)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("Generate() wrote output on error:\n%s", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package generate
+
+import (
+	"strings"
+	"text/template"
+)
+
+// funcs are the template functions; underscore turns a resource name into
+// a Terraform identifier.
+var funcs = template.FuncMap{
+	"underscore": func(s string) string { return strings.ReplaceAll(s, "-", "_") },
+}
+
+var terraformTemplate = template.Must(template.New(TargetTerraform).Funcs(funcs).Parse(`# Generated by octo-sts generate for the aws-lambda distro, storage mode
+# {{.StorageMode}}. Review the values before applying.
+
+terraform {
+  required_version = ">= 1.3"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = ">= 5.0"
+    }
+  }
+}
+
+variable "aws_region" {
+  description = "AWS region to deploy to."
+  type        = string
+}
+{{- if eq .StorageMode "aws-ssm"}}
+
+data "aws_caller_identity" "current" {}
+
+locals {
+  # The setup wizard saves the GitHub App credentials under this prefix
+  ssm_prefix = "{{.Prefix}}"
+  ssm_arn    = "arn:aws:ssm:${var.aws_region}:${data.aws_caller_identity.current.account_id}:parameter${local.ssm_prefix}"
+}
+{{- else}}
+
+# The secret holds the GitHub App credentials as JSON keyed by variable
+# name, e.g. as written by octo-sts store migrate --to aws-secretsmanager.
+data "aws_secretsmanager_secret" "app" {
+  name = "{{.Prefix}}"
+}
+{{- end}}
+
+provider "aws" {
+  region = var.aws_region
+}
+
+module "{{.Name | underscore}}" {
+  source = "git::https://github.com/cruxstack/octo-sts-distros.git//distros/aws-lambda/terraform?ref={{.Ref}}"
+
+  name           = "{{.Name}}"
+  distro_version = "{{.Ref}}"
+{{- if eq .StorageMode "aws-ssm"}}
+
+  github_app_config = {
+    app_id         = "${local.ssm_arn}GITHUB_APP_ID"
+    private_key    = "${local.ssm_arn}GITHUB_APP_PRIVATE_KEY"
+    webhook_secret = "${local.ssm_arn}GITHUB_WEBHOOK_SECRET"
+  }
+
+  # Serves /setup to register the GitHub App until it is configured
+  installer_config = {
+    enabled              = true
+    ssm_parameter_prefix = local.ssm_prefix
+  }
+
+  ssm_parameter_arns = ["${local.ssm_arn}*"]
+{{- else}}
+
+  github_app_config = {
+    app_id         = "${data.aws_secretsmanager_secret.app.arn}#GITHUB_APP_ID"
+    private_key    = "${data.aws_secretsmanager_secret.app.arn}#GITHUB_APP_PRIVATE_KEY"
+    webhook_secret = "${data.aws_secretsmanager_secret.app.arn}#GITHUB_WEBHOOK_SECRET"
+  }
+
+  secretsmanager_secret_arns = [data.aws_secretsmanager_secret.app.arn]
+{{- end}}
+
+  sts_config = {
+    domain = "{{.Domain}}" # empty uses the API Gateway hostname
+  }
+}
+
+output "sts_url" {
+  description = "STS token exchange URL"
+  value       = module.{{.Name | underscore}}.sts_url
+}
+
+output "webhook_url" {
+  description = "Webhook URL to configure in the GitHub App settings"
+  value       = module.{{.Name | underscore}}.webhook_url
+}
+{{- if eq .StorageMode "aws-ssm"}}
+
+output "setup_url" {
+  description = "URL of the setup wizard"
+  value       = module.{{.Name | underscore}}.setup_url
+}
+{{- end}}
+`))
+
+var kubernetesTemplate = template.Must(template.New(TargetKubernetes).Funcs(funcs).Parse(`# Generated by octo-sts generate for the docker image, storage mode
+# {{.StorageMode}}. Review the values before applying.
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{.Namespace}}
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: {{.Name}}
+  namespace: {{.Namespace}}
+{{- if eq .StorageMode "kubernetes"}}
+---
+# Lets the installer save the GitHub App credentials to the Secret, and the
+# services read them
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: {{.Name}}
+  namespace: {{.Namespace}}
+rules:
+  - apiGroups: [""]
+    resources: ["secrets"]
+    verbs: ["create"]
+  - apiGroups: [""]
+    resources: ["secrets"]
+    resourceNames: ["{{.Prefix}}"]
+    verbs: ["get", "patch", "delete"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: {{.Name}}
+  namespace: {{.Namespace}}
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: {{.Name}}
+subjects:
+  - kind: ServiceAccount
+    name: {{.Name}}
+    namespace: {{.Namespace}}
+{{- end}}
+{{- if .FileStore}}
+---
+# Fill in the GitHub App credentials; the installer cannot write to a
+# mounted Secret
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{.SecretName}}
+  namespace: {{.Namespace}}
+type: Opaque
+stringData:
+{{- if eq .StorageMode "envfile"}}
+  .env: |
+    GITHUB_APP_ID=
+    GITHUB_WEBHOOK_SECRET=
+    GITHUB_APP_PRIVATE_KEY=""
+{{- else}}
+  GITHUB_APP_ID: ""
+  GITHUB_WEBHOOK_SECRET: ""
+  GITHUB_APP_PRIVATE_KEY: ""
+{{- end}}
+{{- end}}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{.Name}}
+  namespace: {{.Namespace}}
+  labels:
+    app.kubernetes.io/name: {{.Name}}
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: {{.Name}}
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: {{.Name}}
+    spec:
+      serviceAccountName: {{.Name}}
+      containers:
+        - name: octo-sts
+          image: {{.Image}}
+          args: ["octo-sts", "serve", "all"]
+          ports:
+            - name: http
+              containerPort: 8080
+          env:
+            - name: PORT
+              value: "8080"
+            - name: STS_DOMAIN
+              value: "{{.Domain}}"
+            - name: STORAGE_MODE
+              value: "{{.StorageMode}}"
+            - name: {{.PrefixEnv}}
+              value: "{{.Prefix}}"
+{{- if not .FileStore}}
+            - name: GITHUB_APP_INSTALLER_ENABLED
+              value: "true"
+{{- if ne .StorageMode "kubernetes"}}
+          # Credentials of the config store, e.g. AWS_REGION or VAULT_TOKEN
+          envFrom:
+            - secretRef:
+                name: {{.SecretName}}
+                optional: true
+{{- end}}
+{{- end}}
+          readinessProbe:
+            httpGet:
+              path: /healthz
+              port: http
+          livenessProbe:
+            httpGet:
+              path: /healthz
+              port: http
+{{- if .FileStore}}
+          volumeMounts:
+            - name: config
+              mountPath: /config
+              readOnly: true
+      volumes:
+        - name: config
+          secret:
+            secretName: {{.SecretName}}
+{{- end}}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{.Name}}
+  namespace: {{.Namespace}}
+spec:
+  selector:
+    app.kubernetes.io/name: {{.Name}}
+  ports:
+    - name: http
+      port: 80
+      targetPort: http
+`))
@@ -70,7 +70,8 @@ func OctoSTSManifest() Manifest {
 }
 
 // NewOctoSTSConfig creates an installer config pre-configured for Octo-STS.
-// It sets the manifest and branding for Octo-STS.
+// It sets the manifest and branding for Octo-STS, and saves metadata about
+// the setup with the credentials (see NewMetadataHandler).
 func NewOctoSTSConfig(store configstore.Store) Config {
 	cfg := NewConfigFromEnv()
 	cfg.Store = store
@@ -78,13 +79,17 @@ func NewOctoSTSConfig(store configstore.Store) Config {
 	cfg.AppDisplayName = "Octo-STS"
 
 	// Map CUSTOM_DOMAIN (set by installer UI) to STS_DOMAIN (used by octo-sts)
-	cfg.OnCredentialsSaved = func(_ context.Context, creds *configstore.AppCredentials) error {
+	cfg.OnCredentialsSaved = func(ctx context.Context, creds *configstore.AppCredentials) error {
 		if creds.CustomFields == nil {
 			creds.CustomFields = make(map[string]string)
 		}
 		if domain := creds.CustomFields["CUSTOM_DOMAIN"]; domain != "" {
 			creds.CustomFields["STS_DOMAIN"] = domain
 		}
+		// Record which setup produced the credentials
+		recordMetadata(ctx, creds, cfg.GitHubURL)
+		// Make the credentials available to NewKubernetesManifestHandler
+		recordCredentials(ctx, creds)
 		return nil
 	}
 
@@ -0,0 +1,304 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package installer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/cruxstack/github-app-setup-go/configstore"
+	"sigs.k8s.io/yaml"
+)
+
+// Environment variables for the Kubernetes manifest download.
+const (
+	EnvKubernetesManifestEnabled = "INSTALLER_K8S_MANIFEST_ENABLED"
+	EnvKubernetesNamespace       = "INSTALLER_K8S_NAMESPACE"
+	EnvKubernetesSecretName      = "INSTALLER_K8S_SECRET_NAME"
+	EnvKubernetesConfigMapName   = "INSTALLER_K8S_CONFIGMAP_NAME"
+)
+
+// Kubernetes manifest defaults.
+const (
+	DefaultKubernetesNamespace  = "octo-sts"
+	DefaultKubernetesSecretName = "octo-sts"
+)
+
+// secretKeys are credential keys that always belong in the Secret, even when
+// a ConfigMap is generated for the non-sensitive values.
+var secretKeys = map[string]bool{
+	configstore.EnvGitHubAppPrivateKey: true,
+	configstore.EnvGitHubWebhookSecret: true,
+	configstore.EnvGitHubClientSecret:  true,
+}
+
+// KubernetesManifestConfig configures the generated Kubernetes manifest.
+type KubernetesManifestConfig struct {
+	// Namespace is the namespace of the generated resources.
+	Namespace string
+
+	// SecretName is the name of the generated Secret.
+	SecretName string
+
+	// ConfigMapName, if set, moves non-sensitive values (app ID, slug, client
+	// ID, STS domain) into a ConfigMap with this name instead of the Secret.
+	ConfigMapName string
+}
+
+// KubernetesManifestEnabled returns true if the manifest download is enabled via environment variable.
+func KubernetesManifestEnabled() bool {
+	v, _ := strconv.ParseBool(os.Getenv(EnvKubernetesManifestEnabled))
+	return v
+}
+
+// NewKubernetesManifestConfigFromEnv creates a KubernetesManifestConfig from environment variables.
+func NewKubernetesManifestConfigFromEnv() KubernetesManifestConfig {
+	return KubernetesManifestConfig{
+		Namespace:     configstore.GetEnvDefault(EnvKubernetesNamespace, DefaultKubernetesNamespace),
+		SecretName:    configstore.GetEnvDefault(EnvKubernetesSecretName, DefaultKubernetesSecretName),
+		ConfigMapName: os.Getenv(EnvKubernetesConfigMapName),
+	}
+}
+
+// KubernetesManifest renders a Secret (and optional ConfigMap) containing the
+// app credentials. Keys match the environment variables read by the HTTP
+// binaries, so the resources can be consumed directly via envFrom.
+func KubernetesManifest(creds *configstore.AppCredentials, cfg KubernetesManifestConfig) ([]byte, error) {
+	if creds == nil {
+		return nil, fmt.Errorf("credentials are required")
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = DefaultKubernetesNamespace
+	}
+	if cfg.SecretName == "" {
+		cfg.SecretName = DefaultKubernetesSecretName
+	}
+
+	values := map[string]string{
+		configstore.EnvGitHubAppID:         strconv.FormatInt(creds.AppID, 10),
+		configstore.EnvGitHubWebhookSecret: creds.WebhookSecret,
+		configstore.EnvGitHubClientID:      creds.ClientID,
+		configstore.EnvGitHubClientSecret:  creds.ClientSecret,
+		configstore.EnvGitHubAppPrivateKey: creds.PrivateKey,
+	}
+	if creds.AppSlug != "" {
+		values[configstore.EnvGitHubAppSlug] = creds.AppSlug
+	}
+	if creds.HTMLURL != "" {
+		values[configstore.EnvGitHubAppHTMLURL] = creds.HTMLURL
+	}
+	for key, value := range creds.CustomFields {
+		if value != "" {
+			values[key] = value
+		}
+	}
+
+	secretData := make(map[string]string)
+	configData := make(map[string]string)
+	for key, value := range values {
+		if cfg.ConfigMapName != "" && !secretKeys[key] {
+			configData[key] = value
+			continue
+		}
+		secretData[key] = value
+	}
+
+	labels := map[string]string{
+		"app.kubernetes.io/name":       "octo-sts",
+		"app.kubernetes.io/managed-by": "octo-sts-installer",
+	}
+
+	docs := []any{
+		map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata": map[string]any{
+				"name":      cfg.SecretName,
+				"namespace": cfg.Namespace,
+				"labels":    labels,
+			},
+			"type":       "Opaque",
+			"stringData": secretData,
+		},
+	}
+	if cfg.ConfigMapName != "" {
+		docs = append(docs, map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]any{
+				"name":      cfg.ConfigMapName,
+				"namespace": cfg.Namespace,
+				"labels":    labels,
+			},
+			"data": configData,
+		})
+	}
+
+	var buf bytes.Buffer
+	for i, doc := range docs {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		out, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render manifest: %w", err)
+		}
+		buf.Write(out)
+	}
+	return buf.Bytes(), nil
+}
+
+// credentialsHolderKey is the context key for the per-request credentials holder.
+type credentialsHolderKey struct{}
+
+// credentialsHolder captures the credentials produced during a callback request.
+type credentialsHolder struct {
+	mu    sync.Mutex
+	creds *configstore.AppCredentials
+}
+
+// recordCredentials stores creds in the request's holder, if one is present.
+func recordCredentials(ctx context.Context, creds *configstore.AppCredentials) {
+	holder, ok := ctx.Value(credentialsHolderKey{}).(*credentialsHolder)
+	if !ok {
+		return
+	}
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	holder.creds = creds
+}
+
+var manifestPanelTemplate = template.Must(template.New("panel").Parse(`
+    <div class="container" style="margin-top:16px;">
+        <div class="next-step">
+            <h3>Deploying to Kubernetes?</h3>
+            <p>
+                Download a ready-to-apply manifest containing these credentials. It is only
+                offered once &mdash; store it somewhere safe and apply it with <code>kubectl apply -f</code>.
+            </p>
+            <a href="{{.Href}}" download="{{.Filename}}" class="btn">Download Kubernetes Manifest</a>
+        </div>
+    </div>
+`))
+
+// NewKubernetesManifestHandler wraps the installer handler so the success page
+// rendered after the GitHub callback offers a Kubernetes manifest download.
+//
+// The manifest is embedded in the page as a data URI rather than served from a
+// separate endpoint, so credentials are never re-read from the store and the
+// download works on stateless runtimes such as Lambda.
+func NewKubernetesManifestHandler(next http.Handler, cfg KubernetesManifestConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/callback" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		holder := &credentialsHolder{}
+		r = r.WithContext(context.WithValue(r.Context(), credentialsHolderKey{}, holder))
+
+		rec := newBufferedResponseWriter()
+		next.ServeHTTP(rec, r)
+
+		holder.mu.Lock()
+		creds := holder.creds
+		holder.mu.Unlock()
+
+		body := rec.body.Bytes()
+		if creds != nil && rec.statusCode == http.StatusOK &&
+			strings.HasPrefix(rec.header.Get("Content-Type"), "text/html") {
+			if injected, err := injectManifestPanel(body, creds, cfg); err != nil {
+				clog.FromContext(r.Context()).Warnf("[installer] failed to render kubernetes manifest: %v", err)
+			} else {
+				body = injected
+			}
+		}
+
+		for k, v := range rec.header {
+			w.Header()[k] = v
+		}
+		w.Header().Del("Content-Length")
+		w.WriteHeader(rec.statusCode)
+		if _, err := w.Write(body); err != nil {
+			clog.FromContext(r.Context()).Errorf("[installer] failed to write response: %v", err)
+		}
+	})
+}
+
+// injectManifestPanel inserts the manifest download panel before the closing body tag.
+func injectManifestPanel(page []byte, creds *configstore.AppCredentials, cfg KubernetesManifestConfig) ([]byte, error) {
+	manifest, err := KubernetesManifest(creds, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := bytes.LastIndex(page, []byte("</body>"))
+	if idx == -1 {
+		return nil, fmt.Errorf("success page has no closing body tag")
+	}
+
+	filename := cfg.SecretName
+	if filename == "" {
+		filename = DefaultKubernetesSecretName
+	}
+
+	var panel bytes.Buffer
+	if err := manifestPanelTemplate.Execute(&panel, struct {
+		Href     template.URL
+		Filename string
+	}{
+		// The manifest is base64 encoded, so the data URI is safe to trust.
+		Href:     template.URL("data:application/yaml;base64," + base64.StdEncoding.EncodeToString(manifest)),
+		Filename: filename + "-secret.yaml",
+	}); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(page)+panel.Len())
+	out = append(out, page[:idx]...)
+	out = append(out, panel.Bytes()...)
+	out = append(out, page[idx:]...)
+	return out, nil
+}
+
+// bufferedResponseWriter captures a response so it can be modified before
+// being written to the client.
+type bufferedResponseWriter struct {
+	header     http.Header
+	statusCode int
+	body       *bytes.Buffer
+}
+
+// newBufferedResponseWriter creates a new bufferedResponseWriter with default values.
+func newBufferedResponseWriter() *bufferedResponseWriter {
+	return &bufferedResponseWriter{
+		header:     make(http.Header),
+		statusCode: http.StatusOK,
+		body:       new(bytes.Buffer),
+	}
+}
+
+// Header returns the captured response headers.
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+// Write writes the data to the response body buffer.
+func (b *bufferedResponseWriter) Write(data []byte) (int, error) {
+	return b.body.Write(data)
+}
+
+// WriteHeader records the status code.
+func (b *bufferedResponseWriter) WriteHeader(statusCode int) {
+	b.statusCode = statusCode
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package installer
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cruxstack/github-app-setup-go/configstore"
+
+	internalstore "github.com/cruxstack/octo-sts-distros/internal/configstore"
+	"github.com/cruxstack/octo-sts-distros/internal/version"
+)
+
+// principalHeaders are set by authenticating proxies in front of the
+// installer (oauth2-proxy, ALB OIDC authentication, ...) to identify the
+// user. They are checked in order.
+var principalHeaders = []string{
+	"X-Auth-Request-Email",
+	"X-Auth-Request-User",
+	"X-Forwarded-Email",
+	"X-Forwarded-User",
+	"X-Amzn-Oidc-Identity",
+}
+
+type requesterKey struct{}
+
+// NewMetadataHandler wraps the installer handler so the credentials saved by
+// the GitHub callback record who ran the setup. See NewOctoSTSConfig.
+func NewMetadataHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/callback" {
+			r = r.WithContext(context.WithValue(r.Context(), requesterKey{}, requester(r)))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requester identifies the user making r: the principal reported by an
+// authenticating proxy, else the client IP. Both come from headers that
+// clients can set themselves unless a proxy overwrites them, so they are
+// informational only.
+func requester(r *http.Request) string {
+	for _, header := range principalHeaders {
+		if value := strings.TrimSpace(r.Header.Get(header)); value != "" {
+			return value
+		}
+	}
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		client, _, _ := strings.Cut(forwarded, ",")
+		if client = strings.TrimSpace(client); client != "" {
+			return client
+		}
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// setupMetadata returns the metadata recorded for credentials created by a
+// setup against githubURL.
+func setupMetadata(ctx context.Context, githubURL string) internalstore.Metadata {
+	m := internalstore.Metadata{
+		CreatedAt:        time.Now(),
+		InstallerVersion: installerVersion(),
+	}
+	m.CreatedBy, _ = ctx.Value(requesterKey{}).(string)
+	if githubURL == "" {
+		githubURL = internalstore.DefaultGitHubURL
+	}
+	if u, err := url.Parse(githubURL); err == nil {
+		m.GitHubHost = u.Host
+	}
+	return m
+}
+
+// recordMetadata records the metadata of the setup in creds.
+func recordMetadata(ctx context.Context, creds *configstore.AppCredentials, githubURL string) {
+	internalstore.SetMetadata(creds, setupMetadata(ctx, githubURL))
+}
+
+// installerVersion returns the version of the running build, or "devel"
+// when it was built without one.
+func installerVersion() string {
+	return version.Get().Version
+}
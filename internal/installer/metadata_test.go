@@ -0,0 +1,57 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package installer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cruxstack/github-app-setup-go/configstore"
+
+	internalstore "github.com/cruxstack/octo-sts-distros/internal/configstore"
+)
+
+func TestMetadataHandler(t *testing.T) {
+	t.Setenv(EnvGitHubURL, "https://ghe.example.com")
+	cfg := NewOctoSTSConfig(nil)
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		wantBy string
+	}{
+		{name: "client ip", wantBy: "192.0.2.1"},
+		{name: "forwarded for", header: map[string]string{"X-Forwarded-For": "203.0.113.7, 10.0.0.1"}, wantBy: "203.0.113.7"},
+		{name: "proxy principal", header: map[string]string{"X-Forwarded-For": "203.0.113.7", "X-Auth-Request-Email": "octocat@example.com"}, wantBy: "octocat@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var creds *configstore.AppCredentials
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				creds = &configstore.AppCredentials{AppID: 1}
+				if err := cfg.OnCredentialsSaved(r.Context(), creds); err != nil {
+					t.Fatalf("OnCredentialsSaved() error = %v", err)
+				}
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/callback?code=abc", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			NewMetadataHandler(next).ServeHTTP(httptest.NewRecorder(), req)
+
+			fields := creds.CustomFields
+			if got := fields[internalstore.EnvGitHubAppCreatedBy]; got != tt.wantBy {
+				t.Errorf("created by = %q, want %q", got, tt.wantBy)
+			}
+			if got := fields[internalstore.EnvGitHubAppHost]; got != "ghe.example.com" {
+				t.Errorf("github host = %q, want ghe.example.com", got)
+			}
+			if fields[internalstore.EnvGitHubAppCreatedAt] == "" || fields[internalstore.EnvGitHubAppInstallerVersion] == "" {
+				t.Errorf("expected creation time and installer version, got %v", fields)
+			}
+		})
+	}
+}
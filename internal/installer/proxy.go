@@ -0,0 +1,105 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package installer
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// EnvTrustedProxies is a comma-separated list of the CIDRs or IPs of the
+// reverse proxies in front of the installer. When set, forwarded headers
+// are only honored on connections from them.
+const EnvTrustedProxies = "INSTALLER_TRUSTED_PROXIES"
+
+// forwardedHeaders are set by reverse proxies to describe the original
+// request. The installer builds the redirect and webhook URLs of the app
+// manifest from X-Forwarded-Host and X-Forwarded-Proto, and records the
+// requester from X-Forwarded-For and the X-Forwarded-Email/User principals.
+var forwardedHeaders = []string{
+	"Forwarded",
+	"X-Forwarded-Email",
+	"X-Forwarded-For",
+	"X-Forwarded-Host",
+	"X-Forwarded-Port",
+	"X-Forwarded-Proto",
+	"X-Forwarded-User",
+}
+
+// TrustedProxiesFromEnv parses INSTALLER_TRUSTED_PROXIES. It returns nil
+// when the variable is unset, which trusts every peer.
+func TrustedProxiesFromEnv() ([]netip.Prefix, error) {
+	return ParseTrustedProxies(os.Getenv(EnvTrustedProxies))
+}
+
+// ParseTrustedProxies parses a comma-separated list of CIDRs or IPs.
+func ParseTrustedProxies(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, s := range strings.Split(value, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if strings.Contains(s, "/") {
+			prefix, err := netip.ParsePrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// NewTrustedProxyHandler wraps the installer handler so forwarded headers
+// are removed from requests whose peer is not in trusted, leaving the
+// installer with the Host and connection of the request itself. Anyone
+// able to reach the installer could otherwise point the app's callback and
+// webhook URLs at a host of their choosing. With no trusted proxies, next is
+// returned as is.
+func NewTrustedProxyHandler(next http.Handler, trusted []netip.Prefix) http.Handler {
+	if len(trusted) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isTrustedPeer(r.RemoteAddr, trusted) {
+			r = r.Clone(r.Context())
+			for _, header := range forwardedHeaders {
+				r.Header.Del(header)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isTrustedPeer reports whether remoteAddr, as in http.Request.RemoteAddr,
+// is in one of the trusted prefixes. Peers without an IP, such as Unix
+// socket clients, are not trusted.
+func isTrustedPeer(remoteAddr string, trusted []netip.Prefix) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package installer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "cidrs and ips", value: "10.0.0.0/8, 192.0.2.1,fd00::/8", want: []string{"10.0.0.0/8", "192.0.2.1/32", "fd00::/8"}},
+		{name: "unmasked cidr", value: "10.1.2.3/16", want: []string{"10.1.0.0/16"}},
+		{name: "invalid ip", value: "10.0.0.256", wantErr: true},
+		{name: "invalid cidr", value: "10.0.0.0/33", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTrustedProxies(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTrustedProxies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseTrustedProxies() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].String() != tt.want[i] {
+					t.Errorf("ParseTrustedProxies()[%d] = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTrustedProxyHandler(t *testing.T) {
+	trusted, err := ParseTrustedProxies("10.0.0.0/8,::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		wantHost   string
+	}{
+		{name: "trusted proxy", remoteAddr: "10.1.2.3:51234", wantHost: "octo-sts.example.com"},
+		{name: "trusted ipv6 proxy", remoteAddr: "[::1]:51234", wantHost: "octo-sts.example.com"},
+		{name: "ipv4-mapped trusted proxy", remoteAddr: "[::ffff:10.1.2.3]:51234", wantHost: "octo-sts.example.com"},
+		{name: "untrusted peer", remoteAddr: "203.0.113.7:51234"},
+		{name: "unix socket peer", remoteAddr: "@"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotHost, gotProto, gotFor string
+			h := NewTrustedProxyHandler(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				gotHost = r.Header.Get("X-Forwarded-Host")
+				gotProto = r.Header.Get("X-Forwarded-Proto")
+				gotFor = r.Header.Get("X-Forwarded-For")
+			}), trusted)
+
+			req := httptest.NewRequest(http.MethodGet, "/setup", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-Host", "octo-sts.example.com")
+			req.Header.Set("X-Forwarded-Proto", "https")
+			req.Header.Set("X-Forwarded-For", "198.51.100.1")
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if gotHost != tt.wantHost {
+				t.Errorf("X-Forwarded-Host = %q, want %q", gotHost, tt.wantHost)
+			}
+			if trustedPeer := tt.wantHost != ""; (gotProto != "") != trustedPeer || (gotFor != "") != trustedPeer {
+				t.Errorf("X-Forwarded-Proto = %q, X-Forwarded-For = %q; want them kept only for trusted proxies", gotProto, gotFor)
+			}
+		})
+	}
+
+	// Without trusted proxies every peer is trusted
+	var gotHost string
+	h := NewTrustedProxyHandler(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		gotHost = r.Header.Get("X-Forwarded-Host")
+	}), nil)
+	req := httptest.NewRequest(http.MethodGet, "/setup", nil)
+	req.Header.Set("X-Forwarded-Host", "octo-sts.example.com")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if gotHost != "octo-sts.example.com" {
+		t.Errorf("X-Forwarded-Host without trusted proxies = %q, want it kept", gotHost)
+	}
+}
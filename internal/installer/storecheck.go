@@ -0,0 +1,95 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package installer
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+)
+
+// storeCheckTimeout bounds the store check made before the setup page renders.
+const storeCheckTimeout = 5 * time.Second
+
+// Pinger checks that a config store is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// readOnlyStore is implemented by stores that reject writes, such as
+// configstore.ReadOnlyStore.
+type readOnlyStore interface {
+	ReadOnly() bool
+}
+
+var storeErrorTemplate = template.Must(template.New("store-error").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Storage Unavailable</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; background: #f6f8fa; color: #24292f; margin: 0; padding: 40px 16px; }
+        .container { max-width: 640px; margin: 0 auto; background: #fff; border: 1px solid #d0d7de; border-radius: 6px; padding: 24px; }
+        h1 { font-size: 20px; margin-top: 0; color: #cf222e; }
+        pre { background: #f6f8fa; border-radius: 6px; padding: 12px; white-space: pre-wrap; word-break: break-word; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <h1>Credential storage is unavailable</h1>
+        <p>
+            The installer cannot reach its configured storage backend. GitHub only
+            returns the new app's credentials once, so fix the storage configuration
+            (permissions, network access, or encryption keys) and reload this page
+            before creating the app.
+        </p>
+        <pre>{{.}}</pre>
+    </div>
+</body>
+</html>
+`))
+
+// NewStoreCheckHandler wraps the installer handler so the setup page checks the
+// config store before offering the manifest flow. If the store is unreachable,
+// an error page is shown instead, so a misconfigured backend is found before
+// GitHub hands over credentials that could not be saved. A read-only store is
+// reported the same way.
+func NewStoreCheckHandler(next http.Handler, store Pinger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (r.Method != http.MethodGet && r.Method != http.MethodHead) || (r.URL.Path != "/setup" && r.URL.Path != "/setup/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var err error
+		if ro, ok := store.(readOnlyStore); ok && ro.ReadOnly() {
+			err = fmt.Errorf("%w; unset %s to save new credentials", configstore.ErrReadOnly, configstore.EnvStorageReadOnly)
+		} else {
+			ctx, cancel := context.WithTimeout(r.Context(), storeCheckTimeout)
+			err = store.Ping(ctx)
+			cancel()
+		}
+		if err == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		log := clog.FromContext(r.Context())
+		log.Errorf("[installer] config store check failed: %v", err)
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if err := storeErrorTemplate.Execute(w, err.Error()); err != nil {
+			log.Errorf("[installer] failed to write response: %v", err)
+		}
+	})
+}
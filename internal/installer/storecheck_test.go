@@ -0,0 +1,72 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package installer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// pingFunc adapts a function to the Pinger interface.
+type pingFunc func(context.Context) error
+
+func (f pingFunc) Ping(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestStoreCheckHandler(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("next"))
+	})
+
+	var pingErr error
+	handler := NewStoreCheckHandler(next, pingFunc(func(context.Context) error { return pingErr }))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/setup", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "next" {
+		t.Errorf("healthy store: got %d %q", rec.Code, rec.Body.String())
+	}
+
+	pingErr = errors.New("AccessDeniedException: <ssm:GetParametersByPath>")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/setup/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("unreachable store: status = %d, want 503", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "AccessDeniedException: &lt;ssm:GetParametersByPath&gt;") {
+		t.Errorf("expected escaped store error in page, got %q", body)
+	}
+
+	// Other installer routes are not gated.
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/callback?code=abc", nil))
+	if rec.Body.String() != "next" {
+		t.Errorf("callback should pass through, got %q", rec.Body.String())
+	}
+}
+
+// readOnlyPinger is a healthy store that rejects writes.
+type readOnlyPinger struct{}
+
+func (readOnlyPinger) Ping(context.Context) error { return nil }
+func (readOnlyPinger) ReadOnly() bool             { return true }
+
+func TestStoreCheckHandlerReadOnly(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("next"))
+	})
+	handler := NewStoreCheckHandler(next, readOnlyPinger{})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/setup", nil))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "read-only") {
+		t.Errorf("read-only store: got %d %q", rec.Code, rec.Body.String())
+	}
+}
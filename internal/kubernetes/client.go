@@ -0,0 +1,408 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package kubernetes provides a minimal Kubernetes API client for reading and
+// writing Secrets, configured from the in-cluster service account or a
+// kubeconfig file.
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Environment variables for Kubernetes client configuration.
+const (
+	EnvKubeconfig         = "KUBECONFIG"
+	EnvServiceHost        = "KUBERNETES_SERVICE_HOST"
+	EnvServicePort        = "KUBERNETES_SERVICE_PORT"
+	serviceAccountDir     = "/var/run/secrets/kubernetes.io/serviceaccount"
+	serviceAccountToken   = serviceAccountDir + "/token"
+	serviceAccountCA      = serviceAccountDir + "/ca.crt"
+	serviceAccountNS      = serviceAccountDir + "/namespace"
+	defaultNamespace      = "default"
+	mergePatchContentType = "application/merge-patch+json"
+)
+
+// DefaultTimeout is the default timeout for Kubernetes API requests.
+const DefaultTimeout = 10 * time.Second
+
+// ErrNotFound is returned when a resource does not exist.
+var ErrNotFound = errors.New("kubernetes resource not found")
+
+// Client is a minimal Kubernetes API client.
+type Client struct {
+	// Host is the API server URL.
+	Host string
+
+	// Namespace is the default namespace from the service account or kubeconfig context.
+	Namespace string
+
+	token      string
+	tokenFile  string
+	httpClient *http.Client
+}
+
+// NewClient creates a client using the in-cluster service account when
+// running in a pod, or the kubeconfig at KUBECONFIG (default ~/.kube/config)
+// otherwise.
+func NewClient() (*Client, error) {
+	if os.Getenv(EnvServiceHost) != "" {
+		return NewInClusterClient()
+	}
+
+	path := os.Getenv(EnvKubeconfig)
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate kubeconfig: %w", err)
+		}
+		path = filepath.Join(home, ".kube", "config")
+	}
+	// KUBECONFIG may list several files; the first one is used.
+	path = filepath.SplitList(path)[0]
+	return NewKubeconfigClient(path)
+}
+
+// NewInClusterClient creates a client from the pod's service account.
+// The token is re-read on every request so projected token rotation is honored.
+func NewInClusterClient() (*Client, error) {
+	host, port := os.Getenv(EnvServiceHost), os.Getenv(EnvServicePort)
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a cluster: %s and %s must be set", EnvServiceHost, EnvServicePort)
+	}
+
+	ca, err := os.ReadFile(serviceAccountCA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA: %w", err)
+	}
+	tlsConfig, err := newTLSConfig(ca, nil, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := defaultNamespace
+	if ns, err := os.ReadFile(serviceAccountNS); err == nil && len(bytes.TrimSpace(ns)) > 0 {
+		namespace = string(bytes.TrimSpace(ns))
+	}
+
+	return &Client{
+		Host:       "https://" + hostPort(host, port),
+		Namespace:  namespace,
+		tokenFile:  serviceAccountToken,
+		httpClient: newHTTPClient(tlsConfig),
+	}, nil
+}
+
+// hostPort joins a host and port, bracketing IPv6 addresses.
+func hostPort(host, port string) string {
+	if strings.Contains(host, ":") {
+		return "[" + host + "]:" + port
+	}
+	return host + ":" + port
+}
+
+// kubeconfig is the subset of the kubeconfig format understood by this client.
+type kubeconfig struct {
+	CurrentContext string `json:"current-context"`
+	Clusters       []struct {
+		Name    string `json:"name"`
+		Cluster struct {
+			Server                   string `json:"server"`
+			CertificateAuthority     string `json:"certificate-authority"`
+			CertificateAuthorityData string `json:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+		} `json:"cluster"`
+	} `json:"clusters"`
+	Contexts []struct {
+		Name    string `json:"name"`
+		Context struct {
+			Cluster   string `json:"cluster"`
+			User      string `json:"user"`
+			Namespace string `json:"namespace"`
+		} `json:"context"`
+	} `json:"contexts"`
+	Users []struct {
+		Name string `json:"name"`
+		User struct {
+			Token                 string `json:"token"`
+			TokenFile             string `json:"tokenFile"`
+			ClientCertificate     string `json:"client-certificate"`
+			ClientCertificateData string `json:"client-certificate-data"`
+			ClientKey             string `json:"client-key"`
+			ClientKeyData         string `json:"client-key-data"`
+			Exec                  any    `json:"exec"`
+		} `json:"user"`
+	} `json:"users"`
+}
+
+// NewKubeconfigClient creates a client from the current context of a kubeconfig file.
+// Token and client certificate authentication are supported; exec plugins are not.
+func NewKubeconfigClient(path string) (*Client, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	var cfg kubeconfig
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+	base := filepath.Dir(path)
+
+	client := &Client{Namespace: defaultNamespace}
+
+	var clusterName, userName string
+	for _, c := range cfg.Contexts {
+		if c.Name == cfg.CurrentContext {
+			clusterName, userName = c.Context.Cluster, c.Context.User
+			if c.Context.Namespace != "" {
+				client.Namespace = c.Context.Namespace
+			}
+		}
+	}
+	if clusterName == "" {
+		return nil, fmt.Errorf("kubeconfig current context %q not found", cfg.CurrentContext)
+	}
+
+	var ca []byte
+	var insecure bool
+	for _, c := range cfg.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		client.Host = strings.TrimSuffix(c.Cluster.Server, "/")
+		insecure = c.Cluster.InsecureSkipTLSVerify
+		if ca, err = dataOrFile(c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority, base); err != nil {
+			return nil, fmt.Errorf("failed to load cluster CA: %w", err)
+		}
+	}
+	if client.Host == "" {
+		return nil, fmt.Errorf("kubeconfig cluster %q not found", clusterName)
+	}
+
+	var cert, key []byte
+	for _, u := range cfg.Users {
+		if u.Name != userName {
+			continue
+		}
+		if u.User.Exec != nil {
+			return nil, fmt.Errorf("kubeconfig user %q uses an exec plugin, which is not supported", userName)
+		}
+		client.token = u.User.Token
+		if u.User.TokenFile != "" {
+			client.tokenFile = resolvePath(u.User.TokenFile, base)
+		}
+		if cert, err = dataOrFile(u.User.ClientCertificateData, u.User.ClientCertificate, base); err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		if key, err = dataOrFile(u.User.ClientKeyData, u.User.ClientKey, base); err != nil {
+			return nil, fmt.Errorf("failed to load client key: %w", err)
+		}
+	}
+
+	tlsConfig, err := newTLSConfig(ca, cert, key, insecure)
+	if err != nil {
+		return nil, err
+	}
+	client.httpClient = newHTTPClient(tlsConfig)
+	return client, nil
+}
+
+// dataOrFile returns base64-decoded inline data, or the contents of file.
+func dataOrFile(data, file, base string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file != "" {
+		return os.ReadFile(resolvePath(file, base))
+	}
+	return nil, nil
+}
+
+// resolvePath resolves kubeconfig paths relative to the kubeconfig file.
+func resolvePath(path, base string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(base, path)
+}
+
+func newTLSConfig(ca, cert, key []byte, insecure bool) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: insecure, //nolint:gosec // explicitly requested by kubeconfig
+	}
+	if len(ca) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("failed to parse cluster CA certificate")
+		}
+		cfg.RootCAs = pool
+	}
+	if len(cert) > 0 && len(key) > 0 {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+	}
+	return cfg, nil
+}
+
+func newHTTPClient(tlsConfig *tls.Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport, Timeout: DefaultTimeout}
+}
+
+// Secret is the subset of a Kubernetes Secret used by this package.
+type Secret struct {
+	Name      string
+	Namespace string
+	Labels    map[string]string
+	Data      map[string][]byte
+}
+
+// secretObject is the JSON representation of a Secret.
+type secretObject struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Metadata   struct {
+		Name      string            `json:"name,omitempty"`
+		Namespace string            `json:"namespace,omitempty"`
+		Labels    map[string]string `json:"labels,omitempty"`
+	} `json:"metadata"`
+	Type string            `json:"type,omitempty"`
+	Data map[string][]byte `json:"data"`
+}
+
+// GetSecret reads a Secret. Returns ErrNotFound if it does not exist.
+func (c *Client) GetSecret(ctx context.Context, namespace, name string) (*Secret, error) {
+	var obj secretObject
+	if err := c.do(ctx, http.MethodGet, secretPath(namespace, name), "", nil, &obj); err != nil {
+		return nil, err
+	}
+	return &Secret{
+		Name:      obj.Metadata.Name,
+		Namespace: obj.Metadata.Namespace,
+		Labels:    obj.Metadata.Labels,
+		Data:      obj.Data,
+	}, nil
+}
+
+// ApplySecret merges data and labels into a Secret, creating it if it doesn't
+// exist. Keys not present in data are left untouched and keys with a nil
+// value are removed.
+func (c *Client) ApplySecret(ctx context.Context, secret *Secret) error {
+	var obj secretObject
+	obj.Metadata.Labels = secret.Labels
+	obj.Data = secret.Data
+
+	err := c.do(ctx, http.MethodPatch, secretPath(secret.Namespace, secret.Name), mergePatchContentType, obj, nil)
+	if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	obj.Data = make(map[string][]byte, len(secret.Data))
+	for key, value := range secret.Data {
+		if value != nil {
+			obj.Data[key] = value
+		}
+	}
+	obj.APIVersion = "v1"
+	obj.Kind = "Secret"
+	obj.Type = "Opaque"
+	obj.Metadata.Name = secret.Name
+	obj.Metadata.Namespace = secret.Namespace
+	return c.do(ctx, http.MethodPost, "/api/v1/namespaces/"+url.PathEscape(secret.Namespace)+"/secrets",
+		"application/json", obj, nil)
+}
+
+// DeleteSecret deletes a Secret. Deleting a Secret that does not exist is
+// not an error.
+func (c *Client) DeleteSecret(ctx context.Context, namespace, name string) error {
+	err := c.do(ctx, http.MethodDelete, secretPath(namespace, name), "", nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func secretPath(namespace, name string) string {
+	return "/api/v1/namespaces/" + url.PathEscape(namespace) + "/secrets/" + url.PathEscape(name)
+}
+
+// do sends an authenticated request to the API server.
+func (c *Client) do(ctx context.Context, method, path, contentType string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.Host+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	token := c.token
+	if c.tokenFile != "" {
+		raw, err := os.ReadFile(c.tokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read token file: %w", err)
+		}
+		token = strings.TrimSpace(string(raw))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("kubernetes request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var status struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&status) == nil && status.Message != "" {
+			return fmt.Errorf("kubernetes returned status %d: %s", resp.StatusCode, status.Message)
+		}
+		return fmt.Errorf("kubernetes returned status %d", resp.StatusCode)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode kubernetes response: %w", err)
+	}
+	return nil
+}
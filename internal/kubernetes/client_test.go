@@ -0,0 +1,187 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package kubernetes
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestClient returns a client of a TLS server answering with handler,
+// configured from a kubeconfig whose user has the given fields.
+func newTestClient(t *testing.T, user string, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewTLSServer(handler)
+	t.Cleanup(srv.Close)
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: test
+clusters:
+- name: test
+  cluster:
+    server: %s/
+    certificate-authority-data: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+    namespace: octo-sts
+users:
+- name: test
+  user:
+%s
+`, srv.URL, base64.StdEncoding.EncodeToString(ca), user)
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(kubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewKubeconfigClient(path)
+	if err != nil {
+		t.Fatalf("NewKubeconfigClient() error = %v", err)
+	}
+	return c
+}
+
+func TestKubeconfigToken(t *testing.T) {
+	c := newTestClient(t, "    token: static-token", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer static-token" {
+			t.Errorf("Authorization = %q, want the kubeconfig token", got)
+		}
+		w.Write([]byte(`{"metadata":{"name":"octo-sts","namespace":"octo-sts"},"data":{"GITHUB_APP_ID":"MTIz"}}`))
+	})
+	if c.Namespace != "octo-sts" {
+		t.Errorf("Namespace = %q, want the namespace of the context", c.Namespace)
+	}
+
+	secret, err := c.GetSecret(context.Background(), c.Namespace, "octo-sts")
+	if err != nil {
+		t.Fatalf("GetSecret() error = %v", err)
+	}
+	if string(secret.Data["GITHUB_APP_ID"]) != "123" {
+		t.Errorf("GetSecret() data = %q, want the decoded value", secret.Data)
+	}
+}
+
+func TestTokenFileRotation(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("first\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	c := newTestClient(t, "    tokenFile: "+tokenFile, func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"metadata":{}}`))
+	})
+
+	ctx := context.Background()
+	c.GetSecret(ctx, "octo-sts", "app")
+	if err := os.WriteFile(tokenFile, []byte("second\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c.GetSecret(ctx, "octo-sts", "app")
+	if strings.Join(got, ",") != "Bearer first,Bearer second" {
+		t.Errorf("Authorization = %v, want the token file re-read on each request", got)
+	}
+}
+
+func TestKubeconfigExecPlugin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	kubeconfig := `current-context: test
+clusters:
+- name: test
+  cluster:
+    server: https://example.com
+contexts:
+- name: test
+  context: {cluster: test, user: test}
+users:
+- name: test
+  user:
+    exec: {command: aws}
+`
+	if err := os.WriteFile(path, []byte(kubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewKubeconfigClient(path); err == nil || !strings.Contains(err.Error(), "exec plugin") {
+		t.Errorf("NewKubeconfigClient() error = %v, want exec plugins rejected", err)
+	}
+}
+
+func TestSecretPathEscaping(t *testing.T) {
+	c := newTestClient(t, "    token: t", func(w http.ResponseWriter, r *http.Request) {
+		if want := "/api/v1/namespaces/team%2Fa/secrets/octo%20sts"; r.URL.EscapedPath() != want {
+			t.Errorf("path = %q, want %q", r.URL.EscapedPath(), want)
+		}
+		w.Write([]byte(`{"metadata":{}}`))
+	})
+	if _, err := c.GetSecret(context.Background(), "team/a", "octo sts"); err != nil {
+		t.Errorf("GetSecret() error = %v", err)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	var created map[string]any
+	c := newTestClient(t, "    token: t", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if r.URL.Path != "/api/v1/namespaces/octo-sts/secrets" {
+				t.Errorf("create path = %q", r.URL.Path)
+			}
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"kind":"Status","message":"secrets \"app\" not found"}`))
+	})
+	ctx := context.Background()
+
+	if _, err := c.GetSecret(ctx, "octo-sts", "app"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetSecret() of a missing secret error = %v, want ErrNotFound", err)
+	}
+	if err := c.DeleteSecret(ctx, "octo-sts", "app"); err != nil {
+		t.Errorf("DeleteSecret() of a missing secret error = %v, want nil", err)
+	}
+
+	// Applying to a missing secret creates it, without the removed keys
+	err := c.ApplySecret(ctx, &Secret{Name: "app", Namespace: "octo-sts", Data: map[string][]byte{"KEPT": []byte("v"), "REMOVED": nil}})
+	if err != nil {
+		t.Fatalf("ApplySecret() error = %v", err)
+	}
+	data, _ := created["data"].(map[string]any)
+	if created["kind"] != "Secret" || data["KEPT"] != base64.StdEncoding.EncodeToString([]byte("v")) || data["REMOVED"] != nil {
+		t.Errorf("created secret = %v, want KEPT only", created)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	body := `{"kind":"Status","message":"secrets is forbidden: User \"system:serviceaccount:octo-sts:app\" cannot get resource"}`
+	c := newTestClient(t, "    token: t", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(body))
+	})
+
+	_, err := c.GetSecret(context.Background(), "octo-sts", "app")
+	if err == nil || !strings.Contains(err.Error(), "status 403: secrets is forbidden") {
+		t.Errorf("GetSecret() error = %v, want the status and its message", err)
+	}
+
+	body = "upstream connect error"
+	_, err = c.GetSecret(context.Background(), "octo-sts", "app")
+	if err == nil || err.Error() != "kubernetes returned status 403" {
+		t.Errorf("GetSecret() with a non-JSON body error = %v, want the status only", err)
+	}
+}
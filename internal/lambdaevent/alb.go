@@ -0,0 +1,68 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// FromALB converts an ALB target group request to an API Gateway v2
+// request. Multi-value headers and query parameters are joined with commas,
+// as API Gateway does, and a base64-encoded body is decoded.
+func FromALB(req events.ALBTargetGroupRequest) (events.APIGatewayV2HTTPRequest, error) {
+	body, err := decodeBody(req.Body, req.IsBase64Encoded)
+	if err != nil {
+		return events.APIGatewayV2HTTPRequest{}, fmt.Errorf("failed to decode ALB request body: %w", err)
+	}
+
+	// ALB passes the query string as received, still URL-encoded.
+	query := mergeValues(req.QueryStringParameters, req.MultiValueQueryStringParameters)
+	var raw []string
+	params := make(map[string]string, len(query))
+	for _, k := range slices.Sorted(maps.Keys(query)) {
+		decoded := make([]string, 0, len(query[k]))
+		for _, v := range query[k] {
+			raw = append(raw, k+"="+v)
+			decoded = append(decoded, unescape(v))
+		}
+		params[unescape(k)] = strings.Join(decoded, ",")
+	}
+
+	return newRequest(req.HTTPMethod, req.Path, strings.Join(raw, "&"), params,
+		joinHeaders(req.Headers, req.MultiValueHeaders), body), nil
+}
+
+// ToALB converts an API Gateway v2 response to an ALB target group
+// response. multiValue must match the multi-value headers setting of the
+// target group; without it only the first cookie is set.
+func ToALB(resp events.APIGatewayV2HTTPResponse, multiValue bool) events.ALBTargetGroupResponse {
+	alb := events.ALBTargetGroupResponse{
+		StatusCode:        resp.StatusCode,
+		StatusDescription: fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode)),
+		Body:              resp.Body,
+		IsBase64Encoded:   resp.IsBase64Encoded,
+	}
+
+	if multiValue {
+		alb.MultiValueHeaders = responseHeader(resp)
+		return alb
+	}
+
+	header := responseHeader(resp)
+	alb.Headers = make(map[string]string, len(header))
+	for k, vs := range header {
+		if k == headerSetCookie {
+			alb.Headers[k] = vs[0]
+			continue
+		}
+		alb.Headers[k] = strings.Join(vs, ",")
+	}
+	return alb
+}
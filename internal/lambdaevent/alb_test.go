@@ -0,0 +1,64 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestFromALB(t *testing.T) {
+	req, err := FromALB(events.ALBTargetGroupRequest{
+		HTTPMethod:            http.MethodGet,
+		Path:                  "/healthz",
+		QueryStringParameters: map[string]string{"deep": "1", "name": "a%20b"},
+		Headers: map[string]string{
+			"X-Forwarded-For": "203.0.113.7, 10.0.0.1",
+			"User-Agent":      "probe",
+			"Host":            "octo-sts.example.com",
+		},
+	})
+	if err != nil {
+		t.Fatalf("FromALB() error = %v", err)
+	}
+	if req.RawQueryString != "deep=1&name=a%20b" {
+		t.Errorf("RawQueryString = %q, want %q", req.RawQueryString, "deep=1&name=a%20b")
+	}
+	if want := map[string]string{"deep": "1", "name": "a b"}; !reflect.DeepEqual(req.QueryStringParameters, want) {
+		t.Errorf("QueryStringParameters = %v, want %v", req.QueryStringParameters, want)
+	}
+	if req.RequestContext.HTTP.SourceIP != "203.0.113.7" {
+		t.Errorf("SourceIP = %q, want %q", req.RequestContext.HTTP.SourceIP, "203.0.113.7")
+	}
+	if req.RequestContext.DomainName != "octo-sts.example.com" {
+		t.Errorf("DomainName = %q, want the host header", req.RequestContext.DomainName)
+	}
+	if req.Headers["user-agent"] != "probe" {
+		t.Errorf("Headers = %v, want lower-cased names", req.Headers)
+	}
+
+	if _, err := FromALB(events.ALBTargetGroupRequest{IsBase64Encoded: true, Body: "not base64!"}); err == nil {
+		t.Error("FromALB() expected an error for a malformed base64 body")
+	}
+}
+
+func TestToALBSingleValue(t *testing.T) {
+	resp := ToALB(events.APIGatewayV2HTTPResponse{
+		StatusCode:        http.StatusServiceUnavailable,
+		Headers:           map[string]string{"Retry-After": "5"},
+		MultiValueHeaders: map[string][]string{"Vary": {"Accept", "Origin"}},
+		Cookies:           []string{"a=1", "b=2"},
+	}, false)
+
+	want := map[string]string{"Retry-After": "5", "Vary": "Accept,Origin", "Set-Cookie": "a=1"}
+	if !reflect.DeepEqual(resp.Headers, want) {
+		t.Errorf("Headers = %v, want %v", resp.Headers, want)
+	}
+	if resp.StatusDescription != "503 Service Unavailable" {
+		t.Errorf("StatusDescription = %q, want %q", resp.StatusDescription, "503 Service Unavailable")
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// FromAPIGateway converts an API Gateway REST API (payload format 1.0)
+// request to an API Gateway v2 request. The path excludes the stage, as REST
+// APIs route on it, and a base64-encoded body is decoded.
+func FromAPIGateway(req events.APIGatewayProxyRequest) (events.APIGatewayV2HTTPRequest, error) {
+	body, err := decodeBody(req.Body, req.IsBase64Encoded)
+	if err != nil {
+		return events.APIGatewayV2HTTPRequest{}, fmt.Errorf("failed to decode API Gateway request body: %w", err)
+	}
+
+	// API Gateway passes query parameters decoded, so they are encoded again
+	// for the raw query string.
+	query := mergeValues(req.QueryStringParameters, req.MultiValueQueryStringParameters)
+	var raw []string
+	params := make(map[string]string, len(query))
+	for _, k := range slices.Sorted(maps.Keys(query)) {
+		for _, v := range query[k] {
+			raw = append(raw, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+		params[k] = strings.Join(query[k], ",")
+	}
+
+	v2 := newRequest(req.HTTPMethod, req.Path, strings.Join(raw, "&"), params,
+		joinHeaders(req.Headers, req.MultiValueHeaders), body)
+	if req.RequestContext.DomainName != "" {
+		v2.RequestContext.DomainName = req.RequestContext.DomainName
+	}
+	if req.RequestContext.Identity.SourceIP != "" {
+		v2.RequestContext.HTTP.SourceIP = req.RequestContext.Identity.SourceIP
+	}
+	v2.RequestContext.Stage = req.RequestContext.Stage
+	if req.Resource != "" {
+		// As in HTTP API route keys, e.g. "GET /sts/{proxy+}"
+		v2.RouteKey = req.HTTPMethod + " " + req.Resource
+	}
+	v2.RequestContext.RequestID = req.RequestContext.RequestID
+	v2.PathParameters = req.PathParameters
+	v2.StageVariables = req.StageVariables
+	return v2, nil
+}
+
+// ToAPIGateway converts an API Gateway v2 response to a REST API response.
+// Cookies are returned as Set-Cookie headers.
+func ToAPIGateway(resp events.APIGatewayV2HTTPResponse) events.APIGatewayProxyResponse {
+	return events.APIGatewayProxyResponse{
+		StatusCode:        resp.StatusCode,
+		MultiValueHeaders: responseHeader(resp),
+		Body:              resp.Body,
+		IsBase64Encoded:   resp.IsBase64Encoded,
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"encoding/base64"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestFromAPIGateway(t *testing.T) {
+	req := events.APIGatewayProxyRequest{
+		HTTPMethod:            http.MethodPost,
+		Resource:              "/webhook",
+		Path:                  "/webhook",
+		Headers:               map[string]string{"Host": "octo-sts.example.com", "X-GitHub-Event": "push"},
+		QueryStringParameters: map[string]string{"name": "a b"},
+		IsBase64Encoded:       true,
+		Body:                  base64.StdEncoding.EncodeToString([]byte("payload")),
+	}
+	req.RequestContext.Stage = "prod"
+	req.RequestContext.DomainName = "abc123.execute-api.us-east-1.amazonaws.com"
+	req.RequestContext.Identity.SourceIP = "203.0.113.7"
+
+	got, err := FromAPIGateway(req)
+	if err != nil {
+		t.Fatalf("FromAPIGateway() error = %v", err)
+	}
+	if got.RawPath != "/webhook" || got.RequestContext.HTTP.Method != http.MethodPost {
+		t.Errorf("request = %s %s, want POST /webhook", got.RequestContext.HTTP.Method, got.RawPath)
+	}
+	if got.RouteKey != "POST /webhook" {
+		t.Errorf("RouteKey = %q, want %q", got.RouteKey, "POST /webhook")
+	}
+	if got.RawQueryString != "name=a+b" {
+		t.Errorf("RawQueryString = %q, want %q", got.RawQueryString, "name=a+b")
+	}
+	if want := map[string]string{"name": "a b"}; !reflect.DeepEqual(got.QueryStringParameters, want) {
+		t.Errorf("QueryStringParameters = %v, want %v", got.QueryStringParameters, want)
+	}
+	if got.Headers["x-github-event"] != "push" {
+		t.Errorf("Headers = %v, want lower-cased names", got.Headers)
+	}
+	if got.Body != "payload" {
+		t.Errorf("Body = %q, want the decoded body", got.Body)
+	}
+	if got.RequestContext.DomainName != req.RequestContext.DomainName {
+		t.Errorf("DomainName = %q, want %q", got.RequestContext.DomainName, req.RequestContext.DomainName)
+	}
+	if got.RequestContext.HTTP.SourceIP != "203.0.113.7" {
+		t.Errorf("SourceIP = %q, want %q", got.RequestContext.HTTP.SourceIP, "203.0.113.7")
+	}
+}
+
+func TestToAPIGateway(t *testing.T) {
+	resp := ToAPIGateway(events.APIGatewayV2HTTPResponse{
+		StatusCode: http.StatusFound,
+		Headers:    map[string]string{"Location": "/setup"},
+		Cookies:    []string{"a=1"},
+	})
+
+	want := map[string][]string{"Location": {"/setup"}, "Set-Cookie": {"a=1"}}
+	if resp.StatusCode != http.StatusFound || !reflect.DeepEqual(resp.MultiValueHeaders, want) {
+		t.Errorf("ToAPIGateway() = %d %v, want %d %v", resp.StatusCode, resp.MultiValueHeaders, http.StatusFound, want)
+	}
+}
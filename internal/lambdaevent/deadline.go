@@ -0,0 +1,60 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// EnvDeadlineMargin is how long before the invocation times out the
+// handlers' context is cancelled, as a duration like "2s" (default: 1s).
+const EnvDeadlineMargin = "LAMBDA_DEADLINE_MARGIN"
+
+// DefaultDeadlineMargin is the margin used when LAMBDA_DEADLINE_MARGIN is
+// unset.
+const DefaultDeadlineMargin = time.Second
+
+// DeadlineMarginFromEnv returns the margin from LAMBDA_DEADLINE_MARGIN, or
+// DefaultDeadlineMargin with an error when it is invalid.
+func DeadlineMarginFromEnv() (time.Duration, error) {
+	v := os.Getenv(EnvDeadlineMargin)
+	if v == "" {
+		return DefaultDeadlineMargin, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return DefaultDeadlineMargin, fmt.Errorf("invalid %s: %q", EnvDeadlineMargin, v)
+	}
+	return d, nil
+}
+
+// WithDeadlineMargin returns a context that is cancelled margin before the
+// deadline of ctx, the invocation's timeout in a Lambda handler, so calls to
+// GitHub and the OIDC issuers are abandoned cleanly, with time left to
+// answer, rather than the function being stopped mid-request. The margin is
+// capped at half the remaining time, so short timeouts still leave the
+// handler time to work. Contexts without a deadline are returned as is.
+func WithDeadlineMargin(ctx context.Context, margin time.Duration) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok || margin <= 0 {
+		return context.WithCancel(ctx)
+	}
+	margin = min(margin, time.Until(deadline)/2)
+	return context.WithDeadline(ctx, deadline.Add(-margin))
+}
+
+// WrapDeadline returns a Lambda handler that calls next with a context
+// cancelled margin before the invocation times out.
+func WrapDeadline(margin time.Duration,
+	next func(ctx context.Context, payload json.RawMessage) (any, error)) func(ctx context.Context, payload json.RawMessage) (any, error) {
+	return func(ctx context.Context, payload json.RawMessage) (any, error) {
+		ctx, cancel := WithDeadlineMargin(ctx, margin)
+		defer cancel()
+		return next(ctx, payload)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDeadlineMarginFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default", want: DefaultDeadlineMargin},
+		{name: "set", value: "3s", want: 3 * time.Second},
+		{name: "disabled", value: "0s", want: 0},
+		{name: "negative", value: "-1s", want: DefaultDeadlineMargin, wantErr: true},
+		{name: "invalid", value: "soon", want: DefaultDeadlineMargin, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvDeadlineMargin, tt.value)
+			got, err := DeadlineMarginFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DeadlineMarginFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DeadlineMarginFromEnv() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithDeadlineMargin(t *testing.T) {
+	deadline := time.Now().Add(time.Minute)
+	parent, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	ctx, cancel := WithDeadlineMargin(parent, 5*time.Second)
+	defer cancel()
+	if got, _ := ctx.Deadline(); !got.Equal(deadline.Add(-5 * time.Second)) {
+		t.Errorf("deadline = %s, want 5s before %s", got, deadline)
+	}
+
+	// The margin leaves at least half the remaining time
+	ctx, cancel = WithDeadlineMargin(parent, time.Hour)
+	defer cancel()
+	if got, _ := ctx.Deadline(); got.Before(time.Now().Add(29 * time.Second)) {
+		t.Errorf("deadline = %s, want about 30s from now", got)
+	}
+
+	ctx, cancel = WithDeadlineMargin(context.Background(), time.Second)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("expected no deadline without a parent deadline")
+	}
+}
+
+func TestWrapDeadline(t *testing.T) {
+	deadline := time.Now().Add(time.Minute)
+	parent, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	var got time.Time
+	h := WrapDeadline(2*time.Second, func(ctx context.Context, _ json.RawMessage) (any, error) {
+		got, _ = ctx.Deadline()
+		return nil, nil
+	})
+	if _, err := h(parent, json.RawMessage(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(deadline.Add(-2 * time.Second)) {
+		t.Errorf("handler deadline = %s, want 2s before %s", got, deadline)
+	}
+}
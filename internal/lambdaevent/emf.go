@@ -0,0 +1,227 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Environment variables for the CloudWatch Embedded Metric Format emitter.
+const (
+	// EnvEMF enables the emitter when true (default: false).
+	EnvEMF = "EMF_METRICS"
+
+	// EnvEMFNamespace is the CloudWatch namespace of the metrics (default:
+	// DefaultEMFNamespace).
+	EnvEMFNamespace = "EMF_NAMESPACE"
+)
+
+// DefaultEMFNamespace is the namespace used when EMF_NAMESPACE is unset.
+const DefaultEMFNamespace = "OctoSTS"
+
+// emfMetric is a CloudWatch metric computed from the Prometheus metrics
+// observed during an invocation.
+type emfMetric struct {
+	Name string
+	Unit string
+}
+
+// emfMetrics are the metrics an EMF record can hold, in order.
+var emfMetrics = []emfMetric{
+	{"Exchanges", "Count"},
+	{"ExchangeDenials", "Count"},
+	{"ExchangeErrors", "Count"},
+	{"ExchangeLatency", "Milliseconds"},
+	{"CacheHits", "Count"},
+	{"CacheMisses", "Count"},
+	{"WebhookEvents", "Count"},
+	{"WebhookLatency", "Milliseconds"},
+}
+
+// emfTotals are the running totals of the Prometheus metrics the EMF
+// metrics are computed from.
+type emfTotals struct {
+	exchanges, denials, errors float64
+	exchangeSeconds            float64
+	cacheHits, cacheMisses     float64
+	webhooks                   float64
+	webhookSeconds             float64
+}
+
+// EMF writes the metrics of each invocation to stdout in the CloudWatch
+// Embedded Metric Format, which CloudWatch Logs turns into metrics, so
+// Lambda deployments get metrics without a Prometheus scraper. The metrics
+// are the differences of the Prometheus totals since the last invocation.
+type EMF struct {
+	namespace string
+	service   string
+	out       io.Writer
+	gatherer  prometheus.Gatherer
+
+	mu   sync.Mutex
+	last emfTotals
+}
+
+// NewEMF creates an emitter of the metrics of gatherer for service.
+func NewEMF(namespace, service string, out io.Writer, gatherer prometheus.Gatherer) *EMF {
+	e := &EMF{namespace: namespace, service: service, out: out, gatherer: gatherer}
+	e.last, _ = e.totals()
+	return e
+}
+
+// EMFFromEnv returns an emitter for service per EMF_METRICS and
+// EMF_NAMESPACE, writing to stdout, or nil if it is disabled.
+func EMFFromEnv(service string) (*EMF, error) {
+	v := os.Getenv(EnvEMF)
+	if v == "" {
+		return nil, nil
+	}
+	enabled, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", EnvEMF, v, err)
+	}
+	if !enabled {
+		return nil, nil
+	}
+	namespace := os.Getenv(EnvEMFNamespace)
+	if namespace == "" {
+		namespace = DefaultEMFNamespace
+	}
+	return NewEMF(namespace, service, os.Stdout, prometheus.DefaultGatherer), nil
+}
+
+// Flush writes the metrics observed since the last flush as one EMF record.
+// Nothing is written if no exchange or webhook delivery was observed, e.g.
+// for a warm-up.
+func (e *EMF) Flush() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	cur, err := e.totals()
+	if err != nil {
+		return err
+	}
+	last := e.last
+	e.last = cur
+
+	exchanges := cur.exchanges - last.exchanges
+	webhooks := cur.webhooks - last.webhooks
+	values := map[string]float64{
+		"CacheHits":   cur.cacheHits - last.cacheHits,
+		"CacheMisses": cur.cacheMisses - last.cacheMisses,
+	}
+	if exchanges > 0 {
+		values["Exchanges"] = exchanges
+		values["ExchangeDenials"] = cur.denials - last.denials
+		values["ExchangeErrors"] = cur.errors - last.errors
+		values["ExchangeLatency"] = (cur.exchangeSeconds - last.exchangeSeconds) / exchanges * 1000
+	}
+	if webhooks > 0 {
+		values["WebhookEvents"] = webhooks
+		values["WebhookLatency"] = (cur.webhookSeconds - last.webhookSeconds) / webhooks * 1000
+	}
+	if exchanges == 0 && webhooks == 0 {
+		return nil
+	}
+
+	var metrics []emfMetric
+	record := map[string]any{"Service": e.service}
+	for _, m := range emfMetrics {
+		if v, ok := values[m.Name]; ok {
+			metrics = append(metrics, m)
+			record[m.Name] = v
+		}
+	}
+	record["_aws"] = map[string]any{
+		"Timestamp": time.Now().UnixMilli(),
+		"CloudWatchMetrics": []map[string]any{{
+			"Namespace":  e.namespace,
+			"Dimensions": [][]string{{"Service"}},
+			"Metrics":    metrics,
+		}},
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = e.out.Write(append(data, '\n'))
+	return err
+}
+
+// totals sums the Prometheus metrics the EMF metrics are computed from.
+func (e *EMF) totals() (emfTotals, error) {
+	families, err := e.gatherer.Gather()
+	if err != nil {
+		return emfTotals{}, fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	var t emfTotals
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			switch f.GetName() {
+			case "octo_sts_exchanges_total":
+				v := m.GetCounter().GetValue()
+				t.exchanges += v
+				code, _ := strconv.Atoi(metricLabel(m, "code"))
+				switch {
+				case code == 401 || code == 403:
+					t.denials += v
+				case code >= 500:
+					t.errors += v
+				}
+			case "octo_sts_exchange_duration_seconds":
+				t.exchangeSeconds += m.GetHistogram().GetSampleSum()
+			case "octo_sts_cache_lookups_total":
+				if metricLabel(m, "result") == "hit" {
+					t.cacheHits += m.GetCounter().GetValue()
+				} else {
+					t.cacheMisses += m.GetCounter().GetValue()
+				}
+			case "octo_sts_webhook_events_total":
+				t.webhooks += m.GetCounter().GetValue()
+			case "octo_sts_webhook_duration_seconds":
+				t.webhookSeconds += m.GetHistogram().GetSampleSum()
+			}
+		}
+	}
+	return t, nil
+}
+
+// metricLabel returns the value of the label name of m.
+func metricLabel(m *dto.Metric, name string) string {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
+
+// WrapEMF returns a Lambda handler that flushes the metrics of e after each
+// invocation of next. A nil e returns next.
+func WrapEMF(e *EMF,
+	next func(ctx context.Context, payload json.RawMessage) (any, error)) func(ctx context.Context, payload json.RawMessage) (any, error) {
+	if e == nil {
+		return next
+	}
+	return func(ctx context.Context, payload json.RawMessage) (any, error) {
+		resp, err := next(ctx, payload)
+		if ferr := e.Flush(); ferr != nil {
+			clog.FromContext(ctx).Errorf("[emf] failed to write metrics: %v", ferr)
+		}
+		return resp, err
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestEMF(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	exchanges := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "octo_sts_exchanges_total"}, []string{"code"})
+	duration := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "octo_sts_exchange_duration_seconds"})
+	lookups := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "octo_sts_cache_lookups_total"}, []string{"cache", "result"})
+	reg.MustRegister(exchanges, duration, lookups)
+
+	// Totals from before the emitter started aren't reported
+	exchanges.WithLabelValues("200").Add(5)
+
+	var out bytes.Buffer
+	e := NewEMF("Test", "sts", &out, reg)
+	handler := WrapEMF(e, func(context.Context, json.RawMessage) (any, error) {
+		exchanges.WithLabelValues("200").Inc()
+		exchanges.WithLabelValues("403").Inc()
+		duration.Observe(0.1)
+		duration.Observe(0.3)
+		lookups.WithLabelValues("installation", "hit").Inc()
+		return "ok", nil
+	})
+	if resp, err := handler(context.Background(), nil); resp != "ok" || err != nil {
+		t.Fatalf("handler() = %v, %v, want the response of next", resp, err)
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("invalid record %q: %v", out.String(), err)
+	}
+	if record["Service"] != "sts" {
+		t.Errorf("Service = %v, want sts", record["Service"])
+	}
+	for name, want := range map[string]float64{
+		"Exchanges":       2.0,
+		"ExchangeDenials": 1.0,
+		"ExchangeErrors":  0.0,
+		"ExchangeLatency": 200.0,
+		"CacheHits":       1.0,
+		"CacheMisses":     0.0,
+	} {
+		if got, ok := record[name].(float64); !ok || math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", name, record[name], want)
+		}
+	}
+	if _, ok := record["WebhookEvents"]; ok {
+		t.Error("record has webhook metrics without deliveries")
+	}
+	aws, _ := record["_aws"].(map[string]any)
+	cw, _ := aws["CloudWatchMetrics"].([]any)
+	if len(cw) != 1 || cw[0].(map[string]any)["Namespace"] != "Test" {
+		t.Errorf("_aws = %v, want the metrics of namespace Test", aws)
+	}
+
+	// An invocation without exchanges writes nothing
+	out.Reset()
+	if err := e.Flush(); err != nil || out.Len() != 0 {
+		t.Errorf("Flush() without exchanges = %v, wrote %q", err, out.String())
+	}
+}
+
+func TestEMFFromEnv(t *testing.T) {
+	if e, err := EMFFromEnv("sts"); e != nil || err != nil {
+		t.Errorf("EMFFromEnv() unset = %v, %v, want disabled", e, err)
+	}
+
+	t.Setenv(EnvEMF, "true")
+	e, err := EMFFromEnv("sts")
+	if err != nil || e == nil || e.namespace != DefaultEMFNamespace {
+		t.Errorf("EMFFromEnv() = %+v, %v, want the default namespace", e, err)
+	}
+
+	t.Setenv(EnvEMF, "sometimes")
+	if _, err := EMFFromEnv("sts"); err == nil {
+		t.Error("EMFFromEnv() with an invalid value succeeded")
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// functionURLDomain is part of the domain of every Lambda function URL, as in
+// <url-id>.lambda-url.<region>.on.aws.
+const functionURLDomain = ".lambda-url."
+
+// IsFunctionURL reports whether req was invoked through a Lambda function URL
+// rather than API Gateway. Function URLs share the API Gateway v2 payload but
+// serve each function from the root of its own domain.
+func IsFunctionURL(req events.APIGatewayV2HTTPRequest) bool {
+	return strings.Contains(req.RequestContext.DomainName, functionURLDomain)
+}
+
+// normalize strips the stage from the path of requests to a named API Gateway
+// stage, so that the handlers see the same paths behind the $default stage,
+// a named stage, and a function URL.
+func normalize(req events.APIGatewayV2HTTPRequest) events.APIGatewayV2HTTPRequest {
+	stage := req.RequestContext.Stage
+	if IsFunctionURL(req) || stage == "" || stage == "$default" {
+		return req
+	}
+	req.RawPath = StripPathPrefix(req.RawPath, "/"+stage)
+	return req
+}
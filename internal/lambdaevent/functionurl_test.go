@@ -0,0 +1,48 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name   string
+		domain string
+		stage  string
+		path   string
+		want   string
+	}{
+		{"default stage", "abc123.execute-api.us-east-1.amazonaws.com", "$default", "/sts/exchange", "/sts/exchange"},
+		{"named stage", "abc123.execute-api.us-east-1.amazonaws.com", "prod", "/prod/sts/exchange", "/sts/exchange"},
+		{"named stage root", "abc123.execute-api.us-east-1.amazonaws.com", "prod", "/prod", "/"},
+		{"named stage prefix only", "abc123.execute-api.us-east-1.amazonaws.com", "prod", "/production", "/production"},
+		{"function url", "xyz789.lambda-url.us-east-1.on.aws", "$default", "/exchange", "/exchange"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := events.APIGatewayV2HTTPRequest{RawPath: tt.path}
+			req.RequestContext.DomainName = tt.domain
+			req.RequestContext.Stage = tt.stage
+			if got := normalize(req).RawPath; got != tt.want {
+				t.Errorf("normalize() RawPath = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsFunctionURL(t *testing.T) {
+	var req events.APIGatewayV2HTTPRequest
+	req.RequestContext.DomainName = "xyz789.lambda-url.us-east-1.on.aws"
+	if !IsFunctionURL(req) {
+		t.Error("IsFunctionURL() = false for a function URL domain")
+	}
+	req.RequestContext.DomainName = "abc123.execute-api.us-east-1.amazonaws.com"
+	if IsFunctionURL(req) {
+		t.Error("IsFunctionURL() = true for an API Gateway domain")
+	}
+}
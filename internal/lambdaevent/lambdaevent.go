@@ -0,0 +1,178 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package lambdaevent lets the Lambda handlers, written against the API
+// Gateway v2 payload, also be invoked through a function URL, an API Gateway
+// REST API, or an Application Load Balancer. Wrap detects the payload of each
+// invocation, converts REST API and ALB target group requests and responses
+// to and from the v2 format, and presents the same paths whichever way the
+// function is invoked. Handlers see request bodies decoded, and binary
+// response bodies are base64-encoded for them.
+package lambdaevent
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Handler handles an API Gateway v2 HTTP request.
+type Handler func(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error)
+
+// probe holds the fields that tell the payloads apart.
+type probe struct {
+	Version        string `json:"version"`
+	HTTPMethod     string `json:"httpMethod"`
+	RequestContext struct {
+		ELB *events.ELBContext `json:"elb"`
+	} `json:"requestContext"`
+}
+
+// Wrap returns a Lambda handler that accepts API Gateway v2, function URL,
+// API Gateway REST API, and ALB target group events and serves them with h,
+// answering in the format of the event.
+func Wrap(h Handler) func(ctx context.Context, payload json.RawMessage) (any, error) {
+	return func(ctx context.Context, payload json.RawMessage) (any, error) {
+		var p probe
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return nil, fmt.Errorf("failed to decode event: %w", err)
+		}
+
+		if p.RequestContext.ELB != nil {
+			var req events.ALBTargetGroupRequest
+			if err := json.Unmarshal(payload, &req); err != nil {
+				return nil, fmt.Errorf("failed to decode ALB event: %w", err)
+			}
+			v2Req, err := FromALB(req)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := h(ctx, v2Req)
+			if err != nil {
+				return nil, err
+			}
+			return ToALB(encodeBody(resp), req.MultiValueHeaders != nil), nil
+		}
+
+		// REST APIs, and HTTP APIs with payload format 1.0, name the method
+		// at the top level
+		if p.Version != "2.0" && p.HTTPMethod != "" {
+			var req events.APIGatewayProxyRequest
+			if err := json.Unmarshal(payload, &req); err != nil {
+				return nil, fmt.Errorf("failed to decode API Gateway REST event: %w", err)
+			}
+			v2Req, err := FromAPIGateway(req)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := h(ctx, v2Req)
+			if err != nil {
+				return nil, err
+			}
+			return ToAPIGateway(encodeBody(resp)), nil
+		}
+
+		var req events.APIGatewayV2HTTPRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return nil, fmt.Errorf("failed to decode API Gateway event: %w", err)
+		}
+		body, err := decodeBody(req.Body, req.IsBase64Encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode API Gateway request body: %w", err)
+		}
+		req.Body, req.IsBase64Encoded = body, false
+		resp, err := h(ctx, normalize(req))
+		if err != nil {
+			return nil, err
+		}
+		return encodeBody(resp), nil
+	}
+}
+
+// newRequest builds an API Gateway v2 request. headers must have lower-case
+// names.
+func newRequest(method, path, rawQuery string, params, headers map[string]string, body string) events.APIGatewayV2HTTPRequest {
+	req := events.APIGatewayV2HTTPRequest{
+		Version:               "2.0",
+		RawPath:               path,
+		RawQueryString:        rawQuery,
+		Headers:               headers,
+		QueryStringParameters: params,
+		Body:                  body,
+	}
+	// The HTTP adapter takes the request host from the domain name
+	req.RequestContext.DomainName = headers["host"]
+	req.RequestContext.HTTP = events.APIGatewayV2HTTPRequestContextHTTPDescription{
+		Method:    method,
+		Path:      path,
+		Protocol:  "HTTP/1.1",
+		SourceIP:  strings.TrimSpace(strings.Split(headers["x-forwarded-for"], ",")[0]),
+		UserAgent: headers["user-agent"],
+	}
+	return req
+}
+
+// decodeBody returns the request body, decoding it if it is base64-encoded.
+func decodeBody(body string, isBase64 bool) (string, error) {
+	if !isBase64 {
+		return body, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// encodeBody base64-encodes the body of resp when it is binary, i.e. not
+// valid UTF-8, which would not survive the JSON encoding of the response.
+// Bodies the handler already encoded are left as they are.
+func encodeBody(resp events.APIGatewayV2HTTPResponse) events.APIGatewayV2HTTPResponse {
+	if resp.IsBase64Encoded || utf8.ValidString(resp.Body) {
+		return resp
+	}
+	resp.Body = base64.StdEncoding.EncodeToString([]byte(resp.Body))
+	resp.IsBase64Encoded = true
+	return resp
+}
+
+// joinHeaders merges single and multi-value headers into lower-case names
+// with values joined by commas.
+func joinHeaders(single map[string]string, multi map[string][]string) map[string]string {
+	headers := make(map[string]string, len(single)+len(multi))
+	for k, v := range single {
+		headers[strings.ToLower(k)] = v
+	}
+	for k, vs := range multi {
+		headers[strings.ToLower(k)] = strings.Join(vs, ",")
+	}
+	return headers
+}
+
+// mergeValues merges single and multi-value parameters, preferring the
+// multi-value ones.
+func mergeValues(single map[string]string, multi map[string][]string) map[string][]string {
+	values := make(map[string][]string, len(single)+len(multi))
+	for k, v := range single {
+		values[k] = []string{v}
+	}
+	for k, vs := range multi {
+		values[k] = vs
+	}
+	return values
+}
+
+// unescape decodes a query string component, or returns it as is when it
+// is malformed.
+func unescape(s string) string {
+	if decoded, err := url.QueryUnescape(s); err == nil {
+		return decoded
+	}
+	return s
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func echoHandler(_ context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "text/plain"},
+		Cookies:    []string{"a=1", "b=2"},
+		Body:       req.RequestContext.HTTP.Method + " " + req.RawPath + "?" + req.RawQueryString + " " + req.Body,
+	}, nil
+}
+
+func TestWrapAPIGateway(t *testing.T) {
+	payload := `{"version":"2.0","rawPath":"/sts/exchange","rawQueryString":"scope=org","body":"{}",` +
+		`"requestContext":{"http":{"method":"POST","path":"/sts/exchange"}}}`
+
+	out, err := Wrap(echoHandler)(context.Background(), json.RawMessage(payload))
+	if err != nil {
+		t.Fatalf("Wrap() error = %v", err)
+	}
+	resp, ok := out.(events.APIGatewayV2HTTPResponse)
+	if !ok {
+		t.Fatalf("Wrap() returned %T, want events.APIGatewayV2HTTPResponse", out)
+	}
+	if want := "POST /sts/exchange?scope=org {}"; resp.Body != want || resp.IsBase64Encoded {
+		t.Errorf("Body = %q (base64 %v), want %q", resp.Body, resp.IsBase64Encoded, want)
+	}
+}
+
+func TestWrapAPIGatewayBase64(t *testing.T) {
+	// The start of a gzip stream, which is not valid UTF-8
+	gzipped := []byte{0x1f, 0x8b, 0x08, 0x00, 0xff}
+	payload := `{"version":"2.0","rawPath":"/webhook","isBase64Encoded":true,` +
+		`"body":"` + base64.StdEncoding.EncodeToString(gzipped) + `",` +
+		`"requestContext":{"http":{"method":"POST","path":"/webhook"}}}`
+
+	var got events.APIGatewayV2HTTPRequest
+	out, err := Wrap(func(_ context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		got = req
+		return events.APIGatewayV2HTTPResponse{StatusCode: http.StatusOK, Body: req.Body}, nil
+	})(context.Background(), json.RawMessage(payload))
+	if err != nil {
+		t.Fatalf("Wrap() error = %v", err)
+	}
+	if got.Body != string(gzipped) || got.IsBase64Encoded {
+		t.Errorf("request body = %q (base64 %v), want it decoded", got.Body, got.IsBase64Encoded)
+	}
+
+	resp := out.(events.APIGatewayV2HTTPResponse)
+	if !resp.IsBase64Encoded || resp.Body != base64.StdEncoding.EncodeToString(gzipped) {
+		t.Errorf("response body = %q (base64 %v), want it encoded", resp.Body, resp.IsBase64Encoded)
+	}
+
+	invalid := `{"version":"2.0","rawPath":"/webhook","isBase64Encoded":true,"body":"not base64!"}`
+	if _, err := Wrap(echoHandler)(context.Background(), json.RawMessage(invalid)); err == nil {
+		t.Error("Wrap() expected an error for an invalid base64 body")
+	}
+}
+
+func TestWrapAPIGatewayREST(t *testing.T) {
+	payload := `{"resource":"/sts/{proxy+}","path":"/sts/exchange","httpMethod":"POST",` +
+		`"multiValueQueryStringParameters":{"scope":["org/repo"]},"body":"{}",` +
+		`"requestContext":{"stage":"prod","domainName":"abc123.execute-api.us-east-1.amazonaws.com"}}`
+
+	out, err := Wrap(echoHandler)(context.Background(), json.RawMessage(payload))
+	if err != nil {
+		t.Fatalf("Wrap() error = %v", err)
+	}
+	resp, ok := out.(events.APIGatewayProxyResponse)
+	if !ok {
+		t.Fatalf("Wrap() returned %T, want events.APIGatewayProxyResponse", out)
+	}
+	if want := "POST /sts/exchange?scope=org%2Frepo {}"; resp.Body != want {
+		t.Errorf("Body = %q, want %q", resp.Body, want)
+	}
+	if got := resp.MultiValueHeaders["Set-Cookie"]; !reflect.DeepEqual(got, []string{"a=1", "b=2"}) {
+		t.Errorf("Set-Cookie = %v, want both cookies", got)
+	}
+}
+
+func TestWrapALB(t *testing.T) {
+	body := base64.StdEncoding.EncodeToString([]byte(`{"action":"opened"}`))
+	payload := `{"requestContext":{"elb":{"targetGroupArn":"arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/octo-sts/abc"}},` +
+		`"httpMethod":"POST","path":"/webhook","multiValueQueryStringParameters":{"scope":["org%2Frepo"]},` +
+		`"multiValueHeaders":{"Content-Type":["application/json"]},"isBase64Encoded":true,"body":"` + body + `"}`
+
+	out, err := Wrap(echoHandler)(context.Background(), json.RawMessage(payload))
+	if err != nil {
+		t.Fatalf("Wrap() error = %v", err)
+	}
+	resp, ok := out.(events.ALBTargetGroupResponse)
+	if !ok {
+		t.Fatalf("Wrap() returned %T, want events.ALBTargetGroupResponse", out)
+	}
+	if want := `POST /webhook?scope=org%2Frepo {"action":"opened"}`; resp.Body != want {
+		t.Errorf("Body = %q, want %q", resp.Body, want)
+	}
+	if resp.StatusDescription != "200 OK" {
+		t.Errorf("StatusDescription = %q, want %q", resp.StatusDescription, "200 OK")
+	}
+	if got := resp.MultiValueHeaders["Set-Cookie"]; !reflect.DeepEqual(got, []string{"a=1", "b=2"}) {
+		t.Errorf("Set-Cookie = %v, want both cookies", got)
+	}
+	if resp.Headers != nil {
+		t.Errorf("Headers = %v, want only multi-value headers", resp.Headers)
+	}
+}
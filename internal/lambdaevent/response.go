@@ -0,0 +1,58 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+)
+
+// headerSetCookie is sent once per cookie, and cannot be joined.
+const headerSetCookie = "Set-Cookie"
+
+// FromResponse converts a handler response to an API Gateway v2 response.
+// Header names are canonicalized, so names differing only in case are
+// merged. Set-Cookie values become the cookies of the response, which API
+// Gateway sends as one header each; other headers with several values are
+// joined with commas, since the v2 format has no multi-value headers.
+func FromResponse(resp shared.Response) events.APIGatewayV2HTTPResponse {
+	v2 := events.APIGatewayV2HTTPResponse{
+		StatusCode: resp.StatusCode,
+		Body:       string(resp.Body),
+	}
+	header := resp.Header()
+	if len(header) > 0 {
+		v2.Headers = make(map[string]string, len(header))
+	}
+	for k, vs := range header {
+		if k == headerSetCookie {
+			v2.Cookies = vs
+			continue
+		}
+		v2.Headers[k] = strings.Join(vs, ", ")
+	}
+	return v2
+}
+
+// responseHeader returns the headers and cookies of resp with canonical
+// names.
+func responseHeader(resp events.APIGatewayV2HTTPResponse) http.Header {
+	header := make(http.Header, len(resp.Headers)+len(resp.MultiValueHeaders)+1)
+	for k, v := range resp.Headers {
+		header.Add(k, v)
+	}
+	for k, vs := range resp.MultiValueHeaders {
+		for _, v := range vs {
+			header.Add(k, v)
+		}
+	}
+	for _, c := range resp.Cookies {
+		header.Add(headerSetCookie, c)
+	}
+	return header
+}
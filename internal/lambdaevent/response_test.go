@@ -0,0 +1,48 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+)
+
+func TestFromResponse(t *testing.T) {
+	resp := FromResponse(shared.Response{
+		StatusCode:        http.StatusFound,
+		Headers:           map[string]string{"location": "/setup", "set-cookie": "a=1"},
+		MultiValueHeaders: map[string][]string{"Set-Cookie": {"b=2; Expires=Wed, 21 Oct 2026 07:28:00 GMT"}, "Vary": {"Accept", "Origin"}},
+		Body:              []byte("found"),
+	})
+
+	wantHeaders := map[string]string{"Location": "/setup", "Vary": "Accept, Origin"}
+	if !reflect.DeepEqual(resp.Headers, wantHeaders) {
+		t.Errorf("Headers = %v, want %v", resp.Headers, wantHeaders)
+	}
+	wantCookies := []string{"a=1", "b=2; Expires=Wed, 21 Oct 2026 07:28:00 GMT"}
+	if !reflect.DeepEqual(resp.Cookies, wantCookies) {
+		t.Errorf("Cookies = %v, want %v", resp.Cookies, wantCookies)
+	}
+	if resp.StatusCode != http.StatusFound || resp.Body != "found" {
+		t.Errorf("FromResponse() = %d %q, want %d %q", resp.StatusCode, resp.Body, http.StatusFound, "found")
+	}
+}
+
+func TestResponseHeaderMergesCase(t *testing.T) {
+	got := responseHeader(events.APIGatewayV2HTTPResponse{
+		Headers:           map[string]string{"set-cookie": "a=1", "content-type": "text/html"},
+		MultiValueHeaders: map[string][]string{"Content-Type": {"text/plain"}},
+		Cookies:           []string{"b=2"},
+	})
+
+	want := http.Header{"Set-Cookie": {"a=1", "b=2"}, "Content-Type": {"text/html", "text/plain"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("responseHeader() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// RoutePrefix returns the path prefix matched by the API Gateway route of
+// req when the route ends in a greedy path parameter, e.g. "/sts" for
+// "ANY /sts/{proxy+}" and "" for "ANY /{proxy+}". It returns false for
+// other routes, the $default route, and events without a route, such as
+// function URL and ALB requests.
+func RoutePrefix(req events.APIGatewayV2HTTPRequest) (string, bool) {
+	_, path, found := strings.Cut(req.RouteKey, " ")
+	if !found {
+		return "", false
+	}
+	i := strings.LastIndex(path, "/{")
+	if i < 0 || !strings.HasSuffix(path, "+}") {
+		return "", false
+	}
+	return path[:i], true
+}
+
+// StripPathPrefix removes prefix from path when path is prefix or below it,
+// keeping the leading slash.
+func StripPathPrefix(path, prefix string) string {
+	if prefix == "" || (path != prefix && !strings.HasPrefix(path, prefix+"/")) {
+		return path
+	}
+	path = strings.TrimPrefix(path, prefix)
+	if path == "" {
+		return "/"
+	}
+	return path
+}
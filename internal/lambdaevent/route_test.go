@@ -0,0 +1,54 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestRoutePrefix(t *testing.T) {
+	tests := []struct {
+		routeKey string
+		want     string
+		wantOK   bool
+	}{
+		{routeKey: "ANY /sts/{proxy+}", want: "/sts", wantOK: true},
+		{routeKey: "POST /octo-sts/v1/{path+}", want: "/octo-sts/v1", wantOK: true},
+		{routeKey: "ANY /{proxy+}", want: "", wantOK: true},
+		{routeKey: "POST /sts/exchange"},
+		{routeKey: "GET /sts/{id}"},
+		{routeKey: "$default"},
+		{routeKey: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.routeKey, func(t *testing.T) {
+			got, ok := RoutePrefix(events.APIGatewayV2HTTPRequest{RouteKey: tt.routeKey})
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("RoutePrefix(%q) = %q, %v, want %q, %v", tt.routeKey, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestStripPathPrefix(t *testing.T) {
+	tests := []struct {
+		path   string
+		prefix string
+		want   string
+	}{
+		{path: "/sts/exchange", prefix: "/sts", want: "/exchange"},
+		{path: "/sts", prefix: "/sts", want: "/"},
+		{path: "/sts/", prefix: "/sts", want: "/"},
+		{path: "/stsx/exchange", prefix: "/sts", want: "/stsx/exchange"},
+		{path: "/exchange", prefix: "/sts", want: "/exchange"},
+		{path: "/exchange", prefix: "", want: "/exchange"},
+	}
+	for _, tt := range tests {
+		if got := StripPathPrefix(tt.path, tt.prefix); got != tt.want {
+			t.Errorf("StripPathPrefix(%q, %q) = %q, want %q", tt.path, tt.prefix, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/chainguard-dev/clog"
+)
+
+// sqsEventSource is the event source of the records of an SQS event.
+const sqsEventSource = "aws:sqs"
+
+// sqsProbe holds the fields that identify an SQS event.
+type sqsProbe struct {
+	Records []struct {
+		EventSource string `json:"eventSource"`
+	} `json:"Records"`
+}
+
+// SQSHandler processes one message of an SQS event. A returned error leaves
+// the message on the queue, to be redelivered or moved to the dead-letter
+// queue.
+type SQSHandler func(ctx context.Context, msg events.SQSMessage) error
+
+// IsSQS reports whether payload is an SQS event, delivered by an event
+// source mapping.
+func IsSQS(payload json.RawMessage) bool {
+	var p sqsProbe
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return false
+	}
+	return len(p.Records) > 0 && p.Records[0].EventSource == sqsEventSource
+}
+
+// WrapSQS returns a Lambda handler that processes the messages of SQS events
+// with h, and passes every other event to next. The messages h fails are
+// reported as batch item failures, so only they are redelivered; the event
+// source mapping must enable ReportBatchItemFailures. Without it, the
+// response is ignored and the batch is deleted, so when every message fails
+// an error is returned instead, which redelivers the batch either way.
+func WrapSQS(h SQSHandler,
+	next func(ctx context.Context, payload json.RawMessage) (any, error)) func(ctx context.Context, payload json.RawMessage) (any, error) {
+	return func(ctx context.Context, payload json.RawMessage) (any, error) {
+		if !IsSQS(payload) {
+			return next(ctx, payload)
+		}
+
+		var event events.SQSEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, fmt.Errorf("failed to decode SQS event: %w", err)
+		}
+
+		log := clog.FromContext(ctx)
+		resp := events.SQSEventResponse{BatchItemFailures: []events.SQSBatchItemFailure{}}
+		for _, msg := range event.Records {
+			if err := h(ctx, msg); err != nil {
+				log.Warnf("[sqs] message %s failed: %v", msg.MessageId, err)
+				resp.BatchItemFailures = append(resp.BatchItemFailures, events.SQSBatchItemFailure{
+					ItemIdentifier: msg.MessageId,
+				})
+			}
+		}
+		if n := len(resp.BatchItemFailures); n > 0 && n == len(event.Records) {
+			return nil, fmt.Errorf("all %d SQS messages failed", n)
+		}
+		return resp, nil
+	}
+}
+
+// FromSQS converts a queued webhook delivery to an API Gateway v2 POST
+// request to path. The message body is the delivery's payload, and its
+// string message attributes are the delivery's headers, e.g.
+// X-GitHub-Event and X-Hub-Signature-256.
+func FromSQS(msg events.SQSMessage, path string) events.APIGatewayV2HTTPRequest {
+	headers := make(map[string]string, len(msg.MessageAttributes))
+	for name, attr := range msg.MessageAttributes {
+		if attr.StringValue != nil {
+			headers[strings.ToLower(name)] = *attr.StringValue
+		}
+	}
+	return newRequest(http.MethodPost, path, "", nil, headers, msg.Body)
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestIsSQS(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    bool
+	}{
+		{name: "sqs event", payload: `{"Records":[{"messageId":"1","eventSource":"aws:sqs","body":"{}"}]}`, want: true},
+		{name: "sns event", payload: `{"Records":[{"EventSource":"aws:sns"}]}`, want: false},
+		{name: "no records", payload: `{"Records":[]}`, want: false},
+		{name: "http request", payload: `{"version":"2.0","rawPath":"/webhook"}`, want: false},
+		{name: "not an object", payload: `"Records"`, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSQS(json.RawMessage(tt.payload)); got != tt.want {
+				t.Errorf("IsSQS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapSQS(t *testing.T) {
+	var processed []string
+	routed := 0
+	h := WrapSQS(func(_ context.Context, msg events.SQSMessage) error {
+		processed = append(processed, msg.MessageId)
+		if msg.Body == "fail" {
+			return errors.New("failed")
+		}
+		return nil
+	}, func(context.Context, json.RawMessage) (any, error) {
+		routed++
+		return "routed", nil
+	})
+
+	payload := `{"Records":[
+		{"messageId":"1","eventSource":"aws:sqs","body":"ok"},
+		{"messageId":"2","eventSource":"aws:sqs","body":"fail"},
+		{"messageId":"3","eventSource":"aws:sqs","body":"ok"}]}`
+	out, err := h(context.Background(), json.RawMessage(payload))
+	if err != nil {
+		t.Fatalf("SQS event error = %v", err)
+	}
+	want := events.SQSEventResponse{BatchItemFailures: []events.SQSBatchItemFailure{{ItemIdentifier: "2"}}}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("SQS event = %+v, want %+v", out, want)
+	}
+	if !reflect.DeepEqual(processed, []string{"1", "2", "3"}) || routed != 0 {
+		t.Errorf("processed %v (routed %d), want every message without routing", processed, routed)
+	}
+
+	// A batch that fails entirely is redelivered even without batch item
+	// failures enabled on the event source mapping
+	payload = `{"Records":[
+		{"messageId":"4","eventSource":"aws:sqs","body":"fail"},
+		{"messageId":"5","eventSource":"aws:sqs","body":"fail"}]}`
+	if out, err := h(context.Background(), json.RawMessage(payload)); err == nil || out != nil {
+		t.Errorf("failed SQS event = %+v, %v, want an error", out, err)
+	}
+
+	if out, _ := h(context.Background(), json.RawMessage(`{"version":"2.0"}`)); out != "routed" || routed != 1 {
+		t.Errorf("request = %v (routed %d), want it passed on", out, routed)
+	}
+}
+
+func TestFromSQS(t *testing.T) {
+	event := "push"
+	signature := "sha256=abc"
+	req := FromSQS(events.SQSMessage{
+		Body: `{"action":"created"}`,
+		MessageAttributes: map[string]events.SQSMessageAttribute{
+			"X-GitHub-Event":      {DataType: "String", StringValue: &event},
+			"X-Hub-Signature-256": {DataType: "String", StringValue: &signature},
+			"Payload":             {DataType: "Binary", BinaryValue: []byte("ignored")},
+		},
+	}, "/webhook")
+
+	if req.RequestContext.HTTP.Method != "POST" || req.RawPath != "/webhook" {
+		t.Errorf("request = %s %s, want POST /webhook", req.RequestContext.HTTP.Method, req.RawPath)
+	}
+	wantHeaders := map[string]string{"x-github-event": "push", "x-hub-signature-256": "sha256=abc"}
+	if !reflect.DeepEqual(req.Headers, wantHeaders) {
+		t.Errorf("headers = %v, want %v", req.Headers, wantHeaders)
+	}
+	if req.Body != `{"action":"created"}` {
+		t.Errorf("body = %q", req.Body)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// warmUpProbe holds the fields that identify a warm-up invocation.
+type warmUpProbe struct {
+	Source     string `json:"source"`
+	DetailType string `json:"detail-type"`
+	WarmUp     bool   `json:"warmup"`
+}
+
+// WarmUpResult is the response to a warm-up invocation.
+type WarmUpResult struct {
+	WarmUp bool   `json:"warmup"`
+	Error  string `json:"error,omitempty"`
+}
+
+// IsWarmUp reports whether payload is a warm-up invocation: an EventBridge
+// scheduled event, or a direct invocation with {"warmup": true}. Neither
+// can be sent through API Gateway, a function URL, or an ALB, which nest
+// client input in their body.
+func IsWarmUp(payload json.RawMessage) bool {
+	var p warmUpProbe
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return false
+	}
+	return p.WarmUp || (p.Source == "aws.events" && p.DetailType == "Scheduled Event")
+}
+
+// WrapWarmUp returns a Lambda handler that answers warm-up invocations by
+// calling warm, e.g. to load the configuration and fill caches, and passes
+// every other event to next. A failed warm-up is reported in the result
+// rather than as an error, so the schedule does not retry it.
+func WrapWarmUp(warm func(ctx context.Context) error,
+	next func(ctx context.Context, payload json.RawMessage) (any, error)) func(ctx context.Context, payload json.RawMessage) (any, error) {
+	return func(ctx context.Context, payload json.RawMessage) (any, error) {
+		if !IsWarmUp(payload) {
+			return next(ctx, payload)
+		}
+
+		log := clog.FromContext(ctx)
+		if err := warm(ctx); err != nil {
+			log.Warnf("[warmup] failed: %v", err)
+			return WarmUpResult{WarmUp: true, Error: err.Error()}, nil
+		}
+		log.Infof("[warmup] done")
+		return WarmUpResult{WarmUp: true}, nil
+	}
+}
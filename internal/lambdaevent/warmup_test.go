@@ -0,0 +1,66 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package lambdaevent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestIsWarmUp(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    bool
+	}{
+		{name: "scheduled event", payload: `{"source":"aws.events","detail-type":"Scheduled Event","detail":{}}`, want: true},
+		{name: "direct invocation", payload: `{"warmup":true}`, want: true},
+		{name: "other event", payload: `{"source":"aws.ssm","detail-type":"Parameter Store Change"}`, want: false},
+		{name: "http request", payload: `{"version":"2.0","rawPath":"/","body":"{\"warmup\":true}"}`, want: false},
+		{name: "not an object", payload: `"warmup"`, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsWarmUp(json.RawMessage(tt.payload)); got != tt.want {
+				t.Errorf("IsWarmUp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapWarmUp(t *testing.T) {
+	warmed := 0
+	routed := 0
+	var warmErr error
+	h := WrapWarmUp(func(context.Context) error {
+		warmed++
+		return warmErr
+	}, func(context.Context, json.RawMessage) (any, error) {
+		routed++
+		return "routed", nil
+	})
+
+	out, err := h(context.Background(), json.RawMessage(`{"warmup":true}`))
+	if err != nil {
+		t.Fatalf("warm-up error = %v", err)
+	}
+	if out != (WarmUpResult{WarmUp: true}) || warmed != 1 || routed != 0 {
+		t.Errorf("warm-up = %+v (warmed %d, routed %d), want warmed without routing", out, warmed, routed)
+	}
+
+	warmErr = errors.New("no credentials")
+	out, err = h(context.Background(), json.RawMessage(`{"warmup":true}`))
+	if err != nil {
+		t.Fatalf("failed warm-up error = %v, want it in the result", err)
+	}
+	if out != (WarmUpResult{WarmUp: true, Error: "no credentials"}) {
+		t.Errorf("failed warm-up = %+v", out)
+	}
+
+	if out, _ := h(context.Background(), json.RawMessage(`{"version":"2.0"}`)); out != "routed" || routed != 1 {
+		t.Errorf("request = %v (routed %d), want it passed on", out, routed)
+	}
+}
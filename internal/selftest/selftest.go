@@ -0,0 +1,367 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package selftest exchanges a token end to end within the process, to catch
+// packaging and wiring regressions before a deploy. It mints an OIDC token
+// with a local signer registered as a test issuer, runs it through the STS
+// handler against a fake GitHub API, and delivers a signed webhook to the
+// webhook handler. No configuration or network access is needed.
+package selftest
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/go-jose/go-jose/v4"
+	josejwt "github.com/go-jose/go-jose/v4/jwt"
+	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/google/go-github/v84/github"
+	"github.com/octo-sts/app/pkg/provider"
+
+	"github.com/cruxstack/octo-sts-distros/internal/app"
+	"github.com/cruxstack/octo-sts-distros/internal/sts"
+)
+
+// The identities of the self test. The issuer and domain are reserved
+// names, so they never collide with a real issuer.
+const (
+	Issuer   = "https://selftest.octo-sts.invalid"
+	Domain   = "selftest.octo-sts.invalid"
+	Owner    = "octo-sts-selftest"
+	Repo     = "selftest"
+	Identity = "selftest"
+	Subject  = "repo:octo-sts-selftest/selftest:ref:refs/heads/main"
+
+	installationID = 1
+	appID          = 1
+)
+
+// trustPolicy is the trust policy the fake GitHub API serves for Identity.
+var trustPolicy = fmt.Sprintf(`issuer: %s
+subject: %s
+
+permissions:
+  contents: read
+`, Issuer, Subject)
+
+// ErrFailed is returned by Run when a step failed.
+var ErrFailed = errors.New("self test failed")
+
+// Step is the outcome of one step of the self test.
+type Step struct {
+	// Name describes the step, e.g. "exchange".
+	Name string
+
+	// Detail describes what the step found when it succeeded.
+	Detail string
+
+	// Err is why the step failed.
+	Err error
+
+	// Skipped is set when an earlier step failed, so the step was not run.
+	Skipped bool
+}
+
+// Report is the outcome of every step of the self test.
+type Report struct {
+	Steps []Step
+}
+
+// OK reports whether every step succeeded.
+func (r *Report) OK() bool {
+	for _, step := range r.Steps {
+		if step.Err != nil || step.Skipped {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTo writes the report as one line per step, followed by the result.
+func (r *Report) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	b.WriteString("self test:\n")
+	for _, step := range r.Steps {
+		switch {
+		case step.Skipped:
+			fmt.Fprintf(&b, "  [skip] %s\n", step.Name)
+		case step.Err != nil:
+			fmt.Fprintf(&b, "  [FAIL] %s: %v\n", step.Name, step.Err)
+		case step.Detail != "":
+			fmt.Fprintf(&b, "  [ ok ] %s: %s\n", step.Name, step.Detail)
+		default:
+			fmt.Fprintf(&b, "  [ ok ] %s\n", step.Name)
+		}
+	}
+	if r.OK() {
+		b.WriteString("result: passed\n")
+	} else {
+		b.WriteString("result: failed\n")
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Run runs the self test and writes the report to w. ErrFailed is returned
+// if a step failed.
+func Run(ctx context.Context, w io.Writer) error {
+	report := run(ctx)
+	if _, err := report.WriteTo(w); err != nil {
+		return err
+	}
+	if !report.OK() {
+		return ErrFailed
+	}
+	return nil
+}
+
+// run runs the steps of Run, skipping those after a failure.
+func run(ctx context.Context) *Report {
+	report := &Report{}
+	failed := false
+	step := func(name string, fn func() (string, error)) {
+		if failed {
+			report.Steps = append(report.Steps, Step{Name: name, Skipped: true})
+			return
+		}
+		detail, err := fn()
+		failed = err != nil
+		report.Steps = append(report.Steps, Step{Name: name, Detail: detail, Err: err})
+	}
+
+	gh := newFakeGitHub()
+	defer gh.Close()
+
+	var atr *ghinstallation.AppsTransport
+	step("github app", func() (string, error) {
+		var err error
+		atr, err = gh.appsTransport()
+		return gh.srv.URL, err
+	})
+
+	var token string
+	step("oidc issuer", func() (string, error) {
+		var err error
+		token, err = mintToken()
+		return Issuer, err
+	})
+
+	var handler *sts.STS
+	step("sts", func() (string, error) {
+		var err error
+		handler, err = sts.New(atr, sts.Config{Domain: Domain})
+		return "", err
+	})
+	step("exchange", func() (string, error) {
+		return exchange(ctx, handler, token, gh)
+	})
+
+	secret := []byte(rand.Text())
+	var webhook *app.App
+	step("webhook", func() (string, error) {
+		var err error
+		webhook, err = app.New(atr, app.Config{WebhookSecrets: [][]byte{secret}})
+		return "", err
+	})
+	step("webhook delivery", func() (string, error) {
+		return deliver(ctx, webhook, secret)
+	})
+	return report
+}
+
+// mintToken registers a local signer as Issuer and signs a token with it.
+func mintToken() (string, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create signer: %w", err)
+	}
+	token, err := josejwt.Signed(signer).Claims(josejwt.Claims{
+		Issuer:   Issuer,
+		Subject:  Subject,
+		Audience: josejwt.Audience{Domain},
+		Expiry:   josejwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+	}).Serialize()
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	// The test verifier stands in for the issuer's discovery document and
+	// keys, which would otherwise be fetched over the network
+	provider.AddTestKeySetVerifier(nil, Issuer, &oidc.StaticKeySet{
+		PublicKeys: []crypto.PublicKey{key.Public()},
+	})
+	return token, nil
+}
+
+// exchange exchanges token for a GitHub token through handler, and checks
+// that the token was minted with the trust policy's permissions.
+func exchange(ctx context.Context, handler *sts.STS, token string, gh *fakeGitHub) (string, error) {
+	body, err := json.Marshal(sts.ExchangeRequest{Identity: Identity, Scope: Owner + "/" + Repo})
+	if err != nil {
+		return "", err
+	}
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		return "", fmt.Errorf("status %d: %s", rec.Code, strings.TrimSpace(rec.Body.String()))
+	}
+	var resp sts.ExchangeResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		return "", fmt.Errorf("invalid response: %w", err)
+	}
+	if resp.Token != fakeToken {
+		return "", errors.New("response does not hold the token minted by GitHub")
+	}
+
+	opts := gh.tokenOptions()
+	if opts == nil {
+		return "", errors.New("no token was requested from GitHub")
+	}
+	if len(opts.Repositories) != 1 || opts.Repositories[0] != Repo {
+		return "", fmt.Errorf("token requested for repositories %v, want [%s]", opts.Repositories, Repo)
+	}
+	if opts.Permissions == nil || opts.Permissions.GetContents() != "read" {
+		return "", errors.New("token requested without the trust policy's permissions")
+	}
+	return fmt.Sprintf("%s/%s as %s", Owner, Repo, Identity), nil
+}
+
+// deliver sends a signed ping event to handler, which accepts events it
+// does not act on once their signature is verified.
+func deliver(ctx context.Context, handler *app.App, secret []byte) (string, error) {
+	body := []byte(`{"zen":"Keep it logically awesome.","hook_id":1}`)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", "ping")
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		return "", fmt.Errorf("status %d: %s", rec.Code, strings.TrimSpace(rec.Body.String()))
+	}
+	return "signature verified", nil
+}
+
+// fakeToken is the installation token the fake GitHub API mints.
+const fakeToken = "ghs_selftest"
+
+// fakeGitHub serves the parts of the GitHub API an exchange uses.
+type fakeGitHub struct {
+	srv *httptest.Server
+
+	mu   sync.Mutex
+	opts *github.InstallationTokenOptions
+}
+
+func newFakeGitHub() *fakeGitHub {
+	f := &fakeGitHub{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /app/installations", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]github.Installation{{
+			ID:      github.Ptr(int64(installationID)),
+			Account: &github.User{Login: github.Ptr(Owner)},
+		}})
+	})
+	mux.HandleFunc("POST /app/installations/{id}/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		opts := &github.InstallationTokenOptions{}
+		if err := json.NewDecoder(r.Body).Decode(opts); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.opts = opts
+		f.mu.Unlock()
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(github.InstallationToken{
+			Token:     github.Ptr(fakeToken),
+			ExpiresAt: &github.Timestamp{Time: time.Now().Add(time.Hour)},
+		})
+	})
+	mux.HandleFunc("GET /repos/{owner}/{repo}/contents/.github/chainguard/{file}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("owner") != Owner || r.PathValue("repo") != Repo || r.PathValue("file") != Identity+".sts.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(github.RepositoryContent{
+			Type:     github.Ptr("file"),
+			Encoding: github.Ptr("base64"),
+			Content:  github.Ptr(base64.StdEncoding.EncodeToString([]byte(trustPolicy))),
+		})
+	})
+	f.srv = httptest.NewTLSServer(mux)
+	return f
+}
+
+// Close shuts the fake down.
+func (f *fakeGitHub) Close() {
+	f.srv.Close()
+}
+
+// tokenOptions returns the options of the last installation token minted.
+func (f *fakeGitHub) tokenOptions() *github.InstallationTokenOptions {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.opts
+}
+
+// appsTransport returns a transport authenticating as a throwaway App, which
+// sends every request, whatever its host, to the fake.
+func (f *fakeGitHub) appsTransport() (*ghinstallation.AppsTransport, error) {
+	roots := x509.NewCertPool()
+	roots.AddCert(f.srv.Certificate())
+	addr := f.srv.Listener.Addr().String()
+	dialer := &net.Dialer{}
+	transport := &http.Transport{
+		// The certificate of httptest servers is issued for example.com
+		TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: "example.com"},
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate app key: %w", err)
+	}
+	atr, err := ghinstallation.NewAppsTransportWithOptions(transport, appID,
+		ghinstallation.WithSigner(ghinstallation.NewRSASigner(jwt.SigningMethodRS256, key)))
+	if err != nil {
+		return nil, err
+	}
+	atr.BaseURL = f.srv.URL
+	return atr, nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package selftest
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/chainguard-dev/clog/slogtest"
+)
+
+func TestRun(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Run(slogtest.Context(t), &buf); err != nil {
+		t.Fatalf("Run() error = %v\n%s", err, buf.String())
+	}
+	for _, want := range []string{
+		"[ ok ] exchange: octo-sts-selftest/selftest as selftest\n",
+		"[ ok ] webhook delivery: signature verified\n",
+		"result: passed\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("report does not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	report := &Report{Steps: []Step{
+		{Name: "github app", Detail: "https://127.0.0.1:1234"},
+		{Name: "exchange", Err: errors.New("status 403: token does not match trust policy")},
+		{Name: "webhook", Skipped: true},
+	}}
+	if report.OK() {
+		t.Error("OK() = true with a failed step")
+	}
+
+	var buf bytes.Buffer
+	if _, err := report.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `self test:
+  [ ok ] github app: https://127.0.0.1:1234
+  [FAIL] exchange: status 403: token does not match trust policy
+  [skip] webhook
+result: failed
+`
+	if got := buf.String(); got != want {
+		t.Errorf("report =\n%s\nwant\n%s", got, want)
+	}
+}
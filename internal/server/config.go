@@ -0,0 +1,328 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	"github.com/chainguard-dev/clog"
+
+	"github.com/cruxstack/octo-sts-distros/internal/app"
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+	"github.com/cruxstack/octo-sts-distros/internal/ssmresolver"
+	"github.com/cruxstack/octo-sts-distros/internal/sts"
+	"github.com/cruxstack/octo-sts-distros/internal/vaultresolver"
+	envConfig "github.com/octo-sts/app/pkg/envconfig"
+	"github.com/octo-sts/app/pkg/ghtransport"
+)
+
+// swappableHandler wraps an atomic pointer to the current handler.
+// This allows hot-swapping the handler when configuration is reloaded.
+type swappableHandler struct {
+	handler atomic.Pointer[http.Handler]
+}
+
+func (h *swappableHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handler := h.handler.Load()
+	if handler == nil || *handler == nil {
+		http.Error(w, "service not configured", http.StatusServiceUnavailable)
+		return
+	}
+	(*handler).ServeHTTP(w, r)
+}
+
+func (h *swappableHandler) SetHandler(handler http.Handler) {
+	h.handler.Store(&handler)
+}
+
+// handlers holds the handlers a service swaps on reload.
+type handlers struct {
+	sts     swappableHandler
+	webhook swappableHandler
+
+	// tenants holds the handlers of each tenant by domain; see byHost.
+	tenants atomic.Pointer[tenantRoutes]
+
+	// transport is the GitHub App transport of the last load, for DryRun.
+	transport *ghinstallation.AppsTransport
+
+	// github checks the transport of the last load for /healthz.
+	github shared.GitHubAuth
+
+	// stsReady and webhookReady are set once the component is loaded, and
+	// stay set when a later reload of it fails.
+	stsReady, webhookReady atomic.Bool
+
+	// stsErr and webhookErr hold the error of the component that failed
+	// the last load of the combined service while the other one loaded,
+	// for /readyz; see loadErr.
+	stsErr, webhookErr atomic.Pointer[error]
+
+	// audit receives the audit event of each exchange; it drops them
+	// unless AUDIT_SINK is set.
+	audit sts.AuditFunc
+}
+
+// ready reports whether a component of service is loaded; in the combined
+// service, either one serves while the other is still loading.
+func (h *handlers) ready() bool {
+	return h.stsReady.Load() || h.webhookReady.Load()
+}
+
+// components returns the components of service reported on /readyz; the
+// combined service reports the readiness of each, and whether its last
+// load failed.
+func (h *handlers) components(service Service) []shared.Component {
+	if service != ServiceAll {
+		return nil
+	}
+	return []shared.Component{
+		{Name: "sts", Ready: h.stsReady.Load, Failed: func() bool { return h.stsErr.Load() != nil }},
+		{Name: "webhook", Ready: h.webhookReady.Load, Failed: func() bool { return h.webhookErr.Load() != nil }},
+	}
+}
+
+// loadErr returns the errors of the components that failed the last load
+// while the rest of the service loaded, or nil.
+func (h *handlers) loadErr() error {
+	var errs []error
+	for _, p := range []*atomic.Pointer[error]{&h.stsErr, &h.webhookErr} {
+		if err := p.Load(); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Check loads the configuration of service once, as Run would, without
+// serving it. It reports the problems that would keep the service from
+// becoming ready, every missing or invalid setting at once as a
+// ValidationError.
+func Check(ctx context.Context, service Service) error {
+	if err := (Options{Service: service}).validate(); err != nil {
+		return err
+	}
+	setPortEnv(PortFromEnv())
+
+	store, err := configstore.NewFromEnv()
+	if err != nil {
+		return fmt.Errorf("config store: %w", err)
+	}
+	if err := shared.PingStore(ctx, store); err != nil {
+		return fmt.Errorf("config store: %w", err)
+	}
+
+	// Report the problems of the server and of the service together
+	v := &validator{}
+	validateServer(v, Options{Service: service})
+	h := &handlers{}
+	err = loadConfig(ctx, store, service, h)
+	if err == nil {
+		err = h.loadErr()
+	}
+	v.merge("config", err)
+	return v.err()
+}
+
+// loadConfig loads configuration and creates the handlers of service. When
+// both services run they share one GitHub App transport (supports reload).
+// In the combined service, a component that fails while the other loads
+// doesn't fail the load, so the environment of the loaded one is kept and
+// the load isn't retried; its error is logged and kept for loadErr.
+func loadConfig(ctx context.Context, store configstore.Store, service Service, h *handlers) error {
+	// Resolve AWS, Google Secret Manager, and Azure Key Vault references
+	if err := ssmresolver.ResolveEnvironmentWithDefaults(ctx); err != nil {
+		return fmt.Errorf("secrets: %w", err)
+	}
+
+	// Resolve Vault references and credentials saved to the Vault store
+	if err := vaultresolver.ResolveEnvironmentWithDefaults(ctx); err != nil {
+		return fmt.Errorf("vault: %w", err)
+	}
+
+	// Re-run env mapping for hot-reload support
+	shared.SetupEnvMapping()
+
+	// Prefer credentials saved by the installer over the environment
+	if err := shared.ApplyStoreCredentials(ctx, store); err != nil {
+		return err
+	}
+
+	// In the combined service, a component whose own settings are invalid
+	// doesn't hold back the other
+	v := &validator{}
+	validateService(v, service, true)
+	stsErr, webhookErr, err := splitProblems(v, service)
+	if err != nil {
+		return err
+	}
+
+	baseCfg, err := envConfig.BaseConfig()
+	if err != nil {
+		return fmt.Errorf("base config: %w", err)
+	}
+
+	appID, kmsKey, err := shared.PrimaryGitHubApp(baseCfg)
+	if err != nil {
+		return fmt.Errorf("GitHub app config: %w", err)
+	}
+
+	atr, err := ghtransport.New(ctx, appID, kmsKey, baseCfg, nil, nil)
+	if err != nil {
+		return fmt.Errorf("error creating GitHub App transport: %w", err)
+	}
+
+	var stsInstance, appInstance http.Handler
+	var stsCfg sts.Config
+	var appCfg app.Config
+	if service != ServiceWebhook && stsErr == nil {
+		stsInstance, stsCfg, stsErr = newSTS(atr, service, h.audit)
+	}
+	if service != ServiceSTS && webhookErr == nil {
+		appInstance, appCfg, webhookErr = newWebhook(atr)
+	}
+	loadErr := errors.Join(stsErr, webhookErr)
+	loaded := service
+	switch {
+	case stsErr != nil && (service != ServiceAll || webhookErr != nil):
+		return loadErr
+	case webhookErr != nil && service != ServiceAll:
+		return loadErr
+	case stsErr != nil:
+		loaded = ServiceWebhook
+	case webhookErr != nil:
+		loaded = ServiceSTS
+	}
+
+	tenants, err := TenantsFromEnv()
+	if err != nil {
+		return err
+	}
+	routes, err := loadTenants(ctx, tenants, loaded, stsCfg, appCfg)
+	if err != nil {
+		return err
+	}
+	if loaded != service {
+		routes.keep(h.tenants.Load(), loaded)
+	}
+
+	// Swap the handlers only once all are built, so a failed reload keeps
+	// serving the previous ones. A component that failed to load keeps its
+	// previous handlers, or stays unready.
+	if stsInstance != nil {
+		h.sts.SetHandler(stsInstance)
+		h.stsReady.Store(true)
+	}
+	if appInstance != nil {
+		h.webhook.SetHandler(appInstance)
+		h.webhookReady.Store(true)
+	}
+	h.tenants.Store(&routes)
+	h.transport = atr
+	h.github.Set(atr)
+	setLoadErr(&h.stsErr, stsErr)
+	setLoadErr(&h.webhookErr, webhookErr)
+	if loadErr != nil {
+		clog.FromContext(ctx).Errorf("[config] serving the %s only, the other component failed to load: %v", loaded, loadErr)
+	}
+	return nil
+}
+
+// setLoadErr stores err in p, or clears p if err is nil.
+func setLoadErr(p *atomic.Pointer[error], err error) {
+	if err == nil {
+		p.Store(nil)
+		return
+	}
+	p.Store(&err)
+}
+
+// splitProblems returns the problems of v. In the combined service, the
+// problems of the STS and the webhook are returned as the errors of that
+// component, to load the other one regardless; any other problem is
+// returned as err.
+func splitProblems(v *validator, service Service) (stsErr, webhookErr, err error) {
+	if service != ServiceAll {
+		return nil, nil, v.err()
+	}
+	stsV, webhookV := &validator{}, &validator{}
+	for _, p := range v.problems {
+		switch p.Subsystem {
+		case "sts":
+			stsV.problems = append(stsV.problems, p)
+		case "webhook":
+			webhookV.problems = append(webhookV.problems, p)
+		default:
+			return nil, nil, v.err()
+		}
+	}
+	return stsV.err(), webhookV.err(), nil
+}
+
+// newSTS creates the STS of the default App, recording exchanges with
+// record if set.
+func newSTS(atr *ghinstallation.AppsTransport, service Service, record sts.AuditFunc) (http.Handler, sts.Config, error) {
+	appConfig, err := envConfig.AppConfig()
+	if err != nil {
+		return nil, sts.Config{}, fmt.Errorf("app config: %w", err)
+	}
+
+	maxBodySize, err := shared.MaxBodySizeFromEnv(shared.EnvSTSMaxBodySize)
+	if err != nil {
+		return nil, sts.Config{}, err
+	}
+
+	cfg := sts.Config{
+		Domain:            appConfig.Domain,
+		AdditionalDomains: shared.AdditionalDomainsFromEnv(),
+		MaxBodySize:       maxBodySize,
+		Audit:             record,
+	}
+	if service == ServiceAll {
+		cfg.BasePath = STSBasePath
+	}
+	instance, err := sts.New(atr, cfg)
+	if err != nil {
+		return nil, sts.Config{}, fmt.Errorf("failed to create sts: %w", err)
+	}
+	return instance, cfg, nil
+}
+
+// newWebhook creates the webhook handler of the default App.
+func newWebhook(atr *ghinstallation.AppsTransport) (http.Handler, app.Config, error) {
+	webhookConfig, err := envConfig.WebhookConfig()
+	if err != nil {
+		return nil, app.Config{}, fmt.Errorf("webhook config: %w", err)
+	}
+
+	var orgs []string
+	for _, s := range strings.Split(webhookConfig.OrganizationFilter, ",") {
+		if o := strings.TrimSpace(s); o != "" {
+			orgs = append(orgs, o)
+		}
+	}
+
+	maxBodySize, err := shared.MaxBodySizeFromEnv(shared.EnvWebhookMaxBodySize)
+	if err != nil {
+		return nil, app.Config{}, err
+	}
+
+	cfg := app.Config{
+		WebhookSecrets: [][]byte{[]byte(webhookConfig.WebhookSecret)},
+		Organizations:  orgs,
+		MaxBodySize:    maxBodySize,
+	}
+	instance, err := app.New(atr, cfg)
+	if err != nil {
+		return nil, app.Config{}, fmt.Errorf("failed to create app: %w", err)
+	}
+	return instance, cfg, nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+)
+
+func TestLoadConfigPartial(t *testing.T) {
+	clearServiceEnv(t)
+	t.Setenv(EnvTenants, "")
+	t.Setenv(configstore.EnvGitHubClientID, "")
+	t.Setenv(configstore.EnvGitHubClientSecret, "")
+	t.Setenv("PORT", "8080")
+
+	// The installer saved the credentials, but no STS domain
+	store := configstore.NewLocalFileStore(saveTenant(t, ""))
+	h := &handlers{}
+	load := shared.ConfigWait{MaxRetries: 1}.LoadFunc(shared.WithEnvRollback(func(ctx context.Context) error {
+		return loadConfig(ctx, store, ServiceAll, h)
+	}))
+
+	// The webhook loads, so the load succeeds and isn't rolled back
+	if err := load(context.Background()); err != nil {
+		t.Fatalf("load = %v, want nil with the webhook loaded", err)
+	}
+	if got := os.Getenv(configstore.EnvGitHubAppID); got != "1234" {
+		t.Errorf("%s after the load = %q, want the saved 1234", configstore.EnvGitHubAppID, got)
+	}
+	if !h.webhookReady.Load() || h.stsReady.Load() || !h.ready() {
+		t.Errorf("ready = sts %v, webhook %v, want the webhook only", h.stsReady.Load(), h.webhookReady.Load())
+	}
+	if got := subsystems(t, h.loadErr()); len(got) != 1 || got[0] != "sts" {
+		t.Errorf("loadErr() subsystems = %v, want [sts]", got)
+	}
+
+	rec := httptest.NewRecorder()
+	shared.ReadyHandler(h.ready, shared.NewReloader(0), nil, h.components(ServiceAll)...)(rec, httptest.NewRequest(http.MethodGet, shared.ReadyPath, nil))
+	var resp struct {
+		Status     string `json:"status"`
+		Components []struct {
+			Name   string `json:"name"`
+			Failed bool   `json:"load_failed"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid body %q: %v", rec.Body.String(), err)
+	}
+	if rec.Code != http.StatusOK || resp.Status != "partially ready" || !resp.Components[0].Failed || resp.Components[1].Failed {
+		t.Errorf("/readyz = %d %s, want 200 partially ready with the sts failed", rec.Code, rec.Body.String())
+	}
+
+	// Once the STS loads, it is no longer reported failed
+	creds, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds.CustomFields[configstore.EnvSTSDomain] = "sts.example.com"
+	if err := store.Save(context.Background(), creds); err != nil {
+		t.Fatal(err)
+	}
+	if err := load(context.Background()); err != nil {
+		t.Fatalf("load = %v", err)
+	}
+	if !h.stsReady.Load() || h.loadErr() != nil {
+		t.Errorf("after the STS loaded: ready = %v, loadErr() = %v", h.stsReady.Load(), h.loadErr())
+	}
+}
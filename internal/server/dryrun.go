@@ -0,0 +1,159 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	"github.com/google/go-github/v84/github"
+
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+)
+
+// EnvDryRun makes Run check the configuration and report on it instead of
+// serving (default: false).
+const EnvDryRun = "DRY_RUN"
+
+// ErrDryRunFailed is returned by DryRun when a step failed.
+var ErrDryRunFailed = errors.New("dry run failed")
+
+// DryRunStep is the outcome of one step of a dry run.
+type DryRunStep struct {
+	// Name describes the step, e.g. "config store".
+	Name string
+
+	// Detail describes what the step found when it succeeded.
+	Detail string
+
+	// Err is why the step failed.
+	Err error
+
+	// Skipped is set when an earlier step failed, so the step was not run.
+	Skipped bool
+}
+
+// DryRunReport is the outcome of every step of a dry run.
+type DryRunReport struct {
+	Service Service
+	Steps   []DryRunStep
+}
+
+// OK reports whether every step succeeded.
+func (r *DryRunReport) OK() bool {
+	for _, step := range r.Steps {
+		if step.Err != nil || step.Skipped {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTo writes the report as one line per step, followed by the result.
+func (r *DryRunReport) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "dry run of %s:\n", r.Service)
+	for _, step := range r.Steps {
+		switch {
+		case step.Skipped:
+			fmt.Fprintf(&b, "  [skip] %s\n", step.Name)
+		case step.Err != nil:
+			// Indent multi-line errors, such as a ValidationError
+			fmt.Fprintf(&b, "  [FAIL] %s: %s\n", step.Name, strings.ReplaceAll(step.Err.Error(), "\n", "\n    "))
+		case step.Detail != "":
+			fmt.Fprintf(&b, "  [ ok ] %s: %s\n", step.Name, step.Detail)
+		default:
+			fmt.Fprintf(&b, "  [ ok ] %s\n", step.Name)
+		}
+	}
+	if r.OK() {
+		b.WriteString("result: ready to serve\n")
+	} else {
+		b.WriteString("result: would not become ready\n")
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// DryRun checks that opts could be served without serving them: it
+// validates the settings, reads the config store, resolves secret
+// references, parses the private key, builds the handlers, and
+// authenticates to GitHub as the App. The report is written to w, and
+// ErrDryRunFailed is returned if a step failed.
+func DryRun(ctx context.Context, opts Options, w io.Writer) error {
+	report := dryRun(ctx, opts)
+	if _, err := report.WriteTo(w); err != nil {
+		return err
+	}
+	if !report.OK() {
+		return ErrDryRunFailed
+	}
+	return nil
+}
+
+// dryRun runs the steps of DryRun, skipping those after a failure.
+func dryRun(ctx context.Context, opts Options) *DryRunReport {
+	report := &DryRunReport{Service: opts.Service}
+	failed := false
+	step := func(name string, fn func() (string, error)) {
+		if failed {
+			report.Steps = append(report.Steps, DryRunStep{Name: name, Skipped: true})
+			return
+		}
+		detail, err := fn()
+		failed = err != nil
+		report.Steps = append(report.Steps, DryRunStep{Name: name, Detail: detail, Err: err})
+	}
+
+	if opts.Port == 0 {
+		opts.Port = PortFromEnv()
+	}
+
+	step("options", func() (string, error) {
+		return "", opts.validate()
+	})
+	step("server settings", func() (string, error) {
+		v := &validator{}
+		validateServer(v, opts)
+		return "", v.err()
+	})
+
+	var store configstore.Store
+	step("config store", func() (string, error) {
+		var err error
+		if store, err = configstore.NewFromEnv(); err != nil {
+			return "", err
+		}
+		return configstore.GetEnvDefault(configstore.EnvStorageMode, configstore.StorageModeEnvFile), shared.PingStore(ctx, store)
+	})
+
+	h := &handlers{}
+	step("configuration", func() (string, error) {
+		setPortEnv(opts.Port)
+		if err := loadConfig(ctx, store, opts.Service, h); err != nil {
+			return "", err
+		}
+		return "", h.loadErr()
+	})
+	step("github app", func() (string, error) {
+		return verifyApp(ctx, h.transport)
+	})
+	return report
+}
+
+// verifyApp authenticates to GitHub as the App of atr, and describes it.
+func verifyApp(ctx context.Context, atr *ghinstallation.AppsTransport) (string, error) {
+	client := github.NewClient(&http.Client{Transport: atr})
+	app, _, err := client.Apps.Get(ctx, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to authenticate as the GitHub App: %w", err)
+	}
+	return fmt.Sprintf("authenticated as %s (app %d)", app.GetSlug(), app.GetID()), nil
+}
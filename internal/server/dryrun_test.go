@@ -0,0 +1,70 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDryRunReport(t *testing.T) {
+	report := &DryRunReport{Service: ServiceAll, Steps: []DryRunStep{
+		{Name: "options"},
+		{Name: "config store", Detail: "envfile"},
+		{Name: "configuration", Err: &ValidationError{Problems: []Problem{
+			{Subsystem: "sts", Err: errors.New("STS_DOMAIN is required")},
+		}}},
+		{Name: "github app", Skipped: true},
+	}}
+	if report.OK() {
+		t.Error("OK() = true with a failed step")
+	}
+
+	var buf bytes.Buffer
+	if _, err := report.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `dry run of all:
+  [ ok ] options
+  [ ok ] config store: envfile
+  [FAIL] configuration: invalid configuration (1 problem):
+      sts: STS_DOMAIN is required
+  [skip] github app
+result: would not become ready
+`
+	if got := buf.String(); got != want {
+		t.Errorf("report =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDryRunSkipsAfterFailure(t *testing.T) {
+	var buf bytes.Buffer
+	err := DryRun(context.Background(), Options{Service: "proxy"}, &buf)
+	if !errors.Is(err, ErrDryRunFailed) {
+		t.Fatalf("DryRun() error = %v, want ErrDryRunFailed", err)
+	}
+
+	report := dryRun(context.Background(), Options{Service: "proxy"})
+	if len(report.Steps) != 5 || report.Steps[0].Err == nil {
+		t.Fatalf("steps = %+v, want the options step to fail", report.Steps)
+	}
+	for _, step := range report.Steps[1:] {
+		if !step.Skipped {
+			t.Errorf("step %q ran after a failure", step.Name)
+		}
+	}
+}
+
+func TestOptionsFromEnvDryRun(t *testing.T) {
+	t.Setenv(EnvDryRun, "true")
+	if !OptionsFromEnv(ServiceSTS).DryRun {
+		t.Errorf("DryRun = false with %s=true", EnvDryRun)
+	}
+	t.Setenv(EnvDryRun, "")
+	if OptionsFromEnv(ServiceSTS).DryRun {
+		t.Errorf("DryRun = true with %s unset", EnvDryRun)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+	"github.com/cruxstack/octo-sts-distros/internal/installer"
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+)
+
+// installerPaths are the routes of the installer.
+var installerPaths = []string{"/setup", "/setup/", "/callback", "/"}
+
+// mountInstaller mounts the installer built from cfg on mux.
+func mountInstaller(mux *http.ServeMux, cfg installer.Config, store configstore.Store) error {
+	h, err := installer.New(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create installer handler: %w", err)
+	}
+	var installerHandler http.Handler = installer.NewMetadataHandler(h)
+	if installer.KubernetesManifestEnabled() {
+		installerHandler = installer.NewKubernetesManifestHandler(installerHandler, installer.NewKubernetesManifestConfigFromEnv())
+	}
+	installerHandler = installer.NewStoreCheckHandler(installerHandler, store)
+
+	// Only honor forwarded headers from INSTALLER_TRUSTED_PROXIES, when set
+	trusted, err := installer.TrustedProxiesFromEnv()
+	if err != nil {
+		return err
+	}
+	installerHandler = installer.NewTrustedProxyHandler(installerHandler, trusted)
+
+	for _, path := range installerPaths {
+		mux.Handle(path, installerHandler)
+	}
+	return nil
+}
+
+// Install serves only the installer, on port (zero uses PORT), and returns
+// once the GitHub App credentials are saved to the config store or ctx is
+// done. It registers an app without running either service, e.g. before
+// the first deployment.
+func Install(ctx context.Context, port int) error {
+	if port == 0 {
+		port = PortFromEnv()
+	}
+
+	log := clog.FromContext(ctx)
+
+	store, err := configstore.NewFromEnv()
+	if err != nil {
+		return fmt.Errorf("config store: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Stop serving once the credentials are saved. OnCredentialsSaved runs
+	// before the save, so it can't tell whether the save succeeded.
+	installerCfg := installer.NewOctoSTSConfig(&saveNotifier{Store: store, saved: func() {
+		log.Infof("[installer] credentials saved, shutting down")
+		cancel()
+	}})
+
+	drainer := shared.NewDrainer()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", drainer.HealthHandler(shared.HealthHandler(shared.HealthChecks{Store: store})))
+	if err := mountInstaller(mux, installerCfg, store); err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		ReadHeaderTimeout: shared.DefaultReadHeaderTimeout,
+		Handler:           mux,
+	}
+
+	return serve(ctx, srv, drainer, 0, func(context.Context) error {
+		log.Infof("[config] installer enabled: visit /setup to create GitHub App")
+		return nil
+	})
+}
+
+// saveNotifier is a store that calls saved after each successful Save.
+type saveNotifier struct {
+	configstore.Store
+	saved func()
+}
+
+func (s *saveNotifier) Save(ctx context.Context, creds *configstore.AppCredentials) error {
+	if err := s.Store.Save(ctx, creds); err != nil {
+		return err
+	}
+	s.saved()
+	return nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package server
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+)
+
+// failingStore fails every Save.
+type failingStore struct {
+	configstore.Store
+}
+
+func (failingStore) Save(context.Context, *configstore.AppCredentials) error {
+	return errors.New("access denied")
+}
+
+func TestSaveNotifier(t *testing.T) {
+	ctx := context.Background()
+	creds := &configstore.AppCredentials{AppID: 1234, AppSlug: "octo-sts"}
+
+	saved := false
+	failing := &saveNotifier{
+		Store: failingStore{configstore.NewLocalEnvFileStore(filepath.Join(t.TempDir(), ".env"))},
+		saved: func() { saved = true },
+	}
+	if err := failing.Save(ctx, creds); err == nil {
+		t.Fatal("Save() error = nil, want the store's error")
+	}
+	if saved {
+		t.Error("saved called after a failed Save, the installer would shut down")
+	}
+
+	ok := &saveNotifier{
+		Store: configstore.NewLocalEnvFileStore(filepath.Join(t.TempDir(), ".env")),
+		saved: func() { saved = true },
+	}
+	if err := ok.Save(ctx, creds); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if !saved {
+		t.Error("saved not called after a successful Save")
+	}
+}
@@ -0,0 +1,442 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package server runs the HTTP services of the standalone distributions: the
+// STS, the webhook, or both on one port. It is shared by the http-*,
+// cloudrun, and azure-* commands and the octo-sts CLI, which differ only in
+// how they build Options.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/cruxstack/github-app-setup-go/ghappsetup"
+	"github.com/cruxstack/octo-sts-distros/internal/admin"
+	"github.com/cruxstack/octo-sts-distros/internal/audit"
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+	"github.com/cruxstack/octo-sts-distros/internal/errreport"
+	"github.com/cruxstack/octo-sts-distros/internal/installer"
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+	"github.com/cruxstack/octo-sts-distros/internal/sts"
+	"github.com/cruxstack/octo-sts-distros/internal/version"
+)
+
+// Service selects which handlers a server runs.
+type Service string
+
+// Supported services.
+const (
+	// ServiceSTS serves the STS at the root path.
+	ServiceSTS Service = "sts"
+	// ServiceWebhook serves the webhook at /webhook.
+	ServiceWebhook Service = "webhook"
+	// ServiceAll serves the STS at /sts and the webhook at /webhook.
+	ServiceAll Service = "all"
+)
+
+// EnvPort is the environment variable for the port to listen on.
+const EnvPort = "PORT"
+
+// STSBasePath is where ServiceAll mounts the STS.
+const STSBasePath = "/sts"
+
+// ErrInvalidOptions is returned by Run for options it cannot serve.
+var ErrInvalidOptions = errors.New("invalid server options")
+
+// Options configures Run.
+type Options struct {
+	// Service selects the handlers to serve.
+	Service Service
+
+	// Port is the TCP port to listen on when LISTEN_ADDR and LISTEN_SOCKET
+	// are not set. Zero uses PORT, or DefaultPort.
+	Port int
+
+	// Installer serves the installer at /setup. The STS service doesn't
+	// support it, since it owns the root path.
+	Installer bool
+
+	// DryRun checks the configuration and writes a report to stdout instead
+	// of serving; see DryRun.
+	DryRun bool
+
+	// ShutdownTimeout is the default of SHUTDOWN_TIMEOUT, e.g. to fit the
+	// grace period of the platform. Zero uses shared.DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+}
+
+// OptionsFromEnv returns the options the http-* commands have always read
+// from the environment for service.
+func OptionsFromEnv(service Service) Options {
+	return Options{
+		Service:   service,
+		Port:      PortFromEnv(),
+		Installer: service != ServiceSTS && configstore.InstallerEnabled(),
+		DryRun:    dryRunFromEnv(),
+	}
+}
+
+// dryRunFromEnv reports whether DRY_RUN is set to true.
+func dryRunFromEnv() bool {
+	dryRun, _ := strconv.ParseBool(os.Getenv(EnvDryRun))
+	return dryRun
+}
+
+// PortFromEnv returns PORT, or shared.DefaultPort when it is unset or invalid.
+func PortFromEnv() int {
+	if port, err := strconv.Atoi(os.Getenv(EnvPort)); err == nil && port > 0 {
+		return port
+	}
+	return shared.DefaultPort
+}
+
+// setPortEnv sets PORT to port, which envconfig.BaseConfig requires even
+// though the listener is configured from Options.
+func setPortEnv(port int) {
+	os.Setenv(EnvPort, strconv.Itoa(port))
+}
+
+func (o Options) validate() error {
+	switch o.Service {
+	case ServiceSTS:
+		if o.Installer {
+			return fmt.Errorf("%w: the installer is not available for the %s service", ErrInvalidOptions, o.Service)
+		}
+	case ServiceWebhook, ServiceAll:
+	default:
+		return fmt.Errorf("%w: unknown service %q", ErrInvalidOptions, o.Service)
+	}
+	if o.Port < 0 || o.Port > 65535 {
+		return fmt.Errorf("%w: port %d out of range", ErrInvalidOptions, o.Port)
+	}
+	if o.ShutdownTimeout < 0 {
+		return fmt.Errorf("%w: negative shutdown timeout %s", ErrInvalidOptions, o.ShutdownTimeout)
+	}
+	return nil
+}
+
+// Run serves opts.Service until ctx is done, then shuts the server down
+// gracefully. It returns once configuration fails to load or the server
+// has stopped. With opts.DryRun, it returns the result of DryRun instead.
+func Run(ctx context.Context, opts Options) error {
+	if opts.DryRun {
+		return DryRun(ctx, opts, os.Stdout)
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if opts.Port == 0 {
+		opts.Port = PortFromEnv()
+	}
+	setPortEnv(opts.Port)
+
+	// Report every invalid setting at once, rather than failing on the first
+	v := &validator{}
+	validateServer(v, opts)
+	validateService(v, opts.Service, false)
+	if err := v.err(); err != nil {
+		return err
+	}
+
+	log := clog.FromContext(ctx)
+	log.Infof("[version] %s", version.Get())
+
+	// Report panics and server errors when SENTRY_DSN is set
+	if err := errreport.Init(ctx, string(opts.Service)); err != nil {
+		return fmt.Errorf("error reporting: %w", err)
+	}
+	defer errreport.Flush()
+
+	// Build allowed paths for the ready gate
+	allowedPaths := []string{"GET /healthz", "GET " + shared.ReadyPath, "GET " + version.Path}
+	if opts.Installer {
+		allowedPaths = append(allowedPaths, shared.InstallerAllowedPaths...)
+	}
+
+	// Set up routes, starting with metrics so they bypass the ready gate
+	mux := http.NewServeMux()
+	metricsPath, err := shared.ServeMetrics(ctx, mux)
+	if err != nil {
+		return fmt.Errorf("metrics: %w", err)
+	}
+	if metricsPath != "" {
+		allowedPaths = append(allowedPaths, "GET "+metricsPath)
+	}
+
+	// Send the same metrics to StatsD when STATSD_ADDR or DD_AGENT_HOST is set
+	if err := shared.ServeStatsD(ctx); err != nil {
+		return fmt.Errorf("statsd: %w", err)
+	}
+
+	// Profiles are only served on their own address, when PPROF_ADDR is set
+	if err := shared.ServePprof(ctx); err != nil {
+		return fmt.Errorf("pprof: %w", err)
+	}
+
+	// Create handlers (will be configured after config loads)
+	handlers := &handlers{}
+
+	// Ship exchange audit events when AUDIT_SINK is set
+	if opts.Service != ServiceWebhook {
+		recorder, err := audit.FromEnv(ctx)
+		if err != nil {
+			return fmt.Errorf("audit: %w", err)
+		}
+		handlers.audit = recorder.Record
+		defer func() {
+			closeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), audit.CloseTimeout)
+			defer cancel()
+			if err := recorder.Close(closeCtx); err != nil {
+				log.Errorf("[audit] failed to write queued events: %v", err)
+			}
+		}()
+	}
+
+	store, err := configstore.NewFromEnv()
+	if err != nil {
+		return fmt.Errorf("config store: %w", err)
+	}
+
+	// The admin API answers before configuration loads, so a failed load
+	// can be retried with a reload
+	adminToken := os.Getenv(admin.EnvToken)
+	if adminToken != "" {
+		allowedPaths = append(allowedPaths, admin.PathPrefix+"*", "POST "+admin.ReloadPath)
+	}
+
+	// Retry the first load with jittered backoff, so instances restarted
+	// together after an outage don't retry in lockstep
+	wait, err := shared.ConfigWaitFromEnv()
+	if err != nil {
+		return fmt.Errorf("config wait: %w", err)
+	}
+
+	// Collapse the reload triggers of one change into a single reload
+	debounce, err := shared.ReloadDebounceFromEnv()
+	if err != nil {
+		return fmt.Errorf("reload: %w", err)
+	}
+	reloader := shared.NewReloader(debounce)
+	wait.Wake = reloader.Triggered()
+
+	// Show the attempts of the wait on /readyz
+	progress := &shared.WaitProgress{}
+	wait.Progress = progress.Report
+
+	// Response to requests that arrive before the configuration is loaded
+	notReady, err := shared.NotReadyResponseFromEnv()
+	if err != nil {
+		return fmt.Errorf("ready gate: %w", err)
+	}
+
+	readyFile, err := shared.ReadyFileFromEnv()
+	if err != nil {
+		return err
+	}
+
+	// Create runtime with unified lifecycle management
+	runtime, err := ghappsetup.NewRuntime(ghappsetup.Config{
+		Store: store,
+		LoadFunc: wait.LoadFunc(shared.WithEnvRollback(func(ctx context.Context) error {
+			return loadConfig(ctx, store, opts.Service, handlers)
+		})),
+		MaxRetries: 1,
+	})
+	if err != nil {
+		return fmt.Errorf("runtime: %w", err)
+	}
+	reloader.Register("config", runtime.Reload)
+
+	// Limit requests to the STS and webhook handlers when RATE_LIMIT* is set
+	limiter, err := shared.NewRateLimiterFromEnv()
+	if err != nil {
+		return fmt.Errorf("rate limiting: %w", err)
+	}
+
+	// Readiness turns false as soon as shutdown starts
+	drainer := shared.NewDrainer()
+	go shared.WatchReadyFile(ctx, readyFile, func() bool { return handlers.ready() && !drainer.Draining() })
+	mux.HandleFunc("/healthz", drainer.HealthHandler(shared.HealthHandler(shared.HealthChecks{
+		Ready:  handlers.ready,
+		Store:  store,
+		GitHub: handlers.github.Check,
+	})))
+	mux.HandleFunc(shared.ReadyPath, drainer.HealthHandler(shared.ReadyHandler(handlers.ready, reloader, progress, handlers.components(opts.Service)...)))
+	mux.Handle(version.Path, version.Handler())
+
+	adminCfg := admin.Config{
+		Token:         adminToken,
+		Reload:        reloader.Reload,
+		LastReload:    reloader.LastReload,
+		ReloadHistory: reloader.History,
+	}
+	if opts.Service != ServiceWebhook {
+		adminCfg.FlushCaches = admin.FlushSTSCaches
+		adminCfg.Installations = sts.Installations
+	}
+	admin.New(adminCfg).Register(mux)
+	if adminToken != "" {
+		log.Infof("[admin] admin API enabled at %s and %s", admin.PathPrefix, admin.ReloadPath)
+	}
+
+	// Cap the requests each handler serves at once when *_MAX_IN_FLIGHT is set
+	stsInFlight, err := inFlightLimiter("sts", shared.EnvSTSMaxInFlight)
+	if err != nil {
+		return err
+	}
+	webhookInFlight, err := inFlightLimiter("webhook", shared.EnvWebhookMaxInFlight)
+	if err != nil {
+		return err
+	}
+
+	// Requests sent to a tenant's domain are served by the tenant's App
+	stsHandler := limiter.Handler(stsInFlight.Handler(handlers.byHost(&handlers.sts, func(t *tenantHandlers) http.Handler { return t.sts })))
+	webhookHandler := limiter.Handler(webhookInFlight.Handler(handlers.byHost(&handlers.webhook, func(t *tenantHandlers) http.Handler { return t.webhook })))
+	switch opts.Service {
+	case ServiceSTS:
+		mux.Handle("/", stsHandler)
+	case ServiceWebhook:
+		mux.Handle("/webhook", webhookHandler)
+	case ServiceAll:
+		// Each component is gated on its own, so the webhook serves while
+		// the STS is still loading and the other way round
+		stsHandler = shared.ReadyGate(stsHandler, handlers.stsReady.Load, nil, notReady)
+		webhookHandler = shared.ReadyGate(webhookHandler, handlers.webhookReady.Load, nil, notReady)
+		mux.Handle(STSBasePath, stsHandler)
+		mux.Handle(STSBasePath+"/", stsHandler)
+		mux.Handle("/webhook", webhookHandler)
+	}
+
+	// Enable installer (doesn't require GitHub App config)
+	if opts.Installer {
+		installerCfg := installer.NewOctoSTSConfig(store)
+		// Reload once the installer saved the credentials
+		installerCfg.OnCredentialsSaved = installer.WrapOnCredentialsSaved(installerCfg.OnCredentialsSaved, reloader.TriggerFunc(shared.ReloadSourceInstaller))
+		if err := mountInstaller(mux, installerCfg, store); err != nil {
+			return err
+		}
+		log.Infof("[config] installer enabled: visit /setup to create GitHub App")
+	}
+
+	// Start HTTP server with ReadyGate middleware
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", opts.Port),
+		ReadHeaderTimeout: shared.DefaultReadHeaderTimeout,
+		Handler:           errreport.Handler(shared.ReadyGate(mux, handlers.ready, allowedPaths, notReady)),
+	}
+
+	return serve(ctx, srv, drainer, opts.ShutdownTimeout, func(ctx context.Context) error {
+		// Block until config loads
+		log.Infof("Waiting for configuration...")
+		if err := runtime.Start(ctx); err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		log.Infof("Configuration loaded, service is ready")
+
+		// Drop the cached installations when the reload switched GitHub Apps
+		if opts.Service != ServiceWebhook {
+			reloader.Register("sts caches", sts.CacheReloadHook(func() string { return os.Getenv(configstore.EnvGitHubAppID) }))
+		}
+
+		// Reload on SIGHUP and the installer's and watcher's triggers
+		reloader.Start(ctx)
+
+		// Reload when credentials are changed in the store by another process
+		shared.WatchStore(ctx, store, reloader.TriggerFunc(shared.ReloadSourceStore))
+		return nil
+	})
+}
+
+// inFlightLimiter returns the in-flight limiter of the handler name set by
+// key, or nil if it is unset.
+func inFlightLimiter(name, key string) (*shared.InFlightLimiter, error) {
+	max, err := shared.MaxInFlightFromEnv(key)
+	if err != nil {
+		return nil, err
+	}
+	return shared.NewInFlightLimiter(name, max), nil
+}
+
+// serve listens for srv, runs start once the server is accepting
+// connections, and shuts the server down when ctx is done, draining the
+// requests tracked by drainer for up to SHUTDOWN_TIMEOUT, or
+// shutdownTimeout if set. A start or server error stops the server early
+// and is returned.
+func serve(ctx context.Context, srv *http.Server, drainer *shared.Drainer, shutdownTimeout time.Duration, start func(context.Context) error) error {
+	log := clog.FromContext(ctx)
+
+	if shutdownTimeout == 0 {
+		shutdownTimeout = shared.DefaultShutdownTimeout
+	}
+	shutdownCfg, err := shared.ShutdownConfigFromEnv(shutdownTimeout)
+	if err != nil {
+		return err
+	}
+
+	// Serve TLS directly when a certificate or ACME hosts are configured
+	tlsConfig, err := shared.TLSConfigFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to configure TLS: %w", err)
+	}
+	srv.TLSConfig = tlsConfig
+	srv.Handler = drainer.Handler(srv.Handler)
+
+	// HTTP/2 over TLS, and h2c when HTTP2_CLEARTEXT is set
+	srv.Protocols, err = shared.ProtocolsFromEnv()
+	if err != nil {
+		return err
+	}
+
+	// Listen on LISTEN_SOCKET or LISTEN_ADDR if set, else on the port
+	ln, err := shared.Listen(srv.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	log.Infof("Starting HTTP server on %s", ln.Addr())
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- shared.Serve(srv, ln)
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	startErr := make(chan error, 1)
+	go func() {
+		startErr <- start(ctx)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("server error: %w", err)
+	case err := <-startErr:
+		// A start cut short by shutdown isn't an error
+		if err != nil && ctx.Err() == nil {
+			_ = srv.Close()
+			return err
+		}
+	}
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("server error: %w", err)
+	case <-ctx.Done():
+	}
+	log.Infof("Shutting down server...")
+
+	if err := drainer.Shutdown(ctx, srv, shutdownCfg); err != nil {
+		return fmt.Errorf("server shutdown error: %w", err)
+	}
+	log.Infof("[shutdown] all requests finished")
+	return nil
+}
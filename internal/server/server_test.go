@@ -0,0 +1,85 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{name: "sts", opts: Options{Service: ServiceSTS}},
+		{name: "webhook with installer", opts: Options{Service: ServiceWebhook, Installer: true}},
+		{name: "all with port", opts: Options{Service: ServiceAll, Port: 9090}},
+		{name: "sts with installer", opts: Options{Service: ServiceSTS, Installer: true}, wantErr: true},
+		{name: "unknown service", opts: Options{Service: "proxy"}, wantErr: true},
+		{name: "port out of range", opts: Options{Service: ServiceAll, Port: 70000}, wantErr: true},
+		{name: "shutdown timeout", opts: Options{Service: ServiceAll, ShutdownTimeout: 8 * time.Second}},
+		{name: "negative shutdown timeout", opts: Options{Service: ServiceAll, ShutdownTimeout: -time.Second}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidOptions) {
+				t.Errorf("validate() error = %v, want ErrInvalidOptions", err)
+			}
+		})
+	}
+}
+
+func TestRunInvalidOptions(t *testing.T) {
+	err := Run(context.Background(), Options{Service: ServiceSTS, Installer: true})
+	if !errors.Is(err, ErrInvalidOptions) {
+		t.Fatalf("Run() error = %v, want ErrInvalidOptions", err)
+	}
+}
+
+func TestPortFromEnv(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{value: "", want: 8080},
+		{value: "9090", want: 9090},
+		{value: "http", want: 8080},
+		{value: "-1", want: 8080},
+	}
+	for _, tt := range tests {
+		t.Setenv(EnvPort, tt.value)
+		if got := PortFromEnv(); got != tt.want {
+			t.Errorf("PortFromEnv() with PORT=%q = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSwappableHandler(t *testing.T) {
+	var h swappableHandler
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("unconfigured status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	h.SetHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("configured status = %d, want %d", rec.Code, http.StatusTeapot)
+	}
+}
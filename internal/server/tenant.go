@@ -0,0 +1,206 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/cruxstack/octo-sts-distros/internal/app"
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+	"github.com/cruxstack/octo-sts-distros/internal/sts"
+	envConfig "github.com/octo-sts/app/pkg/envconfig"
+	"github.com/octo-sts/app/pkg/ghtransport"
+)
+
+// EnvTenants lists the tenants served besides the default GitHub App,
+// comma-separated. Each tenant is a GitHub App of its own, whose requests
+// are told apart by their Host.
+const EnvTenants = "TENANTS"
+
+// Suffixes of the variables configuring a tenant, which are prefixed with
+// TENANT_ and the tenant name in upper case, e.g. TENANT_PAYMENTS_DOMAIN.
+const (
+	// tenantEnvDomain is the STS domain of the tenant, which its requests
+	// are sent to. Defaults to STS_DOMAIN in the tenant's store.
+	tenantEnvDomain = "DOMAIN"
+
+	// tenantEnvStoragePrefix locates the tenant's credentials in the config
+	// store, in place of the storage mode's own prefix variable.
+	tenantEnvStoragePrefix = "STORAGE_PREFIX"
+)
+
+// tenantName matches the names of tenants.
+var tenantName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Tenant is a GitHub App served from the same deployment as the default
+// one, for requests sent to its domain.
+type Tenant struct {
+	// Name identifies the tenant in logs and scopes its caches.
+	Name string
+
+	// Domain is the STS domain of the tenant, and the Host its requests are
+	// routed by. Empty to read STS_DOMAIN from the tenant's store.
+	Domain string
+
+	// StoragePrefix locates the tenant's credentials in the config store.
+	StoragePrefix string
+}
+
+// TenantEnv returns the variable configuring suffix for the tenant name,
+// e.g. TENANT_PAYMENTS_DOMAIN.
+func TenantEnv(name, suffix string) string {
+	return "TENANT_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_" + suffix
+}
+
+// TenantsFromEnv returns the tenants listed in TENANTS, or nil when it is
+// unset.
+func TenantsFromEnv() ([]Tenant, error) {
+	var tenants []Tenant
+	seen := make(map[string]bool)
+	for _, s := range strings.Split(os.Getenv(EnvTenants), ",") {
+		name := strings.ToLower(strings.TrimSpace(s))
+		if name == "" {
+			continue
+		}
+		if !tenantName.MatchString(name) {
+			return nil, fmt.Errorf("invalid %s: tenant %q must be lower-case letters, digits, and dashes", EnvTenants, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("invalid %s: tenant %q is listed twice", EnvTenants, name)
+		}
+		seen[name] = true
+
+		t := Tenant{
+			Name:          name,
+			Domain:        normalizeHost(os.Getenv(TenantEnv(name, tenantEnvDomain))),
+			StoragePrefix: os.Getenv(TenantEnv(name, tenantEnvStoragePrefix)),
+		}
+		if t.StoragePrefix == "" {
+			return nil, fmt.Errorf("%s is required", TenantEnv(name, tenantEnvStoragePrefix))
+		}
+		tenants = append(tenants, t)
+	}
+	return tenants, nil
+}
+
+// tenantHandlers are the handlers of one tenant.
+type tenantHandlers struct {
+	name    string
+	sts     http.Handler
+	webhook http.Handler
+}
+
+// tenantRoutes maps the domain of each tenant to its handlers.
+type tenantRoutes map[string]*tenantHandlers
+
+// keep fills in the handlers of the component other than loaded, which
+// failed to load, from the routes of the previous load, so tenants keep
+// serving them.
+func (routes tenantRoutes) keep(previous *tenantRoutes, loaded Service) {
+	if previous == nil {
+		return
+	}
+	for domain, t := range routes {
+		prev, ok := (*previous)[domain]
+		if !ok {
+			continue
+		}
+		if loaded == ServiceWebhook {
+			t.sts = prev.sts
+		} else {
+			t.webhook = prev.webhook
+		}
+	}
+}
+
+// byHost routes requests sent to the domain of a tenant to the handler pick
+// returns for it, and other requests to fallback, the default App.
+func (h *handlers) byHost(fallback http.Handler, pick func(*tenantHandlers) http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if routes := h.tenants.Load(); routes != nil {
+			if t, ok := (*routes)[normalizeHost(r.Host)]; ok {
+				handler := pick(t)
+				if handler == nil {
+					// The tenant's component has not loaded yet
+					http.Error(w, "service not configured", http.StatusServiceUnavailable)
+					return
+				}
+				handler.ServeHTTP(w, r)
+				return
+			}
+		}
+		fallback.ServeHTTP(w, r)
+	})
+}
+
+// normalizeHost returns host without its port, lower-cased and without a
+// trailing dot, so it can be compared to a domain.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// loadTenants builds the handlers of each tenant from its credentials in the
+// config store, configured like the default App's stsCfg and appCfg. No two
+// tenants, nor a tenant and the default App, may share a domain.
+func loadTenants(ctx context.Context, tenants []Tenant, service Service, stsCfg sts.Config, appCfg app.Config) (tenantRoutes, error) {
+	routes := make(tenantRoutes, len(tenants))
+	mode := configstore.GetEnvDefault(configstore.EnvStorageMode, configstore.StorageModeEnvFile)
+	for _, t := range tenants {
+		store, err := configstore.NewForModeWithPrefix(mode, t.StoragePrefix)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: config store: %w", t.Name, err)
+		}
+		creds, err := store.Load(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: failed to load credentials from store: %w", t.Name, err)
+		}
+
+		domain := t.Domain
+		if domain == "" {
+			domain = normalizeHost(creds.CustomFields[configstore.EnvSTSDomain])
+		}
+		if domain == "" {
+			return nil, fmt.Errorf("tenant %s: %s or %s in its store is required", t.Name, TenantEnv(t.Name, tenantEnvDomain), configstore.EnvSTSDomain)
+		}
+		if domain == normalizeHost(stsCfg.Domain) {
+			return nil, fmt.Errorf("tenant %s: domain %s is the default app's", t.Name, domain)
+		}
+		if other, ok := routes[domain]; ok {
+			return nil, fmt.Errorf("tenant %s: domain %s is also tenant %s's", t.Name, domain, other.name)
+		}
+
+		atr, err := ghtransport.New(ctx, creds.AppID, "", &envConfig.EnvConfig{AppSecretCertificateEnvVar: creds.PrivateKey}, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: error creating GitHub App transport: %w", t.Name, err)
+		}
+
+		th := &tenantHandlers{name: t.Name}
+		if service != ServiceWebhook {
+			cfg := stsCfg
+			cfg.Domain, cfg.AdditionalDomains, cfg.Tenant = domain, nil, t.Name
+			if th.sts, err = sts.New(atr, cfg); err != nil {
+				return nil, fmt.Errorf("tenant %s: failed to create sts: %w", t.Name, err)
+			}
+		}
+		if service != ServiceSTS {
+			// The organization filter is the default App's
+			cfg := appCfg
+			cfg.WebhookSecrets, cfg.Organizations = [][]byte{[]byte(creds.WebhookSecret)}, nil
+			if th.webhook, err = app.New(atr, cfg); err != nil {
+				return nil, fmt.Errorf("tenant %s: failed to create app: %w", t.Name, err)
+			}
+		}
+		routes[domain] = th
+	}
+	return routes, nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cruxstack/octo-sts-distros/internal/app"
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+	"github.com/cruxstack/octo-sts-distros/internal/sts"
+)
+
+func TestTenantsFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    []Tenant
+		wantErr string
+	}{
+		{name: "unset"},
+		{
+			name: "tenants",
+			env: map[string]string{
+				EnvTenants:                            "payments, Data-Platform",
+				"TENANT_PAYMENTS_DOMAIN":              "Payments.Example.com.",
+				"TENANT_PAYMENTS_STORAGE_PREFIX":      "/octo-sts/payments/",
+				"TENANT_DATA_PLATFORM_STORAGE_PREFIX": "/octo-sts/data/",
+			},
+			want: []Tenant{
+				{Name: "payments", Domain: "payments.example.com", StoragePrefix: "/octo-sts/payments/"},
+				{Name: "data-platform", StoragePrefix: "/octo-sts/data/"},
+			},
+		},
+		{
+			name:    "missing prefix",
+			env:     map[string]string{EnvTenants: "payments"},
+			wantErr: "TENANT_PAYMENTS_STORAGE_PREFIX is required",
+		},
+		{
+			name:    "invalid name",
+			env:     map[string]string{EnvTenants: "pay_ments"},
+			wantErr: "invalid TENANTS",
+		},
+		{
+			name: "listed twice",
+			env: map[string]string{
+				EnvTenants:                       "payments,payments",
+				"TENANT_PAYMENTS_STORAGE_PREFIX": "/octo-sts/payments/",
+			},
+			wantErr: "listed twice",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvTenants, "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			got, err := TenantsFromEnv()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("TenantsFromEnv() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TenantsFromEnv() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("TenantsFromEnv() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("tenant %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestByHost(t *testing.T) {
+	status := func(code int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(code)
+		})
+	}
+
+	h := &handlers{}
+	routes := tenantRoutes{"payments.example.com": {name: "payments", sts: status(http.StatusTeapot)}}
+	h.tenants.Store(&routes)
+	handler := h.byHost(status(http.StatusOK), func(t *tenantHandlers) http.Handler { return t.sts })
+
+	for host, want := range map[string]int{
+		"payments.example.com":      http.StatusTeapot,
+		"PAYMENTS.example.com:8443": http.StatusTeapot,
+		"sts.example.com":           http.StatusOK,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Host %s: status = %d, want %d", host, rec.Code, want)
+		}
+	}
+
+	// A tenant whose webhook hasn't loaded yet
+	webhook := h.byHost(status(http.StatusOK), func(t *tenantHandlers) http.Handler { return t.webhook })
+	req := httptest.NewRequest(http.MethodPost, "/webhook", nil)
+	req.Host = "payments.example.com"
+	rec := httptest.NewRecorder()
+	webhook.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("unloaded component: status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestTenantRoutesKeep(t *testing.T) {
+	oldSTS, oldWebhook, newWebhook := &swappableHandler{}, &swappableHandler{}, &swappableHandler{}
+	previous := tenantRoutes{"payments.example.com": {name: "payments", sts: oldSTS, webhook: oldWebhook}}
+	routes := tenantRoutes{
+		"payments.example.com": {name: "payments", webhook: newWebhook},
+		"billing.example.com":  {name: "billing", webhook: newWebhook},
+	}
+
+	routes.keep(&previous, ServiceWebhook)
+	if got := routes["payments.example.com"]; got.sts != oldSTS || got.webhook != newWebhook {
+		t.Errorf("payments = %+v, want the previous STS and the new webhook", got)
+	}
+	if got := routes["billing.example.com"]; got.sts != nil {
+		t.Errorf("billing STS = %v, want none for a new tenant", got.sts)
+	}
+
+	routes.keep(nil, ServiceWebhook)
+}
+
+// saveTenant saves credentials for a tenant with domain to a files store
+// in a new directory, and returns the directory.
+func saveTenant(t *testing.T, domain string) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	dir := t.TempDir()
+	err = configstore.NewLocalFileStore(dir).Save(context.Background(), &configstore.AppCredentials{
+		AppID:         1234,
+		ClientID:      "Iv1.abc",
+		ClientSecret:  "client-secret",
+		WebhookSecret: "webhook-secret",
+		PrivateKey:    string(pemKey),
+		CustomFields:  map[string]string{configstore.EnvSTSDomain: domain},
+	})
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	return dir
+}
+
+func TestLoadTenants(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv(configstore.EnvStorageMode, configstore.StorageModeFiles)
+	stsCfg := sts.Config{Domain: "sts.example.com", BasePath: STSBasePath}
+
+	payments := Tenant{Name: "payments", StoragePrefix: saveTenant(t, "payments.example.com")}
+	routes, err := loadTenants(ctx, []Tenant{payments}, ServiceAll, stsCfg, app.Config{})
+	if err != nil {
+		t.Fatalf("loadTenants() error = %v", err)
+	}
+	th, ok := routes["payments.example.com"]
+	if !ok || th.sts == nil || th.webhook == nil {
+		t.Fatalf("routes = %v, want the handlers of payments.example.com", routes)
+	}
+
+	clash := Tenant{Name: "data", Domain: "payments.example.com", StoragePrefix: payments.StoragePrefix}
+	if _, err := loadTenants(ctx, []Tenant{payments, clash}, ServiceAll, stsCfg, app.Config{}); err == nil || !strings.Contains(err.Error(), "also tenant payments's") {
+		t.Errorf("loadTenants() with a shared domain error = %v", err)
+	}
+
+	dflt := Tenant{Name: "data", Domain: "sts.example.com", StoragePrefix: payments.StoragePrefix}
+	if _, err := loadTenants(ctx, []Tenant{dflt}, ServiceAll, stsCfg, app.Config{}); err == nil || !strings.Contains(err.Error(), "default app's") {
+		t.Errorf("loadTenants() with the default domain error = %v", err)
+	}
+
+	empty := Tenant{Name: "empty", StoragePrefix: t.TempDir()}
+	if _, err := loadTenants(ctx, []Tenant{empty}, ServiceAll, stsCfg, app.Config{}); err == nil {
+		t.Error("loadTenants() for an unregistered tenant error = nil")
+	}
+}
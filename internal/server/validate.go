@@ -0,0 +1,194 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	envConfig "github.com/octo-sts/app/pkg/envconfig"
+
+	"github.com/cruxstack/octo-sts-distros/internal/audit"
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+	"github.com/cruxstack/octo-sts-distros/internal/errreport"
+	"github.com/cruxstack/octo-sts-distros/internal/installer"
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+)
+
+// Variables read by the upstream configuration loader, which the GitHub App
+// credentials are mapped to.
+const (
+	envAppIDs                = "GITHUB_APP_IDS"
+	envKMSKeys               = "KMS_KEYS"
+	envAppSecretCertFile     = "APP_SECRET_CERTIFICATE_FILE"
+	envAppSecretCertEnvValue = "APP_SECRET_CERTIFICATE_ENV_VAR"
+)
+
+// Problem is a missing or invalid setting.
+type Problem struct {
+	// Subsystem is the part of the service that needs the setting, e.g.
+	// "sts" or "tls".
+	Subsystem string
+
+	// Err describes the problem and names the variable.
+	Err error
+}
+
+// ValidationError reports every problem found in the configuration, so all
+// of them can be fixed at once.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	if len(e.Problems) == 1 {
+		b.WriteString("invalid configuration (1 problem):")
+	} else {
+		fmt.Fprintf(&b, "invalid configuration (%d problems):", len(e.Problems))
+	}
+	for _, p := range e.Problems {
+		fmt.Fprintf(&b, "\n  %s: %v", p.Subsystem, p.Err)
+	}
+	return b.String()
+}
+
+// validator collects the problems of a configuration.
+type validator struct {
+	problems []Problem
+}
+
+// check records err, if any, as a problem of subsystem.
+func (v *validator) check(subsystem string, err error) {
+	if err != nil {
+		v.problems = append(v.problems, Problem{Subsystem: subsystem, Err: err})
+	}
+}
+
+// require records a problem of subsystem when none of envs is set.
+func (v *validator) require(subsystem string, envs ...string) {
+	for _, env := range envs {
+		if os.Getenv(env) != "" {
+			return
+		}
+	}
+	if len(envs) == 1 {
+		v.check(subsystem, fmt.Errorf("%s is required", envs[0]))
+		return
+	}
+	v.check(subsystem, fmt.Errorf("one of %s is required", strings.Join(envs, ", ")))
+}
+
+// merge records the problems of err, which may itself be a ValidationError.
+func (v *validator) merge(subsystem string, err error) {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		v.problems = append(v.problems, verr.Problems...)
+		return
+	}
+	v.check(subsystem, err)
+}
+
+// err returns the problems as a ValidationError, or nil if there are none.
+func (v *validator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
+
+// validateServer checks the settings of the HTTP server of opts.
+func validateServer(v *validator, opts Options) {
+	if addr := os.Getenv(shared.EnvListenAddr); addr != "" && os.Getenv(shared.EnvListenSocket) == "" {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			v.check("server", fmt.Errorf("invalid %s: %q", shared.EnvListenAddr, addr))
+		}
+	}
+
+	_, err := shared.ShutdownConfigFromEnv(shared.DefaultShutdownTimeout)
+	v.check("shutdown", err)
+
+	_, err = shared.TLSConfigFromEnv()
+	v.check("tls", err)
+
+	_, err = shared.ProtocolsFromEnv()
+	v.check("http2", err)
+
+	_, err = shared.NewRateLimiterFromEnv()
+	v.check("rate limit", err)
+
+	_, err = TenantsFromEnv()
+	v.check("tenants", err)
+
+	_, err = shared.ConfigWaitFromEnv()
+	v.check("config wait", err)
+
+	_, err = shared.ReloadDebounceFromEnv()
+	v.check("reload", err)
+
+	_, err = shared.NotReadyResponseFromEnv()
+	v.check("ready gate", err)
+
+	_, err = shared.ReadyFileFromEnv()
+	v.check("ready file", err)
+
+	_, _, err = shared.StatsDConfigFromEnv()
+	v.check("statsd", err)
+
+	_, _, err = errreport.ConfigFromEnv()
+	v.check("error reporting", err)
+
+	if opts.Service != ServiceWebhook {
+		_, err = audit.ConfigFromEnv()
+		v.check("audit", err)
+	}
+
+	if opts.Installer {
+		_, err = installer.TrustedProxiesFromEnv()
+		v.check("installer", err)
+	}
+}
+
+// validateService checks the settings of the handlers of service. The
+// settings the config store may provide, the GitHub App credentials and
+// STS_DOMAIN, are only checked once they are loaded, since until then they
+// may still come from the store or the installer.
+func validateService(v *validator, service Service, loaded bool) {
+	if service != ServiceWebhook {
+		if loaded {
+			v.require("sts", configstore.EnvSTSDomain)
+		}
+		_, err := shared.MaxBodySizeFromEnv(shared.EnvSTSMaxBodySize)
+		v.check("sts", err)
+		_, err = shared.MaxInFlightFromEnv(shared.EnvSTSMaxInFlight)
+		v.check("sts", err)
+	}
+
+	if service != ServiceSTS {
+		_, err := shared.MaxBodySizeFromEnv(shared.EnvWebhookMaxBodySize)
+		v.check("webhook", err)
+		_, err = shared.MaxInFlightFromEnv(shared.EnvWebhookMaxInFlight)
+		v.check("webhook", err)
+		if loaded {
+			v.require("webhook", configstore.EnvGitHubWebhookSecret)
+		}
+	}
+
+	if !loaded {
+		return
+	}
+
+	// The upstream loader reports only the first problem, so the variables
+	// it requires are checked here first
+	before := len(v.problems)
+	v.require("github app", configstore.EnvGitHubAppID, envAppIDs)
+	v.require("github app", configstore.EnvGitHubAppPrivateKey, envAppSecretCertEnvValue, envAppSecretCertFile, envKMSKeys)
+	if len(v.problems) == before {
+		_, err := envConfig.BaseConfig()
+		v.check("github app", err)
+	}
+}
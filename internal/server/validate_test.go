@@ -0,0 +1,134 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package server
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+)
+
+// clearServiceEnv unsets the variables validateService reads.
+func clearServiceEnv(t *testing.T) {
+	t.Helper()
+	for _, env := range []string{
+		configstore.EnvSTSDomain, configstore.EnvGitHubWebhookSecret,
+		configstore.EnvGitHubAppID, configstore.EnvGitHubAppPrivateKey,
+		envAppIDs, envKMSKeys, envAppSecretCertFile, envAppSecretCertEnvValue,
+		shared.EnvSTSMaxBodySize, shared.EnvWebhookMaxBodySize,
+		shared.EnvSTSMaxInFlight, shared.EnvWebhookMaxInFlight,
+	} {
+		t.Setenv(env, "")
+	}
+}
+
+// subsystems returns the subsystem of each problem of err.
+func subsystems(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("error = %v, want a ValidationError", err)
+	}
+	var got []string
+	for _, p := range verr.Problems {
+		got = append(got, p.Subsystem)
+	}
+	return got
+}
+
+func TestValidateServiceReportsEveryProblem(t *testing.T) {
+	clearServiceEnv(t)
+	t.Setenv(shared.EnvWebhookMaxBodySize, "huge")
+
+	v := &validator{}
+	validateService(v, ServiceAll, true)
+	got := subsystems(t, v.err())
+	want := []string{"sts", "webhook", "webhook", "github app", "github app"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("problems = %v, want %v", got, want)
+	}
+
+	for _, env := range []string{configstore.EnvSTSDomain, configstore.EnvGitHubWebhookSecret, configstore.EnvGitHubAppID, shared.EnvWebhookMaxBodySize} {
+		if !strings.Contains(v.err().Error(), env) {
+			t.Errorf("report does not name %s:\n%v", env, v.err())
+		}
+	}
+}
+
+func TestValidateServiceBeforeLoad(t *testing.T) {
+	clearServiceEnv(t)
+
+	// The credentials and STS_DOMAIN may still come from the config store
+	v := &validator{}
+	validateService(v, ServiceAll, false)
+	if err := v.err(); err != nil {
+		t.Errorf("validateService() before load error = %v", err)
+	}
+
+	t.Setenv(shared.EnvSTSMaxBodySize, "-1")
+	validateService(v, ServiceSTS, false)
+	if got := subsystems(t, v.err()); len(got) != 1 || got[0] != "sts" {
+		t.Errorf("problems = %v, want the STS body size", got)
+	}
+}
+
+func TestValidateServer(t *testing.T) {
+	t.Setenv(shared.EnvListenAddr, "8080")
+	t.Setenv(shared.EnvListenSocket, "")
+	t.Setenv(shared.EnvShutdownTimeout, "soon")
+	t.Setenv(shared.EnvShutdownDrainDelay, "")
+	t.Setenv(shared.EnvRateLimit, "fast")
+
+	v := &validator{}
+	validateServer(v, Options{Service: ServiceAll})
+	got := subsystems(t, v.err())
+	want := []string{"server", "shutdown", "rate limit"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("problems = %v, want %v", got, want)
+	}
+}
+
+func TestValidatorMerge(t *testing.T) {
+	v := &validator{}
+	v.check("tls", errors.New("bad certificate"))
+	v.merge("config", &ValidationError{Problems: []Problem{{Subsystem: "sts", Err: errors.New("STS_DOMAIN is required")}}})
+	v.merge("config", errors.New("secrets: access denied"))
+
+	want := "invalid configuration (3 problems):\n" +
+		"  tls: bad certificate\n" +
+		"  sts: STS_DOMAIN is required\n" +
+		"  config: secrets: access denied"
+	if got := v.err().Error(); got != want {
+		t.Errorf("report =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSplitProblems(t *testing.T) {
+	v := &validator{}
+	v.check("sts", errors.New("STS_DOMAIN is required"))
+	v.check("webhook", errors.New("GITHUB_WEBHOOK_SECRET is required"))
+
+	stsErr, webhookErr, err := splitProblems(v, ServiceAll)
+	if err != nil || stsErr == nil || webhookErr == nil {
+		t.Errorf("splitProblems(all) = %v, %v, %v, want an error per component", stsErr, webhookErr, err)
+	}
+	if !strings.Contains(stsErr.Error(), "STS_DOMAIN") || strings.Contains(stsErr.Error(), "WEBHOOK") {
+		t.Errorf("STS error = %q, want only the STS problem", stsErr)
+	}
+
+	if stsErr, webhookErr, err := splitProblems(v, ServiceSTS); err == nil || stsErr != nil || webhookErr != nil {
+		t.Errorf("splitProblems(sts) = %v, %v, %v, want a single error", stsErr, webhookErr, err)
+	}
+
+	v.check("github app", errors.New("GITHUB_APP_ID is required"))
+	if _, _, err := splitProblems(v, ServiceAll); err == nil {
+		t.Error("splitProblems(all) with a shared problem succeeded")
+	}
+}
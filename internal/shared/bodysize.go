@@ -0,0 +1,75 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Environment variables for the maximum request body sizes, in bytes or
+// with a KiB or MiB suffix, e.g. "64KiB".
+const (
+	EnvSTSMaxBodySize     = "STS_MAX_BODY_SIZE"
+	EnvWebhookMaxBodySize = "WEBHOOK_MAX_BODY_SIZE"
+)
+
+// ErrBodyTooLarge is returned by ReadBody for a body over its limit.
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// ReadBody reads the body of r, up to limit bytes. It returns
+// ErrBodyTooLarge without reading further once the limit is exceeded, and
+// closes the connection afterwards so the rest of the body is not read.
+func ReadBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return nil, ErrBodyTooLarge
+	}
+	return body, err
+}
+
+// MaxBodySizeFromEnv returns the body size limit set by key, or 0 if it is
+// unset, in which case the handler applies its default.
+func MaxBodySizeFromEnv(key string) (int64, error) {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return 0, nil
+	}
+	size, err := parseByteSize(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return size, nil
+}
+
+// parseByteSize parses a positive size in bytes, with an optional KiB or MiB
+// suffix (K, KB, M, and MB are accepted as binary units too).
+func parseByteSize(v string) (int64, error) {
+	number, unit := v, int64(1)
+	upper := strings.ToUpper(v)
+	for _, suffix := range []struct {
+		suffix string
+		unit   int64
+	}{
+		{"KIB", 1 << 10}, {"KB", 1 << 10}, {"K", 1 << 10},
+		{"MIB", 1 << 20}, {"MB", 1 << 20}, {"M", 1 << 20},
+	} {
+		if strings.HasSuffix(upper, suffix.suffix) {
+			number, unit = strings.TrimSpace(v[:len(v)-len(suffix.suffix)]), suffix.unit
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n <= 0 || n > (1<<40)/unit {
+		return 0, fmt.Errorf("%q is not a valid size", v)
+	}
+	return n * unit, nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr error
+	}{
+		{name: "under limit", body: "1234"},
+		{name: "at limit", body: "12345678"},
+		{name: "over limit", body: "123456789", wantErr: ErrBodyTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			body, err := ReadBody(httptest.NewRecorder(), req, 8)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadBody() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && string(body) != tt.body {
+				t.Errorf("ReadBody() = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestMaxBodySizeFromEnv(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "", want: 0},
+		{value: "1024", want: 1024},
+		{value: "64KiB", want: 64 << 10},
+		{value: "64k", want: 64 << 10},
+		{value: "25 MiB", want: 25 << 20},
+		{value: "2MB", want: 2 << 20},
+		{value: "0", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "lots", wantErr: true},
+		{value: "1GiB", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Setenv(EnvSTSMaxBodySize, tt.value)
+		got, err := MaxBodySizeFromEnv(EnvSTSMaxBodySize)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("MaxBodySizeFromEnv(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("MaxBodySizeFromEnv(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
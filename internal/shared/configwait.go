@@ -0,0 +1,237 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// Environment variables for how the servers retry loading their
+// configuration at startup, e.g. while the config store or GitHub is
+// unavailable.
+const (
+	// EnvConfigWaitMaxRetries is the number of attempts before the server
+	// gives up (default: 30).
+	EnvConfigWaitMaxRetries = "CONFIG_WAIT_MAX_RETRIES"
+
+	// EnvConfigWaitRetryInterval is the delay after the first failed
+	// attempt, doubled after each further one (default: 1s).
+	EnvConfigWaitRetryInterval = "CONFIG_WAIT_RETRY_INTERVAL"
+
+	// EnvConfigWaitMaxInterval caps the delay between attempts
+	// (default: 30s).
+	EnvConfigWaitMaxInterval = "CONFIG_WAIT_MAX_INTERVAL"
+
+	// EnvConfigWaitTimeout bounds the whole wait, whatever attempts are
+	// left (default: none).
+	EnvConfigWaitTimeout = "CONFIG_WAIT_TIMEOUT"
+)
+
+// Defaults of the CONFIG_WAIT_* variables.
+const (
+	DefaultConfigWaitMaxRetries    = 30
+	DefaultConfigWaitRetryInterval = time.Second
+	DefaultConfigWaitMaxInterval   = 30 * time.Second
+)
+
+// ConfigWait retries loading the configuration with exponential backoff.
+// Each delay is jittered between half and all of its backoff, so instances
+// started together after an outage spread their retries instead of
+// hitting the config store and GitHub in lockstep.
+type ConfigWait struct {
+	// MaxRetries is the number of attempts, including the first.
+	MaxRetries int
+
+	// RetryInterval is the delay after the first failed attempt.
+	RetryInterval time.Duration
+
+	// MaxInterval caps the delay between attempts.
+	MaxInterval time.Duration
+
+	// Timeout, if set, bounds the whole wait.
+	Timeout time.Duration
+
+	// Wake, if set, cuts a delay short, e.g. when the installer saved the
+	// credentials.
+	Wake <-chan struct{}
+
+	// Progress, if set, is called after each failed attempt that is
+	// retried, e.g. WaitProgress.Report to show it on /readyz.
+	Progress func(ConfigWaitProgress)
+
+	// jitter returns a random duration in [0, n); nil uses math/rand.
+	jitter func(n int64) int64
+}
+
+// ConfigWaitFromEnv returns the retry policy from the CONFIG_WAIT_*
+// environment variables.
+func ConfigWaitFromEnv() (ConfigWait, error) {
+	cfg := ConfigWait{
+		MaxRetries:    DefaultConfigWaitMaxRetries,
+		RetryInterval: DefaultConfigWaitRetryInterval,
+		MaxInterval:   DefaultConfigWaitMaxInterval,
+	}
+
+	if v := os.Getenv(EnvConfigWaitMaxRetries); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return ConfigWait{}, fmt.Errorf("invalid %s %q: must be a positive integer", EnvConfigWaitMaxRetries, v)
+		}
+		cfg.MaxRetries = n
+	}
+	for key, d := range map[string]*time.Duration{
+		EnvConfigWaitRetryInterval: &cfg.RetryInterval,
+		EnvConfigWaitMaxInterval:   &cfg.MaxInterval,
+	} {
+		v := os.Getenv(key)
+		if v == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(v)
+		if err != nil || parsed <= 0 {
+			return ConfigWait{}, fmt.Errorf("invalid %s %q: must be a positive duration such as 2s", key, v)
+		}
+		*d = parsed
+	}
+	if v := os.Getenv(EnvConfigWaitTimeout); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return ConfigWait{}, fmt.Errorf("invalid %s %q: must be a duration such as 5m", EnvConfigWaitTimeout, v)
+		}
+		cfg.Timeout = d
+	}
+	if cfg.MaxInterval < cfg.RetryInterval {
+		return ConfigWait{}, fmt.Errorf("%s (%s) is shorter than %s (%s)",
+			EnvConfigWaitMaxInterval, cfg.MaxInterval, EnvConfigWaitRetryInterval, cfg.RetryInterval)
+	}
+	return cfg, nil
+}
+
+// Delay returns how long to wait after the given failed attempt, counted
+// from 1.
+func (c ConfigWait) Delay(attempt int) time.Duration {
+	backoff := c.RetryInterval
+	for i := 1; i < attempt && backoff < c.MaxInterval; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, c.MaxInterval)
+
+	half := int64(backoff / 2)
+	if half <= 0 {
+		return backoff
+	}
+	jitter := c.jitter
+	if jitter == nil {
+		jitter = rand.Int64N
+	}
+	return time.Duration(half + jitter(half+1))
+}
+
+// Wait calls load until it succeeds, MaxRetries attempts fail, Timeout
+// passes, or ctx is done, and returns the last error.
+func (c ConfigWait) Wait(ctx context.Context, load func(context.Context) error) error {
+	log := clog.FromContext(ctx)
+
+	parent := ctx
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := load(ctx)
+		if err == nil {
+			if attempt > 1 {
+				log.Infof("[configwait] configuration loaded after %d attempts", attempt)
+			}
+			return nil
+		}
+		if attempt >= c.MaxRetries {
+			return err
+		}
+		if ctx.Err() != nil && parent.Err() == nil {
+			return c.timedOut(err)
+		}
+
+		delay := c.Delay(attempt)
+		log.Warnf("[configwait] attempt %d/%d failed, retrying in %s: %v", attempt, c.MaxRetries, delay.Round(time.Millisecond), err)
+		if c.Progress != nil {
+			c.Progress(ConfigWaitProgress{Attempt: attempt, MaxRetries: c.MaxRetries, Delay: delay, Err: err})
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if parent.Err() != nil {
+				return parent.Err()
+			}
+			return c.timedOut(err)
+		case <-timer.C:
+		case <-c.Wake:
+			timer.Stop()
+		}
+	}
+}
+
+// timedOut returns the error of a wait that ran out of Timeout after the
+// attempt that failed with err.
+func (c ConfigWait) timedOut(err error) error {
+	return fmt.Errorf("configuration not loaded within %s: %w", c.Timeout, err)
+}
+
+// LoadFunc wraps load for a ghappsetup.Runtime configured with a single
+// attempt: the first call, which loads the configuration at startup, is
+// retried with Wait, and later reloads call load once, so a failed reload
+// keeps the previous configuration without delay.
+func (c ConfigWait) LoadFunc(load func(context.Context) error) func(context.Context) error {
+	var started atomic.Bool
+	return func(ctx context.Context) error {
+		if started.CompareAndSwap(false, true) {
+			return c.Wait(ctx, load)
+		}
+		return load(ctx)
+	}
+}
+
+// ConfigWaitProgress describes a failed attempt of ConfigWait.Wait that is
+// retried.
+type ConfigWaitProgress struct {
+	// Attempt is the failed attempt, counted from 1, of MaxRetries.
+	Attempt    int
+	MaxRetries int
+
+	// Delay is the wait before the next attempt.
+	Delay time.Duration
+
+	// Err is the error of the attempt.
+	Err error
+}
+
+// WaitProgress keeps the last progress of a ConfigWait, for /readyz.
+type WaitProgress struct {
+	last atomic.Pointer[ConfigWaitProgress]
+}
+
+// Report records p, for ConfigWait.Progress.
+func (w *WaitProgress) Report(p ConfigWaitProgress) {
+	w.last.Store(&p)
+}
+
+// Last returns the last progress reported, or nil if the first attempt is
+// still running or none failed. A nil WaitProgress reports nil.
+func (w *WaitProgress) Last() *ConfigWaitProgress {
+	if w == nil {
+		return nil
+	}
+	return w.last.Load()
+}
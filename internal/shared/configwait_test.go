@@ -0,0 +1,178 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigWaitDelay(t *testing.T) {
+	cfg := ConfigWait{RetryInterval: time.Second, MaxInterval: 10 * time.Second}
+
+	// Without jitter each delay is half its backoff, with the most jitter
+	// the whole backoff
+	for _, tt := range []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{attempt: 1, min: 500 * time.Millisecond, max: time.Second},
+		{attempt: 2, min: time.Second, max: 2 * time.Second},
+		{attempt: 4, min: 4 * time.Second, max: 8 * time.Second},
+		{attempt: 5, min: 5 * time.Second, max: 10 * time.Second},
+		{attempt: 100, min: 5 * time.Second, max: 10 * time.Second},
+	} {
+		cfg.jitter = func(int64) int64 { return 0 }
+		if got := cfg.Delay(tt.attempt); got != tt.min {
+			t.Errorf("Delay(%d) without jitter = %s, want %s", tt.attempt, got, tt.min)
+		}
+		cfg.jitter = func(n int64) int64 { return n - 1 }
+		if got := cfg.Delay(tt.attempt); got != tt.max {
+			t.Errorf("Delay(%d) with full jitter = %s, want %s", tt.attempt, got, tt.max)
+		}
+	}
+}
+
+func TestConfigWaitWait(t *testing.T) {
+	cfg := ConfigWait{MaxRetries: 3, RetryInterval: time.Millisecond, MaxInterval: time.Millisecond}
+	errLoad := errors.New("store unavailable")
+
+	calls := 0
+	err := cfg.Wait(context.Background(), func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errLoad
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Wait() = %v after %d calls, want nil after 3", err, calls)
+	}
+
+	calls = 0
+	err = cfg.Wait(context.Background(), func(context.Context) error {
+		calls++
+		return errLoad
+	})
+	if !errors.Is(err, errLoad) || calls != 3 {
+		t.Errorf("Wait() = %v after %d calls, want %v after 3", err, calls, errLoad)
+	}
+
+	// A wake-up retries at once
+	wake := make(chan struct{}, 1)
+	wake <- struct{}{}
+	calls = 0
+	err = ConfigWait{MaxRetries: 2, RetryInterval: time.Hour, MaxInterval: time.Hour, Wake: wake}.
+		Wait(context.Background(), func(context.Context) error {
+			calls++
+			if calls < 2 {
+				return errLoad
+			}
+			return nil
+		})
+	if err != nil || calls != 2 {
+		t.Errorf("Wait() with wake-up = %v after %d calls, want nil after 2", err, calls)
+	}
+
+	// Progress is reported for each retried attempt
+	var progress []ConfigWaitProgress
+	cfg.Progress = func(p ConfigWaitProgress) { progress = append(progress, p) }
+	_ = cfg.Wait(context.Background(), func(context.Context) error { return errLoad })
+	if len(progress) != 2 || progress[1].Attempt != 2 || progress[1].MaxRetries != 3 || !errors.Is(progress[1].Err, errLoad) {
+		t.Errorf("progress = %+v, want attempts 1 and 2 of 3", progress)
+	}
+	cfg.Progress = nil
+
+	// The timeout bounds the wait whatever attempts are left
+	start := time.Now()
+	err = ConfigWait{MaxRetries: 1000, RetryInterval: 10 * time.Millisecond, MaxInterval: 10 * time.Millisecond, Timeout: 50 * time.Millisecond}.
+		Wait(context.Background(), func(context.Context) error { return errLoad })
+	if !errors.Is(err, errLoad) || !strings.Contains(err.Error(), "not loaded within 50ms") {
+		t.Errorf("Wait() with timeout = %v, want %v within 50ms", err, errLoad)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Wait() with timeout took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cfg.RetryInterval, cfg.MaxInterval = time.Hour, time.Hour
+	err = cfg.Wait(ctx, func(context.Context) error { return errLoad })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() with canceled context = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestConfigWaitLoadFunc(t *testing.T) {
+	cfg := ConfigWait{MaxRetries: 5, RetryInterval: time.Millisecond, MaxInterval: time.Millisecond}
+	errLoad := errors.New("store unavailable")
+
+	calls, fail := 0, 2
+	load := cfg.LoadFunc(func(context.Context) error {
+		calls++
+		if calls <= fail {
+			return errLoad
+		}
+		return nil
+	})
+
+	// The first load is retried until it succeeds
+	if err := load(context.Background()); err != nil || calls != 3 {
+		t.Fatalf("first load = %v after %d calls, want nil after 3", err, calls)
+	}
+
+	// A reload is tried once
+	calls, fail = 0, 1
+	if err := load(context.Background()); !errors.Is(err, errLoad) || calls != 1 {
+		t.Errorf("reload = %v after %d calls, want %v after 1", err, calls, errLoad)
+	}
+}
+
+func TestConfigWaitFromEnv(t *testing.T) {
+	tests := []struct {
+		name                        string
+		retries, interval, maxDelay string
+		timeout                     string
+		want                        ConfigWait
+		wantErr                     bool
+	}{
+		{
+			name: "defaults",
+			want: ConfigWait{MaxRetries: 30, RetryInterval: time.Second, MaxInterval: 30 * time.Second},
+		},
+		{
+			name:    "custom",
+			retries: "10", interval: "500ms", maxDelay: "1m",
+			want: ConfigWait{MaxRetries: 10, RetryInterval: 500 * time.Millisecond, MaxInterval: time.Minute},
+		},
+		{name: "zero retries", retries: "0", wantErr: true},
+		{name: "bad interval", interval: "soon", wantErr: true},
+		{name: "cap below interval", interval: "1m", maxDelay: "10s", wantErr: true},
+		{
+			name:    "timeout",
+			timeout: "5m",
+			want:    ConfigWait{MaxRetries: 30, RetryInterval: time.Second, MaxInterval: 30 * time.Second, Timeout: 5 * time.Minute},
+		},
+		{name: "bad timeout", timeout: "-1s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvConfigWaitMaxRetries, tt.retries)
+			t.Setenv(EnvConfigWaitRetryInterval, tt.interval)
+			t.Setenv(EnvConfigWaitMaxInterval, tt.maxDelay)
+			t.Setenv(EnvConfigWaitTimeout, tt.timeout)
+			got, err := ConfigWaitFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConfigWaitFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ConfigWaitFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
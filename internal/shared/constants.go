@@ -25,3 +25,17 @@ const (
 	// DefaultCacheTTL is the default TTL for cached items (5 minutes).
 	DefaultCacheTTL = 5 * time.Minute
 )
+
+// Health check defaults.
+const (
+	// DefaultStorePingTimeout bounds the store check of a deep health check.
+	DefaultStorePingTimeout = 5 * time.Second
+
+	// DefaultGitHubCheckTimeout bounds the GitHub check of a deep health
+	// check.
+	DefaultGitHubCheckTimeout = 5 * time.Second
+
+	// DefaultGitHubCheckInterval is how long the result of a GitHub check
+	// is reused.
+	DefaultGitHubCheckInterval = 30 * time.Second
+)
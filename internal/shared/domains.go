@@ -0,0 +1,16 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import "os"
+
+// EnvSTSAdditionalDomains is a comma-separated list of domains the STS
+// accepts as the token audience besides STS_DOMAIN, when the trust policy
+// specifies none, e.g. the previous domain during a DNS migration.
+const EnvSTSAdditionalDomains = "STS_ADDITIONAL_DOMAINS"
+
+// AdditionalDomainsFromEnv returns the domains in STS_ADDITIONAL_DOMAINS.
+func AdditionalDomainsFromEnv() []string {
+	return splitHosts(os.Getenv(EnvSTSAdditionalDomains))
+}
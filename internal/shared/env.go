@@ -5,8 +5,15 @@ package shared
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
+
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
 )
 
 // GetEnvDefault returns the value of an environment variable,
@@ -81,3 +88,62 @@ func SetupEnvMapping() {
 		os.Setenv("APP_SECRET_CERTIFICATE_ENV_VAR", pk)
 	}
 }
+
+// storeDerived holds the variables ApplyStoreCredentials set from the stored
+// credentials because they were unset, rather than set by the operator, so
+// they follow the store on every load.
+var storeDerived = struct {
+	sync.Mutex
+	keys map[string]bool
+}{keys: map[string]bool{}}
+
+// ApplyStoreCredentials reads credentials directly from the store and copies
+// them, the private key included, into the process environment, since the
+// upstream octo-sts config loaders only read the environment. Stored values
+// take precedence over the environment since the store holds whatever the
+// installer saved last. It is a no-op if the store has no credentials yet.
+func ApplyStoreCredentials(ctx context.Context, store configstore.Store) error {
+	if store == nil {
+		return nil
+	}
+
+	creds, err := store.Load(ctx)
+	if errors.Is(err, configstore.ErrNotRegistered) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load credentials from store: %w", err)
+	}
+
+	appID := strconv.FormatInt(creds.AppID, 10)
+	values := map[string]string{
+		configstore.EnvGitHubAppID:         appID,
+		configstore.EnvGitHubWebhookSecret: creds.WebhookSecret,
+		configstore.EnvGitHubClientID:      creds.ClientID,
+		configstore.EnvGitHubClientSecret:  creds.ClientSecret,
+		configstore.EnvGitHubAppPrivateKey: creds.PrivateKey,
+	}
+	for key, value := range creds.CustomFields {
+		values[key] = value
+	}
+
+	// The upstream loader reads the app ID list and key material from its own
+	// variables. A list derived from an earlier load is replaced, so it
+	// matches the key of the stored app once that changes.
+	storeDerived.Lock()
+	defer storeDerived.Unlock()
+	if os.Getenv("GITHUB_APP_IDS") == "" || storeDerived.keys["GITHUB_APP_IDS"] {
+		values["GITHUB_APP_IDS"] = appID
+		storeDerived.keys["GITHUB_APP_IDS"] = true
+	}
+	if os.Getenv("APP_SECRET_CERTIFICATE_FILE") == "" && os.Getenv("KMS_KEYS") == "" {
+		values["APP_SECRET_CERTIFICATE_ENV_VAR"] = creds.PrivateKey
+	}
+
+	for key, value := range values {
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+)
+
+func TestApplyStoreCredentialsReload(t *testing.T) {
+	for _, env := range []string{
+		configstore.EnvGitHubAppID, configstore.EnvGitHubAppPrivateKey,
+		configstore.EnvGitHubWebhookSecret, configstore.EnvGitHubClientID, configstore.EnvGitHubClientSecret,
+		"GITHUB_APP_IDS", "APP_SECRET_CERTIFICATE_ENV_VAR", "APP_SECRET_CERTIFICATE_FILE", "KMS_KEYS",
+	} {
+		t.Setenv(env, "")
+	}
+	resetStoreDerived(t)
+	ctx := context.Background()
+	store := configstore.NewLocalFileStore(t.TempDir())
+	save := func(appID int64, key string) {
+		t.Helper()
+		err := store.Save(ctx, &configstore.AppCredentials{
+			AppID:         appID,
+			ClientID:      "Iv1.abc",
+			ClientSecret:  "client-secret",
+			WebhookSecret: "webhook-secret",
+			PrivateKey:    key,
+		})
+		if err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	save(1, "first-key")
+	if err := ApplyStoreCredentials(ctx, store); err != nil {
+		t.Fatalf("ApplyStoreCredentials() error = %v", err)
+	}
+	if got := os.Getenv("GITHUB_APP_IDS"); got != "1" {
+		t.Errorf("GITHUB_APP_IDS = %q, want 1", got)
+	}
+
+	// The installer registered another app: the ID follows the key
+	save(2, "second-key")
+	if err := ApplyStoreCredentials(ctx, store); err != nil {
+		t.Fatalf("ApplyStoreCredentials() error = %v", err)
+	}
+	if got := os.Getenv("GITHUB_APP_IDS"); got != "2" {
+		t.Errorf("GITHUB_APP_IDS after the app changed = %q, want 2", got)
+	}
+	if got := os.Getenv("APP_SECRET_CERTIFICATE_ENV_VAR"); got != "second-key" {
+		t.Errorf("APP_SECRET_CERTIFICATE_ENV_VAR after the app changed = %q, want second-key", got)
+	}
+}
+
+func TestApplyStoreCredentialsKeepsOperatorAppIDs(t *testing.T) {
+	t.Setenv("GITHUB_APP_IDS", "7,8")
+	t.Setenv("APP_SECRET_CERTIFICATE_ENV_VAR", "")
+	t.Setenv(configstore.EnvGitHubAppID, "")
+	t.Setenv(configstore.EnvGitHubAppPrivateKey, "")
+	t.Setenv(configstore.EnvGitHubWebhookSecret, "")
+	t.Setenv(configstore.EnvGitHubClientID, "")
+	t.Setenv(configstore.EnvGitHubClientSecret, "")
+	resetStoreDerived(t)
+
+	ctx := context.Background()
+	store := configstore.NewLocalFileStore(t.TempDir())
+	err := store.Save(ctx, &configstore.AppCredentials{AppID: 1, ClientID: "Iv1.abc", ClientSecret: "s", WebhookSecret: "w", PrivateKey: "key"})
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := ApplyStoreCredentials(ctx, store); err != nil {
+		t.Fatalf("ApplyStoreCredentials() error = %v", err)
+	}
+	if got := os.Getenv("GITHUB_APP_IDS"); got != "7,8" {
+		t.Errorf("GITHUB_APP_IDS = %q, want the operator's 7,8", got)
+	}
+}
+
+// resetStoreDerived forgets the variables derived by earlier tests, and
+// again once t is done.
+func resetStoreDerived(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		storeDerived.Lock()
+		storeDerived.keys = map[string]bool{}
+		storeDerived.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
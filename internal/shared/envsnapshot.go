@@ -0,0 +1,72 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvSnapshot is a copy of the process environment, taken before a load
+// changes it by resolving secret references, mapping variables, and
+// applying stored credentials.
+type EnvSnapshot map[string]string
+
+// SnapshotEnv copies the process environment.
+func SnapshotEnv() EnvSnapshot {
+	env := os.Environ()
+	snap := make(EnvSnapshot, len(env))
+	for _, kv := range env {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			snap[k] = v
+		}
+	}
+	return snap
+}
+
+// Restore returns the process environment to the snapshot, unsetting the
+// variables set since and resetting the ones changed.
+func (s EnvSnapshot) Restore() error {
+	for _, kv := range os.Environ() {
+		k, _, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		if _, kept := s[k]; !kept {
+			if err := os.Unsetenv(k); err != nil {
+				return fmt.Errorf("failed to unset %s: %w", k, err)
+			}
+		}
+	}
+	for k, v := range s {
+		if cur, ok := os.LookupEnv(k); ok && cur == v {
+			continue
+		}
+		if err := os.Setenv(k, v); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", k, err)
+		}
+	}
+	return nil
+}
+
+// WithEnvRollback wraps the load of a configuration, which builds the new
+// transport and handlers from the environment and swaps them in only once
+// all are built. If load fails, the environment is restored as well, so a
+// failed reload leaves no half-applied settings behind for the next reload
+// or the reload hooks that read them.
+func WithEnvRollback(load func(context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		snap := SnapshotEnv()
+		err := load(ctx)
+		if err == nil {
+			return nil
+		}
+		if rerr := snap.Restore(); rerr != nil {
+			return fmt.Errorf("%w (and failed to restore the environment: %v)", err, rerr)
+		}
+		return err
+	}
+}
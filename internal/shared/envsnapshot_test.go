@@ -0,0 +1,48 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestWithEnvRollback(t *testing.T) {
+	t.Setenv("OCTO_STS_TEST_KEPT", "old")
+	t.Setenv("OCTO_STS_TEST_ADDED", "")
+	os.Unsetenv("OCTO_STS_TEST_ADDED")
+
+	errLoad := errors.New("invalid app config")
+	load := func(err error) func(context.Context) error {
+		return WithEnvRollback(func(context.Context) error {
+			os.Setenv("OCTO_STS_TEST_KEPT", "new")
+			os.Setenv("OCTO_STS_TEST_ADDED", "new")
+			return err
+		})
+	}
+
+	// A failed load leaves the environment as it was
+	if err := load(errLoad)(context.Background()); !errors.Is(err, errLoad) {
+		t.Fatalf("load = %v, want %v", err, errLoad)
+	}
+	if got := os.Getenv("OCTO_STS_TEST_KEPT"); got != "old" {
+		t.Errorf("changed variable after a failed load = %q, want old", got)
+	}
+	if _, ok := os.LookupEnv("OCTO_STS_TEST_ADDED"); ok {
+		t.Error("added variable is still set after a failed load")
+	}
+
+	// A successful load keeps its changes
+	if err := load(nil)(context.Background()); err != nil {
+		t.Fatalf("load = %v", err)
+	}
+	if got := os.Getenv("OCTO_STS_TEST_KEPT"); got != "new" {
+		t.Errorf("changed variable after a load = %q, want new", got)
+	}
+	if got := os.Getenv("OCTO_STS_TEST_ADDED"); got != "new" {
+		t.Errorf("added variable after a load = %q, want new", got)
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	"github.com/chainguard-dev/clog"
+
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+)
+
+// DeepHealthQueryParam is the query parameter that requests a deep health
+// check, e.g. /healthz?deep=1.
+const DeepHealthQueryParam = "deep"
+
+// IsDeepHealthCheck reports whether a deep query parameter value asks for a
+// deep health check.
+func IsDeepHealthCheck(value string) bool {
+	deep, _ := strconv.ParseBool(value)
+	return deep
+}
+
+// PingStore checks that store is reachable, bounded by DefaultStorePingTimeout.
+// A nil store is reported as healthy.
+func PingStore(ctx context.Context, store configstore.Store) error {
+	if store == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, DefaultStorePingTimeout)
+	defer cancel()
+	return store.Ping(ctx)
+}
+
+// Health statuses reported by HealthHandler, overall and per check.
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+	HealthFailed   = "failed"
+	HealthSkipped  = "skipped"
+)
+
+// HealthChecks are the dependencies reported by HealthHandler.
+type HealthChecks struct {
+	// Ready reports whether the configuration is loaded; nil skips the check.
+	Ready func() bool
+
+	// Store is pinged on deep checks; nil skips the check.
+	Store configstore.Store
+
+	// GitHub checks that the GitHub App can authenticate on deep checks,
+	// e.g. GitHubAuth.Check; nil skips the check.
+	GitHub func(context.Context) error
+}
+
+// healthResponse is the body of HealthHandler.
+type healthResponse struct {
+	Status string        `json:"status"`
+	Checks []healthCheck `json:"checks"`
+}
+
+// healthCheck is the state of one dependency. Errors are only logged, since
+// /healthz is public.
+type healthCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// HealthHandler reports the state of each dependency as JSON. The store and
+// GitHub are only checked with ?deep=1. The status is "failed" until the
+// configuration is loaded and "degraded" if a deep check fails, both with
+// 503, and "ok" otherwise.
+func HealthHandler(checks HealthChecks) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := clog.FromContext(ctx)
+		deep := IsDeepHealthCheck(r.URL.Query().Get(DeepHealthQueryParam))
+
+		resp := healthResponse{Status: HealthOK}
+		check := func(name string, skip bool, run func() error) {
+			c := healthCheck{Name: name, Status: HealthOK}
+			if skip {
+				c.Status = HealthSkipped
+			} else if err := run(); err != nil {
+				log.Errorf("[health] %s check failed: %v", name, err)
+				c.Status = HealthFailed
+			}
+			resp.Checks = append(resp.Checks, c)
+		}
+
+		loaded := true
+		check("config", checks.Ready == nil, func() error {
+			if loaded = checks.Ready(); !loaded {
+				return errors.New("configuration not loaded")
+			}
+			return nil
+		})
+		check("store", !deep || checks.Store == nil, func() error {
+			return PingStore(ctx, checks.Store)
+		})
+		check("github", !deep || !loaded || checks.GitHub == nil, func() error {
+			return checks.GitHub(ctx)
+		})
+
+		for _, c := range resp.Checks {
+			if c.Status == HealthFailed {
+				resp.Status = HealthDegraded
+			}
+		}
+		if !loaded {
+			resp.Status = HealthFailed
+		}
+
+		code := http.StatusOK
+		if resp.Status != HealthOK {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Errorf("[health] failed to write health: %v", err)
+		}
+	}
+}
+
+// GitHubAuth checks that the GitHub App of the current configuration can
+// authenticate. Results are cached for DefaultGitHubCheckInterval, so
+// frequent deep health checks don't spend the App's rate limit.
+type GitHubAuth struct {
+	atr atomic.Pointer[ghinstallation.AppsTransport]
+
+	mu      sync.Mutex
+	checked time.Time
+	err     error
+}
+
+// Set replaces the transport to check, e.g. after a reload.
+func (g *GitHubAuth) Set(atr *ghinstallation.AppsTransport) {
+	g.atr.Store(atr)
+	g.mu.Lock()
+	g.checked = time.Time{}
+	g.mu.Unlock()
+}
+
+// Check authenticates as the App, bounded by DefaultGitHubCheckTimeout.
+func (g *GitHubAuth) Check(ctx context.Context) error {
+	atr := g.atr.Load()
+	if atr == nil {
+		return errors.New("no GitHub App transport")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.checked.IsZero() && time.Since(g.checked) < DefaultGitHubCheckInterval {
+		return g.err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultGitHubCheckTimeout)
+	defer cancel()
+	g.err = getApp(ctx, atr)
+	g.checked = time.Now()
+	return g.err
+}
+
+// getApp requests the App authenticated by atr.
+func getApp(ctx context.Context, atr *ghinstallation.AppsTransport) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(atr.BaseURL, "/")+"/app", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := (&http.Client{Transport: atr}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GitHub returned %s", resp.Status)
+	}
+	return nil
+}
+
+// ReadyPath is where ReadyHandler is conventionally mounted.
+const ReadyPath = "/readyz"
+
+// readyResponse is the body of ReadyHandler.
+type readyResponse struct {
+	Status     string             `json:"status"`
+	Components []componentSummary `json:"components,omitempty"`
+	Waiting    *waitSummary       `json:"waiting,omitempty"`
+	LastReload *reloadSummary     `json:"last_reload,omitempty"`
+}
+
+// Component is a part of a service that becomes ready on its own, e.g. the
+// STS of a service that also serves the webhook.
+type Component struct {
+	Name  string
+	Ready func() bool
+
+	// Failed, if set, reports whether the last load of the component
+	// failed, while the rest of the service loaded. Its error is only
+	// logged.
+	Failed func() bool
+}
+
+// componentSummary is the readiness of a Component reported by
+// ReadyHandler.
+type componentSummary struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Failed bool   `json:"load_failed,omitempty"`
+}
+
+// waitSummary is the ConfigWaitProgress reported by ReadyHandler, without
+// the error.
+type waitSummary struct {
+	Message     string `json:"message"`
+	Attempt     int    `json:"attempt"`
+	MaxAttempts int    `json:"max_attempts"`
+	RetryInMS   int64  `json:"retry_in_ms"`
+}
+
+// reloadSummary is the ReloadStatus reported by ReadyHandler. Errors are
+// only logged, since /readyz is public.
+type reloadSummary struct {
+	Time        time.Time `json:"time"`
+	DurationMS  int64     `json:"duration_ms"`
+	Status      string    `json:"status"`
+	FailedHooks []string  `json:"failed_hooks,omitempty"`
+	Reloads     int       `json:"reloads"`
+}
+
+// ReadyHandler reports readiness as JSON, with the status of the last
+// reload by reloader, so operators can tell whether a SIGHUP or the
+// installer's reload took effect. It returns 503 until ready reports true,
+// with the progress of the wait for the configuration if there is one.
+// With components, each is reported, and the service is "partially ready"
+// while ready but some are not, or failed their last load.
+func ReadyHandler(ready func() bool, reloader *Reloader, wait *WaitProgress, components ...Component) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := readyResponse{Status: "ready"}
+		code := http.StatusOK
+		for _, c := range components {
+			summary := componentSummary{Name: c.Name, Status: "ready"}
+			if !c.Ready() {
+				summary.Status = "not ready"
+			}
+			summary.Failed = c.Failed != nil && c.Failed()
+			if summary.Status != "ready" || summary.Failed {
+				resp.Status = "partially ready"
+			}
+			resp.Components = append(resp.Components, summary)
+		}
+		if !ready() {
+			resp.Status = "not ready"
+			code = http.StatusServiceUnavailable
+			if p := wait.Last(); p != nil {
+				resp.Waiting = &waitSummary{
+					Message:     fmt.Sprintf("waiting for configuration (attempt %d/%d)", p.Attempt, p.MaxRetries),
+					Attempt:     p.Attempt,
+					MaxAttempts: p.MaxRetries,
+					RetryInMS:   p.Delay.Milliseconds(),
+				}
+			}
+		}
+		if last := reloader.LastReload(); last != nil {
+			resp.LastReload = &reloadSummary{
+				Time:        last.Time.UTC(),
+				DurationMS:  last.Duration.Milliseconds(),
+				Status:      "ok",
+				FailedHooks: last.FailedHooks,
+				Reloads:     last.Reloads,
+			}
+			if !last.OK {
+				resp.LastReload.Status = "failed"
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			clog.FromContext(r.Context()).Errorf("[health] failed to write readiness: %v", err)
+		}
+	}
+}
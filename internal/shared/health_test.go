@@ -0,0 +1,251 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	jwt "github.com/golang-jwt/jwt/v4"
+
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+)
+
+// pingStore is a Store whose Ping returns err.
+type pingStore struct {
+	configstore.Store
+	err error
+}
+
+func (s pingStore) Ping(context.Context) error { return s.err }
+
+func TestHealthHandler(t *testing.T) {
+	errDown := errors.New("unavailable")
+	ready := false
+	store := &pingStore{}
+	var githubErr error
+	handler := HealthHandler(HealthChecks{
+		Ready:  func() bool { return ready },
+		Store:  store,
+		GitHub: func(context.Context) error { return githubErr },
+	})
+
+	get := func(path string) (int, healthResponse) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var resp healthResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid body %q: %v", rec.Body.String(), err)
+		}
+		return rec.Code, resp
+	}
+	statuses := func(resp healthResponse) []string {
+		var s []string
+		for _, c := range resp.Checks {
+			s = append(s, c.Name+"="+c.Status)
+		}
+		return s
+	}
+
+	tests := []struct {
+		name       string
+		ready      bool
+		storeErr   error
+		githubErr  error
+		path       string
+		wantCode   int
+		wantStatus string
+		wantChecks []string
+	}{
+		{
+			name: "not loaded", path: "/healthz?deep=1",
+			wantCode: http.StatusServiceUnavailable, wantStatus: HealthFailed,
+			wantChecks: []string{"config=failed", "store=ok", "github=skipped"},
+		},
+		{
+			name: "shallow", ready: true, storeErr: errDown, path: "/healthz",
+			wantCode: http.StatusOK, wantStatus: HealthOK,
+			wantChecks: []string{"config=ok", "store=skipped", "github=skipped"},
+		},
+		{
+			name: "deep", ready: true, path: "/healthz?deep=1",
+			wantCode: http.StatusOK, wantStatus: HealthOK,
+			wantChecks: []string{"config=ok", "store=ok", "github=ok"},
+		},
+		{
+			name: "store down", ready: true, storeErr: errDown, path: "/healthz?deep=1",
+			wantCode: http.StatusServiceUnavailable, wantStatus: HealthDegraded,
+			wantChecks: []string{"config=ok", "store=failed", "github=ok"},
+		},
+		{
+			name: "github down", ready: true, githubErr: errDown, path: "/healthz?deep=1",
+			wantCode: http.StatusServiceUnavailable, wantStatus: HealthDegraded,
+			wantChecks: []string{"config=ok", "store=ok", "github=failed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ready, store.err, githubErr = tt.ready, tt.storeErr, tt.githubErr
+			code, resp := get(tt.path)
+			if code != tt.wantCode || resp.Status != tt.wantStatus {
+				t.Errorf("health = %d %s, want %d %s", code, resp.Status, tt.wantCode, tt.wantStatus)
+			}
+			if got := statuses(resp); !slices.Equal(got, tt.wantChecks) {
+				t.Errorf("checks = %v, want %v", got, tt.wantChecks)
+			}
+		})
+	}
+}
+
+func TestGitHubAuth(t *testing.T) {
+	var requests atomic.Int32
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/app" {
+			t.Errorf("path = %s, want /app", r.URL.Path)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	var g GitHubAuth
+	if err := g.Check(context.Background()); err == nil {
+		t.Error("Check() without a transport succeeded")
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	atr, err := ghinstallation.NewAppsTransportWithOptions(http.DefaultTransport, 1,
+		ghinstallation.WithSigner(ghinstallation.NewRSASigner(jwt.SigningMethodRS256, key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	atr.BaseURL = srv.URL
+	g.Set(atr)
+
+	// Results are reused until the transport changes
+	for range 3 {
+		if err := g.Check(context.Background()); err != nil {
+			t.Fatalf("Check() = %v", err)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+
+	status = http.StatusUnauthorized
+	g.Set(atr)
+	if err := g.Check(context.Background()); err == nil {
+		t.Error("Check() with a rejected App succeeded")
+	}
+}
+
+func TestReadyHandler(t *testing.T) {
+	ready := false
+	fail := errors.New("store unavailable")
+	r := NewReloader(0)
+	r.Register("config", func(context.Context) error { return fail })
+	progress := &WaitProgress{}
+	handler := ReadyHandler(func() bool { return ready }, r, progress)
+
+	get := func() (int, readyResponse) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, ReadyPath, nil))
+		var resp readyResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid body %q: %v", rec.Body.String(), err)
+		}
+		return rec.Code, resp
+	}
+
+	code, resp := get()
+	if code != http.StatusServiceUnavailable || resp.Status != "not ready" || resp.LastReload != nil || resp.Waiting != nil {
+		t.Errorf("before loading = %d %+v, want 503, not ready, and no reload", code, resp)
+	}
+
+	// The wait for the configuration shows its attempts
+	progress.Report(ConfigWaitProgress{Attempt: 7, MaxRetries: 30, Delay: 2 * time.Second, Err: fail})
+	if _, resp = get(); resp.Waiting == nil || resp.Waiting.Message != "waiting for configuration (attempt 7/30)" || resp.Waiting.RetryInMS != 2000 {
+		t.Errorf("waiting = %+v, want attempt 7/30", resp.Waiting)
+	}
+
+	ready = true
+	_ = r.Reload(context.Background())
+	code, resp = get()
+	if code != http.StatusOK || resp.Status != "ready" || resp.Waiting != nil {
+		t.Errorf("when ready = %d %s, want 200 ready", code, resp.Status)
+	}
+	if resp.LastReload == nil || resp.LastReload.Status != "failed" || !slices.Equal(resp.LastReload.FailedHooks, []string{"config"}) {
+		t.Errorf("last reload = %+v, want failed config hook", resp.LastReload)
+	}
+
+	fail = nil
+	_ = r.Reload(context.Background())
+	if _, resp = get(); resp.LastReload.Status != "ok" || resp.LastReload.Reloads != 2 || resp.LastReload.FailedHooks != nil {
+		t.Errorf("last reload = %+v, want the second reload ok", resp.LastReload)
+	}
+}
+
+func TestReadyHandlerComponents(t *testing.T) {
+	var stsReady, webhookReady, stsFailed bool
+	handler := ReadyHandler(func() bool { return stsReady || webhookReady }, NewReloader(0), nil,
+		Component{Name: "sts", Ready: func() bool { return stsReady }, Failed: func() bool { return stsFailed }},
+		Component{Name: "webhook", Ready: func() bool { return webhookReady }})
+
+	get := func() (int, readyResponse) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, ReadyPath, nil))
+		var resp readyResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid body %q: %v", rec.Body.String(), err)
+		}
+		return rec.Code, resp
+	}
+
+	if code, resp := get(); code != http.StatusServiceUnavailable || resp.Status != "not ready" {
+		t.Errorf("before loading = %d %s, want 503 not ready", code, resp.Status)
+	}
+
+	webhookReady = true
+	code, resp := get()
+	want := []componentSummary{{Name: "sts", Status: "not ready"}, {Name: "webhook", Status: "ready"}}
+	if code != http.StatusOK || resp.Status != "partially ready" || !slices.Equal(resp.Components, want) {
+		t.Errorf("webhook loaded = %d %+v, want 200 partially ready", code, resp)
+	}
+
+	stsFailed = true
+	code, resp = get()
+	want = []componentSummary{{Name: "sts", Status: "not ready", Failed: true}, {Name: "webhook", Status: "ready"}}
+	if code != http.StatusOK || resp.Status != "partially ready" || !slices.Equal(resp.Components, want) {
+		t.Errorf("sts failed = %d %+v, want 200 partially ready with the sts failed", code, resp)
+	}
+
+	stsReady, stsFailed = true, false
+	if _, resp := get(); resp.Status != "ready" {
+		t.Errorf("both loaded = %s, want ready", resp.Status)
+	}
+
+	// A failed reload of a loaded component still reports the service
+	// partially ready
+	stsFailed = true
+	if _, resp := get(); resp.Status != "partially ready" || !resp.Components[0].Failed {
+		t.Errorf("sts reload failed = %+v, want partially ready with the sts failed", resp)
+	}
+}
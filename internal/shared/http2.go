@@ -0,0 +1,42 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+// Environment variables for the HTTP versions of the HTTP servers.
+const (
+	// EnvHTTP2 enables HTTP/2 over TLS, negotiated through ALPN when the
+	// server terminates TLS itself (default: true).
+	EnvHTTP2 = "HTTP2_ENABLED"
+
+	// EnvH2C enables unencrypted HTTP/2 with prior knowledge (h2c) next to
+	// HTTP/1.1, for load balancers and gRPC-style clients that multiplex
+	// requests over plain-text connections (default: false).
+	EnvH2C = "HTTP2_CLEARTEXT"
+)
+
+// ProtocolsFromEnv returns the HTTP versions served per HTTP2_ENABLED and
+// HTTP2_CLEARTEXT, for http.Server.Protocols. HTTP/1.1 is always served.
+func ProtocolsFromEnv() (*http.Protocols, error) {
+	http2, err := strconv.ParseBool(GetEnvDefault(EnvHTTP2, "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %q", EnvHTTP2, os.Getenv(EnvHTTP2))
+	}
+	h2c, err := strconv.ParseBool(GetEnvDefault(EnvH2C, "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %q", EnvH2C, os.Getenv(EnvH2C))
+	}
+
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(http2)
+	protocols.SetUnencryptedHTTP2(h2c)
+	return protocols, nil
+}
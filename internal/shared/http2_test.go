@@ -0,0 +1,89 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestProtocolsFromEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		http2     string
+		h2c       string
+		wantHTTP2 bool
+		wantH2C   bool
+		wantErr   bool
+	}{
+		{name: "defaults", wantHTTP2: true},
+		{name: "h2c", h2c: "true", wantHTTP2: true, wantH2C: true},
+		{name: "http/1.1 only", http2: "false"},
+		{name: "invalid http2", http2: "maybe", wantErr: true},
+		{name: "invalid h2c", h2c: "yes please", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvHTTP2, tt.http2)
+			t.Setenv(EnvH2C, tt.h2c)
+			got, err := ProtocolsFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProtocolsFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !got.HTTP1() || got.HTTP2() != tt.wantHTTP2 || got.UnencryptedHTTP2() != tt.wantH2C {
+				t.Errorf("ProtocolsFromEnv() = %v, want HTTP2 %v, h2c %v", got, tt.wantHTTP2, tt.wantH2C)
+			}
+		})
+	}
+}
+
+func TestServeH2C(t *testing.T) {
+	t.Setenv(EnvH2C, "true")
+	protocols, err := ProtocolsFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := &http.Server{
+		Protocols: protocols,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Proto", r.Proto)
+		}),
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go Serve(srv, ln)
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		name      string
+		h2c       bool
+		wantProto string
+	}{
+		{name: "prior knowledge", h2c: true, wantProto: "HTTP/2.0"},
+		{name: "http/1.1", wantProto: "HTTP/1.1"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			clientProtocols := new(http.Protocols)
+			clientProtocols.SetHTTP1(!tt.h2c)
+			clientProtocols.SetUnencryptedHTTP2(tt.h2c)
+			client := &http.Client{Transport: &http.Transport{Protocols: clientProtocols}}
+
+			resp, err := client.Get("http://" + ln.Addr().String())
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			resp.Body.Close()
+			if got := resp.Header.Get("X-Proto"); got != tt.wantProto {
+				t.Errorf("served over %s, want %s", got, tt.wantProto)
+			}
+		})
+	}
+}
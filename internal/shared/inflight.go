@@ -0,0 +1,91 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Environment variables for the maximum number of requests each handler
+// serves at once; 0 or unset means no limit. Requests over the limit are
+// rejected rather than queued, so a burst of large webhook bodies cannot
+// exhaust the memory of a small container.
+const (
+	EnvSTSMaxInFlight     = "STS_MAX_IN_FLIGHT"
+	EnvWebhookMaxInFlight = "WEBHOOK_MAX_IN_FLIGHT"
+)
+
+var (
+	inFlightRequests = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "octo_sts_in_flight_requests",
+		Help: "Requests being served by a handler with an in-flight limit.",
+	}, []string{"handler"})
+
+	inFlightRejectedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "octo_sts_in_flight_rejected_total",
+		Help: "Requests rejected because their handler was at its in-flight limit.",
+	}, []string{"handler"})
+)
+
+// InFlightLimiter rejects requests with 503 Service Unavailable while its
+// handler is already serving its limit.
+type InFlightLimiter struct {
+	name  string
+	slots chan struct{}
+}
+
+// NewInFlightLimiter creates a limiter of max requests at once for the
+// handler name, which labels its metrics. It returns nil if max is not
+// positive.
+func NewInFlightLimiter(name string, max int) *InFlightLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &InFlightLimiter{name: name, slots: make(chan struct{}, max)}
+}
+
+// MaxInFlightFromEnv returns the in-flight limit set by key, or 0 if it is
+// unset.
+func MaxInFlightFromEnv(key string) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return 0, nil
+	}
+	max, err := strconv.Atoi(v)
+	if err != nil || max < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", key, v)
+	}
+	return max, nil
+}
+
+// Handler wraps next with the limiter. A nil InFlightLimiter returns next
+// as is.
+func (l *InFlightLimiter) Handler(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	gauge := inFlightRequests.WithLabelValues(l.name)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case l.slots <- struct{}{}:
+		default:
+			inFlightRejectedTotal.WithLabelValues(l.name).Inc()
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "too many requests in flight", http.StatusServiceUnavailable)
+			return
+		}
+		gauge.Inc()
+		defer func() {
+			gauge.Dec()
+			<-l.slots
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
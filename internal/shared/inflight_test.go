@@ -0,0 +1,89 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInFlightLimiterHandler(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	blocking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	})
+	handler := NewInFlightLimiter("test", 1).Handler(blocking)
+
+	done := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+		done <- rec.Code
+	}()
+	<-entered
+
+	// The only slot is taken, so the next request is turned away at once
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status over the limit = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("missing Retry-After over the limit")
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("status of the first request = %d, want %d", code, http.StatusOK)
+	}
+
+	// The slot is free again once the first request finished
+	go func() { <-entered }()
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status after release = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestNewInFlightLimiterDisabled(t *testing.T) {
+	if l := NewInFlightLimiter("test", 0); l != nil {
+		t.Errorf("NewInFlightLimiter(0) = %v, want nil", l)
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	var l *InFlightLimiter
+	rec := httptest.NewRecorder()
+	l.Handler(ok).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("nil limiter status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestMaxInFlightFromEnv(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "", want: 0},
+		{value: "32", want: 32},
+		{value: "0", want: 0},
+		{value: "-1", wantErr: true},
+		{value: "many", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Setenv(EnvWebhookMaxInFlight, tt.value)
+		got, err := MaxInFlightFromEnv(EnvWebhookMaxInFlight)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("MaxInFlightFromEnv() with %q error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("MaxInFlightFromEnv() with %q = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Environment variables for the listener of the HTTP servers.
+const (
+	// EnvListenSocket makes the HTTP servers listen on a Unix domain socket
+	// at this path instead of a TCP port.
+	EnvListenSocket = "LISTEN_SOCKET"
+
+	// EnvListenAddr makes the HTTP servers listen on this TCP address, e.g.
+	// "[::]:8080" or "127.0.0.1:9443", instead of every interface on the
+	// port.
+	EnvListenAddr = "LISTEN_ADDR"
+
+	// EnvListenSocketMode sets the octal permissions of the socket
+	// (default: DefaultListenSocketMode).
+	EnvListenSocketMode = "LISTEN_SOCKET_MODE"
+
+	// EnvListenFDs and EnvListenPID are set by systemd when it passes
+	// listening sockets to the process (socket activation).
+	EnvListenFDs = "LISTEN_FDS"
+	EnvListenPID = "LISTEN_PID"
+)
+
+// listenFDsStart is the first file descriptor passed by socket activation.
+const listenFDsStart = 3
+
+// DefaultListenSocketMode lets the owner and group of the socket, such as a
+// reverse proxy's group, connect to it.
+const DefaultListenSocketMode fs.FileMode = 0o660
+
+// Listen returns the listener of an HTTP server: the socket passed by systemd
+// socket activation, else a Unix domain socket when LISTEN_SOCKET is set,
+// else a TCP listener on LISTEN_ADDR, or on addr when it is unset. A socket
+// file left behind by a process that
+// exited uncleanly is replaced; one still accepting connections is not.
+func Listen(addr string) (net.Listener, error) {
+	if ln, err := activationListener(); ln != nil || err != nil {
+		return ln, err
+	}
+
+	path := os.Getenv(EnvListenSocket)
+	if path == "" {
+		if v := os.Getenv(EnvListenAddr); v != "" {
+			// An IPv6 host must be bracketed, e.g. [::1]:8080
+			if _, _, err := net.SplitHostPort(v); err != nil {
+				return nil, fmt.Errorf("invalid %s: %q", EnvListenAddr, v)
+			}
+			addr = v
+		}
+		return net.Listen("tcp", addr)
+	}
+
+	mode := DefaultListenSocketMode
+	if v := os.Getenv(EnvListenSocketMode); v != "" {
+		m, err := strconv.ParseUint(v, 8, 32)
+		if err != nil || m > 0o777 {
+			return nil, fmt.Errorf("invalid %s: %q", EnvListenSocketMode, v)
+		}
+		mode = fs.FileMode(m)
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("socket %s is in use by another process", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return ln, nil
+}
+
+// activationListener returns the first socket passed by systemd socket
+// activation, or nil if the process was not socket-activated. The activation
+// variables are cleared so that child processes don't inherit them.
+func activationListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv(EnvListenPID))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	fds, err := strconv.Atoi(os.Getenv(EnvListenFDs))
+	if err != nil || fds < 1 {
+		return nil, fmt.Errorf("invalid %s: %q", EnvListenFDs, os.Getenv(EnvListenFDs))
+	}
+	os.Unsetenv(EnvListenPID)
+	os.Unsetenv(EnvListenFDs)
+
+	file := os.NewFile(uintptr(listenFDsStart), "systemd-socket")
+	defer file.Close()
+	ln, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use the socket passed by systemd: %w", err)
+	}
+	return ln, nil
+}
+
+// Serve serves srv on ln, with TLS when srv.TLSConfig is set (see
+// TLSConfigFromEnv). The socket file of a Unix listener is removed when the
+// server shuts down.
+func Serve(srv *http.Server, ln net.Listener) error {
+	if srv.TLSConfig != nil {
+		return srv.ServeTLS(ln, "", "")
+	}
+	return srv.Serve(ln)
+}
@@ -0,0 +1,157 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// socketPath returns a socket path short enough for the platform limit.
+func socketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "sock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "octo-sts.sock")
+}
+
+func TestListenSocket(t *testing.T) {
+	path := socketPath(t)
+	t.Setenv(EnvListenSocket, path)
+	t.Setenv(EnvListenSocketMode, "600")
+
+	ln, err := Listen(":0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if got := info.Mode().Perm(); got != 0o600 {
+		t.Errorf("socket mode = %o, want %o", got, 0o600)
+	}
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		io.WriteString(w, "ok")
+	})}
+	go Serve(srv, ln)
+
+	// A second listener must not take over the socket in use
+	if _, err := Listen(":0"); err == nil {
+		t.Error("Listen() expected an error for a socket in use")
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		Dial: func(_, _ string) (net.Conn, error) { return net.Dial("unix", path) },
+	}}
+	resp, err := client.Get("http://octo-sts/healthz")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ok" {
+		t.Errorf("body = %q, want %q", body, "ok")
+	}
+
+	srv.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the socket to be removed on close, Stat() error = %v", err)
+	}
+}
+
+func TestListenStaleSocket(t *testing.T) {
+	path := socketPath(t)
+	t.Setenv(EnvListenSocket, path)
+
+	// Leave a socket file behind without a listener
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ln, err := Listen("")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	ln.Close()
+}
+
+func TestListenInvalid(t *testing.T) {
+	path := socketPath(t)
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvListenSocket, path)
+	if _, err := Listen(""); err == nil {
+		t.Error("Listen() expected an error for a regular file")
+	}
+
+	t.Setenv(EnvListenSocket, socketPath(t))
+	t.Setenv(EnvListenSocketMode, "rw")
+	if _, err := Listen(""); err == nil {
+		t.Error("Listen() expected an error for an invalid mode")
+	}
+}
+
+func TestListenAddr(t *testing.T) {
+	tests := []struct {
+		name string
+		addr string
+		host string
+	}{
+		{name: "ipv4 loopback", addr: "127.0.0.1:0", host: "127.0.0.1"},
+		{name: "ipv6 loopback", addr: "[::1]:0", host: "::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvListenAddr, tt.addr)
+			ln, err := Listen(":8080")
+			if err != nil && net.ParseIP(tt.host).To4() == nil {
+				t.Skipf("IPv6 unavailable: %v", err)
+			}
+			if err != nil {
+				t.Fatalf("Listen() error = %v", err)
+			}
+			defer ln.Close()
+			if got := ln.Addr().(*net.TCPAddr).IP.String(); got != tt.host {
+				t.Errorf("listening on %s, want host %s", ln.Addr(), tt.host)
+			}
+		})
+	}
+
+	for _, addr := range []string{"8080", "::1:8080", "localhost"} {
+		t.Setenv(EnvListenAddr, addr)
+		if ln, err := Listen(":0"); err == nil {
+			ln.Close()
+			t.Errorf("Listen() expected an error for %s=%q", EnvListenAddr, addr)
+		}
+	}
+}
+
+func TestListenActivation(t *testing.T) {
+	// Not activated: the LISTEN_PID of another process is ignored
+	t.Setenv(EnvListenPID, "1")
+	t.Setenv(EnvListenFDs, "1")
+	if ln, err := activationListener(); ln != nil || err != nil {
+		t.Fatalf("activationListener() = %v, %v, want no listener", ln, err)
+	}
+
+	t.Setenv(EnvListenPID, strconv.Itoa(os.Getpid()))
+	t.Setenv(EnvListenFDs, "0")
+	if _, err := activationListener(); err == nil {
+		t.Error("activationListener() expected an error without sockets")
+	}
+}
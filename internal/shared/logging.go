@@ -16,6 +16,10 @@ const (
 
 	// LogFormatText outputs logs in human-readable text format.
 	LogFormatText = "text"
+
+	// LogFormatGCP outputs JSON logs with the severity and message fields of
+	// Google Cloud Logging structured logs.
+	LogFormatGCP = "gcp"
 )
 
 // Environment variable names for logging configuration.
@@ -38,11 +42,40 @@ func NewSlogHandler() slog.Handler {
 	switch format {
 	case LogFormatText:
 		return slog.NewTextHandler(os.Stderr, opts)
+	case LogFormatGCP:
+		opts.ReplaceAttr = gcpAttr
+		return slog.NewJSONHandler(os.Stderr, opts)
 	default:
 		return slog.NewJSONHandler(os.Stderr, opts)
 	}
 }
 
+// gcpAttr renames the level and message attributes to the severity and
+// message fields Cloud Logging reads from structured logs.
+func gcpAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.LevelKey:
+		var severity string
+		switch level := a.Value.Any().(slog.Level); {
+		case level >= slog.LevelError:
+			severity = "ERROR"
+		case level >= slog.LevelWarn:
+			severity = "WARNING"
+		case level >= slog.LevelInfo:
+			severity = "INFO"
+		default:
+			severity = "DEBUG"
+		}
+		return slog.String("severity", severity)
+	case slog.MessageKey:
+		a.Key = "message"
+	}
+	return a
+}
+
 // parseLogLevel converts a string log level to slog.Level.
 func parseLogLevel(level string) slog.Level {
 	switch strings.ToLower(level) {
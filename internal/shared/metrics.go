@@ -0,0 +1,108 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Environment variables for the Prometheus metrics endpoint.
+const (
+	// EnvMetrics disables the metrics endpoint when false (default: true), as
+	// in the upstream octo-sts/app.
+	EnvMetrics = "METRICS"
+
+	// EnvMetricsToken requires "Authorization: Bearer <token>" to read
+	// metrics.
+	EnvMetricsToken = "METRICS_TOKEN"
+
+	// EnvMetricsPort serves metrics on their own port instead of on the
+	// service port.
+	EnvMetricsPort = "METRICS_PORT"
+)
+
+// MetricsPath is the path of the Prometheus metrics endpoint.
+const MetricsPath = "/metrics"
+
+// MetricsHandler serves the metrics of the default Prometheus registry: the
+// STS, webhook, cache, and secret resolver metrics, and the GitHub client
+// metrics of the GitHub App transport. A non-empty token must be presented
+// as a bearer token.
+func MetricsHandler(token string) http.Handler {
+	handler := promhttp.Handler()
+	if token == "" {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// ServeMetrics exposes the metrics endpoint as configured by the environment.
+// It returns MetricsPath if the endpoint was added to mux, which callers must
+// then let through their ready gate, and an empty path if metrics are
+// disabled or served on METRICS_PORT, in which case a separate server runs
+// until ctx is done.
+func ServeMetrics(ctx context.Context, mux *http.ServeMux) (string, error) {
+	log := clog.FromContext(ctx)
+
+	if enabled, err := strconv.ParseBool(GetEnvDefault(EnvMetrics, "true")); err == nil && !enabled {
+		return "", nil
+	}
+	handler := MetricsHandler(os.Getenv(EnvMetricsToken))
+
+	port := os.Getenv(EnvMetricsPort)
+	if port == "" {
+		mux.Handle(MetricsPath, handler)
+		log.Infof("[metrics] serving metrics at %s", MetricsPath)
+		return MetricsPath, nil
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return "", fmt.Errorf("invalid %s: %q", EnvMetricsPort, port)
+	}
+
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle(MetricsPath, handler)
+	serveInternal(ctx, "metrics", ":"+port, metricsMux)
+	log.Infof("[metrics] serving metrics on port %s at %s", port, MetricsPath)
+	return "", nil
+}
+
+// serveInternal runs an internal server such as the metrics or debug server
+// on addr until ctx is done. Errors are logged under the [name] prefix
+// rather than stopping the service.
+func serveInternal(ctx context.Context, name, addr string, handler http.Handler) {
+	log := clog.FromContext(ctx)
+
+	srv := &http.Server{
+		Addr:              addr,
+		ReadHeaderTimeout: DefaultReadHeaderTimeout,
+		Handler:           handler,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("[%s] server error: %v", name, err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+}
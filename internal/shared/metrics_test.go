@@ -0,0 +1,70 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetricsHandlerToken(t *testing.T) {
+	handler := MetricsHandler("s3cret")
+
+	for header, want := range map[string]int{
+		"":              http.StatusUnauthorized,
+		"Bearer wrong":  http.StatusUnauthorized,
+		"s3cret":        http.StatusUnauthorized,
+		"Bearer s3cret": http.StatusOK,
+	} {
+		req := httptest.NewRequest(http.MethodGet, MetricsPath, nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Authorization %q: status = %d, want %d", header, rec.Code, want)
+		}
+	}
+}
+
+func TestServeMetrics(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		wantPath string
+		wantErr  bool
+	}{
+		{name: "default", wantPath: MetricsPath},
+		{name: "disabled", env: map[string]string{EnvMetrics: "false"}},
+		{name: "separate port", env: map[string]string{EnvMetricsPort: "0"}},
+		{name: "invalid port", env: map[string]string{EnvMetricsPort: "metrics"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{EnvMetrics, EnvMetricsPort, EnvMetricsToken} {
+				t.Setenv(key, tt.env[key])
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			mux := http.NewServeMux()
+			path, err := ServeMetrics(ctx, mux)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ServeMetrics() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if path != tt.wantPath {
+				t.Errorf("ServeMetrics() path = %q, want %q", path, tt.wantPath)
+			}
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+			if served := rec.Code == http.StatusOK; served != (tt.wantPath != "") {
+				t.Errorf("mux served %s with status %d", MetricsPath, rec.Code)
+			}
+		})
+	}
+}
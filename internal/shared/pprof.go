@@ -0,0 +1,68 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"strconv"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// EnvPprofAddr enables the pprof debug server on the given address, e.g.
+// "localhost:6060". A bare port listens on localhost only. The debug server
+// is never mounted on the service port.
+const EnvPprofAddr = "PPROF_ADDR"
+
+// PprofPath is the path prefix of the pprof debug endpoints.
+const PprofPath = "/debug/pprof/"
+
+// PprofHandler serves the runtime profiles of net/http/pprof under
+// PprofPath.
+func PprofHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(PprofPath, pprof.Index)
+	mux.HandleFunc(PprofPath+"cmdline", pprof.Cmdline)
+	mux.HandleFunc(PprofPath+"profile", pprof.Profile)
+	mux.HandleFunc(PprofPath+"symbol", pprof.Symbol)
+	mux.HandleFunc(PprofPath+"trace", pprof.Trace)
+	return mux
+}
+
+// ServePprof starts the pprof debug server if PPROF_ADDR is set. It runs
+// until ctx is done.
+func ServePprof(ctx context.Context) error {
+	addr, err := pprofAddr(os.Getenv(EnvPprofAddr))
+	if err != nil || addr == "" {
+		return err
+	}
+
+	serveInternal(ctx, "pprof", addr, PprofHandler())
+	clog.FromContext(ctx).Warnf("[pprof] serving profiles on %s at %s; do not expose this address publicly", addr, PprofPath)
+	return nil
+}
+
+// pprofAddr validates the PPROF_ADDR value, binding a bare port to
+// localhost.
+func pprofAddr(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if _, err := strconv.ParseUint(value, 10, 16); err == nil {
+		return net.JoinHostPort("localhost", value), nil
+	}
+	_, port, err := net.SplitHostPort(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", EnvPprofAddr, err)
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return "", fmt.Errorf("invalid %s: %q", EnvPprofAddr, value)
+	}
+	return value, nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPprofAddr(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "", want: ""},
+		{value: "6060", want: "localhost:6060"},
+		{value: "localhost:6060", want: "localhost:6060"},
+		{value: ":6060", want: ":6060"},
+		{value: "0.0.0.0:6060", want: "0.0.0.0:6060"},
+		{value: "localhost", wantErr: true},
+		{value: "localhost:pprof", wantErr: true},
+		{value: "70000", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := pprofAddr(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("pprofAddr(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("pprofAddr(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestPprofHandler(t *testing.T) {
+	handler := PprofHandler()
+
+	for path, want := range map[string]int{
+		PprofPath:                  http.StatusOK,
+		PprofPath + "goroutine":    http.StatusOK,
+		PprofPath + "heap?debug=1": http.StatusOK,
+		PprofPath + "cmdline":      http.StatusOK,
+		"/metrics":                 http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("GET %s: status = %d, want %d", path, rec.Code, want)
+		}
+	}
+}
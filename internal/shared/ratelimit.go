@@ -0,0 +1,241 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/time/rate"
+)
+
+// Environment variables for rate limiting. Limits are in requests per
+// second; a limit of 0 or unset disables it.
+const (
+	// EnvRateLimit limits the requests of all clients together.
+	EnvRateLimit = "RATE_LIMIT"
+
+	// EnvRateLimitBurst is the burst of the global limit (default: the
+	// limit, rounded up).
+	EnvRateLimitBurst = "RATE_LIMIT_BURST"
+
+	// EnvRateLimitPerClient limits the requests of each client IP.
+	EnvRateLimitPerClient = "RATE_LIMIT_PER_CLIENT"
+
+	// EnvRateLimitPerClientBurst is the burst of the per-client limit
+	// (default: the limit, rounded up).
+	EnvRateLimitPerClientBurst = "RATE_LIMIT_PER_CLIENT_BURST"
+
+	// EnvRateLimitProxyHops is the number of reverse proxies in front of the
+	// server that append to X-Forwarded-For (default: 0). The client IP is
+	// read from the entry the outermost of them appended, which clients
+	// cannot forge.
+	EnvRateLimitProxyHops = "RATE_LIMIT_PROXY_HOPS"
+)
+
+// clientIdleTimeout is how long the limiter of an idle client is kept.
+const clientIdleTimeout = 10 * time.Minute
+
+var rateLimitedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "octo_sts_rate_limited_total",
+	Help: "Requests rejected by the rate limiter, by the limit they exceeded.",
+}, []string{"limit"})
+
+// RateLimiter rejects requests over a global limit or a per-client-IP limit
+// with 429 Too Many Requests.
+type RateLimiter struct {
+	global *rate.Limiter
+
+	clientLimit rate.Limit
+	clientBurst int
+	proxyHops   int
+
+	mu        sync.Mutex
+	clients   map[string]*clientLimiter
+	lastSweep time.Time
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimitOption is a functional option for configuring RateLimiter.
+type RateLimitOption func(*RateLimiter)
+
+// WithGlobalLimit limits the requests of all clients together to limit per
+// second with the given burst.
+func WithGlobalLimit(limit float64, burst int) RateLimitOption {
+	return func(l *RateLimiter) {
+		l.global = rate.NewLimiter(rate.Limit(limit), burst)
+	}
+}
+
+// WithClientLimit limits the requests of each client IP to limit per second
+// with the given burst.
+func WithClientLimit(limit float64, burst int) RateLimitOption {
+	return func(l *RateLimiter) {
+		l.clientLimit = rate.Limit(limit)
+		l.clientBurst = burst
+	}
+}
+
+// WithProxyHops reads the client IP from X-Forwarded-For as appended by the
+// given number of trusted reverse proxies.
+func WithProxyHops(hops int) RateLimitOption {
+	return func(l *RateLimiter) {
+		l.proxyHops = hops
+	}
+}
+
+// NewRateLimiter creates a rate limiter. Without a global or client limit it
+// lets every request through.
+func NewRateLimiter(opts ...RateLimitOption) *RateLimiter {
+	l := &RateLimiter{clients: make(map[string]*clientLimiter)}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// NewRateLimiterFromEnv creates a rate limiter from the RATE_LIMIT_*
+// environment variables. It returns nil if no limit is set.
+func NewRateLimiterFromEnv() (*RateLimiter, error) {
+	var opts []RateLimitOption
+
+	limit, burst, err := rateLimitFromEnv(EnvRateLimit, EnvRateLimitBurst)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 {
+		opts = append(opts, WithGlobalLimit(limit, burst))
+	}
+
+	limit, burst, err = rateLimitFromEnv(EnvRateLimitPerClient, EnvRateLimitPerClientBurst)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 {
+		opts = append(opts, WithClientLimit(limit, burst))
+	}
+
+	if len(opts) == 0 {
+		return nil, nil
+	}
+
+	if v := os.Getenv(EnvRateLimitProxyHops); v != "" {
+		hops, err := strconv.Atoi(v)
+		if err != nil || hops < 0 {
+			return nil, fmt.Errorf("invalid %s: %q", EnvRateLimitProxyHops, v)
+		}
+		opts = append(opts, WithProxyHops(hops))
+	}
+	return NewRateLimiter(opts...), nil
+}
+
+// rateLimitFromEnv parses a limit and its burst, which defaults to the
+// limit rounded up.
+func rateLimitFromEnv(limitKey, burstKey string) (float64, int, error) {
+	v := os.Getenv(limitKey)
+	if v == "" {
+		return 0, 0, nil
+	}
+	limit, err := strconv.ParseFloat(v, 64)
+	if err != nil || limit < 0 || math.IsInf(limit, 0) {
+		return 0, 0, fmt.Errorf("invalid %s: %q", limitKey, v)
+	}
+
+	burst := int(math.Ceil(limit))
+	if v := os.Getenv(burstKey); v != "" {
+		if burst, err = strconv.Atoi(v); err != nil || burst < 1 {
+			return 0, 0, fmt.Errorf("invalid %s: %q", burstKey, v)
+		}
+	}
+	return limit, burst, nil
+}
+
+// Handler wraps next with the rate limiter. A nil RateLimiter returns next
+// as is.
+func (l *RateLimiter) Handler(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Check the client first so one client cannot use up the global limit
+		if !l.allowClient(l.clientIP(r)) {
+			rejectRateLimited(w, "client")
+			return
+		}
+		if l.global != nil && !l.global.Allow() {
+			rejectRateLimited(w, "global")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func rejectRateLimited(w http.ResponseWriter, limit string) {
+	rateLimitedTotal.WithLabelValues(limit).Inc()
+	w.Header().Set("Retry-After", "1")
+	http.Error(w, "too many requests", http.StatusTooManyRequests)
+}
+
+// allowClient reports whether the client at ip is within its limit.
+func (l *RateLimiter) allowClient(ip string) bool {
+	if l.clientLimit <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Drop idle clients so the map doesn't grow with every address seen
+	if now.Sub(l.lastSweep) > clientIdleTimeout {
+		for key, c := range l.clients {
+			if now.Sub(c.lastSeen) > clientIdleTimeout {
+				delete(l.clients, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	c, ok := l.clients[ip]
+	if !ok {
+		c = &clientLimiter{limiter: rate.NewLimiter(l.clientLimit, l.clientBurst)}
+		l.clients[ip] = c
+	}
+	c.lastSeen = now
+	return c.limiter.AllowN(now, 1)
+}
+
+// clientIP returns the IP of the client making r. Behind proxyHops proxies
+// it is the entry of X-Forwarded-For appended by the outermost proxy;
+// entries before it are set by the client.
+func (l *RateLimiter) clientIP(r *http.Request) string {
+	if l.proxyHops > 0 {
+		var entries []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			entries = append(entries, strings.Split(header, ",")...)
+		}
+		if i := len(entries) - l.proxyHops; i >= 0 {
+			if ip := strings.TrimSpace(entries[i]); ip != "" {
+				return ip
+			}
+		}
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
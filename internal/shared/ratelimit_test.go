@@ -0,0 +1,117 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRateLimiterHandler(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name    string
+		limiter *RateLimiter
+		// addrs are the remote addresses of consecutive requests
+		addrs []string
+		want  []int
+	}{
+		{
+			name:  "nil limiter",
+			addrs: []string{"192.0.2.1:1", "192.0.2.1:1", "192.0.2.1:1"},
+			want:  []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+		{
+			name:    "global",
+			limiter: NewRateLimiter(WithGlobalLimit(0.001, 2)),
+			addrs:   []string{"192.0.2.1:1", "192.0.2.2:1", "192.0.2.3:1"},
+			want:    []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:    "per client",
+			limiter: NewRateLimiter(WithClientLimit(0.001, 1)),
+			addrs:   []string{"192.0.2.1:1", "192.0.2.1:2", "192.0.2.2:1"},
+			want:    []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := tt.limiter.Handler(ok)
+			for i, addr := range tt.addrs {
+				req := httptest.NewRequest(http.MethodPost, "/", nil)
+				req.RemoteAddr = addr
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				if rec.Code != tt.want[i] {
+					t.Errorf("request %d from %s: status = %d, want %d", i, addr, rec.Code, tt.want[i])
+				}
+				if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+					t.Errorf("request %d: missing Retry-After", i)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		hops      int
+		forwarded []string
+		want      string
+	}{
+		{name: "no proxy", forwarded: []string{"198.51.100.1"}, want: "192.0.2.1"},
+		{name: "one proxy", hops: 1, forwarded: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "one proxy, forged entry", hops: 1, forwarded: []string{"203.0.113.9, 198.51.100.1"}, want: "198.51.100.1"},
+		{name: "two proxies", hops: 2, forwarded: []string{"203.0.113.9, 198.51.100.1", "10.0.0.2"}, want: "198.51.100.1"},
+		{name: "missing header", hops: 1, want: "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter(WithProxyHops(tt.hops))
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			for _, v := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			if got := l.clientIP(req); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRateLimiterFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "unset", wantNil: true},
+		{name: "zero", env: map[string]string{EnvRateLimit: "0"}, wantNil: true},
+		{name: "global", env: map[string]string{EnvRateLimit: "50", EnvRateLimitBurst: "100"}},
+		{name: "per client", env: map[string]string{EnvRateLimitPerClient: "0.5", EnvRateLimitProxyHops: "1"}},
+		{name: "invalid limit", env: map[string]string{EnvRateLimit: "fast"}, wantErr: true},
+		{name: "negative limit", env: map[string]string{EnvRateLimitPerClient: "-1"}, wantErr: true},
+		{name: "invalid burst", env: map[string]string{EnvRateLimit: "5", EnvRateLimitBurst: "0"}, wantErr: true},
+		{name: "invalid hops", env: map[string]string{EnvRateLimit: "5", EnvRateLimitProxyHops: "-1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{EnvRateLimit, EnvRateLimitBurst, EnvRateLimitPerClient, EnvRateLimitPerClientBurst, EnvRateLimitProxyHops} {
+				t.Setenv(key, tt.env[key])
+			}
+			l, err := NewRateLimiterFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewRateLimiterFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (l == nil) != tt.wantNil {
+				t.Errorf("NewRateLimiterFromEnv() = %v, wantNil %v", l, tt.wantNil)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// EnvReadyFile is the path of a file that exists only while the service is
+// ready, for health checks that can't probe HTTP, e.g. `test -f` in an ECS
+// or Nomad check or a systemd ExecStartPost.
+const EnvReadyFile = "READY_FILE"
+
+// readyFilePollInterval is how often WatchReadyFile checks readiness.
+const readyFilePollInterval = time.Second
+
+// readyFileContent is the JSON written to the ready file.
+type readyFileContent struct {
+	Status string    `json:"status"`
+	Since  time.Time `json:"since"`
+	PID    int       `json:"pid"`
+}
+
+// ReadyFileFromEnv returns the path of READY_FILE, or "" if unset. Its
+// directory must exist.
+func ReadyFileFromEnv() (string, error) {
+	path := os.Getenv(EnvReadyFile)
+	if path == "" {
+		return "", nil
+	}
+	info, err := os.Stat(filepath.Dir(path))
+	if err != nil {
+		return "", fmt.Errorf("invalid %s %q: %w", EnvReadyFile, path, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("invalid %s %q: %s is not a directory", EnvReadyFile, path, filepath.Dir(path))
+	}
+	return path, nil
+}
+
+// WatchReadyFile writes the ready file at path while ready reports true and
+// removes it while it reports false, until ctx is done, when the file is
+// removed for good. It returns at once if path is "".
+func WatchReadyFile(ctx context.Context, path string, ready func() bool) {
+	if path == "" {
+		return
+	}
+	log := clog.FromContext(ctx)
+
+	written := false
+	update := func(ready bool) {
+		if ready == written {
+			return
+		}
+		if ready {
+			if err := writeReadyFile(path); err != nil {
+				log.Errorf("[ready] failed to write %s: %v", path, err)
+				return
+			}
+			log.Infof("[ready] wrote %s", path)
+		} else {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				log.Errorf("[ready] failed to remove %s: %v", path, err)
+				return
+			}
+			log.Infof("[ready] removed %s", path)
+		}
+		written = ready
+	}
+
+	// A file left behind by a previous run doesn't mean this one is ready
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Errorf("[ready] failed to remove stale %s: %v", path, err)
+	}
+
+	ticker := time.NewTicker(readyFilePollInterval)
+	defer ticker.Stop()
+	for {
+		update(ready())
+		select {
+		case <-ctx.Done():
+			update(false)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeReadyFile writes the ready file through a temporary file, so a check
+// never reads it half-written.
+func writeReadyFile(path string) error {
+	data, err := json.Marshal(readyFileContent{Status: "ready", Since: time.Now().UTC(), PID: os.Getpid()})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadyFileFromEnv(t *testing.T) {
+	if got, err := ReadyFileFromEnv(); got != "" || err != nil {
+		t.Errorf("ReadyFileFromEnv() unset = %q, %v, want none", got, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "ready")
+	t.Setenv(EnvReadyFile, path)
+	if got, err := ReadyFileFromEnv(); got != path || err != nil {
+		t.Errorf("ReadyFileFromEnv() = %q, %v, want %q", got, err, path)
+	}
+
+	t.Setenv(EnvReadyFile, filepath.Join(t.TempDir(), "missing", "ready"))
+	if _, err := ReadyFileFromEnv(); err == nil {
+		t.Error("ReadyFileFromEnv() in a missing directory succeeded")
+	}
+}
+
+func TestWatchReadyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ready")
+	if err := os.WriteFile(path, []byte("stale"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var ready atomic.Bool
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		WatchReadyFile(ctx, path, ready.Load)
+	}()
+
+	waitFor := func(exists bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if _, err := os.Stat(path); (err == nil) == exists {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("ready file exists = %v, want %v", !exists, exists)
+	}
+
+	// The stale file of a previous run is removed until ready
+	waitFor(false)
+
+	ready.Store(true)
+	waitFor(true)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content readyFileContent
+	if err := json.Unmarshal(data, &content); err != nil || content.Status != "ready" || content.PID != os.Getpid() {
+		t.Errorf("ready file = %s, %v, want the ready status and PID", data, err)
+	}
+
+	ready.Store(false)
+	waitFor(false)
+
+	ready.Store(true)
+	waitFor(true)
+	cancel()
+	<-done
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("ready file after shutdown: %v, want removed", err)
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Environment variables for the response to requests that arrive before
+// the configuration is loaded.
+const (
+	// EnvNotReadyPage is a file served instead of the built-in responses.
+	EnvNotReadyPage = "NOT_READY_PAGE"
+
+	// EnvNotReadyContentType is the content type of NOT_READY_PAGE
+	// (default: from its extension, or text/html).
+	EnvNotReadyContentType = "NOT_READY_CONTENT_TYPE"
+
+	// EnvNotReadyRetryAfter is the Retry-After of the response (default:
+	// 5s). 0 leaves the header out.
+	EnvNotReadyRetryAfter = "NOT_READY_RETRY_AFTER"
+)
+
+// DefaultNotReadyRetryAfter is the default of NOT_READY_RETRY_AFTER.
+const DefaultNotReadyRetryAfter = 5 * time.Second
+
+// notReadyJSON is the built-in response for API clients, as the upstream
+// ready gate writes it.
+const notReadyJSON = `{"error":"service_unavailable","message":"service not ready, configuration loading"}` + "\n"
+
+// notReadyHTML is the built-in response for browsers, e.g. someone opening
+// the service before the GitHub App is set up.
+const notReadyHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <meta http-equiv="refresh" content="10">
+    <title>Starting Up</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; background: #f6f8fa; color: #24292f; margin: 0; padding: 40px 16px; }
+        .container { max-width: 640px; margin: 0 auto; background: #fff; border: 1px solid #d0d7de; border-radius: 6px; padding: 24px; }
+        h1 { font-size: 20px; margin-top: 0; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <h1>Octo-STS is starting up</h1>
+        <p>
+            The service is still loading its configuration, or waiting for the
+            GitHub App to be set up. This page reloads until it is ready.
+        </p>
+    </div>
+</body>
+</html>
+`
+
+// NotReadyResponse is written for requests that arrive before the
+// configuration is loaded.
+type NotReadyResponse struct {
+	// Body and ContentType replace the built-in responses when Body is set.
+	Body        []byte
+	ContentType string
+
+	// RetryAfter is sent as Retry-After, rounded up to seconds, unless 0.
+	RetryAfter time.Duration
+}
+
+// NotReadyResponseFromEnv returns the response set by the NOT_READY_*
+// environment variables.
+func NotReadyResponseFromEnv() (NotReadyResponse, error) {
+	resp := NotReadyResponse{RetryAfter: DefaultNotReadyRetryAfter}
+
+	if v := os.Getenv(EnvNotReadyRetryAfter); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return NotReadyResponse{}, fmt.Errorf("invalid %s %q: must be a duration such as 5s", EnvNotReadyRetryAfter, v)
+		}
+		resp.RetryAfter = d
+	}
+
+	if path := os.Getenv(EnvNotReadyPage); path != "" {
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return NotReadyResponse{}, fmt.Errorf("failed to read %s: %w", EnvNotReadyPage, err)
+		}
+		resp.Body = body
+		resp.ContentType = os.Getenv(EnvNotReadyContentType)
+		if resp.ContentType == "" {
+			resp.ContentType = mime.TypeByExtension(filepath.Ext(path))
+		}
+		if resp.ContentType == "" {
+			resp.ContentType = "text/html; charset=utf-8"
+		}
+	}
+	return resp, nil
+}
+
+// readyGateMaxPaths bounds the distinct path labels of
+// octo_sts_ready_gate_rejected_total; later paths are counted as "other",
+// so clients probing random paths can't grow the metrics without bound.
+const readyGateMaxPaths = 100
+
+var readyGateRejectedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "octo_sts_ready_gate_rejected_total",
+	Help: "Requests rejected with 503 before the configuration was loaded, by path.",
+}, []string{"path"})
+
+// rejectedPaths are the path labels of octo_sts_ready_gate_rejected_total
+// so far.
+var rejectedPaths = struct {
+	sync.Mutex
+	seen map[string]bool
+}{seen: map[string]bool{}}
+
+// rejectedPathLabel returns the label of path for
+// octo_sts_ready_gate_rejected_total.
+func rejectedPathLabel(path string) string {
+	rejectedPaths.Lock()
+	defer rejectedPaths.Unlock()
+	if !rejectedPaths.seen[path] {
+		if len(rejectedPaths.seen) >= readyGateMaxPaths {
+			return "other"
+		}
+		rejectedPaths.seen[path] = true
+	}
+	return path
+}
+
+// InstallerAllowedPaths are the ReadyGate rules of the installer pages,
+// which are served before the GitHub App exists. Disabling the installer
+// waits until the configuration is loaded.
+var InstallerAllowedPaths = []string{"GET /", "GET /setup", "GET /setup/", "GET /callback"}
+
+// ReadyGate serves next once ready reports true, and until then only the
+// requests matching one of rules. Other requests get resp with 503: by
+// default HTML for browsers and JSON otherwise.
+//
+// A rule is "[METHOD ]PATH". Without a method it matches any method, and
+// GET also matches HEAD, as in http.ServeMux. A PATH ending in "*" matches
+// by prefix, e.g. "/-/admin/*", and any other PATH only itself, so
+// "GET /setup" doesn't let "POST /setup/disable" through. ReadyGate panics
+// on an invalid rule.
+func ReadyGate(next http.Handler, ready func() bool, rules []string, resp NotReadyResponse) http.Handler {
+	allowed := make([]allowedPath, 0, len(rules))
+	for _, rule := range rules {
+		a, err := parseAllowedPath(rule)
+		if err != nil {
+			panic(err)
+		}
+		allowed = append(allowed, a)
+	}
+
+	var retryAfter string
+	if resp.RetryAfter > 0 {
+		retryAfter = strconv.Itoa(int(math.Ceil(resp.RetryAfter.Seconds())))
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ready() || isAllowed(r, allowed) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		readyGateRejectedTotal.WithLabelValues(rejectedPathLabel(r.URL.Path)).Inc()
+
+		body, contentType := resp.Body, resp.ContentType
+		if body == nil {
+			body, contentType = []byte(notReadyJSON), "application/json"
+			if strings.Contains(r.Header.Get("Accept"), "text/html") {
+				body, contentType = []byte(notReadyHTML), "text/html; charset=utf-8"
+			}
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "no-store")
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if _, err := w.Write(body); err != nil {
+			clog.FromContext(r.Context()).Debugf("[ready] failed to write response: %v", err)
+		}
+	})
+}
+
+// allowedPath is a parsed rule of ReadyGate.
+type allowedPath struct {
+	method string
+	path   string
+	prefix bool
+}
+
+// parseAllowedPath parses a rule of ReadyGate.
+func parseAllowedPath(rule string) (allowedPath, error) {
+	var a allowedPath
+	a.path = rule
+	if method, path, ok := strings.Cut(rule, " "); ok {
+		a.method, a.path = method, strings.TrimSpace(path)
+	}
+	a.path, a.prefix = strings.CutSuffix(a.path, "*")
+	if !strings.HasPrefix(a.path, "/") || strings.ContainsAny(a.method, "/ ") {
+		return allowedPath{}, fmt.Errorf("invalid ready gate rule %q: must be [METHOD ]PATH with PATH starting with /", rule)
+	}
+	return a, nil
+}
+
+// isAllowed reports whether r matches one of allowed.
+func isAllowed(r *http.Request, allowed []allowedPath) bool {
+	for _, a := range allowed {
+		if a.method != "" && a.method != r.Method && (a.method != http.MethodGet || r.Method != http.MethodHead) {
+			continue
+		}
+		if r.URL.Path == a.path || (a.prefix && strings.HasPrefix(r.URL.Path, a.path)) {
+			return true
+		}
+	}
+	return false
+}
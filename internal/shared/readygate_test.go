@@ -0,0 +1,195 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestReadyGate(t *testing.T) {
+	ready := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	gate := ReadyGate(next, func() bool { return ready }, []string{"GET /", "GET /healthz", "GET /setup", "/-/admin/*"},
+		NotReadyResponse{RetryAfter: 1500 * time.Millisecond})
+
+	serve := func(method, path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		gate.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Rules match their method, GET also HEAD, and their path exactly
+	// unless it ends in *
+	for _, tt := range []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/", http.StatusOK},
+		{http.MethodHead, "/healthz", http.StatusOK},
+		{http.MethodGet, "/setup", http.StatusOK},
+		{http.MethodPost, "/-/admin/reload", http.StatusOK},
+		{http.MethodPost, "/", http.StatusServiceUnavailable},
+		{http.MethodPost, "/setup", http.StatusServiceUnavailable},
+		{http.MethodPost, "/setup/disable", http.StatusServiceUnavailable},
+		{http.MethodGet, "/setup/disable", http.StatusServiceUnavailable},
+		{http.MethodGet, "/healthzz", http.StatusServiceUnavailable},
+	} {
+		if rec := serve(tt.method, tt.path, ""); rec.Code != tt.want {
+			t.Errorf("%s %s before ready = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+
+	rec := serve(http.MethodPost, "/sts/exchange", "application/json")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+	if !strings.Contains(rec.Body.String(), `"service_unavailable"`) {
+		t.Errorf("body = %q, want the JSON error", rec.Body.String())
+	}
+
+	rec = serve(http.MethodPost, "/sts/exchange", "text/html,application/xhtml+xml")
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/html") {
+		t.Errorf("Content-Type for a browser = %q, want text/html", got)
+	}
+
+	ready = true
+	if rec := serve(http.MethodPost, "/sts/exchange", ""); rec.Code != http.StatusOK {
+		t.Errorf("status after ready = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestReadyGateRejectedCounter(t *testing.T) {
+	gate := ReadyGate(http.NotFoundHandler(), func() bool { return false }, []string{"GET /healthz"}, NotReadyResponse{})
+	counter := readyGateRejectedTotal.WithLabelValues("/sts/exchange")
+	before := testutil.ToFloat64(counter)
+
+	for _, path := range []string{"/sts/exchange", "/sts/exchange", "/healthz"} {
+		gate.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if got := testutil.ToFloat64(counter) - before; got != 2 {
+		t.Errorf("rejected /sts/exchange = %v, want 2", got)
+	}
+}
+
+func TestRejectedPathLabel(t *testing.T) {
+	rejectedPaths.Lock()
+	saved := rejectedPaths.seen
+	rejectedPaths.seen = map[string]bool{}
+	rejectedPaths.Unlock()
+	t.Cleanup(func() {
+		rejectedPaths.Lock()
+		rejectedPaths.seen = saved
+		rejectedPaths.Unlock()
+	})
+
+	for i := range readyGateMaxPaths {
+		rejectedPathLabel(fmt.Sprintf("/probe/%d", i))
+	}
+	if got := rejectedPathLabel("/probe/new"); got != "other" {
+		t.Errorf("label past the cap = %q, want other", got)
+	}
+	if got := rejectedPathLabel("/probe/1"); got != "/probe/1" {
+		t.Errorf("label of a seen path = %q, want /probe/1", got)
+	}
+}
+
+func TestReadyGateInvalidRule(t *testing.T) {
+	for _, rule := range []string{"", "healthz", "GET healthz", "GET  "} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("ReadyGate() with rule %q didn't panic", rule)
+				}
+			}()
+			ReadyGate(http.NotFoundHandler(), func() bool { return false }, []string{rule}, NotReadyResponse{})
+		}()
+	}
+}
+
+func TestReadyGateCustomResponse(t *testing.T) {
+	gate := ReadyGate(http.NotFoundHandler(), func() bool { return false }, nil,
+		NotReadyResponse{Body: []byte("setting up"), ContentType: "text/plain"})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/html")
+	rec := httptest.NewRecorder()
+	gate.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable || rec.Body.String() != "setting up" {
+		t.Errorf("response = %d %q, want 503 %q", rec.Code, rec.Body.String(), "setting up")
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/plain" {
+		t.Errorf("Content-Type = %q, want text/plain", got)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "" {
+		t.Errorf("Retry-After = %q, want none", got)
+	}
+}
+
+func TestNotReadyResponseFromEnv(t *testing.T) {
+	page := filepath.Join(t.TempDir(), "setup.html")
+	if err := os.WriteFile(page, []byte("<p>setting up</p>"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		got, err := NotReadyResponseFromEnv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Body != nil || got.RetryAfter != DefaultNotReadyRetryAfter {
+			t.Errorf("NotReadyResponseFromEnv() = %+v, want the built-in responses", got)
+		}
+	})
+
+	t.Run("page", func(t *testing.T) {
+		t.Setenv(EnvNotReadyPage, page)
+		t.Setenv(EnvNotReadyRetryAfter, "0")
+		got, err := NotReadyResponseFromEnv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got.Body) != "<p>setting up</p>" || !strings.HasPrefix(got.ContentType, "text/html") || got.RetryAfter != 0 {
+			t.Errorf("NotReadyResponseFromEnv() = %+v", got)
+		}
+
+		t.Setenv(EnvNotReadyContentType, "text/plain")
+		if got, _ := NotReadyResponseFromEnv(); got.ContentType != "text/plain" {
+			t.Errorf("ContentType = %q, want text/plain", got.ContentType)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Setenv(EnvNotReadyRetryAfter, "soon")
+		if _, err := NotReadyResponseFromEnv(); err == nil {
+			t.Error("NotReadyResponseFromEnv() with a bad Retry-After succeeded")
+		}
+		t.Setenv(EnvNotReadyRetryAfter, "")
+		t.Setenv(EnvNotReadyPage, filepath.Join(t.TempDir(), "missing.html"))
+		if _, err := NotReadyResponseFromEnv(); err == nil {
+			t.Error("NotReadyResponseFromEnv() with a missing page succeeded")
+		}
+	})
+}
@@ -0,0 +1,321 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// EnvReloadDebounce is how long a reload waits for further triggers after
+// the first one, so the installer saving credentials, a SIGHUP, and the
+// store watcher noticing the same change reload once (default: 1s). 0
+// reloads on every trigger that arrives while no reload is pending.
+const EnvReloadDebounce = "RELOAD_DEBOUNCE"
+
+// DefaultReloadDebounce is the default of RELOAD_DEBOUNCE.
+const DefaultReloadDebounce = time.Second
+
+// ReloadHistorySize is the number of reloads kept by Reloader.History.
+const ReloadHistorySize = 20
+
+// Sources of reloads, as recorded in ReloadStatus.Sources.
+const (
+	ReloadSourceSignal    = "sighup"
+	ReloadSourceInstaller = "installer"
+	ReloadSourceStore     = "store"
+	ReloadSourceAdmin     = "admin"
+)
+
+// ReloadDebounceFromEnv returns the debounce window set by RELOAD_DEBOUNCE.
+func ReloadDebounceFromEnv() (time.Duration, error) {
+	v := os.Getenv(EnvReloadDebounce)
+	if v == "" {
+		return DefaultReloadDebounce, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a duration such as 1s", EnvReloadDebounce, v)
+	}
+	return d, nil
+}
+
+var (
+	reloadsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "octo_sts_reloads_total",
+		Help: "Configuration reloads, by result: success or failure.",
+	}, []string{"result"})
+
+	reloadHookFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "octo_sts_reload_hook_failures_total",
+		Help: "Reload hooks that failed, by hook.",
+	}, []string{"hook"})
+
+	reloadDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "octo_sts_reload_duration_seconds",
+		Help:    "Duration of configuration reloads.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+// ReloadStatus describes the last reload.
+type ReloadStatus struct {
+	// Time is when the reload started.
+	Time time.Time
+
+	// Duration is how long the hooks took.
+	Duration time.Duration
+
+	// OK is set if every hook succeeded.
+	OK bool
+
+	// FailedHooks names the hooks that failed.
+	FailedHooks []string
+
+	// Errors are the errors of the failed hooks, in the order of
+	// FailedHooks, e.g. for the admin API; /readyz doesn't serve them.
+	Errors []*ReloadHookError
+
+	// Reloads is the number of reloads so far.
+	Reloads int
+
+	// Sources are what triggered the reload, e.g. ReloadSourceInstaller;
+	// several when triggers were collapsed into it.
+	Sources []string
+}
+
+// Reloader reloads the configuration on SIGHUP and on triggers from the
+// installer and the store watcher by calling its hooks. Triggers within the
+// debounce window of the first one collapse into a single reload, and a
+// trigger during a reload queues one more.
+type Reloader struct {
+	debounce time.Duration
+	triggers chan struct{}
+
+	// mu serializes reloads and guards hooks
+	mu    sync.Mutex
+	hooks []reloadHook
+
+	// sourcesMu guards sources, the sources of the pending triggers
+	sourcesMu sync.Mutex
+	sources   []string
+
+	last atomic.Pointer[ReloadStatus]
+
+	// historyMu guards history, the last reloads, oldest first
+	historyMu sync.Mutex
+	history   []*ReloadStatus
+}
+
+// reloadHook is a named callback of a reload.
+type reloadHook struct {
+	name string
+	fn   func(context.Context) error
+}
+
+// ReloadHookError is the error of one hook of a reload.
+type ReloadHookError struct {
+	Hook string
+	Err  error
+}
+
+func (e *ReloadHookError) Error() string { return e.Hook + ": " + e.Err.Error() }
+func (e *ReloadHookError) Unwrap() error { return e.Err }
+
+// NewReloader creates a Reloader with the given debounce window.
+func NewReloader(debounce time.Duration) *Reloader {
+	return &Reloader{debounce: debounce, triggers: make(chan struct{}, 1)}
+}
+
+// Register adds a hook called on every reload, after the hooks registered
+// before it, e.g. "config" to reload the configuration and then the hooks
+// of the components that depend on it.
+func (r *Reloader) Register(name string, hook func(context.Context) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, reloadHook{name: name, fn: hook})
+}
+
+// Reload calls every hook in order, even if an earlier one failed, and
+// returns the errors of the failed ones as ReloadHookErrors. It is
+// recorded as requested through the admin API.
+func (r *Reloader) Reload(ctx context.Context) error {
+	return r.reload(ctx, []string{ReloadSourceAdmin})
+}
+
+// reload performs a reload triggered by sources.
+func (r *Reloader) reload(ctx context.Context, sources []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	log := clog.FromContext(ctx)
+	status := &ReloadStatus{Time: time.Now(), OK: true, Reloads: 1, Sources: sources}
+	if last := r.last.Load(); last != nil {
+		status.Reloads = last.Reloads + 1
+	}
+	var errs []error
+	for _, h := range r.hooks {
+		if err := h.fn(ctx); err != nil {
+			log.Errorf("[reload] %s failed: %v", h.name, err)
+			reloadHookFailuresTotal.WithLabelValues(h.name).Inc()
+			hookErr := &ReloadHookError{Hook: h.name, Err: err}
+			errs = append(errs, hookErr)
+			status.OK = false
+			status.FailedHooks = append(status.FailedHooks, h.name)
+			status.Errors = append(status.Errors, hookErr)
+		}
+	}
+	status.Duration = time.Since(status.Time)
+	r.last.Store(status)
+	r.historyMu.Lock()
+	r.history = append(r.history, status)
+	if len(r.history) > ReloadHistorySize {
+		r.history = r.history[len(r.history)-ReloadHistorySize:]
+	}
+	r.historyMu.Unlock()
+
+	reloadDuration.Observe(status.Duration.Seconds())
+	if status.OK {
+		reloadsTotal.WithLabelValues("success").Inc()
+	} else {
+		reloadsTotal.WithLabelValues("failure").Inc()
+	}
+	return errors.Join(errs...)
+}
+
+// LastReload returns the status of the last reload, or nil if there was
+// none yet. The status must not be modified.
+func (r *Reloader) LastReload() *ReloadStatus {
+	return r.last.Load()
+}
+
+// History returns the last ReloadHistorySize reloads, newest first. The
+// statuses must not be modified.
+func (r *Reloader) History() []*ReloadStatus {
+	r.historyMu.Lock()
+	defer r.historyMu.Unlock()
+	history := make([]*ReloadStatus, len(r.history))
+	for i, status := range r.history {
+		history[len(r.history)-1-i] = status
+	}
+	return history
+}
+
+// TriggerFunc returns a function that triggers a reload from source, e.g.
+// for installer.WrapOnCredentialsSaved.
+func (r *Reloader) TriggerFunc(source string) func() {
+	return func() { r.Trigger(source) }
+}
+
+// Trigger requests a reload from source. It never blocks, and is safe to
+// call before Start and from any goroutine.
+func (r *Reloader) Trigger(source string) {
+	r.addSource(source)
+	select {
+	case r.triggers <- struct{}{}:
+	default:
+		// A reload is already pending
+	}
+}
+
+// Triggered receives the pending trigger. Before Start, it lets the wait
+// for the initial configuration retry at once, e.g. when the installer
+// saved the credentials.
+func (r *Reloader) Triggered() <-chan struct{} {
+	return r.triggers
+}
+
+// addSource records source for the pending reload.
+func (r *Reloader) addSource(source string) {
+	r.sourcesMu.Lock()
+	defer r.sourcesMu.Unlock()
+	if !slices.Contains(r.sources, source) {
+		r.sources = append(r.sources, source)
+	}
+}
+
+// takeSources returns the sources of the pending reload and clears them.
+func (r *Reloader) takeSources() []string {
+	r.sourcesMu.Lock()
+	defer r.sourcesMu.Unlock()
+	sources := r.sources
+	r.sources = nil
+	return sources
+}
+
+// Start reloads on SIGHUP and triggers until ctx is done. A hook that
+// fails is logged, and the component keeps its previous configuration.
+func (r *Reloader) Start(ctx context.Context) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	// Triggers consumed by the wait for the initial configuration led to
+	// no reload
+	if len(r.triggers) == 0 {
+		r.takeSources()
+	}
+
+	go func() {
+		defer signal.Stop(sighup)
+		log := clog.FromContext(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sighup:
+				r.addSource(ReloadSourceSignal)
+			case <-r.triggers:
+			}
+
+			n, ok := r.settle(ctx, sighup)
+			if !ok {
+				return
+			}
+			sources := r.takeSources()
+			log.Infof("[reload] reloading configuration (%d triggers from %s)", n, strings.Join(sources, ", "))
+			if err := r.reload(ctx, sources); err != nil {
+				continue
+			}
+			log.Infof("[reload] configuration reloaded")
+		}
+	}()
+}
+
+// settle waits out the debounce window, absorbing the triggers and signals
+// that arrive meanwhile. It returns how many triggers the reload covers,
+// and false if ctx is done first.
+func (r *Reloader) settle(ctx context.Context, sighup <-chan os.Signal) (int, bool) {
+	n := 1
+	if r.debounce <= 0 {
+		return n, true
+	}
+	timer := time.NewTimer(r.debounce)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return n, false
+		case <-sighup:
+			r.addSource(ReloadSourceSignal)
+			n++
+		case <-r.triggers:
+			n++
+		case <-timer.C:
+			return n, true
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReloaderDebounce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reloads := make(chan struct{}, 10)
+	r := NewReloader(50 * time.Millisecond)
+	r.Register("test", func(context.Context) error {
+		reloads <- struct{}{}
+		return nil
+	})
+	r.Start(ctx)
+
+	// A burst of triggers within the window reloads once
+	for range 5 {
+		r.Trigger("test")
+		time.Sleep(5 * time.Millisecond)
+	}
+	select {
+	case <-reloads:
+	case <-time.After(time.Second):
+		t.Fatal("no reload after triggers")
+	}
+	select {
+	case <-reloads:
+		t.Fatal("burst of triggers reloaded more than once")
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	// A later trigger reloads again
+	r.Trigger("test")
+	select {
+	case <-reloads:
+	case <-time.After(time.Second):
+		t.Fatal("no reload after a later trigger")
+	}
+}
+
+func TestReloaderTriggerDuringReload(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	r := NewReloader(0)
+	r.Register("test", func(context.Context) error {
+		entered <- struct{}{}
+		<-release
+		return nil
+	})
+	r.Start(ctx)
+
+	r.Trigger("test")
+	<-entered
+
+	// Triggers during a reload queue exactly one more
+	r.Trigger("test")
+	r.Trigger("test")
+	release <- struct{}{}
+	select {
+	case <-entered:
+	case <-time.After(time.Second):
+		t.Fatal("no reload for triggers during a reload")
+	}
+	release <- struct{}{}
+	select {
+	case <-entered:
+		t.Fatal("triggers during a reload queued more than one reload")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestReloaderReloadHooks(t *testing.T) {
+	errConfig := errors.New("store unavailable")
+	var called []string
+	hook := func(name string, err error) func(context.Context) error {
+		return func(context.Context) error {
+			called = append(called, name)
+			return err
+		}
+	}
+
+	r := NewReloader(0)
+	r.Register("config", hook("config", errConfig))
+	r.Register("sts", hook("sts", nil))
+	r.Register("webhook", hook("webhook", errConfig))
+
+	err := r.Reload(context.Background())
+	if got := strings.Join(called, ","); got != "config,sts,webhook" {
+		t.Errorf("hooks called = %s, want config,sts,webhook", got)
+	}
+	if !errors.Is(err, errConfig) {
+		t.Fatalf("Reload() = %v, want %v", err, errConfig)
+	}
+
+	// Each failed hook is reported by name
+	var failed []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var hookErr *ReloadHookError
+		if errors.As(e, &hookErr) {
+			failed = append(failed, hookErr.Hook)
+		}
+	}
+	if got := strings.Join(failed, ","); got != "config,webhook" {
+		t.Errorf("failed hooks = %s, want config,webhook", got)
+	}
+
+	// The last reload keeps the errors
+	last := r.LastReload()
+	if len(last.Errors) != 2 || last.Errors[0].Hook != "config" || !errors.Is(last.Errors[1], errConfig) {
+		t.Errorf("last reload errors = %v, want config and webhook", last.Errors)
+	}
+}
+
+func TestReloaderHistory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{}, 10)
+	r := NewReloader(50 * time.Millisecond)
+	r.Register("test", func(context.Context) error {
+		done <- struct{}{}
+		return nil
+	})
+	r.Start(ctx)
+
+	// Triggers collapsed into one reload record each source once
+	r.Trigger(ReloadSourceInstaller)
+	r.Trigger(ReloadSourceStore)
+	r.Trigger(ReloadSourceInstaller)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("no reload after triggers")
+	}
+	if err := r.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	history := r.History()
+	if len(history) != 2 {
+		t.Fatalf("history has %d reloads, want 2", len(history))
+	}
+	if got := strings.Join(history[0].Sources, ","); got != ReloadSourceAdmin {
+		t.Errorf("newest reload sources = %s, want %s", got, ReloadSourceAdmin)
+	}
+	if got := strings.Join(history[1].Sources, ","); got != "installer,store" {
+		t.Errorf("oldest reload sources = %s, want installer,store", got)
+	}
+
+	// Only the last ReloadHistorySize reloads are kept
+	for range ReloadHistorySize {
+		_ = r.Reload(ctx)
+		<-done
+	}
+	if history := r.History(); len(history) != ReloadHistorySize || history[0].Reloads != ReloadHistorySize+2 {
+		t.Errorf("history has %d reloads, newest %d, want %d and %d", len(history), history[0].Reloads, ReloadHistorySize, ReloadHistorySize+2)
+	}
+}
+
+func TestReloadDebounceFromEnv(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "", want: DefaultReloadDebounce},
+		{value: "250ms", want: 250 * time.Millisecond},
+		{value: "0", want: 0},
+		{value: "-1s", wantErr: true},
+		{value: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Setenv(EnvReloadDebounce, tt.value)
+		got, err := ReloadDebounceFromEnv()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ReloadDebounceFromEnv() with %q error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ReloadDebounceFromEnv() with %q = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@
 package shared
 
 import (
+	"net/http"
 	"strings"
 )
 
@@ -47,10 +48,62 @@ type Response struct {
 	// Headers contains response headers.
 	Headers map[string]string
 
+	// MultiValueHeaders contains headers sent more than once, such as
+	// Set-Cookie, whose values cannot be joined into one. A name set in
+	// both maps is sent with the value of Headers first.
+	MultiValueHeaders map[string][]string
+
 	// Body contains the raw response body.
 	Body []byte
 }
 
+// NewResponse builds a Response from header as written by an
+// http.Handler, keeping headers with several values in MultiValueHeaders.
+func NewResponse(statusCode int, header http.Header, body []byte) Response {
+	resp := Response{StatusCode: statusCode, Headers: make(map[string]string, len(header)), Body: body}
+	for k, vs := range header {
+		switch len(vs) {
+		case 0:
+		case 1:
+			resp.Headers[k] = vs[0]
+		default:
+			if resp.MultiValueHeaders == nil {
+				resp.MultiValueHeaders = make(map[string][]string)
+			}
+			resp.MultiValueHeaders[k] = vs
+		}
+	}
+	return resp
+}
+
+// Header returns the headers of r with canonical names, so names that
+// differ only in case are merged.
+func (r Response) Header() http.Header {
+	h := make(http.Header, len(r.Headers)+len(r.MultiValueHeaders))
+	for k, v := range r.Headers {
+		h.Add(k, v)
+	}
+	for k, vs := range r.MultiValueHeaders {
+		for _, v := range vs {
+			h.Add(k, v)
+		}
+	}
+	return h
+}
+
+// Write writes r to w.
+func (r Response) Write(w http.ResponseWriter) error {
+	for k, vs := range r.Header() {
+		w.Header()[k] = vs
+	}
+	w.WriteHeader(r.StatusCode)
+	if r.Body == nil {
+		return nil
+	}
+	_, err := w.Write(r.Body)
+	return err
+}
+
 // NormalizeHeaders converts header keys to lowercase for consistent access
 // across different runtime environments.
 func NormalizeHeaders(headers map[string]string) map[string]string {
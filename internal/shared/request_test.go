@@ -0,0 +1,49 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestNewResponse(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Type", "text/plain")
+	header.Add("Set-Cookie", "a=1")
+	header.Add("Set-Cookie", "b=2")
+
+	resp := NewResponse(http.StatusOK, header, []byte("ok"))
+	if want := map[string]string{"Content-Type": "text/plain"}; !reflect.DeepEqual(resp.Headers, want) {
+		t.Errorf("Headers = %v, want %v", resp.Headers, want)
+	}
+	if want := map[string][]string{"Set-Cookie": {"a=1", "b=2"}}; !reflect.DeepEqual(resp.MultiValueHeaders, want) {
+		t.Errorf("MultiValueHeaders = %v, want %v", resp.MultiValueHeaders, want)
+	}
+}
+
+func TestResponseWrite(t *testing.T) {
+	resp := Response{
+		StatusCode:        http.StatusFound,
+		Headers:           map[string]string{"location": "/setup", "set-cookie": "a=1"},
+		MultiValueHeaders: map[string][]string{"Set-Cookie": {"b=2"}},
+		Body:              []byte("found"),
+	}
+
+	rec := httptest.NewRecorder()
+	if err := resp.Write(rec); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if rec.Code != http.StatusFound || rec.Body.String() != "found" {
+		t.Errorf("response = %d %q, want %d %q", rec.Code, rec.Body.String(), http.StatusFound, "found")
+	}
+	if got := rec.Header().Values("Set-Cookie"); !reflect.DeepEqual(got, []string{"a=1", "b=2"}) {
+		t.Errorf("Set-Cookie = %v, want both cookies", got)
+	}
+	if got := rec.Header().Get("Location"); got != "/setup" {
+		t.Errorf("Location = %q, want %q", got, "/setup")
+	}
+}
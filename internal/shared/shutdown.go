@@ -0,0 +1,147 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// Environment variables for graceful shutdown, as durations like "30s".
+const (
+	// EnvShutdownTimeout bounds how long in-flight requests may take to
+	// finish once the listener is closed (default: 30s, 8s on Cloud Run).
+	EnvShutdownTimeout = "SHUTDOWN_TIMEOUT"
+
+	// EnvShutdownDrainDelay is how long the server keeps accepting requests
+	// while /healthz reports 503, before the listener is closed (default:
+	// 0). Set it to the time the load balancer or Kubernetes takes to stop
+	// routing to a pod that is no longer ready.
+	EnvShutdownDrainDelay = "SHUTDOWN_DRAIN_DELAY"
+)
+
+// drainPollInterval is how often Shutdown checks for in-flight requests
+// after the server has shut down.
+const drainPollInterval = 50 * time.Millisecond
+
+// ShutdownConfig configures Drainer.Shutdown.
+type ShutdownConfig struct {
+	// Timeout bounds how long in-flight requests may take to finish.
+	Timeout time.Duration
+
+	// DrainDelay is how long new requests are still served after readiness
+	// turns false.
+	DrainDelay time.Duration
+}
+
+// ShutdownConfigFromEnv returns the shutdown configuration from
+// SHUTDOWN_TIMEOUT and SHUTDOWN_DRAIN_DELAY, with defaultTimeout when
+// SHUTDOWN_TIMEOUT is unset.
+func ShutdownConfigFromEnv(defaultTimeout time.Duration) (ShutdownConfig, error) {
+	cfg := ShutdownConfig{Timeout: defaultTimeout}
+	if v := os.Getenv(EnvShutdownTimeout); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return ShutdownConfig{}, fmt.Errorf("invalid %s: %q", EnvShutdownTimeout, v)
+		}
+		cfg.Timeout = d
+	}
+	if v := os.Getenv(EnvShutdownDrainDelay); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return ShutdownConfig{}, fmt.Errorf("invalid %s: %q", EnvShutdownDrainDelay, v)
+		}
+		cfg.DrainDelay = d
+	}
+	return cfg, nil
+}
+
+// Drainer tracks in-flight requests and readiness so a server can shut down
+// without dropping them: readiness turns false as soon as shutdown starts,
+// and Shutdown returns only once every tracked request has finished.
+type Drainer struct {
+	draining atomic.Bool
+	active   atomic.Int64
+}
+
+// NewDrainer returns a Drainer that is not draining.
+func NewDrainer() *Drainer {
+	return &Drainer{}
+}
+
+// Handler tracks the requests served by next.
+func (d *Drainer) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d.active.Add(1)
+		defer d.active.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HealthHandler wraps a readiness handler to report 503 once shutdown has
+// started.
+func (d *Drainer) HealthHandler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if d.Draining() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("shutting down"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Draining reports whether shutdown has started.
+func (d *Drainer) Draining() bool {
+	return d.draining.Load()
+}
+
+// InFlight returns the number of requests being served.
+func (d *Drainer) InFlight() int64 {
+	return d.active.Load()
+}
+
+// Shutdown turns readiness false, keeps serving for cfg.DrainDelay, then
+// closes the listener and waits up to cfg.Timeout for in-flight requests to
+// finish. It returns an error if some were still running at the timeout.
+func (d *Drainer) Shutdown(ctx context.Context, srv *http.Server, cfg ShutdownConfig) error {
+	log := clog.FromContext(ctx)
+	d.draining.Store(true)
+
+	// Let clients reconnect elsewhere rather than reuse this server
+	srv.SetKeepAlivesEnabled(false)
+
+	if cfg.DrainDelay > 0 {
+		log.Infof("[shutdown] not ready, draining for %s (%d requests in flight)", cfg.DrainDelay, d.InFlight())
+		time.Sleep(cfg.DrainDelay)
+	}
+
+	log.Infof("[shutdown] closing listener, waiting up to %s for %d requests in flight", cfg.Timeout, d.InFlight())
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cfg.Timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("%w with %d requests in flight", err, d.InFlight())
+	}
+
+	// Shutdown doesn't wait for hijacked connections, so wait for the
+	// tracked requests too
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for d.InFlight() > 0 {
+		select {
+		case <-ticker.C:
+		case <-shutdownCtx.Done():
+			return fmt.Errorf("%w with %d requests in flight", shutdownCtx.Err(), d.InFlight())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestShutdownConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name       string
+		timeout    string
+		drainDelay string
+		want       ShutdownConfig
+		wantErr    bool
+	}{
+		{name: "defaults", want: ShutdownConfig{Timeout: 8 * time.Second}},
+		{name: "set", timeout: "1m", drainDelay: "5s", want: ShutdownConfig{Timeout: time.Minute, DrainDelay: 5 * time.Second}},
+		{name: "zero drain delay", drainDelay: "0s", want: ShutdownConfig{Timeout: 8 * time.Second}},
+		{name: "zero timeout", timeout: "0s", wantErr: true},
+		{name: "invalid timeout", timeout: "soon", wantErr: true},
+		{name: "negative drain delay", drainDelay: "-1s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvShutdownTimeout, tt.timeout)
+			t.Setenv(EnvShutdownDrainDelay, tt.drainDelay)
+			got, err := ShutdownConfigFromEnv(8 * time.Second)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ShutdownConfigFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ShutdownConfigFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDrainerHealthHandler(t *testing.T) {
+	d := NewDrainer()
+	h := d.HealthHandler(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status before shutdown = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	d.draining.Store(true)
+	rec = httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status while draining = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestDrainerShutdownWaitsForInFlight(t *testing.T) {
+	d := NewDrainer()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv := &http.Server{Handler: d.Handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	}))}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(ln)
+
+	type result struct {
+		body string
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		results <- result{body: string(body), err: err}
+	}()
+	<-started
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- d.Shutdown(context.Background(), srv, ShutdownConfig{Timeout: 5 * time.Second})
+	}()
+
+	// Shutdown must not return while the request is in flight
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("Shutdown() returned %v with a request in flight", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if !d.Draining() {
+		t.Error("expected Draining() once shutdown started")
+	}
+
+	close(release)
+	if err := <-shutdownErr; err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if r := <-results; r.err != nil || r.body != "done" {
+		t.Errorf("in-flight request = %q, %v; want it to complete", r.body, r.err)
+	}
+}
+
+func TestDrainerShutdownTimeout(t *testing.T) {
+	d := NewDrainer()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	srv := &http.Server{Handler: d.Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		close(started)
+		<-release
+	}))}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(ln)
+	go http.Get("http://" + ln.Addr().String())
+	<-started
+
+	if err := d.Shutdown(context.Background(), srv, ShutdownConfig{Timeout: 50 * time.Millisecond}); err == nil {
+		t.Fatal("Shutdown() expected an error when a request outlives the timeout")
+	}
+	if got := d.InFlight(); got != 1 {
+		t.Errorf("InFlight() = %d, want 1", got)
+	}
+}
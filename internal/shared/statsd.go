@@ -0,0 +1,263 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Environment variables for the StatsD metrics sink.
+const (
+	// EnvStatsDAddr is the host:port of the StatsD or DogStatsD server. When
+	// unset, DD_AGENT_HOST and DD_DOGSTATSD_PORT are used, as set for the
+	// Datadog agent, and the sink is disabled without either.
+	EnvStatsDAddr = "STATSD_ADDR"
+
+	// EnvStatsDPrefix replaces the octo_sts_ prefix of the metric names
+	// (default: "octo_sts.").
+	EnvStatsDPrefix = "STATSD_PREFIX"
+
+	// EnvStatsDTags are tags added to every metric, as comma-separated
+	// key:value pairs.
+	EnvStatsDTags = "STATSD_TAGS"
+
+	// EnvStatsDInterval is how often metrics are sent, as a duration like
+	// "10s" (default: 10s).
+	EnvStatsDInterval = "STATSD_INTERVAL"
+)
+
+// Datadog environment variables the StatsD sink honors, so the Datadog
+// agent's usual settings work unchanged.
+const (
+	envDDAgentHost     = "DD_AGENT_HOST"
+	envDDDogStatsDPort = "DD_DOGSTATSD_PORT"
+	envDDEnv           = "DD_ENV"
+	envDDService       = "DD_SERVICE"
+	envDDVersion       = "DD_VERSION"
+)
+
+const (
+	// DefaultStatsDInterval is how often metrics are sent when
+	// STATSD_INTERVAL is unset.
+	DefaultStatsDInterval = 10 * time.Second
+
+	// defaultStatsDPrefix replaces the octo_sts_ prefix of the metric names.
+	defaultStatsDPrefix = "octo_sts."
+
+	// defaultDogStatsDPort is the port of the Datadog agent's DogStatsD.
+	defaultDogStatsDPort = "8125"
+
+	// statsDMaxPacket keeps each UDP packet within a typical MTU.
+	statsDMaxPacket = 1432
+)
+
+// StatsDConfig configures a StatsD sink.
+type StatsDConfig struct {
+	// Addr is the host:port of the server.
+	Addr string
+
+	// Prefix replaces the octo_sts_ prefix of the metric names.
+	Prefix string
+
+	// Tags are added to every metric, as key:value pairs.
+	Tags []string
+
+	// Interval is how often metrics are sent.
+	Interval time.Duration
+}
+
+// StatsDConfigFromEnv returns the StatsD sink configuration from the
+// environment, and false if the sink is disabled.
+func StatsDConfigFromEnv() (StatsDConfig, bool, error) {
+	cfg := StatsDConfig{
+		Addr:     os.Getenv(EnvStatsDAddr),
+		Prefix:   GetEnvDefault(EnvStatsDPrefix, defaultStatsDPrefix),
+		Interval: DefaultStatsDInterval,
+	}
+	if cfg.Addr == "" {
+		host := os.Getenv(envDDAgentHost)
+		if host == "" {
+			return StatsDConfig{}, false, nil
+		}
+		cfg.Addr = net.JoinHostPort(host, GetEnvDefault(envDDDogStatsDPort, defaultDogStatsDPort))
+	}
+	if _, _, err := net.SplitHostPort(cfg.Addr); err != nil {
+		return StatsDConfig{}, false, fmt.Errorf("invalid %s %q: %w", EnvStatsDAddr, cfg.Addr, err)
+	}
+
+	if v := os.Getenv(EnvStatsDInterval); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return StatsDConfig{}, false, fmt.Errorf("invalid %s: %q", EnvStatsDInterval, v)
+		}
+		cfg.Interval = d
+	}
+
+	for _, tag := range strings.Split(os.Getenv(EnvStatsDTags), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			cfg.Tags = append(cfg.Tags, tag)
+		}
+	}
+	for env, key := range map[string]string{envDDEnv: "env", envDDService: "service", envDDVersion: "version"} {
+		if v := os.Getenv(env); v != "" {
+			cfg.Tags = append(cfg.Tags, key+":"+v)
+		}
+	}
+	sort.Strings(cfg.Tags)
+	return cfg, true, nil
+}
+
+// StatsD sends the octo_sts_ metrics of a Prometheus gatherer to a StatsD
+// server with DogStatsD tags, so the STS and webhook metrics reach Datadog
+// without scraping /metrics. Counters are sent as the increase since the
+// last send, gauges as their value, and histograms as the increase of their
+// count and sum. Labels become tags.
+type StatsD struct {
+	cfg      StatsDConfig
+	gatherer prometheus.Gatherer
+	conn     net.Conn
+	last     map[string]float64
+}
+
+// NewStatsD creates a sink that sends the metrics of gatherer per cfg.
+func NewStatsD(cfg StatsDConfig, gatherer prometheus.Gatherer) (*StatsD, error) {
+	conn, err := net.Dial("udp", cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to StatsD at %s: %w", cfg.Addr, err)
+	}
+	return &StatsD{cfg: cfg, gatherer: gatherer, conn: conn, last: map[string]float64{}}, nil
+}
+
+// ServeStatsD sends the metrics of the default Prometheus registry to the
+// StatsD server configured by the environment until ctx is done, when they
+// are sent a last time. It returns at once if the sink is disabled.
+func ServeStatsD(ctx context.Context) error {
+	cfg, enabled, err := StatsDConfigFromEnv()
+	if err != nil || !enabled {
+		return err
+	}
+	s, err := NewStatsD(cfg, prometheus.DefaultGatherer)
+	if err != nil {
+		return err
+	}
+	clog.FromContext(ctx).Infof("[statsd] sending metrics to %s every %s", cfg.Addr, cfg.Interval)
+	go s.Run(ctx)
+	return nil
+}
+
+// Run sends the metrics every interval until ctx is done, when they are
+// sent a last time.
+func (s *StatsD) Run(ctx context.Context) {
+	log := clog.FromContext(ctx)
+	defer s.conn.Close()
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := s.Flush(); err != nil {
+				log.Warnf("[statsd] failed to send metrics: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				log.Warnf("[statsd] failed to send metrics: %v", err)
+			}
+		}
+	}
+}
+
+// Flush sends the current metrics.
+func (s *StatsD) Flush() error {
+	families, err := s.gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	var lines []string
+	for _, f := range families {
+		name, ok := strings.CutPrefix(f.GetName(), "octo_sts_")
+		if !ok {
+			continue
+		}
+		name = s.cfg.Prefix + strings.TrimSuffix(name, "_total")
+		for _, m := range f.GetMetric() {
+			tags := s.tags(m)
+			switch f.GetType() {
+			case dto.MetricType_COUNTER:
+				lines = s.appendCount(lines, name, tags, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				lines = append(lines, statsDLine(name, m.GetGauge().GetValue(), "g", tags))
+			case dto.MetricType_HISTOGRAM:
+				lines = s.appendCount(lines, name+".count", tags, float64(m.GetHistogram().GetSampleCount()))
+				lines = s.appendCount(lines, name+".sum", tags, m.GetHistogram().GetSampleSum())
+			}
+		}
+	}
+	return s.send(lines)
+}
+
+// appendCount appends the increase of the counter name with tags since the
+// last flush to lines, if it increased.
+func (s *StatsD) appendCount(lines []string, name, tags string, value float64) []string {
+	key := name + "|" + tags
+	delta := value - s.last[key]
+	s.last[key] = value
+	if delta <= 0 {
+		return lines
+	}
+	return append(lines, statsDLine(name, delta, "c", tags))
+}
+
+// tags returns the DogStatsD tags of m: the configured tags and its labels.
+func (s *StatsD) tags(m *dto.Metric) string {
+	tags := append([]string(nil), s.cfg.Tags...)
+	for _, l := range m.GetLabel() {
+		tags = append(tags, l.GetName()+":"+l.GetValue())
+	}
+	return strings.Join(tags, ",")
+}
+
+// statsDLine formats a metric in the DogStatsD format.
+func statsDLine(name string, value float64, kind, tags string) string {
+	line := name + ":" + strconv.FormatFloat(value, 'f', -1, 64) + "|" + kind
+	if tags != "" {
+		line += "|#" + tags
+	}
+	return line
+}
+
+// send writes lines in as few packets as fit statsDMaxPacket.
+func (s *StatsD) send(lines []string) error {
+	var packet []byte
+	for _, line := range lines {
+		if len(packet) > 0 && len(packet)+1+len(line) > statsDMaxPacket {
+			if _, err := s.conn.Write(packet); err != nil {
+				return err
+			}
+			packet = packet[:0]
+		}
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		packet = append(packet, line...)
+	}
+	if len(packet) == 0 {
+		return nil
+	}
+	_, err := s.conn.Write(packet)
+	return err
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestStatsD(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	reg := prometheus.NewRegistry()
+	exchanges := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "octo_sts_exchanges_total"}, []string{"code"})
+	inFlight := prometheus.NewGauge(prometheus.GaugeOpts{Name: "octo_sts_in_flight_requests"})
+	duration := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "octo_sts_exchange_duration_seconds"})
+	other := prometheus.NewCounter(prometheus.CounterOpts{Name: "go_other_total"})
+	reg.MustRegister(exchanges, inFlight, duration, other)
+
+	s, err := NewStatsD(StatsDConfig{Addr: pc.LocalAddr().String(), Prefix: "octo_sts.", Tags: []string{"env:prod"}}, reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.conn.Close()
+
+	read := func() []string {
+		t.Helper()
+		buf := make([]byte, statsDMaxPacket)
+		_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(string(buf[:n]), "\n")
+		slices.Sort(lines)
+		return lines
+	}
+
+	exchanges.WithLabelValues("200").Add(3)
+	inFlight.Set(2)
+	duration.Observe(0.5)
+	other.Inc()
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"octo_sts.exchange_duration_seconds.count:1|c|#env:prod",
+		"octo_sts.exchange_duration_seconds.sum:0.5|c|#env:prod",
+		"octo_sts.exchanges:3|c|#env:prod,code:200",
+		"octo_sts.in_flight_requests:2|g|#env:prod",
+	}
+	if got := read(); !slices.Equal(got, want) {
+		t.Errorf("first flush = %q, want %q", got, want)
+	}
+
+	// Counters are sent as their increase since the last flush
+	exchanges.WithLabelValues("200").Inc()
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{
+		"octo_sts.exchanges:1|c|#env:prod,code:200",
+		"octo_sts.in_flight_requests:2|g|#env:prod",
+	}
+	if got := read(); !slices.Equal(got, want) {
+		t.Errorf("second flush = %q, want %q", got, want)
+	}
+}
+
+func TestStatsDConfigFromEnv(t *testing.T) {
+	if _, enabled, err := StatsDConfigFromEnv(); enabled || err != nil {
+		t.Errorf("StatsDConfigFromEnv() unset = %v, %v, want disabled", enabled, err)
+	}
+
+	t.Setenv(envDDAgentHost, "datadog-agent")
+	t.Setenv(envDDEnv, "prod")
+	t.Setenv(EnvStatsDTags, "team:platform, ")
+	cfg, enabled, err := StatsDConfigFromEnv()
+	if err != nil || !enabled {
+		t.Fatalf("StatsDConfigFromEnv() = %v, %v, want enabled", enabled, err)
+	}
+	if cfg.Addr != "datadog-agent:8125" || cfg.Interval != DefaultStatsDInterval || cfg.Prefix != "octo_sts." {
+		t.Errorf("StatsDConfigFromEnv() = %+v, want the Datadog agent", cfg)
+	}
+	if want := []string{"env:prod", "team:platform"}; !slices.Equal(cfg.Tags, want) {
+		t.Errorf("tags = %q, want %q", cfg.Tags, want)
+	}
+
+	t.Setenv(EnvStatsDAddr, "statsd:9125")
+	if cfg, _, _ := StatsDConfigFromEnv(); cfg.Addr != "statsd:9125" {
+		t.Errorf("Addr = %q, want STATSD_ADDR", cfg.Addr)
+	}
+
+	for env, value := range map[string]string{EnvStatsDAddr: "statsd", EnvStatsDInterval: "0s"} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv(env, value)
+			if _, _, err := StatsDConfigFromEnv(); err == nil {
+				t.Errorf("StatsDConfigFromEnv() with %s=%q succeeded", env, value)
+			}
+		})
+	}
+}
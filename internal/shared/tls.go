@@ -0,0 +1,95 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Environment variables for TLS configuration of the HTTP servers.
+const (
+	// EnvTLSCertFile and EnvTLSKeyFile serve a static certificate and key.
+	EnvTLSCertFile = "TLS_CERT_FILE"
+	EnvTLSKeyFile  = "TLS_KEY_FILE"
+
+	// EnvTLSAutocertHosts lists the comma-separated host names to obtain
+	// certificates for from an ACME CA such as Let's Encrypt.
+	EnvTLSAutocertHosts = "TLS_AUTOCERT_HOSTS"
+
+	// EnvTLSAutocertCacheDir is where ACME certificates and the account key
+	// are kept (default: DefaultAutocertCacheDir).
+	EnvTLSAutocertCacheDir = "TLS_AUTOCERT_CACHE_DIR"
+
+	// EnvTLSAutocertEmail is the contact address of the ACME account.
+	EnvTLSAutocertEmail = "TLS_AUTOCERT_EMAIL"
+
+	// EnvTLSAutocertDirectoryURL overrides the ACME directory, e.g. with the
+	// Let's Encrypt staging environment (default: Let's Encrypt production).
+	EnvTLSAutocertDirectoryURL = "TLS_AUTOCERT_DIRECTORY_URL"
+)
+
+// DefaultAutocertCacheDir is the default ACME certificate cache directory.
+const DefaultAutocertCacheDir = ".autocert"
+
+// TLSConfigFromEnv returns the TLS configuration of the HTTP servers, or nil
+// if TLS is not enabled. A static certificate is served with TLS_CERT_FILE
+// and TLS_KEY_FILE; with TLS_AUTOCERT_HOSTS, certificates for those hosts
+// are obtained and renewed through the TLS-ALPN-01 challenge, which requires
+// the server to be reachable on port 443.
+func TLSConfigFromEnv() (*tls.Config, error) {
+	certFile, keyFile := os.Getenv(EnvTLSCertFile), os.Getenv(EnvTLSKeyFile)
+	hosts := splitHosts(os.Getenv(EnvTLSAutocertHosts))
+
+	switch {
+	case (certFile != "" || keyFile != "") && len(hosts) > 0:
+		return nil, fmt.Errorf("%s and %s cannot be combined with %s", EnvTLSCertFile, EnvTLSKeyFile, EnvTLSAutocertHosts)
+
+	case certFile != "" || keyFile != "":
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("%s and %s must be set together", EnvTLSCertFile, EnvTLSKeyFile)
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		return &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{cert},
+		}, nil
+
+	case len(hosts) > 0:
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      autocert.DirCache(GetEnvDefault(EnvTLSAutocertCacheDir, DefaultAutocertCacheDir)),
+			HostPolicy: autocert.HostWhitelist(hosts...),
+			Email:      os.Getenv(EnvTLSAutocertEmail),
+		}
+		if directoryURL := os.Getenv(EnvTLSAutocertDirectoryURL); directoryURL != "" {
+			manager.Client = &acme.Client{DirectoryURL: directoryURL}
+		}
+		cfg := manager.TLSConfig()
+		cfg.MinVersion = tls.VersionTLS12
+		return cfg, nil
+
+	default:
+		return nil, nil
+	}
+}
+
+// splitHosts splits a comma-separated list of host names.
+func splitHosts(value string) []string {
+	var hosts []string
+	for _, h := range strings.Split(value, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts
+}
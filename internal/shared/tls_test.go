@@ -0,0 +1,121 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func writeCertificate(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "octo-sts.example.com"},
+		DNSNames:     []string{"octo-sts.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestTLSConfigFromEnv(t *testing.T) {
+	certFile, keyFile := writeCertificate(t)
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantTLS bool
+		wantErr bool
+	}{
+		{name: "disabled"},
+		{
+			name:    "static certificate",
+			env:     map[string]string{EnvTLSCertFile: certFile, EnvTLSKeyFile: keyFile},
+			wantTLS: true,
+		},
+		{
+			name:    "certificate without key",
+			env:     map[string]string{EnvTLSCertFile: certFile},
+			wantErr: true,
+		},
+		{
+			name:    "missing certificate",
+			env:     map[string]string{EnvTLSCertFile: certFile + ".missing", EnvTLSKeyFile: keyFile},
+			wantErr: true,
+		},
+		{
+			name:    "autocert",
+			env:     map[string]string{EnvTLSAutocertHosts: "octo-sts.example.com", EnvTLSAutocertCacheDir: t.TempDir()},
+			wantTLS: true,
+		},
+		{
+			name:    "static certificate and autocert",
+			env:     map[string]string{EnvTLSCertFile: certFile, EnvTLSKeyFile: keyFile, EnvTLSAutocertHosts: "octo-sts.example.com"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{EnvTLSCertFile, EnvTLSKeyFile, EnvTLSAutocertHosts, EnvTLSAutocertCacheDir} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			cfg, err := TLSConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TLSConfigFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (cfg != nil) != tt.wantTLS {
+				t.Errorf("TLSConfigFromEnv() = %v, want TLS %v", cfg, tt.wantTLS)
+			}
+		})
+	}
+}
+
+func TestTLSConfigFromEnvAutocertALPN(t *testing.T) {
+	t.Setenv(EnvTLSAutocertHosts, "octo-sts.example.com, sts.example.com")
+	t.Setenv(EnvTLSAutocertCacheDir, t.TempDir())
+
+	cfg, err := TLSConfigFromEnv()
+	if err != nil {
+		t.Fatalf("TLSConfigFromEnv() error = %v", err)
+	}
+	if cfg.GetCertificate == nil {
+		t.Error("expected autocert to provide certificates")
+	}
+	if !slices.Contains(cfg.NextProtos, "acme-tls/1") {
+		t.Errorf("NextProtos = %v, want the TLS-ALPN-01 protocol", cfg.NextProtos)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Environment variables for the trust policies a warm-up reads into the
+// cache besides the installations.
+const (
+	// EnvWarmUpTrustPolicies lists trust policies to read on every warm-up,
+	// comma-separated, each as scope:identity, e.g. "org/repo:ci,org:deploy".
+	EnvWarmUpTrustPolicies = "WARMUP_TRUST_POLICIES"
+
+	// EnvWarmUpRecentTrustPolicies is how many of the most recently used
+	// trust policies a warm-up re-reads (default: 20). 0 turns it off.
+	EnvWarmUpRecentTrustPolicies = "WARMUP_RECENT_TRUST_POLICIES"
+)
+
+// DefaultWarmUpRecentTrustPolicies is the default of
+// WARMUP_RECENT_TRUST_POLICIES.
+const DefaultWarmUpRecentTrustPolicies = 20
+
+// WarmUpConfig selects the trust policies a warm-up reads.
+type WarmUpConfig struct {
+	// TrustPolicies are read on every warm-up, as scope:identity.
+	TrustPolicies []string
+
+	// RecentTrustPolicies is how many of the most recently used trust
+	// policies are re-read.
+	RecentTrustPolicies int
+}
+
+// WarmUpConfigFromEnv returns the warm-up configuration from the WARMUP_*
+// environment variables.
+func WarmUpConfigFromEnv() (WarmUpConfig, error) {
+	cfg := WarmUpConfig{RecentTrustPolicies: DefaultWarmUpRecentTrustPolicies}
+	for _, s := range strings.Split(os.Getenv(EnvWarmUpTrustPolicies), ",") {
+		ref := strings.TrimSpace(s)
+		if ref == "" {
+			continue
+		}
+		if scope, identity, ok := strings.Cut(ref, ":"); !ok || scope == "" || identity == "" {
+			return WarmUpConfig{}, fmt.Errorf("invalid %s: %q is not scope:identity", EnvWarmUpTrustPolicies, ref)
+		}
+		cfg.TrustPolicies = append(cfg.TrustPolicies, ref)
+	}
+
+	if v := os.Getenv(EnvWarmUpRecentTrustPolicies); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return WarmUpConfig{}, fmt.Errorf("invalid %s: %q", EnvWarmUpRecentTrustPolicies, v)
+		}
+		cfg.RecentTrustPolicies = n
+	}
+	return cfg, nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWarmUpConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies string
+		recent   string
+		want     WarmUpConfig
+		wantErr  bool
+	}{
+		{
+			name: "defaults",
+			want: WarmUpConfig{RecentTrustPolicies: DefaultWarmUpRecentTrustPolicies},
+		},
+		{
+			name:     "policies",
+			policies: "org/repo:ci, org:deploy,",
+			recent:   "0",
+			want:     WarmUpConfig{TrustPolicies: []string{"org/repo:ci", "org:deploy"}},
+		},
+		{name: "missing identity", policies: "org/repo", wantErr: true},
+		{name: "empty scope", policies: ":ci", wantErr: true},
+		{name: "negative recent", recent: "-1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvWarmUpTrustPolicies, tt.policies)
+			t.Setenv(EnvWarmUpRecentTrustPolicies, tt.recent)
+			got, err := WarmUpConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WarmUpConfigFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WarmUpConfigFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"context"
+	"errors"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+)
+
+// WatchStore calls reload whenever the config store reports a change made
+// outside this process, such as credentials rotated out-of-band. It does
+// nothing if STORAGE_WATCH is false or the store can't be watched, and stops
+// when ctx is done.
+func WatchStore(ctx context.Context, store configstore.Store, reload func()) {
+	log := clog.FromContext(ctx)
+	if store == nil || !configstore.WatchEnabled() {
+		return
+	}
+
+	changes, err := configstore.Watch(ctx, store)
+	if errors.Is(err, configstore.ErrWatchNotSupported) {
+		log.Debugf("[config] config store does not support watching for changes")
+		return
+	}
+	if err != nil {
+		log.Warnf("[config] failed to watch config store, credential changes require a restart or SIGHUP: %v", err)
+		return
+	}
+
+	log.Infof("[config] watching config store for credential changes")
+	go func() {
+		for range changes {
+			log.Infof("[config] config store changed, reloading configuration")
+			reload()
+		}
+	}()
+}
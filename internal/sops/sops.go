@@ -0,0 +1,407 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package sops reads and writes flat SOPS-encrypted YAML and JSON documents,
+// compatible with the sops CLI, using AWS KMS, age, and PGP master keys.
+package sops
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"golang.org/x/crypto/openpgp"
+	pgparmor "golang.org/x/crypto/openpgp/armor"
+	"sigs.k8s.io/yaml"
+
+	// openpgp falls back to RIPEMD160 for keys without hash preferences.
+	_ "golang.org/x/crypto/ripemd160"
+)
+
+// Version is the sops format version written to the metadata.
+const Version = "3.9.0"
+
+// UnencryptedSuffix marks keys whose values are stored in plaintext.
+const UnencryptedSuffix = "_unencrypted"
+
+// metadataKey is the top-level key holding the sops metadata.
+const metadataKey = "sops"
+
+// ivSize matches the 256-bit nonce sops uses with AES-GCM.
+const ivSize = 32
+
+// ErrNoDataKey is returned when none of the configured keys can decrypt the
+// document's data key.
+var ErrNoDataKey = errors.New("no configured key could decrypt the sops data key")
+
+var encryptedValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+// Format is the document format.
+type Format int
+
+const (
+	// FormatYAML writes YAML documents.
+	FormatYAML Format = iota
+	// FormatJSON writes JSON documents.
+	FormatJSON
+)
+
+// FormatForPath returns FormatJSON for .json files and FormatYAML otherwise.
+func FormatForPath(path string) Format {
+	if strings.HasSuffix(strings.ToLower(path), ".json") {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// KMSClient defines the AWS KMS operations used to wrap the data key.
+type KMSClient interface {
+	Encrypt(ctx context.Context, params *kms.EncryptInput,
+		optFns ...func(*kms.Options)) (*kms.EncryptOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput,
+		optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// Keys are the master keys a document's data key is encrypted to. Any one
+// key that is able to decrypt is sufficient to read a document.
+type Keys struct {
+	KMSARNs   []string
+	KMSClient KMSClient
+
+	AgeRecipients []age.Recipient
+	AgeIdentities []age.Identity
+
+	// PGPKeys are used for encryption with their public keys and for
+	// decryption when they include decrypted private keys.
+	PGPKeys openpgp.EntityList
+}
+
+type metadata struct {
+	KMS               []kmsKey `json:"kms,omitempty"`
+	Age               []ageKey `json:"age,omitempty"`
+	PGP               []pgpKey `json:"pgp,omitempty"`
+	LastModified      string   `json:"lastmodified"`
+	MAC               string   `json:"mac"`
+	UnencryptedSuffix string   `json:"unencrypted_suffix"`
+	Version           string   `json:"version"`
+}
+
+type kmsKey struct {
+	ARN        string `json:"arn"`
+	CreatedAt  string `json:"created_at"`
+	Enc        string `json:"enc"`
+	AWSProfile string `json:"aws_profile"`
+}
+
+type ageKey struct {
+	Recipient string `json:"recipient"`
+	Enc       string `json:"enc"`
+}
+
+type pgpKey struct {
+	CreatedAt string `json:"created_at"`
+	Enc       string `json:"enc"`
+	FP        string `json:"fp"`
+}
+
+// Encrypt encrypts values into a sops document. Keys are written in sorted
+// order, which is also the order the MAC is computed in.
+func Encrypt(ctx context.Context, values map[string]string, format Format, keys Keys) ([]byte, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	meta := metadata{
+		LastModified:      now.Format(time.RFC3339),
+		UnencryptedSuffix: UnencryptedSuffix,
+		Version:           Version,
+	}
+	if err := keys.wrap(ctx, dataKey, now, &meta); err != nil {
+		return nil, err
+	}
+
+	doc := make(map[string]any, len(values)+1)
+	mac := sha512.New()
+	for _, key := range sortedKeys(values) {
+		value := values[key]
+		mac.Write([]byte(value))
+		if strings.HasSuffix(key, UnencryptedSuffix) {
+			doc[key] = value
+			continue
+		}
+		enc, err := encryptValue(dataKey, value, key+":")
+		if err != nil {
+			return nil, err
+		}
+		doc[key] = enc
+	}
+
+	encMAC, err := encryptValue(dataKey, fmt.Sprintf("%X", mac.Sum(nil)), meta.LastModified)
+	if err != nil {
+		return nil, err
+	}
+	meta.MAC = encMAC
+	doc[metadataKey] = meta
+
+	if format == FormatJSON {
+		return json.MarshalIndent(doc, "", "\t")
+	}
+	return yaml.Marshal(doc)
+}
+
+// Decrypt decrypts a sops document and verifies its MAC. Only flat
+// documents with string values are supported.
+func Decrypt(ctx context.Context, data []byte, keys Keys) (map[string]string, error) {
+	var doc map[string]json.RawMessage
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid sops document: %w", err)
+	}
+
+	rawMeta, ok := doc[metadataKey]
+	if !ok {
+		return nil, fmt.Errorf("invalid sops document: missing %q metadata", metadataKey)
+	}
+	var meta metadata
+	if err := json.Unmarshal(rawMeta, &meta); err != nil {
+		return nil, fmt.Errorf("invalid sops metadata: %w", err)
+	}
+	delete(doc, metadataKey)
+
+	dataKey, err := keys.unwrap(ctx, &meta)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(doc))
+	mac := sha512.New()
+	for _, key := range sortedKeys(doc) {
+		var raw string
+		if err := json.Unmarshal(doc[key], &raw); err != nil {
+			return nil, fmt.Errorf("value %s is not a string", key)
+		}
+		value := raw
+		if !strings.HasSuffix(key, meta.UnencryptedSuffix) || meta.UnencryptedSuffix == "" {
+			if value, err = decryptValue(dataKey, raw, key+":"); err != nil {
+				return nil, fmt.Errorf("failed to decrypt %s: %w", key, err)
+			}
+		}
+		mac.Write([]byte(value))
+		values[key] = value
+	}
+
+	storedMAC, err := decryptValue(dataKey, meta.MAC, meta.LastModified)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt MAC: %w", err)
+	}
+	if storedMAC != fmt.Sprintf("%X", mac.Sum(nil)) {
+		return nil, fmt.Errorf("MAC mismatch: the document was modified outside sops")
+	}
+	return values, nil
+}
+
+// wrap encrypts the data key to every configured master key.
+func (k Keys) wrap(ctx context.Context, dataKey []byte, now time.Time, meta *metadata) error {
+	for _, arn := range k.KMSARNs {
+		if k.KMSClient == nil {
+			return fmt.Errorf("kms client is required to encrypt to %s", arn)
+		}
+		out, err := k.KMSClient.Encrypt(ctx, &kms.EncryptInput{KeyId: aws.String(arn), Plaintext: dataKey})
+		if err != nil {
+			return fmt.Errorf("failed to encrypt data key with %s: %w", arn, err)
+		}
+		meta.KMS = append(meta.KMS, kmsKey{
+			ARN:       arn,
+			CreatedAt: now.Format(time.RFC3339),
+			Enc:       base64.StdEncoding.EncodeToString(out.CiphertextBlob),
+		})
+	}
+
+	for _, recipient := range k.AgeRecipients {
+		var buf bytes.Buffer
+		armored := armor.NewWriter(&buf)
+		w, err := age.Encrypt(armored, recipient)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt data key with age: %w", err)
+		}
+		if _, err := w.Write(dataKey); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		if err := armored.Close(); err != nil {
+			return err
+		}
+		meta.Age = append(meta.Age, ageKey{Recipient: fmt.Sprint(recipient), Enc: buf.String()})
+	}
+
+	for _, entity := range k.PGPKeys {
+		var buf bytes.Buffer
+		armored, err := pgparmor.Encode(&buf, "PGP MESSAGE", nil)
+		if err != nil {
+			return err
+		}
+		w, err := openpgp.Encrypt(armored, openpgp.EntityList{entity}, nil, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt data key with pgp: %w", err)
+		}
+		if _, err := w.Write(dataKey); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		if err := armored.Close(); err != nil {
+			return err
+		}
+		meta.PGP = append(meta.PGP, pgpKey{
+			CreatedAt: now.Format(time.RFC3339),
+			Enc:       buf.String(),
+			FP:        fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint),
+		})
+	}
+
+	if len(meta.KMS)+len(meta.Age)+len(meta.PGP) == 0 {
+		return fmt.Errorf("at least one kms, age, or pgp key is required")
+	}
+	return nil
+}
+
+// unwrap decrypts the data key with the first master key that can.
+func (k Keys) unwrap(ctx context.Context, meta *metadata) ([]byte, error) {
+	var errs []error
+
+	if k.KMSClient != nil {
+		for _, entry := range meta.KMS {
+			blob, err := base64.StdEncoding.DecodeString(entry.Enc)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("kms %s: %w", entry.ARN, err))
+				continue
+			}
+			out, err := k.KMSClient.Decrypt(ctx, &kms.DecryptInput{KeyId: aws.String(entry.ARN), CiphertextBlob: blob})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("kms %s: %w", entry.ARN, err))
+				continue
+			}
+			return out.Plaintext, nil
+		}
+	}
+
+	if len(k.AgeIdentities) > 0 {
+		for _, entry := range meta.Age {
+			r, err := age.Decrypt(armor.NewReader(strings.NewReader(entry.Enc)), k.AgeIdentities...)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("age %s: %w", entry.Recipient, err))
+				continue
+			}
+			return io.ReadAll(r)
+		}
+	}
+
+	if len(k.PGPKeys.DecryptionKeys()) > 0 {
+		for _, entry := range meta.PGP {
+			block, err := pgparmor.Decode(strings.NewReader(entry.Enc))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("pgp %s: %w", entry.FP, err))
+				continue
+			}
+			md, err := openpgp.ReadMessage(block.Body, k.PGPKeys, nil, nil)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("pgp %s: %w", entry.FP, err))
+				continue
+			}
+			return io.ReadAll(md.UnverifiedBody)
+		}
+	}
+
+	return nil, errors.Join(append([]error{ErrNoDataKey}, errs...)...)
+}
+
+// encryptValue encrypts a string value in the sops ENC[...] format, bound to
+// the given additional data.
+func encryptValue(dataKey []byte, value, additionalData string) (string, error) {
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, ivSize)
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nil, iv, []byte(value), []byte(additionalData))
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:str]",
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(tag)), nil
+}
+
+// decryptValue decrypts a value in the sops ENC[...] format.
+func decryptValue(dataKey []byte, value, additionalData string) (string, error) {
+	match := encryptedValue.FindStringSubmatch(value)
+	if match == nil {
+		return "", fmt.Errorf("value is not sops-encrypted")
+	}
+	if match[4] != "str" {
+		return "", fmt.Errorf("unsupported value type %q", match[4])
+	}
+
+	var parts [3][]byte
+	for i := range parts {
+		decoded, err := base64.StdEncoding.DecodeString(match[i+1])
+		if err != nil {
+			return "", err
+		}
+		parts[i] = decoded
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+	if len(iv) != gcm.NonceSize() {
+		return "", fmt.Errorf("invalid iv size %d", len(iv))
+	}
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCMWithNonceSize(block, ivSize)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,163 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/cruxstack/octo-sts-distros/internal/azure"
+)
+
+// azureRefPattern matches azkv://<vault>/<secret>[#<key>].
+var azureRefPattern = regexp.MustCompile(`^azkv://([A-Za-z0-9.-]+)/([0-9A-Za-z-]{1,127})(?:#(.+))?$`)
+
+// AzureSecretClient defines the interface for Azure Key Vault reads.
+type AzureSecretClient interface {
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// AzureResolver resolves Azure Key Vault references of the form
+// azkv://<vault>/<secret>, where <vault> is the vault name, or its host for
+// clouds other than the public one. A #<key> suffix selects a field of a
+// JSON secret, such as one written by the azure-keyvault configstore backend.
+type AzureResolver struct {
+	// Concurrency bounds the secrets read at once. Zero uses
+	// DefaultConcurrency.
+	Concurrency int
+
+	newClient func(vaultURI string) (AzureSecretClient, error)
+
+	mu      sync.Mutex
+	clients map[string]AzureSecretClient
+}
+
+// NewAzureResolver creates an AzureResolver authenticated with the managed
+// identity selected by AZURE_CLIENT_ID, or the system-assigned identity.
+func NewAzureResolver() *AzureResolver {
+	tokens := azure.NewManagedIdentity(os.Getenv(azure.EnvAzureClientID))
+	return NewAzureResolverWithClients(func(vaultURI string) (AzureSecretClient, error) {
+		return azure.NewKeyVaultClient(vaultURI, tokens)
+	})
+}
+
+// NewAzureResolverWithClients creates an AzureResolver that creates the
+// client of each vault with newClient.
+func NewAzureResolverWithClients(newClient func(vaultURI string) (AzureSecretClient, error)) *AzureResolver {
+	return &AzureResolver{newClient: newClient, clients: make(map[string]AzureSecretClient)}
+}
+
+// IsAzureRef checks if the given value is an Azure Key Vault reference.
+func IsAzureRef(value string) bool {
+	_, _, _, ok := ParseAzureRef(value)
+	return ok
+}
+
+// ParseAzureRef splits an Azure Key Vault reference into the vault URI, the
+// secret name, and the JSON key, which is empty when the whole secret is
+// referenced.
+func ParseAzureRef(ref string) (vaultURI, name, key string, ok bool) {
+	matches := azureRefPattern.FindStringSubmatch(normalizeRef(ref))
+	if len(matches) != 4 {
+		return "", "", "", false
+	}
+	host := matches[1]
+	if !strings.Contains(host, ".") {
+		host += ".vault.azure.net"
+	}
+	return "https://" + host, matches[2], matches[3], true
+}
+
+// IsRef reports whether value is an Azure Key Vault reference.
+func (r *AzureResolver) IsRef(value string) bool {
+	return IsAzureRef(value)
+}
+
+// client returns the client of the vault at vaultURI.
+func (r *AzureResolver) client(vaultURI string) (AzureSecretClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c, ok := r.clients[vaultURI]; ok {
+		return c, nil
+	}
+	c, err := r.newClient(vaultURI)
+	if err != nil {
+		return nil, err
+	}
+	r.clients[vaultURI] = c
+	return c, nil
+}
+
+// ResolveRefs resolves refs, keyed by environment variable name, and sets
+// the variables to their values. Each secret is read once, up to Concurrency
+// at a time. A reference that fails to resolve is reported as a *RefError,
+// joined with the others, and the rest are still resolved.
+func (r *AzureResolver) ResolveRefs(ctx context.Context, refs map[string]string) error {
+	secrets := make(map[string]string)
+	failed := make(map[string]error)
+
+	// Read the secrets up front and concurrently.
+	type secretRef struct{ vaultURI, name string }
+	var reads []secretRef
+	for _, ref := range refs {
+		if vaultURI, name, _, ok := ParseAzureRef(ref); ok && !slices.Contains(reads, secretRef{vaultURI, name}) {
+			reads = append(reads, secretRef{vaultURI, name})
+		}
+	}
+	var mu sync.Mutex
+	forEach(r.Concurrency, reads, func(s secretRef) {
+		c, err := r.client(s.vaultURI)
+		var secret string
+		if err == nil {
+			secret, err = c.GetSecret(ctx, s.name)
+		}
+
+		id := s.vaultURI + "/secrets/" + s.name
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			failed[id] = fmt.Errorf("failed to get %s: %w", id, err)
+			return
+		}
+		secrets[id] = secret
+	})
+
+	var errs []error
+	for key, ref := range refs {
+		value, err := r.resolveRef(ref, secrets, failed)
+		if err != nil {
+			errs = append(errs, &RefError{Key: key, Ref: ref, Err: err})
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resolveRef resolves a reference from the secrets read into secrets, or the
+// reads that failed.
+func (r *AzureResolver) resolveRef(ref string, secrets map[string]string, failed map[string]error) (string, error) {
+	vaultURI, name, key, ok := ParseAzureRef(ref)
+	if !ok {
+		return "", fmt.Errorf("invalid Azure Key Vault reference: %s", ref)
+	}
+	id := vaultURI + "/secrets/" + name
+	if err, ok := failed[id]; ok {
+		return "", err
+	}
+	secret := secrets[id]
+	if key == "" {
+		return secret, nil
+	}
+	return jsonField("secret "+id, secret, key)
+}
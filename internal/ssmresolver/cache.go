@@ -0,0 +1,209 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables configuring the cache of ResolveEnvironmentWithDefaults.
+const (
+	// EnvCacheTTL is how long resolved values are reused, e.g. 5m. Caching is
+	// off when unset, so every reload reads the values again.
+	EnvCacheTTL = "SSM_RESOLVER_CACHE_TTL"
+	// EnvRefreshInterval enables a background refresh of the cached values
+	// at this interval, so reloads don't wait for AWS. Requires EnvCacheTTL
+	// and should be shorter than it.
+	EnvRefreshInterval = "SSM_RESOLVER_REFRESH_INTERVAL"
+)
+
+// Cache key prefixes, so parameter names and secret IDs cannot collide.
+const (
+	cacheParamPrefix  = "ssm:"
+	cacheSecretPrefix = "secretsmanager:"
+)
+
+// Option configures an AWSResolver.
+type Option func(*AWSResolver)
+
+// WithCacheTTL reuses resolved values for ttl instead of reading them again
+// on every resolution. Zero disables the cache.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(r *AWSResolver) {
+		if ttl > 0 {
+			r.cache = &cache{ttl: ttl, entries: make(map[string]cacheEntry)}
+		} else {
+			r.cache = nil
+		}
+	}
+}
+
+// cache holds resolved values until they expire.
+type cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	value string
+	// list is set for StringList parameters.
+	list    bool
+	expires time.Time
+}
+
+// get returns the cached entry of key if it has not expired.
+func (c *cache) get(key string, now time.Time) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !now.Before(entry.expires) {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// set caches entry under key for the TTL.
+func (c *cache) set(key string, entry cacheEntry, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.expires = now.Add(c.ttl)
+	c.entries[key] = entry
+}
+
+// keys returns the keys of all cached values, expired or not.
+func (c *cache) keys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := make([]string, 0, len(c.entries))
+	for key := range c.entries {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// cached copies the unexpired cached values into f, so they are not read
+// again.
+func (r *AWSResolver) cached(f *fetched) {
+	if r.cache == nil {
+		return
+	}
+	now := r.now()
+	for _, key := range r.cache.keys() {
+		entry, ok := r.cache.get(key, now)
+		if !ok {
+			continue
+		}
+		if name, ok := strings.CutPrefix(key, cacheParamPrefix); ok {
+			f.params[name] = entry.value
+			f.lists[name] = entry.list
+		} else if secretID, ok := strings.CutPrefix(key, cacheSecretPrefix); ok {
+			f.secrets[secretID] = entry.value
+		}
+		f.cached[key] = true
+	}
+}
+
+// store caches the values in f that were read from AWS. Values taken from
+// the cache keep their expiry, so reloads do not extend it.
+func (r *AWSResolver) store(f *fetched) {
+	if r.cache == nil {
+		return
+	}
+	now := r.now()
+	set := func(key string, entry cacheEntry) {
+		if !f.cached[key] {
+			r.cache.set(key, entry, now)
+		}
+	}
+	for name, value := range f.params {
+		set(cacheParamPrefix+name, cacheEntry{value: value, list: f.lists[name]})
+	}
+	for secretID, value := range f.secrets {
+		set(cacheSecretPrefix+secretID, cacheEntry{value: value})
+	}
+}
+
+// Refresh reads all cached values again and renews their TTL. Values that
+// fail to refresh stay cached until they expire. It is a no-op when caching
+// is off.
+func (r *AWSResolver) Refresh(ctx context.Context) error {
+	if r.cache == nil {
+		return nil
+	}
+
+	f := newFetched()
+	var names, secretIDs []string
+	for _, key := range r.cache.keys() {
+		if name, ok := strings.CutPrefix(key, cacheParamPrefix); ok {
+			names = append(names, name)
+		} else if secretID, ok := strings.CutPrefix(key, cacheSecretPrefix); ok {
+			secretIDs = append(secretIDs, secretID)
+		}
+	}
+	// Values read besides the ones that fail are still renewed.
+	var errs []error
+	if len(names) > 0 {
+		if err := r.getParameters(ctx, names, f); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(secretIDs) > 0 {
+		if err := r.getSecrets(ctx, secretIDs, f); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	r.store(f)
+	return errors.Join(errs...)
+}
+
+// StartRefresh refreshes the cached values every interval until ctx is
+// done. Refresh errors are dropped; the affected values expire and are read
+// again by the next resolution, which reports the error.
+func (r *AWSResolver) StartRefresh(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_ = r.Refresh(ctx)
+			}
+		}
+	}()
+}
+
+// cacheOptionsFromEnv reads SSM_RESOLVER_CACHE_TTL and
+// SSM_RESOLVER_REFRESH_INTERVAL.
+func cacheOptionsFromEnv() (ttl, refresh time.Duration, err error) {
+	if value := os.Getenv(EnvCacheTTL); value != "" {
+		ttl, err = time.ParseDuration(value)
+		if err != nil || ttl < 0 {
+			return 0, 0, fmt.Errorf("invalid %s %q: must be a duration such as 5m", EnvCacheTTL, value)
+		}
+	}
+	if value := os.Getenv(EnvRefreshInterval); value != "" {
+		refresh, err = time.ParseDuration(value)
+		if err != nil || refresh <= 0 {
+			return 0, 0, fmt.Errorf("invalid %s %q: must be a positive duration such as 1m", EnvRefreshInterval, value)
+		}
+		if ttl == 0 {
+			return 0, 0, fmt.Errorf("%s requires %s to be set", EnvRefreshInterval, EnvCacheTTL)
+		}
+	}
+	return ttl, refresh, nil
+}
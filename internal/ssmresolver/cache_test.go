@@ -0,0 +1,120 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestResolverCache(t *testing.T) {
+	ssmClient := &fakeSSM{params: map[string]string{"/octo-sts/GITHUB_APP_ID": "1234"}}
+	secrets := &fakeSecretsManager{secrets: map[string]string{testSecretARN: `{"GITHUB_APP_PRIVATE_KEY":"pem"}`}}
+	resolver := NewWithClients(ssmClient, secrets, WithCacheTTL(time.Minute))
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	resolver.now = func() time.Time { return now }
+
+	t.Setenv("TEST_AWS_CACHED_APP_ID", "ssm:///octo-sts/GITHUB_APP_ID")
+	t.Setenv("TEST_AWS_CACHED_KEY", testSecretARN+"#GITHUB_APP_PRIVATE_KEY")
+	t.Cleanup(func() {
+		resolved.Lock()
+		delete(resolved.refs, "TEST_AWS_CACHED_APP_ID")
+		delete(resolved.refs, "TEST_AWS_CACHED_KEY")
+		resolved.Unlock()
+	})
+
+	ctx := context.Background()
+	if err := resolver.ResolveEnvironment(ctx); err != nil {
+		t.Fatalf("ResolveEnvironment() error = %v", err)
+	}
+
+	// Within the TTL, reloads reuse the cached values.
+	ssmClient.params["/octo-sts/GITHUB_APP_ID"] = "5678"
+	now = now.Add(30 * time.Second)
+	if err := resolver.ResolveEnvironment(ctx); err != nil {
+		t.Fatalf("ResolveEnvironment() error = %v", err)
+	}
+	if len(ssmClient.calls) != 1 || secrets.reads != 1 {
+		t.Errorf("expected cached values to be reused, got %d SSM calls and %d secret reads", len(ssmClient.calls), secrets.reads)
+	}
+
+	// Once expired, values are read again. Reloads from the cache don't
+	// extend the TTL.
+	now = now.Add(30 * time.Second)
+	if err := resolver.ResolveEnvironment(ctx); err != nil {
+		t.Fatalf("ResolveEnvironment() error = %v", err)
+	}
+	if got := os.Getenv("TEST_AWS_CACHED_APP_ID"); got != "5678" {
+		t.Errorf("TEST_AWS_CACHED_APP_ID = %q, want %q", got, "5678")
+	}
+	if secrets.reads != 2 {
+		t.Errorf("expected the expired secret to be read again, got %d reads", secrets.reads)
+	}
+}
+
+func TestResolverRefresh(t *testing.T) {
+	ssmClient := &fakeSSM{params: map[string]string{"/octo-sts/GITHUB_APP_ID": "1234"}}
+	secrets := &fakeSecretsManager{secrets: map[string]string{testSecretARN: "secret"}}
+	resolver := NewWithClients(ssmClient, secrets, WithCacheTTL(time.Minute))
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	resolver.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	for _, ref := range []string{"ssm:///octo-sts/GITHUB_APP_ID", testSecretARN} {
+		if _, err := resolver.ResolveValue(ctx, ref); err != nil {
+			t.Fatalf("ResolveValue(%q) error = %v", ref, err)
+		}
+	}
+
+	ssmClient.params["/octo-sts/GITHUB_APP_ID"] = "5678"
+	secrets.secrets[testSecretARN] = "rotated"
+	now = now.Add(45 * time.Second)
+	if err := resolver.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	// The refreshed values are served from the cache past the original TTL.
+	now = now.Add(45 * time.Second)
+	calls, reads := len(ssmClient.calls), secrets.reads
+	for ref, want := range map[string]string{"ssm:///octo-sts/GITHUB_APP_ID": "5678", testSecretARN: "rotated"} {
+		got, err := resolver.ResolveValue(ctx, ref)
+		if err != nil {
+			t.Fatalf("ResolveValue(%q) error = %v", ref, err)
+		}
+		if got != want {
+			t.Errorf("ResolveValue(%q) = %q, want %q", ref, got, want)
+		}
+	}
+	if len(ssmClient.calls) != calls || secrets.reads != reads {
+		t.Error("expected refreshed values to be served from the cache")
+	}
+
+	// A failed refresh keeps the cached values.
+	delete(ssmClient.params, "/octo-sts/GITHUB_APP_ID")
+	if err := resolver.Refresh(ctx); err == nil {
+		t.Error("expected Refresh() to report the missing parameter")
+	}
+	if got, err := resolver.ResolveValue(ctx, "ssm:///octo-sts/GITHUB_APP_ID"); err != nil || got != "5678" {
+		t.Errorf("ResolveValue() = (%q, %v), want cached value", got, err)
+	}
+}
+
+func TestCacheOptionsFromEnv(t *testing.T) {
+	t.Setenv(EnvCacheTTL, "")
+	t.Setenv(EnvRefreshInterval, "1m")
+	if _, _, err := cacheOptionsFromEnv(); err == nil {
+		t.Errorf("expected error for %s without %s", EnvRefreshInterval, EnvCacheTTL)
+	}
+
+	t.Setenv(EnvCacheTTL, "5m")
+	ttl, refresh, err := cacheOptionsFromEnv()
+	if err != nil {
+		t.Fatalf("cacheOptionsFromEnv() error = %v", err)
+	}
+	if ttl != 5*time.Minute || refresh != time.Minute {
+		t.Errorf("cacheOptionsFromEnv() = (%v, %v), want (5m, 1m)", ttl, refresh)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// Environment variables configuring the role ResolveEnvironmentWithDefaults
+// assumes, e.g. to read parameters shared from a central security account.
+const (
+	EnvAssumeRoleARN        = "SSM_RESOLVER_ASSUME_ROLE_ARN"
+	EnvAssumeRoleExternalID = "SSM_RESOLVER_ASSUME_ROLE_EXTERNAL_ID"
+)
+
+// assumeRoleSessionName is the session name used when assuming the role.
+const assumeRoleSessionName = "octo-sts-ssmresolver"
+
+// WithAssumeRole reads the references with credentials for roleARN, assumed
+// with the default AWS credentials. It only applies to New.
+func WithAssumeRole(roleARN string) Option {
+	return func(r *AWSResolver) {
+		r.roleARN = roleARN
+	}
+}
+
+// WithAssumeRoleExternalID sets the external ID required by the trust policy
+// of the role set with WithAssumeRole.
+func WithAssumeRoleExternalID(externalID string) Option {
+	return func(r *AWSResolver) {
+		r.externalID = externalID
+	}
+}
+
+// clientSet holds the clients for one region.
+type clientSet struct {
+	ssm     SSMClient
+	secrets SecretsManagerClient
+	paths   SSMPathClient
+}
+
+// regionalClients returns a function creating clients from cfg for a
+// region, reusing them across calls. The empty region and the region of cfg
+// share the same clients.
+func regionalClients(cfg aws.Config) func(region string) clientSet {
+	var mu sync.Mutex
+	clients := make(map[string]clientSet)
+
+	return func(region string) clientSet {
+		if region == "" {
+			region = cfg.Region
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if c, ok := clients[region]; ok {
+			return c
+		}
+		regionCfg := cfg.Copy()
+		regionCfg.Region = region
+		ssmClient := ssm.NewFromConfig(regionCfg)
+		c := clientSet{
+			ssm:     ssmClient,
+			secrets: secretsmanager.NewFromConfig(regionCfg),
+			paths:   ssmClient,
+		}
+		clients[region] = c
+		return c
+	}
+}
+
+// regionOf returns the region of an ARN, or "" for a name.
+func regionOf(id string) string {
+	parsed, err := arn.Parse(id)
+	if err != nil {
+		return ""
+	}
+	return parsed.Region
+}
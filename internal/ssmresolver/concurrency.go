@@ -0,0 +1,57 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// EnvConcurrency sets how many reads ResolveEnvironmentWithDefaults runs at
+// once per resolver.
+const EnvConcurrency = "SSM_RESOLVER_CONCURRENCY"
+
+// DefaultConcurrency is the default number of reads a resolver runs at once.
+const DefaultConcurrency = 8
+
+// WithConcurrency bounds the SSM batches and secret reads the AWSResolver
+// runs at once to n. 1 reads one at a time; zero uses DefaultConcurrency.
+func WithConcurrency(n int) Option {
+	return func(r *AWSResolver) {
+		r.concurrency = n
+	}
+}
+
+// forEach calls fn for each item, running at most limit calls at once, or
+// DefaultConcurrency when limit is zero. fn must record its own failures.
+func forEach[T any](limit int, items []T, fn func(T)) {
+	if limit < 1 {
+		limit = DefaultConcurrency
+	}
+	var g errgroup.Group
+	g.SetLimit(limit)
+	for _, item := range items {
+		g.Go(func() error {
+			fn(item)
+			return nil
+		})
+	}
+	_ = g.Wait()
+}
+
+// concurrencyFromEnv reads SSM_RESOLVER_CONCURRENCY.
+func concurrencyFromEnv() (int, error) {
+	value := os.Getenv(EnvConcurrency)
+	if value == "" {
+		return DefaultConcurrency, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive integer", EnvConcurrency, value)
+	}
+	return n, nil
+}
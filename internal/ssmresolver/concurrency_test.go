@@ -0,0 +1,75 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// slowSecretsManager is a SecretsManagerClient that records how many reads
+// run at once.
+type slowSecretsManager struct {
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (s *slowSecretsManager) GetSecretValue(_ context.Context, in *secretsmanager.GetSecretValueInput,
+	_ ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	s.mu.Lock()
+	s.inFlight++
+	s.maxInFlight = max(s.maxInFlight, s.inFlight)
+	s.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String("value of " + aws.ToString(in.SecretId))}, nil
+}
+
+func TestResolveRefsConcurrency(t *testing.T) {
+	secrets := &slowSecretsManager{}
+	resolver := NewWithClients(&fakeSSM{}, secrets, WithConcurrency(2))
+
+	refs := make(map[string]string)
+	for i := range 6 {
+		key := fmt.Sprintf("TEST_CONCURRENCY_%d", i)
+		refs[key] = fmt.Sprintf("secretsmanager://octo-sts/secret-%d", i)
+		t.Setenv(key, "")
+	}
+
+	if err := resolver.ResolveRefs(context.Background(), refs); err != nil {
+		t.Fatalf("ResolveRefs() error = %v", err)
+	}
+	for key, ref := range refs {
+		secretID, _, _ := ParseSecretRef(ref)
+		if got := os.Getenv(key); got != "value of "+secretID {
+			t.Errorf("%s = %q, want %q", key, got, "value of "+secretID)
+		}
+	}
+	if secrets.maxInFlight != 2 {
+		t.Errorf("max concurrent reads = %d, want 2", secrets.maxInFlight)
+	}
+}
+
+func TestConcurrencyFromEnv(t *testing.T) {
+	t.Setenv(EnvConcurrency, "")
+	if n, err := concurrencyFromEnv(); err != nil || n != DefaultConcurrency {
+		t.Errorf("concurrencyFromEnv() = (%d, %v), want (%d, nil)", n, err, DefaultConcurrency)
+	}
+	t.Setenv(EnvConcurrency, "0")
+	if _, err := concurrencyFromEnv(); err == nil {
+		t.Errorf("expected error for %s=0", EnvConcurrency)
+	}
+}
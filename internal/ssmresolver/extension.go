@@ -0,0 +1,206 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// Transports an AWSResolver reads references through.
+const (
+	// TransportAuto uses the extension when it is installed, else the SDK.
+	TransportAuto = "auto"
+	// TransportSDK calls SSM and Secrets Manager with the AWS SDK.
+	TransportSDK = "sdk"
+	// TransportExtension reads through the AWS Parameters and Secrets Lambda
+	// Extension, which caches values and saves the SDK calls at cold start.
+	TransportExtension = "extension"
+)
+
+// EnvTransport selects the transport of ResolveEnvironmentWithDefaults.
+const EnvTransport = "SSM_RESOLVER_TRANSPORT"
+
+// Settings of the AWS Parameters and Secrets Lambda Extension.
+const (
+	envExtensionPort     = "PARAMETERS_SECRETS_EXTENSION_HTTP_PORT"
+	envSessionToken      = "AWS_SESSION_TOKEN"
+	defaultExtensionPort = "2773"
+	extensionTokenHeader = "X-Aws-Parameters-Secrets-Token"
+	extensionPath        = "/opt/extensions/AWSParametersAndSecretsLambdaExtension"
+	extensionTimeout     = 10 * time.Second
+)
+
+// WithTransport selects how the AWSResolver reads references: TransportAuto
+// (the default), TransportSDK, or TransportExtension. The extension only
+// serves the function's own region and credentials, so ARNs in other regions
+// and the role set by WithAssumeRole are always read with the SDK. It only
+// applies to New.
+func WithTransport(transport string) Option {
+	return func(r *AWSResolver) {
+		r.transport = transport
+	}
+}
+
+// useExtension reports whether transport selects the extension.
+func useExtension(transport string) (bool, error) {
+	switch transport {
+	case TransportAuto, "":
+		_, err := os.Stat(extensionPath)
+		return err == nil || os.Getenv(envExtensionPort) != "", nil
+	case TransportSDK:
+		return false, nil
+	case TransportExtension:
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid transport %q: expected %s, %s, or %s",
+			transport, TransportAuto, TransportSDK, TransportExtension)
+	}
+}
+
+// extensionClient reads parameters and secrets through the extension's
+// local HTTP endpoint. It implements SSMClient and SecretsManagerClient.
+type extensionClient struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// newExtensionClient creates an extensionClient for the extension of the
+// running function.
+func newExtensionClient() *extensionClient {
+	port := os.Getenv(envExtensionPort)
+	if port == "" {
+		port = defaultExtensionPort
+	}
+	return &extensionClient{
+		baseURL: "http://localhost:" + port,
+		token:   os.Getenv(envSessionToken),
+		client:  &http.Client{Timeout: extensionTimeout},
+	}
+}
+
+// extensionNotFound is the error type the extension reports for a missing
+// parameter.
+const extensionNotFound = "ParameterNotFound"
+
+// extensionError is a non-200 response of the extension.
+type extensionError struct {
+	statusCode int
+	body       string
+}
+
+func (e *extensionError) Error() string {
+	return fmt.Sprintf("extension returned status %d: %s", e.statusCode, e.body)
+}
+
+// GetParameters reads each parameter with one request to the extension,
+// which has no batch endpoint. Missing parameters are returned as invalid,
+// as with SSM.
+func (c *extensionClient) GetParameters(ctx context.Context, in *ssm.GetParametersInput,
+	_ ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	decrypt := "false"
+	if in.WithDecryption != nil && *in.WithDecryption {
+		decrypt = "true"
+	}
+
+	out := &ssm.GetParametersOutput{}
+	for _, name := range in.Names {
+		var resp struct {
+			Parameter struct {
+				ARN      *string
+				Name     *string
+				Selector *string
+				Type     types.ParameterType
+				Value    *string
+			}
+		}
+		// The extension takes a pinned version or label as its own parameter.
+		base, selector, _ := SplitSelector(name)
+		query := url.Values{"name": {base}, "withDecryption": {decrypt}}
+		if _, err := strconv.Atoi(selector); err == nil {
+			query.Set("version", selector)
+		} else if selector != "" {
+			query.Set("label", selector)
+		}
+		err := c.get(ctx, "/systemsmanager/parameters/get?"+query.Encode(), &resp)
+		if err != nil {
+			var extErr *extensionError
+			if errors.As(err, &extErr) && strings.Contains(extErr.body, extensionNotFound) {
+				out.InvalidParameters = append(out.InvalidParameters, name)
+				continue
+			}
+			return nil, err
+		}
+		if selector != "" {
+			resp.Parameter.Selector = aws.String(":" + selector)
+		}
+		out.Parameters = append(out.Parameters, types.Parameter{
+			ARN:      resp.Parameter.ARN,
+			Name:     resp.Parameter.Name,
+			Selector: resp.Parameter.Selector,
+			Type:     resp.Parameter.Type,
+			Value:    resp.Parameter.Value,
+		})
+	}
+	return out, nil
+}
+
+// GetSecretValue reads a secret from the extension.
+func (c *extensionClient) GetSecretValue(ctx context.Context, in *secretsmanager.GetSecretValueInput,
+	_ ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	var resp struct {
+		SecretString *string
+		SecretBinary []byte
+	}
+	query := url.Values{"secretId": {aws.ToString(in.SecretId)}}
+	if err := c.get(ctx, "/secretsmanager/get?"+query.Encode(), &resp); err != nil {
+		return nil, err
+	}
+	return &secretsmanager.GetSecretValueOutput{
+		SecretString: resp.SecretString,
+		SecretBinary: resp.SecretBinary,
+	}, nil
+}
+
+// get requests path from the extension and decodes the JSON response into v.
+func (c *extensionClient) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create extension request: %w", err)
+	}
+	req.Header.Set(extensionTokenHeader, c.token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach the parameters and secrets extension: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read extension response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &extensionError{statusCode: resp.StatusCode, body: strings.TrimSpace(string(body))}
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode extension response: %w", err)
+	}
+	return nil
+}
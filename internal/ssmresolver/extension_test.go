@@ -0,0 +1,94 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExtensionClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(extensionTokenHeader) != "session-token" {
+			http.Error(w, "missing token", http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/systemsmanager/parameters/get":
+			if r.URL.Query().Get("withDecryption") != "true" {
+				http.Error(w, "expected decryption", http.StatusBadRequest)
+				return
+			}
+			name := r.URL.Query().Get("name")
+			if name != "/octo-sts/GITHUB_APP_ID" {
+				http.Error(w, `{"__type":"ParameterNotFound"}`, http.StatusBadRequest)
+				return
+			}
+			value := "1234"
+			if r.URL.Query().Get("version") == "3" {
+				value = "5678"
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"Parameter": map[string]string{"Name": name, "Value": value},
+			})
+		case "/secretsmanager/get":
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"SecretString": `{"GITHUB_APP_PRIVATE_KEY":"pem"}`,
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ext := &extensionClient{baseURL: srv.URL, token: "session-token", client: srv.Client()}
+	resolver := NewWithClients(ext, ext)
+	ctx := context.Background()
+
+	for ref, want := range map[string]string{
+		"ssm:///octo-sts/GITHUB_APP_ID":                        "1234",
+		"ssm:///octo-sts/GITHUB_APP_ID:3":                      "5678",
+		testSecretARN + "#GITHUB_APP_PRIVATE_KEY":              "pem",
+		"secretsmanager://octo-sts/app#GITHUB_APP_PRIVATE_KEY": "pem",
+	} {
+		got, err := resolver.ResolveValue(ctx, ref)
+		if err != nil {
+			t.Fatalf("ResolveValue(%q) error = %v", ref, err)
+		}
+		if got != want {
+			t.Errorf("ResolveValue(%q) = %q, want %q", ref, got, want)
+		}
+	}
+
+	if _, err := resolver.ResolveValue(ctx, "ssm:///octo-sts/MISSING"); err == nil {
+		t.Error("expected error for a missing parameter")
+	}
+
+	ext.token = "wrong"
+	if _, err := resolver.ResolveValue(ctx, "ssm:///octo-sts/GITHUB_APP_ID"); err == nil {
+		t.Error("expected error when the extension rejects the request")
+	}
+}
+
+func TestUseExtension(t *testing.T) {
+	t.Setenv(envExtensionPort, "")
+	for transport, want := range map[string]bool{TransportSDK: false, TransportExtension: true} {
+		got, err := useExtension(transport)
+		if err != nil || got != want {
+			t.Errorf("useExtension(%q) = (%v, %v), want %v", transport, got, err, want)
+		}
+	}
+
+	t.Setenv(envExtensionPort, "2773")
+	if got, _ := useExtension(TransportAuto); !got {
+		t.Errorf("expected %s to use the extension when %s is set", TransportAuto, envExtensionPort)
+	}
+
+	if _, err := useExtension("grpc"); err == nil {
+		t.Error("expected error for an unknown transport")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"os"
+	"strings"
+)
+
+// Environment variables configuring which variables
+// ResolveEnvironmentWithDefaults resolves. Both take comma-separated names
+// or prefixes ending in *, e.g. GITHUB_*,STS_DOMAIN.
+const (
+	// EnvAllow limits resolution to the listed variables. All variables are
+	// eligible when unset.
+	EnvAllow = "SSM_RESOLVER_ALLOW"
+	// EnvDeny excludes the listed variables from resolution, even when
+	// allowed.
+	EnvDeny = "SSM_RESOLVER_DENY"
+)
+
+// Filter selects the environment variables whose references are resolved,
+// so references in variables owned by other software sharing the runtime
+// are left alone. Entries are variable names, or prefixes ending in *. The
+// zero Filter allows every variable.
+type Filter struct {
+	// Allow lists the eligible variables. Every variable is eligible when
+	// empty.
+	Allow []string
+	// Deny lists variables that are never resolved, overriding Allow.
+	Deny []string
+}
+
+// Allows reports whether the references in the variable key may be
+// resolved.
+func (f Filter) Allows(key string) bool {
+	if matchAny(f.Deny, key) {
+		return false
+	}
+	return len(f.Allow) == 0 || matchAny(f.Allow, key)
+}
+
+// matchAny reports whether key is one of the names or starts with one of the
+// prefixes in patterns.
+func matchAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// WithFilter only resolves the references in the variables filter allows.
+func WithFilter(filter Filter) RegistryOption {
+	return func(g *Registry) {
+		g.filter = filter
+	}
+}
+
+// filterFromEnv reads SSM_RESOLVER_ALLOW and SSM_RESOLVER_DENY.
+func filterFromEnv() Filter {
+	return Filter{
+		Allow: splitList(os.Getenv(EnvAllow)),
+		Deny:  splitList(os.Getenv(EnvDeny)),
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestFilterAllows(t *testing.T) {
+	filter := Filter{Allow: []string{"GITHUB_*", "STS_DOMAIN"}, Deny: []string{"GITHUB_WEBHOOK_*"}}
+	for key, want := range map[string]bool{
+		"GITHUB_APP_ID":         true,
+		"STS_DOMAIN":            true,
+		"STS_DOMAIN_ALIAS":      false,
+		"GITHUB_WEBHOOK_SECRET": false,
+		"OTHER_APP_TOKEN":       false,
+	} {
+		if got := filter.Allows(key); got != want {
+			t.Errorf("Allows(%q) = %v, want %v", key, got, want)
+		}
+	}
+	if !(Filter{}).Allows("ANY") {
+		t.Error("expected the zero Filter to allow every variable")
+	}
+}
+
+func TestRegistryFilter(t *testing.T) {
+	ssmClient := &fakeSSM{params: map[string]string{"/octo-sts/GITHUB_APP_ID": "1234"}}
+	registry := NewRegistry(WithFilter(Filter{Deny: []string{"TEST_FILTER_OTHER_*"}}))
+	registry.Register(NewWithClients(ssmClient, &fakeSecretsManager{}), AWSSchemes...)
+
+	env := map[string]string{
+		"TEST_FILTER_APP_ID":      "ssm:///octo-sts/GITHUB_APP_ID",
+		"TEST_FILTER_OTHER_TOKEN": "ssm:///octo-sts/GITHUB_APP_ID",
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	t.Cleanup(func() {
+		resolved.Lock()
+		for key := range env {
+			delete(resolved.refs, key)
+		}
+		resolved.Unlock()
+	})
+
+	if err := registry.ResolveEnvironment(context.Background()); err != nil {
+		t.Fatalf("ResolveEnvironment() error = %v", err)
+	}
+	if got := os.Getenv("TEST_FILTER_APP_ID"); got != "1234" {
+		t.Errorf("TEST_FILTER_APP_ID = %q, want %q", got, "1234")
+	}
+	if got := os.Getenv("TEST_FILTER_OTHER_TOKEN"); got != env["TEST_FILTER_OTHER_TOKEN"] {
+		t.Errorf("TEST_FILTER_OTHER_TOKEN = %q, want the reference left as is", got)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/cruxstack/octo-sts-distros/internal/gcp"
+)
+
+// gcpRefPattern matches gcpsm://<project>/<secret>[/<version>][#<key>].
+var gcpRefPattern = regexp.MustCompile(`^gcpsm://([a-z0-9-]+)/([A-Za-z0-9_-]+)(?:/([A-Za-z0-9_-]+))?(?:#(.+))?$`)
+
+// GCPSecretClient defines the interface for Google Secret Manager reads.
+type GCPSecretClient interface {
+	AccessSecretVersion(ctx context.Context, name string) ([]byte, error)
+}
+
+// GCPResolver resolves Google Secret Manager references of the form
+// gcpsm://<project>/<secret>[/<version>], reading the latest version unless
+// one is given. A #<key> suffix selects a field of a JSON secret.
+type GCPResolver struct {
+	// Concurrency bounds the secret versions read at once. Zero uses
+	// DefaultConcurrency.
+	Concurrency int
+
+	client GCPSecretClient
+}
+
+// NewGCPResolver creates a GCPResolver authenticated as the service account
+// of the metadata server.
+func NewGCPResolver() (*GCPResolver, error) {
+	client, err := gcp.NewSecretManagerClient(gcp.NewMetadataServer())
+	if err != nil {
+		return nil, err
+	}
+	return NewGCPResolverWithClient(client), nil
+}
+
+// NewGCPResolverWithClient creates a GCPResolver with a custom client.
+func NewGCPResolverWithClient(client GCPSecretClient) *GCPResolver {
+	return &GCPResolver{client: client}
+}
+
+// IsGCPRef checks if the given value is a Google Secret Manager reference.
+func IsGCPRef(value string) bool {
+	_, _, ok := ParseGCPRef(value)
+	return ok
+}
+
+// ParseGCPRef splits a Google Secret Manager reference into the resource
+// name of the secret version and the JSON key, which is empty when the whole
+// secret is referenced.
+func ParseGCPRef(ref string) (name, key string, ok bool) {
+	matches := gcpRefPattern.FindStringSubmatch(normalizeRef(ref))
+	if len(matches) != 5 {
+		return "", "", false
+	}
+	version := matches[3]
+	if version == "" {
+		version = "latest"
+	}
+	return fmt.Sprintf("projects/%s/secrets/%s/versions/%s", matches[1], matches[2], version), matches[4], true
+}
+
+// IsRef reports whether value is a Google Secret Manager reference.
+func (r *GCPResolver) IsRef(value string) bool {
+	return IsGCPRef(value)
+}
+
+// ResolveRefs resolves refs, keyed by environment variable name, and sets
+// the variables to their values. Each secret version is read once, up to
+// Concurrency at a time. A reference that fails to resolve is reported as a
+// *RefError, joined with the others, and the rest are still resolved.
+func (r *GCPResolver) ResolveRefs(ctx context.Context, refs map[string]string) error {
+	secrets := make(map[string]string)
+	failed := make(map[string]error)
+
+	// Read the secret versions up front and concurrently.
+	var names []string
+	for _, ref := range refs {
+		if name, _, ok := ParseGCPRef(ref); ok && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	var mu sync.Mutex
+	forEach(r.Concurrency, names, func(name string) {
+		data, err := r.client.AccessSecretVersion(ctx, name)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			failed[name] = fmt.Errorf("failed to access %s: %w", name, err)
+			return
+		}
+		secrets[name] = string(data)
+	})
+
+	var errs []error
+	for key, ref := range refs {
+		value, err := r.resolveRef(ref, secrets, failed)
+		if err != nil {
+			errs = append(errs, &RefError{Key: key, Ref: ref, Err: err})
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resolveRef resolves a reference from the secret versions read into
+// secrets, or the reads that failed.
+func (r *GCPResolver) resolveRef(ref string, secrets map[string]string, failed map[string]error) (string, error) {
+	name, key, ok := ParseGCPRef(ref)
+	if !ok {
+		return "", fmt.Errorf("invalid Google Secret Manager reference: %s", ref)
+	}
+	if err, ok := failed[name]; ok {
+		return "", err
+	}
+	secret := secrets[name]
+	if key == "" {
+		return secret, nil
+	}
+	return jsonField("secret "+name, secret, key)
+}
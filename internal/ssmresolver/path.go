@@ -0,0 +1,110 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// EnvPath is the SSM path whose parameters ResolveEnvironmentWithDefaults
+// loads into the environment, e.g. /octo-sts/prod/.
+const EnvPath = "SSM_RESOLVER_PATH"
+
+// SSMPathClient defines the interface for reading SSM parameters by path.
+// The *ssm.Client implements it; the extension transport does not, so path
+// loading always uses the SDK.
+type SSMPathClient interface {
+	ssm.GetParametersByPathAPIClient
+}
+
+// WithPath loads every parameter under path into the environment on each
+// ResolveEnvironment, named after the rest of the parameter name, so
+// /octo-sts/prod/GITHUB_APP_ID under /octo-sts/prod/ sets GITHUB_APP_ID.
+// Nested names have their slashes replaced by underscores. Variables set
+// explicitly take precedence over loaded ones.
+func WithPath(path string) Option {
+	return func(r *AWSResolver) {
+		r.path = path
+	}
+}
+
+// pathEnvKey returns the variable a parameter under path is loaded as.
+func pathEnvKey(path, name string) string {
+	key := strings.TrimPrefix(strings.TrimPrefix(name, path), "/")
+	return strings.NewReplacer("/", "_", "-", "_", ".", "_").Replace(key)
+}
+
+// loadPath loads the parameters under r.path into the environment. Variables
+// loaded by a previous call whose parameter is gone are unset.
+func (r *AWSResolver) loadPath(ctx context.Context) error {
+	client := r.clients("").paths
+	if client == nil {
+		return fmt.Errorf("SSM client does not support loading parameters by path")
+	}
+	path := r.path
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("invalid SSM path %q: must start with /", path)
+	}
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+
+	vars := make(map[string]string)
+	paginator := ssm.NewGetParametersByPathPaginator(client, &ssm.GetParametersByPathInput{
+		Path:           aws.String(path),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get SSM parameters under %s: %w", path, err)
+		}
+		for _, param := range page.Parameters {
+			key := pathEnvKey(path, aws.ToString(param.Name))
+			if key == "" {
+				continue
+			}
+			value := aws.ToString(param.Value)
+			if param.Type != types.ParameterTypeStringList {
+				vars[key] = value
+				continue
+			}
+			// Indexed items are tracked and unset like any other loaded variable.
+			for name, value := range r.expandList(key, value) {
+				vars[name] = value
+			}
+		}
+	}
+
+	resolved.Lock()
+	defer resolved.Unlock()
+
+	for key := range resolved.pathKeys {
+		if _, ok := vars[key]; !ok {
+			if err := os.Unsetenv(key); err != nil {
+				return fmt.Errorf("failed to unset %s: %w", key, err)
+			}
+			delete(resolved.pathKeys, key)
+		}
+	}
+	for key, value := range vars {
+		// Variables set explicitly, or resolved from a reference, win.
+		if _, ok := os.LookupEnv(key); ok && !resolved.pathKeys[key] {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+		resolved.pathKeys[key] = true
+	}
+	return nil
+}
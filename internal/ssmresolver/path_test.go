@@ -0,0 +1,118 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// fakePathSSM is a fakeSSM that also reads parameters by path, two per page.
+type fakePathSSM struct {
+	fakeSSM
+}
+
+func (f *fakePathSSM) GetParametersByPath(_ context.Context, in *ssm.GetParametersByPathInput,
+	_ ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	var names []string
+	for name := range f.params {
+		if strings.HasPrefix(name, aws.ToString(in.Path)) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	start, _ := strconv.Atoi(aws.ToString(in.NextToken))
+	end := min(start+2, len(names))
+	out := &ssm.GetParametersByPathOutput{}
+	for _, name := range names[start:end] {
+		param := types.Parameter{Name: aws.String(name), Value: aws.String(f.params[name]), Type: types.ParameterTypeString}
+		if f.lists[name] {
+			param.Type = types.ParameterTypeStringList
+		}
+		out.Parameters = append(out.Parameters, param)
+	}
+	if end < len(names) {
+		out.NextToken = aws.String(strconv.Itoa(end))
+	}
+	return out, nil
+}
+
+func TestResolveEnvironmentPath(t *testing.T) {
+	ssmClient := &fakePathSSM{fakeSSM{
+		params: map[string]string{
+			"/octo-sts/prod/TEST_AWS_PATH_APP_ID":   "1234",
+			"/octo-sts/prod/TEST_AWS_PATH_EXPLICIT": "from-ssm",
+			"/octo-sts/prod/TEST_AWS_PATH_LIST":     "a,b",
+			"/octo-sts/prod/webhook/TEST_AWS_PATH":  "nested",
+			"/octo-sts/dev/TEST_AWS_PATH_APP_ID":    "other",
+		},
+		lists: map[string]bool{"/octo-sts/prod/TEST_AWS_PATH_LIST": true},
+	}}
+	resolver := NewWithClients(ssmClient, &fakeSecretsManager{}, WithPath("/octo-sts/prod"))
+
+	keys := []string{
+		"TEST_AWS_PATH_APP_ID", "TEST_AWS_PATH_LIST", "webhook_TEST_AWS_PATH",
+	}
+	for _, key := range keys {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	t.Setenv("TEST_AWS_PATH_EXPLICIT", "explicit")
+	t.Cleanup(func() {
+		resolved.Lock()
+		for _, key := range keys {
+			delete(resolved.pathKeys, key)
+		}
+		resolved.Unlock()
+	})
+
+	ctx := context.Background()
+	if err := resolver.ResolveEnvironment(ctx); err != nil {
+		t.Fatalf("ResolveEnvironment() error = %v", err)
+	}
+	for key, want := range map[string]string{
+		"TEST_AWS_PATH_APP_ID":   "1234",
+		"TEST_AWS_PATH_EXPLICIT": "explicit",
+		"TEST_AWS_PATH_LIST":     "a,b",
+		"webhook_TEST_AWS_PATH":  "nested",
+	} {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+
+	// Changed parameters are loaded again and removed ones are unset.
+	ssmClient.params["/octo-sts/prod/TEST_AWS_PATH_APP_ID"] = "5678"
+	delete(ssmClient.params, "/octo-sts/prod/webhook/TEST_AWS_PATH")
+	if err := resolver.ResolveEnvironment(ctx); err != nil {
+		t.Fatalf("ResolveEnvironment() error = %v", err)
+	}
+	if got := os.Getenv("TEST_AWS_PATH_APP_ID"); got != "5678" {
+		t.Errorf("TEST_AWS_PATH_APP_ID = %q, want %q", got, "5678")
+	}
+	if _, ok := os.LookupEnv("webhook_TEST_AWS_PATH"); ok {
+		t.Error("expected the variable of a removed parameter to be unset")
+	}
+}
+
+func TestResolveEnvironmentPathErrors(t *testing.T) {
+	resolver := NewWithClients(&fakeSSM{}, &fakeSecretsManager{}, WithPath("/octo-sts/prod/"))
+	if err := resolver.ResolveEnvironment(context.Background()); err == nil {
+		t.Error("expected error for a client without path support")
+	}
+
+	resolver = NewWithClients(&fakePathSSM{}, &fakeSecretsManager{}, WithPath("octo-sts/prod/"))
+	if err := resolver.ResolveEnvironment(context.Background()); err == nil {
+		t.Error("expected error for a relative path")
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// Environment variables configuring the policy of
+// ResolveEnvironmentWithDefaults.
+const (
+	// EnvPolicy is the policy of variables not listed in EnvOptional or
+	// EnvRequired: PolicyRequired (the default) or PolicyOptional.
+	EnvPolicy = "SSM_RESOLVER_POLICY"
+	// EnvOptional lists the variables, comma-separated, that may fail to
+	// resolve, e.g. GITHUB_ORG_FILTER.
+	EnvOptional = "SSM_RESOLVER_OPTIONAL"
+	// EnvRequired lists the variables, comma-separated, that must resolve
+	// even when EnvPolicy is PolicyOptional.
+	EnvRequired = "SSM_RESOLVER_REQUIRED"
+	// EnvFallback is what optional variables that fail to resolve are set
+	// to: FallbackLiteral (the default) or FallbackEmpty.
+	EnvFallback = "SSM_RESOLVER_FALLBACK"
+)
+
+// Resolution policies.
+const (
+	// PolicyRequired fails the resolution when the variable fails to resolve.
+	PolicyRequired = "required"
+	// PolicyOptional logs the failure and sets the variable to its fallback.
+	PolicyOptional = "optional"
+)
+
+// Fallbacks of optional variables that fail to resolve.
+const (
+	// FallbackLiteral leaves the variable unchanged: the reference itself
+	// at startup, and the last resolved value on reload.
+	FallbackLiteral = "literal"
+	// FallbackEmpty sets the variable to the empty string.
+	FallbackEmpty = "empty"
+)
+
+// RefError reports a reference that failed to resolve.
+type RefError struct {
+	// Key is the environment variable holding the reference.
+	Key string
+	// Ref is the reference.
+	Ref string
+	// Err is why the reference failed to resolve.
+	Err error
+}
+
+func (e *RefError) Error() string {
+	return fmt.Sprintf("failed to resolve %s: %v", e.Key, e.Err)
+}
+
+func (e *RefError) Unwrap() error {
+	return e.Err
+}
+
+// Policy decides which variables may fail to resolve, and what they are set
+// to when they do. The zero Policy requires every variable.
+type Policy struct {
+	// Default is the policy of variables not listed in Optional or
+	// Required: PolicyRequired, the default, or PolicyOptional.
+	Default string
+	// Optional lists variables that may fail to resolve.
+	Optional []string
+	// Required lists variables that must resolve, overriding Default.
+	Required []string
+	// Fallback is FallbackLiteral, the default, or FallbackEmpty.
+	Fallback string
+}
+
+// optional reports whether the variable key may fail to resolve.
+func (p Policy) optional(key string) bool {
+	if slices.Contains(p.Required, key) {
+		return false
+	}
+	return p.Default == PolicyOptional || slices.Contains(p.Optional, key)
+}
+
+// RegistryOption configures a Registry.
+type RegistryOption func(*Registry)
+
+// WithPolicy resolves variables according to policy instead of requiring
+// every one.
+func WithPolicy(policy Policy) RegistryOption {
+	return func(g *Registry) {
+		g.policy = policy
+	}
+}
+
+// tolerate applies the policy to the error of a resolution: the failures of
+// optional variables are logged and set to the fallback, and the rest are
+// returned.
+func (g *Registry) tolerate(ctx context.Context, err error) error {
+	var errs []error
+	for _, err := range unjoin(err) {
+		var refErr *RefError
+		if !errors.As(err, &refErr) || !g.policy.optional(refErr.Key) {
+			errs = append(errs, err)
+			continue
+		}
+		fallback := FallbackLiteral
+		if g.policy.Fallback == FallbackEmpty {
+			fallback = FallbackEmpty
+			if err := os.Setenv(refErr.Key, ""); err != nil {
+				errs = append(errs, fmt.Errorf("failed to set %s: %w", refErr.Key, err))
+				continue
+			}
+		}
+		clog.FromContext(ctx).Warnf("[ssmresolver] optional variable %s failed to resolve, using the %s fallback: %v",
+			refErr.Key, fallback, refErr.Err)
+	}
+	return errors.Join(errs...)
+}
+
+// policyFromEnv reads SSM_RESOLVER_POLICY, SSM_RESOLVER_OPTIONAL,
+// SSM_RESOLVER_REQUIRED, and SSM_RESOLVER_FALLBACK.
+func policyFromEnv() (Policy, error) {
+	policy := Policy{
+		Default:  os.Getenv(EnvPolicy),
+		Optional: splitList(os.Getenv(EnvOptional)),
+		Required: splitList(os.Getenv(EnvRequired)),
+		Fallback: os.Getenv(EnvFallback),
+	}
+	switch policy.Default {
+	case "", PolicyRequired, PolicyOptional:
+	default:
+		return Policy{}, fmt.Errorf("invalid %s %q: expected %s or %s", EnvPolicy, policy.Default, PolicyRequired, PolicyOptional)
+	}
+	switch policy.Fallback {
+	case "", FallbackLiteral, FallbackEmpty:
+	default:
+		return Policy{}, fmt.Errorf("invalid %s %q: expected %s or %s", EnvFallback, policy.Fallback, FallbackLiteral, FallbackEmpty)
+	}
+	return policy, nil
+}
+
+// splitList splits a comma-separated list, dropping blank items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
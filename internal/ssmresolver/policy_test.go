@@ -0,0 +1,94 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestRegistryPolicy(t *testing.T) {
+	ssmClient := &fakeSSM{params: map[string]string{"/octo-sts/GITHUB_APP_ID": "1234"}}
+	newRegistry := func(policy Policy) *Registry {
+		registry := NewRegistry(WithPolicy(policy))
+		registry.Register(NewWithClients(ssmClient, &fakeSecretsManager{}), AWSSchemes...)
+		return registry
+	}
+
+	env := map[string]string{
+		"TEST_POLICY_APP_ID":     "ssm:///octo-sts/GITHUB_APP_ID",
+		"TEST_POLICY_ORG_FILTER": "ssm:///octo-sts/GITHUB_ORG_FILTER",
+	}
+	setEnv := func() {
+		for key, value := range env {
+			t.Setenv(key, value)
+		}
+	}
+	t.Cleanup(func() {
+		resolved.Lock()
+		for key := range env {
+			delete(resolved.refs, key)
+		}
+		resolved.Unlock()
+	})
+	ctx := context.Background()
+
+	// Required by default: the missing parameter fails the resolution, but
+	// the other variables are still resolved.
+	setEnv()
+	err := newRegistry(Policy{}).ResolveEnvironment(ctx)
+	var refErr *RefError
+	if !errors.As(err, &refErr) || refErr.Key != "TEST_POLICY_ORG_FILTER" {
+		t.Fatalf("ResolveEnvironment() error = %v, want a RefError for TEST_POLICY_ORG_FILTER", err)
+	}
+	if got := os.Getenv("TEST_POLICY_APP_ID"); got != "1234" {
+		t.Errorf("TEST_POLICY_APP_ID = %q, want %q", got, "1234")
+	}
+
+	// Optional with the literal fallback: the reference is kept.
+	setEnv()
+	if err := newRegistry(Policy{Optional: []string{"TEST_POLICY_ORG_FILTER"}}).ResolveEnvironment(ctx); err != nil {
+		t.Fatalf("ResolveEnvironment() error = %v", err)
+	}
+	if got := os.Getenv("TEST_POLICY_ORG_FILTER"); got != env["TEST_POLICY_ORG_FILTER"] {
+		t.Errorf("TEST_POLICY_ORG_FILTER = %q, want the reference", got)
+	}
+
+	// Optional by default with the empty fallback.
+	setEnv()
+	if err := newRegistry(Policy{Default: PolicyOptional, Fallback: FallbackEmpty}).ResolveEnvironment(ctx); err != nil {
+		t.Fatalf("ResolveEnvironment() error = %v", err)
+	}
+	if got, ok := os.LookupEnv("TEST_POLICY_ORG_FILTER"); !ok || got != "" {
+		t.Errorf("TEST_POLICY_ORG_FILTER = (%q, %v), want empty", got, ok)
+	}
+
+	// Required overrides an optional default.
+	setEnv()
+	policy := Policy{Default: PolicyOptional, Required: []string{"TEST_POLICY_ORG_FILTER"}}
+	if err := newRegistry(policy).ResolveEnvironment(ctx); err == nil {
+		t.Error("expected the required variable to fail the resolution")
+	}
+}
+
+func TestPolicyFromEnv(t *testing.T) {
+	t.Setenv(EnvPolicy, "optional")
+	t.Setenv(EnvOptional, "")
+	t.Setenv(EnvRequired, " GITHUB_APP_ID, ,GITHUB_APP_PRIVATE_KEY ")
+	t.Setenv(EnvFallback, "empty")
+	policy, err := policyFromEnv()
+	if err != nil {
+		t.Fatalf("policyFromEnv() error = %v", err)
+	}
+	if policy.optional("GITHUB_APP_ID") || policy.optional("GITHUB_APP_PRIVATE_KEY") || !policy.optional("GITHUB_ORG_FILTER") {
+		t.Errorf("policyFromEnv() = %+v, want the listed variables required and the rest optional", policy)
+	}
+
+	t.Setenv(EnvFallback, "previous")
+	if _, err := policyFromEnv(); err == nil {
+		t.Errorf("expected error for invalid %s", EnvFallback)
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schemes of the references resolved by the default registry.
+var (
+	// AWSSchemes are resolved by an AWSResolver: ARNs and the ssm:// and
+	// secretsmanager:// URIs.
+	AWSSchemes = []string{"arn", "ssm", "secretsmanager"}
+	// GCPScheme is resolved by a GCPResolver.
+	GCPScheme = "gcpsm"
+	// AzureScheme is resolved by an AzureResolver.
+	AzureScheme = "azkv"
+)
+
+// Resolver resolves the references of one or more schemes into the
+// environment.
+type Resolver interface {
+	// IsRef reports whether value is a reference the Resolver resolves.
+	IsRef(value string) bool
+	// ResolveRefs resolves refs, keyed by environment variable name, and
+	// sets the variables to their values.
+	ResolveRefs(ctx context.Context, refs map[string]string) error
+}
+
+// environmentLoader is implemented by resolvers that load variables into the
+// environment before references are resolved, such as an AWSResolver with a
+// parameter path.
+type environmentLoader interface {
+	LoadEnvironment(ctx context.Context) error
+}
+
+// Registry resolves references with the Resolver registered for their
+// scheme, the part of the value before the first colon.
+type Registry struct {
+	resolvers map[string]Resolver
+	// order holds the resolvers in registration order, so they run in a
+	// predictable order.
+	order  []Resolver
+	policy Policy
+	filter Filter
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry(opts ...RegistryOption) *Registry {
+	g := &Registry{resolvers: make(map[string]Resolver)}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Register resolves the references of schemes with r, replacing any resolver
+// previously registered for them.
+func (g *Registry) Register(r Resolver, schemes ...string) {
+	for _, scheme := range schemes {
+		g.resolvers[scheme] = r
+	}
+	for _, existing := range g.order {
+		if existing == r {
+			return
+		}
+	}
+	g.order = append(g.order, r)
+}
+
+// lookup returns the resolver registered for the scheme of value, or nil.
+func (g *Registry) lookup(value string) Resolver {
+	scheme, _, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil
+	}
+	return g.resolvers[scheme]
+}
+
+// IsRef reports whether value is a reference of a registered scheme.
+func (g *Registry) IsRef(value string) bool {
+	r := g.lookup(value)
+	return r != nil && r.IsRef(value)
+}
+
+// ResolveEnvironment resolves the references in environment variables with
+// the registered resolvers, after letting them load their own variables.
+// References resolved by a previous call are resolved again, and references
+// in variables the filter set by WithFilter excludes are left as is.
+// Failures of variables the policy makes optional are logged; the others are
+// returned joined, after every resolver has run. A summary of the resolution
+// is logged and counted in the octo_sts_resolver_* metrics.
+func (g *Registry) ResolveEnvironment(ctx context.Context) error {
+	report, err := g.Resolve(ctx)
+	if report != nil {
+		report.log(ctx)
+	}
+	return err
+}
+
+// Resolve resolves the references in environment variables like
+// ResolveEnvironment, returning a Report of the resolution instead of
+// logging it. The Report is nil if loading the environment failed.
+func (g *Registry) Resolve(ctx context.Context) (*Report, error) {
+	for _, r := range g.order {
+		if loader, ok := r.(environmentLoader); ok {
+			if err := loader.LoadEnvironment(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	groups := make(map[Resolver]map[string]string)
+	for key, ref := range pendingRefs(g.IsRef) {
+		if !g.filter.Allows(key) {
+			continue
+		}
+		r := g.lookup(ref)
+		if groups[r] == nil {
+			groups[r] = make(map[string]string)
+		}
+		groups[r][key] = ref
+	}
+	report := &Report{}
+	start := time.Now()
+	var errs []error
+	for _, r := range g.order {
+		if refs := groups[r]; len(refs) > 0 {
+			if err := g.tolerate(ctx, report.resolveRefs(ctx, r, refs)); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	report.Duration = time.Since(start)
+	return report, errors.Join(errs...)
+}
+
+// defaultRegistry is the Registry of ResolveEnvironmentWithDefaults, kept
+// across calls so the AWS cache outlives a single load.
+var defaultRegistry struct {
+	sync.Mutex
+	registry *Registry
+}
+
+// isDefaultRef reports whether value is a reference of the default registry.
+func isDefaultRef(value string) bool {
+	return IsRef(value) || IsGCPRef(value) || IsAzureRef(value)
+}
+
+// ResolveEnvironmentWithDefaults resolves AWS, Google Secret Manager, and
+// Azure Key Vault references in the environment. AWS references use the
+// default AWS config, configured by the SSM_RESOLVER_* variables: the cache
+// TTL and refresh interval, the role to assume, the transport, the
+// StringList expansion, and the path to load parameters from. Google and
+// Azure references use the workload's service account or managed identity.
+// SSM_RESOLVER_POLICY, SSM_RESOLVER_OPTIONAL, SSM_RESOLVER_REQUIRED, and
+// SSM_RESOLVER_FALLBACK set which variables may fail to resolve, and
+// SSM_RESOLVER_CONCURRENCY how many reads each resolver runs at once.
+// SSM_RESOLVER_ALLOW and SSM_RESOLVER_DENY restrict the variables resolved.
+// It is a no-op when no references or path are set, so it is safe to call
+// unconditionally.
+func ResolveEnvironmentWithDefaults(ctx context.Context) error {
+	if len(pendingRefs(isDefaultRef)) == 0 && os.Getenv(EnvPath) == "" {
+		return nil
+	}
+
+	defaultRegistry.Lock()
+	defer defaultRegistry.Unlock()
+
+	if defaultRegistry.registry == nil {
+		registry, err := newDefaultRegistry(ctx)
+		if err != nil {
+			return err
+		}
+		defaultRegistry.registry = registry
+	}
+	return defaultRegistry.registry.ResolveEnvironment(ctx)
+}
+
+// newDefaultRegistry creates the registry of ResolveEnvironmentWithDefaults.
+func newDefaultRegistry(ctx context.Context) (*Registry, error) {
+	policy, err := policyFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	ttl, refresh, err := cacheOptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	listOpt, err := stringListOptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	concurrency, err := concurrencyFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	opts := []Option{
+		WithCacheTTL(ttl), WithTransport(os.Getenv(EnvTransport)), listOpt,
+		WithPath(os.Getenv(EnvPath)), WithConcurrency(concurrency),
+	}
+	if roleARN := os.Getenv(EnvAssumeRoleARN); roleARN != "" {
+		opts = append(opts, WithAssumeRole(roleARN), WithAssumeRoleExternalID(os.Getenv(EnvAssumeRoleExternalID)))
+	}
+	awsResolver, err := New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if refresh > 0 {
+		awsResolver.StartRefresh(context.WithoutCancel(ctx), refresh)
+	}
+
+	gcpResolver, err := NewGCPResolver()
+	if err != nil {
+		return nil, err
+	}
+	gcpResolver.Concurrency = concurrency
+	azureResolver := NewAzureResolver()
+	azureResolver.Concurrency = concurrency
+
+	registry := NewRegistry(WithPolicy(policy), WithFilter(filterFromEnv()))
+	registry.Register(awsResolver, AWSSchemes...)
+	registry.Register(gcpResolver, GCPScheme)
+	registry.Register(azureResolver, AzureScheme)
+	return registry, nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/cruxstack/octo-sts-distros/internal/gcp"
+)
+
+type fakeGCPSecrets struct {
+	secrets map[string]string
+
+	mu    sync.Mutex
+	reads int
+}
+
+func (f *fakeGCPSecrets) AccessSecretVersion(_ context.Context, name string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reads++
+	secret, ok := f.secrets[name]
+	if !ok {
+		return nil, gcp.ErrSecretNotFound
+	}
+	return []byte(secret), nil
+}
+
+type fakeKeyVault struct {
+	secrets map[string]string
+
+	mu    sync.Mutex
+	reads int
+}
+
+func (f *fakeKeyVault) GetSecret(_ context.Context, name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reads++
+	return f.secrets[name], nil
+}
+
+func TestParseGCPRef(t *testing.T) {
+	tests := []struct {
+		ref      string
+		wantName string
+		wantKey  string
+		wantOK   bool
+	}{
+		{"gcpsm://my-project/github-app", "projects/my-project/secrets/github-app/versions/latest", "", true},
+		{"gcpsm://my-project/github-app/3", "projects/my-project/secrets/github-app/versions/3", "", true},
+		{"gcpsm://my-project/github-app#GITHUB_APP_ID", "projects/my-project/secrets/github-app/versions/latest", "GITHUB_APP_ID", true},
+		{"gcpsm://my-project/github-app | jsonkey=GITHUB_APP_ID", "projects/my-project/secrets/github-app/versions/latest", "GITHUB_APP_ID", true},
+		{"gcpsm://my-project", "", "", false},
+		{"gcpsm://My_Project/github-app", "", "", false},
+		{"azkv://vault/github-app", "", "", false},
+	}
+	for _, tt := range tests {
+		name, key, ok := ParseGCPRef(tt.ref)
+		if name != tt.wantName || key != tt.wantKey || ok != tt.wantOK {
+			t.Errorf("ParseGCPRef(%q) = (%q, %q, %v), want (%q, %q, %v)",
+				tt.ref, name, key, ok, tt.wantName, tt.wantKey, tt.wantOK)
+		}
+	}
+}
+
+func TestParseAzureRef(t *testing.T) {
+	tests := []struct {
+		ref       string
+		wantVault string
+		wantName  string
+		wantKey   string
+		wantOK    bool
+	}{
+		{"azkv://my-vault/github-app", "https://my-vault.vault.azure.net", "github-app", "", true},
+		{"azkv://my-vault.vault.usgovcloudapi.net/github-app", "https://my-vault.vault.usgovcloudapi.net", "github-app", "", true},
+		{"azkv://my-vault/github-app#GITHUB_APP_ID", "https://my-vault.vault.azure.net", "github-app", "GITHUB_APP_ID", true},
+		{"azkv://my-vault/github_app", "", "", "", false},
+		{"azkv://my-vault", "", "", "", false},
+	}
+	for _, tt := range tests {
+		vault, name, key, ok := ParseAzureRef(tt.ref)
+		if vault != tt.wantVault || name != tt.wantName || key != tt.wantKey || ok != tt.wantOK {
+			t.Errorf("ParseAzureRef(%q) = (%q, %q, %q, %v), want (%q, %q, %q, %v)",
+				tt.ref, vault, name, key, ok, tt.wantVault, tt.wantName, tt.wantKey, tt.wantOK)
+		}
+	}
+}
+
+func TestRegistryResolveEnvironment(t *testing.T) {
+	ssmClient := &fakeSSM{params: map[string]string{"/octo-sts/GITHUB_APP_ID": "1234"}}
+	gcpClient := &fakeGCPSecrets{secrets: map[string]string{
+		"projects/my-project/secrets/github-app/versions/latest": `{"GITHUB_WEBHOOK_SECRET":"gcp-webhook","GITHUB_CLIENT_ID":"gcp-client"}`,
+	}}
+	vault := &fakeKeyVault{secrets: map[string]string{"github-app-key": "pem"}}
+	var vaultURIs []string
+
+	registry := NewRegistry()
+	registry.Register(NewWithClients(ssmClient, &fakeSecretsManager{}), AWSSchemes...)
+	registry.Register(NewGCPResolverWithClient(gcpClient), GCPScheme)
+	registry.Register(NewAzureResolverWithClients(func(vaultURI string) (AzureSecretClient, error) {
+		vaultURIs = append(vaultURIs, vaultURI)
+		return vault, nil
+	}), AzureScheme)
+
+	env := map[string]string{
+		"TEST_REGISTRY_APP_ID":         "ssm:///octo-sts/GITHUB_APP_ID",
+		"TEST_REGISTRY_WEBHOOK_SECRET": "gcpsm://my-project/github-app#GITHUB_WEBHOOK_SECRET",
+		"TEST_REGISTRY_CLIENT_ID":      "gcpsm://my-project/github-app#GITHUB_CLIENT_ID",
+		"TEST_REGISTRY_KEY":            "azkv://my-vault/github-app-key",
+		"TEST_REGISTRY_PLAIN":          "plain-value",
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	t.Cleanup(func() {
+		resolved.Lock()
+		for key := range env {
+			delete(resolved.refs, key)
+		}
+		resolved.Unlock()
+	})
+
+	if err := registry.ResolveEnvironment(context.Background()); err != nil {
+		t.Fatalf("ResolveEnvironment() error = %v", err)
+	}
+
+	want := map[string]string{
+		"TEST_REGISTRY_APP_ID":         "1234",
+		"TEST_REGISTRY_WEBHOOK_SECRET": "gcp-webhook",
+		"TEST_REGISTRY_CLIENT_ID":      "gcp-client",
+		"TEST_REGISTRY_KEY":            "pem",
+		"TEST_REGISTRY_PLAIN":          "plain-value",
+	}
+	for key, value := range want {
+		if got := os.Getenv(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+	if gcpClient.reads != 1 {
+		t.Errorf("expected the shared Google secret to be read once, got %d reads", gcpClient.reads)
+	}
+	if len(vaultURIs) != 1 || vaultURIs[0] != "https://my-vault.vault.azure.net" {
+		t.Errorf("vault clients = %v, want one for https://my-vault.vault.azure.net", vaultURIs)
+	}
+}
+
+func TestRegistryIsRef(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(NewGCPResolverWithClient(&fakeGCPSecrets{}), GCPScheme)
+
+	if !registry.IsRef("gcpsm://my-project/github-app") {
+		t.Error("expected a Google Secret Manager reference to match")
+	}
+	for _, value := range []string{"ssm:///octo-sts/GITHUB_APP_ID", "gcpsm://", "plain-value"} {
+		if registry.IsRef(value) {
+			t.Errorf("IsRef(%q) = true, want false", value)
+		}
+	}
+}
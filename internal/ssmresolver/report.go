@@ -0,0 +1,143 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Results of a reference, as reported by ReferenceReport and the
+// octo_sts_resolver_references_total counter.
+const (
+	// ResultResolved is a reference read from its source.
+	ResultResolved = "resolved"
+	// ResultCached is a reference served from the cache.
+	ResultCached = "cached"
+	// ResultFailed is a reference that failed to resolve, whether or not the
+	// policy made it optional.
+	ResultFailed = "failed"
+)
+
+var (
+	referencesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "octo_sts_resolver_references_total",
+		Help: "Environment variable references resolved, by scheme and result.",
+	}, []string{"scheme", "result"})
+	resolutionDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "octo_sts_resolver_duration_seconds",
+		Help:    "Duration of environment resolutions with references.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+// Report summarizes a resolution of the environment.
+type Report struct {
+	// Duration is how long resolving the references took.
+	Duration time.Duration `json:"duration"`
+	// References holds the outcome of each reference, by variable name.
+	References []ReferenceReport `json:"references"`
+}
+
+// ReferenceReport is the outcome of the reference in one variable.
+type ReferenceReport struct {
+	// Key is the environment variable holding the reference.
+	Key string `json:"key"`
+	// Ref is the reference, naming the parameter or secret it was read from.
+	Ref string `json:"ref"`
+	// Scheme is the scheme of Ref, such as ssm or gcpsm.
+	Scheme string `json:"scheme"`
+	// Result is ResultResolved, ResultCached, or ResultFailed.
+	Result string `json:"result"`
+	// Error is why the reference failed to resolve.
+	Error string `json:"error,omitempty"`
+}
+
+// Count returns the number of references with result.
+func (r *Report) Count(result string) int {
+	n := 0
+	for _, ref := range r.References {
+		if ref.Result == result {
+			n++
+		}
+	}
+	return n
+}
+
+// cachingResolver is implemented by resolvers that report which variables
+// were served from their cache.
+type cachingResolver interface {
+	resolveRefs(ctx context.Context, refs map[string]string) (cached map[string]bool, err error)
+}
+
+// resolveRefs resolves refs with r, adding their outcome to report.
+func (report *Report) resolveRefs(ctx context.Context, r Resolver, refs map[string]string) error {
+	var cached map[string]bool
+	var err error
+	if c, ok := r.(cachingResolver); ok {
+		cached, err = c.resolveRefs(ctx, refs)
+	} else {
+		err = r.ResolveRefs(ctx, refs)
+	}
+
+	failed := make(map[string]error)
+	for _, err := range unjoin(err) {
+		var refErr *RefError
+		if errors.As(err, &refErr) {
+			failed[refErr.Key] = refErr.Err
+		}
+	}
+	for key, ref := range refs {
+		entry := ReferenceReport{Key: key, Ref: ref, Result: ResultResolved}
+		entry.Scheme, _, _ = strings.Cut(ref, ":")
+		switch {
+		case failed[key] != nil:
+			entry.Result, entry.Error = ResultFailed, failed[key].Error()
+		case err != nil && len(failed) == 0:
+			// The error is not attributable to a variable, so none is
+			// known to have resolved.
+			entry.Result, entry.Error = ResultFailed, err.Error()
+		case cached[key]:
+			entry.Result = ResultCached
+		}
+		referencesTotal.WithLabelValues(entry.Scheme, entry.Result).Inc()
+		report.References = append(report.References, entry)
+	}
+	slices.SortFunc(report.References, func(a, b ReferenceReport) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return err
+}
+
+// log logs the report, unless no references were resolved.
+func (report *Report) log(ctx context.Context) {
+	if len(report.References) == 0 {
+		return
+	}
+	resolutionDuration.Observe(report.Duration.Seconds())
+	clog.FromContext(ctx).Info("[ssmresolver] resolved environment references",
+		"resolved", report.Count(ResultResolved),
+		"cached", report.Count(ResultCached),
+		"failed", report.Count(ResultFailed),
+		"duration", report.Duration.String(),
+		"references", report.References)
+}
+
+// unjoin returns the errors joined in err, or err itself.
+func unjoin(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
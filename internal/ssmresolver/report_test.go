@@ -0,0 +1,61 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRegistryResolveReport(t *testing.T) {
+	ssmClient := &fakeSSM{params: map[string]string{"/octo-sts/GITHUB_APP_ID": "1234"}}
+	gcpClient := &fakeGCPSecrets{secrets: map[string]string{}}
+	registry := NewRegistry(WithPolicy(Policy{Optional: []string{"TEST_REPORT_ORG_FILTER"}}))
+	registry.Register(NewWithClients(ssmClient, &fakeSecretsManager{}, WithCacheTTL(time.Minute)), AWSSchemes...)
+	registry.Register(NewGCPResolverWithClient(gcpClient), GCPScheme)
+
+	env := map[string]string{
+		"TEST_REPORT_APP_ID":     "ssm:///octo-sts/GITHUB_APP_ID",
+		"TEST_REPORT_ORG_FILTER": "gcpsm://my-project/org-filter",
+	}
+	setEnv := func() {
+		for key, value := range env {
+			t.Setenv(key, value)
+		}
+	}
+	t.Cleanup(func() {
+		resolved.Lock()
+		for key := range env {
+			delete(resolved.refs, key)
+		}
+		resolved.Unlock()
+	})
+
+	ctx := context.Background()
+	want := map[string]string{"TEST_REPORT_APP_ID": ResultResolved, "TEST_REPORT_ORG_FILTER": ResultFailed}
+	for range 2 {
+		setEnv()
+		report, err := registry.Resolve(ctx)
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if len(report.References) != len(want) {
+			t.Fatalf("Resolve() reported %d references, want %d", len(report.References), len(want))
+		}
+		for _, ref := range report.References {
+			if ref.Result != want[ref.Key] {
+				t.Errorf("%s result = %q, want %q", ref.Key, ref.Result, want[ref.Key])
+			}
+			if ref.Ref != env[ref.Key] {
+				t.Errorf("%s ref = %q, want %q", ref.Key, ref.Ref, env[ref.Key])
+			}
+		}
+		if failed := report.References[1]; failed.Scheme != GCPScheme || failed.Error == "" {
+			t.Errorf("failed reference = %+v, want the gcpsm scheme and an error", failed)
+		}
+		// The second resolution is served from the cache.
+		want["TEST_REPORT_APP_ID"] = ResultCached
+	}
+}
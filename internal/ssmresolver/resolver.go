@@ -0,0 +1,622 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package ssmresolver resolves secret references in environment variables,
+// so deployments can pass SSM parameters, Secrets Manager secrets, and the
+// secrets of other clouds by reference instead of by value. It supersedes the
+// ghappsetup library's resolver of the same name, which only knows SSM
+// parameters.
+//
+// Supported references:
+//   - arn:aws:ssm:<region>:<account>:parameter/<name>
+//   - ssm://<name>, e.g. ssm:///octo-sts/prod/GITHUB_APP_ID
+//   - arn:aws:secretsmanager:<region>:<account>:secret:<name>
+//   - secretsmanager://<name>
+//   - gcpsm://<project>/<secret>[/<version>] for Google Secret Manager
+//   - azkv://<vault>/<secret> for Azure Key Vault
+//
+// Any reference can be followed by #<key>, or by "| jsonkey=<key>", to
+// select a field of a JSON value, such as a secret written by the
+// aws-secretsmanager configstore backend. One JSON parameter or secret can
+// so populate several variables. Parameters can be pinned to a version or
+// label, as in ssm:///octo-sts/prod/GITHUB_APP_ID:3.
+//
+// ARNs are read in the region they name, and parameters shared from another
+// account by their full ARN. The URI forms are resolved in the account and
+// region of the AWS config, so the same value works across deployments.
+//
+// Each scheme is resolved by a Resolver; a Registry dispatches references to
+// the Resolver of their scheme.
+package ssmresolver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+var (
+	ssmARNPattern    = regexp.MustCompile(`^(arn:aws[a-z-]*:ssm:[^:]+:[^:]+:parameter/([^#]+))(?:#(.+))?$`)
+	secretARNPattern = regexp.MustCompile(`^(arn:aws[a-z-]*:secretsmanager:[^:]+:[^:]+:secret:[^#]+)(?:#(.+))?$`)
+	ssmURIPattern    = regexp.MustCompile(`^ssm://(/?[^/#][^#]*)(?:#(.+))?$`)
+	secretURIPattern = regexp.MustCompile(`^secretsmanager://([^#]+)(?:#(.+))?$`)
+
+	// selectorPattern matches the version number or label a parameter name
+	// can be pinned to with a colon, as in /octo-sts/GITHUB_APP_ID:3.
+	selectorPattern = regexp.MustCompile(`^(?:[0-9]+|[A-Za-z_.-][A-Za-z0-9_.-]{0,99})$`)
+)
+
+// maxParametersPerCall is the most names SSM accepts per GetParameters call.
+const maxParametersPerCall = 10
+
+// SSMClient defines the interface for SSM parameter reads.
+type SSMClient interface {
+	GetParameters(ctx context.Context, params *ssm.GetParametersInput,
+		optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error)
+}
+
+// SecretsManagerClient defines the interface for Secrets Manager reads.
+type SecretsManagerClient interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput,
+		optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// AWSResolver resolves SSM parameter and Secrets Manager references.
+type AWSResolver struct {
+	// clients returns the clients for a region, or for the region of the
+	// AWS config when region is empty.
+	clients    func(region string) clientSet
+	cache      *cache
+	now        func() time.Time
+	roleARN    string
+	externalID string
+	transport  string
+
+	listMode      string
+	listDelimiter string
+
+	path string
+
+	concurrency int
+}
+
+// resolved remembers the references that were resolved into the
+// environment, so they are fetched again on reload instead of keeping the
+// value from the first load.
+var resolved = struct {
+	sync.Mutex
+	refs map[string]string
+	// indexed holds how many indexed variables each StringList variable
+	// was expanded into.
+	indexed map[string]int
+	// pathKeys holds the variables loaded from the parameters under a path.
+	pathKeys map[string]bool
+}{
+	refs:     make(map[string]string),
+	indexed:  make(map[string]int),
+	pathKeys: make(map[string]bool),
+}
+
+// New creates an AWSResolver using the default AWS config, with the role set by
+// WithAssumeRole if any, reading through the transport set by WithTransport.
+func New(ctx context.Context, opts ...Option) (*AWSResolver, error) {
+	r := newResolver(opts)
+	if r.roleARN != "" {
+		if parsed, err := arn.Parse(r.roleARN); err != nil || parsed.Service != "iam" || !strings.HasPrefix(parsed.Resource, "role/") {
+			return nil, fmt.Errorf("invalid role ARN to assume: %q", r.roleARN)
+		}
+	}
+	extension, err := useExtension(r.transport)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	if r.roleARN != "" {
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), r.roleARN, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = assumeRoleSessionName
+			if r.externalID != "" {
+				o.ExternalID = aws.String(r.externalID)
+			}
+		})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+	r.clients = regionalClients(cfg)
+	if extension && r.roleARN == "" {
+		sdkClients, ext := r.clients, newExtensionClient()
+		r.clients = func(region string) clientSet {
+			if region == "" || region == cfg.Region {
+				return clientSet{ssm: ext, secrets: ext, paths: sdkClients(region).paths}
+			}
+			return sdkClients(region)
+		}
+	}
+	return r, nil
+}
+
+// NewWithClients creates an AWSResolver with custom SSM and Secrets Manager
+// clients, used for every region. WithPath requires ssmClient to implement
+// SSMPathClient.
+func NewWithClients(ssmClient SSMClient, secretsClient SecretsManagerClient, opts ...Option) *AWSResolver {
+	r := newResolver(opts)
+	pathClient, _ := ssmClient.(SSMPathClient)
+	r.clients = func(string) clientSet {
+		return clientSet{ssm: ssmClient, secrets: secretsClient, paths: pathClient}
+	}
+	return r
+}
+
+func newResolver(opts []Option) *AWSResolver {
+	r := &AWSResolver{now: time.Now}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// jsonKeySuffix is the prefix of the "| jsonkey=<key>" suffix of a reference.
+const jsonKeySuffix = "jsonkey="
+
+// normalizeRef rewrites a "<ref> | jsonkey=<key>" reference to <ref>#<key>.
+// A malformed suffix returns "", which is not a reference.
+func normalizeRef(ref string) string {
+	base, suffix, ok := strings.Cut(ref, "|")
+	if !ok {
+		return ref
+	}
+	key, ok := strings.CutPrefix(strings.TrimSpace(suffix), jsonKeySuffix)
+	base = strings.TrimSpace(base)
+	if !ok || key == "" || strings.Contains(base, "#") {
+		return ""
+	}
+	return base + "#" + key
+}
+
+// IsSSMARN checks if the given value is an SSM parameter ARN, with or
+// without a key suffix.
+func IsSSMARN(value string) bool {
+	return ssmARNPattern.MatchString(normalizeRef(value))
+}
+
+// IsSecretARN checks if the given value is a Secrets Manager secret ARN,
+// with or without a key suffix.
+func IsSecretARN(value string) bool {
+	return secretARNPattern.MatchString(normalizeRef(value))
+}
+
+// IsRef checks if the given value is a reference the AWSResolver resolves.
+func IsRef(value string) bool {
+	if _, _, ok := ParseParameterRef(value); ok {
+		return true
+	}
+	_, _, ok := ParseSecretRef(value)
+	return ok
+}
+
+// ExtractParameterName extracts the parameter name from an SSM ARN.
+func ExtractParameterName(arn string) (string, bool) {
+	matches := ssmARNPattern.FindStringSubmatch(normalizeRef(arn))
+	if len(matches) != 4 {
+		return "", false
+	}
+	name := matches[2]
+	if !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+	return name, true
+}
+
+// ParseParameterRef splits an SSM ARN or ssm:// URI into the parameter name
+// and the JSON key, which is empty when the whole value is referenced. The
+// URI path is the name as is, so ssm:///a/b names /a/b and ssm://a names a.
+// A name pinned to a version or label keeps its selector, as in
+// /octo-sts/GITHUB_APP_ID:3; see SplitSelector.
+func ParseParameterRef(ref string) (name, key string, ok bool) {
+	ref = normalizeRef(ref)
+	if matches := ssmARNPattern.FindStringSubmatch(ref); len(matches) == 4 {
+		name, _ = ExtractParameterName(matches[1])
+		key = matches[3]
+	} else if matches := ssmURIPattern.FindStringSubmatch(ref); len(matches) == 3 {
+		name, key = matches[1], matches[2]
+	} else {
+		return "", "", false
+	}
+	if _, _, ok := SplitSelector(name); !ok {
+		return "", "", false
+	}
+	return name, key, true
+}
+
+// SplitSelector splits a parameter name or ARN pinned to a version or label,
+// such as /octo-sts/GITHUB_APP_ID:3 or /octo-sts/GITHUB_APP_ID:prod, into
+// the unpinned name and the selector, without its colon. The selector is
+// empty for an unpinned name. ok is false for a malformed selector.
+func SplitSelector(name string) (base, selector string, ok bool) {
+	// The colons of an ARN precede the name, which itself has none.
+	prefix := ""
+	if parsed, err := arn.Parse(name); err == nil {
+		prefix, name = strings.TrimSuffix(name, parsed.Resource), parsed.Resource
+	}
+	base, selector, found := strings.Cut(name, ":")
+	if !found {
+		return prefix + name, "", true
+	}
+	if !selectorPattern.MatchString(selector) {
+		return "", "", false
+	}
+	return prefix + base, selector, true
+}
+
+// ParseSecretRef splits a Secrets Manager ARN or secretsmanager:// URI into
+// the secret ID and the JSON key, which is empty when the whole secret is
+// referenced.
+func ParseSecretRef(ref string) (secretID, key string, ok bool) {
+	ref = normalizeRef(ref)
+	matches := secretARNPattern.FindStringSubmatch(ref)
+	if len(matches) != 3 {
+		matches = secretURIPattern.FindStringSubmatch(ref)
+	}
+	if len(matches) != 3 {
+		return "", "", false
+	}
+	return matches[1], matches[2], true
+}
+
+// ResolveValue resolves a reference to its value, or returns it unchanged.
+func (r *AWSResolver) ResolveValue(ctx context.Context, value string) (string, error) {
+	if !IsRef(value) {
+		return value, nil
+	}
+	f := newFetched()
+	r.cached(f)
+	value, err := r.resolveRef(ctx, value, f)
+	if err != nil {
+		return "", err
+	}
+	r.store(f)
+	return value, nil
+}
+
+// fetched holds the parameter and secret values read during one resolution,
+// so each is read at most once however many references share it.
+type fetched struct {
+	params  map[string]string
+	secrets map[string]string
+	// lists holds the IDs of the StringList parameters in params.
+	lists map[string]bool
+	// cached holds the cache keys of the values taken from the cache.
+	cached map[string]bool
+	// errs holds why values failed to be read, by cache key, so each
+	// failure is reported for every reference to it without reading again.
+	errs map[string]error
+}
+
+func newFetched() *fetched {
+	return &fetched{
+		params:  make(map[string]string),
+		secrets: make(map[string]string),
+		lists:   make(map[string]bool),
+		cached:  make(map[string]bool),
+		errs:    make(map[string]error),
+	}
+}
+
+// parameterID splits a parameter reference into the ID it is read by and
+// the JSON key. The ID is the full ARN, which is required for parameters
+// shared from another account, or the name of an ssm:// URI.
+func parameterID(ref string) (id, key string, ok bool) {
+	if matches := ssmARNPattern.FindStringSubmatch(normalizeRef(ref)); len(matches) == 4 {
+		return matches[1], matches[3], true
+	}
+	return ParseParameterRef(ref)
+}
+
+// cacheKey returns the cache key of the value a reference is read from.
+func cacheKey(ref string) string {
+	if id, _, ok := parameterID(ref); ok {
+		return cacheParamPrefix + id
+	}
+	secretID, _, _ := ParseSecretRef(ref)
+	return cacheSecretPrefix + secretID
+}
+
+// resolveRef resolves a reference, reading values missing from f.
+func (r *AWSResolver) resolveRef(ctx context.Context, ref string, f *fetched) (string, error) {
+	if id, key, ok := parameterID(ref); ok {
+		value, ok := f.params[id]
+		if !ok {
+			if err, failed := f.errs[cacheParamPrefix+id]; failed {
+				return "", err
+			}
+			if err := r.getParameters(ctx, []string{id}, f); err != nil {
+				return "", err
+			}
+			value = f.params[id]
+		}
+		if key == "" {
+			return value, nil
+		}
+		return jsonField("SSM parameter "+id, value, key)
+	}
+
+	secretID, key, ok := ParseSecretRef(ref)
+	if !ok {
+		return "", fmt.Errorf("invalid AWS reference: %s", ref)
+	}
+	secret, ok := f.secrets[secretID]
+	if !ok {
+		if err, failed := f.errs[cacheSecretPrefix+secretID]; failed {
+			return "", err
+		}
+		var err error
+		secret, err = r.getSecret(ctx, secretID)
+		if err != nil {
+			f.errs[cacheSecretPrefix+secretID] = err
+			return "", err
+		}
+		f.secrets[secretID] = secret
+	}
+	if key == "" {
+		return secret, nil
+	}
+	return jsonField("secret "+secretID, secret, key)
+}
+
+// getParameters reads and decrypts SSM parameters into f, keyed by name or
+// ARN as requested, batching maxParametersPerCall names per request to the
+// region of each and running up to the concurrency limit of requests at
+// once. Failures are recorded in f per parameter and returned joined, naming
+// the parameters that do not exist.
+func (r *AWSResolver) getParameters(ctx context.Context, names []string, f *fetched) error {
+	byRegion := make(map[string][]string)
+	for _, name := range names {
+		region := regionOf(name)
+		byRegion[region] = append(byRegion[region], name)
+	}
+
+	type batch struct {
+		client SSMClient
+		names  []string
+	}
+	var batches []batch
+	for _, region := range slices.Sorted(maps.Keys(byRegion)) {
+		names := byRegion[region]
+		slices.Sort(names)
+		names = slices.Compact(names)
+
+		client := r.clients(region).ssm
+		for chunk := range slices.Chunk(names, maxParametersPerCall) {
+			batches = append(batches, batch{client: client, names: chunk})
+		}
+	}
+
+	var mu sync.Mutex
+	var errs []error
+	var missing []string
+	forEach(r.concurrency, batches, func(b batch) {
+		resp, err := b.client.GetParameters(ctx, &ssm.GetParametersInput{
+			Names:          b.names,
+			WithDecryption: aws.Bool(true),
+		})
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			err = fmt.Errorf("failed to get SSM parameters %s: %w", strings.Join(b.names, ", "), err)
+			for _, name := range b.names {
+				f.errs[cacheParamPrefix+name] = err
+			}
+			errs = append(errs, err)
+			return
+		}
+		for _, param := range resp.Parameters {
+			// A name with a version or label selector is returned without it.
+			selector := aws.ToString(param.Selector)
+			ids := []string{aws.ToString(param.Name) + selector}
+			if param.ARN != nil {
+				ids = append(ids, aws.ToString(param.ARN)+selector)
+			}
+			for _, id := range ids {
+				f.params[id] = aws.ToString(param.Value)
+				f.lists[id] = param.Type == types.ParameterTypeStringList
+			}
+		}
+		for _, name := range resp.InvalidParameters {
+			f.errs[cacheParamPrefix+name] = fmt.Errorf("SSM parameter not found: %s", name)
+		}
+		missing = append(missing, resp.InvalidParameters...)
+	})
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		errs = append(errs, fmt.Errorf("SSM parameters not found: %s", strings.Join(missing, ", ")))
+	}
+	return errors.Join(errs...)
+}
+
+// getSecrets reads secrets into f, running up to the concurrency limit of
+// reads at once. Failures are recorded in f per secret and returned joined.
+func (r *AWSResolver) getSecrets(ctx context.Context, secretIDs []string, f *fetched) error {
+	var mu sync.Mutex
+	var errs []error
+	forEach(r.concurrency, secretIDs, func(secretID string) {
+		secret, err := r.getSecret(ctx, secretID)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			f.errs[cacheSecretPrefix+secretID] = err
+			errs = append(errs, err)
+			return
+		}
+		f.secrets[secretID] = secret
+	})
+	return errors.Join(errs...)
+}
+
+// getSecret reads the current value of a secret.
+func (r *AWSResolver) getSecret(ctx context.Context, secretID string) (string, error) {
+	resp, err := r.clients(regionOf(secretID)).secrets.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get secret %s: %w", secretID, err)
+	}
+	switch {
+	case resp.SecretString != nil:
+		return *resp.SecretString, nil
+	case resp.SecretBinary != nil:
+		return string(resp.SecretBinary), nil
+	default:
+		return "", fmt.Errorf("secret %s has no value", secretID)
+	}
+}
+
+// jsonField returns a field of the JSON value of source, e.g. "secret
+// <id>". Non-string fields are returned as JSON.
+func jsonField(source, value, key string) (string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("%s is not a JSON object, so key %s cannot be selected", source, key)
+	}
+	raw, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("%s has no key %s", source, key)
+	}
+	var field string
+	if err := json.Unmarshal(raw, &field); err != nil {
+		return string(raw), nil
+	}
+	return field, nil
+}
+
+// ResolveEnvironment resolves any AWS references in environment variables,
+// after loading the parameters under the path set by WithPath, if any.
+// References resolved by a previous call are resolved again.
+func (r *AWSResolver) ResolveEnvironment(ctx context.Context) error {
+	if err := r.LoadEnvironment(ctx); err != nil {
+		return err
+	}
+	return r.ResolveRefs(ctx, pendingRefs(IsRef))
+}
+
+// IsRef reports whether value is an SSM or Secrets Manager reference.
+func (r *AWSResolver) IsRef(value string) bool {
+	return IsRef(value)
+}
+
+// LoadEnvironment loads the parameters under the path set by WithPath into
+// the environment. It is a no-op when no path is set.
+func (r *AWSResolver) LoadEnvironment(ctx context.Context) error {
+	if r.path == "" {
+		return nil
+	}
+	return r.loadPath(ctx)
+}
+
+// ResolveRefs resolves refs, keyed by environment variable name, and sets
+// the variables to their values. A reference that fails to resolve is
+// reported as a *RefError, joined with the others, and the rest are still
+// resolved.
+func (r *AWSResolver) ResolveRefs(ctx context.Context, refs map[string]string) error {
+	_, err := r.resolveRefs(ctx, refs)
+	return err
+}
+
+// resolveRefs is ResolveRefs, also returning the variables whose value was
+// served from the cache.
+func (r *AWSResolver) resolveRefs(ctx context.Context, refs map[string]string) (map[string]bool, error) {
+	// Read all uncached parameters and secrets up front, so parameters take
+	// one request per batch instead of one per variable, and a cold start
+	// doesn't wait on each batch and secret in turn. Failures are recorded
+	// in f and reported per variable below.
+	f := newFetched()
+	r.cached(f)
+	var names, secretIDs []string
+	for _, ref := range refs {
+		if name, _, ok := parameterID(ref); ok {
+			if _, ok := f.params[name]; !ok {
+				names = append(names, name)
+			}
+		} else if secretID, _, ok := ParseSecretRef(ref); ok {
+			if _, ok := f.secrets[secretID]; !ok && !slices.Contains(secretIDs, secretID) {
+				secretIDs = append(secretIDs, secretID)
+			}
+		}
+	}
+	if len(names) > 0 {
+		_ = r.getParameters(ctx, names, f)
+	}
+	if len(secretIDs) > 0 {
+		_ = r.getSecrets(ctx, secretIDs, f)
+	}
+
+	var errs []error
+	cached := make(map[string]bool)
+	for key, ref := range refs {
+		value, err := r.resolveRef(ctx, ref, f)
+		if err != nil {
+			errs = append(errs, &RefError{Key: key, Ref: ref, Err: err})
+			continue
+		}
+		cached[key] = f.cached[cacheKey(ref)]
+
+		vars := map[string]string{key: value}
+		if id, jsonKey, ok := parameterID(ref); ok && jsonKey == "" && f.lists[id] {
+			vars = r.expandList(key, value)
+		}
+		for name, value := range vars {
+			if err := os.Setenv(name, value); err != nil {
+				return nil, fmt.Errorf("failed to set %s: %w", name, err)
+			}
+		}
+		// The variables besides key are the indexed items, if any.
+		if err := setIndexed(key, len(vars)-1); err != nil {
+			return nil, err
+		}
+	}
+	r.store(f)
+	return cached, errors.Join(errs...)
+}
+
+// pendingRefs records the references matched by isRef currently in the
+// environment and returns all such references seen so far, keyed by
+// environment variable name.
+func pendingRefs(isRef func(string) bool) map[string]string {
+	resolved.Lock()
+	defer resolved.Unlock()
+
+	for _, env := range os.Environ() {
+		key, value, ok := strings.Cut(env, "=")
+		if ok && isRef(value) {
+			resolved.refs[key] = value
+		}
+	}
+
+	refs := make(map[string]string)
+	for key, ref := range resolved.refs {
+		if isRef(ref) {
+			refs[key] = ref
+		}
+	}
+	return refs
+}
@@ -0,0 +1,409 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// fakeSSM is an in-memory SSMClient keyed by parameter name. Parameters can
+// also be read by ARN.
+type fakeSSM struct {
+	params map[string]string
+	// lists holds the names of the StringList parameters.
+	lists map[string]bool
+
+	mu    sync.Mutex
+	calls [][]string
+}
+
+func (f *fakeSSM) GetParameters(_ context.Context, in *ssm.GetParametersInput,
+	_ ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	if len(in.Names) > maxParametersPerCall {
+		return nil, errors.New("ValidationException: too many names")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, in.Names)
+	out := &ssm.GetParametersOutput{}
+	for _, requested := range in.Names {
+		name := requested
+		if n, ok := ExtractParameterName(requested); ok {
+			name = n
+		}
+		value, ok := f.params[name]
+		if !ok {
+			out.InvalidParameters = append(out.InvalidParameters, requested)
+			continue
+		}
+		// Pinned parameters are returned by name, with the selector apart.
+		base, selector, _ := SplitSelector(name)
+		param := types.Parameter{Name: aws.String(base), Value: aws.String(value), Type: types.ParameterTypeString}
+		if selector != "" {
+			param.Selector = aws.String(":" + selector)
+		}
+		if f.lists[name] {
+			param.Type = types.ParameterTypeStringList
+		}
+		if name != requested {
+			baseARN, _, _ := SplitSelector(requested)
+			param.ARN = aws.String(baseARN)
+		}
+		out.Parameters = append(out.Parameters, param)
+	}
+	return out, nil
+}
+
+// fakeSecretsManager is an in-memory SecretsManagerClient keyed by secret ID.
+type fakeSecretsManager struct {
+	secrets map[string]string
+
+	mu    sync.Mutex
+	reads int
+}
+
+func (f *fakeSecretsManager) GetSecretValue(_ context.Context, in *secretsmanager.GetSecretValueInput,
+	_ ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reads++
+	value, ok := f.secrets[aws.ToString(in.SecretId)]
+	if !ok {
+		return nil, errors.New("ResourceNotFoundException")
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(value)}, nil
+}
+
+const testSecretARN = "arn:aws:secretsmanager:us-east-1:123456789012:secret:octo-sts/app-AbCdEf"
+
+func TestParseRefs(t *testing.T) {
+	tests := []struct {
+		ref        string
+		wantSSM    bool
+		wantSecret bool
+		wantID     string
+		wantKey    string
+	}{
+		{ref: "arn:aws:ssm:us-east-1:123456789012:parameter/octo-sts/GITHUB_APP_ID", wantSSM: true, wantID: "/octo-sts/GITHUB_APP_ID"},
+		{ref: "arn:aws-us-gov:ssm:us-gov-west-1:123456789012:parameter/octo-sts/GITHUB_APP_ID", wantSSM: true, wantID: "/octo-sts/GITHUB_APP_ID"},
+		{ref: "arn:aws:ssm:us-east-1:123456789012:parameter/octo-sts/app#private_key", wantSSM: true, wantID: "/octo-sts/app", wantKey: "private_key"},
+		{ref: "arn:aws:ssm:us-east-1:123456789012:parameter/octo-sts/app | jsonkey=private_key", wantSSM: true, wantID: "/octo-sts/app", wantKey: "private_key"},
+		{ref: "ssm:///octo-sts/prod/GITHUB_APP_ID", wantSSM: true, wantID: "/octo-sts/prod/GITHUB_APP_ID"},
+		{ref: "ssm://GITHUB_APP_ID", wantSSM: true, wantID: "GITHUB_APP_ID"},
+		{ref: "ssm:///octo-sts/app#app_id", wantSSM: true, wantID: "/octo-sts/app", wantKey: "app_id"},
+		{ref: "ssm:///octo-sts/GITHUB_APP_ID:3", wantSSM: true, wantID: "/octo-sts/GITHUB_APP_ID:3"},
+		{ref: "ssm:///octo-sts/app:prod#app_id", wantSSM: true, wantID: "/octo-sts/app:prod", wantKey: "app_id"},
+		{ref: "arn:aws:ssm:us-east-1:123456789012:parameter/octo-sts/GITHUB_APP_ID:prod", wantSSM: true, wantID: "/octo-sts/GITHUB_APP_ID:prod"},
+		{ref: "ssm:///octo-sts/GITHUB_APP_ID:"},
+		{ref: "ssm:///octo-sts/GITHUB_APP_ID:3:prod"},
+		{ref: testSecretARN, wantSecret: true, wantID: testSecretARN},
+		{ref: testSecretARN + "#GITHUB_APP_PRIVATE_KEY", wantSecret: true, wantID: testSecretARN, wantKey: "GITHUB_APP_PRIVATE_KEY"},
+		{ref: testSecretARN + "|jsonkey=GITHUB_APP_PRIVATE_KEY", wantSecret: true, wantID: testSecretARN, wantKey: "GITHUB_APP_PRIVATE_KEY"},
+		{ref: "secretsmanager://octo-sts/app", wantSecret: true, wantID: "octo-sts/app"},
+		{ref: "secretsmanager://octo-sts/app#GITHUB_APP_ID", wantSecret: true, wantID: "octo-sts/app", wantKey: "GITHUB_APP_ID"},
+		{ref: "arn:aws:secretsmanager:us-east-1:123456789012:octo-sts"},
+		{ref: "ssm://"},
+		{ref: "ssm:////"},
+		{ref: "ssm://#key"},
+		{ref: "secretsmanager://#key"},
+		{ref: "ssm:///octo-sts/app | key=private_key"},
+		{ref: "ssm:///octo-sts/app#a | jsonkey=b"},
+		{ref: "arn:aws:s3:::bucket"},
+		{ref: "plain-value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			name, key, ok := ParseParameterRef(tt.ref)
+			if ok != tt.wantSSM || (ok && (name != tt.wantID || key != tt.wantKey)) {
+				t.Errorf("ParseParameterRef() = (%q, %q, %v), want (%q, %q, %v)", name, key, ok, tt.wantID, tt.wantKey, tt.wantSSM)
+			}
+			id, key, ok := ParseSecretRef(tt.ref)
+			if ok != tt.wantSecret || (ok && (id != tt.wantID || key != tt.wantKey)) {
+				t.Errorf("ParseSecretRef() = (%q, %q, %v), want (%q, %q, %v)", id, key, ok, tt.wantID, tt.wantKey, tt.wantSecret)
+			}
+			if got := IsRef(tt.ref); got != (tt.wantSSM || tt.wantSecret) {
+				t.Errorf("IsRef() = %v, want %v", got, tt.wantSSM || tt.wantSecret)
+			}
+		})
+	}
+}
+
+func TestSplitSelector(t *testing.T) {
+	tests := []struct {
+		name         string
+		wantBase     string
+		wantSelector string
+		wantOK       bool
+	}{
+		{"/octo-sts/GITHUB_APP_ID", "/octo-sts/GITHUB_APP_ID", "", true},
+		{"/octo-sts/GITHUB_APP_ID:3", "/octo-sts/GITHUB_APP_ID", "3", true},
+		{"/octo-sts/GITHUB_APP_ID:prod", "/octo-sts/GITHUB_APP_ID", "prod", true},
+		{"arn:aws:ssm:us-east-1:123456789012:parameter/octo-sts/GITHUB_APP_ID", "arn:aws:ssm:us-east-1:123456789012:parameter/octo-sts/GITHUB_APP_ID", "", true},
+		{"arn:aws:ssm:us-east-1:123456789012:parameter/octo-sts/GITHUB_APP_ID:7", "arn:aws:ssm:us-east-1:123456789012:parameter/octo-sts/GITHUB_APP_ID", "7", true},
+		{"/octo-sts/GITHUB_APP_ID:", "", "", false},
+		{"/octo-sts/GITHUB_APP_ID:pro/d", "", "", false},
+	}
+	for _, tt := range tests {
+		base, selector, ok := SplitSelector(tt.name)
+		if base != tt.wantBase || selector != tt.wantSelector || ok != tt.wantOK {
+			t.Errorf("SplitSelector(%q) = (%q, %q, %v), want (%q, %q, %v)",
+				tt.name, base, selector, ok, tt.wantBase, tt.wantSelector, tt.wantOK)
+		}
+	}
+}
+
+func TestResolvePinnedParameters(t *testing.T) {
+	const paramARN = "arn:aws:ssm:us-east-1:123456789012:parameter/octo-sts/GITHUB_APP_ID"
+	ssmClient := &fakeSSM{params: map[string]string{
+		"/octo-sts/GITHUB_APP_ID":      "latest",
+		"/octo-sts/GITHUB_APP_ID:3":    "version-3",
+		"/octo-sts/GITHUB_APP_ID:prod": "label-prod",
+	}}
+	resolver := NewWithClients(ssmClient, &fakeSecretsManager{})
+
+	refs := map[string]string{
+		"TEST_PINNED_LATEST":    "ssm:///octo-sts/GITHUB_APP_ID",
+		"TEST_PINNED_VERSION":   "ssm:///octo-sts/GITHUB_APP_ID:3",
+		"TEST_PINNED_LABEL":     paramARN + ":prod",
+		"TEST_PINNED_ARN":       paramARN,
+		"TEST_PINNED_ARN_EXACT": paramARN + ":3",
+	}
+	for key := range refs {
+		t.Setenv(key, "")
+	}
+	if err := resolver.ResolveRefs(context.Background(), refs); err != nil {
+		t.Fatalf("ResolveRefs() error = %v", err)
+	}
+
+	want := map[string]string{
+		"TEST_PINNED_LATEST":    "latest",
+		"TEST_PINNED_VERSION":   "version-3",
+		"TEST_PINNED_LABEL":     "label-prod",
+		"TEST_PINNED_ARN":       "latest",
+		"TEST_PINNED_ARN_EXACT": "version-3",
+	}
+	for key, value := range want {
+		if got := os.Getenv(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestResolveParameterJSONKeys(t *testing.T) {
+	ssmClient := &fakeSSM{params: map[string]string{
+		"/octo-sts/app": `{"app_id":1234,"private_key":"pem"}`,
+	}}
+	resolver := NewWithClients(ssmClient, &fakeSecretsManager{})
+
+	env := map[string]string{
+		"TEST_AWS_JSON_APP_ID": "arn:aws:ssm:us-east-1:123456789012:parameter/octo-sts/app#app_id",
+		"TEST_AWS_JSON_KEY":    "arn:aws:ssm:us-east-1:123456789012:parameter/octo-sts/app | jsonkey=private_key",
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	t.Cleanup(func() {
+		resolved.Lock()
+		for key := range env {
+			delete(resolved.refs, key)
+		}
+		resolved.Unlock()
+	})
+
+	if err := resolver.ResolveEnvironment(context.Background()); err != nil {
+		t.Fatalf("ResolveEnvironment() error = %v", err)
+	}
+	for key, want := range map[string]string{"TEST_AWS_JSON_APP_ID": "1234", "TEST_AWS_JSON_KEY": "pem"} {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if len(ssmClient.calls) != 1 || len(ssmClient.calls[0]) != 1 {
+		t.Errorf("expected the parameter to be read once, got %v", ssmClient.calls)
+	}
+
+	if _, err := resolver.ResolveValue(context.Background(), "ssm:///octo-sts/app#missing"); err == nil {
+		t.Error("expected error for a missing key")
+	}
+}
+
+func TestResolveEnvironment(t *testing.T) {
+	ssmClient := &fakeSSM{params: map[string]string{
+		"/octo-sts/GITHUB_APP_ID":    "1234",
+		"/octo-sts/GITHUB_CLIENT_ID": "client",
+	}}
+	secrets := &fakeSecretsManager{secrets: map[string]string{
+		testSecretARN: `{"GITHUB_APP_PRIVATE_KEY":"pem","GITHUB_WEBHOOK_SECRET":"webhook"}`,
+		"arn:aws:secretsmanager:us-east-1:123456789012:secret:plain-XyZabc": "plain-secret",
+		"octo-sts/oauth": `{"GITHUB_CLIENT_SECRET":"oauth-secret"}`,
+	}}
+	resolver := NewWithClients(ssmClient, secrets)
+
+	env := map[string]string{
+		"TEST_AWS_APP_ID":      "arn:aws:ssm:us-east-1:123456789012:parameter/octo-sts/GITHUB_APP_ID",
+		"TEST_AWS_PRIVATE_KEY": testSecretARN + "#GITHUB_APP_PRIVATE_KEY",
+		"TEST_AWS_WEBHOOK":     testSecretARN + "#GITHUB_WEBHOOK_SECRET",
+		"TEST_AWS_PLAIN":       "arn:aws:secretsmanager:us-east-1:123456789012:secret:plain-XyZabc",
+		"TEST_AWS_CLIENT_ID":   "ssm:///octo-sts/GITHUB_CLIENT_ID",
+		"TEST_AWS_CLIENT_KEY":  "secretsmanager://octo-sts/oauth#GITHUB_CLIENT_SECRET",
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	t.Cleanup(func() {
+		resolved.Lock()
+		for key := range env {
+			delete(resolved.refs, key)
+		}
+		resolved.Unlock()
+	})
+
+	if err := resolver.ResolveEnvironment(context.Background()); err != nil {
+		t.Fatalf("ResolveEnvironment() error = %v", err)
+	}
+	for key, want := range map[string]string{
+		"TEST_AWS_APP_ID":      "1234",
+		"TEST_AWS_PRIVATE_KEY": "pem",
+		"TEST_AWS_WEBHOOK":     "webhook",
+		"TEST_AWS_PLAIN":       "plain-secret",
+		"TEST_AWS_CLIENT_ID":   "client",
+		"TEST_AWS_CLIENT_KEY":  "oauth-secret",
+	} {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if secrets.reads != 3 {
+		t.Errorf("expected each secret to be read once, got %d reads", secrets.reads)
+	}
+
+	// References are resolved again on reload.
+	ssmClient.params["/octo-sts/GITHUB_APP_ID"] = "5678"
+	if err := resolver.ResolveEnvironment(context.Background()); err != nil {
+		t.Fatalf("ResolveEnvironment() error = %v", err)
+	}
+	if got := os.Getenv("TEST_AWS_APP_ID"); got != "5678" {
+		t.Errorf("expected TEST_AWS_APP_ID to be resolved again, got %q", got)
+	}
+}
+
+func TestResolveEnvironmentBatchesParameters(t *testing.T) {
+	ssmClient := &fakeSSM{params: make(map[string]string)}
+	resolver := NewWithClients(ssmClient, &fakeSecretsManager{})
+
+	env := make(map[string]string)
+	for i := range 23 {
+		name := fmt.Sprintf("/octo-sts/PARAM_%d", i)
+		ssmClient.params[name] = strconv.Itoa(i)
+		env[fmt.Sprintf("TEST_AWS_BATCH_%d", i)] = "ssm://" + name
+	}
+	// Variables sharing a parameter read it once.
+	env["TEST_AWS_BATCH_DUP"] = "ssm:///octo-sts/PARAM_0"
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	t.Cleanup(func() {
+		resolved.Lock()
+		for key := range env {
+			delete(resolved.refs, key)
+		}
+		resolved.Unlock()
+	})
+
+	if err := resolver.ResolveEnvironment(context.Background()); err != nil {
+		t.Fatalf("ResolveEnvironment() error = %v", err)
+	}
+	if len(ssmClient.calls) != 3 {
+		t.Errorf("expected 3 GetParameters calls, got %d", len(ssmClient.calls))
+	}
+	if got := os.Getenv("TEST_AWS_BATCH_22"); got != "22" {
+		t.Errorf("TEST_AWS_BATCH_22 = %q, want %q", got, "22")
+	}
+	if got := os.Getenv("TEST_AWS_BATCH_DUP"); got != "0" {
+		t.Errorf("TEST_AWS_BATCH_DUP = %q, want %q", got, "0")
+	}
+
+	// A missing parameter fails the resolution and is named in the error.
+	delete(ssmClient.params, "/octo-sts/PARAM_7")
+	err := resolver.ResolveEnvironment(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "/octo-sts/PARAM_7") {
+		t.Errorf("expected error naming the missing parameter, got %v", err)
+	}
+}
+
+func TestResolveEnvironmentRegions(t *testing.T) {
+	const (
+		sharedParam  = "arn:aws:ssm:eu-west-1:210987654321:parameter/security/GITHUB_APP_ID"
+		sharedSecret = "arn:aws:secretsmanager:eu-west-1:210987654321:secret:octo-sts-AbCdEf"
+	)
+	local := &fakeSSM{params: map[string]string{"/octo-sts/GITHUB_CLIENT_ID": "client"}}
+	remote := &fakeSSM{params: map[string]string{"/security/GITHUB_APP_ID": "1234"}}
+	remoteSecrets := &fakeSecretsManager{secrets: map[string]string{sharedSecret: `{"GITHUB_APP_PRIVATE_KEY":"pem"}`}}
+	resolver := NewWithClients(nil, nil)
+	resolver.clients = func(region string) clientSet {
+		if region == "eu-west-1" {
+			return clientSet{ssm: remote, secrets: remoteSecrets}
+		}
+		return clientSet{ssm: local, secrets: &fakeSecretsManager{}}
+	}
+
+	env := map[string]string{
+		"TEST_AWS_REGION_APP_ID":    sharedParam,
+		"TEST_AWS_REGION_CLIENT_ID": "ssm:///octo-sts/GITHUB_CLIENT_ID",
+		"TEST_AWS_REGION_KEY":       sharedSecret + "#GITHUB_APP_PRIVATE_KEY",
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	t.Cleanup(func() {
+		resolved.Lock()
+		for key := range env {
+			delete(resolved.refs, key)
+		}
+		resolved.Unlock()
+	})
+
+	if err := resolver.ResolveEnvironment(context.Background()); err != nil {
+		t.Fatalf("ResolveEnvironment() error = %v", err)
+	}
+	for key, want := range map[string]string{
+		"TEST_AWS_REGION_APP_ID":    "1234",
+		"TEST_AWS_REGION_CLIENT_ID": "client",
+		"TEST_AWS_REGION_KEY":       "pem",
+	} {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	// Shared parameters must be read by their full ARN.
+	if len(remote.calls) != 1 || len(remote.calls[0]) != 1 || remote.calls[0][0] != sharedParam {
+		t.Errorf("expected the remote parameter to be read by ARN, got %v", remote.calls)
+	}
+}
+
+func TestNewInvalidRole(t *testing.T) {
+	if _, err := New(context.Background(), WithAssumeRole("arn:aws:s3:::bucket")); err == nil {
+		t.Error("expected error for a role ARN that is not an IAM role")
+	}
+}
+
+func TestResolveValueMissingKey(t *testing.T) {
+	resolver := NewWithClients(&fakeSSM{}, &fakeSecretsManager{secrets: map[string]string{testSecretARN: `{"A":"1"}`}})
+	if _, err := resolver.ResolveValue(context.Background(), testSecretARN+"#B"); err == nil {
+		t.Error("expected error for a missing key")
+	}
+}
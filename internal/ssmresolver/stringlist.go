@@ -0,0 +1,95 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// How StringList parameters are set in the environment.
+const (
+	// StringListJoin sets the variable to the items joined by the delimiter.
+	StringListJoin = "join"
+	// StringListIndexed also sets <VAR>_0, <VAR>_1, ... to the items, e.g.
+	// for several webhook secrets kept in one parameter.
+	StringListIndexed = "indexed"
+)
+
+// Environment variables configuring the StringList expansion of
+// ResolveEnvironmentWithDefaults.
+const (
+	EnvStringListMode      = "SSM_RESOLVER_STRINGLIST_MODE"
+	EnvStringListDelimiter = "SSM_RESOLVER_STRINGLIST_DELIMITER"
+)
+
+// stringListSeparator separates the items of a StringList value as SSM
+// returns it. Items cannot contain it.
+const stringListSeparator = ","
+
+// WithStringList sets how ResolveEnvironment sets StringList parameters:
+// StringListJoin (the default) or StringListIndexed, with the items joined
+// by delimiter, or by commas when delimiter is empty. References with a JSON
+// key are never expanded.
+func WithStringList(mode, delimiter string) Option {
+	return func(r *AWSResolver) {
+		r.listMode = mode
+		r.listDelimiter = delimiter
+	}
+}
+
+// expandList returns the variables a StringList value referenced by key is
+// set as.
+func (r *AWSResolver) expandList(key, value string) map[string]string {
+	items := strings.Split(value, stringListSeparator)
+	delimiter := r.listDelimiter
+	if delimiter == "" {
+		delimiter = stringListSeparator
+	}
+
+	vars := map[string]string{key: strings.Join(items, delimiter)}
+	if r.listMode == StringListIndexed {
+		for i, item := range items {
+			vars[indexedKey(key, i)] = item
+		}
+	}
+	return vars
+}
+
+// indexedKey returns the variable of item i of the StringList set as key.
+func indexedKey(key string, i int) string {
+	return fmt.Sprintf("%s_%d", key, i)
+}
+
+// setIndexed records that key was expanded into n indexed variables, and
+// unsets the ones left over from a previous, longer value.
+func setIndexed(key string, n int) error {
+	resolved.Lock()
+	defer resolved.Unlock()
+
+	for i := n; i < resolved.indexed[key]; i++ {
+		if err := os.Unsetenv(indexedKey(key, i)); err != nil {
+			return fmt.Errorf("failed to unset %s: %w", indexedKey(key, i), err)
+		}
+	}
+	if n == 0 {
+		delete(resolved.indexed, key)
+	} else {
+		resolved.indexed[key] = n
+	}
+	return nil
+}
+
+// stringListOptionsFromEnv reads SSM_RESOLVER_STRINGLIST_MODE and
+// SSM_RESOLVER_STRINGLIST_DELIMITER.
+func stringListOptionsFromEnv() (Option, error) {
+	mode := os.Getenv(EnvStringListMode)
+	switch mode {
+	case "", StringListJoin, StringListIndexed:
+	default:
+		return nil, fmt.Errorf("invalid %s %q: expected %s or %s", EnvStringListMode, mode, StringListJoin, StringListIndexed)
+	}
+	return WithStringList(mode, os.Getenv(EnvStringListDelimiter)), nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package ssmresolver
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestResolveEnvironmentStringList(t *testing.T) {
+	const name = "/octo-sts/GITHUB_WEBHOOK_SECRETS"
+	ssmClient := &fakeSSM{
+		params: map[string]string{name: "old,new,next", "/octo-sts/PLAIN": "a,b"},
+		lists:  map[string]bool{name: true},
+	}
+	resolver := NewWithClients(ssmClient, &fakeSecretsManager{}, WithStringList(StringListIndexed, " "))
+
+	t.Setenv("TEST_AWS_LIST", "ssm://"+name)
+	t.Setenv("TEST_AWS_LIST_PLAIN", "ssm:///octo-sts/PLAIN")
+	for _, key := range []string{"TEST_AWS_LIST_0", "TEST_AWS_LIST_1", "TEST_AWS_LIST_2"} {
+		t.Setenv(key, "")
+	}
+	t.Cleanup(func() {
+		resolved.Lock()
+		delete(resolved.refs, "TEST_AWS_LIST")
+		delete(resolved.refs, "TEST_AWS_LIST_PLAIN")
+		delete(resolved.indexed, "TEST_AWS_LIST")
+		resolved.Unlock()
+	})
+
+	ctx := context.Background()
+	if err := resolver.ResolveEnvironment(ctx); err != nil {
+		t.Fatalf("ResolveEnvironment() error = %v", err)
+	}
+	for key, want := range map[string]string{
+		"TEST_AWS_LIST":       "old new next",
+		"TEST_AWS_LIST_0":     "old",
+		"TEST_AWS_LIST_1":     "new",
+		"TEST_AWS_LIST_2":     "next",
+		"TEST_AWS_LIST_PLAIN": "a,b",
+	} {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if _, ok := os.LookupEnv("TEST_AWS_LIST_PLAIN_0"); ok {
+		t.Error("expected a String parameter not to be expanded")
+	}
+
+	// Items dropped from the list are unset on reload.
+	ssmClient.params[name] = "new"
+	if err := resolver.ResolveEnvironment(ctx); err != nil {
+		t.Fatalf("ResolveEnvironment() error = %v", err)
+	}
+	if got := os.Getenv("TEST_AWS_LIST_0"); got != "new" {
+		t.Errorf("TEST_AWS_LIST_0 = %q, want %q", got, "new")
+	}
+	for _, key := range []string{"TEST_AWS_LIST_1", "TEST_AWS_LIST_2"} {
+		if _, ok := os.LookupEnv(key); ok {
+			t.Errorf("expected %s to be unset", key)
+		}
+	}
+}
+
+func TestStringListOptionsFromEnv(t *testing.T) {
+	t.Setenv(EnvStringListMode, "split")
+	if _, err := stringListOptionsFromEnv(); err == nil {
+		t.Error("expected error for an unknown mode")
+	}
+
+	t.Setenv(EnvStringListMode, StringListIndexed)
+	t.Setenv(EnvStringListDelimiter, ";")
+	opt, err := stringListOptionsFromEnv()
+	if err != nil {
+		t.Fatalf("stringListOptionsFromEnv() error = %v", err)
+	}
+	resolver := NewWithClients(nil, nil, opt)
+	if resolver.listMode != StringListIndexed || resolver.listDelimiter != ";" {
+		t.Errorf("got mode %q and delimiter %q", resolver.listMode, resolver.listDelimiter)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package sts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/google/go-github/v84/github"
+)
+
+// FlushCaches empties the installation ID and trust policy caches, so the
+// next exchanges look installations up again and re-read trust policies that
+// changed in the meantime. It returns the number of entries dropped from
+// each.
+func FlushCaches() (installations, policies int) {
+	installations, policies = installationIDs.Len(), trustPolicies.Len()
+	installationIDs.Purge()
+	trustPolicies.Purge()
+	return installations, policies
+}
+
+// CacheReloadHook returns a reload hook that flushes the caches when the
+// GitHub App ID returned by appID changed since the hook was created or
+// last called, since installations and trust policies read with one App
+// don't apply to another.
+func CacheReloadHook(appID func() string) func(context.Context) error {
+	var mu sync.Mutex
+	last := appID()
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		id := appID()
+		if id == last {
+			return nil
+		}
+		last = id
+		installations, policies := FlushCaches()
+		clog.FromContext(ctx).Infof("[sts] GitHub App changed, flushed %d installations and %d trust policies", installations, policies)
+		return nil
+	}
+}
+
+// Installations returns the cached installation ID of each owner. Owners
+// cached for a tenant are prefixed with the tenant, as in "acme/org".
+func Installations() map[string]int64 {
+	ids := make(map[string]int64, installationIDs.Len())
+	for _, owner := range installationIDs.Keys() {
+		if id, ok := installationIDs.Peek(owner); ok {
+			ids[owner] = id
+		}
+	}
+	return ids
+}
+
+// WarmUp fills the installation ID cache with the installations of the
+// GitHub App, so the first exchange for each owner skips the lookup. It also
+// signs an app JWT, which exercises the private key or KMS key ahead of the
+// first request. It returns the number of installations cached.
+func (s *STS) WarmUp(ctx context.Context) (int, error) {
+	client := github.NewClient(&http.Client{
+		Transport: s.transport,
+	})
+
+	count := 0
+	page := 1
+	for page != 0 {
+		installs, resp, err := client.Apps.ListInstallations(ctx, &github.ListOptions{
+			Page:    page,
+			PerPage: 100,
+		})
+		if err != nil {
+			return count, err
+		}
+
+		for _, install := range installs {
+			installationIDs.Add(s.installKey(install.Account.GetLogin()), install.GetID())
+			count++
+		}
+		page = resp.NextPage
+	}
+	return count, nil
+}
+
+// RefreshTrustPolicies re-reads trust policies into the trust policy cache,
+// so the exchanges that use them skip the lookup: those of refs, each of the
+// form scope:identity as in an exchange request (e.g. "org/repo:ci"), then
+// up to recent of the policies this STS used most recently, whose cache
+// entries would otherwise expire. It returns the number of policies read,
+// and the errors of those that could not be.
+func (s *STS) RefreshTrustPolicies(ctx context.Context, refs []string, recent int) (int, error) {
+	var keys []cacheTrustPolicyKey
+	var errs []error
+	for _, ref := range refs {
+		scope, identity, ok := strings.Cut(ref, ":")
+		if !ok || scope == "" || identity == "" {
+			errs = append(errs, fmt.Errorf("invalid trust policy %q: want scope:identity", ref))
+			continue
+		}
+		owner, repo, _ := splitScope(scope)
+		keys = append(keys, cacheTrustPolicyKey{tenant: s.tenant, owner: owner, repo: repo, identity: identity})
+	}
+
+	// Keys are ordered from the least to the most recently used
+	cached := trustPolicies.Keys()
+	for i := len(cached) - 1; i >= 0 && recent > 0; i-- {
+		if k := cached[i]; k.tenant == s.tenant && !slices.Contains(keys, k) {
+			keys = append(keys, k)
+			recent--
+		}
+	}
+
+	count := 0
+	for _, k := range keys {
+		trustPolicies.Remove(k)
+		if _, _, err := s.lookupInstallAndTrustPolicy(ctx, k.owner+"/"+k.repo, k.identity); err != nil {
+			errs = append(errs, fmt.Errorf("%s/%s:%s: %w", k.owner, k.repo, k.identity, err))
+			continue
+		}
+		count++
+	}
+	return count, errors.Join(errs...)
+}
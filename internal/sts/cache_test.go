@@ -0,0 +1,136 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package sts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chainguard-dev/clog/slogtest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCaches(t *testing.T) {
+	FlushCaches()
+	t.Cleanup(func() { FlushCaches() })
+
+	installationIDs.Add("org-a", 1)
+	installationIDs.Add("org-b", 2)
+	trustPolicies.Add(cacheTrustPolicyKey{owner: "org-a", repo: "repo", identity: "ci"}, "policy")
+
+	want := map[string]int64{"org-a": 1, "org-b": 2}
+	if diff := cmp.Diff(want, Installations()); diff != "" {
+		t.Errorf("Installations() mismatch (-want +got):\n%s", diff)
+	}
+
+	installations, policies := FlushCaches()
+	if installations != 2 || policies != 1 {
+		t.Errorf("FlushCaches() = %d, %d, want 2, 1", installations, policies)
+	}
+	if got := Installations(); len(got) != 0 {
+		t.Errorf("Installations() after flush = %v, want empty", got)
+	}
+}
+
+func TestCacheReloadHook(t *testing.T) {
+	FlushCaches()
+	t.Cleanup(func() { FlushCaches() })
+	ctx := slogtest.Context(t)
+
+	appID := "1"
+	hook := CacheReloadHook(func() string { return appID })
+	installationIDs.Add("org-a", 1)
+
+	// A reload with the same App keeps the cache
+	if err := hook(ctx); err != nil {
+		t.Fatalf("hook() error = %v", err)
+	}
+	if got := installationIDs.Len(); got != 1 {
+		t.Errorf("cached installations after reload = %d, want 1", got)
+	}
+
+	// A reload that switched Apps flushes it
+	appID = "2"
+	if err := hook(ctx); err != nil {
+		t.Fatalf("hook() error = %v", err)
+	}
+	if got := installationIDs.Len(); got != 0 {
+		t.Errorf("cached installations after App change = %d, want 0", got)
+	}
+}
+
+func TestWarmUp(t *testing.T) {
+	FlushCaches()
+	t.Cleanup(func() { FlushCaches() })
+
+	sts, err := New(newGitHubClient(t, newFakeGitHub()), Config{Domain: "octosts"})
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+
+	count, err := sts.WarmUp(slogtest.Context(t))
+	if err != nil {
+		t.Fatalf("WarmUp() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("WarmUp() = %d, want 1", count)
+	}
+	if diff := cmp.Diff(map[string]int64{"org": 1234}, Installations()); diff != "" {
+		t.Errorf("Installations() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWarmUpTenant(t *testing.T) {
+	FlushCaches()
+	t.Cleanup(func() { FlushCaches() })
+
+	sts, err := New(newGitHubClient(t, newFakeGitHub()), Config{Domain: "octosts", Tenant: "acme"})
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+
+	if _, err := sts.WarmUp(slogtest.Context(t)); err != nil {
+		t.Fatalf("WarmUp() error = %v", err)
+	}
+	if diff := cmp.Diff(map[string]int64{"acme/org": 1234}, Installations()); diff != "" {
+		t.Errorf("Installations() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRefreshTrustPolicies(t *testing.T) {
+	FlushCaches()
+	t.Cleanup(func() { FlushCaches() })
+	ctx := slogtest.Context(t)
+
+	sts, err := New(newGitHubClient(t, newFakeGitHub()), Config{Domain: "octosts"})
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+
+	count, err := sts.RefreshTrustPolicies(ctx, []string{"org/repo:foo", "org/repo"}, 0)
+	if count != 1 {
+		t.Errorf("RefreshTrustPolicies() = %d, want 1", count)
+	}
+	if err == nil || !strings.Contains(err.Error(), `invalid trust policy "org/repo"`) {
+		t.Errorf("RefreshTrustPolicies() error = %v, want the invalid reference reported", err)
+	}
+	if _, ok := trustPolicies.Peek(cacheTrustPolicyKey{owner: "org", repo: "repo", identity: "foo"}); !ok {
+		t.Error("trust policy of org/repo:foo not cached")
+	}
+
+	// The policies used most recently are refreshed without being listed
+	count, err = sts.RefreshTrustPolicies(ctx, nil, 10)
+	if err != nil || count != 1 {
+		t.Errorf("RefreshTrustPolicies() recent = %d, %v, want 1, nil", count, err)
+	}
+
+	// Policies cached for another tenant are not
+	other, err := New(newGitHubClient(t, newFakeGitHub()), Config{Domain: "octosts", Tenant: "acme"})
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	if count, err := other.RefreshTrustPolicies(ctx, nil, 10); err != nil || count != 0 {
+		t.Errorf("RefreshTrustPolicies() for another tenant = %d, %v, want 0, nil", count, err)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"net/http/httputil"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/chainguard-dev/clog"
@@ -23,6 +24,8 @@ import (
 	expirablelru "github.com/hashicorp/golang-lru/v2/expirable"
 	"sigs.k8s.io/yaml"
 
+	"github.com/cruxstack/octo-sts-distros/internal/audit"
+	"github.com/cruxstack/octo-sts-distros/internal/errreport"
 	"github.com/cruxstack/octo-sts-distros/internal/shared"
 	"github.com/octo-sts/app/pkg/octosts"
 	"github.com/octo-sts/app/pkg/oidcvalidate"
@@ -35,7 +38,12 @@ var (
 	trustPolicies      = expirablelru.NewLRU[cacheTrustPolicyKey, string](200, nil, 5*60*1e9) // 5 minutes
 )
 
+// errProvider marks a failure to get the OIDC provider of a token's issuer,
+// as opposed to a token that fails verification.
+var errProvider = errors.New("unable to fetch or create the provider")
+
 type cacheTrustPolicyKey struct {
+	tenant   string
 	owner    string
 	repo     string
 	identity string
@@ -48,6 +56,12 @@ func (s *STS) HandleRequest(ctx context.Context, req shared.Request) shared.Resp
 	log := clog.FromContext(ctx)
 	ctx = clog.WithLogger(ctx, log)
 
+	// Bodies not read through ServeHTTP, e.g. from Lambda events, are
+	// checked here
+	if int64(len(req.Body)) > s.maxBodySize {
+		return ErrorResponse(http.StatusRequestEntityTooLarge, "request body too large")
+	}
+
 	switch {
 	case req.Method == http.MethodPost && (reqPath == "/" || reqPath == "" || reqPath == "/sts/exchange"):
 		return s.handleExchange(ctx, req)
@@ -61,6 +75,48 @@ func (s *STS) HandleRequest(ctx context.Context, req shared.Request) shared.Resp
 	}
 }
 
+// ServeHTTP implements http.Handler interface, allowing the STS to be used
+// directly as an HTTP handler without the Request/Response abstraction.
+func (s *STS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := shared.ReadBody(w, r, s.maxBodySize)
+	if errors.Is(err, shared.ErrBodyTooLarge) {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	// Convert http.Request headers to map[string]string (lowercase keys)
+	headers := make(map[string]string)
+	for k := range r.Header {
+		headers[strings.ToLower(k)] = r.Header.Get(k)
+	}
+
+	// Convert URL query parameters to map[string]string
+	queryParams := make(map[string]string)
+	for k, v := range r.URL.Query() {
+		if len(v) > 0 {
+			queryParams[k] = v[0]
+		}
+	}
+
+	resp := s.HandleRequest(r.Context(), shared.Request{
+		Type:        shared.RequestTypeHTTP,
+		Method:      r.Method,
+		Path:        r.URL.Path,
+		Headers:     headers,
+		QueryParams: queryParams,
+		Body:        body,
+	})
+
+	if err := resp.Write(w); err != nil {
+		clog.FromContext(r.Context()).Errorf("failed to write response body: %v", err)
+	}
+}
+
 // stripBasePath removes the configured base path prefix from the request path.
 func (s *STS) stripBasePath(reqPath string) string {
 	if s.basePath == "" {
@@ -81,9 +137,33 @@ func (s *STS) handleRoot(_ context.Context) shared.Response {
 	})
 }
 
-// handleExchange processes token exchange requests.
-// Supports both POST with JSON body and GET with query parameters.
+// handleExchange processes token exchange requests and records their outcome
+// in the exchange metrics and the audit trail.
 func (s *STS) handleExchange(ctx context.Context, req shared.Request) shared.Response {
+	start := time.Now()
+	ev := &audit.Event{Time: start.UTC(), Tenant: s.tenant}
+	resp := s.exchange(ctx, req, ev)
+	observeExchange(resp.StatusCode, time.Since(start).Seconds())
+
+	if s.audit != nil {
+		ev.Status = resp.StatusCode
+		ev.Outcome = audit.OutcomeOf(resp.StatusCode)
+		ev.DurationMS = time.Since(start).Milliseconds()
+		if resp.StatusCode != http.StatusOK {
+			var body ErrorResponseBody
+			if json.Unmarshal(resp.Body, &body) == nil {
+				ev.Reason = body.Error
+			}
+		}
+		s.audit(ctx, *ev)
+	}
+	return resp
+}
+
+// exchange processes a token exchange request, filling in ev as the
+// request, the token, and the trust policy become known.
+// Supports both POST with JSON body and GET with query parameters.
+func (s *STS) exchange(ctx context.Context, req shared.Request, ev *audit.Event) shared.Response {
 	log := clog.FromContext(ctx)
 
 	var exchangeReq ExchangeRequest
@@ -102,6 +182,7 @@ func (s *STS) handleExchange(ctx context.Context, req shared.Request) shared.Res
 	}
 
 	log.Infof("exchange request: identity=%s, scope=%s", exchangeReq.Identity, exchangeReq.Scope)
+	ev.Scope, ev.Identity = exchangeReq.Scope, exchangeReq.Identity
 
 	auth := req.Headers[HeaderAuthorization]
 	if auth == "" {
@@ -121,20 +202,28 @@ func (s *STS) handleExchange(ctx context.Context, req shared.Request) shared.Res
 	if !oidcvalidate.IsValidIssuer(issuer) {
 		return ErrorResponse(http.StatusBadRequest, "invalid issuer format")
 	}
+	ev.Issuer = issuer
 
-	p, err := provider.Get(ctx, issuer)
-	if err != nil {
+	var tok *oidc.IDToken
+	err = s.trace(ctx, PhaseOIDCVerify, func(ctx context.Context) error {
+		p, err := provider.Get(ctx, issuer)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errProvider, err)
+		}
+		// Audience is verified later by the trust policy
+		verifier := p.Verifier(&oidc.Config{SkipClientIDCheck: true})
+		tok, err = verifier.Verify(ctx, bearer)
+		return err
+	})
+	if errors.Is(err, errProvider) {
 		log.Debugf("unable to fetch or create the provider: %v", err)
 		return ErrorResponse(http.StatusBadRequest, "unable to fetch or create the provider")
 	}
-
-	// Audience is verified later by the trust policy
-	verifier := p.Verifier(&oidc.Config{SkipClientIDCheck: true})
-	tok, err := verifier.Verify(ctx, bearer)
 	if err != nil {
 		log.Debugf("unable to validate token: %v", err)
 		return ErrorResponse(http.StatusUnauthorized, "unable to verify bearer token")
 	}
+	ev.Subject, ev.Audience = tok.Subject, tok.Audience
 
 	if exchangeReq.Scope == "" {
 		return ErrorResponse(http.StatusBadRequest, "scope must be provided")
@@ -143,14 +232,22 @@ func (s *STS) handleExchange(ctx context.Context, req shared.Request) shared.Res
 		return ErrorResponse(http.StatusBadRequest, "identity must be provided")
 	}
 
-	installID, trustPolicy, err := s.lookupInstallAndTrustPolicy(ctx, exchangeReq.Scope, exchangeReq.Identity)
+	var installID int64
+	var trustPolicy *octosts.OrgTrustPolicy
+	err = s.trace(ctx, PhaseTrustPolicy, func(ctx context.Context) error {
+		installID, trustPolicy, err = s.lookupInstallAndTrustPolicy(ctx, exchangeReq.Scope, exchangeReq.Identity)
+		return err
+	})
 	if err != nil {
 		log.Debugf("failed to lookup trust policy: %v", err)
 		return ErrorResponse(http.StatusNotFound, "unable to find trust policy")
 	}
 	log.Infof("trust policy: %#v", trustPolicy)
+	ev.InstallationID = installID
+	ev.Repositories = trustPolicy.Repositories
+	ev.Permissions = permissionsMap(&trustPolicy.Permissions)
 
-	_, err = trustPolicy.CheckToken(tok, s.domain)
+	_, err = trustPolicy.CheckToken(tok, s.audienceDomain(tok.Audience))
 	if err != nil {
 		log.Warnf("token does not match trust policy: %v", err)
 		return ErrorResponse(http.StatusForbidden, "token does not match trust policy")
@@ -170,7 +267,11 @@ func (s *STS) handleExchange(ctx context.Context, req shared.Request) shared.Res
 			formatPermissions(&trustPolicy.Permissions))
 	}
 
-	token, err := atr.Token(ctx)
+	var token string
+	err = s.trace(ctx, PhaseGitHubToken, func(ctx context.Context) error {
+		token, err = atr.Token(ctx)
+		return err
+	})
 	if err != nil {
 		var herr *ghinstallation.HTTPError
 		if errors.As(err, &herr) && herr.Response != nil {
@@ -195,6 +296,7 @@ func (s *STS) handleExchange(ctx context.Context, req shared.Request) shared.Res
 		} else {
 			log.Warnf("token exchange failure: %v", redactTokenInError(err))
 		}
+		errreport.CaptureError(ctx, errors.New(redactTokenInError(err)))
 		return ErrorResponse(http.StatusInternalServerError, "failed to get token")
 	}
 
@@ -208,10 +310,8 @@ func (s *STS) lookupInstallAndTrustPolicy(ctx context.Context, scope, identity s
 	otp := &octosts.OrgTrustPolicy{}
 	var tp trustPolicy = &otp.TrustPolicy
 
-	owner, repo := path.Dir(scope), path.Base(scope)
-	if owner == "." {
-		owner, repo = repo, ".github"
-	} else {
+	owner, repo, org := splitScope(scope)
+	if !org {
 		otp.Repositories = []string{repo}
 	}
 
@@ -225,6 +325,7 @@ func (s *STS) lookupInstallAndTrustPolicy(ctx context.Context, scope, identity s
 	}
 
 	trustPolicyKey := cacheTrustPolicyKey{
+		tenant:   s.tenant,
 		owner:    owner,
 		repo:     repo,
 		identity: identity,
@@ -236,6 +337,17 @@ func (s *STS) lookupInstallAndTrustPolicy(ctx context.Context, scope, identity s
 	return id, otp, nil
 }
 
+// splitScope returns the owner and repository of an exchange scope. An
+// organization scope, which names only the owner, is that of the owner's
+// .github repository and reports org.
+func splitScope(scope string) (owner, repo string, org bool) {
+	owner, repo = path.Dir(scope), path.Base(scope)
+	if owner == "." {
+		return repo, ".github", true
+	}
+	return owner, repo, false
+}
+
 // trustPolicy interface for polymorphic trust policy handling
 type trustPolicy interface {
 	Compile() error
@@ -243,7 +355,9 @@ type trustPolicy interface {
 
 // lookupInstall looks up the GitHub App installation ID for the given owner.
 func (s *STS) lookupInstall(ctx context.Context, owner string) (int64, error) {
-	if v, ok := installationIDs.Get(owner); ok {
+	v, ok := installationIDs.Get(s.installKey(owner))
+	observeCache(cacheInstallation, ok)
+	if ok {
 		clog.InfoContextf(ctx, "found installation in cache for %s", owner)
 		return v, nil
 	}
@@ -265,7 +379,7 @@ func (s *STS) lookupInstall(ctx context.Context, owner string) (int64, error) {
 		for _, install := range installs {
 			if install.Account.GetLogin() == owner {
 				installID := install.GetID()
-				installationIDs.Add(owner, installID)
+				installationIDs.Add(s.installKey(owner), installID)
 				return installID, nil
 			}
 		}
@@ -275,10 +389,21 @@ func (s *STS) lookupInstall(ctx context.Context, owner string) (int64, error) {
 	return 0, fmt.Errorf("no installation found for %q", owner)
 }
 
+// installKey returns the installation ID cache key of owner, prefixed with
+// the tenant when there is one.
+func (s *STS) installKey(owner string) string {
+	if s.tenant == "" {
+		return owner
+	}
+	return s.tenant + "/" + owner
+}
+
 // lookupTrustPolicy fetches and parses the trust policy for the given identity.
 func (s *STS) lookupTrustPolicy(ctx context.Context, install int64, trustPolicyKey cacheTrustPolicyKey, tp trustPolicy) error {
 	raw := ""
-	if cachedRawPolicy, ok := trustPolicies.Get(trustPolicyKey); ok {
+	cachedRawPolicy, ok := trustPolicies.Get(trustPolicyKey)
+	observeCache(cacheTrustPolicy, ok)
+	if ok {
 		clog.InfoContextf(ctx, "found trust policy in cache for %s", trustPolicyKey)
 		raw = cachedRawPolicy
 	}
@@ -371,6 +496,19 @@ func ptr[T any](in T) *T {
 	return &in
 }
 
+// permissionsMap returns the permissions that are set, by name.
+func permissionsMap(perms *github.InstallationPermissions) map[string]string {
+	data, err := json.Marshal(perms)
+	if err != nil {
+		return nil
+	}
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil || len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
 // formatPermissions returns a string representation of the permissions being requested.
 func formatPermissions(perms *github.InstallationPermissions) string {
 	if perms == nil {
@@ -0,0 +1,48 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package sts
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Caches reported by the octo_sts_cache_lookups_total counter.
+const (
+	cacheInstallation = "installation"
+	cacheTrustPolicy  = "trust_policy"
+)
+
+var (
+	exchangesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "octo_sts_exchanges_total",
+		Help: "Token exchange requests, by response status code.",
+	}, []string{"code"})
+	exchangeDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "octo_sts_exchange_duration_seconds",
+		Help:    "Duration of token exchange requests.",
+		Buckets: prometheus.DefBuckets,
+	})
+	cacheLookupsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "octo_sts_cache_lookups_total",
+		Help: "Lookups of the installation and trust policy caches, by result.",
+	}, []string{"cache", "result"})
+)
+
+// observeCache records a lookup of cache.
+func observeCache(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookupsTotal.WithLabelValues(cache, result).Inc()
+}
+
+// observeExchange records a token exchange that answered with code.
+func observeExchange(code int, seconds float64) {
+	exchangesTotal.WithLabelValues(strconv.Itoa(code)).Inc()
+	exchangeDuration.Observe(seconds)
+}
@@ -0,0 +1,58 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package sts
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/chainguard-dev/clog/slogtest"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/cruxstack/octo-sts-distros/internal/shared"
+)
+
+func TestExchangeMetrics(t *testing.T) {
+	ctx := slogtest.Context(t)
+	atr := newGitHubClient(t, newFakeGitHub())
+
+	sts, err := New(atr, Config{Domain: "octosts"})
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+
+	counter := exchangesTotal.WithLabelValues("401")
+	before := testutil.ToFloat64(counter)
+
+	resp := sts.HandleRequest(ctx, shared.Request{
+		Type:   shared.RequestTypeHTTP,
+		Method: http.MethodPost,
+		Path:   "/",
+		Body:   []byte(`{"identity":"foo","scope":"org/repo"}`),
+	})
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("HandleRequest() status = %d, expected %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("exchanges with code 401 = %v, expected 1", got)
+	}
+}
+
+func TestObserveCache(t *testing.T) {
+	hits := cacheLookupsTotal.WithLabelValues(cacheTrustPolicy, "hit")
+	misses := cacheLookupsTotal.WithLabelValues(cacheTrustPolicy, "miss")
+	beforeHits, beforeMisses := testutil.ToFloat64(hits), testutil.ToFloat64(misses)
+
+	observeCache(cacheTrustPolicy, true)
+	observeCache(cacheTrustPolicy, false)
+	observeCache(cacheTrustPolicy, false)
+
+	if got := testutil.ToFloat64(hits) - beforeHits; got != 1 {
+		t.Errorf("hits = %v, expected 1", got)
+	}
+	if got := testutil.ToFloat64(misses) - beforeMisses; got != 2 {
+		t.Errorf("misses = %v, expected 2", got)
+	}
+}
@@ -4,10 +4,14 @@
 package sts
 
 import (
+	"context"
 	"errors"
+	"slices"
 	"strings"
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
+
+	"github.com/cruxstack/octo-sts-distros/internal/audit"
 )
 
 // Config provides configuration for the STS service.
@@ -16,19 +20,65 @@ type Config struct {
 	// is specified in the trust policy (e.g., "sts.octo-sts.dev").
 	Domain string
 
+	// AdditionalDomains are also accepted as the audience when no audience
+	// is specified in the trust policy, e.g. the previous domain during a
+	// DNS migration.
+	AdditionalDomains []string
+
 	// BasePath is stripped from incoming request paths before routing.
 	// For example, if BasePath is "/sts", then a request to "/sts/exchange"
 	// will be routed as if it were "/exchange".
 	BasePath string
+
+	// MaxBodySize is the largest request body accepted, in bytes. Larger
+	// requests are rejected with 413. Defaults to DefaultMaxBodySize.
+	MaxBodySize int64
+
+	// Trace, if set, wraps each phase of an exchange (verifying the OIDC
+	// token, fetching the trust policy, and minting the GitHub token), e.g.
+	// to record it as a tracing span named name. It must call fn with the
+	// context it is given or one derived from it.
+	Trace TraceFunc
+
+	// Tenant, if set, scopes the installation ID and trust policy caches to
+	// one tenant, for processes serving several GitHub Apps that may be
+	// installed on the same owner under different installation IDs.
+	Tenant string
+
+	// Audit, if set, receives the audit event of each exchange, e.g.
+	// audit.Recorder.Record.
+	Audit AuditFunc
 }
 
+// AuditFunc records the audit event of an exchange.
+type AuditFunc func(ctx context.Context, e audit.Event)
+
+// TraceFunc runs fn as the phase name of a request.
+type TraceFunc func(ctx context.Context, name string, fn func(context.Context) error) error
+
+// Names of the phases passed to Config.Trace.
+const (
+	PhaseOIDCVerify  = "oidc-verify"
+	PhaseTrustPolicy = "trust-policy"
+	PhaseGitHubToken = "github-token"
+)
+
+// DefaultMaxBodySize is the default limit of exchange request bodies, which
+// hold little more than an identity and a scope.
+const DefaultMaxBodySize int64 = 64 << 10
+
 // STS handles GitHub STS token exchange requests in a runtime-agnostic way.
 // It provides a unified interface that works with both standard HTTP servers
 // and AWS API Gateway v2 with Lambda.
 type STS struct {
 	transport *ghinstallation.AppsTransport
-	domain    string
+	domains   []string // Domain first, then AdditionalDomains
 	basePath  string
+
+	maxBodySize int64
+	trace       TraceFunc
+	tenant      string
+	audit       AuditFunc
 }
 
 // New creates a new STS instance with the given GitHub App transport and configuration.
@@ -48,9 +98,44 @@ func New(transport *ghinstallation.AppsTransport, cfg Config) (*STS, error) {
 	// Normalize base path: ensure no trailing slash
 	basePath := strings.TrimSuffix(cfg.BasePath, "/")
 
+	maxBodySize := cfg.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+
+	trace := cfg.Trace
+	if trace == nil {
+		trace = func(ctx context.Context, _ string, fn func(context.Context) error) error {
+			return fn(ctx)
+		}
+	}
+
+	domains := []string{cfg.Domain}
+	for _, d := range cfg.AdditionalDomains {
+		if d != "" && !slices.Contains(domains, d) {
+			domains = append(domains, d)
+		}
+	}
+
 	return &STS{
-		transport: transport,
-		domain:    cfg.Domain,
-		basePath:  basePath,
+		transport:   transport,
+		domains:     domains,
+		basePath:    basePath,
+		maxBodySize: maxBodySize,
+		trace:       trace,
+		tenant:      cfg.Tenant,
+		audit:       cfg.Audit,
 	}, nil
 }
+
+// audienceDomain returns the domain a token is checked against when the
+// trust policy specifies no audience: the first configured domain among
+// the token's audiences, else Domain so the mismatch is reported against it.
+func (s *STS) audienceDomain(audiences []string) string {
+	for _, d := range s.domains {
+		if slices.Contains(audiences, d) {
+			return d
+		}
+	}
+	return s.domains[0]
+}
@@ -4,6 +4,7 @@
 package sts
 
 import (
+	"context"
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
@@ -35,6 +36,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-github/v84/github"
 
+	"github.com/cruxstack/octo-sts-distros/internal/audit"
 	"github.com/cruxstack/octo-sts-distros/internal/shared"
 	"github.com/octo-sts/app/pkg/provider"
 )
@@ -280,6 +282,79 @@ func TestHandleRequestRouting(t *testing.T) {
 	}
 }
 
+func TestServeHTTP(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := ghinstallation.NewAppsTransportFromPrivateKey(http.DefaultTransport, 1234, key)
+
+	sts, err := New(tr, Config{
+		Domain:   "sts.example.com",
+		BasePath: "/sts",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method         string
+		target         string
+		expectedStatus int
+	}{
+		{http.MethodGet, "/sts", http.StatusOK},
+		{http.MethodGet, "/sts/", http.StatusOK},
+		{http.MethodGet, "/sts/exchange?scope=org/repo&identity=test", http.StatusUnauthorized},
+		{http.MethodPost, "/sts/other", http.StatusNotFound},
+	}
+
+	ctx := slogtest.Context(t)
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			sts.ServeHTTP(rec, httptest.NewRequestWithContext(ctx, tt.method, tt.target, nil))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("ServeHTTP() status = %d, expected %d, body = %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := ghinstallation.NewAppsTransportFromPrivateKey(http.DefaultTransport, 1234, key)
+
+	sts, err := New(tr, Config{
+		Domain:      "sts.example.com",
+		MaxBodySize: 16,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := slogtest.Context(t)
+	body := strings.Repeat("x", 17)
+
+	rec := httptest.NewRecorder()
+	sts.ServeHTTP(rec, httptest.NewRequestWithContext(ctx, http.MethodPost, "/", strings.NewReader(body)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("ServeHTTP() status = %d, expected %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	resp := sts.HandleRequest(ctx, shared.Request{
+		Type:   shared.RequestTypeHTTP,
+		Method: http.MethodPost,
+		Path:   "/",
+		Body:   []byte(body),
+	})
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("HandleRequest() status = %d, expected %d", resp.StatusCode, http.StatusRequestEntityTooLarge)
+	}
+}
+
 func TestResponseHelpers(t *testing.T) {
 	t.Run("OKResponse", func(t *testing.T) {
 		resp := OKResponse()
@@ -547,8 +622,10 @@ func TestExchangeValidation(t *testing.T) {
 		PublicKeys: []crypto.PublicKey{pk.Public()},
 	})
 
+	var recorded audit.Event
 	sts, err := New(atr, Config{
 		Domain: "octosts",
+		Audit:  func(_ context.Context, e audit.Event) { recorded = e },
 	})
 	if err != nil {
 		t.Fatalf("New() = %v", err)
@@ -606,6 +683,9 @@ func TestExchangeValidation(t *testing.T) {
 			if resp.StatusCode != tc.expectedStatus {
 				t.Errorf("HandleRequest() status = %d, expected %d, body = %s", resp.StatusCode, tc.expectedStatus, string(resp.Body))
 			}
+			if recorded.Status != tc.expectedStatus || recorded.Outcome != audit.OutcomeDenied || recorded.Reason == "" {
+				t.Errorf("audit event = %+v, want the denied exchange and its reason", recorded)
+			}
 		})
 	}
 }
@@ -689,3 +769,133 @@ func generateTLS(tmpl *x509.Certificate) (*tls.Config, error) {
 		InsecureSkipVerify: true,
 	}, nil
 }
+
+func TestExchangeAdditionalDomains(t *testing.T) {
+	ctx := slogtest.Context(t)
+	atr := newGitHubClient(t, newFakeGitHub())
+
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate RSA key %v", err)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: jose.RS256,
+		Key:       pk,
+	}, nil)
+	if err != nil {
+		t.Fatalf("jose.NewSigner() = %v", err)
+	}
+
+	iss := "https://token.actions.githubusercontent.com"
+	provider.AddTestKeySetVerifier(t, iss, &oidc.StaticKeySet{
+		PublicKeys: []crypto.PublicKey{pk.Public()},
+	})
+
+	sts, err := New(atr, Config{
+		Domain:            "sts.example.com",
+		AdditionalDomains: []string{"octo-sts.internal.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		audience       josejwt.Audience
+		expectedStatus int
+	}{
+		{name: "domain", audience: josejwt.Audience{"sts.example.com"}, expectedStatus: http.StatusOK},
+		{name: "additional domain", audience: josejwt.Audience{"octo-sts.internal.example.com"}, expectedStatus: http.StatusOK},
+		{name: "among other audiences", audience: josejwt.Audience{"other", "octo-sts.internal.example.com"}, expectedStatus: http.StatusOK},
+		{name: "other domain", audience: josejwt.Audience{"octosts"}, expectedStatus: http.StatusForbidden},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := josejwt.Signed(signer).Claims(josejwt.Claims{
+				Subject:  "foo",
+				Issuer:   iss,
+				Audience: tc.audience,
+				Expiry:   josejwt.NewNumericDate(time.Now().Add(10 * time.Minute)),
+			}).Serialize()
+			if err != nil {
+				t.Fatalf("CompactSerialize failed: %v", err)
+			}
+
+			resp := sts.HandleRequest(ctx, shared.Request{
+				Type:   shared.RequestTypeHTTP,
+				Method: http.MethodPost,
+				Path:   "/",
+				Headers: shared.NormalizeHeaders(map[string]string{
+					"Authorization": "Bearer " + token,
+					"Content-Type":  "application/json",
+				}),
+				Body: []byte(`{"identity":"domain","scope":"org/repo"}`),
+			})
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("HandleRequest() status = %d, expected %d, body = %s", resp.StatusCode, tc.expectedStatus, string(resp.Body))
+			}
+		})
+	}
+}
+
+func TestExchangeTrace(t *testing.T) {
+	ctx := slogtest.Context(t)
+	atr := newGitHubClient(t, newFakeGitHub())
+
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate RSA key %v", err)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: jose.RS256,
+		Key:       pk,
+	}, nil)
+	if err != nil {
+		t.Fatalf("jose.NewSigner() = %v", err)
+	}
+
+	iss := "https://token.actions.githubusercontent.com"
+	token, err := josejwt.Signed(signer).Claims(josejwt.Claims{
+		Subject:  "foo",
+		Issuer:   iss,
+		Audience: josejwt.Audience{"octosts"},
+		Expiry:   josejwt.NewNumericDate(time.Now().Add(10 * time.Minute)),
+	}).Serialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize failed: %v", err)
+	}
+	provider.AddTestKeySetVerifier(t, iss, &oidc.StaticKeySet{
+		PublicKeys: []crypto.PublicKey{pk.Public()},
+	})
+
+	var phases []string
+	sts, err := New(atr, Config{
+		Domain: "octosts",
+		Trace: func(ctx context.Context, name string, fn func(context.Context) error) error {
+			phases = append(phases, name)
+			return fn(ctx)
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+
+	resp := sts.HandleRequest(ctx, shared.Request{
+		Type:   shared.RequestTypeHTTP,
+		Method: http.MethodPost,
+		Path:   "/",
+		Headers: shared.NormalizeHeaders(map[string]string{
+			"Authorization": "Bearer " + token,
+			"Content-Type":  "application/json",
+		}),
+		Body: []byte(`{"identity":"foo","scope":"org/repo"}`),
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("HandleRequest failed: status=%d, body=%s", resp.StatusCode, string(resp.Body))
+	}
+
+	want := []string{PhaseOIDCVerify, PhaseTrustPolicy, PhaseGitHubToken}
+	if diff := cmp.Diff(want, phases); diff != "" {
+		t.Errorf("traced phases mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,492 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package vault provides a minimal HashiCorp Vault client for reading and
+// writing KV v2 secrets and using the transit engine, with token, AppRole, or
+// Kubernetes authentication.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables for Vault configuration.
+const (
+	EnvVaultAddr         = "VAULT_ADDR"
+	EnvVaultToken        = "VAULT_TOKEN"
+	EnvVaultNamespace    = "VAULT_NAMESPACE"
+	EnvVaultAuthMethod   = "VAULT_AUTH_METHOD"
+	EnvVaultAuthMount    = "VAULT_AUTH_MOUNT"
+	EnvVaultRoleID       = "VAULT_ROLE_ID"
+	EnvVaultSecretID     = "VAULT_SECRET_ID"
+	EnvVaultK8sRole      = "VAULT_K8S_ROLE"
+	EnvVaultK8sTokenPath = "VAULT_K8S_TOKEN_PATH"
+)
+
+// Supported authentication methods.
+const (
+	AuthMethodToken      = "token"
+	AuthMethodAppRole    = "approle"
+	AuthMethodKubernetes = "kubernetes"
+)
+
+// DefaultK8sTokenPath is the projected service account token path in a pod.
+const DefaultK8sTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// DefaultTimeout is the default timeout for Vault HTTP requests.
+const DefaultTimeout = 10 * time.Second
+
+// ErrNotFound is returned when a secret does not exist.
+var ErrNotFound = errors.New("vault secret not found")
+
+// Auth obtains a Vault client token.
+type Auth interface {
+	Login(ctx context.Context, c *Client) (string, error)
+}
+
+// TokenAuth authenticates with a static token.
+type TokenAuth struct {
+	Token string
+}
+
+// Login returns the static token.
+func (a TokenAuth) Login(_ context.Context, _ *Client) (string, error) {
+	if a.Token == "" {
+		return "", fmt.Errorf("%s is required for token auth", EnvVaultToken)
+	}
+	return a.Token, nil
+}
+
+// AppRoleAuth authenticates with an AppRole role ID and secret ID.
+type AppRoleAuth struct {
+	Mount    string
+	RoleID   string
+	SecretID string
+}
+
+// Login exchanges the role and secret IDs for a client token.
+func (a AppRoleAuth) Login(ctx context.Context, c *Client) (string, error) {
+	mount := a.Mount
+	if mount == "" {
+		mount = AuthMethodAppRole
+	}
+	return c.login(ctx, mount, map[string]string{
+		"role_id":   a.RoleID,
+		"secret_id": a.SecretID,
+	})
+}
+
+// KubernetesAuth authenticates with the pod's service account token.
+type KubernetesAuth struct {
+	Mount     string
+	Role      string
+	TokenPath string
+}
+
+// Login exchanges the service account JWT for a client token.
+func (a KubernetesAuth) Login(ctx context.Context, c *Client) (string, error) {
+	mount := a.Mount
+	if mount == "" {
+		mount = AuthMethodKubernetes
+	}
+	tokenPath := a.TokenPath
+	if tokenPath == "" {
+		tokenPath = DefaultK8sTokenPath
+	}
+	jwt, err := os.ReadFile(tokenPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read service account token: %w", err)
+	}
+	return c.login(ctx, mount, map[string]string{
+		"role": a.Role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	})
+}
+
+// Client is a minimal Vault HTTP API client.
+type Client struct {
+	Address   string
+	Namespace string
+
+	auth       Auth
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	token string
+}
+
+// Option is a functional option for configuring Client.
+type Option func(*Client)
+
+// WithNamespace sets the Vault Enterprise namespace.
+func WithNamespace(namespace string) Option {
+	return func(c *Client) {
+		c.Namespace = namespace
+	}
+}
+
+// WithHTTPClient sets a custom HTTP client.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// NewClient creates a new Vault client for the given address and auth method.
+func NewClient(address string, auth Auth, opts ...Option) (*Client, error) {
+	if address == "" {
+		return nil, fmt.Errorf("vault address cannot be empty")
+	}
+	if auth == nil {
+		return nil, fmt.Errorf("vault auth method cannot be nil")
+	}
+
+	c := &Client{
+		Address:    strings.TrimSuffix(address, "/"),
+		auth:       auth,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// NewClientFromEnv creates a Vault client from the standard VAULT_* environment variables.
+func NewClientFromEnv() (*Client, error) {
+	addr := os.Getenv(EnvVaultAddr)
+	if addr == "" {
+		return nil, fmt.Errorf("%s is required", EnvVaultAddr)
+	}
+
+	var auth Auth
+	switch method := strings.ToLower(os.Getenv(EnvVaultAuthMethod)); method {
+	case "", AuthMethodToken:
+		auth = TokenAuth{Token: os.Getenv(EnvVaultToken)}
+	case AuthMethodAppRole:
+		auth = AppRoleAuth{
+			Mount:    os.Getenv(EnvVaultAuthMount),
+			RoleID:   os.Getenv(EnvVaultRoleID),
+			SecretID: os.Getenv(EnvVaultSecretID),
+		}
+	case AuthMethodKubernetes:
+		auth = KubernetesAuth{
+			Mount:     os.Getenv(EnvVaultAuthMount),
+			Role:      os.Getenv(EnvVaultK8sRole),
+			TokenPath: os.Getenv(EnvVaultK8sTokenPath),
+		}
+	default:
+		return nil, fmt.Errorf("unknown %s: %s (expected '%s', '%s', or '%s')",
+			EnvVaultAuthMethod, method, AuthMethodToken, AuthMethodAppRole, AuthMethodKubernetes)
+	}
+
+	return NewClient(addr, auth, WithNamespace(os.Getenv(EnvVaultNamespace)))
+}
+
+// ReadKV reads the latest version of a KV v2 secret.
+// Returns ErrNotFound if the secret does not exist or its latest version is deleted.
+func (c *Client) ReadKV(ctx context.Context, mount, path string) (map[string]string, error) {
+	return c.readKV(ctx, kvDataPath(mount, path))
+}
+
+// ReadKVVersion reads a specific version of a KV v2 secret.
+// Returns ErrNotFound if the version does not exist, is deleted, or is destroyed.
+func (c *Client) ReadKVVersion(ctx context.Context, mount, path string, version int) (map[string]string, error) {
+	return c.readKV(ctx, kvDataPath(mount, path)+"?version="+strconv.Itoa(version))
+}
+
+// KVVersion describes one version of a KV v2 secret.
+type KVVersion struct {
+	Version   int
+	CreatedAt time.Time
+	Deleted   bool
+}
+
+// ListKVVersions returns the retained versions of a KV v2 secret, newest
+// first. Returns ErrNotFound if the secret does not exist.
+func (c *Client) ListKVVersions(ctx context.Context, mount, path string) ([]KVVersion, error) {
+	var resp struct {
+		Data struct {
+			Versions map[string]struct {
+				CreatedTime  time.Time `json:"created_time"`
+				DeletionTime string    `json:"deletion_time"`
+				Destroyed    bool      `json:"destroyed"`
+			} `json:"versions"`
+		} `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, kvMetadataPath(mount, path), nil, &resp); err != nil {
+		return nil, err
+	}
+
+	versions := make([]KVVersion, 0, len(resp.Data.Versions))
+	for key, meta := range resp.Data.Versions {
+		version, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, KVVersion{
+			Version:   version,
+			CreatedAt: meta.CreatedTime,
+			Deleted:   meta.DeletionTime != "" || meta.Destroyed,
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version > versions[j].Version })
+	return versions, nil
+}
+
+// readKV reads the secret data at a KV v2 data path.
+func (c *Client) readKV(ctx context.Context, apiPath string) (map[string]string, error) {
+	var resp struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, apiPath, nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Data.Data == nil {
+		return nil, ErrNotFound
+	}
+
+	values := make(map[string]string, len(resp.Data.Data))
+	for key, value := range resp.Data.Data {
+		switch v := value.(type) {
+		case string:
+			values[key] = v
+		default:
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode value %s: %w", key, err)
+			}
+			values[key] = string(encoded)
+		}
+	}
+	return values, nil
+}
+
+// WriteKV writes a new version of a KV v2 secret, replacing all keys.
+func (c *Client) WriteKV(ctx context.Context, mount, path string, data map[string]string) error {
+	return c.do(ctx, http.MethodPost, kvDataPath(mount, path), map[string]any{"data": data}, nil)
+}
+
+// DeleteKV permanently deletes a KV v2 secret, including all of its versions
+// and metadata. Deleting a secret that does not exist is not an error.
+func (c *Client) DeleteKV(ctx context.Context, mount, path string) error {
+	err := c.do(ctx, http.MethodDelete, kvMetadataPath(mount, path), nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// TransitEncrypt encrypts plaintext with a transit engine key and returns
+// the "vault:v<n>:..." ciphertext.
+func (c *Client) TransitEncrypt(ctx context.Context, mount, key string, plaintext []byte) (string, error) {
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	body := map[string]string{"plaintext": base64.StdEncoding.EncodeToString(plaintext)}
+	if err := c.do(ctx, http.MethodPost, transitPath(mount, "encrypt", key), body, &resp); err != nil {
+		return "", err
+	}
+	if resp.Data.Ciphertext == "" {
+		return "", fmt.Errorf("vault transit returned no ciphertext")
+	}
+	return resp.Data.Ciphertext, nil
+}
+
+// TransitDecrypt decrypts a ciphertext produced by TransitEncrypt.
+func (c *Client) TransitDecrypt(ctx context.Context, mount, key, ciphertext string) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	body := map[string]string{"ciphertext": ciphertext}
+	if err := c.do(ctx, http.MethodPost, transitPath(mount, "decrypt", key), body, &resp); err != nil {
+		return nil, err
+	}
+	plaintext, err := base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode transit plaintext: %w", err)
+	}
+	return plaintext, nil
+}
+
+// RenewToken renews the lease of the client token and returns its new TTL.
+// A zero TTL means the token does not expire or can't be renewed. If the
+// renewal fails, the cached token is dropped so the next request logs in
+// again (except with a static token).
+func (c *Client) RenewToken(ctx context.Context) (time.Duration, error) {
+	var resp struct {
+		Auth struct {
+			LeaseDuration int  `json:"lease_duration"`
+			Renewable     bool `json:"renewable"`
+		} `json:"auth"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/v1/auth/token/renew-self", map[string]string{}, &resp); err != nil {
+		if _, static := c.auth.(TokenAuth); !static {
+			c.mu.Lock()
+			c.token = ""
+			c.mu.Unlock()
+		}
+		return 0, fmt.Errorf("failed to renew vault token: %w", err)
+	}
+	if !resp.Auth.Renewable {
+		return 0, nil
+	}
+	return time.Duration(resp.Auth.LeaseDuration) * time.Second, nil
+}
+
+// kvDataPath returns the API path for a KV v2 secret.
+func kvDataPath(mount, path string) string {
+	return "/v1/" + strings.Trim(mount, "/") + "/data/" + strings.Trim(path, "/")
+}
+
+// kvMetadataPath returns the API path for a KV v2 secret's metadata.
+func kvMetadataPath(mount, path string) string {
+	return "/v1/" + strings.Trim(mount, "/") + "/metadata/" + strings.Trim(path, "/")
+}
+
+// transitPath returns the API path for a transit engine operation.
+func transitPath(mount, op, key string) string {
+	return "/v1/" + strings.Trim(mount, "/") + "/" + op + "/" + url.PathEscape(key)
+}
+
+// login performs a login request against an auth mount and returns the client token.
+func (c *Client) login(ctx context.Context, mount string, body map[string]string) (string, error) {
+	var resp struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := c.send(ctx, http.MethodPost, "/v1/auth/"+strings.Trim(mount, "/")+"/login", "", body, &resp); err != nil {
+		return "", fmt.Errorf("vault login failed: %w", err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return "", fmt.Errorf("vault login returned no client token")
+	}
+	return resp.Auth.ClientToken, nil
+}
+
+// do sends an authenticated request, logging in again once if the token is rejected.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	token, err := c.currentToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = c.send(ctx, method, path, token, body, out)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden {
+		if _, static := c.auth.(TokenAuth); static {
+			return err
+		}
+		c.mu.Lock()
+		c.token = ""
+		c.mu.Unlock()
+		if token, err = c.currentToken(ctx); err != nil {
+			return err
+		}
+		err = c.send(ctx, method, path, token, body, out)
+	}
+	return err
+}
+
+// currentToken returns the cached token, logging in if necessary.
+func (c *Client) currentToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" {
+		return c.token, nil
+	}
+	token, err := c.auth.Login(ctx, c)
+	if err != nil {
+		return "", err
+	}
+	c.token = token
+	return token, nil
+}
+
+// APIError is returned for non-successful Vault API responses.
+type APIError struct {
+	StatusCode int
+	Errors     []string
+}
+
+func (e *APIError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("vault returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("vault returned status %d: %s", e.StatusCode, strings.Join(e.Errors, "; "))
+}
+
+// send performs a single Vault API request.
+func (c *Client) send(ctx context.Context, method, path, token string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.Address+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if c.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.Namespace)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		var errBody struct {
+			Errors []string `json:"errors"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&errBody) == nil {
+			apiErr.Errors = errBody.Errors
+		}
+		return apiErr
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode vault response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestClient returns a client of a server answering with handler.
+func newTestClient(t *testing.T, auth Auth, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := NewClient(srv.URL+"/", auth, opts...)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return c
+}
+
+func TestTokenAuth(t *testing.T) {
+	c := newTestClient(t, TokenAuth{Token: "s.static"}, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Vault-Token"); got != "s.static" {
+			t.Errorf("X-Vault-Token = %q, want s.static", got)
+		}
+		if got := r.Header.Get("X-Vault-Namespace"); got != "team" {
+			t.Errorf("X-Vault-Namespace = %q, want team", got)
+		}
+		if r.URL.Path != "/v1/secret/data/octo-sts/app" {
+			t.Errorf("path = %q, want /v1/secret/data/octo-sts/app", r.URL.Path)
+		}
+		w.Write([]byte(`{"data":{"data":{"GITHUB_APP_ID":"123","LIMITS":{"max":2}}}}`))
+	}, WithNamespace("team"))
+
+	values, err := c.ReadKV(context.Background(), "/secret/", "/octo-sts/app/")
+	if err != nil {
+		t.Fatalf("ReadKV() error = %v", err)
+	}
+	if values["GITHUB_APP_ID"] != "123" || values["LIMITS"] != `{"max":2}` {
+		t.Errorf("ReadKV() = %v, want the string and JSON-encoded values", values)
+	}
+
+	if _, err := (TokenAuth{}).Login(context.Background(), c); err == nil || !strings.Contains(err.Error(), EnvVaultToken) {
+		t.Errorf("Login() without a token error = %v, want one naming %s", err, EnvVaultToken)
+	}
+}
+
+func TestAppRoleAuthRelogin(t *testing.T) {
+	var logins int
+	token := "s.first"
+	c := newTestClient(t, AppRoleAuth{Mount: "ci/", RoleID: "role", SecretID: "secret"}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/auth/ci/login" {
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["role_id"] != "role" || body["secret_id"] != "secret" {
+				t.Errorf("login body = %v, want the role and secret IDs", body)
+			}
+			if r.Header.Get("X-Vault-Token") != "" {
+				t.Error("login sent a token")
+			}
+			logins++
+			json.NewEncoder(w).Encode(map[string]any{"auth": map[string]string{"client_token": token}})
+			return
+		}
+		// The first token expires after the first request
+		if r.Header.Get("X-Vault-Token") != "s.second" {
+			token = "s.second"
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"KEY":"value"}}}`))
+	})
+
+	values, err := c.ReadKV(context.Background(), "secret", "app")
+	if err != nil {
+		t.Fatalf("ReadKV() error = %v", err)
+	}
+	if values["KEY"] != "value" || logins != 2 {
+		t.Errorf("ReadKV() = %v after %d logins, want a value after logging in again", values, logins)
+	}
+}
+
+func TestKubernetesAuth(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenPath, []byte("service-account-jwt\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := newTestClient(t, KubernetesAuth{Role: "octo-sts", TokenPath: tokenPath}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/auth/kubernetes/login" {
+			t.Errorf("login path = %q, want the default kubernetes mount", r.URL.Path)
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["role"] != "octo-sts" || body["jwt"] != "service-account-jwt" {
+			t.Errorf("login body = %v, want the role and the trimmed JWT", body)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"auth": map[string]string{"client_token": "s.k8s"}})
+	})
+
+	token, err := c.auth.Login(context.Background(), c)
+	if err != nil || token != "s.k8s" {
+		t.Errorf("Login() = %q, %v, want s.k8s", token, err)
+	}
+
+	missing := KubernetesAuth{Role: "octo-sts", TokenPath: filepath.Join(t.TempDir(), "missing")}
+	if _, err := missing.Login(context.Background(), c); err == nil {
+		t.Error("Login() without a token file error = nil")
+	}
+}
+
+func TestTransitKeyEscaping(t *testing.T) {
+	c := newTestClient(t, TokenAuth{Token: "s.static"}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/v1/transit/encrypt/team%2Fkey" {
+			t.Errorf("path = %q, want the key escaped", r.URL.EscapedPath())
+		}
+		w.Write([]byte(`{"data":{"ciphertext":"vault:v1:abc"}}`))
+	})
+
+	ciphertext, err := c.TransitEncrypt(context.Background(), "transit", "team/key", []byte("plaintext"))
+	if err != nil || ciphertext != "vault:v1:abc" {
+		t.Errorf("TransitEncrypt() = %q, %v", ciphertext, err)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	c := newTestClient(t, TokenAuth{Token: "s.static"}, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/deleted"):
+			// The latest version is deleted: Vault answers with no data
+			w.Write([]byte(`{"data":{"data":null}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	})
+	ctx := context.Background()
+
+	if _, err := c.ReadKV(ctx, "secret", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ReadKV() of a missing secret error = %v, want ErrNotFound", err)
+	}
+	if _, err := c.ReadKV(ctx, "secret", "deleted"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ReadKV() of a deleted secret error = %v, want ErrNotFound", err)
+	}
+	if _, err := c.ListKVVersions(ctx, "secret", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ListKVVersions() of a missing secret error = %v, want ErrNotFound", err)
+	}
+	if err := c.DeleteKV(ctx, "secret", "missing"); err != nil {
+		t.Errorf("DeleteKV() of a missing secret error = %v, want nil", err)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	body := `{"errors":["1 error occurred:","permission denied"]}`
+	c := newTestClient(t, TokenAuth{Token: "s.static"}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(body))
+	})
+
+	err := c.WriteKV(context.Background(), "secret", "app", map[string]string{"KEY": "value"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("WriteKV() error = %v, want an APIError with status 500", err)
+	}
+	if !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("error = %q, want the errors of the body", err)
+	}
+
+	body = "<html>bad gateway</html>"
+	err = c.WriteKV(context.Background(), "secret", "app", nil)
+	if !errors.As(err, &apiErr) || err.Error() != "vault returned status 500" {
+		t.Errorf("WriteKV() with a non-JSON body error = %v, want the status only", err)
+	}
+}
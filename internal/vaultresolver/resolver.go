@@ -0,0 +1,206 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package vaultresolver resolves HashiCorp Vault KV v2 references in
+// environment variables. It is the read-path counterpart of the Vault
+// configstore backend, in the same way ssmresolver is for AWS SSM.
+//
+// A reference has the form vault://<mount>/<path>#<key>, for example
+// vault://secret/octo-sts#GITHUB_APP_PRIVATE_KEY. The mount is the first
+// path segment.
+package vaultresolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/cruxstack/octo-sts-distros/internal/configstore"
+	"github.com/cruxstack/octo-sts-distros/internal/vault"
+)
+
+// RefPrefix is the URI scheme used for Vault references.
+const RefPrefix = "vault://"
+
+// Client defines the interface for Vault KV v2 reads.
+type Client interface {
+	ReadKV(ctx context.Context, mount, path string) (map[string]string, error)
+}
+
+// Resolver handles Vault reference resolution.
+type Resolver struct {
+	client Client
+}
+
+// resolved remembers the references and store-backed keys that were resolved
+// into the environment, so they are fetched again on reload instead of
+// keeping the value from the first load.
+var resolved = struct {
+	sync.Mutex
+	refs      map[string]string
+	fromStore map[string]bool
+}{
+	refs:      make(map[string]string),
+	fromStore: make(map[string]bool),
+}
+
+// New creates a Resolver using the VAULT_* environment variables.
+func New() (*Resolver, error) {
+	client, err := vault.NewClientFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return &Resolver{client: client}, nil
+}
+
+// NewWithClient creates a Resolver with a custom Vault client.
+func NewWithClient(client Client) *Resolver {
+	return &Resolver{client: client}
+}
+
+// IsVaultRef checks if the given value is a Vault reference.
+func IsVaultRef(value string) bool {
+	return strings.HasPrefix(value, RefPrefix)
+}
+
+// ParseRef splits a Vault reference into its mount, path, and key.
+func ParseRef(ref string) (mount, path, key string, ok bool) {
+	if !IsVaultRef(ref) {
+		return "", "", "", false
+	}
+	rest, key, found := strings.Cut(strings.TrimPrefix(ref, RefPrefix), "#")
+	if !found || key == "" {
+		return "", "", "", false
+	}
+	mount, path, found = strings.Cut(strings.Trim(rest, "/"), "/")
+	if !found || mount == "" || path == "" {
+		return "", "", "", false
+	}
+	return mount, path, key, true
+}
+
+// ResolveValue resolves a Vault reference to its value, or returns it unchanged.
+func (r *Resolver) ResolveValue(ctx context.Context, value string) (string, error) {
+	if !IsVaultRef(value) {
+		return value, nil
+	}
+	return r.resolveRef(ctx, value, make(map[string]map[string]string))
+}
+
+// resolveRef resolves a reference, reading each secret at most once per cache.
+func (r *Resolver) resolveRef(ctx context.Context, ref string, cache map[string]map[string]string) (string, error) {
+	mount, path, key, ok := ParseRef(ref)
+	if !ok {
+		return "", fmt.Errorf("invalid vault reference (expected %s<mount>/<path>#<key>): %s", RefPrefix, ref)
+	}
+
+	cacheKey := mount + "/" + path
+	values, ok := cache[cacheKey]
+	if !ok {
+		var err error
+		values, err = r.client.ReadKV(ctx, mount, path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read vault secret %s: %w", cacheKey, err)
+		}
+		cache[cacheKey] = values
+	}
+
+	value, ok := values[key]
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no key %s", cacheKey, key)
+	}
+	return value, nil
+}
+
+// ResolveEnvironment resolves any Vault references in environment variables.
+// References resolved by a previous call are resolved again.
+func (r *Resolver) ResolveEnvironment(ctx context.Context) error {
+	refs := pendingRefs()
+	cache := make(map[string]map[string]string)
+
+	for key, ref := range refs {
+		value, err := r.resolveRef(ctx, ref, cache)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", key, err)
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// LoadStoreEnvironment copies values from the Vault configstore secret into
+// environment variables that are not set explicitly. A missing secret is not
+// an error, since the installer may not have saved credentials yet.
+func (r *Resolver) LoadStoreEnvironment(ctx context.Context, mount, path string) error {
+	values, err := r.client.ReadKV(ctx, mount, path)
+	if errors.Is(err, vault.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read vault secret %s/%s: %w", mount, path, err)
+	}
+
+	resolved.Lock()
+	defer resolved.Unlock()
+	for key, value := range values {
+		if os.Getenv(key) != "" && !resolved.fromStore[key] {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+		resolved.fromStore[key] = true
+	}
+	return nil
+}
+
+// ResolveEnvironmentWithDefaults resolves Vault references in the environment
+// and, when STORAGE_MODE is "vault", loads credentials saved by the installer.
+// It is a no-op when Vault is not in use, so it is safe to call unconditionally.
+func ResolveEnvironmentWithDefaults(ctx context.Context) error {
+	storeMode := os.Getenv(configstore.EnvStorageMode) == configstore.StorageModeVault &&
+		os.Getenv(configstore.EnvVaultKVPath) != ""
+	if !storeMode && len(pendingRefs()) == 0 {
+		return nil
+	}
+
+	resolver, err := New()
+	if err != nil {
+		return err
+	}
+	if err := resolver.ResolveEnvironment(ctx); err != nil {
+		return err
+	}
+	if storeMode {
+		mount := configstore.GetEnvDefault(configstore.EnvVaultKVMount, configstore.DefaultVaultKVMount)
+		if err := resolver.LoadStoreEnvironment(ctx, mount, os.Getenv(configstore.EnvVaultKVPath)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pendingRefs records any Vault references currently in the environment and
+// returns all references seen so far, keyed by environment variable name.
+func pendingRefs() map[string]string {
+	resolved.Lock()
+	defer resolved.Unlock()
+
+	for _, env := range os.Environ() {
+		key, value, ok := strings.Cut(env, "=")
+		if ok && IsVaultRef(value) {
+			resolved.refs[key] = value
+		}
+	}
+
+	refs := make(map[string]string, len(resolved.refs))
+	for key, ref := range resolved.refs {
+		refs[key] = ref
+	}
+	return refs
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package vaultresolver
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/cruxstack/octo-sts-distros/internal/vault"
+)
+
+// fakeClient is an in-memory Client keyed by "<mount>/<path>".
+type fakeClient struct {
+	secrets map[string]map[string]string
+	reads   int
+}
+
+func (f *fakeClient) ReadKV(_ context.Context, mount, path string) (map[string]string, error) {
+	f.reads++
+	values, ok := f.secrets[mount+"/"+path]
+	if !ok {
+		return nil, vault.ErrNotFound
+	}
+	return values, nil
+}
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		ref       string
+		wantMount string
+		wantPath  string
+		wantKey   string
+		wantOK    bool
+	}{
+		{"vault://secret/octo-sts#GITHUB_APP_ID", "secret", "octo-sts", "GITHUB_APP_ID", true},
+		{"vault://kv/teams/platform/octo-sts#key", "kv", "teams/platform/octo-sts", "key", true},
+		{"vault://secret/octo-sts", "", "", "", false},
+		{"vault://secret#key", "", "", "", false},
+		{"arn:aws:ssm:us-east-1:123456789012:parameter/foo", "", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			mount, path, key, ok := ParseRef(tt.ref)
+			if ok != tt.wantOK || mount != tt.wantMount || path != tt.wantPath || key != tt.wantKey {
+				t.Errorf("ParseRef() = (%q, %q, %q, %v), want (%q, %q, %q, %v)",
+					mount, path, key, ok, tt.wantMount, tt.wantPath, tt.wantKey, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestResolveEnvironment(t *testing.T) {
+	client := &fakeClient{secrets: map[string]map[string]string{
+		"secret/octo-sts": {"GITHUB_APP_ID": "1234", "GITHUB_CLIENT_ID": "Iv1.abc"},
+	}}
+	resolver := NewWithClient(client)
+
+	t.Setenv("TEST_VAULT_APP_ID", "vault://secret/octo-sts#GITHUB_APP_ID")
+	t.Setenv("TEST_VAULT_CLIENT_ID", "vault://secret/octo-sts#GITHUB_CLIENT_ID")
+	t.Setenv("TEST_VAULT_PLAIN", "plain-value")
+	t.Cleanup(func() {
+		resolved.Lock()
+		delete(resolved.refs, "TEST_VAULT_APP_ID")
+		delete(resolved.refs, "TEST_VAULT_CLIENT_ID")
+		resolved.Unlock()
+	})
+
+	if err := resolver.ResolveEnvironment(context.Background()); err != nil {
+		t.Fatalf("ResolveEnvironment() error = %v", err)
+	}
+	if got := os.Getenv("TEST_VAULT_APP_ID"); got != "1234" {
+		t.Errorf("expected TEST_VAULT_APP_ID=1234, got %q", got)
+	}
+	if got := os.Getenv("TEST_VAULT_CLIENT_ID"); got != "Iv1.abc" {
+		t.Errorf("expected TEST_VAULT_CLIENT_ID=Iv1.abc, got %q", got)
+	}
+	if got := os.Getenv("TEST_VAULT_PLAIN"); got != "plain-value" {
+		t.Errorf("expected plain value to be untouched, got %q", got)
+	}
+	if client.reads != 1 {
+		t.Errorf("expected secret to be read once, got %d", client.reads)
+	}
+
+	// References are resolved again on reload.
+	client.secrets["secret/octo-sts"]["GITHUB_APP_ID"] = "5678"
+	if err := resolver.ResolveEnvironment(context.Background()); err != nil {
+		t.Fatalf("ResolveEnvironment() error = %v", err)
+	}
+	if got := os.Getenv("TEST_VAULT_APP_ID"); got != "5678" {
+		t.Errorf("expected reloaded TEST_VAULT_APP_ID=5678, got %q", got)
+	}
+}
+
+func TestLoadStoreEnvironment(t *testing.T) {
+	client := &fakeClient{secrets: map[string]map[string]string{}}
+	resolver := NewWithClient(client)
+
+	t.Setenv("TEST_VAULT_STORE_SET", "explicit")
+	t.Setenv("TEST_VAULT_STORE_NEW", "")
+	t.Cleanup(func() {
+		resolved.Lock()
+		delete(resolved.fromStore, "TEST_VAULT_STORE_NEW")
+		resolved.Unlock()
+	})
+
+	// Missing secret is not an error before the installer has run.
+	if err := resolver.LoadStoreEnvironment(context.Background(), "secret", "octo-sts"); err != nil {
+		t.Fatalf("LoadStoreEnvironment() error = %v", err)
+	}
+
+	client.secrets["secret/octo-sts"] = map[string]string{
+		"TEST_VAULT_STORE_SET": "from-vault",
+		"TEST_VAULT_STORE_NEW": "v1",
+	}
+	if err := resolver.LoadStoreEnvironment(context.Background(), "secret", "octo-sts"); err != nil {
+		t.Fatalf("LoadStoreEnvironment() error = %v", err)
+	}
+	if got := os.Getenv("TEST_VAULT_STORE_SET"); got != "explicit" {
+		t.Errorf("expected explicit value to win, got %q", got)
+	}
+	if got := os.Getenv("TEST_VAULT_STORE_NEW"); got != "v1" {
+		t.Errorf("expected value from vault, got %q", got)
+	}
+
+	client.secrets["secret/octo-sts"]["TEST_VAULT_STORE_NEW"] = "v2"
+	if err := resolver.LoadStoreEnvironment(context.Background(), "secret", "octo-sts"); err != nil {
+		t.Fatalf("LoadStoreEnvironment() error = %v", err)
+	}
+	if got := os.Getenv("TEST_VAULT_STORE_NEW"); got != "v2" {
+		t.Errorf("expected store-backed value to refresh, got %q", got)
+	}
+}
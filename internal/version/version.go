@@ -0,0 +1,94 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package version reports which build of the distro is running. The values
+// are set at build time with ldflags, e.g.
+//
+//	go build -ldflags "-X github.com/cruxstack/octo-sts-distros/internal/version.Version=v1.2.3 \
+//	  -X github.com/cruxstack/octo-sts-distros/internal/version.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/cruxstack/octo-sts-distros/internal/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Values left unset fall back to the build information embedded by the Go
+// toolchain.
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+// Path is where Handler is conventionally mounted.
+const Path = "/version"
+
+// Set at build time with -ldflags "-X ...".
+var (
+	Version = ""
+	Commit  = ""
+	Date    = ""
+)
+
+// Info describes the running build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build information of the running binary. Unknown values
+// are reported as "unknown", and an unknown version as "devel".
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.Date == "":
+				info.Date = setting.Value
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "devel"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.Date == "" {
+		info.Date = "unknown"
+	}
+	return info
+}
+
+// String formats the build information for logs.
+func (i Info) String() string {
+	return fmt.Sprintf("version=%s commit=%s date=%s go=%s", i.Version, i.Commit, i.Date, i.GoVersion)
+}
+
+// Handler serves the build information as JSON.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(Get())
+	})
+}
@@ -0,0 +1,59 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	Version, Commit, Date = "v1.2.3", "abc123", "2026-01-02T03:04:05Z"
+	t.Cleanup(func() { Version, Commit, Date = "", "", "" })
+
+	info := Get()
+	if info.Version != "v1.2.3" || info.Commit != "abc123" || info.Date != "2026-01-02T03:04:05Z" {
+		t.Errorf("Get() = %+v, want the ldflags values", info)
+	}
+	if info.GoVersion == "" {
+		t.Error("Get() GoVersion is empty")
+	}
+}
+
+func TestGetDefaults(t *testing.T) {
+	info := Get()
+	// Test binaries carry no module version or VCS information
+	if info.Version != "devel" {
+		t.Errorf("Get() Version = %q, want %q", info.Version, "devel")
+	}
+	if info.Commit == "" || info.Date == "" {
+		t.Errorf("Get() = %+v, want no empty values", info)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	Version = "v1.2.3"
+	t.Cleanup(func() { Version = "" })
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var info Info
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("invalid JSON %q: %v", rec.Body.String(), err)
+	}
+	if info.Version != "v1.2.3" {
+		t.Errorf("version = %q, want %q", info.Version, "v1.2.3")
+	}
+
+	rec = httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, Path, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+// Package xraytrace records AWS X-Ray subsegments in the Lambda functions, so
+// the latency of an invocation can be attributed to its phases: resolving SSM
+// references, verifying the OIDC token, fetching the trust policy, and
+// minting the GitHub token. Subsegments are only recorded when the function
+// has active tracing enabled; otherwise Capture just calls its function.
+package xraytrace
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-xray-sdk-go/strategy/ctxmissing"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/aws/aws-xray-sdk-go/xraylog"
+)
+
+// EnvDaemonAddress is set by Lambda when active tracing is enabled.
+const EnvDaemonAddress = "AWS_XRAY_DAEMON_ADDRESS"
+
+// SegmentSSMResolve is the subsegment of resolving SSM references. The
+// phases of an exchange are named by the sts package.
+const SegmentSSMResolve = "ssm-resolve"
+
+var configure = sync.OnceFunc(func() {
+	// Configuration loaded outside an invocation, e.g. at init, has no
+	// segment to attach to; it is simply not traced
+	_ = xray.Configure(xray.Config{
+		ContextMissingStrategy: ctxmissing.NewDefaultIgnoreErrorStrategy(),
+	})
+	xray.SetLogger(xraylog.NewDefaultLogger(os.Stderr, xraylog.LogLevelWarn))
+})
+
+// Enabled reports whether active tracing is enabled for the function.
+func Enabled() bool {
+	return os.Getenv(EnvDaemonAddress) != ""
+}
+
+// Capture runs fn in an X-Ray subsegment named name when tracing is enabled,
+// recording the error it returns. It matches the Trace hook of sts.Config.
+func Capture(ctx context.Context, name string, fn func(context.Context) error) error {
+	if !Enabled() {
+		return fn(ctx)
+	}
+	configure()
+	return xray.Capture(ctx, name, fn)
+}
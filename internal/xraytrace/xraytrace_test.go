@@ -0,0 +1,44 @@
+// Copyright 2026 CruxStack
+// SPDX-License-Identifier: MIT
+
+package xraytrace
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type ctxKey struct{}
+
+func TestCaptureDisabled(t *testing.T) {
+	t.Setenv(EnvDaemonAddress, "")
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "invocation")
+	want := errors.New("failed")
+	err := Capture(ctx, "exchange", func(got context.Context) error {
+		if got.Value(ctxKey{}) != "invocation" {
+			t.Error("Capture() did not pass the context through")
+		}
+		return want
+	})
+	if !errors.Is(err, want) {
+		t.Errorf("Capture() error = %v, want %v", err, want)
+	}
+}
+
+func TestCaptureWithoutSegment(t *testing.T) {
+	// Outside an invocation there is no segment, which must not fail the call
+	t.Setenv(EnvDaemonAddress, "127.0.0.1:2000")
+
+	called := false
+	if err := Capture(context.Background(), SegmentSSMResolve, func(context.Context) error {
+		called = true
+		return nil
+	}); err != nil {
+		t.Errorf("Capture() error = %v", err)
+	}
+	if !called {
+		t.Error("Capture() did not call fn")
+	}
+}